@@ -0,0 +1,274 @@
+// Package backpressure provides credit-based flow control for pipeline
+// stages: a Window bounds the work in flight between a producer and a
+// consumer, blocking the producer once its credits run out.
+// Engine-side utilities only — must not be imported by SDK or plugins.
+package backpressure
+
+import (
+	"container/list"
+	"context"
+	stderrors "errors"
+	"fmt"
+	"sync"
+
+	"github.com/planx-lab/planx-common/errors"
+	"github.com/planx-lab/planx-common/telemetry"
+)
+
+// ErrClosed is returned by Consume on a closed window.
+var ErrClosed = stderrors.New("backpressure: window closed")
+
+// Config configures a Window.
+type Config struct {
+	// Size is the initial number of credits, e.g. the batches a processor
+	// may have in flight. Zero starts the window empty, for windows whose
+	// credits are all granted by a peer.
+	Size int `yaml:"size" json:"size" validate:"min=0"`
+
+	// Stage, if set, makes the window report its in-flight count to the
+	// planx.window.backlog gauge under this stage.
+	Stage string `yaml:"stage" json:"stage"`
+}
+
+// Window is a credit-based flow-control window. Producers Consume credits
+// before sending work; credits come back in one of two ways:
+//
+//   - Release, when the work is done and the window is local, e.g. one
+//     bounding the batches in flight to a processor;
+//   - Grant, when the credits are issued by a peer, as with a remote
+//     consumer announcing how much more it can take; the producer calls
+//     Ack as work completes so the in-flight count stays right.
+//
+// Waiting consumers are served in order, so a large request is not
+// starved by smaller ones. It is safe for concurrent use.
+type Window struct {
+	stage string
+
+	mu       sync.Mutex
+	size     int
+	credits  int // may go negative after Resize shrinks the window
+	inFlight int
+	waiters  list.List // of *waiter, in arrival order
+	closed   bool
+}
+
+type waiter struct {
+	n     int
+	ready chan struct{} // closed once the credits are taken for the waiter
+}
+
+// NewWindow returns a window holding cfg.Size credits. It panics if
+// cfg.Size is negative.
+func NewWindow(cfg Config) *Window {
+	if cfg.Size < 0 {
+		panic("backpressure: NewWindow with negative size")
+	}
+	return &Window{stage: cfg.Stage, size: cfg.Size, credits: cfg.Size}
+}
+
+// Consume takes n credits, blocking until they are available. If ctx is
+// done first it returns the context error reclassified by
+// errors.FromContext, and no credits are taken. A request larger than the
+// credits that can ever be available blocks until ctx is done.
+func (w *Window) Consume(ctx context.Context, n int) error {
+	if n <= 0 {
+		return nil
+	}
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return ErrClosed
+	}
+	if w.waiters.Len() == 0 && w.credits >= n {
+		w.take(n)
+		w.mu.Unlock()
+		return nil
+	}
+	wt := &waiter{n: n, ready: make(chan struct{})}
+	elem := w.waiters.PushBack(wt)
+	w.mu.Unlock()
+
+	select {
+	case <-wt.ready:
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		if w.closed && wt.n == 0 {
+			return ErrClosed
+		}
+		return nil
+	case <-ctx.Done():
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		select {
+		case <-wt.ready:
+			// Served while ctx ended: hand the credits back.
+			if wt.n > 0 {
+				w.give(wt.n, true)
+			}
+		default:
+			w.waiters.Remove(elem)
+			// Leaving the head may unblock smaller requests behind it.
+			w.serve()
+		}
+		return errors.FromContext(ctx, ctx.Err())
+	}
+}
+
+// TryConsume takes n credits without blocking. If they are not available
+// it returns a *errors.BackpressureError carrying the in-flight count.
+func (w *Window) TryConsume(n int) error {
+	if n <= 0 {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return ErrClosed
+	}
+	if w.waiters.Len() > 0 || w.credits < n {
+		return errors.NewBackpressureError("backpressure: window exhausted", w.inFlight, 0)
+	}
+	w.take(n)
+	return nil
+}
+
+// Release returns n consumed credits to the window once their work is
+// done. It panics if more credits are released than are in flight.
+func (w *Window) Release(n int) {
+	if n <= 0 {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.complete(n, "Release")
+	w.give(n, false)
+}
+
+// Ack marks n consumed credits' work as done without returning them, for
+// windows replenished by Grant. It panics if more credits are acked than
+// are in flight.
+func (w *Window) Ack(n int) {
+	if n <= 0 {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.complete(n, "Ack")
+}
+
+// Grant adds n credits, e.g. announced by a peer. Granted credits are not
+// bounded by the window's size.
+func (w *Window) Grant(n int) {
+	if n <= 0 {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.give(n, false)
+}
+
+// Resize changes the window's size to size, adding or removing the
+// difference in credits. After a shrink, credits may be negative until
+// enough work is released. It panics if size is negative.
+func (w *Window) Resize(size int) {
+	if size < 0 {
+		panic("backpressure: Resize with negative size")
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.credits += size - w.size
+	w.size = size
+	w.serve()
+}
+
+// Close makes pending and future Consume calls return ErrClosed. Release,
+// Ack and Grant keep working so in-flight work can finish.
+func (w *Window) Close() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return
+	}
+	w.closed = true
+	for e := w.waiters.Front(); e != nil; e = e.Next() {
+		wt := e.Value.(*waiter)
+		wt.n = 0
+		close(wt.ready)
+	}
+	w.waiters.Init()
+}
+
+// Size returns the window's size.
+func (w *Window) Size() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.size
+}
+
+// Available returns the credits available now, negative after a shrink.
+func (w *Window) Available() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.credits
+}
+
+// InFlight returns the credits consumed and not yet released or acked.
+func (w *Window) InFlight() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.inFlight
+}
+
+// Waiting returns the number of blocked Consume calls.
+func (w *Window) Waiting() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.waiters.Len()
+}
+
+// take consumes n credits. w.mu must be held.
+func (w *Window) take(n int) {
+	w.credits -= n
+	w.inFlight += n
+	w.backlog(n)
+}
+
+// complete ends n in-flight credits. w.mu must be held.
+func (w *Window) complete(n int, op string) {
+	if n > w.inFlight {
+		panic(fmt.Sprintf("backpressure: %s of %d with %d in flight", op, n, w.inFlight))
+	}
+	w.inFlight -= n
+	w.backlog(-n)
+}
+
+// give adds n credits and serves waiters; undo also reverses the take of
+// a waiter that gave up. w.mu must be held.
+func (w *Window) give(n int, undo bool) {
+	if undo {
+		w.inFlight -= n
+		w.backlog(-n)
+	}
+	w.credits += n
+	w.serve()
+}
+
+// serve hands credits to waiters in order while the head fits. w.mu must
+// be held.
+func (w *Window) serve() {
+	for e := w.waiters.Front(); e != nil; e = w.waiters.Front() {
+		wt := e.Value.(*waiter)
+		if w.credits < wt.n {
+			return
+		}
+		w.take(wt.n)
+		w.waiters.Remove(e)
+		close(wt.ready)
+	}
+}
+
+func (w *Window) backlog(delta int) {
+	if w.stage != "" {
+		telemetry.UpdateWindowBacklog(context.Background(), w.stage, int64(delta))
+	}
+}
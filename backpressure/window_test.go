@@ -0,0 +1,198 @@
+package backpressure
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+	"time"
+
+	"github.com/planx-lab/planx-common/errors"
+)
+
+func TestWindow_ConsumeRelease(t *testing.T) {
+	w := NewWindow(Config{Size: 3})
+	ctx := context.Background()
+	if err := w.Consume(ctx, 2); err != nil {
+		t.Fatalf("Consume: %v", err)
+	}
+	if got := w.Available(); got != 1 {
+		t.Fatalf("Available: got %d, want 1", got)
+	}
+	if got := w.InFlight(); got != 2 {
+		t.Fatalf("InFlight: got %d, want 2", got)
+	}
+	err := w.TryConsume(2)
+	var bp *errors.BackpressureError
+	if !stderrors.As(err, &bp) || bp.QueueDepth != 2 {
+		t.Fatalf("TryConsume on an exhausted window: got %v", err)
+	}
+	w.Release(2)
+	if w.Available() != 3 || w.InFlight() != 0 {
+		t.Fatalf("after Release: available %d, in flight %d", w.Available(), w.InFlight())
+	}
+	if err := w.TryConsume(3); err != nil {
+		t.Fatalf("TryConsume: %v", err)
+	}
+}
+
+func TestWindow_BlockingConsume(t *testing.T) {
+	w := NewWindow(Config{Size: 1})
+	ctx := context.Background()
+	_ = w.Consume(ctx, 1)
+
+	done := make(chan error, 1)
+	go func() { done <- w.Consume(ctx, 1) }()
+	select {
+	case <-done:
+		t.Fatal("Consume on an exhausted window did not block")
+	case <-time.After(10 * time.Millisecond):
+	}
+	w.Release(1)
+	if err := <-done; err != nil {
+		t.Fatalf("blocked Consume: %v", err)
+	}
+	if got := w.InFlight(); got != 1 {
+		t.Fatalf("InFlight: got %d, want 1", got)
+	}
+}
+
+func TestWindow_FIFO(t *testing.T) {
+	w := NewWindow(Config{Size: 4})
+	ctx := context.Background()
+	_ = w.Consume(ctx, 4)
+
+	big := make(chan error, 1)
+	go func() { big <- w.Consume(ctx, 3) }()
+	waitWaiting(t, w, 1)
+	small := make(chan error, 1)
+	go func() { small <- w.Consume(ctx, 1) }()
+	waitWaiting(t, w, 2)
+
+	// One credit back fits the small request, but it queued behind the big one.
+	w.Release(1)
+	select {
+	case <-small:
+		t.Fatal("small request overtook the big one")
+	case <-time.After(10 * time.Millisecond):
+	}
+	w.Release(3)
+	if err := <-big; err != nil {
+		t.Fatalf("big Consume: %v", err)
+	}
+	if err := <-small; err != nil {
+		t.Fatalf("small Consume: %v", err)
+	}
+	if got := w.Available(); got != 0 {
+		t.Fatalf("Available: got %d, want 0", got)
+	}
+}
+
+func TestWindow_ConsumeCanceled(t *testing.T) {
+	w := NewWindow(Config{Size: 2})
+	_ = w.Consume(context.Background(), 2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	big := make(chan error, 1)
+	go func() { big <- w.Consume(ctx, 2) }()
+	waitWaiting(t, w, 1)
+	small := make(chan error, 1)
+	go func() { small <- w.Consume(context.Background(), 1) }()
+	waitWaiting(t, w, 2)
+	w.Release(1)
+
+	// Giving up at the head lets the request behind it through.
+	cancel()
+	if err := <-big; errors.CodeOf(err) != errors.CodeCanceled {
+		t.Fatalf("canceled Consume: got %v", err)
+	}
+	if err := <-small; err != nil {
+		t.Fatalf("small Consume: %v", err)
+	}
+	if w.InFlight() != 2 || w.Available() != 0 {
+		t.Fatalf("in flight %d, available %d", w.InFlight(), w.Available())
+	}
+}
+
+func TestWindow_GrantAck(t *testing.T) {
+	w := NewWindow(Config{})
+	ctx := context.Background()
+	if err := w.TryConsume(1); err == nil {
+		t.Fatal("TryConsume on an empty window succeeded")
+	}
+	w.Grant(5)
+	if err := w.Consume(ctx, 5); err != nil {
+		t.Fatalf("Consume: %v", err)
+	}
+	w.Ack(5)
+	if w.InFlight() != 0 || w.Available() != 0 {
+		t.Fatalf("after Ack: in flight %d, available %d", w.InFlight(), w.Available())
+	}
+}
+
+func TestWindow_Resize(t *testing.T) {
+	w := NewWindow(Config{Size: 4})
+	ctx := context.Background()
+	_ = w.Consume(ctx, 3)
+
+	w.Resize(2)
+	if got := w.Available(); got != -1 {
+		t.Fatalf("Available after shrink: got %d, want -1", got)
+	}
+	w.Release(2)
+	if err := w.TryConsume(1); err != nil {
+		t.Fatalf("TryConsume after releases: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- w.Consume(ctx, 2) }()
+	waitWaiting(t, w, 1)
+	w.Resize(4)
+	if err := <-done; err != nil {
+		t.Fatalf("Consume after grow: %v", err)
+	}
+	if w.Size() != 4 || w.InFlight() != 4 {
+		t.Fatalf("size %d, in flight %d", w.Size(), w.InFlight())
+	}
+}
+
+func TestWindow_Close(t *testing.T) {
+	w := NewWindow(Config{Size: 1})
+	ctx := context.Background()
+	_ = w.Consume(ctx, 1)
+
+	done := make(chan error, 1)
+	go func() { done <- w.Consume(ctx, 1) }()
+	waitWaiting(t, w, 1)
+	w.Close()
+	if err := <-done; err != ErrClosed {
+		t.Fatalf("blocked Consume: got %v", err)
+	}
+	if err := w.Consume(ctx, 1); err != ErrClosed {
+		t.Fatalf("Consume after Close: got %v", err)
+	}
+	w.Release(1)
+	if got := w.InFlight(); got != 0 {
+		t.Fatalf("InFlight: got %d", got)
+	}
+}
+
+func TestWindow_OverRelease(t *testing.T) {
+	w := NewWindow(Config{Size: 1})
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Release beyond in-flight did not panic")
+		}
+	}()
+	w.Release(1)
+}
+
+func waitWaiting(t *testing.T, w *Window, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for w.Waiting() != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("waiting: got %d, want %d", w.Waiting(), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,96 @@
+// Package batch provides the engine's canonical in-memory Batch and Record
+// types, so engine components stop keeping divergent copies. The wire form
+// exchanged with the SDK and plugins is defined by planx-proto; this package
+// only mirrors it inside the engine.
+// Engine-side utilities only — must not be imported by SDK or plugins.
+package batch
+
+import (
+	"context"
+	"time"
+
+	"github.com/planx-lab/planx-common/id"
+	"github.com/planx-lab/planx-common/telemetry"
+)
+
+// Record is a single record in a batch.
+type Record struct {
+	Key       []byte            `json:"key,omitempty"`
+	Payload   []byte            `json:"payload"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	EventTime time.Time         `json:"event_time,omitzero"`
+}
+
+// Size returns the bytes r accounts for in a batch: its key, payload and
+// header keys and values.
+func (r Record) Size() int64 {
+	n := int64(len(r.Key) + len(r.Payload))
+	for k, v := range r.Headers {
+		n += int64(len(k) + len(v))
+	}
+	return n
+}
+
+// Batch is a group of records moving through a session's pipeline.
+//
+// Context carries propagation metadata such as the W3C trace context; see
+// InjectTrace. ByteSize is the sum of the records' sizes and is kept up to
+// date by New and Append; code that edits Records directly should call
+// Recount.
+type Batch struct {
+	ID        string            `json:"id"`
+	TenantID  string            `json:"tenant_id"`
+	SessionID string            `json:"session_id"`
+	Records   []Record          `json:"records"`
+	Context   map[string]string `json:"context,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+	ByteSize  int64             `json:"byte_size"`
+}
+
+// New returns a batch of records for the tenant's session with a fresh
+// id.Batch, stamped with the current time.
+func New(tenantID, sessionID string, records []Record) *Batch {
+	b := &Batch{
+		ID:        id.NewBatch().String(),
+		TenantID:  tenantID,
+		SessionID: sessionID,
+		Records:   records,
+		Context:   map[string]string{},
+		CreatedAt: time.Now(),
+	}
+	b.Recount()
+	return b
+}
+
+// Len returns the number of records in b.
+func (b *Batch) Len() int { return len(b.Records) }
+
+// Append adds records to b.
+func (b *Batch) Append(records ...Record) {
+	b.Records = append(b.Records, records...)
+	for _, r := range records {
+		b.ByteSize += r.Size()
+	}
+}
+
+// Recount recomputes ByteSize from the records.
+func (b *Batch) Recount() {
+	b.ByteSize = 0
+	for _, r := range b.Records {
+		b.ByteSize += r.Size()
+	}
+}
+
+// InjectTrace stores the trace context of ctx in b.Context.
+func (b *Batch) InjectTrace(ctx context.Context) {
+	if b.Context == nil {
+		b.Context = map[string]string{}
+	}
+	telemetry.InjectTraceContext(ctx, b.Context)
+}
+
+// TraceContext returns ctx carrying the trace context stored in b.Context,
+// so spans for b continue the trace of the stage that produced it.
+func (b *Batch) TraceContext(ctx context.Context) context.Context {
+	return telemetry.ExtractTraceContext(ctx, b.Context)
+}
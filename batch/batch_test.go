@@ -0,0 +1,64 @@
+package batch
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/planx-lab/planx-common/id"
+)
+
+func TestRecordSize(t *testing.T) {
+	r := Record{Key: []byte("k1"), Payload: []byte("hello"), Headers: map[string]string{"ct": "json"}}
+	if got := r.Size(); got != 2+5+2+4 {
+		t.Fatalf("got %d", got)
+	}
+}
+
+func TestNew(t *testing.T) {
+	b := New("acme", "s-1", []Record{{Payload: []byte("abc")}, {Payload: []byte("de")}})
+	if _, err := id.ParseBatch(b.ID); err != nil || b.CreatedAt.IsZero() || b.Context == nil {
+		t.Fatalf("got %+v", b)
+	}
+	if b.Len() != 2 || b.ByteSize != 5 {
+		t.Fatalf("len %d, size %d", b.Len(), b.ByteSize)
+	}
+	if other := New("acme", "s-1", nil); other.ID == b.ID {
+		t.Fatal("IDs not unique")
+	}
+
+	b.Append(Record{Payload: []byte("fgh")})
+	if b.Len() != 3 || b.ByteSize != 8 {
+		t.Fatalf("after Append: len %d, size %d", b.Len(), b.ByteSize)
+	}
+	b.Records = b.Records[:1]
+	b.Recount()
+	if b.ByteSize != 3 {
+		t.Fatalf("after Recount: size %d", b.ByteSize)
+	}
+}
+
+func TestTraceContext(t *testing.T) {
+	prev := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTextMapPropagator(prev) })
+
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1, 2, 3},
+		SpanID:     trace.SpanID{4, 5, 6},
+		TraceFlags: trace.FlagsSampled,
+	})
+	var b Batch
+	b.InjectTrace(trace.ContextWithSpanContext(context.Background(), sc))
+	if b.Context["traceparent"] == "" {
+		t.Fatalf("context: got %v", b.Context)
+	}
+
+	got := trace.SpanContextFromContext(b.TraceContext(context.Background()))
+	if got.TraceID() != sc.TraceID() || got.SpanID() != sc.SpanID() {
+		t.Fatalf("got %v", got)
+	}
+}
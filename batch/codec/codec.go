@@ -0,0 +1,141 @@
+// Package codec serializes batch.Batch for plugin RPCs, as protobuf or JSON.
+// Both encodings carry a schema version; decoders accept every version up
+// to SchemaVersion and fill in what older versions did not carry.
+// Engine-side utilities only — must not be imported by SDK or plugins.
+package codec
+
+import (
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/planx-lab/planx-common/batch"
+	"github.com/planx-lab/planx-common/errors"
+)
+
+// SchemaVersion is the batch schema version written by the codecs.
+//
+//	0  payloads written before the version field existed; no byte size
+//	1  adds the byte size
+const SchemaVersion = 1
+
+// Codec error codes.
+const (
+	CodeDecode             errors.Code = "PLX-CODEC-DECODE"
+	CodeUnsupportedVersion errors.Code = "PLX-CODEC-VERSION"
+)
+
+func init() {
+	errors.RegisterCode(errors.CodeInfo{Code: CodeDecode, Description: "batch payload is malformed"})
+	errors.RegisterCode(errors.CodeInfo{Code: CodeUnsupportedVersion, Description: "batch payload has a newer schema version"})
+	Register(Proto)
+	Register(JSON)
+}
+
+// Codec encodes and decodes batches.
+type Codec interface {
+	// Name is the codec's short name, e.g. "proto".
+	Name() string
+	// ContentType is the media type of encoded payloads.
+	ContentType() string
+	// Marshal returns the encoding of b.
+	Marshal(b *batch.Batch) ([]byte, error)
+	// Encode writes the encoding of b to w, sparing Marshal's copy.
+	Encode(w io.Writer, b *batch.Batch) error
+	// Unmarshal decodes a batch encoded by any supported schema version.
+	Unmarshal(data []byte) (*batch.Batch, error)
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Codec{}
+)
+
+// Register makes c available to Lookup under its name and content type.
+// It is meant to be called from init and panics if c has an empty name or
+// either key is already taken.
+func Register(c Codec) {
+	if c.Name() == "" {
+		panic("codec: Register with empty name")
+	}
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	for _, key := range []string{c.Name(), c.ContentType()} {
+		if _, dup := registry[key]; dup {
+			panic("codec: " + key + " registered twice")
+		}
+	}
+	registry[c.Name()] = c
+	registry[c.ContentType()] = c
+}
+
+// Lookup returns the codec registered under name or content type. Media
+// type parameters such as "; charset=utf-8" are ignored.
+func Lookup(key string) (Codec, bool) {
+	key, _, _ = strings.Cut(key, ";")
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	c, ok := registry[strings.TrimSpace(key)]
+	return c, ok
+}
+
+// Names returns the names of the registered codecs, sorted.
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	var names []string
+	for key, c := range registry {
+		if key == c.Name() {
+			names = append(names, key)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// checkVersion rejects payloads written by a newer schema.
+func checkVersion(v uint64) error {
+	if v > SchemaVersion {
+		return errors.NewWithCode(CodeUnsupportedVersion, "codec: unsupported batch schema version").
+			WithField("version", v).
+			WithField("supported", SchemaVersion)
+	}
+	return nil
+}
+
+// upgrade fills in what payloads of older schema versions did not carry.
+func upgrade(b *batch.Batch, version uint64) {
+	if version < 1 {
+		b.Recount()
+	}
+	if b.Context == nil {
+		b.Context = map[string]string{}
+	}
+}
+
+func decodeError(err error, message string) error {
+	if err == nil {
+		return errors.NewWithCode(CodeDecode, message)
+	}
+	return errors.Wrap(err, message).WithCode(CodeDecode)
+}
+
+// maxPooled is the largest buffer returned to the pool; bigger ones, from
+// rare huge batches, are left to the garbage collector.
+const maxPooled = 1 << 20
+
+var bufPool = sync.Pool{New: func() interface{} {
+	buf := make([]byte, 0, 4096)
+	return &buf
+}}
+
+func getBuf() *[]byte { return bufPool.Get().(*[]byte) }
+
+func putBuf(buf *[]byte) {
+	if cap(*buf) > maxPooled {
+		return
+	}
+	*buf = (*buf)[:0]
+	bufPool.Put(buf)
+}
@@ -0,0 +1,181 @@
+package codec
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/planx-lab/planx-common/batch"
+	"github.com/planx-lab/planx-common/errors"
+)
+
+func testBatch() *batch.Batch {
+	b := batch.New("acme", "s-1", []batch.Record{
+		{Key: []byte("k1"), Payload: []byte(`{"n":1}`), Headers: map[string]string{"ct": "json"}, EventTime: time.Unix(1700000000, 5).UTC()},
+		{Payload: []byte(`{"n":2}`)},
+	})
+	b.Context["traceparent"] = "00-0102-0304-01"
+	b.CreatedAt = time.Unix(1700000100, 123456789).UTC()
+	return b
+}
+
+// equalBatch compares batches, treating nil and empty maps alike.
+func equalBatch(t *testing.T, got, want *batch.Batch) {
+	t.Helper()
+	if got.ID != want.ID || got.TenantID != want.TenantID || got.SessionID != want.SessionID ||
+		!got.CreatedAt.Equal(want.CreatedAt) || got.ByteSize != want.ByteSize {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+	if !reflect.DeepEqual(got.Context, want.Context) {
+		t.Fatalf("context: got %v, want %v", got.Context, want.Context)
+	}
+	if len(got.Records) != len(want.Records) {
+		t.Fatalf("records: got %d, want %d", len(got.Records), len(want.Records))
+	}
+	for i, r := range got.Records {
+		w := want.Records[i]
+		if !bytes.Equal(r.Key, w.Key) || !bytes.Equal(r.Payload, w.Payload) ||
+			!r.EventTime.Equal(w.EventTime) || len(r.Headers) != len(w.Headers) {
+			t.Fatalf("record %d: got %+v, want %+v", i, r, w)
+		}
+		for k, v := range w.Headers {
+			if r.Headers[k] != v {
+				t.Fatalf("record %d header %q: got %q", i, k, r.Headers[k])
+			}
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, c := range []Codec{Proto, JSON} {
+		t.Run(c.Name(), func(t *testing.T) {
+			want := testBatch()
+			data, err := c.Marshal(want)
+			if err != nil {
+				t.Fatalf("Marshal: %v", err)
+			}
+			got, err := c.Unmarshal(data)
+			if err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			equalBatch(t, got, want)
+
+			var buf bytes.Buffer
+			if err := c.Encode(&buf, want); err != nil {
+				t.Fatalf("Encode: %v", err)
+			}
+			if !bytes.Equal(buf.Bytes(), data) {
+				t.Fatalf("Encode and Marshal differ:\n%s\n%s", buf.Bytes(), data)
+			}
+		})
+	}
+}
+
+func TestRoundTrip_LargeRecord(t *testing.T) {
+	want := testBatch()
+	want.Append(batch.Record{Payload: bytes.Repeat([]byte("x"), 70000)})
+	data, err := Proto.Marshal(want)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	got, err := Proto.Unmarshal(data)
+	if err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	equalBatch(t, got, want)
+}
+
+func TestLookup(t *testing.T) {
+	if c, ok := Lookup("proto"); !ok || c != Proto {
+		t.Fatalf("proto: got %v, %v", c, ok)
+	}
+	if c, ok := Lookup("application/json; charset=utf-8"); !ok || c != JSON {
+		t.Fatalf("json: got %v, %v", c, ok)
+	}
+	if _, ok := Lookup("avro"); ok {
+		t.Fatal("avro should not be registered")
+	}
+	if got := Names(); !reflect.DeepEqual(got, []string{"json", "proto"}) {
+		t.Fatalf("names: got %v", got)
+	}
+}
+
+func TestRegister_DuplicatePanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic")
+		}
+	}()
+	Register(JSON)
+}
+
+func TestCheckVersion(t *testing.T) {
+	if err := checkVersion(SchemaVersion); err != nil {
+		t.Fatalf("current version: %v", err)
+	}
+	err := checkVersion(SchemaVersion + 1)
+	if errors.CodeOf(err) != CodeUnsupportedVersion {
+		t.Fatalf("got %v", err)
+	}
+}
+
+func benchBatch(records int) *batch.Batch {
+	rs := make([]batch.Record, records)
+	for i := range rs {
+		rs[i] = batch.Record{
+			Key:       []byte(fmt.Sprintf("key-%d", i)),
+			Payload:   bytes.Repeat([]byte("p"), 256),
+			Headers:   map[string]string{"content-type": "application/json"},
+			EventTime: time.Unix(1700000000, int64(i)),
+		}
+	}
+	return batch.New("acme", "s-1", rs)
+}
+
+func BenchmarkMarshal(b *testing.B) {
+	for _, c := range []Codec{Proto, JSON} {
+		bt := benchBatch(500)
+		b.Run(c.Name(), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := c.Marshal(bt); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkEncode shows the pooled path: no allocation for the encoding
+// once the pool is warm.
+func BenchmarkEncode(b *testing.B) {
+	for _, c := range []Codec{Proto, JSON} {
+		bt := benchBatch(500)
+		var w bytes.Buffer
+		b.Run(c.Name(), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				w.Reset()
+				if err := c.Encode(&w, bt); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkUnmarshal(b *testing.B) {
+	for _, c := range []Codec{Proto, JSON} {
+		data, _ := c.Marshal(benchBatch(500))
+		b.Run(c.Name(), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := c.Unmarshal(data); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"sync"
+
+	"github.com/planx-lab/planx-common/batch"
+)
+
+// JSON is the JSON codec, for debugging and HTTP plugins. Keys and payloads
+// are base64 encoded; unknown members are ignored.
+var JSON Codec = jsonCodec{}
+
+type jsonCodec struct{}
+
+// jsonBatch adds the schema version to the batch's own members.
+type jsonBatch struct {
+	SchemaVersion uint64 `json:"schema_version"`
+	*batch.Batch
+}
+
+var jsonBufPool = sync.Pool{New: func() interface{} { return new(bytes.Buffer) }}
+
+func (jsonCodec) Name() string        { return "json" }
+func (jsonCodec) ContentType() string { return "application/json" }
+
+func (c jsonCodec) Marshal(b *batch.Batch) ([]byte, error) {
+	buf := jsonBufPool.Get().(*bytes.Buffer)
+	defer putJSONBuf(buf)
+	if err := c.encode(buf, b); err != nil {
+		return nil, err
+	}
+	return append([]byte(nil), buf.Bytes()...), nil
+}
+
+func (c jsonCodec) Encode(w io.Writer, b *batch.Batch) error {
+	buf := jsonBufPool.Get().(*bytes.Buffer)
+	defer putJSONBuf(buf)
+	if err := c.encode(buf, b); err != nil {
+		return err
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func (jsonCodec) encode(buf *bytes.Buffer, b *batch.Batch) error {
+	if err := json.NewEncoder(buf).Encode(jsonBatch{SchemaVersion: SchemaVersion, Batch: b}); err != nil {
+		return err
+	}
+	buf.Truncate(buf.Len() - 1) // drop Encode's trailing newline
+	return nil
+}
+
+func (jsonCodec) Unmarshal(data []byte) (*batch.Batch, error) {
+	in := jsonBatch{Batch: &batch.Batch{}}
+	if err := json.Unmarshal(data, &in); err != nil {
+		return nil, decodeError(err, "codec: malformed JSON batch")
+	}
+	if err := checkVersion(in.SchemaVersion); err != nil {
+		return nil, err
+	}
+	upgrade(in.Batch, in.SchemaVersion)
+	return in.Batch, nil
+}
+
+func putJSONBuf(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooled {
+		return
+	}
+	buf.Reset()
+	jsonBufPool.Put(buf)
+}
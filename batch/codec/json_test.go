@@ -0,0 +1,38 @@
+package codec
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/planx-lab/planx-common/errors"
+)
+
+func TestJSON_SchemaVersion(t *testing.T) {
+	data, _ := JSON.Marshal(testBatch())
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if m["schema_version"] != float64(SchemaVersion) || m["tenant_id"] != "acme" {
+		t.Fatalf("got %s", data)
+	}
+}
+
+func TestJSON_LegacyPayload(t *testing.T) {
+	b, err := JSON.Unmarshal([]byte(`{"id":"b-1","records":[{"payload":"aGVsbG8="}],"extra":true}`))
+	if err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if b.ID != "b-1" || string(b.Records[0].Payload) != "hello" || b.ByteSize != 5 || b.Context == nil {
+		t.Fatalf("got %+v", b)
+	}
+}
+
+func TestJSON_Errors(t *testing.T) {
+	if _, err := JSON.Unmarshal([]byte(`{"schema_version":99}`)); errors.CodeOf(err) != CodeUnsupportedVersion {
+		t.Fatalf("newer version: got %v", err)
+	}
+	if _, err := JSON.Unmarshal([]byte(`{"id":`)); errors.CodeOf(err) != CodeDecode {
+		t.Fatalf("malformed: got %v", err)
+	}
+}
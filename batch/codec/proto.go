@@ -0,0 +1,285 @@
+package codec
+
+import (
+	"io"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/planx-lab/planx-common/batch"
+)
+
+// Proto is the protobuf codec. Its field numbers mirror the Batch and
+// Record messages of planx-proto:
+//
+//	message Batch {
+//	  string id = 1;
+//	  string tenant_id = 2;
+//	  string session_id = 3;
+//	  repeated Record records = 4;
+//	  map<string, string> context = 5;
+//	  google.protobuf.Timestamp created_at = 6;
+//	  int64 byte_size = 7;
+//	  uint32 schema_version = 15;
+//	}
+//
+//	message Record {
+//	  bytes key = 1;
+//	  bytes payload = 2;
+//	  map<string, string> headers = 3;
+//	  google.protobuf.Timestamp event_time = 4;
+//	}
+//
+// Unknown fields are skipped, so payloads from newer minor revisions decode.
+var Proto Codec = protoCodec{}
+
+type protoCodec struct{}
+
+func (protoCodec) Name() string        { return "proto" }
+func (protoCodec) ContentType() string { return "application/x-protobuf" }
+
+func (protoCodec) Marshal(b *batch.Batch) ([]byte, error) {
+	buf := getBuf()
+	defer putBuf(buf)
+	*buf = appendBatch(*buf, b)
+	return append([]byte(nil), *buf...), nil
+}
+
+func (protoCodec) Encode(w io.Writer, b *batch.Batch) error {
+	buf := getBuf()
+	defer putBuf(buf)
+	*buf = appendBatch(*buf, b)
+	_, err := w.Write(*buf)
+	return err
+}
+
+func (protoCodec) Unmarshal(data []byte) (*batch.Batch, error) {
+	b := &batch.Batch{}
+	var version uint64
+	err := consumeFields(data, func(num protowire.Number, typ protowire.Type, v []byte) (int, error) {
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			return consumeString(v, &b.ID)
+		case num == 2 && typ == protowire.BytesType:
+			return consumeString(v, &b.TenantID)
+		case num == 3 && typ == protowire.BytesType:
+			return consumeString(v, &b.SessionID)
+		case num == 4 && typ == protowire.BytesType:
+			msg, n := protowire.ConsumeBytes(v)
+			if n < 0 {
+				return n, nil
+			}
+			r, err := decodeRecord(msg)
+			if err != nil {
+				return 0, err
+			}
+			b.Records = append(b.Records, r)
+			return n, nil
+		case num == 5 && typ == protowire.BytesType:
+			if b.Context == nil {
+				b.Context = map[string]string{}
+			}
+			return consumeMapEntry(v, b.Context)
+		case num == 6 && typ == protowire.BytesType:
+			return consumeTimestamp(v, &b.CreatedAt)
+		case num == 7 && typ == protowire.VarintType:
+			x, n := protowire.ConsumeVarint(v)
+			b.ByteSize = int64(x)
+			return n, nil
+		case num == 15 && typ == protowire.VarintType:
+			x, n := protowire.ConsumeVarint(v)
+			version = x
+			return n, nil
+		}
+		return protowire.ConsumeFieldValue(num, typ, v), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVersion(version); err != nil {
+		return nil, err
+	}
+	upgrade(b, version)
+	return b, nil
+}
+
+func appendBatch(buf []byte, b *batch.Batch) []byte {
+	buf = appendString(buf, 1, b.ID)
+	buf = appendString(buf, 2, b.TenantID)
+	buf = appendString(buf, 3, b.SessionID)
+	for i := range b.Records {
+		buf = protowire.AppendTag(buf, 4, protowire.BytesType)
+		buf = appendMessage(buf, func(buf []byte) []byte { return appendRecord(buf, &b.Records[i]) })
+	}
+	buf = appendMap(buf, 5, b.Context)
+	buf = appendTimestamp(buf, 6, b.CreatedAt)
+	if b.ByteSize != 0 {
+		buf = protowire.AppendTag(buf, 7, protowire.VarintType)
+		buf = protowire.AppendVarint(buf, uint64(b.ByteSize))
+	}
+	buf = protowire.AppendTag(buf, 15, protowire.VarintType)
+	return protowire.AppendVarint(buf, SchemaVersion)
+}
+
+func appendRecord(buf []byte, r *batch.Record) []byte {
+	if len(r.Key) > 0 {
+		buf = protowire.AppendTag(buf, 1, protowire.BytesType)
+		buf = protowire.AppendBytes(buf, r.Key)
+	}
+	if len(r.Payload) > 0 {
+		buf = protowire.AppendTag(buf, 2, protowire.BytesType)
+		buf = protowire.AppendBytes(buf, r.Payload)
+	}
+	buf = appendMap(buf, 3, r.Headers)
+	return appendTimestamp(buf, 4, r.EventTime)
+}
+
+func decodeRecord(data []byte) (batch.Record, error) {
+	var r batch.Record
+	err := consumeFields(data, func(num protowire.Number, typ protowire.Type, v []byte) (int, error) {
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			return consumeBytes(v, &r.Key)
+		case num == 2 && typ == protowire.BytesType:
+			return consumeBytes(v, &r.Payload)
+		case num == 3 && typ == protowire.BytesType:
+			if r.Headers == nil {
+				r.Headers = map[string]string{}
+			}
+			return consumeMapEntry(v, r.Headers)
+		case num == 4 && typ == protowire.BytesType:
+			return consumeTimestamp(v, &r.EventTime)
+		}
+		return protowire.ConsumeFieldValue(num, typ, v), nil
+	})
+	return r, err
+}
+
+// appendMessage appends a length-prefixed message written by fn. The
+// length is reserved as one byte and the message shifted if it needs more.
+func appendMessage(buf []byte, fn func([]byte) []byte) []byte {
+	start := len(buf)
+	buf = append(buf, 0)
+	buf = fn(buf)
+	size := len(buf) - start - 1
+	if n := protowire.SizeVarint(uint64(size)); n > 1 {
+		buf = append(buf, make([]byte, n-1)...)
+		copy(buf[start+n:], buf[start+1:start+1+size])
+	}
+	protowire.AppendVarint(buf[:start], uint64(size))
+	return buf
+}
+
+func appendString(buf []byte, num protowire.Number, s string) []byte {
+	if s == "" {
+		return buf
+	}
+	buf = protowire.AppendTag(buf, num, protowire.BytesType)
+	return protowire.AppendString(buf, s)
+}
+
+func appendMap(buf []byte, num protowire.Number, m map[string]string) []byte {
+	for k, v := range m {
+		buf = protowire.AppendTag(buf, num, protowire.BytesType)
+		buf = appendMessage(buf, func(buf []byte) []byte {
+			buf = appendString(buf, 1, k)
+			return appendString(buf, 2, v)
+		})
+	}
+	return buf
+}
+
+// appendTimestamp appends t as a google.protobuf.Timestamp.
+func appendTimestamp(buf []byte, num protowire.Number, t time.Time) []byte {
+	if t.IsZero() {
+		return buf
+	}
+	buf = protowire.AppendTag(buf, num, protowire.BytesType)
+	return appendMessage(buf, func(buf []byte) []byte {
+		if secs := t.Unix(); secs != 0 {
+			buf = protowire.AppendTag(buf, 1, protowire.VarintType)
+			buf = protowire.AppendVarint(buf, uint64(secs))
+		}
+		if nanos := t.Nanosecond(); nanos != 0 {
+			buf = protowire.AppendTag(buf, 2, protowire.VarintType)
+			buf = protowire.AppendVarint(buf, uint64(nanos))
+		}
+		return buf
+	})
+}
+
+// consumeFields calls fn for each field in data. fn returns the length of
+// the field's value, or a negative protowire error code.
+func consumeFields(data []byte, fn func(protowire.Number, protowire.Type, []byte) (int, error)) error {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return decodeError(protowire.ParseError(n), "codec: malformed protobuf batch")
+		}
+		data = data[n:]
+		m, err := fn(num, typ, data)
+		if err != nil {
+			return err
+		}
+		if m < 0 {
+			return decodeError(protowire.ParseError(m), "codec: malformed protobuf batch")
+		}
+		data = data[m:]
+	}
+	return nil
+}
+
+func consumeString(data []byte, s *string) (int, error) {
+	v, n := protowire.ConsumeString(data)
+	*s = v
+	return n, nil
+}
+
+func consumeBytes(data []byte, b *[]byte) (int, error) {
+	v, n := protowire.ConsumeBytes(data)
+	if n >= 0 {
+		*b = append([]byte(nil), v...)
+	}
+	return n, nil
+}
+
+func consumeMapEntry(data []byte, m map[string]string) (int, error) {
+	msg, n := protowire.ConsumeBytes(data)
+	if n < 0 {
+		return n, nil
+	}
+	var k, v string
+	err := consumeFields(msg, func(num protowire.Number, typ protowire.Type, data []byte) (int, error) {
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			return consumeString(data, &k)
+		case num == 2 && typ == protowire.BytesType:
+			return consumeString(data, &v)
+		}
+		return protowire.ConsumeFieldValue(num, typ, data), nil
+	})
+	m[k] = v
+	return n, err
+}
+
+func consumeTimestamp(data []byte, t *time.Time) (int, error) {
+	msg, n := protowire.ConsumeBytes(data)
+	if n < 0 {
+		return n, nil
+	}
+	var secs, nanos uint64
+	err := consumeFields(msg, func(num protowire.Number, typ protowire.Type, data []byte) (int, error) {
+		var n int
+		switch {
+		case num == 1 && typ == protowire.VarintType:
+			secs, n = protowire.ConsumeVarint(data)
+		case num == 2 && typ == protowire.VarintType:
+			nanos, n = protowire.ConsumeVarint(data)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, data)
+		}
+		return n, nil
+	})
+	*t = time.Unix(int64(secs), int64(nanos)).UTC()
+	return n, err
+}
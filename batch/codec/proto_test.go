@@ -0,0 +1,53 @@
+package codec
+
+import (
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/planx-lab/planx-common/errors"
+)
+
+func TestProto_LegacyPayload(t *testing.T) {
+	// A version 0 payload: no schema version and no byte size.
+	var data []byte
+	data = protowire.AppendTag(data, 1, protowire.BytesType)
+	data = protowire.AppendString(data, "b-1")
+	data = protowire.AppendTag(data, 4, protowire.BytesType)
+	data = protowire.AppendBytes(data, protowire.AppendBytes(protowire.AppendTag(nil, 2, protowire.BytesType), []byte("hello")))
+
+	b, err := Proto.Unmarshal(data)
+	if err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if b.ID != "b-1" || b.Len() != 1 || string(b.Records[0].Payload) != "hello" {
+		t.Fatalf("got %+v", b)
+	}
+	if b.ByteSize != 5 || b.Context == nil {
+		t.Fatalf("not upgraded: size %d, context %v", b.ByteSize, b.Context)
+	}
+}
+
+func TestProto_SkipsUnknownFields(t *testing.T) {
+	data, _ := Proto.Marshal(testBatch())
+	data = protowire.AppendTag(data, 99, protowire.BytesType)
+	data = protowire.AppendString(data, "from a newer revision")
+	if _, err := Proto.Unmarshal(data); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+}
+
+func TestProto_NewerVersion(t *testing.T) {
+	data := protowire.AppendTag(nil, 15, protowire.VarintType)
+	data = protowire.AppendVarint(data, SchemaVersion+1)
+	if _, err := Proto.Unmarshal(data); errors.CodeOf(err) != CodeUnsupportedVersion {
+		t.Fatalf("got %v", err)
+	}
+}
+
+func TestProto_Malformed(t *testing.T) {
+	data, _ := Proto.Marshal(testBatch())
+	if _, err := Proto.Unmarshal(data[:len(data)/2]); errors.CodeOf(err) != CodeDecode {
+		t.Fatalf("got %v", err)
+	}
+}
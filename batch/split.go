@@ -0,0 +1,113 @@
+package batch
+
+import (
+	"crypto/sha256"
+	"maps"
+	"strconv"
+
+	"github.com/planx-lab/planx-common/id"
+)
+
+// Limits bound the size of a batch. Zero fields mean no limit.
+type Limits struct {
+	MaxBytes   int64 `yaml:"max_bytes" json:"max_bytes" validate:"min=0"`
+	MaxRecords int   `yaml:"max_records" json:"max_records" validate:"min=0"`
+}
+
+// fits reports whether a batch of n records and size bytes is within l.
+func (l Limits) fits(n int, size int64) bool {
+	return (l.MaxRecords <= 0 || n <= l.MaxRecords) && (l.MaxBytes <= 0 || size <= l.MaxBytes)
+}
+
+// SplitBySize splits b into batches of at most maxBytes and maxRecords each,
+// for sinks with payload limits such as 5MB HTTP bodies. Zero means no
+// limit. A record larger than maxBytes on its own gets a batch to itself.
+//
+// Records keep their order. Each child copies b's tenant, session, context
+// and creation time, and is given the ID "<b.ID>/<index>", so retrying the
+// same split yields the same IDs. b itself is returned if it already fits.
+func SplitBySize(b *Batch, maxBytes int64, maxRecords int) []*Batch {
+	limits := Limits{MaxBytes: maxBytes, MaxRecords: maxRecords}
+	if limits.fits(b.Len(), b.ByteSize) {
+		return []*Batch{b}
+	}
+
+	var children []*Batch
+	start, size := 0, int64(0)
+	for i, r := range b.Records {
+		rs := r.Size()
+		if i > start && !limits.fits(i-start+1, size+rs) {
+			children = append(children, b.child(len(children), start, i, size))
+			start, size = i, 0
+		}
+		size += rs
+	}
+	return append(children, b.child(len(children), start, b.Len(), size))
+}
+
+// child returns the index'th child of b, holding records [from, to).
+func (b *Batch) child(index, from, to int, size int64) *Batch {
+	return &Batch{
+		ID:        b.ID + "/" + strconv.Itoa(index),
+		TenantID:  b.TenantID,
+		SessionID: b.SessionID,
+		Records:   b.Records[from:to:to],
+		Context:   maps.Clone(b.Context),
+		CreatedAt: b.CreatedAt,
+		ByteSize:  size,
+	}
+}
+
+// Coalesce merges runs of consecutive small batches into batches within
+// limits, cutting the number of sink calls. Only batches of the same tenant
+// and session are merged, and batch and record order is kept. A merged
+// batch takes the context and creation time of its first batch and a ULID
+// derived from the IDs it merged, so coalescing the same input again yields
+// the same IDs. Batches that do not need merging are returned as they are;
+// a batch already over the limits is passed through, to be split with
+// SplitBySize if need be.
+func Coalesce(batches []*Batch, limits Limits) []*Batch {
+	var out []*Batch
+	for start := 0; start < len(batches); {
+		first := batches[start]
+		end, n, size := start+1, first.Len(), first.ByteSize
+		for ; end < len(batches); end++ {
+			next := batches[end]
+			if next.TenantID != first.TenantID || next.SessionID != first.SessionID ||
+				!limits.fits(n+next.Len(), size+next.ByteSize) {
+				break
+			}
+			n += next.Len()
+			size += next.ByteSize
+		}
+		out = append(out, merge(batches[start:end], n, size))
+		start = end
+	}
+	return out
+}
+
+// merge joins run, whose batches hold n records of size bytes in total.
+func merge(run []*Batch, n int, size int64) *Batch {
+	if len(run) == 1 {
+		return run[0]
+	}
+	first := run[0]
+	merged := &Batch{
+		TenantID:  first.TenantID,
+		SessionID: first.SessionID,
+		Records:   make([]Record, 0, n),
+		Context:   maps.Clone(first.Context),
+		CreatedAt: first.CreatedAt,
+		ByteSize:  size,
+	}
+	h := sha256.New()
+	for _, b := range run {
+		merged.Records = append(merged.Records, b.Records...)
+		h.Write([]byte(b.ID))
+		h.Write([]byte{0})
+	}
+	var entropy [10]byte
+	copy(entropy[:], h.Sum(nil))
+	merged.ID = id.ULIDAt(first.CreatedAt, entropy).String()
+	return merged
+}
@@ -0,0 +1,114 @@
+package batch
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/planx-lab/planx-common/id"
+)
+
+// records returns records with payloads of the given sizes, each payload
+// starting with its index.
+func records(sizes ...int) []Record {
+	rs := make([]Record, len(sizes))
+	for i, n := range sizes {
+		rs[i] = Record{Payload: []byte(string(rune('a'+i)) + strings.Repeat("x", n-1))}
+	}
+	return rs
+}
+
+// order returns the first payload byte of every record in bs.
+func order(bs []*Batch) string {
+	var sb strings.Builder
+	for _, b := range bs {
+		for _, r := range b.Records {
+			sb.WriteByte(r.Payload[0])
+		}
+	}
+	return sb.String()
+}
+
+func TestSplitBySize(t *testing.T) {
+	b := New("acme", "s-1", records(4, 4, 4, 10, 1, 1))
+	b.Context["traceparent"] = "tp"
+
+	children := SplitBySize(b, 8, 0)
+	if len(children) != 4 || order(children) != "abcdef" {
+		t.Fatalf("got %d children, order %q", len(children), order(children))
+	}
+	for i, c := range children {
+		if c.ID != b.ID+"/"+string(rune('0'+i)) || c.TenantID != "acme" || c.Context["traceparent"] != "tp" {
+			t.Fatalf("child %d: got %+v", i, c)
+		}
+	}
+	// The oversized record is alone.
+	if children[2].Len() != 1 || children[2].ByteSize != 10 || children[3].ByteSize != 2 {
+		t.Fatalf("got sizes %d, %d", children[2].ByteSize, children[3].ByteSize)
+	}
+
+	children[0].Context["traceparent"] = "changed"
+	if b.Context["traceparent"] != "tp" {
+		t.Fatal("child context shares the parent's map")
+	}
+	children[0].Records = append(children[0].Records, Record{})
+	if b.Records[2].Payload[0] != 'c' {
+		t.Fatal("appending to a child overwrote the parent's records")
+	}
+
+	again := SplitBySize(b, 8, 0)
+	for i := range again {
+		if again[i].ID != children[i].ID {
+			t.Fatal("child IDs are not deterministic")
+		}
+	}
+}
+
+func TestSplitBySize_MaxRecords(t *testing.T) {
+	b := New("acme", "s-1", records(1, 1, 1, 1, 1))
+	children := SplitBySize(b, 0, 2)
+	if len(children) != 3 || children[2].Len() != 1 || order(children) != "abcde" {
+		t.Fatalf("got %d children", len(children))
+	}
+	if got := SplitBySize(b, 100, 10); len(got) != 1 || got[0] != b {
+		t.Fatal("a batch within the limits should be returned as is")
+	}
+}
+
+func TestCoalesce(t *testing.T) {
+	created := time.Unix(1700000000, 0)
+	mk := func(tenant string, sizes ...int) *Batch {
+		b := New(tenant, "s-1", records(sizes...))
+		b.CreatedAt = created
+		return b
+	}
+	in := []*Batch{mk("acme", 2, 2), mk("acme", 3), mk("acme", 5), mk("other", 1), mk("other", 20)}
+	in[0].Context["traceparent"] = "first"
+
+	out := Coalesce(in, Limits{MaxBytes: 8})
+	if len(out) != 4 {
+		t.Fatalf("got %d batches", len(out))
+	}
+	if out[0].Len() != 3 || out[0].ByteSize != 7 || out[0].Context["traceparent"] != "first" || !out[0].CreatedAt.Equal(created) {
+		t.Fatalf("merged: got %+v", out[0])
+	}
+	if out[1] != in[2] || out[2] != in[3] || out[3] != in[4] {
+		t.Fatal("unmerged batches should pass through")
+	}
+
+	again := Coalesce(in, Limits{MaxBytes: 8})
+	if _, err := id.ParseBatch(out[0].ID); err != nil || again[0].ID != out[0].ID {
+		t.Fatalf("merged ID not deterministic: %q, %q", again[0].ID, out[0].ID)
+	}
+}
+
+func TestCoalesce_MaxRecords(t *testing.T) {
+	var in []*Batch
+	for i := 0; i < 5; i++ {
+		in = append(in, New("acme", "s-1", records(1)))
+	}
+	out := Coalesce(in, Limits{MaxRecords: 2})
+	if len(out) != 3 || out[0].Len() != 2 || out[2].Len() != 1 {
+		t.Fatalf("got %d batches", len(out))
+	}
+}
@@ -0,0 +1,322 @@
+// Package circuitbreaker provides circuit breakers that stop calls to a
+// failing downstream, such as a sink endpoint, until it has had time to
+// recover.
+// Engine-side utilities only — must not be imported by SDK or plugins.
+package circuitbreaker
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/planx-lab/planx-common/clock"
+	"github.com/planx-lab/planx-common/config"
+	"github.com/planx-lab/planx-common/errors"
+	"github.com/planx-lab/planx-common/logger"
+	"github.com/planx-lab/planx-common/telemetry"
+)
+
+// CodeOpen is the code of the error returned while a breaker is open.
+const CodeOpen errors.Code = "PLX-CIRCUIT-OPEN"
+
+func init() {
+	errors.RegisterCode(errors.CodeInfo{Code: CodeOpen, Description: "rejected by an open circuit breaker"})
+}
+
+// State is the state of a breaker. The values are those reported by the
+// planx.circuitbreaker.state gauge.
+type State int
+
+// Breaker states.
+const (
+	StateClosed   State = iota // calls pass, outcomes are counted
+	StateHalfOpen              // a few probe calls pass to test recovery
+	StateOpen                  // calls are rejected
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateHalfOpen:
+		return "half-open"
+	case StateOpen:
+		return "open"
+	}
+	return fmt.Sprintf("State(%d)", int(s))
+}
+
+// Defaults for zero Config fields.
+const (
+	DefaultConsecutiveFailures = 5
+	DefaultMinRequests         = 10
+	DefaultWindow              = 10 * time.Second
+	DefaultOpenTimeout         = 30 * time.Second
+	DefaultHalfOpenProbes      = 1
+)
+
+// windowBuckets is the resolution of the failure-rate window.
+const windowBuckets = 10
+
+// Config configures a Breaker. It trips when either condition holds:
+// ConsecutiveFailures failures in a row, or a failure ratio of at least
+// FailureRate over Window once MinRequests calls were made in it. A zero
+// FailureRate disables the ratio; if both conditions are unset,
+// ConsecutiveFailures defaults to DefaultConsecutiveFailures.
+//
+// After OpenTimeout the breaker lets HalfOpenProbes calls through: if they
+// all succeed it closes, and the first failure opens it again.
+type Config struct {
+	ConsecutiveFailures int             `yaml:"consecutive_failures" json:"consecutive_failures" validate:"omitempty,min=1"`
+	FailureRate         float64         `yaml:"failure_rate" json:"failure_rate" validate:"omitempty,min=0,max=1"`
+	MinRequests         int             `yaml:"min_requests" json:"min_requests" validate:"omitempty,min=1"`
+	Window              config.Duration `yaml:"window" json:"window"`
+	OpenTimeout         config.Duration `yaml:"open_timeout" json:"open_timeout"`
+	HalfOpenProbes      int             `yaml:"half_open_probes" json:"half_open_probes" validate:"omitempty,min=1"`
+
+	// IsFailure decides which errors count against the downstream. The
+	// default counts every error except context.Canceled, which is the
+	// caller giving up.
+	IsFailure func(err error) bool `yaml:"-" json:"-"`
+
+	// OnStateChange, if set, is called after every transition, outside the
+	// breaker's lock.
+	OnStateChange func(name string, from, to State) `yaml:"-" json:"-"`
+}
+
+// Breaker is a circuit breaker. It is safe for concurrent use.
+type Breaker struct {
+	name  string
+	cfg   Config
+	clock clock.Clock
+
+	mu          sync.Mutex
+	state       State
+	generation  uint64 // bumped on every transition, so stale outcomes are ignored
+	openedAt    time.Time
+	consecutive int
+	probes      int // half-open calls in flight
+	successes   int // half-open successes
+	buckets     [windowBuckets]bucket
+}
+
+type bucket struct {
+	start             time.Time
+	success, failures int
+}
+
+// Option configures a Breaker.
+type Option func(*Breaker)
+
+// WithClock sets the clock the window and open timeout are measured on. The
+// default is clock.Real; tests pass a clock.Fake.
+func WithClock(c clock.Clock) Option {
+	return func(b *Breaker) { b.clock = c }
+}
+
+// New returns a closed breaker. name identifies it in logs, metrics and
+// errors, e.g. the sink endpoint it guards.
+func New(name string, cfg Config, opts ...Option) *Breaker {
+	if cfg.ConsecutiveFailures <= 0 && cfg.FailureRate <= 0 {
+		cfg.ConsecutiveFailures = DefaultConsecutiveFailures
+	}
+	if cfg.MinRequests <= 0 {
+		cfg.MinRequests = DefaultMinRequests
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = config.Duration(DefaultWindow)
+	}
+	// Each bucket must be at least a nanosecond wide.
+	cfg.Window = max(cfg.Window, windowBuckets)
+	if cfg.OpenTimeout <= 0 {
+		cfg.OpenTimeout = config.Duration(DefaultOpenTimeout)
+	}
+	if cfg.HalfOpenProbes <= 0 {
+		cfg.HalfOpenProbes = DefaultHalfOpenProbes
+	}
+	if cfg.IsFailure == nil {
+		cfg.IsFailure = func(err error) bool {
+			return err != nil && !stderrors.Is(err, context.Canceled)
+		}
+	}
+	b := &Breaker{name: name, cfg: cfg, clock: clock.Real}
+	for _, opt := range opts {
+		opt(b)
+	}
+	telemetry.RecordCircuitState(context.Background(), name, int64(StateClosed))
+	return b
+}
+
+// Name returns the breaker's name.
+func (b *Breaker) Name() string { return b.name }
+
+// State returns the current state. An open breaker whose timeout has passed
+// reports half-open.
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	from := b.state
+	b.advance()
+	to := b.state
+	b.mu.Unlock()
+	if to != from {
+		b.notify(from, to)
+	}
+	return to
+}
+
+// Do runs fn if the breaker allows it and records the outcome. While the
+// breaker is open it returns a non-retryable *errors.TransportError coded
+// CodeOpen without calling fn; failing fast is the point of an open circuit.
+func (b *Breaker) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	done, err := b.Allow()
+	if err != nil {
+		return err
+	}
+	err = fn(ctx)
+	done(err)
+	return err
+}
+
+// Allow reports whether a call may proceed, for callers that cannot wrap the
+// call in Do. If err is nil the caller must make the call and pass its
+// outcome to done exactly once.
+func (b *Breaker) Allow() (done func(err error), err error) {
+	b.mu.Lock()
+	from := b.state
+	b.advance()
+	state, gen := b.state, b.generation
+	var rejected error
+	switch state {
+	case StateOpen:
+		retryIn := b.openedAt.Add(b.cfg.OpenTimeout.Std()).Sub(b.clock.Now())
+		rejected = errors.NewTransportErrorf(false, "circuitbreaker: %s is open", b.name).
+			WithCode(CodeOpen).
+			WithField("breaker", b.name).
+			WithField("retry_in", retryIn.String())
+	case StateHalfOpen:
+		if b.probes >= b.cfg.HalfOpenProbes {
+			rejected = errors.NewTransportErrorf(false, "circuitbreaker: %s is half-open and probing", b.name).
+				WithCode(CodeOpen).
+				WithField("breaker", b.name)
+		} else {
+			b.probes++
+		}
+	}
+	b.mu.Unlock()
+	if state != from {
+		b.notify(from, state)
+	}
+	if rejected != nil {
+		return nil, rejected
+	}
+
+	var once sync.Once
+	return func(err error) {
+		once.Do(func() { b.record(gen, err) })
+	}, nil
+}
+
+// record applies the outcome of a call allowed in generation gen.
+func (b *Breaker) record(gen uint64, err error) {
+	failed := b.cfg.IsFailure(err)
+
+	b.mu.Lock()
+	if gen != b.generation {
+		b.mu.Unlock()
+		return
+	}
+	from := b.state
+	switch b.state {
+	case StateClosed:
+		bk := b.bucket()
+		if failed {
+			bk.failures++
+			b.consecutive++
+		} else {
+			bk.success++
+			b.consecutive = 0
+		}
+		if failed && b.shouldTrip() {
+			b.transition(StateOpen)
+		}
+	case StateHalfOpen:
+		b.probes--
+		if failed {
+			b.transition(StateOpen)
+		} else if b.successes++; b.successes >= b.cfg.HalfOpenProbes {
+			b.transition(StateClosed)
+		}
+	}
+	to := b.state
+	b.mu.Unlock()
+	if to != from {
+		b.notify(from, to)
+	}
+}
+
+func (b *Breaker) shouldTrip() bool {
+	if b.cfg.ConsecutiveFailures > 0 && b.consecutive >= b.cfg.ConsecutiveFailures {
+		return true
+	}
+	if b.cfg.FailureRate <= 0 {
+		return false
+	}
+	var total, failures int
+	horizon := b.clock.Now().Add(-b.cfg.Window.Std())
+	for _, bk := range b.buckets {
+		if bk.start.After(horizon) {
+			total += bk.success + bk.failures
+			failures += bk.failures
+		}
+	}
+	return total >= b.cfg.MinRequests && float64(failures)/float64(total) >= b.cfg.FailureRate
+}
+
+// bucket returns the window bucket for now, recycling an expired one.
+func (b *Breaker) bucket() *bucket {
+	width := b.cfg.Window.Std() / windowBuckets
+	now := b.clock.Now()
+	start := now.Truncate(width)
+	bk := &b.buckets[int(start.UnixNano()/int64(width))%windowBuckets]
+	if !bk.start.Equal(start) {
+		*bk = bucket{start: start}
+	}
+	return bk
+}
+
+// advance moves an open breaker to half-open once its timeout has passed.
+// b.mu must be held.
+func (b *Breaker) advance() {
+	if b.state == StateOpen && !b.clock.Now().Before(b.openedAt.Add(b.cfg.OpenTimeout.Std())) {
+		b.transition(StateHalfOpen)
+	}
+}
+
+// transition switches to state and resets the counters. b.mu must be held.
+func (b *Breaker) transition(state State) {
+	b.state = state
+	b.generation++
+	b.consecutive, b.probes, b.successes = 0, 0, 0
+	switch state {
+	case StateOpen:
+		b.openedAt = b.clock.Now()
+	case StateClosed:
+		b.buckets = [windowBuckets]bucket{}
+	}
+}
+
+// notify reports a transition. It must be called without b.mu held.
+func (b *Breaker) notify(from, to State) {
+	telemetry.RecordCircuitState(context.Background(), b.name, int64(to))
+	event := logger.Info()
+	if to == StateOpen {
+		event = logger.Warn()
+	}
+	event.Str("breaker", b.name).Str("from", from.String()).Str("to", to.String()).
+		Msg("circuit breaker state changed")
+	if b.cfg.OnStateChange != nil {
+		b.cfg.OnStateChange(b.name, from, to)
+	}
+}
@@ -0,0 +1,152 @@
+package circuitbreaker
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+	"time"
+
+	"github.com/planx-lab/planx-common/clock"
+	"github.com/planx-lab/planx-common/config"
+	"github.com/planx-lab/planx-common/errors"
+)
+
+var errDown = stderrors.New("down")
+
+func newTestBreaker(cfg Config) (*Breaker, *clock.Fake) {
+	clk := clock.NewFake(time.Unix(1700000000, 0))
+	return New("sink", cfg, WithClock(clk)), clk
+}
+
+func fail(context.Context) error    { return errDown }
+func succeed(context.Context) error { return nil }
+
+func TestBreaker_ConsecutiveFailures(t *testing.T) {
+	var transitions []string
+	b, clk := newTestBreaker(Config{
+		ConsecutiveFailures: 3,
+		OpenTimeout:         config.Duration(time.Minute),
+		OnStateChange: func(name string, from, to State) {
+			transitions = append(transitions, from.String()+"->"+to.String())
+		},
+	})
+	ctx := context.Background()
+
+	_ = b.Do(ctx, fail)
+	_ = b.Do(ctx, fail)
+	_ = b.Do(ctx, succeed)
+	_ = b.Do(ctx, fail)
+	_ = b.Do(ctx, fail)
+	if b.State() != StateClosed {
+		t.Fatal("a success should reset the consecutive count")
+	}
+	_ = b.Do(ctx, fail)
+	if b.State() != StateOpen {
+		t.Fatalf("state: got %v", b.State())
+	}
+
+	called := false
+	err := b.Do(ctx, func(context.Context) error { called = true; return nil })
+	if called || errors.CodeOf(err) != CodeOpen || errors.IsRetryable(err) {
+		t.Fatalf("open breaker: called=%v err=%v", called, err)
+	}
+
+	clk.Add(time.Minute)
+	if b.State() != StateHalfOpen {
+		t.Fatalf("state after timeout: got %v", b.State())
+	}
+	if err := b.Do(ctx, succeed); err != nil {
+		t.Fatalf("probe: %v", err)
+	}
+	if b.State() != StateClosed {
+		t.Fatalf("state after probe: got %v", b.State())
+	}
+
+	want := []string{"closed->open", "open->half-open", "half-open->closed"}
+	if len(transitions) != len(want) {
+		t.Fatalf("transitions: got %v", transitions)
+	}
+	for i := range want {
+		if transitions[i] != want[i] {
+			t.Fatalf("transitions: got %v", transitions)
+		}
+	}
+}
+
+func TestBreaker_HalfOpenFailureReopens(t *testing.T) {
+	b, clk := newTestBreaker(Config{ConsecutiveFailures: 1, HalfOpenProbes: 2})
+	ctx := context.Background()
+	_ = b.Do(ctx, fail)
+	clk.Add(DefaultOpenTimeout)
+
+	done, err := b.Allow()
+	if err != nil {
+		t.Fatalf("first probe: %v", err)
+	}
+	done2, err := b.Allow()
+	if err != nil {
+		t.Fatalf("second probe: %v", err)
+	}
+	if _, err := b.Allow(); err == nil {
+		t.Fatal("a third probe should be rejected")
+	}
+	done(nil)
+	done2(errDown)
+	if b.State() != StateOpen {
+		t.Fatalf("state: got %v", b.State())
+	}
+}
+
+func TestBreaker_FailureRate(t *testing.T) {
+	b, clk := newTestBreaker(Config{
+		FailureRate: 0.5,
+		MinRequests: 4,
+		Window:      config.Duration(10 * time.Second),
+	})
+	ctx := context.Background()
+
+	// Old failures fall out of the window.
+	_ = b.Do(ctx, fail)
+	_ = b.Do(ctx, fail)
+	clk.Add(20 * time.Second)
+	_ = b.Do(ctx, succeed)
+	_ = b.Do(ctx, succeed)
+	_ = b.Do(ctx, fail)
+	if b.State() != StateClosed {
+		t.Fatalf("below min requests: got %v", b.State())
+	}
+	_ = b.Do(ctx, fail)
+	if b.State() != StateOpen {
+		t.Fatalf("at 50%% failures: got %v", b.State())
+	}
+}
+
+func TestBreaker_TinyWindow(t *testing.T) {
+	b, _ := newTestBreaker(Config{FailureRate: 0.5, MinRequests: 1, Window: config.Duration(time.Nanosecond)})
+	_ = b.Do(context.Background(), fail)
+	if b.State() != StateOpen {
+		t.Fatalf("got %v", b.State())
+	}
+}
+
+func TestBreaker_IgnoresCanceled(t *testing.T) {
+	b, _ := newTestBreaker(Config{ConsecutiveFailures: 1})
+	_ = b.Do(context.Background(), func(context.Context) error { return context.Canceled })
+	if b.State() != StateClosed {
+		t.Fatalf("state: got %v", b.State())
+	}
+}
+
+func TestBreaker_StaleOutcomeIgnored(t *testing.T) {
+	b, _ := newTestBreaker(Config{ConsecutiveFailures: 1})
+	slow, err := b.Allow()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = b.Do(context.Background(), fail)
+	slow(nil)
+	slow(nil)
+	if b.State() != StateOpen {
+		t.Fatalf("state: got %v", b.State())
+	}
+}
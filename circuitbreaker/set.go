@@ -0,0 +1,78 @@
+package circuitbreaker
+
+import (
+	"sort"
+	"sync"
+)
+
+// Set holds one breaker per key, such as one per sink endpoint, so a single
+// failing endpoint does not cut off the others. It is safe for concurrent
+// use.
+type Set struct {
+	cfg  Config
+	opts []Option
+
+	mu       sync.RWMutex
+	breakers map[string]*Breaker
+}
+
+// NewSet returns a Set whose breakers are created with cfg and opts.
+func NewSet(cfg Config, opts ...Option) *Set {
+	return &Set{cfg: cfg, opts: opts, breakers: map[string]*Breaker{}}
+}
+
+// Get returns the breaker for key, creating it on first use. The breaker is
+// named after key.
+func (s *Set) Get(key string) *Breaker {
+	s.mu.RLock()
+	b, ok := s.breakers[key]
+	s.mu.RUnlock()
+	if ok {
+		return b
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if b, ok := s.breakers[key]; ok {
+		return b
+	}
+	b = New(key, s.cfg, s.opts...)
+	s.breakers[key] = b
+	return b
+}
+
+// Remove forgets the breaker for key, e.g. when the endpoint is removed from
+// the configuration.
+func (s *Set) Remove(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.breakers, key)
+}
+
+// Keys returns the keys with a breaker, sorted.
+func (s *Set) Keys() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	keys := make([]string, 0, len(s.breakers))
+	for key := range s.breakers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// States returns the current state of every breaker by key.
+func (s *Set) States() map[string]State {
+	s.mu.RLock()
+	breakers := make([]*Breaker, 0, len(s.breakers))
+	for _, b := range s.breakers {
+		breakers = append(breakers, b)
+	}
+	s.mu.RUnlock()
+
+	out := make(map[string]State, len(breakers))
+	for _, b := range breakers {
+		out[b.name] = b.State()
+	}
+	return out
+}
@@ -0,0 +1,28 @@
+package circuitbreaker
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSet(t *testing.T) {
+	s := NewSet(Config{ConsecutiveFailures: 1})
+	a := s.Get("http://a")
+	if s.Get("http://a") != a {
+		t.Fatal("Get should return the same breaker for a key")
+	}
+	_ = a.Do(context.Background(), fail)
+	_ = s.Get("http://b").Do(context.Background(), succeed)
+
+	states := s.States()
+	if states["http://a"] != StateOpen || states["http://b"] != StateClosed {
+		t.Fatalf("got %v", states)
+	}
+	if keys := s.Keys(); len(keys) != 2 || keys[0] != "http://a" {
+		t.Fatalf("keys: got %v", keys)
+	}
+	s.Remove("http://a")
+	if s.Get("http://a").State() != StateClosed {
+		t.Fatal("a removed key should get a fresh breaker")
+	}
+}
@@ -0,0 +1,20 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReal(t *testing.T) {
+	start := Real.Now()
+	timer := Real.NewTimer(time.Millisecond)
+	<-timer.C()
+	ticker := Real.NewTicker(time.Millisecond)
+	<-ticker.C()
+	ticker.Stop()
+	<-Real.After(time.Millisecond)
+	Real.Sleep(time.Millisecond)
+	if Real.Since(start) < 3*time.Millisecond {
+		t.Fatalf("elapsed %v", Real.Since(start))
+	}
+}
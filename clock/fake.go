@@ -0,0 +1,223 @@
+package clock
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Fake is a Clock that only moves when told to. Timers, tickers and
+// sleepers fire as Add or Set moves the time past their deadlines, in
+// deadline order. Like the real ones, timer and ticker channels have room
+// for one value, and ticks nobody received are dropped.
+//
+// Tests usually start the code under test, wait with BlockUntil for it to
+// reach its timer, then Add the time it waits for. A Fake is safe for
+// concurrent use.
+type Fake struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []*fakeWaiter
+	changed chan struct{} // closed and replaced when waiters change
+}
+
+type fakeWaiter struct {
+	clock  *Fake
+	at     time.Time
+	period time.Duration // for tickers
+	ch     chan time.Time
+}
+
+// NewFake returns a Fake set to t.
+func NewFake(t time.Time) *Fake {
+	return &Fake{now: t, changed: make(chan struct{})}
+}
+
+// Now implements Clock.
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// Since implements Clock.
+func (f *Fake) Since(t time.Time) time.Duration { return f.Now().Sub(t) }
+
+// After implements Clock.
+func (f *Fake) After(d time.Duration) <-chan time.Time { return f.NewTimer(d).C() }
+
+// Sleep implements Clock: it blocks until the clock is moved d ahead.
+func (f *Fake) Sleep(d time.Duration) {
+	if d > 0 {
+		<-f.After(d)
+	}
+}
+
+// NewTimer implements Clock.
+func (f *Fake) NewTimer(d time.Duration) Timer {
+	w := &fakeWaiter{clock: f, ch: make(chan time.Time, 1)}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.schedule(w, d)
+	return fakeTimer{w}
+}
+
+// NewTicker implements Clock. Like time.NewTicker it panics if d <= 0.
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+	w := &fakeWaiter{clock: f, period: d, ch: make(chan time.Time, 1)}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.schedule(w, d)
+	return fakeTicker{w}
+}
+
+// Add moves the clock d ahead, firing what falls due on the way.
+func (f *Fake) Add(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.advance(f.now.Add(d))
+}
+
+// Set moves the clock to t. Moving it back fires nothing.
+func (f *Fake) Set(t time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if t.Before(f.now) {
+		f.now = t
+		return
+	}
+	f.advance(t)
+}
+
+// Waiters returns the number of pending timers, tickers and sleepers.
+func (f *Fake) Waiters() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.waiters)
+}
+
+// BlockUntil blocks until at least n timers, tickers and sleepers are
+// pending, so a test can advance the clock knowing the code under test is
+// waiting on it.
+func (f *Fake) BlockUntil(n int) {
+	for {
+		f.mu.Lock()
+		pending, ch := len(f.waiters), f.changed
+		f.mu.Unlock()
+		if pending >= n {
+			return
+		}
+		<-ch
+	}
+}
+
+// advance fires the waiters due by t in order, then sets the time to t.
+// f.mu must be held.
+func (f *Fake) advance(t time.Time) {
+	for len(f.waiters) > 0 && !f.waiters[0].at.After(t) {
+		w := f.waiters[0]
+		f.now = w.at
+		select {
+		case w.ch <- w.at:
+		default:
+		}
+		f.remove(w)
+		if w.period > 0 {
+			f.schedule(w, w.period)
+		}
+	}
+	f.now = t
+}
+
+// schedule (re)arms w to fire d from now. f.mu must be held.
+func (f *Fake) schedule(w *fakeWaiter, d time.Duration) {
+	w.at = f.now.Add(d)
+	if d <= 0 {
+		select {
+		case w.ch <- f.now:
+		default:
+		}
+		return
+	}
+	i := sort.Search(len(f.waiters), func(i int) bool { return f.waiters[i].at.After(w.at) })
+	f.waiters = append(f.waiters, nil)
+	copy(f.waiters[i+1:], f.waiters[i:])
+	f.waiters[i] = w
+	f.notify()
+}
+
+// remove disarms w, reporting whether it was armed. f.mu must be held.
+func (f *Fake) remove(w *fakeWaiter) bool {
+	for i, other := range f.waiters {
+		if other == w {
+			f.waiters = append(f.waiters[:i], f.waiters[i+1:]...)
+			f.notify()
+			return true
+		}
+	}
+	return false
+}
+
+// drain discards an undelivered value, so a stopped or reset timer sends
+// nothing stale, as with Go 1.23 timers.
+func (w *fakeWaiter) drain() {
+	select {
+	case <-w.ch:
+	default:
+	}
+}
+
+func (f *Fake) notify() {
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+type fakeTimer struct{ w *fakeWaiter }
+
+func (t fakeTimer) C() <-chan time.Time { return t.w.ch }
+
+func (t fakeTimer) Stop() bool {
+	f := t.w.clock
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	t.w.drain()
+	return f.remove(t.w)
+}
+
+func (t fakeTimer) Reset(d time.Duration) bool {
+	f := t.w.clock
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	t.w.drain()
+	active := f.remove(t.w)
+	f.schedule(t.w, d)
+	return active
+}
+
+type fakeTicker struct{ w *fakeWaiter }
+
+func (t fakeTicker) C() <-chan time.Time { return t.w.ch }
+
+func (t fakeTicker) Stop() {
+	f := t.w.clock
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	t.w.drain()
+	f.remove(t.w)
+}
+
+func (t fakeTicker) Reset(d time.Duration) {
+	if d <= 0 {
+		panic("clock: non-positive interval for Ticker.Reset")
+	}
+	f := t.w.clock
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	t.w.drain()
+	f.remove(t.w)
+	t.w.period = d
+	f.schedule(t.w, d)
+}
@@ -0,0 +1,136 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+var epoch = time.Unix(1700000000, 0)
+
+// received returns the value waiting on ch, if any.
+func received(ch <-chan time.Time) (time.Time, bool) {
+	select {
+	case t := <-ch:
+		return t, true
+	default:
+		return time.Time{}, false
+	}
+}
+
+func TestFake_Timer(t *testing.T) {
+	f := NewFake(epoch)
+	timer := f.NewTimer(time.Second)
+
+	f.Add(999 * time.Millisecond)
+	if _, ok := received(timer.C()); ok {
+		t.Fatal("fired early")
+	}
+	f.Add(time.Millisecond)
+	if got, ok := received(timer.C()); !ok || !got.Equal(epoch.Add(time.Second)) {
+		t.Fatalf("got %v, %v", got, ok)
+	}
+	if timer.Stop() {
+		t.Fatal("Stop of a fired timer reported it active")
+	}
+
+	if timer.Reset(time.Second) {
+		t.Fatal("Reset of a fired timer reported it active")
+	}
+	if !timer.Stop() {
+		t.Fatal("Stop of an armed timer reported it inactive")
+	}
+	f.Add(time.Hour)
+	if _, ok := received(timer.C()); ok {
+		t.Fatal("stopped timer fired")
+	}
+}
+
+func TestFake_ResetDropsStaleValue(t *testing.T) {
+	f := NewFake(epoch)
+	timer := f.NewTimer(time.Second)
+	f.Add(time.Second)
+	timer.Reset(time.Second)
+	if _, ok := received(timer.C()); ok {
+		t.Fatal("received the value from before Reset")
+	}
+}
+
+func TestFake_OrderAndTime(t *testing.T) {
+	f := NewFake(epoch)
+	late, early := f.NewTimer(2*time.Second), f.NewTimer(time.Second)
+	f.Add(time.Minute)
+	a, _ := received(early.C())
+	b, _ := received(late.C())
+	if !a.Equal(epoch.Add(time.Second)) || !b.Equal(epoch.Add(2*time.Second)) {
+		t.Fatalf("got %v, %v", a, b)
+	}
+	if !f.Now().Equal(epoch.Add(time.Minute)) || f.Since(epoch) != time.Minute {
+		t.Fatalf("now: got %v", f.Now())
+	}
+	if zero := f.NewTimer(0); func() bool { _, ok := received(zero.C()); return !ok }() {
+		t.Fatal("zero timer did not fire at once")
+	}
+}
+
+func TestFake_Ticker(t *testing.T) {
+	f := NewFake(epoch)
+	ticker := f.NewTicker(time.Second)
+	f.Add(time.Second)
+	if _, ok := received(ticker.C()); !ok {
+		t.Fatal("no first tick")
+	}
+	// Unreceived ticks are dropped, not queued.
+	f.Add(5 * time.Second)
+	if got, ok := received(ticker.C()); !ok || !got.Equal(epoch.Add(2*time.Second)) {
+		t.Fatalf("got %v, %v", got, ok)
+	}
+	if _, ok := received(ticker.C()); ok {
+		t.Fatal("ticks queued up")
+	}
+
+	ticker.Reset(time.Minute)
+	f.Add(59 * time.Second)
+	if _, ok := received(ticker.C()); ok {
+		t.Fatal("ticked before the new period")
+	}
+	ticker.Stop()
+	f.Add(time.Hour)
+	if _, ok := received(ticker.C()); ok || f.Waiters() != 0 {
+		t.Fatal("stopped ticker ticked")
+	}
+}
+
+func TestFake_SleepAndBlockUntil(t *testing.T) {
+	f := NewFake(epoch)
+	done := make(chan struct{})
+	go func() {
+		f.Sleep(time.Minute)
+		close(done)
+	}()
+	f.BlockUntil(1)
+	f.Add(30 * time.Second)
+	select {
+	case <-done:
+		t.Fatal("woke early")
+	default:
+	}
+	f.Add(30 * time.Second)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("sleeper not woken")
+	}
+}
+
+func TestFake_SetBackwards(t *testing.T) {
+	f := NewFake(epoch)
+	timer := f.NewTimer(time.Second)
+	f.Set(epoch.Add(-time.Hour))
+	if _, ok := received(timer.C()); ok || !f.Now().Equal(epoch.Add(-time.Hour)) {
+		t.Fatal("moving back fired or did not move")
+	}
+	f.Set(epoch.Add(time.Second))
+	if _, ok := received(timer.C()); !ok {
+		t.Fatal("timer did not fire on Set")
+	}
+}
@@ -0,0 +1,146 @@
+package compress
+
+import (
+	"bytes"
+	"io"
+	"sync"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+)
+
+// Built-in codecs.
+var (
+	// Identity leaves payloads as they are.
+	Identity Codec = identityCodec{}
+
+	// Gzip is gzip at the default level, for peers that support nothing
+	// better.
+	Gzip Codec = gzipCodec{}
+
+	// Zstd is Zstandard at the default level, the best ratio for batch
+	// payloads at little CPU cost.
+	Zstd Codec = zstdCodec{}
+
+	// Snappy is the Snappy block format, the cheapest in CPU.
+	Snappy Codec = snappyCodec{}
+
+	// LZ4 is the LZ4 frame format, about as cheap as Snappy with a better
+	// ratio.
+	LZ4 Codec = lz4Codec{}
+)
+
+type identityCodec struct{}
+
+func (identityCodec) Name() string                          { return "identity" }
+func (identityCodec) Compress(src []byte) ([]byte, error)   { return src, nil }
+func (identityCodec) Decompress(src []byte) ([]byte, error) { return src, nil }
+
+type gzipCodec struct{}
+
+var (
+	gzipWriters = sync.Pool{New: func() interface{} { return gzip.NewWriter(nil) }}
+	gzipReaders sync.Pool
+)
+
+func (gzipCodec) Name() string { return "gzip" }
+
+func (gzipCodec) Compress(src []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzipWriters.Get().(*gzip.Writer)
+	defer gzipWriters.Put(w)
+	w.Reset(&buf)
+	if _, err := w.Write(src); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gzipCodec) Decompress(src []byte) ([]byte, error) {
+	var r *gzip.Reader
+	if pooled, ok := gzipReaders.Get().(*gzip.Reader); ok {
+		r = pooled
+		if err := r.Reset(bytes.NewReader(src)); err != nil {
+			return nil, err
+		}
+	} else {
+		var err error
+		if r, err = gzip.NewReader(bytes.NewReader(src)); err != nil {
+			return nil, err
+		}
+	}
+	defer gzipReaders.Put(r)
+	return io.ReadAll(r)
+}
+
+type zstdCodec struct{}
+
+// The zstd encoder and decoder pool their state internally and are safe
+// for concurrent EncodeAll and DecodeAll calls, so one of each is shared.
+var (
+	zstdEncoder = sync.OnceValue(func() *zstd.Encoder {
+		enc, _ := zstd.NewWriter(nil)
+		return enc
+	})
+	zstdDecoder = sync.OnceValue(func() *zstd.Decoder {
+		dec, _ := zstd.NewReader(nil, zstd.WithDecoderConcurrency(0))
+		return dec
+	})
+)
+
+func (zstdCodec) Name() string { return "zstd" }
+
+func (zstdCodec) Compress(src []byte) ([]byte, error) {
+	return zstdEncoder().EncodeAll(src, make([]byte, 0, len(src)/2)), nil
+}
+
+func (zstdCodec) Decompress(src []byte) ([]byte, error) {
+	return zstdDecoder().DecodeAll(src, nil)
+}
+
+type snappyCodec struct{}
+
+func (snappyCodec) Name() string { return "snappy" }
+
+func (snappyCodec) Compress(src []byte) ([]byte, error) {
+	return snappy.Encode(nil, src), nil
+}
+
+func (snappyCodec) Decompress(src []byte) ([]byte, error) {
+	return snappy.Decode(nil, src)
+}
+
+type lz4Codec struct{}
+
+var (
+	lz4Writers = sync.Pool{New: func() interface{} { return lz4.NewWriter(nil) }}
+	lz4Readers = sync.Pool{New: func() interface{} { return lz4.NewReader(nil) }}
+)
+
+func (lz4Codec) Name() string { return "lz4" }
+
+func (lz4Codec) Compress(src []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := lz4Writers.Get().(*lz4.Writer)
+	defer lz4Writers.Put(w)
+	w.Reset(&buf)
+	if _, err := w.Write(src); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (lz4Codec) Decompress(src []byte) ([]byte, error) {
+	r := lz4Readers.Get().(*lz4.Reader)
+	defer lz4Readers.Put(r)
+	r.Reset(bytes.NewReader(src))
+	return io.ReadAll(r)
+}
@@ -0,0 +1,80 @@
+package compress
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func payload() []byte {
+	var buf bytes.Buffer
+	for i := 0; i < 2000; i++ {
+		fmt.Fprintf(&buf, `{"id":%d,"tenant":"acme","status":"ok"}`+"\n", i)
+	}
+	return buf.Bytes()
+}
+
+func TestCodecs_RoundTrip(t *testing.T) {
+	src := payload()
+	for _, c := range []Codec{Identity, Gzip, Zstd, Snappy, LZ4} {
+		t.Run(c.Name(), func(t *testing.T) {
+			compressed, err := c.Compress(src)
+			if err != nil {
+				t.Fatalf("Compress: %v", err)
+			}
+			if c != Identity && len(compressed) >= len(src)/2 {
+				t.Fatalf("compressed %d bytes to %d", len(src), len(compressed))
+			}
+			got, err := c.Decompress(compressed)
+			if err != nil {
+				t.Fatalf("Decompress: %v", err)
+			}
+			if !bytes.Equal(got, src) {
+				t.Fatal("round trip changed the payload")
+			}
+
+			empty, _ := c.Compress(nil)
+			if got, err := c.Decompress(empty); err != nil || len(got) != 0 {
+				t.Fatalf("empty: got %q, %v", got, err)
+			}
+		})
+	}
+}
+
+func TestCodecs_Concurrent(t *testing.T) {
+	src := payload()
+	for _, c := range []Codec{Gzip, Zstd, LZ4} {
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				compressed, err := c.Compress(src)
+				if err != nil {
+					t.Errorf("%s: %v", c.Name(), err)
+					return
+				}
+				if got, err := c.Decompress(compressed); err != nil || !bytes.Equal(got, src) {
+					t.Errorf("%s: round trip failed: %v", c.Name(), err)
+				}
+			}()
+		}
+		wg.Wait()
+	}
+}
+
+func BenchmarkCompress(b *testing.B) {
+	src := payload()
+	for _, c := range []Codec{Gzip, Zstd, Snappy} {
+		b.Run(c.Name(), func(b *testing.B) {
+			b.SetBytes(int64(len(src)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := c.Compress(src); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+// Package compress provides compression codecs for batch payloads moved
+// between nodes, with Content-Encoding negotiation and throughput metrics.
+// Engine-side utilities only — must not be imported by SDK or plugins.
+package compress
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/planx-lab/planx-common/errors"
+	"github.com/planx-lab/planx-common/telemetry"
+)
+
+// Compression error codes.
+const (
+	CodeUnsupported errors.Code = "PLX-COMPRESS-UNSUPPORTED"
+	CodeCorrupt     errors.Code = "PLX-COMPRESS-CORRUPT"
+)
+
+func init() {
+	errors.RegisterCode(errors.CodeInfo{Code: CodeUnsupported, Description: "content encoding is not supported"})
+	errors.RegisterCode(errors.CodeInfo{Code: CodeCorrupt, Description: "compressed payload is corrupt"})
+	for _, c := range []Codec{Identity, Gzip, Zstd, Snappy, LZ4} {
+		Register(c)
+	}
+}
+
+// Codec compresses whole payloads.
+type Codec interface {
+	// Name is the codec's Content-Encoding token, e.g. "zstd".
+	Name() string
+	// Compress returns the compressed form of src.
+	Compress(src []byte) ([]byte, error)
+	// Decompress returns the original of a payload produced by Compress.
+	Decompress(src []byte) ([]byte, error)
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Codec{}
+)
+
+// Register makes c available to Lookup, Negotiate and ForEncoding. Codecs
+// are instrumented on registration: each call records its bytes in and out
+// and its latency with telemetry.RecordCompression. Register is meant to be
+// called from init and panics if c has an empty name or the name is taken.
+func Register(c Codec) {
+	if c.Name() == "" {
+		panic("compress: Register with empty name")
+	}
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, dup := registry[c.Name()]; dup {
+		panic("compress: codec " + c.Name() + " registered twice")
+	}
+	registry[c.Name()] = instrumented{c}
+}
+
+// Lookup returns the registered codec with the given name.
+func Lookup(name string) (Codec, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	c, ok := registry[strings.ToLower(name)]
+	return c, ok
+}
+
+// Names returns the names of the registered codecs, sorted.
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// instrumented records metrics for the codec it wraps.
+type instrumented struct {
+	Codec
+}
+
+func (c instrumented) Compress(src []byte) ([]byte, error) {
+	start := time.Now()
+	out, err := c.Codec.Compress(src)
+	if err == nil {
+		telemetry.RecordCompression(context.Background(), c.Name(), "compress", int64(len(src)), int64(len(out)), time.Since(start))
+	}
+	return out, err
+}
+
+func (c instrumented) Decompress(src []byte) ([]byte, error) {
+	start := time.Now()
+	out, err := c.Codec.Decompress(src)
+	if err != nil {
+		return nil, errors.Wrapf(err, "compress: decoding %s payload", c.Name()).WithCode(CodeCorrupt)
+	}
+	telemetry.RecordCompression(context.Background(), c.Name(), "decompress", int64(len(src)), int64(len(out)), time.Since(start))
+	return out, nil
+}
@@ -0,0 +1,39 @@
+package compress
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/planx-lab/planx-common/errors"
+)
+
+func TestRegistry(t *testing.T) {
+	if got := Names(); !reflect.DeepEqual(got, []string{"gzip", "identity", "lz4", "snappy", "zstd"}) {
+		t.Fatalf("got %v", got)
+	}
+	c, ok := Lookup("ZSTD")
+	if !ok || c.Name() != "zstd" {
+		t.Fatalf("got %v, %v", c, ok)
+	}
+	if _, ok := c.(instrumented); !ok {
+		t.Fatalf("registered codec not instrumented: %T", c)
+	}
+}
+
+func TestRegister_DuplicatePanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic")
+		}
+	}()
+	Register(Gzip)
+}
+
+func TestDecompress_Corrupt(t *testing.T) {
+	for _, name := range []string{"gzip", "zstd", "snappy", "lz4"} {
+		c, _ := Lookup(name)
+		if _, err := c.Decompress([]byte("not compressed at all")); errors.CodeOf(err) != CodeCorrupt {
+			t.Fatalf("%s: got %v", name, err)
+		}
+	}
+}
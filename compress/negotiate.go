@@ -0,0 +1,96 @@
+package compress
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/planx-lab/planx-common/errors"
+)
+
+// Preference is the default order in which Negotiate picks codecs a peer
+// accepts equally: best ratio first, identity last.
+var Preference = []string{"zstd", "lz4", "snappy", "gzip", "identity"}
+
+// AcceptEncoding returns an Accept-Encoding value listing the codecs in
+// Preference that are registered, for requests to peers.
+func AcceptEncoding() string {
+	var names []string
+	for _, name := range Preference {
+		if _, ok := Lookup(name); ok {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// Negotiate picks the codec for a response from the peer's Accept-Encoding
+// value: the registered codec with the highest q-value, ties going to the
+// earliest in preferred, or in Preference if preferred is empty. It returns
+// Identity when the peer accepts none of them or sent no header.
+func Negotiate(acceptEncoding string, preferred ...string) Codec {
+	if strings.TrimSpace(acceptEncoding) == "" {
+		return Identity
+	}
+	if len(preferred) == 0 {
+		preferred = Preference
+	}
+	accepted := parseAccept(acceptEncoding)
+
+	best, bestQ := Identity, 0.0
+	for _, name := range preferred {
+		c, ok := Lookup(name)
+		if !ok {
+			continue
+		}
+		q, ok := accepted[c.Name()]
+		if !ok {
+			q, ok = accepted["*"]
+		}
+		if !ok && c.Name() == "identity" {
+			q = 1 // identity is acceptable unless excluded
+		}
+		if q > bestQ {
+			best, bestQ = c, q
+		}
+	}
+	return best
+}
+
+// parseAccept returns the q-value of each coding in an Accept-Encoding
+// value. Codings without a q-value get 1.
+func parseAccept(header string) map[string]float64 {
+	accepted := map[string]float64{}
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(part, ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding == "" {
+			continue
+		}
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(key, "q") {
+				if v, err := strconv.ParseFloat(value, 64); err == nil {
+					q = v
+				}
+			}
+		}
+		accepted[coding] = q
+	}
+	return accepted
+}
+
+// ForEncoding returns the codec for a payload's Content-Encoding value,
+// Identity if it is empty. An unknown encoding fails with CodeUnsupported.
+func ForEncoding(contentEncoding string) (Codec, error) {
+	name := strings.TrimSpace(contentEncoding)
+	if name == "" {
+		return Identity, nil
+	}
+	c, ok := Lookup(name)
+	if !ok {
+		return nil, errors.NewWithCode(CodeUnsupported, "compress: unsupported content encoding").
+			WithField("encoding", name)
+	}
+	return c, nil
+}
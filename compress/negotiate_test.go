@@ -0,0 +1,53 @@
+package compress
+
+import (
+	"testing"
+
+	"github.com/planx-lab/planx-common/errors"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		header    string
+		preferred []string
+		want      string
+	}{
+		{"", nil, "identity"},
+		{"gzip", nil, "gzip"},
+		{"gzip, zstd", nil, "zstd"},
+		{"gzip;q=1.0, zstd;q=0.5", nil, "gzip"},
+		{"GZIP, Snappy", nil, "snappy"},
+		{"snappy, lz4", nil, "lz4"},
+		{"br", nil, "identity"},
+		{"*", nil, "zstd"},
+		{"*;q=0.5, gzip", nil, "gzip"},
+		{"zstd;q=0, identity;q=0.1", nil, "identity"},
+		{"gzip, zstd", []string{"gzip", "zstd"}, "gzip"},
+	}
+	for _, tt := range tests {
+		if got := Negotiate(tt.header, tt.preferred...); got.Name() != tt.want {
+			t.Errorf("Negotiate(%q, %v): got %s, want %s", tt.header, tt.preferred, got.Name(), tt.want)
+		}
+	}
+}
+
+func TestAcceptEncoding(t *testing.T) {
+	if got := AcceptEncoding(); got != "zstd, lz4, snappy, gzip, identity" {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestForEncoding(t *testing.T) {
+	if c, err := ForEncoding(""); err != nil || c.Name() != "identity" {
+		t.Fatalf("empty: got %v, %v", c, err)
+	}
+	if c, err := ForEncoding("gzip"); err != nil || c.Name() != "gzip" {
+		t.Fatalf("gzip: got %v, %v", c, err)
+	}
+	if c, err := ForEncoding("lz4"); err != nil || c.Name() != "lz4" {
+		t.Fatalf("lz4: got %v, %v", c, err)
+	}
+	if _, err := ForEncoding("br"); errors.CodeOf(err) != CodeUnsupported {
+		t.Fatalf("br: got %v", err)
+	}
+}
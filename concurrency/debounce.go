@@ -0,0 +1,203 @@
+package concurrency
+
+import (
+	"sync"
+	"time"
+
+	"github.com/planx-lab/planx-common/errors"
+	"github.com/planx-lab/planx-common/logger"
+)
+
+// Debouncer coalesces bursts of calls into one: fn runs once the calls have
+// stopped for the debounce interval, such as a config reload after a flurry
+// of file events. Create one with Debounce.
+//
+// fn runs on its own goroutine and never concurrently with itself. A panic
+// in fn is logged instead of crashing the process.
+type Debouncer struct {
+	d  time.Duration
+	fn func()
+
+	run sync.Mutex // held while fn runs
+
+	mu      sync.Mutex
+	timer   *time.Timer
+	due     time.Time
+	pending bool
+	stopped bool
+}
+
+// Debounce returns a Debouncer that runs fn d after the last call to Call.
+func Debounce(d time.Duration, fn func()) *Debouncer {
+	return &Debouncer{d: d, fn: fn}
+}
+
+// Call schedules fn, pushing back a run that is already scheduled. It does
+// nothing after Stop.
+func (b *Debouncer) Call() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.stopped {
+		return
+	}
+	b.pending = true
+	b.due = time.Now().Add(b.d)
+	if b.timer == nil {
+		b.timer = time.AfterFunc(b.d, b.fire)
+	} else {
+		b.timer.Reset(b.d)
+	}
+}
+
+func (b *Debouncer) fire() {
+	b.run.Lock()
+	defer b.run.Unlock()
+	b.mu.Lock()
+	if !b.pending {
+		b.mu.Unlock()
+		return
+	}
+	// A Call may have pushed the run back after the timer fired.
+	if wait := time.Until(b.due); wait > 0 {
+		b.timer.Reset(wait)
+		b.mu.Unlock()
+		return
+	}
+	b.pending = false
+	b.mu.Unlock()
+	runSafe("debounced", b.fn)
+}
+
+// Flush runs a scheduled fn now, on the calling goroutine, and reports
+// whether there was one. Use it to commit a pending checkpoint before
+// shutdown.
+func (b *Debouncer) Flush() bool {
+	b.run.Lock()
+	defer b.run.Unlock()
+	b.mu.Lock()
+	if !b.pending {
+		b.mu.Unlock()
+		return false
+	}
+	b.pending = false
+	b.timer.Stop()
+	b.mu.Unlock()
+	runSafe("debounced", b.fn)
+	return true
+}
+
+// Stop drops a scheduled run, waits for a running fn to return and makes
+// later calls no-ops. Call Flush first to run pending work instead.
+func (b *Debouncer) Stop() {
+	b.mu.Lock()
+	b.stopped = true
+	b.pending = false
+	if b.timer != nil {
+		b.timer.Stop()
+	}
+	b.mu.Unlock()
+	// Wait for a running fn to return.
+	b.run.Lock()
+	defer b.run.Unlock()
+}
+
+// Throttler runs fn at most once per interval however often it is called,
+// such as checkpoint commits driven by every acknowledged batch. The first
+// call in a quiet period runs fn right away; calls within the interval are
+// coalesced into one trailing run at its end, so the last call is never
+// lost. Create one with Throttle.
+//
+// fn runs on its own goroutine and never concurrently with itself. A panic
+// in fn is logged instead of crashing the process.
+type Throttler struct {
+	d  time.Duration
+	fn func()
+
+	run sync.Mutex // held while fn runs
+
+	mu      sync.Mutex
+	timer   *time.Timer
+	last    time.Time
+	pending bool
+	stopped bool
+}
+
+// Throttle returns a Throttler that runs fn at most once every d.
+func Throttle(d time.Duration, fn func()) *Throttler {
+	return &Throttler{d: d, fn: fn}
+}
+
+// Call schedules fn: now if it has not run within the interval, else at the
+// interval's end. Calls while a run is already scheduled are absorbed by it.
+// It does nothing after Stop.
+func (t *Throttler) Call() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.stopped || t.pending {
+		return
+	}
+	t.pending = true
+	wait := max(time.Until(t.last.Add(t.d)), 0)
+	if t.timer == nil {
+		t.timer = time.AfterFunc(wait, t.fire)
+	} else {
+		t.timer.Reset(wait)
+	}
+}
+
+func (t *Throttler) fire() {
+	t.run.Lock()
+	defer t.run.Unlock()
+	t.mu.Lock()
+	if !t.pending {
+		t.mu.Unlock()
+		return
+	}
+	t.pending = false
+	t.last = time.Now()
+	t.mu.Unlock()
+	runSafe("throttled", t.fn)
+}
+
+// Flush runs a scheduled fn now, on the calling goroutine, and reports
+// whether there was one. The flushed run starts a new interval.
+func (t *Throttler) Flush() bool {
+	t.run.Lock()
+	defer t.run.Unlock()
+	t.mu.Lock()
+	if !t.pending {
+		t.mu.Unlock()
+		return false
+	}
+	t.pending = false
+	t.timer.Stop()
+	t.last = time.Now()
+	t.mu.Unlock()
+	runSafe("throttled", t.fn)
+	return true
+}
+
+// Stop drops a scheduled run, waits for a running fn to return and makes
+// later calls no-ops. Call Flush first to run pending work instead.
+func (t *Throttler) Stop() {
+	t.mu.Lock()
+	t.stopped = true
+	t.pending = false
+	if t.timer != nil {
+		t.timer.Stop()
+	}
+	t.mu.Unlock()
+	// Wait for a running fn to return.
+	t.run.Lock()
+	defer t.run.Unlock()
+}
+
+func runSafe(kind string, fn func()) {
+	err := errors.Safe(func() error {
+		fn()
+		return nil
+	})
+	if err != nil {
+		logger.Error().Err(err).Msgf("%s function panicked", kind)
+	}
+}
@@ -0,0 +1,117 @@
+package concurrency
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/planx-lab/planx-common/logger/logtest"
+)
+
+// eventually polls cond for up to a second.
+func eventually(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met within a second")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestDebounce_Coalesces(t *testing.T) {
+	var runs int32
+	b := Debounce(20*time.Millisecond, func() { atomic.AddInt32(&runs, 1) })
+	defer b.Stop()
+
+	for i := 0; i < 5; i++ {
+		b.Call()
+		time.Sleep(2 * time.Millisecond)
+	}
+	eventually(t, func() bool { return atomic.LoadInt32(&runs) == 1 })
+	time.Sleep(40 * time.Millisecond)
+	if got := atomic.LoadInt32(&runs); got != 1 {
+		t.Fatalf("got %d runs, want 1", got)
+	}
+
+	b.Call()
+	eventually(t, func() bool { return atomic.LoadInt32(&runs) == 2 })
+}
+
+func TestDebounce_FlushAndStop(t *testing.T) {
+	var runs int32
+	b := Debounce(time.Hour, func() { atomic.AddInt32(&runs, 1) })
+
+	if b.Flush() {
+		t.Fatal("Flush with nothing pending reported a run")
+	}
+	b.Call()
+	if !b.Flush() || runs != 1 {
+		t.Fatalf("Flush: got %d runs", runs)
+	}
+	if b.Flush() {
+		t.Fatal("second Flush ran again")
+	}
+
+	b.Call()
+	b.Stop()
+	b.Call()
+	if b.Flush() || runs != 1 {
+		t.Fatalf("after Stop: got %d runs", runs)
+	}
+}
+
+func TestThrottle_LeadingAndTrailing(t *testing.T) {
+	var runs int32
+	th := Throttle(30*time.Millisecond, func() { atomic.AddInt32(&runs, 1) })
+	defer th.Stop()
+
+	th.Call()
+	eventually(t, func() bool { return atomic.LoadInt32(&runs) == 1 })
+
+	// Calls within the interval collapse into one trailing run.
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		th.Call()
+	}
+	eventually(t, func() bool { return atomic.LoadInt32(&runs) == 2 })
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Fatalf("trailing run after %v, want about the interval", elapsed)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if got := atomic.LoadInt32(&runs); got != 2 {
+		t.Fatalf("got %d runs, want 2", got)
+	}
+}
+
+func TestThrottle_FlushAndStop(t *testing.T) {
+	var runs int32
+	th := Throttle(time.Hour, func() { atomic.AddInt32(&runs, 1) })
+
+	th.Call()
+	eventually(t, func() bool { return atomic.LoadInt32(&runs) == 1 })
+	th.Call()
+	if !th.Flush() || atomic.LoadInt32(&runs) != 2 {
+		t.Fatalf("Flush: got %d runs", runs)
+	}
+
+	th.Call()
+	th.Stop()
+	th.Call()
+	if th.Flush() || atomic.LoadInt32(&runs) != 2 {
+		t.Fatalf("after Stop: got %d runs", runs)
+	}
+}
+
+func TestDebounce_PanicLogged(t *testing.T) {
+	rec := logtest.Capture(t)
+	b := Debounce(time.Hour, func() { panic("boom") })
+	b.Call()
+	b.Flush()
+	if rec.Find(zerolog.ErrorLevel, "debounced function panicked") == nil {
+		t.Fatal("panic not logged")
+	}
+}
@@ -0,0 +1,115 @@
+// Package concurrency provides concurrency primitives for Planx engine
+// stages, such as bounded fan-out groups, request deduplication and
+// debouncing.
+// Engine-side utilities only — must not be imported by SDK or plugins.
+package concurrency
+
+import (
+	"context"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/planx-lab/planx-common/errors"
+	"github.com/planx-lab/planx-common/telemetry"
+)
+
+// Mode selects how a Group handles task errors.
+type Mode int
+
+// Group modes.
+const (
+	// FirstError cancels the group's context on the first failure and
+	// returns that error from Wait.
+	FirstError Mode = iota
+
+	// CollectAll lets every task run and returns all failures from Wait as
+	// an *errors.MultiError.
+	CollectAll
+)
+
+// GroupOption configures a Group.
+type GroupOption func(*Group)
+
+// WithLimit bounds the number of tasks running at once; Go blocks while
+// the limit is reached. n <= 0 means no limit.
+func WithLimit(n int) GroupOption {
+	return func(g *Group) { g.limit = n }
+}
+
+// WithMode sets the error mode. The default is FirstError.
+func WithMode(mode Mode) GroupOption {
+	return func(g *Group) { g.mode = mode }
+}
+
+// Group runs related tasks concurrently, such as the fan-out of a batch to
+// several sinks, wrapping errgroup. Each task runs in its own span, named
+// "<group>/<task>", and a panicking task fails with a CodePanic error
+// instead of crashing the process.
+type Group struct {
+	name  string
+	limit int
+	mode  Mode
+	ctx   context.Context
+	eg    *errgroup.Group
+
+	mu   sync.Mutex
+	errs []error
+}
+
+// NewGroup returns a Group and the context its tasks receive. In FirstError
+// mode the context is canceled when a task fails or Wait returns.
+func NewGroup(ctx context.Context, name string, opts ...GroupOption) (*Group, context.Context) {
+	g := &Group{name: name}
+	for _, opt := range opts {
+		opt(g)
+	}
+	if g.mode == FirstError {
+		g.eg, ctx = errgroup.WithContext(ctx)
+	} else {
+		g.eg = &errgroup.Group{}
+	}
+	if g.limit > 0 {
+		g.eg.SetLimit(g.limit)
+	}
+	g.ctx = ctx
+	return g, ctx
+}
+
+// Go runs fn in a new goroutine, blocking first while the limit is reached.
+func (g *Group) Go(task string, fn func(ctx context.Context) error) {
+	g.eg.Go(func() error {
+		ctx, span := telemetry.StartSpan(g.ctx, g.name+"/"+task,
+			attribute.String("planx.group", g.name),
+			attribute.String("planx.task", task))
+		defer span.End()
+
+		err := errors.Safe(func() error { return fn(ctx) })
+		if err == nil {
+			return nil
+		}
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		if g.mode == CollectAll {
+			g.mu.Lock()
+			g.errs = append(g.errs, err)
+			g.mu.Unlock()
+			return nil
+		}
+		return err
+	})
+}
+
+// Wait waits for every task. In FirstError mode it returns the first error;
+// in CollectAll mode an *errors.MultiError of all errors in completion
+// order, or nil.
+func (g *Group) Wait() error {
+	if err := g.eg.Wait(); err != nil {
+		return err
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return errors.NewMultiError(g.errs...)
+}
@@ -0,0 +1,88 @@
+package concurrency
+
+import (
+	"context"
+	stderrors "errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/planx-lab/planx-common/errors"
+)
+
+func TestGroup_FirstError(t *testing.T) {
+	g, ctx := NewGroup(context.Background(), "fanout")
+	boom := stderrors.New("boom")
+	g.Go("fail", func(context.Context) error { return boom })
+	g.Go("wait", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if err := g.Wait(); err != boom {
+		t.Fatalf("got %v", err)
+	}
+	if ctx.Err() == nil {
+		t.Fatal("group context should be canceled")
+	}
+}
+
+func TestGroup_CollectAll(t *testing.T) {
+	g, ctx := NewGroup(context.Background(), "fanout", WithMode(CollectAll))
+	var ran int32
+	for _, name := range []string{"a", "b", "c"} {
+		g.Go(name, func(context.Context) error {
+			atomic.AddInt32(&ran, 1)
+			if name == "b" {
+				return nil
+			}
+			return stderrors.New(name + " failed")
+		})
+	}
+	err := g.Wait()
+	var multi *errors.MultiError
+	if !stderrors.As(err, &multi) || len(multi.Errors) != 2 {
+		t.Fatalf("got %v", err)
+	}
+	if ran != 3 || ctx.Err() != nil {
+		t.Fatalf("ran %d tasks, ctx err %v", ran, ctx.Err())
+	}
+
+	g, _ = NewGroup(context.Background(), "fanout", WithMode(CollectAll))
+	g.Go("ok", func(context.Context) error { return nil })
+	if err := g.Wait(); err != nil {
+		t.Fatalf("no failures: got %v", err)
+	}
+}
+
+func TestGroup_Limit(t *testing.T) {
+	g, _ := NewGroup(context.Background(), "fanout", WithLimit(2))
+	var running, peak int32
+	for i := 0; i < 6; i++ {
+		g.Go("task", func(context.Context) error {
+			n := atomic.AddInt32(&running, 1)
+			for {
+				p := atomic.LoadInt32(&peak)
+				if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			atomic.AddInt32(&running, -1)
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if peak > 2 {
+		t.Fatalf("peak concurrency %d exceeds the limit", peak)
+	}
+}
+
+func TestGroup_Panic(t *testing.T) {
+	g, _ := NewGroup(context.Background(), "fanout")
+	g.Go("panics", func(context.Context) error { panic("bad record") })
+	if err := g.Wait(); errors.CodeOf(err) != errors.CodePanic {
+		t.Fatalf("got %v", err)
+	}
+}
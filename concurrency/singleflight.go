@@ -0,0 +1,146 @@
+package concurrency
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/planx-lab/planx-common/clock"
+	"github.com/planx-lab/planx-common/errors"
+)
+
+// Singleflight deduplicates concurrent calls by key, such as schema lookups
+// or auth token refreshes shared by many sessions: while a call for a key
+// is running, other callers for the key wait for its result instead of
+// starting their own. Successful results are also cached for the TTL;
+// errors are never cached, so the next caller after a failure tries again.
+// Expired results are dropped when their key is next used.
+//
+// Each caller waits under its own context. The shared call runs with the
+// values of the first caller's context but is only canceled once every
+// caller waiting for it has given up.
+//
+// A Singleflight is safe for concurrent use.
+type Singleflight[K comparable, V any] struct {
+	ttl   time.Duration
+	clock clock.Clock
+
+	mu    sync.Mutex
+	calls map[K]*flight[V]
+	cache map[K]cachedResult[V]
+}
+
+type flight[V any] struct {
+	done    chan struct{}
+	val     V
+	err     error
+	waiters int
+	cancel  context.CancelFunc
+}
+
+type cachedResult[V any] struct {
+	val     V
+	expires time.Time
+}
+
+// SingleflightOption configures a Singleflight.
+type SingleflightOption func(*singleflightOptions)
+
+type singleflightOptions struct {
+	clock clock.Clock
+}
+
+// WithClock sets the clock cached results expire on. The default is
+// clock.Real; tests pass a clock.Fake.
+func WithClock(c clock.Clock) SingleflightOption {
+	return func(o *singleflightOptions) { o.clock = c }
+}
+
+// NewSingleflight returns a Singleflight caching results for ttl; zero
+// disables caching, so only concurrent calls are shared.
+func NewSingleflight[K comparable, V any](ttl time.Duration, opts ...SingleflightOption) *Singleflight[K, V] {
+	o := singleflightOptions{clock: clock.Real}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Singleflight[K, V]{
+		ttl:   ttl,
+		clock: o.clock,
+		calls: map[K]*flight[V]{},
+		cache: map[K]cachedResult[V]{},
+	}
+}
+
+// Do returns the cached result for key, joins the call in flight for key,
+// or calls fn. shared reports whether the result came from the cache or
+// another caller's call. If ctx is done before the result is ready Do
+// returns the context error; the call goes on for the other callers.
+func (s *Singleflight[K, V]) Do(ctx context.Context, key K, fn func(ctx context.Context) (V, error)) (v V, shared bool, err error) {
+	s.mu.Lock()
+	if c, ok := s.cache[key]; ok {
+		if s.clock.Now().Before(c.expires) {
+			s.mu.Unlock()
+			return c.val, true, nil
+		}
+		delete(s.cache, key)
+	}
+	f, joined := s.calls[key]
+	if !joined {
+		callCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		f = &flight[V]{done: make(chan struct{}), cancel: cancel}
+		s.calls[key] = f
+		go s.run(callCtx, key, f, fn)
+	}
+	f.waiters++
+	s.mu.Unlock()
+
+	select {
+	case <-f.done:
+		return f.val, joined, f.err
+	case <-ctx.Done():
+		s.mu.Lock()
+		f.waiters--
+		if f.waiters == 0 {
+			f.cancel()
+			// Detach the canceled call so the next caller starts afresh
+			// rather than joining it for a context error.
+			if s.calls[key] == f {
+				delete(s.calls, key)
+			}
+		}
+		s.mu.Unlock()
+		var zero V
+		return zero, joined, errors.FromContext(ctx, ctx.Err())
+	}
+}
+
+func (s *Singleflight[K, V]) run(ctx context.Context, key K, f *flight[V], fn func(ctx context.Context) (V, error)) {
+	defer f.cancel()
+	err := errors.Safe(func() error {
+		var err error
+		f.val, err = fn(ctx)
+		return err
+	})
+
+	s.mu.Lock()
+	f.err = err
+	// A detached call, abandoned or forgotten, leaves the key and its
+	// cache to the newer call, if any.
+	if s.calls[key] == f {
+		delete(s.calls, key)
+		if err == nil && s.ttl > 0 {
+			s.cache[key] = cachedResult[V]{val: f.val, expires: s.clock.Now().Add(s.ttl)}
+		}
+	}
+	s.mu.Unlock()
+	close(f.done)
+}
+
+// Forget drops the cached result for key, and detaches the call in flight
+// for key so the next Do starts a new one, e.g. after a token was revoked.
+func (s *Singleflight[K, V]) Forget(key K) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.cache, key)
+	delete(s.calls, key)
+}
@@ -0,0 +1,182 @@
+package concurrency
+
+import (
+	"context"
+	stderrors "errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/planx-lab/planx-common/clock"
+	"github.com/planx-lab/planx-common/errors"
+)
+
+// waitWaiters waits until n callers are waiting for the call for key.
+func waitWaiters[V any](s *Singleflight[string, V], key string, n int) {
+	for {
+		s.mu.Lock()
+		waiters := 0
+		if f := s.calls[key]; f != nil {
+			waiters = f.waiters
+		}
+		s.mu.Unlock()
+		if waiters == n {
+			return
+		}
+		runtime.Gosched()
+	}
+}
+
+func TestSingleflight_Dedup(t *testing.T) {
+	s := NewSingleflight[string, int](0)
+	var calls int32
+	release := make(chan struct{})
+	fn := func(context.Context) (int, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return 42, nil
+	}
+
+	var wg sync.WaitGroup
+	var sharedCount int32
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, shared, err := s.Do(context.Background(), "schema-1", fn)
+			if err != nil || v != 42 {
+				t.Errorf("got %d, %v", v, err)
+			}
+			if shared {
+				atomic.AddInt32(&sharedCount, 1)
+			}
+		}()
+	}
+	waitWaiters(s, "schema-1", 5)
+	close(release)
+	wg.Wait()
+	if calls != 1 || sharedCount != 4 {
+		t.Fatalf("calls %d, shared %d", calls, sharedCount)
+	}
+
+	// Without a TTL nothing is cached.
+	if _, shared, _ := s.Do(context.Background(), "schema-1", fn); shared || calls != 2 {
+		t.Fatalf("shared %v, calls %d", shared, calls)
+	}
+}
+
+func TestSingleflight_TTL(t *testing.T) {
+	clk := clock.NewFake(time.Unix(1700000000, 0))
+	s := NewSingleflight[string, string](time.Minute, WithClock(clk))
+	var calls int32
+	fn := func(context.Context) (string, error) {
+		atomic.AddInt32(&calls, 1)
+		return "token", nil
+	}
+	ctx := context.Background()
+
+	_, _, _ = s.Do(ctx, "auth", fn)
+	if v, shared, _ := s.Do(ctx, "auth", fn); v != "token" || !shared || calls != 1 {
+		t.Fatalf("cached: got %q, %v after %d calls", v, shared, calls)
+	}
+	clk.Add(time.Minute)
+	_, _, _ = s.Do(ctx, "auth", fn)
+	if calls != 2 {
+		t.Fatalf("expired: got %d calls", calls)
+	}
+	s.Forget("auth")
+	_, _, _ = s.Do(ctx, "auth", fn)
+	if calls != 3 {
+		t.Fatalf("forgotten: got %d calls", calls)
+	}
+}
+
+func TestSingleflight_ErrorsNotCached(t *testing.T) {
+	s := NewSingleflight[int, int](time.Minute)
+	var calls int32
+	fn := func(context.Context) (int, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			return 0, stderrors.New("registry down")
+		}
+		return 7, nil
+	}
+	if _, _, err := s.Do(context.Background(), 1, fn); err == nil {
+		t.Fatal("expected the first call to fail")
+	}
+	if v, _, err := s.Do(context.Background(), 1, fn); err != nil || v != 7 {
+		t.Fatalf("retry: got %d, %v", v, err)
+	}
+}
+
+func TestSingleflight_CallerCanceled(t *testing.T) {
+	s := NewSingleflight[string, int](0)
+	started, callCanceled := make(chan struct{}), make(chan struct{})
+	fn := func(ctx context.Context) (int, error) {
+		close(started)
+		<-ctx.Done()
+		close(callCanceled)
+		return 0, ctx.Err()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+	_, _, err := s.Do(ctx, "k", fn)
+	if errors.CodeOf(err) != errors.CodeCanceled {
+		t.Fatalf("got %v", err)
+	}
+	select {
+	case <-callCanceled:
+	case <-time.After(time.Second):
+		t.Fatal("call not canceled after its only caller left")
+	}
+}
+
+func TestSingleflight_NewCallAfterCanceled(t *testing.T) {
+	s := NewSingleflight[string, int](time.Minute)
+	started, release := make(chan struct{}), make(chan struct{})
+	slow := func(context.Context) (int, error) {
+		close(started)
+		// The abandoned call is slow to notice its cancellation.
+		<-release
+		return 1, nil
+	}
+	fresh := func(context.Context) (int, error) { return 2, nil }
+
+	var abandoned *flight[int]
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		s.mu.Lock()
+		abandoned = s.calls["k"]
+		s.mu.Unlock()
+		cancel()
+	}()
+	if _, _, err := s.Do(ctx, "k", slow); errors.CodeOf(err) != errors.CodeCanceled {
+		t.Fatalf("canceled Do: got %v", err)
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	v, shared, err := s.Do(ctx, "k", fresh)
+	if err != nil || shared || v != 2 {
+		t.Fatalf("Do after the only caller left = %d, %v, %v, want a fresh call", v, shared, err)
+	}
+
+	// The abandoned call finishing must not displace the fresh result.
+	close(release)
+	<-abandoned.done
+	if v, shared, err := s.Do(context.Background(), "k", slow); err != nil || !shared || v != 2 {
+		t.Fatalf("cached Do = %d, %v, %v", v, shared, err)
+	}
+}
+
+func TestSingleflight_Panic(t *testing.T) {
+	s := NewSingleflight[string, int](0)
+	_, _, err := s.Do(context.Background(), "k", func(context.Context) (int, error) { panic("boom") })
+	if errors.CodeOf(err) != errors.CodePanic {
+		t.Fatalf("got %v", err)
+	}
+}
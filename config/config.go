@@ -11,29 +11,46 @@ import (
 )
 
 // LoadYAML loads a YAML configuration file into the given struct.
+// Defaults are applied first, see ApplyDefaults, and !include tags are
+// resolved, see IncludeTag.
 func LoadYAML(path string, v interface{}) error {
-	data, err := os.ReadFile(path)
+	doc, err := readYAMLFile(path, nil)
 	if err != nil {
 		return err
 	}
-	return yaml.Unmarshal(data, v)
+	return decodeYAMLNode(doc, v)
 }
 
 // LoadJSON loads a JSON configuration file into the given struct.
+// Defaults are applied first, see ApplyDefaults.
 func LoadJSON(path string, v interface{}) error {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return err
 	}
-	return json.Unmarshal(data, v)
+	return ParseJSON(data, v)
 }
 
 // ParseYAML parses YAML bytes into the given struct.
+// Defaults are applied first, see ApplyDefaults.
 func ParseYAML(data []byte, v interface{}) error {
-	return yaml.Unmarshal(data, v)
+	if err := applyDefaultsIfStruct(v); err != nil {
+		return err
+	}
+	if err := yaml.Unmarshal(data, v); err != nil {
+		return err
+	}
+	return applyDeprecations(v, func(p interface{}) error { return yaml.Unmarshal(data, p) })
 }
 
 // ParseJSON parses JSON bytes into the given struct.
+// Defaults are applied first, see ApplyDefaults.
 func ParseJSON(data []byte, v interface{}) error {
-	return json.Unmarshal(data, v)
+	if err := applyDefaultsIfStruct(v); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return err
+	}
+	return applyDeprecations(v, func(p interface{}) error { return json.Unmarshal(data, p) })
 }
@@ -0,0 +1,149 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// checkRequiredWhen applies the conditional required rules to v, a field of
+// parent. ok reports whether name is one of them; msg is the violation, or
+// "" if the rule holds.
+func checkRequiredWhen(v, parent reflect.Value, name, arg string) (msg string, ok bool) {
+	var other string
+	switch name {
+	case "required_if", "required_unless":
+		other, _, _ = strings.Cut(arg, " ")
+	case "required_with", "required_without":
+		other = arg
+	default:
+		return "", false
+	}
+	sib, label, found := sibling(parent, other)
+	if !found {
+		return fmt.Sprintf("unknown field %q in %s rule", other, name), true
+	}
+
+	var cond string
+	switch name {
+	case "required_if", "required_unless":
+		_, want, _ := strings.Cut(arg, " ")
+		want = strings.TrimSpace(want)
+		equal := valueText(sib) == want
+		if name == "required_if" && equal {
+			cond = "when " + label + " is " + want
+		} else if name == "required_unless" && !equal {
+			cond = "unless " + label + " is " + want
+		}
+	case "required_with":
+		if !isEmpty(sib) {
+			cond = "when " + label + " is set"
+		}
+	case "required_without":
+		if isEmpty(sib) {
+			cond = "when " + label + " is not set"
+		}
+	}
+	if cond == "" || !isEmpty(v) {
+		return "", true
+	}
+	return "is required " + cond, true
+}
+
+// fieldComparisons maps the sibling comparison rules to their wording.
+var fieldComparisons = map[string]string{
+	"eqfield":  "equal to",
+	"nefield":  "different from",
+	"gtfield":  "greater than",
+	"gtefield": "greater than or equal to",
+	"ltfield":  "less than",
+	"ltefield": "less than or equal to",
+}
+
+// checkFieldRule applies excluded_with and the comparison rules to v, a
+// field of parent. ok reports whether name is one of them; msg is the
+// violation, or "" if the rule holds. Comparisons are skipped while either
+// side is a nil pointer.
+func checkFieldRule(v, parent reflect.Value, name, arg string) (msg string, ok bool) {
+	wording, isCompare := fieldComparisons[name]
+	if !isCompare && name != "excluded_with" {
+		return "", false
+	}
+	sib, label, found := sibling(parent, arg)
+	if !found {
+		return fmt.Sprintf("unknown field %q in %s rule", arg, name), true
+	}
+	if name == "excluded_with" {
+		if !isEmpty(v) && !isEmpty(sib) {
+			return "must not be set when " + label + " is set", true
+		}
+		return "", true
+	}
+
+	a, b := indirect(v), indirect(sib)
+	if !a.IsValid() || !b.IsValid() {
+		return "", true
+	}
+	var holds bool
+	switch name {
+	case "eqfield", "nefield":
+		holds = reflect.DeepEqual(a.Interface(), b.Interface()) == (name == "eqfield")
+	default:
+		x, okA := numeric(a)
+		y, okB := numeric(b)
+		if !okA || !okB {
+			return fmt.Sprintf("invalid %s rule: %s and %s are not both numeric", name, a.Type(), b.Type()), true
+		}
+		switch name {
+		case "gtfield":
+			holds = x > y
+		case "gtefield":
+			holds = x >= y
+		case "ltfield":
+			holds = x < y
+		case "ltefield":
+			holds = x <= y
+		}
+	}
+	if holds {
+		return "", true
+	}
+	return fmt.Sprintf("must be %s %s", wording, label), true
+}
+
+// sibling finds the field of parent named by Go name or configuration name
+// and returns it with its configuration name.
+func sibling(parent reflect.Value, name string) (reflect.Value, string, bool) {
+	if !parent.IsValid() || parent.Kind() != reflect.Struct {
+		return reflect.Value{}, "", false
+	}
+	if f, ok := parent.Type().FieldByName(name); ok && f.IsExported() {
+		return parent.FieldByIndex(f.Index), fieldName(f), true
+	}
+	if v, ok := fieldByName(parent, name); ok {
+		return v, name, true
+	}
+	return reflect.Value{}, "", false
+}
+
+// valueText formats v for required_if and required_unless; a nil pointer
+// formats as "".
+func valueText(v reflect.Value) string {
+	v = indirect(v)
+	if !v.IsValid() {
+		return ""
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+func numeric(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
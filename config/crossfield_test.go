@@ -0,0 +1,102 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+type tlsConfig struct {
+	TLS      bool   `yaml:"tls"`
+	CertFile string `yaml:"cert_file" validate:"required_if=TLS true"`
+	Mode     string `yaml:"mode"`
+	Token    string `yaml:"token" validate:"required_unless=mode anonymous"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password" validate:"required_with=User"`
+	Socket   string `yaml:"socket" validate:"required_without=Address"`
+	Address  string `yaml:"address" validate:"excluded_with=Socket"`
+}
+
+func TestValidate_RequiredWhen(t *testing.T) {
+	cfg := tlsConfig{TLS: true, User: "admin"}
+	got := Violations(Validate(cfg))
+	want := []string{
+		"cert_file: is required when tls is true",
+		"token: is required unless mode is anonymous",
+		"password: is required when user is set",
+		"socket: is required when address is not set",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %v", got)
+	}
+	for i, w := range want {
+		if got[i].String() != w {
+			t.Fatalf("violation %d: got %q, want %q", i, got[i], w)
+		}
+	}
+
+	cfg = tlsConfig{Mode: "anonymous", Address: "localhost:9000"}
+	if err := Validate(cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cfg = tlsConfig{Mode: "anonymous", Socket: "/run/planx.sock", Address: "localhost:9000"}
+	got = Violations(Validate(cfg))
+	if len(got) != 1 || got[0].String() != "address: must not be set when socket is set" {
+		t.Fatalf("got %v", got)
+	}
+}
+
+type batchConfig struct {
+	MinBatch   int           `yaml:"min_batch" validate:"ltefield=MaxBatch"`
+	MaxBatch   int           `yaml:"max_batch"`
+	Timeout    time.Duration `yaml:"timeout"`
+	MaxTimeout Duration      `yaml:"max_timeout" validate:"gtfield=Timeout"`
+	Limit      *ByteSize     `yaml:"limit" validate:"gtefield=Buffer"`
+	Buffer     ByteSize      `yaml:"buffer"`
+	Primary    string        `yaml:"primary"`
+	Fallback   string        `yaml:"fallback" validate:"omitempty,nefield=Primary"`
+}
+
+func TestValidate_FieldComparisons(t *testing.T) {
+	limit := ByteSize(KiB)
+	cfg := batchConfig{
+		MinBatch: 10, MaxBatch: 5,
+		Timeout: time.Second, MaxTimeout: Duration(time.Second),
+		Limit: &limit, Buffer: MiB,
+		Primary: "a", Fallback: "a",
+	}
+	got := Violations(Validate(cfg))
+	want := []string{
+		"min_batch: must be less than or equal to max_batch",
+		"max_timeout: must be greater than timeout",
+		"limit: must be greater than or equal to buffer",
+		"fallback: must be different from primary",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %v", got)
+	}
+	for i, w := range want {
+		if got[i].String() != w {
+			t.Fatalf("violation %d: got %q, want %q", i, got[i], w)
+		}
+	}
+
+	cfg = batchConfig{MinBatch: 5, MaxBatch: 5, MaxTimeout: Duration(time.Second)}
+	if err := Validate(cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestValidate_CrossFieldBadRule(t *testing.T) {
+	bad := struct {
+		Min  int    `validate:"ltfield=Missing"`
+		Name string `validate:"gtfield=Min"`
+	}{}
+	got := Violations(Validate(bad))
+	if len(got) != 2 || got[0].Message != `unknown field "Missing" in ltfield rule` {
+		t.Fatalf("got %v", got)
+	}
+	if got[1].Field != "Name" {
+		t.Fatalf("got %v", got)
+	}
+}
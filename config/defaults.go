@@ -0,0 +1,83 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/planx-lab/planx-common/internal/reflectset"
+)
+
+// Defaulter is implemented by configuration structs whose defaults cannot be
+// expressed as tags, e.g. because they depend on other fields. SetDefaults
+// is called before the file is decoded, after the struct's tag defaults.
+type Defaulter interface {
+	SetDefaults()
+}
+
+// ApplyDefaults fills the zero-valued fields of v from their `default` tags
+// and then calls SetDefaults on every Defaulter it walks:
+//
+//	Timeout time.Duration `yaml:"timeout" default:"10s"`
+//	Hosts   []string      `yaml:"hosts" default:"localhost"`
+//
+// Tag values are parsed like environment overrides (see LoadWithEnv).
+// Nested structs are walked, and so are non-nil pointers to structs; nil
+// pointers stay nil, so an optional section's defaults apply only if it was
+// allocated beforehand. Fields that already hold a value are left alone.
+//
+// The loaders in this package call ApplyDefaults before decoding, so keys
+// present in the file win over defaults and absent keys keep them. A value
+// that is not a pointer to a struct, e.g. a map, is left unchanged.
+func ApplyDefaults(v interface{}) error {
+	rv, err := structPtr(v)
+	if err != nil {
+		return err
+	}
+	return applyDefaults(rv, "")
+}
+
+// applyDefaultsIfStruct is ApplyDefaults for loaders, which also accept maps
+// and other non-struct targets.
+func applyDefaultsIfStruct(v interface{}) error {
+	rv, err := structPtr(v)
+	if err != nil {
+		return nil
+	}
+	return applyDefaults(rv, "")
+}
+
+func applyDefaults(rv reflect.Value, path string) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fv := rv.Field(i)
+		fpath := joinPath(path, fieldName(field))
+
+		if def, ok := field.Tag.Lookup("default"); ok {
+			if fv.IsZero() {
+				if err := reflectset.Set(fv, def); err != nil {
+					return fmt.Errorf("config: default for %s: %w", fpath, err)
+				}
+			}
+			continue
+		}
+
+		switch {
+		case fv.Kind() == reflect.Struct:
+			if err := applyDefaults(fv, fpath); err != nil {
+				return err
+			}
+		case fv.Kind() == reflect.Ptr && !fv.IsNil() && fv.Elem().Kind() == reflect.Struct:
+			if err := applyDefaults(fv.Elem(), fpath); err != nil {
+				return err
+			}
+		}
+	}
+	if d, ok := rv.Addr().Interface().(Defaulter); ok {
+		d.SetDefaults()
+	}
+	return nil
+}
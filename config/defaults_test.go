@@ -0,0 +1,100 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type defaultSink struct {
+	Endpoint string        `yaml:"endpoint" default:"http://localhost:4317"`
+	Timeout  time.Duration `yaml:"timeout" default:"10s"`
+	Hosts    []string      `yaml:"hosts" default:"a,b"`
+}
+
+type defaultConfig struct {
+	Workers int          `yaml:"workers" json:"workers" default:"4"`
+	Enabled bool         `yaml:"enabled" json:"enabled" default:"true"`
+	Sink    defaultSink  `yaml:"sink" json:"sink"`
+	Extra   *defaultSink `yaml:"extra" json:"extra"`
+	Queue   int          `yaml:"queue" json:"queue"`
+}
+
+func (c *defaultConfig) SetDefaults() {
+	if c.Queue == 0 {
+		c.Queue = c.Workers * 10
+	}
+}
+
+func TestApplyDefaults(t *testing.T) {
+	cfg := defaultConfig{Workers: 2, Extra: &defaultSink{}}
+	if err := ApplyDefaults(&cfg); err != nil {
+		t.Fatalf("ApplyDefaults: %v", err)
+	}
+	if cfg.Workers != 2 {
+		t.Errorf("set field overwritten: %d", cfg.Workers)
+	}
+	if !cfg.Enabled || cfg.Queue != 20 {
+		t.Errorf("got %+v", cfg)
+	}
+	if cfg.Sink.Timeout != 10*time.Second || len(cfg.Sink.Hosts) != 2 {
+		t.Errorf("nested defaults not applied: %+v", cfg.Sink)
+	}
+	if cfg.Extra.Endpoint != "http://localhost:4317" {
+		t.Errorf("allocated pointer defaults not applied: %+v", cfg.Extra)
+	}
+
+	var empty defaultConfig
+	if err := ApplyDefaults(&empty); err != nil {
+		t.Fatalf("ApplyDefaults: %v", err)
+	}
+	if empty.Extra != nil {
+		t.Error("nil optional section should stay nil")
+	}
+}
+
+func TestApplyDefaults_BadTag(t *testing.T) {
+	cfg := struct {
+		Timeout time.Duration `yaml:"timeout" default:"soon"`
+	}{}
+	if err := ApplyDefaults(&cfg); err == nil {
+		t.Fatal("expected an error for an unparsable default")
+	}
+}
+
+func TestLoadYAML_Defaults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "engine.yaml")
+	content := []byte("workers: 8\nenabled: false\nsink:\n  timeout: 1s\n")
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	var cfg defaultConfig
+	if err := LoadYAML(path, &cfg); err != nil {
+		t.Fatalf("LoadYAML: %v", err)
+	}
+	if cfg.Workers != 8 || cfg.Enabled {
+		t.Errorf("file values should win over defaults: %+v", cfg)
+	}
+	if cfg.Sink.Timeout != time.Second || cfg.Sink.Endpoint != "http://localhost:4317" {
+		t.Errorf("got sink %+v", cfg.Sink)
+	}
+	if cfg.Queue != 40 {
+		t.Errorf("Defaulter saw workers=%d, queue=%d", cfg.Workers, cfg.Queue)
+	}
+}
+
+func TestParseJSON_Defaults(t *testing.T) {
+	var cfg defaultConfig
+	if err := ParseJSON([]byte(`{"enabled":false}`), &cfg); err != nil {
+		t.Fatalf("ParseJSON: %v", err)
+	}
+	if cfg.Workers != 4 || cfg.Enabled {
+		t.Fatalf("got %+v", cfg)
+	}
+
+	m := map[string]interface{}{}
+	if err := ParseJSON([]byte(`{"a":1}`), &m); err != nil || m["a"] != float64(1) {
+		t.Fatalf("map target: %v %v", m, err)
+	}
+}
@@ -0,0 +1,175 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/planx-lab/planx-common/logger"
+)
+
+// applyDeprecations handles renamed fields, which keep their old name in the
+// struct with a `deprecated` tag. When a loaded configuration sets one, a
+// warning is logged and, if the tag reads "use <path>", the value is copied
+// onto the field at that path (yaml names from the root) unless it is set
+// too:
+//
+//	type Config struct {
+//		Sink struct {
+//			Endpoint string `yaml:"endpoint"`
+//		} `yaml:"sink"`
+//		// Deprecated: renamed to sink.endpoint in v2.
+//		SinkURL string `yaml:"sink_url" deprecated:"use sink.endpoint"`
+//	}
+//
+// Any other tag text is logged as the reason, without mapping. All loaders
+// and Parse functions apply deprecations after decoding.
+//
+// Defaults are applied before decoding, so the replacement's value cannot
+// tell whether the document set it. decode, which decodes the same
+// document into its argument, is run again on a zero value without
+// defaults to find out; if decode is nil the replacement counts as set
+// when it is not zero.
+func applyDeprecations(v interface{}, decode func(interface{}) error) error {
+	rv, err := structPtr(v)
+	if err != nil || !hasDeprecated(rv.Type(), map[reflect.Type]bool{}) {
+		return nil
+	}
+	decoded := rv
+	if decode != nil {
+		probe := reflect.New(rv.Type())
+		if err := decode(probe.Interface()); err != nil {
+			return err
+		}
+		decoded = probe.Elem()
+	}
+	return walkDeprecated(rv, decoded, rv, "")
+}
+
+// hasDeprecated reports whether struct type t has a deprecated field at
+// any depth.
+func hasDeprecated(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if seen[t] {
+		return false
+	}
+	seen[t] = true
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if _, ok := field.Tag.Lookup("deprecated"); ok && field.IsExported() {
+			return true
+		}
+		ft := field.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct && hasDeprecated(ft, seen) {
+			return true
+		}
+	}
+	return false
+}
+
+// walkDeprecated handles the deprecated fields below rv. root is the
+// configuration being loaded and decoded the document alone, without
+// defaults.
+func walkDeprecated(root, decoded, rv reflect.Value, path string) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fv := rv.Field(i)
+		fpath := joinPath(path, fieldName(field))
+
+		if reason, ok := field.Tag.Lookup("deprecated"); ok {
+			if !fv.IsZero() {
+				if err := deprecate(root, decoded, fv, fpath, reason); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		switch {
+		case fv.Kind() == reflect.Struct:
+			if err := walkDeprecated(root, decoded, fv, fpath); err != nil {
+				return err
+			}
+		case fv.Kind() == reflect.Ptr && !fv.IsNil() && fv.Elem().Kind() == reflect.Struct:
+			if err := walkDeprecated(root, decoded, fv.Elem(), fpath); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func deprecate(root, decoded, fv reflect.Value, path, reason string) error {
+	target, ok := strings.CutPrefix(reason, "use ")
+	if !ok {
+		logger.Warn().Str("field", path).Str("reason", reason).Msg("config: deprecated field is set")
+		return nil
+	}
+	target = strings.TrimSpace(target)
+	tv, err := fieldByPath(root, target)
+	if err != nil {
+		return fmt.Errorf("config: deprecated field %s: %w", path, err)
+	}
+	if tv.Type() != fv.Type() {
+		return fmt.Errorf("config: deprecated field %s: cannot map %s onto %s of type %s", path, fv.Type(), target, tv.Type())
+	}
+	set, err := fieldByPath(decoded, target)
+	if err != nil {
+		return fmt.Errorf("config: deprecated field %s: %w", path, err)
+	}
+	if !set.IsZero() {
+		logger.Warn().Str("field", path).Str("replacement", target).
+			Msg("config: deprecated field is set along with its replacement, ignoring it")
+		return nil
+	}
+	tv.Set(fv)
+	logger.Warn().Str("field", path).Str("replacement", target).Msg("config: deprecated field is set, use its replacement")
+	return nil
+}
+
+// fieldByPath returns the field at the dotted yaml-name path below root,
+// allocating nil struct pointers on the way.
+func fieldByPath(root reflect.Value, path string) (reflect.Value, error) {
+	v := root
+	for _, name := range strings.Split(path, ".") {
+		if v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		if v.Kind() != reflect.Struct {
+			return reflect.Value{}, fmt.Errorf("no field %s", path)
+		}
+		next, ok := fieldByName(v, name)
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("no field %s", path)
+		}
+		v = next
+	}
+	return v, nil
+}
+
+// fieldByName finds the field of struct v whose configuration name is name,
+// looking through inlined embedded structs.
+func fieldByName(v reflect.Value, name string) (reflect.Value, bool) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && isInline(field) && v.Field(i).Kind() == reflect.Struct {
+			if f, ok := fieldByName(v.Field(i), name); ok {
+				return f, true
+			}
+			continue
+		}
+		if field.IsExported() && fieldName(field) == name {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
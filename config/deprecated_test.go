@@ -0,0 +1,134 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/planx-lab/planx-common/logger/logtest"
+	"github.com/rs/zerolog"
+)
+
+type deprecatedConfig struct {
+	Sink *struct {
+		Endpoint string `yaml:"endpoint" json:"endpoint"`
+	} `yaml:"sink" json:"sink"`
+	SinkURL  string `yaml:"sink_url" json:"sink_url" deprecated:"use sink.endpoint"`
+	Legacy   bool   `yaml:"legacy" json:"legacy" deprecated:"has no effect since v2"`
+	Workers  int    `yaml:"workers" json:"workers"`
+	Threads  int    `yaml:"threads" json:"threads" deprecated:"use workers"`
+	Unmapped string `yaml:"unmapped" deprecated:"use nowhere.at_all"`
+}
+
+func TestDeprecated_MapsOntoReplacement(t *testing.T) {
+	rec := logtest.Capture(t)
+	var cfg deprecatedConfig
+	if err := ParseYAML([]byte("sink_url: http://old\nlegacy: true\nthreads: 4\n"), &cfg); err != nil {
+		t.Fatalf("ParseYAML: %v", err)
+	}
+	if cfg.Sink == nil || cfg.Sink.Endpoint != "http://old" || cfg.Workers != 4 {
+		t.Fatalf("got %+v", cfg)
+	}
+
+	entries := rec.Find(zerolog.WarnLevel, "deprecated field is set")
+	if len(entries) != 3 {
+		t.Fatalf("got %d warnings", len(entries))
+	}
+	if entries[0].Str("field") != "sink_url" || entries[0].Str("replacement") != "sink.endpoint" {
+		t.Errorf("got %+v", entries[0].Fields)
+	}
+	if entries[1].Str("reason") != "has no effect since v2" {
+		t.Errorf("got %+v", entries[1].Fields)
+	}
+}
+
+func TestDeprecated_ReplacementWins(t *testing.T) {
+	rec := logtest.Capture(t)
+	var cfg deprecatedConfig
+	if err := ParseJSON([]byte(`{"threads":4,"workers":8}`), &cfg); err != nil {
+		t.Fatalf("ParseJSON: %v", err)
+	}
+	if cfg.Workers != 8 {
+		t.Fatalf("got %d", cfg.Workers)
+	}
+	rec.AssertLogged(zerolog.WarnLevel, "along with its replacement")
+}
+
+func TestDeprecated_Quiet(t *testing.T) {
+	rec := logtest.Capture(t)
+	var cfg deprecatedConfig
+	if err := ParseYAML([]byte("workers: 2\n"), &cfg); err != nil {
+		t.Fatalf("ParseYAML: %v", err)
+	}
+	rec.AssertNotLogged(zerolog.WarnLevel, "deprecated")
+}
+
+func TestDeprecated_BadTarget(t *testing.T) {
+	var cfg deprecatedConfig
+	err := ParseYAML([]byte("unmapped: x\n"), &cfg)
+	if err == nil || !strings.Contains(err.Error(), "no field nowhere.at_all") {
+		t.Fatalf("got %v", err)
+	}
+	var mismatch struct {
+		Workers int    `yaml:"workers"`
+		Old     string `yaml:"old" deprecated:"use workers"`
+	}
+	if err := ParseYAML([]byte("old: x\n"), &mismatch); err == nil {
+		t.Fatal("expected a type mismatch error")
+	}
+}
+
+type defaultedConfig struct {
+	Workers int `yaml:"workers" json:"workers" toml:"workers" env:"WORKERS" default:"2"`
+	Threads int `yaml:"threads" json:"threads" toml:"threads" env:"THREADS" deprecated:"use workers"`
+}
+
+func TestDeprecated_DefaultedReplacement(t *testing.T) {
+	parsers := map[string]struct {
+		parse     func([]byte, interface{}) error
+		old, both string
+	}{
+		"yaml":   {ParseYAML, "threads: 4\n", "threads: 4\nworkers: 8\n"},
+		"json":   {ParseJSON, `{"threads":4}`, `{"threads":4,"workers":8}`},
+		"toml":   {ParseTOML, "threads = 4\n", "threads = 4\nworkers = 8\n"},
+		"dotenv": {ParseDotEnv, "THREADS=4\n", "THREADS=4\nWORKERS=8\n"},
+	}
+	for name, p := range parsers {
+		t.Run(name, func(t *testing.T) {
+			rec := logtest.Capture(t)
+			var cfg defaultedConfig
+			if err := p.parse([]byte(p.old), &cfg); err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			if cfg.Workers != 4 {
+				t.Fatalf("workers = %d, want the deprecated value 4", cfg.Workers)
+			}
+			rec.AssertNotLogged(zerolog.WarnLevel, "along with its replacement")
+
+			cfg = defaultedConfig{}
+			if err := p.parse([]byte(p.both), &cfg); err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			if cfg.Workers != 8 {
+				t.Fatalf("workers = %d, want 8", cfg.Workers)
+			}
+			rec.AssertLogged(zerolog.WarnLevel, "along with its replacement")
+		})
+	}
+}
+
+func TestDeprecated_DefaultedReplacementInclude(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(path, []byte("threads: 4\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	var cfg defaultedConfig
+	if err := LoadYAML(path, &cfg); err != nil {
+		t.Fatalf("LoadYAML: %v", err)
+	}
+	if cfg.Workers != 4 {
+		t.Fatalf("workers = %d, want 4", cfg.Workers)
+	}
+}
@@ -0,0 +1,149 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/planx-lab/planx-common/logger"
+)
+
+// Change is one field that differs between two configurations. Old and New
+// are in the form Dump writes, so secret fields show RedactedValue and a
+// field that was added or removed has a nil Old or New.
+type Change struct {
+	Path string      `json:"path"`
+	Old  interface{} `json:"old"`
+	New  interface{} `json:"new"`
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %v -> %v", c.Path, c.Old, c.New)
+}
+
+// Diff returns the leaf-level changes from old to new, two values of the
+// same configuration type, in field order. Paths use the yaml (else json)
+// names, with list indexes and typed map keys in brackets
+// ("sinks[0].endpoint", "labels[env]"); keys of generic maps such as Map are
+// dotted ("sink.http.endpoint"). Fields tagged `secret:"true"` are compared on their real
+// values but reported redacted, so a rotated credential shows up as a change
+// without leaking. Diff returns nil if nothing changed.
+func Diff(old, new interface{}) []Change {
+	var changes []Change
+	diffValues("", reflect.ValueOf(old), reflect.ValueOf(new), false, &changes)
+	return changes
+}
+
+// LogChanges logs each change at info level, for auditing reloads.
+func LogChanges(changes []Change) {
+	for _, c := range changes {
+		logger.Info().
+			Str("path", c.Path).
+			Interface("old", c.Old).
+			Interface("new", c.New).
+			Msg("config changed")
+	}
+}
+
+func diffValues(path string, a, b reflect.Value, secret bool, out *[]Change) {
+	if secret {
+		if !reflect.DeepEqual(interfaceOf(a), interfaceOf(b)) {
+			*out = append(*out, Change{path, redact(a), redact(b)})
+		}
+		return
+	}
+	a, b = indirect(a), indirect(b)
+	if !a.IsValid() || !b.IsValid() || a.Type() != b.Type() || isLeaf(a) {
+		da, db := dumpValue(a), dumpValue(b)
+		if !reflect.DeepEqual(da, db) {
+			*out = append(*out, Change{path, da, db})
+		}
+		return
+	}
+
+	switch a.Kind() {
+	case reflect.Struct:
+		t := a.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() || skipped(field) {
+				continue
+			}
+			fpath := path
+			if !field.Anonymous || !isInline(field) {
+				fpath = joinPath(path, fieldName(field))
+			}
+			diffValues(fpath, a.Field(i), b.Field(i), field.Tag.Get("secret") == "true", out)
+		}
+	case reflect.Map:
+		keys := map[string]reflect.Value{}
+		for _, k := range append(a.MapKeys(), b.MapKeys()...) {
+			keys[fmt.Sprint(k.Interface())] = k
+		}
+		names := make([]string, 0, len(keys))
+		for name := range keys {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		// Generic subtrees (config.Map and the like) read as dotted paths;
+		// typed maps such as labels use brackets.
+		dotted := a.Type().Elem().Kind() == reflect.Interface
+		for _, name := range names {
+			k := keys[name]
+			kpath := fmt.Sprintf("%s[%s]", path, name)
+			if dotted {
+				kpath = joinPath(path, name)
+			}
+			diffValues(kpath, a.MapIndex(k), b.MapIndex(k), false, out)
+		}
+	case reflect.Slice, reflect.Array:
+		n := max(a.Len(), b.Len())
+		for i := 0; i < n; i++ {
+			var ea, eb reflect.Value
+			if i < a.Len() {
+				ea = a.Index(i)
+			}
+			if i < b.Len() {
+				eb = b.Index(i)
+			}
+			diffValues(fmt.Sprintf("%s[%d]", path, i), ea, eb, false, out)
+		}
+	}
+}
+
+// indirect follows pointers and interfaces, returning the zero Value for nil.
+func indirect(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// isLeaf reports whether v is compared as a whole rather than walked.
+func isLeaf(v reflect.Value) bool {
+	if v.Type() == durationType || v.Type().Implements(textMarshalerType) {
+		return true
+	}
+	switch v.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+		return false
+	}
+	return true
+}
+
+func interfaceOf(v reflect.Value) interface{} {
+	if v = indirect(v); !v.IsValid() {
+		return nil
+	}
+	return v.Interface()
+}
+
+func redact(v reflect.Value) interface{} {
+	if v = indirect(v); !v.IsValid() || v.IsZero() {
+		return nil
+	}
+	return RedactedValue
+}
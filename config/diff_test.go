@@ -0,0 +1,83 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/planx-lab/planx-common/logger/logtest"
+	"github.com/rs/zerolog"
+)
+
+type diffSink struct {
+	Endpoint string `yaml:"endpoint"`
+	Token    string `yaml:"token" secret:"true"`
+}
+
+type diffConfig struct {
+	DumpCommon `yaml:",inline"`
+	Timeout    time.Duration     `yaml:"timeout"`
+	Sinks      []diffSink        `yaml:"sinks"`
+	Labels     map[string]string `yaml:"labels"`
+	TLS        *struct {
+		CertFile string `yaml:"cert_file"`
+	} `yaml:"tls"`
+}
+
+func TestDiff(t *testing.T) {
+	old := diffConfig{
+		Timeout: time.Second,
+		Sinks:   []diffSink{{Endpoint: "http://a", Token: "t1"}, {Endpoint: "http://b"}},
+		Labels:  map[string]string{"env": "dev", "team": "core"},
+	}
+	old.Name = "engine"
+	new := diffConfig{
+		Timeout: 2 * time.Second,
+		Sinks:   []diffSink{{Endpoint: "http://a", Token: "t2"}},
+		Labels:  map[string]string{"env": "prod", "zone": "b"},
+	}
+	new.Name = "engine"
+	new.TLS = &struct {
+		CertFile string `yaml:"cert_file"`
+	}{CertFile: "/tls.crt"}
+
+	got := Diff(old, &new)
+	want := []string{
+		"timeout: 1s -> 2s",
+		"sinks[0].token: [REDACTED] -> [REDACTED]",
+		"sinks[1]: [{endpoint http://b} {token <nil>}] -> <nil>",
+		"labels[env]: dev -> prod",
+		"labels[team]: core -> <nil>",
+		"labels[zone]: <nil> -> b",
+		"tls: <nil> -> [{cert_file /tls.crt}]",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %v", got)
+	}
+	for i := range want {
+		if got[i].String() != want[i] {
+			t.Errorf("change %d: got %q, want %q", i, got[i], want[i])
+		}
+	}
+
+	if changes := Diff(old, old); changes != nil {
+		t.Fatalf("expected no changes, got %v", changes)
+	}
+}
+
+func TestDiff_Map(t *testing.T) {
+	old := Map{"sink": map[string]interface{}{"retries": 3, "hosts": []interface{}{"a"}}}
+	new := Map{"sink": map[string]interface{}{"retries": "3", "hosts": []interface{}{"a", "b"}}}
+	got := Diff(old, new)
+	if len(got) != 2 || got[0].Path != "sink.hosts[1]" || got[1].Path != "sink.retries" {
+		t.Fatalf("got %v", got)
+	}
+}
+
+func TestLogChanges(t *testing.T) {
+	rec := logtest.Capture(t)
+	LogChanges([]Change{{Path: "timeout", Old: "1s", New: "2s"}})
+	entries := rec.Find(zerolog.InfoLevel, "config changed")
+	if len(entries) != 1 || entries[0].Str("path") != "timeout" || entries[0].Str("new") != "2s" {
+		t.Fatalf("got %+v", entries)
+	}
+}
@@ -0,0 +1,111 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// LoadDotEnv loads a .env file into the given struct. Each KEY=VALUE line
+// sets the field tagged `env:"KEY"`, as LoadWithEnv does with the process
+// environment (which LoadDotEnv does not read or modify). Keys without a
+// tagged field are ignored. Defaults are applied first, see ApplyDefaults.
+func LoadDotEnv(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return ParseDotEnv(data, v)
+}
+
+// ParseDotEnv parses .env bytes into the given struct. See LoadDotEnv.
+func ParseDotEnv(data []byte, v interface{}) error {
+	rv, err := structPtr(v)
+	if err != nil {
+		return err
+	}
+	vars, err := parseDotEnv(data)
+	if err != nil {
+		return err
+	}
+	if err := applyDefaults(rv, ""); err != nil {
+		return err
+	}
+	lookup := func(key string) (string, bool) {
+		val, ok := vars[key]
+		return val, ok
+	}
+	if _, err := applyEnv(rv, "", lookup); err != nil {
+		return err
+	}
+	return applyDeprecations(v, func(p interface{}) error {
+		_, err := applyEnv(reflect.ValueOf(p).Elem(), "", lookup)
+		return err
+	})
+}
+
+// parseDotEnv reads KEY=VALUE lines. Blank lines and lines starting with #
+// are skipped, and an optional "export " prefix is allowed. Values may be
+// single-quoted (taken literally) or double-quoted (with \n, \t, \" and \\
+// escapes); unquoted values end at " #".
+func parseDotEnv(data []byte) (map[string]string, error) {
+	vars := map[string]string{}
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for lineNo := 1; sc.Scan(); lineNo++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		key, val, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("config: .env line %d: expected KEY=VALUE", lineNo)
+		}
+		val, err := dotEnvValue(strings.TrimSpace(val))
+		if err != nil {
+			return nil, fmt.Errorf("config: .env line %d: %w", lineNo, err)
+		}
+		vars[key] = val
+	}
+	return vars, sc.Err()
+}
+
+func dotEnvValue(val string) (string, error) {
+	switch {
+	case strings.HasPrefix(val, `"`):
+		end := closingQuote(val)
+		if end < 0 {
+			return "", fmt.Errorf("unterminated double quote")
+		}
+		return strconv.Unquote(val[:end+1])
+	case strings.HasPrefix(val, "'"):
+		end := strings.IndexByte(val[1:], '\'')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated single quote")
+		}
+		return val[1 : end+1], nil
+	}
+	if i := strings.Index(val, " #"); i >= 0 {
+		val = val[:i]
+	}
+	return strings.TrimSpace(val), nil
+}
+
+// closingQuote returns the index of the double quote closing the one at
+// val[0], or -1.
+func closingQuote(val string) int {
+	for i := 1; i < len(val); i++ {
+		switch val[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+type dotEnvConfig struct {
+	Endpoint string        `env:"SINK_ENDPOINT"`
+	Timeout  time.Duration `env:"SINK_TIMEOUT" default:"10s"`
+	Token    string        `env:"TOKEN"`
+	Banner   string        `env:"BANNER"`
+	Raw      string        `env:"RAW"`
+	Workers  int           `env:"WORKERS" default:"2"`
+}
+
+func TestParseDotEnv(t *testing.T) {
+	data := []byte(`
+# sink settings
+SINK_ENDPOINT=http://sink:4317 # trailing comment
+export TOKEN="s3cr#t"
+BANNER="hello\nworld"
+RAW='no $expansion \n here'
+UNUSED=ignored
+`)
+	var cfg dotEnvConfig
+	if err := ParseDotEnv(data, &cfg); err != nil {
+		t.Fatalf("ParseDotEnv: %v", err)
+	}
+	want := dotEnvConfig{
+		Endpoint: "http://sink:4317",
+		Timeout:  10 * time.Second,
+		Token:    "s3cr#t",
+		Banner:   "hello\nworld",
+		Raw:      `no $expansion \n here`,
+		Workers:  2,
+	}
+	if cfg != want {
+		t.Fatalf("got %+v, want %+v", cfg, want)
+	}
+}
+
+func TestParseDotEnv_Errors(t *testing.T) {
+	for _, data := range []string{
+		"NOVALUE\n",
+		"=value\n",
+		`KEY="open` + "\n",
+		"KEY='open\n",
+		"WORKERS=many\n",
+	} {
+		var cfg dotEnvConfig
+		if err := ParseDotEnv([]byte(data), &cfg); err == nil {
+			t.Errorf("%q: expected an error", data)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package config
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RedactedValue replaces the value of secret fields in dumps.
+const RedactedValue = "[REDACTED]"
+
+// Dump writes the effective configuration v as YAML, for startup logs and
+// support bundles. Fields tagged `secret:"true"` are replaced by
+// RedactedValue unless empty, and passwords embedded in URLs are masked:
+//
+//	type SinkConfig struct {
+//		Endpoint string `yaml:"endpoint"`
+//		Token    string `yaml:"token" secret:"true"`
+//	}
+//
+// Keys follow the yaml (else json) names in field order; Duration, ByteSize
+// and other encoding.TextMarshaler values are written in their text form.
+func Dump(v interface{}, w io.Writer) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(dumpValue(reflect.ValueOf(v))); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// DumpJSON is Dump with indented JSON output.
+func DumpJSON(v interface{}, w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(dumpValue(reflect.ValueOf(v)))
+}
+
+// DebugHandler serves the redacted configuration v as JSON, for a
+// /debug/config endpoint. v is read on every request, so pass the pointer
+// the engine reloads into.
+func DebugHandler(v interface{}) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		var buf bytes.Buffer
+		if err := DumpJSON(v, &buf); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(buf.Bytes())
+	})
+}
+
+// dumpField and dumpObject keep struct fields in declaration order, which
+// maps would lose.
+type dumpField struct {
+	key string
+	val interface{}
+}
+
+type dumpObject []dumpField
+
+func (o dumpObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, f := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(f.key)
+		if err != nil {
+			return nil, err
+		}
+		val, err := json.Marshal(f.val)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(val)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func (o dumpObject) MarshalYAML() (interface{}, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	for _, f := range o {
+		var val yaml.Node
+		if err := val.Encode(f.val); err != nil {
+			return nil, err
+		}
+		node.Content = append(node.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Value: f.key}, &val)
+	}
+	return node, nil
+}
+
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+// dumpValue converts v into plain values, dumpObjects and slices with
+// secrets redacted.
+func dumpValue(v reflect.Value) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+	}
+	switch x := v.Interface().(type) {
+	case URL:
+		return x.Redacted()
+	case *URL:
+		return x.Redacted()
+	case url.URL:
+		return x.Redacted()
+	case *url.URL:
+		return x.Redacted()
+	}
+	if v.Type() == durationType {
+		return fmt.Sprint(v.Interface())
+	}
+	if v.Type().Implements(textMarshalerType) {
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return fmt.Sprintf("!error: %v", err)
+		}
+		return string(text)
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return dumpValue(v.Elem())
+	case reflect.Struct:
+		return dumpStruct(v)
+	case reflect.Map:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+		})
+		obj := make(dumpObject, 0, len(keys))
+		for _, k := range keys {
+			obj = append(obj, dumpField{fmt.Sprint(k.Interface()), dumpValue(v.MapIndex(k))})
+		}
+		return obj
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		out := make([]interface{}, v.Len())
+		for i := range out {
+			out[i] = dumpValue(v.Index(i))
+		}
+		return out
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		return nil
+	}
+	return v.Interface()
+}
+
+func dumpStruct(v reflect.Value) dumpObject {
+	t := v.Type()
+	obj := dumpObject{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if skipped(field) {
+			continue
+		}
+		fv := v.Field(i)
+		if field.Tag.Get("secret") == "true" {
+			var val interface{}
+			if !fv.IsZero() {
+				val = RedactedValue
+			}
+			obj = append(obj, dumpField{fieldName(field), val})
+			continue
+		}
+		val := dumpValue(fv)
+		if inner, ok := val.(dumpObject); ok && field.Anonymous && isInline(field) {
+			obj = append(obj, inner...)
+			continue
+		}
+		obj = append(obj, dumpField{fieldName(field), val})
+	}
+	return obj
+}
+
+// skipped reports whether field is excluded from decoding by a "-" tag.
+func skipped(field reflect.StructField) bool {
+	if tag, ok := field.Tag.Lookup("yaml"); ok {
+		return tag == "-"
+	}
+	return field.Tag.Get("json") == "-"
+}
+
+// isInline reports whether the embedded field is flattened when decoding.
+func isInline(field reflect.StructField) bool {
+	if tag, ok := field.Tag.Lookup("yaml"); ok {
+		_, opts, _ := strings.Cut(tag, ",")
+		return opts == "inline"
+	}
+	return field.Tag.Get("json") == ""
+}
@@ -0,0 +1,143 @@
+package config
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// EncryptedPrefix marks an encrypted configuration value:
+//
+//	password: enc:aesgcm:2x0bY3...
+//	token: enc:kms:AQICAHh...
+//
+// The second segment names the Decrypter and the rest is the base64
+// (standard encoding) ciphertext.
+const EncryptedPrefix = "enc:"
+
+// Decrypter decrypts configuration values, e.g. with a KMS key or age
+// identities.
+type Decrypter interface {
+	Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error)
+}
+
+// DecrypterFunc adapts a function to the Decrypter interface.
+type DecrypterFunc func(ctx context.Context, ciphertext []byte) ([]byte, error)
+
+// Decrypt calls f.
+func (f DecrypterFunc) Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	return f(ctx, ciphertext)
+}
+
+var (
+	decryptersMu sync.RWMutex
+	decrypters   = map[string]Decrypter{}
+)
+
+// RegisterDecrypter makes ResolveSecrets decrypt values of the form
+// "enc:name:<base64>" with d. No decrypter is registered by default, since
+// each needs a key; the engine registers the ones its deployment uses:
+//
+//	key, _ := os.ReadFile("/run/secrets/config-key")
+//	d, err := config.NewAESGCMDecrypter(key)
+//	...
+//	config.RegisterDecrypter("aesgcm", d)
+//
+// age or a cloud KMS plug in the same way, by wrapping their client in a
+// Decrypter. Like RegisterSecretResolver it panics if name is empty or
+// already registered.
+func RegisterDecrypter(name string, d Decrypter) {
+	if name == "" || d == nil {
+		panic("config: RegisterDecrypter with empty name or nil decrypter")
+	}
+	decryptersMu.Lock()
+	defer decryptersMu.Unlock()
+	if _, dup := decrypters[name]; dup {
+		panic(fmt.Sprintf("config: decrypter %q registered twice", name))
+	}
+	decrypters[name] = d
+}
+
+// Decrypters returns the registered decrypter names, sorted.
+func Decrypters() []string {
+	decryptersMu.RLock()
+	defer decryptersMu.RUnlock()
+	out := make([]string, 0, len(decrypters))
+	for name := range decrypters {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// decryptValue decrypts an EncryptedPrefix value. Unlike unregistered
+// secret schemes, an unknown decrypter is an error: the value is known to
+// be ciphertext and must not be used as is.
+func decryptValue(ctx context.Context, s string) (string, error) {
+	name, data, ok := strings.Cut(strings.TrimPrefix(s, EncryptedPrefix), ":")
+	if !ok || name == "" {
+		return "", fmt.Errorf("want %sname:<base64>", EncryptedPrefix)
+	}
+	decryptersMu.RLock()
+	d := decrypters[name]
+	decryptersMu.RUnlock()
+	if d == nil {
+		return "", fmt.Errorf("no decrypter registered for %q", name)
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return "", fmt.Errorf("invalid base64: %w", err)
+	}
+	plaintext, err := d.Decrypt(ctx, ciphertext)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// NewAESGCMDecrypter returns a Decrypter for values sealed by EncryptAESGCM
+// with the same 16, 24 or 32 byte key. It needs only the standard library,
+// for deployments that want encrypted values in git without running a KMS.
+func NewAESGCMDecrypter(key []byte) (Decrypter, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	return DecrypterFunc(func(_ context.Context, ciphertext []byte) ([]byte, error) {
+		n := aead.NonceSize()
+		if len(ciphertext) < n {
+			return nil, fmt.Errorf("ciphertext too short")
+		}
+		return aead.Open(nil, ciphertext[:n], ciphertext[n:], nil)
+	}), nil
+}
+
+// EncryptAESGCM seals plaintext with key and returns the value to put in a
+// configuration file, "enc:aesgcm:<base64>". It is meant for tooling; the
+// engine only decrypts.
+func EncryptAESGCM(key, plaintext []byte) (string, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, plaintext, nil)
+	return EncryptedPrefix + "aesgcm:" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
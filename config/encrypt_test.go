@@ -0,0 +1,79 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var testKey = bytes.Repeat([]byte{7}, 32)
+
+func init() {
+	d, err := NewAESGCMDecrypter(testKey)
+	if err != nil {
+		panic(err)
+	}
+	RegisterDecrypter("aesgcm", d)
+	RegisterDecrypter("testkms", DecrypterFunc(func(_ context.Context, ciphertext []byte) ([]byte, error) {
+		if !bytes.HasPrefix(ciphertext, []byte("kms:")) {
+			return nil, fmt.Errorf("not a kms blob")
+		}
+		return bytes.TrimPrefix(ciphertext, []byte("kms:")), nil
+	}))
+}
+
+func TestEncryptedValues(t *testing.T) {
+	sealed, err := EncryptAESGCM(testKey, []byte("db-password"))
+	if err != nil {
+		t.Fatalf("EncryptAESGCM: %v", err)
+	}
+	if !strings.HasPrefix(sealed, "enc:aesgcm:") {
+		t.Fatalf("got %q", sealed)
+	}
+
+	path := filepath.Join(t.TempDir(), "engine.yaml")
+	content := "password: " + sealed + "\ntoken: enc:testkms:a21zOnRvaw==\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	var cfg struct {
+		Password string `yaml:"password"`
+		Token    string `yaml:"token"`
+	}
+	if err := Load(path, &cfg); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Password != "db-password" || cfg.Token != "tok" {
+		t.Fatalf("got %+v", cfg)
+	}
+}
+
+func TestEncryptedValues_Errors(t *testing.T) {
+	otherKey := bytes.Repeat([]byte{9}, 32)
+	wrongKey, err := EncryptAESGCM(otherKey, []byte("x"))
+	if err != nil {
+		t.Fatalf("EncryptAESGCM: %v", err)
+	}
+	for _, value := range []string{
+		wrongKey,
+		"enc:unknown:eA==",
+		"enc:aesgcm:not base64",
+		"enc:aesgcm:AAAA",
+		"enc:",
+	} {
+		cfg := struct {
+			Password string `yaml:"password"`
+		}{value}
+		err := ResolveSecrets(context.Background(), &cfg)
+		if err == nil || !strings.Contains(err.Error(), "password: decrypt") {
+			t.Errorf("%q: got %v", value, err)
+		}
+	}
+	if _, err := NewAESGCMDecrypter([]byte("short")); err == nil {
+		t.Fatal("expected an error for a bad key size")
+	}
+}
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/planx-lab/planx-common/env"
+	"github.com/planx-lab/planx-common/errors"
+	"github.com/planx-lab/planx-common/internal/reflectset"
+)
+
+// LoadWithEnv loads path into v with Load and then overrides fields from
+// the environment, 12-factor style. Fields tagged `env:"NAME"` are read from
+// prefix+NAME; nested structs are walked, so with prefix "PLANX_":
+//
+//	type Config struct {
+//		Sink struct {
+//			Endpoint string `yaml:"endpoint" env:"SINK_ENDPOINT"` // PLANX_SINK_ENDPOINT
+//		} `yaml:"sink"`
+//	}
+//
+// Unset variables leave the file value in place; set but empty variables
+// override it. Secret references, including ones set through the
+// environment, are resolved last; see ResolveSecrets. v must be a pointer to
+// a struct.
+func LoadWithEnv(path string, v interface{}, prefix string) error {
+	if err := loadFile(path, v); err != nil {
+		return err
+	}
+	if err := ApplyEnv(v, prefix); err != nil {
+		return err
+	}
+	return ResolveSecrets(context.Background(), v)
+}
+
+// ApplyEnv overrides the fields of v tagged `env:"NAME"` from the
+// environment variables prefix+NAME. See LoadWithEnv.
+func ApplyEnv(v interface{}, prefix string) error {
+	rv, err := structPtr(v)
+	if err != nil {
+		return err
+	}
+	_, err = applyEnv(rv, prefix, env.Lookup)
+	return err
+}
+
+// applyEnv sets the tagged fields of rv and reports whether any was set.
+func applyEnv(rv reflect.Value, prefix string, lookup func(string) (string, bool)) (bool, error) {
+	changed := false
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fv := rv.Field(i)
+
+		if name, ok := field.Tag.Lookup("env"); ok && name != "-" {
+			val, set := lookup(prefix + name)
+			if !set {
+				continue
+			}
+			if err := reflectset.Set(fv, val); err != nil {
+				return changed, errors.WrapConfigError(reflectset.Redact(err), "config: "+prefix+name+" is invalid").WithField("env", prefix+name)
+			}
+			changed = true
+			continue
+		}
+
+		switch {
+		case fv.Kind() == reflect.Struct:
+			c, err := applyEnv(fv, prefix, lookup)
+			changed = changed || c
+			if err != nil {
+				return changed, err
+			}
+		case fv.Kind() == reflect.Ptr && fv.Type().Elem().Kind() == reflect.Struct:
+			// Only allocate an optional section if a variable targets it.
+			target := fv
+			if fv.IsNil() {
+				target = reflect.New(fv.Type().Elem())
+			}
+			c, err := applyEnv(target.Elem(), prefix, lookup)
+			if err != nil {
+				return changed, err
+			}
+			if c && fv.IsNil() {
+				fv.Set(target)
+			}
+			changed = changed || c
+		}
+	}
+	return changed, nil
+}
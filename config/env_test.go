@@ -0,0 +1,101 @@
+package config
+
+import (
+	stderrors "errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/planx-lab/planx-common/errors"
+)
+
+type envSink struct {
+	Endpoint string        `yaml:"endpoint" env:"SINK_ENDPOINT"`
+	Timeout  time.Duration `yaml:"timeout" env:"SINK_TIMEOUT"`
+	Hosts    []string      `yaml:"hosts" env:"SINK_HOSTS"`
+}
+
+type envConfig struct {
+	Name    string   `yaml:"name" json:"name" env:"NAME"`
+	Workers int      `yaml:"workers" json:"workers" env:"WORKERS"`
+	Debug   bool     `yaml:"debug" json:"debug" env:"DEBUG"`
+	Ratio   *float64 `yaml:"ratio" json:"ratio" env:"RATIO"`
+	Ignored string   `yaml:"ignored" env:"-"`
+	Sink    envSink  `yaml:"sink"`
+	TLS     *struct {
+		CertFile string `yaml:"cert_file" env:"TLS_CERT_FILE"`
+	} `yaml:"tls"`
+	Metrics *struct {
+		Addr string `yaml:"addr" env:"METRICS_ADDR"`
+	} `yaml:"metrics"`
+}
+
+func TestLoadWithEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "engine.yaml")
+	content := []byte("name: file\nworkers: 2\nsink:\n  endpoint: http://file\n  timeout: 1s\n")
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	t.Setenv("PLANX_WORKERS", "8")
+	t.Setenv("PLANX_DEBUG", "true")
+	t.Setenv("PLANX_RATIO", "0.5")
+	t.Setenv("PLANX_SINK_ENDPOINT", "http://env")
+	t.Setenv("PLANX_SINK_TIMEOUT", "30s")
+	t.Setenv("PLANX_SINK_HOSTS", "a, b")
+	t.Setenv("PLANX_TLS_CERT_FILE", "/etc/tls.crt")
+	t.Setenv("PLANX_-", "nope")
+
+	var cfg envConfig
+	if err := LoadWithEnv(path, &cfg, "PLANX_"); err != nil {
+		t.Fatalf("LoadWithEnv: %v", err)
+	}
+	if cfg.Name != "file" {
+		t.Errorf("unset variable should keep the file value, got %q", cfg.Name)
+	}
+	if cfg.Workers != 8 || !cfg.Debug || cfg.Ratio == nil || *cfg.Ratio != 0.5 {
+		t.Errorf("unexpected top-level overrides %+v", cfg)
+	}
+	if cfg.Sink.Endpoint != "http://env" || cfg.Sink.Timeout != 30*time.Second {
+		t.Errorf("unexpected sink %+v", cfg.Sink)
+	}
+	if len(cfg.Sink.Hosts) != 2 || cfg.Sink.Hosts[1] != "b" {
+		t.Errorf("unexpected hosts %q", cfg.Sink.Hosts)
+	}
+	if cfg.TLS == nil || cfg.TLS.CertFile != "/etc/tls.crt" {
+		t.Errorf("expected TLS section to be allocated, got %+v", cfg.TLS)
+	}
+	if cfg.Metrics != nil {
+		t.Error("untouched optional section should stay nil")
+	}
+}
+
+func TestLoadWithEnv_JSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "engine.json")
+	if err := os.WriteFile(path, []byte(`{"name":"file","workers":1}`), 0644); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	t.Setenv("APP_NAME", "env")
+
+	var cfg envConfig
+	if err := LoadWithEnv(path, &cfg, "APP_"); err != nil {
+		t.Fatalf("LoadWithEnv: %v", err)
+	}
+	if cfg.Name != "env" || cfg.Workers != 1 {
+		t.Fatalf("got %+v", cfg)
+	}
+}
+
+func TestApplyEnv_Errors(t *testing.T) {
+	t.Setenv("X_WORKERS", "s3cret")
+	var cfg envConfig
+	err := ApplyEnv(&cfg, "X_")
+	var cfgErr *errors.ConfigError
+	if !stderrors.As(err, &cfgErr) || !strings.Contains(err.Error(), "X_WORKERS is invalid") || strings.Contains(err.Error(), "s3cret") {
+		t.Fatalf("got %v, want a config error naming the variable without its value", err)
+	}
+	if err := ApplyEnv(cfg, "X_"); err == nil {
+		t.Fatal("expected an error for a non-pointer")
+	}
+}
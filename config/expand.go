@@ -0,0 +1,91 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/planx-lab/planx-common/env"
+)
+
+// LoadYAMLExpanded loads a YAML file like LoadYAML after substituting
+// environment placeholders (see ExpandEnv), including in included files.
+func LoadYAMLExpanded(path string, v interface{}) error {
+	doc, err := readYAMLFile(path, ExpandEnv)
+	if err != nil {
+		return err
+	}
+	return decodeYAMLNode(doc, v)
+}
+
+// LoadJSONExpanded loads a JSON file like LoadJSON after substituting
+// environment placeholders (see ExpandEnv). Substituted values are inserted
+// verbatim, so values containing quotes must be escaped in the environment.
+func LoadJSONExpanded(path string, v interface{}) error {
+	data, err := readExpanded(path)
+	if err != nil {
+		return err
+	}
+	return ParseJSON(data, v)
+}
+
+func readExpanded(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err = ExpandEnv(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return data, nil
+}
+
+// ExpandEnv substitutes placeholders in data with environment variables:
+//
+//	${VAR}          value of VAR, empty if unset
+//	${VAR:-default} value of VAR, or default if VAR is unset or empty
+//	$${             a literal "${"
+//
+// A "$" not followed by "{" is left as is, so plain dollar signs in
+// passwords survive. An unterminated placeholder is an error.
+func ExpandEnv(data []byte) ([]byte, error) {
+	return expand(data, env.Lookup)
+}
+
+func expand(data []byte, lookup func(string) (string, bool)) ([]byte, error) {
+	if !bytes.Contains(data, []byte("${")) {
+		return data, nil
+	}
+	var out bytes.Buffer
+	out.Grow(len(data))
+	for i := 0; i < len(data); {
+		if bytes.HasPrefix(data[i:], []byte("$${")) {
+			out.WriteString("${")
+			i += 3
+			continue
+		}
+		if !bytes.HasPrefix(data[i:], []byte("${")) {
+			out.WriteByte(data[i])
+			i++
+			continue
+		}
+		end := bytes.IndexByte(data[i+2:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated placeholder at offset %d", i)
+		}
+		expr := string(data[i+2 : i+2+end])
+		name, def, hasDefault := strings.Cut(expr, ":-")
+		if name == "" {
+			return nil, fmt.Errorf("empty placeholder at offset %d", i)
+		}
+		val, ok := lookup(name)
+		if hasDefault && (!ok || val == "") {
+			val = def
+		}
+		out.WriteString(val)
+		i += 2 + end + 1
+	}
+	return out.Bytes(), nil
+}
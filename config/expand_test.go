@@ -0,0 +1,92 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExpand(t *testing.T) {
+	env := map[string]string{"HOST": "db", "EMPTY": ""}
+	lookup := func(k string) (string, bool) {
+		v, ok := env[k]
+		return v, ok
+	}
+	tests := []struct {
+		in, want string
+	}{
+		{"plain", "plain"},
+		{"host: ${HOST}", "host: db"},
+		{"host: ${MISSING}", "host: "},
+		{"port: ${PORT:-5432}", "port: 5432"},
+		{"x: ${EMPTY:-fallback}", "x: fallback"},
+		{"x: ${HOST:-fallback}", "x: db"},
+		{"url: ${MISSING:-http://a:1/b}", "url: http://a:1/b"},
+		{"pw: pa$$word$", "pw: pa$$word$"},
+		{"lit: $${HOST}", "lit: ${HOST}"},
+		{"${HOST}${HOST}", "dbdb"},
+	}
+	for _, tt := range tests {
+		got, err := expand([]byte(tt.in), lookup)
+		if err != nil {
+			t.Fatalf("expand(%q): %v", tt.in, err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("expand(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"x: ${HOST", "x: ${}", "x: ${:-d}"} {
+		if _, err := expand([]byte(bad), lookup); err == nil {
+			t.Errorf("expand(%q): expected an error", bad)
+		}
+	}
+}
+
+func TestLoadYAMLExpanded(t *testing.T) {
+	t.Setenv("PLANX_TEST_NAME", "planx")
+	path := filepath.Join(t.TempDir(), "test.yaml")
+	content := []byte("name: ${PLANX_TEST_NAME}\nversion: ${PLANX_TEST_VERSION:-4}\n")
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	var cfg testConfig
+	if err := LoadYAMLExpanded(path, &cfg); err != nil {
+		t.Fatalf("LoadYAMLExpanded: %v", err)
+	}
+	if cfg.Name != "planx" || cfg.Version != 4 {
+		t.Fatalf("got %+v", cfg)
+	}
+}
+
+func TestLoadJSONExpanded(t *testing.T) {
+	t.Setenv("PLANX_TEST_VERSION", "7")
+	path := filepath.Join(t.TempDir(), "test.json")
+	content := []byte(`{"name":"${PLANX_TEST_NAME:-planx}","version":${PLANX_TEST_VERSION}}`)
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	var cfg testConfig
+	if err := LoadJSONExpanded(path, &cfg); err != nil {
+		t.Fatalf("LoadJSONExpanded: %v", err)
+	}
+	if cfg.Name != "planx" || cfg.Version != 7 {
+		t.Fatalf("got %+v", cfg)
+	}
+}
+
+func TestLoadYAMLExpanded_Errors(t *testing.T) {
+	var cfg testConfig
+	if err := LoadYAMLExpanded("/nonexistent/path.yaml", &cfg); err == nil {
+		t.Fatal("expected error for missing file")
+	}
+	path := filepath.Join(t.TempDir(), "bad.yaml")
+	if err := os.WriteFile(path, []byte("name: ${UNTERMINATED\n"), 0644); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	if err := LoadYAMLExpanded(path, &cfg); err == nil {
+		t.Fatal("expected error for an unterminated placeholder")
+	}
+}
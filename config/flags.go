@@ -0,0 +1,150 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"reflect"
+
+	"github.com/planx-lab/planx-common/internal/reflectset"
+)
+
+// Flags is the set of command-line flags generated by BindFlags.
+type Flags struct {
+	root  reflect.Value
+	flags []*fieldFlag
+}
+
+// BindFlags defines a flag on fs for every field of v tagged `flag:"name"`,
+// with the help text from its `usage` tag and the default shown from its
+// `default` tag:
+//
+//	Endpoint string `yaml:"endpoint" env:"SINK_ENDPOINT" flag:"sink-endpoint" usage:"sink address"`
+//
+// Flags are parsed and checked by fs.Parse but only written to v by Apply,
+// so that they can win over the file and the environment whatever the
+// order of the calls, giving the precedence flags > env > file > defaults:
+//
+//	flags, err := config.BindFlags(fs, &cfg)
+//	...
+//	fs.Parse(os.Args[1:])
+//	config.LoadWithEnv(*path, &cfg, "PLANX_")
+//	flags.Apply()
+//
+// Nested structs and pointers to structs are walked. Flags that were not set
+// on the command line leave v alone. v must be a pointer to a struct.
+func BindFlags(fs *flag.FlagSet, v interface{}) (*Flags, error) {
+	rv, err := structPtr(v)
+	if err != nil {
+		return nil, err
+	}
+	f := &Flags{root: rv}
+	if err := f.bind(fs, rv.Type(), nil); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *Flags) bind(fs *flag.FlagSet, t reflect.Type, index []int) error {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fieldIndex := append(append([]int{}, index...), i)
+
+		if name, ok := field.Tag.Lookup("flag"); ok && name != "-" {
+			if fs.Lookup(name) != nil {
+				return fmt.Errorf("config: flag -%s defined twice", name)
+			}
+			ff := &fieldFlag{
+				index: fieldIndex,
+				typ:   field.Type,
+				def:   field.Tag.Get("default"),
+			}
+			if !reflectset.Supported(field.Type) {
+				return fmt.Errorf("config: flag -%s: unsupported type %s", name, field.Type)
+			}
+			fs.Var(ff, name, field.Tag.Get("usage"))
+			f.flags = append(f.flags, ff)
+			continue
+		}
+
+		ft := field.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct && !decodesItself(ft) {
+			if err := f.bind(fs, ft, fieldIndex); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Apply writes the flags set on the command line into v. Call it after
+// fs.Parse and after loading the file and environment.
+func (f *Flags) Apply() error {
+	for _, ff := range f.flags {
+		if !ff.set {
+			continue
+		}
+		if err := reflectset.Set(fieldByIndexAlloc(f.root, ff.index), ff.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fieldByIndexAlloc is reflect.Value.FieldByIndex, allocating nil struct
+// pointers on the way.
+func fieldByIndexAlloc(v reflect.Value, index []int) reflect.Value {
+	for _, x := range index {
+		if v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+// fieldFlag is the flag.Value for one tagged field. It records the
+// command-line value for Apply instead of writing v directly.
+type fieldFlag struct {
+	index []int
+	typ   reflect.Type
+	def   string
+	value string
+	set   bool
+}
+
+func (ff *fieldFlag) String() string {
+	if ff == nil {
+		return ""
+	}
+	if ff.set {
+		return ff.value
+	}
+	return ff.def
+}
+
+// Set checks s against the field type so fs.Parse reports bad values.
+func (ff *fieldFlag) Set(s string) error {
+	if err := reflectset.Set(reflect.New(ff.typ).Elem(), s); err != nil {
+		return err
+	}
+	ff.value, ff.set = s, true
+	return nil
+}
+
+// IsBoolFlag lets boolean fields be set with a bare -name.
+func (ff *fieldFlag) IsBoolFlag() bool {
+	t := ff.typ
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Bool
+}
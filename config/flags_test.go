@@ -0,0 +1,105 @@
+package config
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type flagConfig struct {
+	Name    string   `yaml:"name" env:"NAME" flag:"name" usage:"node name"`
+	Workers int      `yaml:"workers" env:"WORKERS" flag:"workers" default:"2"`
+	Debug   bool     `yaml:"debug" flag:"debug"`
+	Timeout Duration `yaml:"timeout" flag:"timeout" default:"5s"`
+	Sink    *struct {
+		Endpoint string   `yaml:"endpoint" flag:"sink-endpoint"`
+		Hosts    []string `yaml:"hosts" flag:"sink-hosts"`
+	} `yaml:"sink"`
+}
+
+func newFlagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet("engine", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	return fs
+}
+
+func TestBindFlags_Precedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "engine.yaml")
+	if err := os.WriteFile(path, []byte("name: file\nworkers: 3\ntimeout: 1s\n"), 0644); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	t.Setenv("PLANX_NAME", "env")
+	t.Setenv("PLANX_WORKERS", "4")
+
+	var cfg flagConfig
+	fs := newFlagSet()
+	flags, err := BindFlags(fs, &cfg)
+	if err != nil {
+		t.Fatalf("BindFlags: %v", err)
+	}
+	if err := fs.Parse([]string{"-workers=8", "-debug", "-sink-endpoint", "http://flag", "-sink-hosts=a,b"}); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if err := LoadWithEnv(path, &cfg, "PLANX_"); err != nil {
+		t.Fatalf("LoadWithEnv: %v", err)
+	}
+	if err := flags.Apply(); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+
+	if cfg.Name != "env" {
+		t.Errorf("env should win over file, got %q", cfg.Name)
+	}
+	if cfg.Workers != 8 || !cfg.Debug {
+		t.Errorf("flags should win over env, got %+v", cfg)
+	}
+	if cfg.Timeout.Std() != time.Second {
+		t.Errorf("unset flag should keep the file value, got %v", cfg.Timeout)
+	}
+	if cfg.Sink == nil || cfg.Sink.Endpoint != "http://flag" || len(cfg.Sink.Hosts) != 2 {
+		t.Errorf("got sink %+v", cfg.Sink)
+	}
+}
+
+func TestBindFlags_Usage(t *testing.T) {
+	var cfg flagConfig
+	fs := newFlagSet()
+	if _, err := BindFlags(fs, &cfg); err != nil {
+		t.Fatalf("BindFlags: %v", err)
+	}
+	f := fs.Lookup("workers")
+	if f == nil || f.DefValue != "2" {
+		t.Fatalf("got %+v", f)
+	}
+	if fs.Lookup("name").Usage != "node name" {
+		t.Fatalf("usage: got %q", fs.Lookup("name").Usage)
+	}
+}
+
+func TestBindFlags_Errors(t *testing.T) {
+	var cfg flagConfig
+	fs := newFlagSet()
+	if _, err := BindFlags(fs, &cfg); err != nil {
+		t.Fatalf("BindFlags: %v", err)
+	}
+	if err := fs.Parse([]string{"-workers=many"}); err == nil || !strings.Contains(err.Error(), "workers") {
+		t.Fatalf("expected a parse error, got %v", err)
+	}
+	if _, err := BindFlags(fs, &cfg); err == nil {
+		t.Fatal("expected an error for flags defined twice")
+	}
+
+	bad := struct {
+		Limits map[string]int `flag:"limits"`
+	}{}
+	if _, err := BindFlags(newFlagSet(), &bad); err == nil {
+		t.Fatal("expected an error for an unsupported type")
+	}
+	if _, err := BindFlags(newFlagSet(), cfg); err == nil {
+		t.Fatal("expected an error for a non-pointer")
+	}
+}
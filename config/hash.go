@@ -0,0 +1,25 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"reflect"
+)
+
+// Hash returns a fingerprint of the effective configuration v: the hex
+// SHA-256 of its normalized form, the compact JSON of what DumpJSON writes.
+// Map keys are sorted, durations and sizes use their text form, and secret
+// values and URL passwords are redacted, so rotating a token does not change
+// the hash while any other setting does. The engine tags sessions and
+// metrics with it to tell which config version they were started under.
+func Hash(v interface{}) string {
+	data, err := json.Marshal(dumpValue(reflect.ValueOf(v)))
+	if err != nil {
+		// Only unencodable values such as NaN get here; hash the error so
+		// the result stays deterministic.
+		data = []byte("!error: " + err.Error())
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
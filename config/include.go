@@ -0,0 +1,115 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// IncludeTag marks a YAML value to be replaced by the contents of another
+// file, so large pipeline configs can be split into fragments:
+//
+//	sinks:
+//	  - !include sinks/kafka.yaml
+//	  - !include sinks/s3.yaml
+//	transforms: !include transforms.yaml
+//
+// Paths are relative to the including file. Includes may nest; a cycle is an
+// error. LoadYAML, LoadYAMLStrict, LoadYAMLExpanded, Load and LoadLayered
+// resolve includes; the Parse functions, which have no file to resolve
+// against, do not.
+const IncludeTag = "!include"
+
+// readYAMLFile parses path into a node with its includes resolved. If
+// preprocess is non-nil, it is applied to every file before parsing.
+func readYAMLFile(path string, preprocess func([]byte) ([]byte, error)) (*yaml.Node, error) {
+	r := includeResolver{preprocess: preprocess}
+	return r.read(path)
+}
+
+type includeResolver struct {
+	preprocess func([]byte) ([]byte, error)
+	stack      []string // absolute paths of the files being read
+}
+
+func (r *includeResolver) read(path string) (*yaml.Node, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	for i, p := range r.stack {
+		if p == abs {
+			chain := append(append([]string{}, r.stack[i:]...), abs)
+			return nil, fmt.Errorf("config: include cycle: %s", strings.Join(chain, " -> "))
+		}
+	}
+	r.stack = append(r.stack, abs)
+	defer func() { r.stack = r.stack[:len(r.stack)-1] }()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if r.preprocess != nil {
+		if data, err = r.preprocess(data); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("config: %s: %w", path, err)
+	}
+	if err := r.resolve(&doc, filepath.Dir(path), path); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// resolve replaces include nodes below node in place.
+func (r *includeResolver) resolve(node *yaml.Node, dir, file string) error {
+	if node.Tag == IncludeTag {
+		if node.Kind != yaml.ScalarNode || node.Value == "" {
+			return fmt.Errorf("config: %s:%d: %s needs a file path", file, node.Line, IncludeTag)
+		}
+		target := node.Value
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(dir, target)
+		}
+		doc, err := r.read(target)
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", file, node.Line, err)
+		}
+		if len(doc.Content) == 0 {
+			*node = yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}
+			return nil
+		}
+		*node = *doc.Content[0]
+		return nil
+	}
+	for _, child := range node.Content {
+		if err := r.resolve(child, dir, file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeYAMLNode decodes a parsed document into v like ParseYAML.
+func decodeYAMLNode(doc *yaml.Node, v interface{}) error {
+	if err := applyDefaultsIfStruct(v); err != nil {
+		return err
+	}
+	decode := func(p interface{}) error {
+		if len(doc.Content) == 0 {
+			return nil
+		}
+		return doc.Decode(p)
+	}
+	if err := decode(v); err != nil {
+		return err
+	}
+	return applyDeprecations(v, decode)
+}
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("setup: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("setup: %v", err)
+		}
+	}
+}
+
+type includeConfig struct {
+	Name  string `yaml:"name"`
+	Sinks []struct {
+		Kind     string `yaml:"kind"`
+		Endpoint string `yaml:"endpoint"`
+	} `yaml:"sinks"`
+	Transforms map[string]string `yaml:"transforms"`
+}
+
+func TestLoadYAML_Include(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"pipeline.yaml": `
+name: orders
+sinks:
+  - !include sinks/kafka.yaml
+  - kind: stdout
+transforms: !include transforms.yaml
+`,
+		"sinks/kafka.yaml":    "kind: kafka\nendpoint: !include endpoint.yaml\n",
+		"sinks/endpoint.yaml": "kafka:9092\n",
+		"transforms.yaml":     "mask: email\n",
+	})
+
+	var cfg includeConfig
+	if err := LoadYAML(filepath.Join(dir, "pipeline.yaml"), &cfg); err != nil {
+		t.Fatalf("LoadYAML: %v", err)
+	}
+	if len(cfg.Sinks) != 2 || cfg.Sinks[0].Kind != "kafka" || cfg.Sinks[1].Kind != "stdout" {
+		t.Fatalf("got sinks %+v", cfg.Sinks)
+	}
+	if cfg.Sinks[0].Endpoint != "kafka:9092" {
+		t.Errorf("nested include should resolve relative to its parent, got %q", cfg.Sinks[0].Endpoint)
+	}
+	if cfg.Transforms["mask"] != "email" {
+		t.Errorf("got transforms %v", cfg.Transforms)
+	}
+}
+
+func TestLoadYAML_IncludeCycle(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.yaml": "name: !include b.yaml\n",
+		"b.yaml": "!include a.yaml\n",
+	})
+	var cfg includeConfig
+	err := LoadYAML(filepath.Join(dir, "a.yaml"), &cfg)
+	if err == nil || !strings.Contains(err.Error(), "include cycle") {
+		t.Fatalf("expected a cycle error, got %v", err)
+	}
+}
+
+func TestLoadYAML_IncludeErrors(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"missing.yaml": "name: !include nope.yaml\n",
+		"bad.yaml":     "name: !include [a, b]\n",
+	})
+	var cfg includeConfig
+	if err := LoadYAML(filepath.Join(dir, "missing.yaml"), &cfg); err == nil || !strings.Contains(err.Error(), "missing.yaml:1") {
+		t.Fatalf("expected an error naming the including file, got %v", err)
+	}
+	if err := LoadYAML(filepath.Join(dir, "bad.yaml"), &cfg); err == nil {
+		t.Fatal("expected an error for a non-scalar include")
+	}
+}
+
+func TestLoadYAMLStrict_Include(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"pipeline.yaml": "sinks:\n  - !include sink.yaml\n",
+		"sink.yaml":     "kind: kafka\nendpont: x\n",
+	})
+	var cfg includeConfig
+	err := LoadYAMLStrict(filepath.Join(dir, "pipeline.yaml"), &cfg)
+	if got := Violations(err); len(got) != 1 || got[0].Field != "sinks[0].endpont" {
+		t.Fatalf("got %v", err)
+	}
+}
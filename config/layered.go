@@ -0,0 +1,110 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LoadLayered deep-merges the files at paths, in order, and decodes the
+// result into v. Later files override earlier ones, so the usual stack is
+//
+//	config.LoadLayered(&cfg, "base.yaml", "prod.yaml", "local.yaml")
+//
+// Merge semantics, applied key by key:
+//
+//   - maps merge recursively; keys absent from the override are kept
+//   - slices and scalars replace the earlier value wholesale
+//   - an explicit null (YAML "~" or "null", JSON null) deletes the key, so
+//     the field falls back to its default
+//
+// Files with a .json extension are parsed as JSON, anything else as YAML. The
+// merged document is decoded with json tags if every layer is JSON and with
+// yaml tags otherwise. Defaults are applied first, see ApplyDefaults.
+func LoadLayered(v interface{}, paths ...string) error {
+	if len(paths) == 0 {
+		return fmt.Errorf("config: LoadLayered needs at least one path")
+	}
+	merged := map[string]interface{}{}
+	allJSON := true
+	for _, path := range paths {
+		layer, err := readLayer(path)
+		if err != nil {
+			return err
+		}
+		mergeLayer(merged, layer)
+		allJSON = allJSON && isJSON(path)
+	}
+
+	return decodeMerged(merged, allJSON, v)
+}
+
+// decodeMerged decodes a merged document into v, with json tags if asJSON
+// and yaml tags otherwise.
+func decodeMerged(doc map[string]interface{}, asJSON bool, v interface{}) error {
+	if asJSON {
+		data, err := json.Marshal(doc)
+		if err != nil {
+			return err
+		}
+		return ParseJSON(data, v)
+	}
+	data, err := yaml.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return ParseYAML(data, v)
+}
+
+func readLayer(path string) (map[string]interface{}, error) {
+	var layer map[string]interface{}
+	if !isJSON(path) {
+		doc, err := readYAMLFile(path, nil)
+		if err != nil {
+			return nil, err
+		}
+		if len(doc.Content) > 0 {
+			if err := doc.Decode(&layer); err != nil {
+				return nil, fmt.Errorf("config: %s: %w", path, err)
+			}
+		}
+		return layer, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &layer); err != nil {
+		return nil, fmt.Errorf("config: %s: %w", path, err)
+	}
+	return layer, nil
+}
+
+// mergeLayer merges src into dst following the LoadLayered semantics.
+func mergeLayer(dst, src map[string]interface{}) {
+	for key, val := range src {
+		if val == nil {
+			delete(dst, key)
+			continue
+		}
+		srcMap, ok := val.(map[string]interface{})
+		if !ok {
+			dst[key] = val
+			continue
+		}
+		dstMap, ok := dst[key].(map[string]interface{})
+		if !ok {
+			dstMap = map[string]interface{}{}
+			dst[key] = dstMap
+		}
+		mergeLayer(dstMap, srcMap)
+	}
+}
+
+func isJSON(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".json")
+}
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type layeredConfig struct {
+	Name    string            `yaml:"name" json:"name"`
+	Workers int               `yaml:"workers" json:"workers" default:"1"`
+	Hosts   []string          `yaml:"hosts" json:"hosts"`
+	Labels  map[string]string `yaml:"labels" json:"labels"`
+	Sink    struct {
+		Endpoint string `yaml:"endpoint" json:"endpoint"`
+		Timeout  string `yaml:"timeout" json:"timeout"`
+	} `yaml:"sink" json:"sink"`
+}
+
+func writeLayer(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	return path
+}
+
+func TestLoadLayered(t *testing.T) {
+	dir := t.TempDir()
+	base := writeLayer(t, dir, "base.yaml", `
+name: base
+workers: 4
+hosts: [a, b]
+labels: {team: core, env: dev}
+sink:
+  endpoint: http://base
+  timeout: 1s
+`)
+	prod := writeLayer(t, dir, "prod.json", `{"hosts":["c"],"labels":{"env":"prod"},"sink":{"endpoint":"http://prod"}}`)
+	local := writeLayer(t, dir, "local.yaml", "workers: ~\nlabels:\n  team: ~\n")
+
+	var cfg layeredConfig
+	if err := LoadLayered(&cfg, base, prod, local); err != nil {
+		t.Fatalf("LoadLayered: %v", err)
+	}
+	if cfg.Name != "base" {
+		t.Errorf("name: got %q", cfg.Name)
+	}
+	if cfg.Workers != 1 {
+		t.Errorf("null should delete the key and restore the default, got %d", cfg.Workers)
+	}
+	if len(cfg.Hosts) != 1 || cfg.Hosts[0] != "c" {
+		t.Errorf("slices should be replaced, got %v", cfg.Hosts)
+	}
+	if len(cfg.Labels) != 1 || cfg.Labels["env"] != "prod" {
+		t.Errorf("labels: got %v", cfg.Labels)
+	}
+	if cfg.Sink.Endpoint != "http://prod" || cfg.Sink.Timeout != "1s" {
+		t.Errorf("maps should merge recursively, got %+v", cfg.Sink)
+	}
+}
+
+func TestLoadLayered_JSONTags(t *testing.T) {
+	dir := t.TempDir()
+	a := writeLayer(t, dir, "a.json", `{"name":"a","workers":2}`)
+	b := writeLayer(t, dir, "b.json", `{"name":"b"}`)
+
+	var cfg struct {
+		Name    string `json:"service_name"`
+		Workers int    `json:"workers"`
+	}
+	if err := LoadLayered(&cfg, a, b); err != nil {
+		t.Fatalf("LoadLayered: %v", err)
+	}
+	if cfg.Workers != 2 || cfg.Name != "" {
+		t.Fatalf("got %+v", cfg)
+	}
+}
+
+func TestLoadLayered_Errors(t *testing.T) {
+	var cfg layeredConfig
+	if err := LoadLayered(&cfg); err == nil {
+		t.Fatal("expected an error without paths")
+	}
+	dir := t.TempDir()
+	if err := LoadLayered(&cfg, filepath.Join(dir, "missing.yaml")); err == nil {
+		t.Fatal("expected an error for a missing file")
+	}
+	bad := writeLayer(t, dir, "bad.yaml", "name: [unterminated\n")
+	if err := LoadLayered(&cfg, bad); err == nil {
+		t.Fatal("expected a parse error")
+	}
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Load loads the configuration file at path into v, picking the format from
+// the extension:
+//
+//	.yaml, .yml  LoadYAML
+//	.json        LoadJSON
+//	.toml        LoadTOML
+//	.env         LoadDotEnv (also a file named exactly ".env")
+//
+// Other extensions are an error. Secret references are resolved after
+// decoding, see ResolveSecrets.
+func Load(path string, v interface{}) error {
+	if err := loadFile(path, v); err != nil {
+		return err
+	}
+	return ResolveSecrets(context.Background(), v)
+}
+
+// loadFile decodes path into v according to its extension.
+func loadFile(path string, v interface{}) error {
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		return LoadYAML(path, v)
+	case ".json":
+		return LoadJSON(path, v)
+	case ".toml":
+		return LoadTOML(path, v)
+	case ".env":
+		return LoadDotEnv(path, v)
+	default:
+		return fmt.Errorf("config: %s: unsupported format %q", path, ext)
+	}
+}
+
+// parseByExt decodes data into v according to the extension ext, as Load
+// does for files.
+func parseByExt(ext string, data []byte, v interface{}) error {
+	switch strings.ToLower(ext) {
+	case ".yaml", ".yml":
+		return ParseYAML(data, v)
+	case ".json":
+		return ParseJSON(data, v)
+	case ".toml":
+		return ParseTOML(data, v)
+	case ".env":
+		return ParseDotEnv(data, v)
+	default:
+		return fmt.Errorf("config: unsupported format %q", ext)
+	}
+}
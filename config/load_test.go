@@ -0,0 +1,52 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	type loadConfig struct {
+		Name string `yaml:"name" json:"name" toml:"name" env:"NAME"`
+	}
+	dir := t.TempDir()
+	files := map[string]string{
+		"engine.yaml": "name: from-yaml\n",
+		"engine.yml":  "name: from-yml\n",
+		"engine.json": `{"name":"from-json"}`,
+		"engine.toml": `name = "from-toml"`,
+		".env":        "NAME=from-env\n",
+		"prod.ENV":    "NAME=from-prod-env\n",
+	}
+	want := map[string]string{
+		"engine.yaml": "from-yaml",
+		"engine.yml":  "from-yml",
+		"engine.json": "from-json",
+		"engine.toml": "from-toml",
+		".env":        "from-env",
+		"prod.ENV":    "from-prod-env",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("setup: %v", err)
+		}
+		var cfg loadConfig
+		if err := Load(path, &cfg); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if cfg.Name != want[name] {
+			t.Errorf("%s: got %q", name, cfg.Name)
+		}
+	}
+
+	path := filepath.Join(dir, "engine.ini")
+	if err := os.WriteFile(path, []byte("name=x"), 0644); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	var cfg loadConfig
+	if err := Load(path, &cfg); err == nil {
+		t.Fatal("expected an error for an unsupported extension")
+	}
+}
@@ -0,0 +1,187 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Map is configuration of unknown shape, such as a plugin's block that the
+// plugin host passes through. Load any format into it like into a struct:
+//
+//	var m config.Map
+//	err := config.Load(path, &m)
+//	endpoint := m.GetString("sink.http.endpoint")
+//
+// Paths are dot-separated keys; a numeric segment indexes a list, as in
+// "sinks.0.endpoint". The Get methods return the zero value when the path is
+// missing or the value does not convert; use Lookup to tell them apart.
+type Map map[string]interface{}
+
+// Lookup returns the value at path and whether it exists.
+func (m Map) Lookup(path string) (interface{}, bool) {
+	var cur interface{} = map[string]interface{}(m)
+	if path == "" {
+		return cur, m != nil
+	}
+	for _, key := range strings.Split(path, ".") {
+		switch node := cur.(type) {
+		case map[string]interface{}:
+			v, ok := node[key]
+			if !ok {
+				return nil, false
+			}
+			cur = v
+		case Map:
+			v, ok := node[key]
+			if !ok {
+				return nil, false
+			}
+			cur = v
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, false
+			}
+			cur = node[i]
+		default:
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+// Has reports whether path exists.
+func (m Map) Has(path string) bool {
+	_, ok := m.Lookup(path)
+	return ok
+}
+
+// GetString returns the value at path as a string. Numbers and booleans are
+// formatted; maps and lists yield "".
+func (m Map) GetString(path string) string {
+	v, _ := m.Lookup(path)
+	switch x := v.(type) {
+	case string:
+		return x
+	case int, int64, uint64, float64, bool:
+		return fmt.Sprint(x)
+	}
+	return ""
+}
+
+// GetInt returns the value at path as an int. Numeric strings are parsed.
+func (m Map) GetInt(path string) int {
+	v, _ := m.Lookup(path)
+	switch x := v.(type) {
+	case int:
+		return x
+	case int64:
+		return int(x)
+	case uint64:
+		return int(x)
+	case float64:
+		if x == float64(int(x)) {
+			return int(x)
+		}
+	case string:
+		n, _ := strconv.Atoi(strings.TrimSpace(x))
+		return n
+	}
+	return 0
+}
+
+// GetFloat returns the value at path as a float64. Numeric strings are
+// parsed.
+func (m Map) GetFloat(path string) float64 {
+	v, _ := m.Lookup(path)
+	switch x := v.(type) {
+	case int:
+		return float64(x)
+	case int64:
+		return float64(x)
+	case uint64:
+		return float64(x)
+	case float64:
+		return x
+	case string:
+		f, _ := strconv.ParseFloat(strings.TrimSpace(x), 64)
+		return f
+	}
+	return 0
+}
+
+// GetBool returns the value at path as a bool. Strings are parsed with
+// strconv.ParseBool.
+func (m Map) GetBool(path string) bool {
+	v, _ := m.Lookup(path)
+	switch x := v.(type) {
+	case bool:
+		return x
+	case string:
+		b, _ := strconv.ParseBool(strings.TrimSpace(x))
+		return b
+	}
+	return false
+}
+
+// GetDuration returns the value at path parsed as a duration such as "10s".
+// Like Duration, bare numbers other than 0 are not accepted.
+func (m Map) GetDuration(path string) time.Duration {
+	var d Duration
+	if err := d.UnmarshalText([]byte(m.GetString(path))); err != nil {
+		return 0
+	}
+	return d.Std()
+}
+
+// GetStringSlice returns the list at path with each item formatted as by
+// GetString. A single string yields a one-item slice.
+func (m Map) GetStringSlice(path string) []string {
+	v, _ := m.Lookup(path)
+	switch x := v.(type) {
+	case string:
+		return []string{x}
+	case []interface{}:
+		out := make([]string, len(x))
+		for i := range x {
+			out[i] = Map{"v": x[i]}.GetString("v")
+		}
+		return out
+	}
+	return nil
+}
+
+// GetMap returns the map at path, or nil.
+func (m Map) GetMap(path string) Map {
+	v, _ := m.Lookup(path)
+	switch x := v.(type) {
+	case map[string]interface{}:
+		return Map(x)
+	case Map:
+		return x
+	}
+	return nil
+}
+
+// Sub decodes the subtree at path into v, which is typically the struct a
+// plugin declares for its block, using its yaml tags. Defaults are applied
+// first, see ApplyDefaults, so a missing subtree leaves v at its defaults.
+// An empty path decodes the whole map.
+func (m Map) Sub(path string, v interface{}) error {
+	sub, ok := m.Lookup(path)
+	if !ok {
+		return applyDefaultsIfStruct(v)
+	}
+	data, err := yaml.Marshal(sub)
+	if err != nil {
+		return fmt.Errorf("config: %s: %w", path, err)
+	}
+	if err := ParseYAML(data, v); err != nil {
+		return fmt.Errorf("config: %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func loadTestMap(t *testing.T, name, content string) Map {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	var m Map
+	if err := Load(path, &m); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	return m
+}
+
+func TestMap_Getters(t *testing.T) {
+	m := loadTestMap(t, "plugin.yaml", `
+sink:
+  http:
+    endpoint: http://sink
+    timeout: 5s
+    retries: 3
+    ratio: 0.25
+    gzip: true
+    port: "8080"
+  hosts: [a, b]
+sinks:
+  - endpoint: http://first
+`)
+	if got := m.GetString("sink.http.endpoint"); got != "http://sink" {
+		t.Errorf("GetString: got %q", got)
+	}
+	if got := m.GetInt("sink.http.retries"); got != 3 {
+		t.Errorf("GetInt: got %d", got)
+	}
+	if got := m.GetInt("sink.http.port"); got != 8080 {
+		t.Errorf("GetInt from string: got %d", got)
+	}
+	if got := m.GetString("sink.http.retries"); got != "3" {
+		t.Errorf("GetString from int: got %q", got)
+	}
+	if got := m.GetFloat("sink.http.ratio"); got != 0.25 {
+		t.Errorf("GetFloat: got %v", got)
+	}
+	if !m.GetBool("sink.http.gzip") {
+		t.Error("GetBool: got false")
+	}
+	if got := m.GetDuration("sink.http.timeout"); got != 5*time.Second {
+		t.Errorf("GetDuration: got %v", got)
+	}
+	if got := m.GetStringSlice("sink.hosts"); len(got) != 2 || got[1] != "b" {
+		t.Errorf("GetStringSlice: got %v", got)
+	}
+	if got := m.GetString("sinks.0.endpoint"); got != "http://first" {
+		t.Errorf("list index: got %q", got)
+	}
+	if got := m.GetMap("sink.http"); got.GetInt("retries") != 3 {
+		t.Errorf("GetMap: got %v", got)
+	}
+
+	for _, path := range []string{"sink.missing", "sinks.5.endpoint", "sink.http.endpoint.deeper", "sinks.x"} {
+		if m.Has(path) || m.GetString(path) != "" {
+			t.Errorf("%s: expected missing", path)
+		}
+	}
+	if m.GetInt("sink.http.endpoint") != 0 || m.GetDuration("sink.http.retries") != 0 {
+		t.Error("unconvertible values should yield zero")
+	}
+}
+
+func TestMap_JSONNumbers(t *testing.T) {
+	m := loadTestMap(t, "plugin.json", `{"batch":{"size":500,"ratio":1.5}}`)
+	if m.GetInt("batch.size") != 500 || m.GetFloat("batch.ratio") != 1.5 || m.GetInt("batch.ratio") != 0 {
+		t.Fatalf("got %v", m)
+	}
+}
+
+func TestMap_Sub(t *testing.T) {
+	m := loadTestMap(t, "plugin.yaml", "sink:\n  http:\n    endpoint: http://sink\n")
+
+	var cfg struct {
+		Endpoint string        `yaml:"endpoint"`
+		Timeout  time.Duration `yaml:"timeout" default:"10s"`
+	}
+	if err := m.Sub("sink.http", &cfg); err != nil {
+		t.Fatalf("Sub: %v", err)
+	}
+	if cfg.Endpoint != "http://sink" || cfg.Timeout != 10*time.Second {
+		t.Fatalf("got %+v", cfg)
+	}
+
+	var missing struct {
+		Workers int `yaml:"workers" default:"4"`
+	}
+	if err := m.Sub("source", &missing); err != nil || missing.Workers != 4 {
+		t.Fatalf("missing subtree: got %+v, %v", missing, err)
+	}
+
+	var wrong struct {
+		HTTP []string `yaml:"http"`
+	}
+	if err := m.Sub("sink", &wrong); err == nil {
+		t.Fatal("expected a decode error")
+	}
+}
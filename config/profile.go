@@ -0,0 +1,75 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ProfilesKey is the top-level key holding the profiles of a document.
+const ProfilesKey = "profiles"
+
+// LoadProfile loads a YAML or JSON document whose top-level "profiles"
+// section holds per-environment overlays, and decodes the base document
+// with the named profile merged over it:
+//
+//	workers: 4
+//	sink:
+//	  endpoint: http://localhost:4317
+//	profiles:
+//	  production:
+//	    workers: 32
+//	    sink:
+//	      endpoint: https://otel.prod:4317
+//
+// The merge follows the LoadLayered semantics: maps merge recursively,
+// slices and scalars replace, and null deletes. An empty profile loads the
+// base document alone; an unknown profile is an error. The profiles section
+// itself is not decoded into v. Secret references are resolved as by Load.
+func LoadProfile(path, profile string, v interface{}) error {
+	doc, err := readLayer(path)
+	if err != nil {
+		return err
+	}
+	if doc == nil {
+		doc = map[string]interface{}{}
+	}
+	raw := doc[ProfilesKey]
+	profiles, ok := raw.(map[string]interface{})
+	if raw != nil && !ok {
+		return fmt.Errorf("config: %s: %s must be a map of profile names", path, ProfilesKey)
+	}
+	delete(doc, ProfilesKey)
+
+	if profile != "" {
+		overlay, ok := profiles[profile]
+		if !ok {
+			return fmt.Errorf("config: %s: unknown profile %q (have %s)", path, profile, profileNames(profiles))
+		}
+		if overlay != nil {
+			m, ok := overlay.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("config: %s: profile %q must be a map", path, profile)
+			}
+			mergeLayer(doc, m)
+		}
+	}
+
+	if err := decodeMerged(doc, isJSON(path), v); err != nil {
+		return err
+	}
+	return ResolveSecrets(context.Background(), v)
+}
+
+func profileNames(profiles map[string]interface{}) string {
+	if len(profiles) == 0 {
+		return "none"
+	}
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type profileConfig struct {
+	Workers int      `yaml:"workers" json:"workers" default:"1"`
+	Debug   bool     `yaml:"debug" json:"debug"`
+	Hosts   []string `yaml:"hosts" json:"hosts"`
+	Sink    struct {
+		Endpoint string `yaml:"endpoint" json:"endpoint"`
+		Timeout  string `yaml:"timeout" json:"timeout"`
+	} `yaml:"sink" json:"sink"`
+}
+
+const profileDoc = `
+workers: 4
+debug: true
+hosts: [a, b]
+sink:
+  endpoint: http://localhost:4317
+  timeout: 1s
+profiles:
+  production:
+    debug: ~
+    hosts: [prod-a]
+    sink:
+      endpoint: https://otel.prod:4317
+  staging:
+    workers: 8
+  empty:
+`
+
+func TestLoadProfile(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"engine.yaml": profileDoc})
+	path := filepath.Join(dir, "engine.yaml")
+
+	var prod profileConfig
+	if err := LoadProfile(path, "production", &prod); err != nil {
+		t.Fatalf("LoadProfile: %v", err)
+	}
+	if prod.Workers != 4 || prod.Debug || len(prod.Hosts) != 1 {
+		t.Errorf("got %+v", prod)
+	}
+	if prod.Sink.Endpoint != "https://otel.prod:4317" || prod.Sink.Timeout != "1s" {
+		t.Errorf("got sink %+v", prod.Sink)
+	}
+
+	var base profileConfig
+	if err := LoadProfile(path, "", &base); err != nil || base.Workers != 4 || !base.Debug {
+		t.Errorf("base: %+v, %v", base, err)
+	}
+	var empty profileConfig
+	if err := LoadProfile(path, "empty", &empty); err != nil || empty.Workers != 4 {
+		t.Errorf("empty profile: %+v, %v", empty, err)
+	}
+
+	err := LoadProfile(path, "prod", &base)
+	if err == nil || !strings.Contains(err.Error(), "empty, production, staging") {
+		t.Fatalf("expected an unknown profile error, got %v", err)
+	}
+}
+
+func TestLoadProfile_JSON(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"engine.json": `{"workers":2,"profiles":{"staging":{"workers":8}}}`,
+		"bad.yaml":    "profiles: [a]\n",
+	})
+	var cfg profileConfig
+	if err := LoadProfile(filepath.Join(dir, "engine.json"), "staging", &cfg); err != nil || cfg.Workers != 8 {
+		t.Fatalf("got %+v, %v", cfg, err)
+	}
+	if err := LoadProfile(filepath.Join(dir, "bad.yaml"), "a", &cfg); err == nil {
+		t.Fatal("expected an error for a malformed profiles section")
+	}
+}
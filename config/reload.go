@@ -0,0 +1,150 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/planx-lab/planx-common/logger"
+)
+
+// ReloadFunc applies a changed section live. section is the section's new
+// value: the top-level field of the configuration (a pointer to it when
+// addressable) or, for a Map, the subtree.
+type ReloadFunc func(section interface{}) error
+
+var (
+	reloadMu  sync.RWMutex
+	reloaders = map[string]ReloadFunc{}
+)
+
+// Reloadable marks the top-level section name (its yaml, else json, name) as
+// safe to change at runtime and registers the function that applies it:
+//
+//	config.Reloadable("logging", func(section interface{}) error {
+//		return logger.SetLevel(section.(*LoggingConfig).Level)
+//	})
+//
+// Sections that are not registered, such as listen ports and storage paths,
+// are immutable: Reload reports their changes as requiring a restart instead
+// of applying them. Like errors.RegisterCode it is meant to be called from
+// init and panics if the section is empty or already registered.
+func Reloadable(section string, apply ReloadFunc) {
+	if section == "" || apply == nil {
+		panic("config: Reloadable with empty section or nil function")
+	}
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+	if _, dup := reloaders[section]; dup {
+		panic(fmt.Sprintf("config: section %q registered twice", section))
+	}
+	reloaders[section] = apply
+}
+
+// ReloadableSections returns the registered sections, sorted.
+func ReloadableSections() []string {
+	reloadMu.RLock()
+	defer reloadMu.RUnlock()
+	out := make([]string, 0, len(reloaders))
+	for section := range reloaders {
+		out = append(out, section)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Reload compares old and new, two values of the same configuration type,
+// and calls the ReloadFunc of every reloadable section that changed, in
+// field order. Changes to any other section are not applied; each is logged
+// as a "config change requires restart" warning and returned in restart, so
+// the engine can surface it rather than run with a config that differs from
+// what it reports. The caller keeps serving old until Reload returns.
+//
+// Reload stops at the first ReloadFunc error and returns it, naming the
+// section; sections before it have already been applied.
+func Reload(old, new interface{}) (restart []Change, err error) {
+	changes := Diff(old, new)
+	if len(changes) == 0 {
+		return nil, nil
+	}
+
+	reloadMu.RLock()
+	var (
+		sections []string
+		applies  = map[string]ReloadFunc{}
+	)
+	for _, c := range changes {
+		name := SectionOf(c.Path)
+		apply, ok := reloaders[name]
+		if !ok {
+			restart = append(restart, c)
+			continue
+		}
+		if _, seen := applies[name]; !seen {
+			sections = append(sections, name)
+			applies[name] = apply
+		}
+	}
+	reloadMu.RUnlock()
+
+	for _, c := range restart {
+		logger.Warn().
+			Str("path", c.Path).
+			Interface("old", c.Old).
+			Interface("new", c.New).
+			Msg("config change requires restart")
+	}
+
+	for _, name := range sections {
+		section, ok := sectionValue(reflect.ValueOf(new), name)
+		if !ok {
+			continue
+		}
+		if err := applies[name](section); err != nil {
+			return restart, fmt.Errorf("config: reloading section %q: %w", name, err)
+		}
+		logger.Info().Str("section", name).Msg("config section reloaded")
+	}
+	return restart, nil
+}
+
+// SectionOf returns the top-level section of a Change path, e.g. "sinks"
+// for "sinks[0].endpoint".
+func SectionOf(path string) string {
+	if i := strings.IndexAny(path, ".["); i >= 0 {
+		return path[:i]
+	}
+	return path
+}
+
+// sectionValue returns the section called name from the configuration v.
+func sectionValue(v reflect.Value, name string) (interface{}, bool) {
+	v = indirect(v)
+	if !v.IsValid() {
+		return nil, false
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		f, ok := fieldByName(v, name)
+		if !ok {
+			return nil, false
+		}
+		if f.CanAddr() {
+			return f.Addr().Interface(), true
+		}
+		return f.Interface(), true
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil, false
+		}
+		// A section removed from a generic map reloads as nil.
+		f := v.MapIndex(reflect.ValueOf(name).Convert(v.Type().Key()))
+		if !f.IsValid() {
+			return nil, true
+		}
+		return f.Interface(), true
+	}
+	return nil, false
+}
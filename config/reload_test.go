@@ -0,0 +1,98 @@
+package config
+
+import (
+	stderrors "errors"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	"github.com/planx-lab/planx-common/logger/logtest"
+)
+
+type reloadLogging struct {
+	Level string `yaml:"level"`
+}
+
+type reloadConfig struct {
+	Listen  string        `yaml:"listen"`
+	Logging reloadLogging `yaml:"reload_logging"`
+	Flaky   string        `yaml:"reload_flaky"`
+}
+
+var reloadedLevels []string
+
+func init() {
+	Reloadable("reload_logging", func(section interface{}) error {
+		reloadedLevels = append(reloadedLevels, section.(*reloadLogging).Level)
+		return nil
+	})
+	Reloadable("reload_flaky", func(interface{}) error {
+		return stderrors.New("boom")
+	})
+}
+
+func TestReload(t *testing.T) {
+	rec := logtest.Capture(t)
+	reloadedLevels = nil
+
+	old := &reloadConfig{Listen: ":8080", Logging: reloadLogging{Level: "info"}}
+	next := &reloadConfig{Listen: ":9090", Logging: reloadLogging{Level: "debug"}}
+	restart, err := Reload(old, next)
+	if err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if len(reloadedLevels) != 1 || reloadedLevels[0] != "debug" {
+		t.Fatalf("reloaded: got %v", reloadedLevels)
+	}
+	if len(restart) != 1 || restart[0].Path != "listen" {
+		t.Fatalf("restart: got %v", restart)
+	}
+	if len(rec.Find(zerolog.WarnLevel, "requires restart")) != 1 {
+		t.Fatal("expected a restart warning")
+	}
+
+	reloadedLevels = nil
+	if restart, err := Reload(next, next); err != nil || restart != nil || reloadedLevels != nil {
+		t.Fatalf("unchanged: got %v, %v, %v", restart, err, reloadedLevels)
+	}
+}
+
+func TestReload_Error(t *testing.T) {
+	_, err := Reload(reloadConfig{}, reloadConfig{Flaky: "x"})
+	if err == nil || !strings.Contains(err.Error(), `"reload_flaky"`) {
+		t.Fatalf("got %v", err)
+	}
+}
+
+func TestReload_Map(t *testing.T) {
+	reloadedLevels = nil
+	defer func() { reloadedLevels = nil }()
+	old := Map{"listen": ":8080", "other": Map{"a": 1}}
+	next := Map{"listen": ":8080", "other": Map{"a": 2}}
+	restart, err := Reload(old, next)
+	if err != nil || len(restart) != 1 || restart[0].Path != "other.a" {
+		t.Fatalf("got %v, %v", restart, err)
+	}
+}
+
+func TestSectionOf(t *testing.T) {
+	for path, want := range map[string]string{
+		"listen":            "listen",
+		"logging.level":     "logging",
+		"sinks[0].endpoint": "sinks",
+	} {
+		if got := SectionOf(path); got != want {
+			t.Fatalf("%s: got %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestReloadable_Panics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic for a duplicate section")
+		}
+	}()
+	Reloadable("reload_logging", func(interface{}) error { return nil })
+}
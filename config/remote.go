@@ -0,0 +1,231 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/planx-lab/planx-common/logger"
+)
+
+// Defaults for RemoteConfig.
+const (
+	DefaultRemoteInterval   = 30 * time.Second
+	DefaultRemoteMaxBackoff = 5 * time.Minute
+)
+
+// RemoteConfig configures a RemoteSource.
+type RemoteConfig struct {
+	// URL serves the configuration document.
+	URL string
+	// Format is the document's extension, e.g. ".json". If empty it is
+	// taken from the URL path, defaulting to ".yaml".
+	Format string
+	// CacheFile, if set, keeps a copy of the last document fetched so that
+	// Load can start from it while the config service is unavailable.
+	CacheFile string
+	// Client defaults to http.DefaultClient.
+	Client *http.Client
+	// Header is added to every request, e.g. for authorization.
+	Header http.Header
+	// Interval is the polling interval of Watch (DefaultRemoteInterval).
+	Interval time.Duration
+	// MaxBackoff caps the delay between polls after consecutive errors,
+	// which doubles from Interval (DefaultRemoteMaxBackoff).
+	MaxBackoff time.Duration
+}
+
+// RemoteSource fetches configuration from an HTTP config service. It sends
+// conditional requests (If-None-Match, If-Modified-Since) so unchanged
+// documents cost a 304, backs off on errors and falls back to a local cache
+// file. Methods are safe for concurrent use.
+type RemoteSource struct {
+	cfg RemoteConfig
+
+	mu           sync.Mutex
+	data         []byte
+	etag         string
+	lastModified string
+}
+
+// NewRemoteSource returns a source for cfg.
+func NewRemoteSource(cfg RemoteConfig) *RemoteSource {
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultRemoteInterval
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = DefaultRemoteMaxBackoff
+	}
+	cfg.MaxBackoff = max(cfg.MaxBackoff, cfg.Interval)
+	if cfg.Format == "" {
+		cfg.Format = ".yaml"
+		if u, err := url.Parse(cfg.URL); err == nil && path.Ext(u.Path) != "" {
+			cfg.Format = path.Ext(u.Path)
+		}
+	}
+	return &RemoteSource{cfg: cfg}
+}
+
+// Fetch returns the current document and whether it changed since the
+// previous successful Fetch. A 304 response returns the document held in
+// memory unchanged. New documents are written to the cache file, if any.
+func (s *RemoteSource) Fetch(ctx context.Context) ([]byte, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.cfg.URL, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("config: %w", err)
+	}
+	for k, vs := range s.cfg.Header {
+		for _, v := range vs {
+			req.Header.Add(k, v)
+		}
+	}
+	s.mu.Lock()
+	if s.data != nil {
+		if s.etag != "" {
+			req.Header.Set("If-None-Match", s.etag)
+		}
+		if s.lastModified != "" {
+			req.Header.Set("If-Modified-Since", s.lastModified)
+		}
+	}
+	s.mu.Unlock()
+
+	resp, err := s.cfg.Client.Do(req)
+	if err != nil {
+		return nil, false, fmt.Errorf("config: fetch %s: %w", s.cfg.URL, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified:
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return s.data, false, nil
+	case resp.StatusCode != http.StatusOK:
+		return nil, false, fmt.Errorf("config: fetch %s: %s", s.cfg.URL, resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, false, fmt.Errorf("config: fetch %s: %w", s.cfg.URL, err)
+	}
+
+	s.mu.Lock()
+	changed := s.data == nil || string(s.data) != string(data)
+	s.data = data
+	s.etag = resp.Header.Get("ETag")
+	s.lastModified = resp.Header.Get("Last-Modified")
+	s.mu.Unlock()
+
+	if changed && s.cfg.CacheFile != "" {
+		if err := writeFileAtomic(s.cfg.CacheFile, data, 0600); err != nil {
+			logger.Warn().Err(err).Str("path", s.cfg.CacheFile).Msg("config: cannot write remote cache")
+		}
+	}
+	return data, changed, nil
+}
+
+// Load fetches the document and decodes it into v. If the fetch fails and a
+// cache file exists, the cached document is used instead and a warning is
+// logged, so nodes can start while the config service is briefly down.
+// Secret references are resolved as by Load.
+func (s *RemoteSource) Load(ctx context.Context, v interface{}) error {
+	data, _, err := s.Fetch(ctx)
+	if err != nil {
+		if s.cfg.CacheFile == "" {
+			return err
+		}
+		cached, cacheErr := os.ReadFile(s.cfg.CacheFile)
+		if cacheErr != nil {
+			return err
+		}
+		logger.Warn().Err(err).Str("path", s.cfg.CacheFile).Msg("config: remote unavailable, using cached copy")
+		data = cached
+	}
+	if err := parseByExt(s.cfg.Format, data, v); err != nil {
+		return err
+	}
+	return ResolveSecrets(ctx, v)
+}
+
+// Watch polls the source and calls onChange with each new document until
+// the returned stop function is called. After an error the next poll is
+// delayed, doubling up to MaxBackoff, and the previous document stays in
+// effect.
+func (s *RemoteSource) Watch(onChange func(data []byte)) (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		failures := 0
+		timer := time.NewTimer(s.cfg.Interval)
+		defer timer.Stop()
+		for {
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				return
+			}
+			data, changed, err := s.Fetch(ctx)
+			switch {
+			case err != nil:
+				if ctx.Err() != nil {
+					return
+				}
+				failures++
+				logger.Warn().Err(err).Int("failures", failures).Msg("config: remote poll failed")
+			default:
+				failures = 0
+				if changed {
+					onChange(data)
+				}
+			}
+			timer.Reset(s.backoff(failures))
+		}
+	}()
+
+	var once sync.Once
+	return func() { once.Do(cancel) }
+}
+
+// backoff returns the delay before the next poll after failures
+// consecutive errors.
+func (s *RemoteSource) backoff(failures int) time.Duration {
+	d := s.cfg.Interval
+	for i := 0; i < failures && d < s.cfg.MaxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, s.cfg.MaxBackoff)
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames
+// it into place, so readers never see a partial file.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,162 @@
+package config
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// configServer serves body with an ETag and can be switched to failing.
+type configServer struct {
+	mu          sync.Mutex
+	body        string
+	etag        string
+	failing     bool
+	requests    int
+	conditional int
+}
+
+func (s *configServer) set(body, etag string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.body, s.etag = body, etag
+}
+
+func (s *configServer) fail(failing bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failing = failing
+}
+
+func (s *configServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests++
+	if s.failing {
+		http.Error(w, "down", http.StatusServiceUnavailable)
+		return
+	}
+	if r.Header.Get("Authorization") != "Bearer t" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if r.Header.Get("If-None-Match") != "" {
+		s.conditional++
+		if r.Header.Get("If-None-Match") == s.etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+	w.Header().Set("ETag", s.etag)
+	_, _ = w.Write([]byte(s.body))
+}
+
+func newTestRemote(t *testing.T, srv *configServer, cfg RemoteConfig) *RemoteSource {
+	t.Helper()
+	ts := httptest.NewServer(srv)
+	t.Cleanup(ts.Close)
+	cfg.URL = ts.URL + "/v1/config/node-1.json"
+	cfg.Header = http.Header{"Authorization": {"Bearer t"}}
+	return NewRemoteSource(cfg)
+}
+
+func TestRemoteSource_Fetch(t *testing.T) {
+	srv := &configServer{}
+	srv.set(`{"name":"a"}`, `"v1"`)
+	cache := filepath.Join(t.TempDir(), "remote.json")
+	src := newTestRemote(t, srv, RemoteConfig{CacheFile: cache})
+	ctx := context.Background()
+
+	data, changed, err := src.Fetch(ctx)
+	if err != nil || !changed || string(data) != `{"name":"a"}` {
+		t.Fatalf("first fetch: %s %v %v", data, changed, err)
+	}
+	data, changed, err = src.Fetch(ctx)
+	if err != nil || changed || string(data) != `{"name":"a"}` || srv.conditional != 1 {
+		t.Fatalf("unchanged fetch: %s %v %v (conditional=%d)", data, changed, err, srv.conditional)
+	}
+	srv.set(`{"name":"b"}`, `"v2"`)
+	if _, changed, _ = src.Fetch(ctx); !changed {
+		t.Fatal("expected a change")
+	}
+	if cached, _ := os.ReadFile(cache); string(cached) != `{"name":"b"}` {
+		t.Fatalf("cache: got %s", cached)
+	}
+
+	srv.fail(true)
+	if _, _, err := src.Fetch(ctx); err == nil {
+		t.Fatal("expected an error from a failing server")
+	}
+}
+
+func TestRemoteSource_LoadFallsBackToCache(t *testing.T) {
+	srv := &configServer{}
+	srv.set(`{"name":"remote"}`, `"v1"`)
+	cache := filepath.Join(t.TempDir(), "remote.json")
+	var cfg struct {
+		Name string `json:"name"`
+	}
+
+	src := newTestRemote(t, srv, RemoteConfig{CacheFile: cache})
+	if err := src.Load(context.Background(), &cfg); err != nil || cfg.Name != "remote" {
+		t.Fatalf("Load: %+v %v", cfg, err)
+	}
+
+	// A fresh node starting while the service is down.
+	srv.fail(true)
+	cfg.Name = ""
+	restarted := newTestRemote(t, srv, RemoteConfig{CacheFile: cache})
+	if err := restarted.Load(context.Background(), &cfg); err != nil || cfg.Name != "remote" {
+		t.Fatalf("Load from cache: %+v %v", cfg, err)
+	}
+
+	noCache := newTestRemote(t, srv, RemoteConfig{})
+	if err := noCache.Load(context.Background(), &cfg); err == nil {
+		t.Fatal("expected an error without a cache")
+	}
+}
+
+func TestRemoteSource_Watch(t *testing.T) {
+	srv := &configServer{}
+	srv.set("a", `"v1"`)
+	src := newTestRemote(t, srv, RemoteConfig{Interval: 5 * time.Millisecond, MaxBackoff: 20 * time.Millisecond})
+
+	changes := make(chan string, 10)
+	stop := src.Watch(func(data []byte) { changes <- string(data) })
+	defer stop()
+
+	expect := func(want string) {
+		t.Helper()
+		select {
+		case got := <-changes:
+			if got != want {
+				t.Fatalf("got %q, want %q", got, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("no change to %q", want)
+		}
+	}
+	expect("a")
+	srv.fail(true)
+	time.Sleep(50 * time.Millisecond)
+	srv.set("b", `"v2"`)
+	srv.fail(false)
+	expect("b")
+}
+
+func TestRemoteSource_Backoff(t *testing.T) {
+	src := NewRemoteSource(RemoteConfig{URL: "http://cfg/x", Interval: time.Second, MaxBackoff: 5 * time.Second})
+	for failures, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second} {
+		if got := src.backoff(failures); got != want {
+			t.Errorf("failures=%d: got %v, want %v", failures, got, want)
+		}
+	}
+	if src.cfg.Format != ".yaml" {
+		t.Errorf("format: got %q", src.cfg.Format)
+	}
+}
@@ -0,0 +1,140 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SaveOption configures SaveYAML.
+type SaveOption func(*saveOptions)
+
+type saveOptions struct {
+	backup string
+}
+
+// WithBackup makes SaveYAML copy the file it replaces to path+suffix first,
+// e.g. WithBackup(".bak"). An empty suffix means ".bak".
+func WithBackup(suffix string) SaveOption {
+	return func(o *saveOptions) {
+		if suffix == "" {
+			suffix = ".bak"
+		}
+		o.backup = suffix
+	}
+}
+
+// SaveYAML writes v to path as YAML, for tools that edit managed config in
+// place. The file is replaced atomically (temp file and rename), so readers
+// and Watch never see a partial write, and keeps its permissions.
+//
+// If path already holds YAML, v is merged into its node tree instead of
+// replacing it: comments, key order and scalar styles survive for every key
+// that still exists, new keys are appended and keys no longer in v are
+// dropped. Values are written as they are, so secret references such as
+// env://TOKEN round-trip unchanged; SaveYAML must not be given a value
+// after ResolveSecrets. Include tags and templates are not preserved.
+func SaveYAML(path string, v interface{}, opts ...SaveOption) error {
+	var o saveOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	var doc yaml.Node
+	if err := doc.Encode(v); err != nil {
+		return fmt.Errorf("config: encoding %s: %w", path, err)
+	}
+
+	perm := os.FileMode(0o644)
+	old, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if info, statErr := os.Stat(path); statErr == nil {
+			perm = info.Mode().Perm()
+		}
+		var existing yaml.Node
+		if yaml.Unmarshal(old, &existing) == nil && len(existing.Content) == 1 {
+			mergeNode(existing.Content[0], &doc)
+			doc = existing
+		}
+		if o.backup != "" {
+			if err := writeFileAtomic(path+o.backup, old, perm); err != nil {
+				return fmt.Errorf("config: backing up %s: %w", path, err)
+			}
+		}
+	case !os.IsNotExist(err):
+		return fmt.Errorf("config: reading %s: %w", path, err)
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return fmt.Errorf("config: encoding %s: %w", path, err)
+	}
+	if err := enc.Close(); err != nil {
+		return fmt.Errorf("config: encoding %s: %w", path, err)
+	}
+	if err := writeFileAtomic(path, buf.Bytes(), perm); err != nil {
+		return fmt.Errorf("config: writing %s: %w", path, err)
+	}
+	return nil
+}
+
+// mergeNode updates dst, a node read from the existing file, to the content
+// of src while keeping dst's comments and, for unchanged types, its style.
+func mergeNode(dst, src *yaml.Node) {
+	if dst.Kind != src.Kind {
+		head, line, foot := dst.HeadComment, dst.LineComment, dst.FootComment
+		*dst = *src
+		dst.HeadComment, dst.LineComment, dst.FootComment = head, line, foot
+		return
+	}
+
+	switch dst.Kind {
+	case yaml.ScalarNode:
+		if dst.Value == src.Value && dst.Tag == src.Tag {
+			return
+		}
+		// A quoted "8080" must not stay quoted once it becomes an int.
+		if src.Style != 0 || dst.Tag != src.Tag {
+			dst.Style = src.Style
+		}
+		dst.Value, dst.Tag = src.Value, src.Tag
+	case yaml.MappingNode:
+		incoming := map[string]*yaml.Node{}
+		for i := 0; i+1 < len(src.Content); i += 2 {
+			incoming[src.Content[i].Value] = src.Content[i+1]
+		}
+		// Existing keys keep their file order; new ones follow.
+		content := make([]*yaml.Node, 0, len(src.Content))
+		kept := map[string]bool{}
+		for i := 0; i+1 < len(dst.Content); i += 2 {
+			key := dst.Content[i].Value
+			val, ok := incoming[key]
+			if !ok {
+				continue
+			}
+			mergeNode(dst.Content[i+1], val)
+			content = append(content, dst.Content[i], dst.Content[i+1])
+			kept[key] = true
+		}
+		for i := 0; i+1 < len(src.Content); i += 2 {
+			if !kept[src.Content[i].Value] {
+				content = append(content, src.Content[i], src.Content[i+1])
+			}
+		}
+		dst.Content = content
+	case yaml.SequenceNode:
+		for i, val := range src.Content {
+			if i < len(dst.Content) {
+				mergeNode(dst.Content[i], val)
+				continue
+			}
+			dst.Content = append(dst.Content, val)
+		}
+		dst.Content = dst.Content[:len(src.Content)]
+	}
+}
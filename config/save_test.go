@@ -0,0 +1,83 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type savedSink struct {
+	Name     string `yaml:"name"`
+	Endpoint string `yaml:"endpoint"`
+}
+
+type savedConfig struct {
+	Port  int         `yaml:"port"`
+	Mode  string      `yaml:"mode"`
+	Token string      `yaml:"token,omitempty"`
+	Sinks []savedSink `yaml:"sinks"`
+}
+
+func TestSaveYAML_New(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "planx.yaml")
+	cfg := savedConfig{Port: 8080, Mode: "grpc", Sinks: []savedSink{{"a", "http://a"}}}
+	if err := SaveYAML(path, cfg); err != nil {
+		t.Fatalf("SaveYAML: %v", err)
+	}
+	var got savedConfig
+	if err := LoadYAML(path, &got); err != nil {
+		t.Fatalf("LoadYAML: %v", err)
+	}
+	if got.Port != 8080 || len(got.Sinks) != 1 || got.Sinks[0].Endpoint != "http://a" {
+		t.Fatalf("got %+v", got)
+	}
+}
+
+func TestSaveYAML_PreservesComments(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"planx.yaml": `# Planx engine
+port: "8080" # listen port
+mode: 'grpc'
+token: env://TOKEN
+sinks:
+  # primary sink
+  - name: a
+    endpoint: http://a
+  - name: b
+    endpoint: http://b
+`})
+	path := filepath.Join(dir, "planx.yaml")
+	if err := os.Chmod(path, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := savedConfig{Port: 9090, Mode: "http", Sinks: []savedSink{{"a", "http://a2"}}}
+	if err := SaveYAML(path, cfg, WithBackup("")); err != nil {
+		t.Fatalf("SaveYAML: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(data)
+	for _, want := range []string{"# Planx engine", "port: 9090 # listen port", "mode: 'http'", "# primary sink", "endpoint: http://a2"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("missing %q in:\n%s", want, out)
+		}
+	}
+	for _, gone := range []string{"token", "http://b"} {
+		if strings.Contains(out, gone) {
+			t.Fatalf("%q should be gone:\n%s", gone, out)
+		}
+	}
+
+	info, err := os.Stat(path)
+	if err != nil || info.Mode().Perm() != 0o600 {
+		t.Fatalf("mode: got %v, %v", info.Mode(), err)
+	}
+	backup, err := os.ReadFile(path + ".bak")
+	if err != nil || !strings.Contains(string(backup), "http://b") {
+		t.Fatalf("backup: got %q, %v", backup, err)
+	}
+}
@@ -0,0 +1,185 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/planx-lab/planx-common/env"
+)
+
+// SecretResolver returns the secret a reference points to. ref is the part
+// of the value after "scheme://", e.g. "/run/secrets/db" for
+// "file:///run/secrets/db" or "kv/sink#token" for "vault://kv/sink#token".
+type SecretResolver func(ctx context.Context, ref string) (string, error)
+
+var (
+	resolversMu sync.RWMutex
+	resolvers   = map[string]SecretResolver{}
+)
+
+func init() {
+	RegisterSecretResolver("env", resolveEnvSecret)
+	RegisterSecretResolver("file", resolveFileSecret)
+}
+
+// RegisterSecretResolver makes ResolveSecrets resolve values of the form
+// scheme://ref with r. The env and file schemes are built in; backends such
+// as Vault are registered by the engine, which owns their clients:
+//
+//	config.RegisterSecretResolver("vault", func(ctx context.Context, ref string) (string, error) {
+//		path, key, _ := strings.Cut(ref, "#")
+//		return vaultClient.Read(ctx, path, key)
+//	})
+//
+// Like errors.RegisterCode it is meant to be called from init and panics if
+// the scheme is empty or already registered.
+func RegisterSecretResolver(scheme string, r SecretResolver) {
+	if scheme == "" || r == nil {
+		panic("config: RegisterSecretResolver with empty scheme or nil resolver")
+	}
+	resolversMu.Lock()
+	defer resolversMu.Unlock()
+	if _, dup := resolvers[scheme]; dup {
+		panic(fmt.Sprintf("config: secret scheme %q registered twice", scheme))
+	}
+	resolvers[scheme] = r
+}
+
+// SecretSchemes returns the registered schemes, sorted.
+func SecretSchemes() []string {
+	resolversMu.RLock()
+	defer resolversMu.RUnlock()
+	out := make([]string, 0, len(resolvers))
+	for scheme := range resolvers {
+		out = append(out, scheme)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// ResolveSecrets replaces every string in v that is a reference to a
+// registered scheme with the secret it points to, so credentials never live
+// in the configuration file itself:
+//
+//	password: env://PLANX_DB_PASSWORD
+//	token: file:///run/secrets/sink-token
+//	api_key: vault://kv/sink#api_key
+//
+// Encrypted values ("enc:name:<base64>", see RegisterDecrypter) are
+// decrypted. Strings in nested structs, pointers, slices and map values are
+// resolved.
+// Values with an unregistered scheme, such as http:// endpoints, are left
+// alone. Load and LoadWithEnv call ResolveSecrets after decoding. Errors name
+// the field and the reference but never the secret.
+func ResolveSecrets(ctx context.Context, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("config: expected a non-nil pointer, got %T", v)
+	}
+	return resolveSecrets(ctx, rv.Elem(), "")
+}
+
+func resolveSecrets(ctx context.Context, v reflect.Value, path string) error {
+	switch v.Kind() {
+	case reflect.String:
+		s, err := resolveSecret(ctx, v.String(), path)
+		if err != nil {
+			return err
+		}
+		if v.CanSet() {
+			v.SetString(s)
+		}
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		if v.Kind() == reflect.Interface {
+			// Interface values are not addressable; resolve a copy.
+			elem := reflect.New(v.Elem().Type()).Elem()
+			elem.Set(v.Elem())
+			if err := resolveSecrets(ctx, elem, path); err != nil {
+				return err
+			}
+			v.Set(elem)
+			return nil
+		}
+		return resolveSecrets(ctx, v.Elem(), path)
+	case reflect.Struct:
+		rt := v.Type()
+		for i := 0; i < rt.NumField(); i++ {
+			if !rt.Field(i).IsExported() {
+				continue
+			}
+			if err := resolveSecrets(ctx, v.Field(i), joinPath(path, fieldName(rt.Field(i)))); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := resolveSecrets(ctx, v.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			// Map values are not addressable; resolve a copy and store it back.
+			elem := reflect.New(iter.Value().Type()).Elem()
+			elem.Set(iter.Value())
+			if err := resolveSecrets(ctx, elem, fmt.Sprintf("%s[%v]", path, iter.Key())); err != nil {
+				return err
+			}
+			v.SetMapIndex(iter.Key(), elem)
+		}
+	}
+	return nil
+}
+
+func resolveSecret(ctx context.Context, s, path string) (string, error) {
+	if strings.HasPrefix(s, EncryptedPrefix) {
+		plaintext, err := decryptValue(ctx, s)
+		if err != nil {
+			return "", fmt.Errorf("config: %s: decrypt: %w", path, err)
+		}
+		return plaintext, nil
+	}
+	scheme, ref, ok := strings.Cut(s, "://")
+	if !ok {
+		return s, nil
+	}
+	resolversMu.RLock()
+	r := resolvers[scheme]
+	resolversMu.RUnlock()
+	if r == nil {
+		return s, nil
+	}
+	secret, err := r(ctx, ref)
+	if err != nil {
+		return "", fmt.Errorf("config: %s: resolve %s: %w", path, s, err)
+	}
+	return secret, nil
+}
+
+func resolveEnvSecret(_ context.Context, name string) (string, error) {
+	val, ok := env.Lookup(name)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+	return val, nil
+}
+
+// resolveFileSecret reads a secret file, dropping the trailing newline most
+// secret mounts and editors add.
+func resolveFileSecret(_ context.Context, path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	s := strings.TrimSuffix(string(data), "\n")
+	return strings.TrimSuffix(s, "\r"), nil
+}
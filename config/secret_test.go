@@ -0,0 +1,107 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func init() {
+	RegisterSecretResolver("testvault", func(_ context.Context, ref string) (string, error) {
+		path, key, _ := strings.Cut(ref, "#")
+		if path != "kv/sink" {
+			return "", fmt.Errorf("no secret at %s", path)
+		}
+		return "vault-" + key, nil
+	})
+}
+
+func TestResolveSecrets(t *testing.T) {
+	dir := t.TempDir()
+	secretFile := filepath.Join(dir, "token")
+	if err := os.WriteFile(secretFile, []byte("file-secret\n"), 0600); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	t.Setenv("TEST_DB_PASSWORD", "env-secret")
+
+	type sink struct {
+		Token string `yaml:"token"`
+	}
+	cfg := struct {
+		Endpoint string                 `yaml:"endpoint"`
+		Password string                 `yaml:"password"`
+		Sinks    []sink                 `yaml:"sinks"`
+		Headers  map[string]string      `yaml:"headers"`
+		Extra    map[string]interface{} `yaml:"extra"`
+		APIKey   *string                `yaml:"api_key"`
+	}{
+		Endpoint: "http://sink:4317",
+		Password: "env://TEST_DB_PASSWORD",
+		Sinks:    []sink{{Token: "file://" + secretFile}},
+		Headers:  map[string]string{"Authorization": "testvault://kv/sink#auth"},
+		Extra:    map[string]interface{}{"key": "env://TEST_DB_PASSWORD", "n": 1},
+	}
+	key := "testvault://kv/sink#api_key"
+	cfg.APIKey = &key
+
+	if err := ResolveSecrets(context.Background(), &cfg); err != nil {
+		t.Fatalf("ResolveSecrets: %v", err)
+	}
+	if cfg.Endpoint != "http://sink:4317" {
+		t.Errorf("unregistered scheme changed: %q", cfg.Endpoint)
+	}
+	if cfg.Password != "env-secret" || cfg.Sinks[0].Token != "file-secret" {
+		t.Errorf("got password %q, token %q", cfg.Password, cfg.Sinks[0].Token)
+	}
+	if cfg.Headers["Authorization"] != "vault-auth" || *cfg.APIKey != "vault-api_key" {
+		t.Errorf("got headers %v, api key %q", cfg.Headers, *cfg.APIKey)
+	}
+	if cfg.Extra["key"] != "env-secret" || cfg.Extra["n"] != 1 {
+		t.Errorf("got extra %v", cfg.Extra)
+	}
+}
+
+func TestResolveSecrets_Errors(t *testing.T) {
+	cfg := struct {
+		Sink struct {
+			Token string `yaml:"token"`
+		} `yaml:"sink"`
+	}{}
+	cfg.Sink.Token = "env://TEST_UNSET_SECRET"
+	err := ResolveSecrets(context.Background(), &cfg)
+	if err == nil || !strings.Contains(err.Error(), "sink.token") {
+		t.Fatalf("expected an error naming the field, got %v", err)
+	}
+	if err := ResolveSecrets(context.Background(), cfg); err == nil {
+		t.Fatal("expected an error for a non-pointer")
+	}
+}
+
+func TestLoad_ResolvesSecrets(t *testing.T) {
+	t.Setenv("TEST_SINK_TOKEN", "s3cret")
+	path := filepath.Join(t.TempDir(), "engine.yaml")
+	if err := os.WriteFile(path, []byte("token: env://TEST_SINK_TOKEN\n"), 0644); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	var cfg struct {
+		Token string `yaml:"token"`
+	}
+	if err := Load(path, &cfg); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Token != "s3cret" {
+		t.Fatalf("got %q", cfg.Token)
+	}
+}
+
+func TestRegisterSecretResolver_Duplicate(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic")
+		}
+	}()
+	RegisterSecretResolver("env", resolveEnvSecret)
+}
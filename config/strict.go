@@ -0,0 +1,252 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LoadYAMLStrict is LoadYAML but rejects keys that match no field.
+// See ParseYAMLStrict.
+func LoadYAMLStrict(path string, v interface{}) error {
+	doc, err := readYAMLFile(path, nil)
+	if err != nil {
+		return err
+	}
+	if err := checkUnknown(doc, v, "yaml"); err != nil {
+		return err
+	}
+	return decodeYAMLNode(doc, v)
+}
+
+// LoadJSONStrict is LoadJSON but rejects keys that match no field.
+// See ParseYAMLStrict.
+func LoadJSONStrict(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return ParseJSONStrict(data, v)
+}
+
+// ParseYAMLStrict is ParseYAML but rejects keys that match no field, so a
+// misspelled key fails loudly instead of leaving the field at its default.
+// All unknown keys are reported together as an *errors.ConfigError, with
+// the closest field name suggested for near misses:
+//
+//	invalid configuration: sink.endpont: unknown field (line 4), did you mean "endpoint"?
+//
+// Use Violations to read the individual keys back. Maps accept any key, and
+// fields whose type decodes itself (yaml.Unmarshaler and the like) are not
+// inspected.
+func ParseYAMLStrict(data []byte, v interface{}) error {
+	if err := checkUnknownData(data, v, "yaml"); err != nil {
+		return err
+	}
+	return ParseYAML(data, v)
+}
+
+// ParseJSONStrict is ParseJSON but rejects keys that match no field.
+// See ParseYAMLStrict.
+func ParseJSONStrict(data []byte, v interface{}) error {
+	if err := checkUnknownData(data, v, "json"); err != nil {
+		return err
+	}
+	return ParseJSON(data, v)
+}
+
+// checkUnknownData reports the keys in data that match no field of v,
+// decoded with the given tag key. JSON is a subset of YAML, so both formats
+// are walked as YAML nodes, which carry line numbers.
+func checkUnknownData(data []byte, v interface{}, tagKey string) error {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		// Leave syntax errors to the real decoder, which words them for
+		// the format at hand.
+		return nil
+	}
+	return checkUnknown(&doc, v, tagKey)
+}
+
+// checkUnknown reports the keys in doc that match no field of v.
+func checkUnknown(doc *yaml.Node, v interface{}, tagKey string) error {
+	var vs []Violation
+	walkUnknown(doc, reflect.TypeOf(v), "", tagKey, &vs)
+	return violationsError(vs)
+}
+
+var (
+	yamlUnmarshalerType = reflect.TypeOf((*yaml.Unmarshaler)(nil)).Elem()
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+)
+
+func walkUnknown(node *yaml.Node, t reflect.Type, path, tagKey string, vs *[]Violation) {
+	if t == nil {
+		return
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if decodesItself(t) {
+		return
+	}
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) > 0 {
+			walkUnknown(node.Content[0], t, path, tagKey, vs)
+		}
+	case yaml.MappingNode:
+		switch t.Kind() {
+		case reflect.Struct:
+			fields := knownFields(t, tagKey)
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				key, val := node.Content[i], node.Content[i+1]
+				field, ok := lookupField(fields, key.Value, tagKey)
+				if !ok {
+					*vs = append(*vs, unknownField(joinPath(path, key.Value), key, fields))
+					continue
+				}
+				walkUnknown(val, field, joinPath(path, key.Value), tagKey, vs)
+			}
+		case reflect.Map:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				walkUnknown(node.Content[i+1], t.Elem(), fmt.Sprintf("%s[%s]", path, node.Content[i].Value), tagKey, vs)
+			}
+		}
+	case yaml.SequenceNode:
+		if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+			for i, item := range node.Content {
+				walkUnknown(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i), tagKey, vs)
+			}
+		}
+	}
+}
+
+func decodesItself(t reflect.Type) bool {
+	pt := reflect.PointerTo(t)
+	for _, iface := range []reflect.Type{yamlUnmarshalerType, jsonUnmarshalerType, textUnmarshalerType} {
+		if t.Implements(iface) || pt.Implements(iface) {
+			return true
+		}
+	}
+	return false
+}
+
+// knownFields maps the decodable key names of struct t to their types,
+// flattening inlined (yaml) or embedded (json) structs.
+func knownFields(t reflect.Type, tagKey string) map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+		name, opts, _ := strings.Cut(field.Tag.Get(tagKey), ",")
+		if name == "-" && opts == "" {
+			continue
+		}
+		ft := field.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		inline := strings.Contains(","+opts+",", ",inline,")
+		if tagKey == "json" {
+			inline = field.Anonymous && name == "" && ft.Kind() == reflect.Struct
+		}
+		if inline && ft.Kind() == reflect.Struct {
+			for k, v := range knownFields(ft, tagKey) {
+				fields[k] = v
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+			if tagKey == "yaml" {
+				name = strings.ToLower(name)
+			}
+		}
+		fields[name] = field.Type
+	}
+	return fields
+}
+
+// lookupField finds key in fields. encoding/json matches names
+// case-insensitively, yaml.v3 exactly.
+func lookupField(fields map[string]reflect.Type, key, tagKey string) (reflect.Type, bool) {
+	if t, ok := fields[key]; ok {
+		return t, true
+	}
+	if tagKey == "json" {
+		for name, t := range fields {
+			if strings.EqualFold(name, key) {
+				return t, true
+			}
+		}
+	}
+	return nil, false
+}
+
+func unknownField(path string, key *yaml.Node, fields map[string]reflect.Type) Violation {
+	msg := fmt.Sprintf("unknown field (line %d)", key.Line)
+	if s := suggest(key.Value, fields); s != "" {
+		msg += fmt.Sprintf(", did you mean %q?", s)
+	}
+	return Violation{Field: path, Message: msg}
+}
+
+// suggest returns the known name closest to key, if it is close enough to
+// be a typo.
+func suggest(key string, fields map[string]reflect.Type) string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	best, bestDist := "", 0
+	for _, name := range names {
+		d := editDistance(strings.ToLower(key), strings.ToLower(name))
+		if best == "" || d < bestDist {
+			best, bestDist = name, d
+		}
+	}
+	if best == "" || bestDist > max(1, len(key)/3) {
+		return ""
+	}
+	return best
+}
+
+// editDistance is the optimal string alignment distance between a and b:
+// the Levenshtein distance, with swapping two adjacent characters counted
+// as one edit since that is the most common typo.
+func editDistance(a, b string) int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
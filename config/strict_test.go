@@ -0,0 +1,102 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type strictBase struct {
+	Name string `yaml:"name" json:"name"`
+}
+
+type strictConfig struct {
+	strictBase `yaml:",inline"`
+	Sink       struct {
+		Endpoint string   `yaml:"endpoint" json:"endpoint"`
+		Timeout  Duration `yaml:"timeout" json:"timeout"`
+	} `yaml:"sink" json:"sink"`
+	Sources []struct {
+		Topic string `yaml:"topic" json:"topic"`
+	} `yaml:"sources" json:"sources"`
+	Labels  map[string]string `yaml:"labels" json:"labels"`
+	Workers int
+}
+
+func TestParseYAMLStrict(t *testing.T) {
+	data := []byte(`
+name: engine
+workers: 2
+labels: {anything: goes}
+sink:
+  endpont: http://typo
+  timeout: 1s
+sources:
+  - topic: a
+  - topik: b
+retries: 3
+`)
+	var cfg strictConfig
+	err := ParseYAMLStrict(data, &cfg)
+	if err == nil {
+		t.Fatal("expected unknown fields to be rejected")
+	}
+	got := Violations(err)
+	want := []Violation{
+		{Field: "sink.endpont", Message: `unknown field (line 6), did you mean "endpoint"?`},
+		{Field: "sources[1].topik", Message: `unknown field (line 10), did you mean "topic"?`},
+		{Field: "retries", Message: "unknown field (line 11)"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %v", got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("got %v, want %v", got[i], want[i])
+		}
+	}
+	if cfg.Sink.Endpoint != "" {
+		t.Error("nothing should be decoded on failure")
+	}
+
+	ok := []byte("name: engine\nworkers: 2\nsink:\n  endpoint: http://sink\n")
+	if err := ParseYAMLStrict(ok, &cfg); err != nil {
+		t.Fatalf("ParseYAMLStrict: %v", err)
+	}
+	if cfg.Name != "engine" || cfg.Workers != 2 || cfg.Sink.Endpoint != "http://sink" {
+		t.Fatalf("got %+v", cfg)
+	}
+}
+
+func TestParseJSONStrict(t *testing.T) {
+	var cfg struct {
+		strictBase
+		Endpoint string `json:"endpoint"`
+	}
+	if err := ParseJSONStrict([]byte(`{"NAME":"engine","Endpoint":"http://sink"}`), &cfg); err != nil {
+		t.Fatalf("ParseJSONStrict: %v", err)
+	}
+	if cfg.Name != "engine" || cfg.Endpoint != "http://sink" {
+		t.Fatalf("got %+v", cfg)
+	}
+	err := ParseJSONStrict([]byte(`{"name":"engine","endpont":"x"}`), &cfg)
+	if err == nil || !strings.Contains(err.Error(), `did you mean "endpoint"`) {
+		t.Fatalf("got %v", err)
+	}
+	if err := ParseJSONStrict([]byte(`{"name":`), &cfg); err == nil {
+		t.Fatal("expected a syntax error")
+	}
+}
+
+func TestLoadYAMLStrict(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "engine.yaml")
+	if err := os.WriteFile(path, []byte("nmae: engine\n"), 0644); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	var cfg strictConfig
+	err := LoadYAMLStrict(path, &cfg)
+	if got := Violations(err); len(got) != 1 || !strings.Contains(got[0].Message, `did you mean "name"`) {
+		t.Fatalf("got %v", err)
+	}
+}
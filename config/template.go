@@ -0,0 +1,113 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/planx-lab/planx-common/env"
+)
+
+// LoadYAMLTemplated loads a YAML file like LoadYAML after rendering it, and
+// any included files, as a Go template (see RenderTemplate). Templating is
+// opt-in because "{{" is otherwise legal YAML content.
+func LoadYAMLTemplated(path string, v interface{}) error {
+	doc, err := readYAMLFile(path, RenderTemplate)
+	if err != nil {
+		return err
+	}
+	return decodeYAMLNode(doc, v)
+}
+
+// LoadJSONTemplated loads a JSON file like LoadJSON after rendering it as a
+// Go template (see RenderTemplate).
+func LoadJSONTemplated(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if data, err = RenderTemplate(data); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return ParseJSON(data, v)
+}
+
+// RenderTemplate renders data as a text/template with per-node helpers, so
+// values can be injected without an external templating step:
+//
+//	node_id: {{ hostname }}
+//	endpoint: {{ env "SINK_ENDPOINT" | default "http://localhost:4317" }}
+//	token: {{ file "/run/secrets/token" | quote }}
+//	region: {{ required "REGION must be set" (env "REGION") }}
+//
+// Functions:
+//
+//	env NAME            environment variable, "" if unset
+//	file PATH           file contents without the trailing newline
+//	hostname            os.Hostname
+//	default DEF VALUE   VALUE, or DEF if VALUE is empty
+//	required MSG VALUE  VALUE, or fail with MSG if it is empty
+//	upper, lower, trim, trimPrefix PREFIX S, trimSuffix SUFFIX S,
+//	replace OLD NEW S, split SEP S, join SEP LIST, contains SUBSTR S,
+//	quote S (a double-quoted string, valid in YAML and JSON),
+//	b64enc, b64dec, toJSON
+//
+// Referencing an unknown function is an error.
+func RenderTemplate(data []byte) ([]byte, error) {
+	tmpl, err := template.New("config").Funcs(templateFuncs).Parse(string(data))
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, nil); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+var templateFuncs = template.FuncMap{
+	"env": func(name string) string { return env.String(name, "") },
+	"file": func(path string) (string, error) {
+		return resolveFileSecret(context.Background(), path)
+	},
+	"hostname": os.Hostname,
+	"default": func(def string, value interface{}) interface{} {
+		if value == nil || value == "" {
+			return def
+		}
+		return value
+	},
+	"required": func(msg string, value interface{}) (interface{}, error) {
+		if value == nil || value == "" {
+			return nil, fmt.Errorf("%s", msg)
+		}
+		return value, nil
+	},
+	"upper":      strings.ToUpper,
+	"lower":      strings.ToLower,
+	"trim":       strings.TrimSpace,
+	"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+	"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+	"replace":    func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+	"split":      func(sep, s string) []string { return strings.Split(s, sep) },
+	"join":       func(sep string, list []string) string { return strings.Join(list, sep) },
+	"contains":   func(substr, s string) bool { return strings.Contains(s, substr) },
+	"quote": func(s string) string {
+		b, _ := json.Marshal(s)
+		return string(b)
+	},
+	"b64enc": func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) },
+	"b64dec": func(s string) (string, error) {
+		b, err := base64.StdEncoding.DecodeString(s)
+		return string(b), err
+	},
+	"toJSON": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	t.Setenv("TEST_REGION", "eu-west-1")
+	secret := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(secret, []byte("a\"b\n"), 0600); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	host, _ := os.Hostname()
+
+	tests := []struct {
+		in, want string
+	}{
+		{`{{ env "TEST_REGION" }}`, "eu-west-1"},
+		{`{{ env "TEST_UNSET" | default "local" }}`, "local"},
+		{`{{ env "TEST_REGION" | default "local" | upper }}`, "EU-WEST-1"},
+		{`{{ file "` + secret + `" | quote }}`, `"a\"b"`},
+		{`{{ hostname }}`, host},
+		{`{{ split "," "a,b" | join ";" }}`, "a;b"},
+		{`{{ "x" | b64enc | b64dec }}`, "x"},
+		{`{{ replace "-" "_" "a-b" | trimPrefix "a" }}`, "_b"},
+		{`{{ split "," "a,b" | toJSON }}`, `["a","b"]`},
+		{`{{ if contains "west" (env "TEST_REGION") }}w{{ end }}`, "w"},
+	}
+	for _, tt := range tests {
+		got, err := RenderTemplate([]byte(tt.in))
+		if err != nil || string(got) != tt.want {
+			t.Errorf("%s: got %q, %v; want %q", tt.in, got, err, tt.want)
+		}
+	}
+
+	for _, in := range []string{
+		`{{ required "TEST_UNSET must be set" (env "TEST_UNSET") }}`,
+		`{{ nosuchfunc }}`,
+		`{{ file "/nonexistent/secret" }}`,
+		`{{ unterminated`,
+	} {
+		if _, err := RenderTemplate([]byte(in)); err == nil {
+			t.Errorf("%s: expected an error", in)
+		}
+	}
+}
+
+func TestLoadYAMLTemplated(t *testing.T) {
+	t.Setenv("TEST_SINK", "http://sink")
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"engine.yaml": "name: {{ env \"TEST_NAME\" | default \"engine\" }}\nsink: !include sink.yaml\n",
+		"sink.yaml":   "endpoint: {{ env \"TEST_SINK\" }}\n",
+		"engine.json": `{"name": {{ env "TEST_SINK" | quote }}}`,
+	})
+	var cfg struct {
+		Name string `yaml:"name" json:"name"`
+		Sink struct {
+			Endpoint string `yaml:"endpoint"`
+		} `yaml:"sink"`
+	}
+	if err := LoadYAMLTemplated(filepath.Join(dir, "engine.yaml"), &cfg); err != nil {
+		t.Fatalf("LoadYAMLTemplated: %v", err)
+	}
+	if cfg.Name != "engine" || cfg.Sink.Endpoint != "http://sink" {
+		t.Fatalf("got %+v", cfg)
+	}
+	if err := LoadJSONTemplated(filepath.Join(dir, "engine.json"), &cfg); err != nil || cfg.Name != "http://sink" {
+		t.Fatalf("LoadJSONTemplated: %+v, %v", cfg, err)
+	}
+
+	// Without the opt-in, template syntax is left to the YAML parser.
+	writeFiles(t, dir, map[string]string{"plain.yaml": "name: '{{ hostname }}'\n"})
+	if err := LoadYAML(filepath.Join(dir, "plain.yaml"), &cfg); err != nil || !strings.Contains(cfg.Name, "{{") {
+		t.Fatalf("LoadYAML: %+v, %v", cfg, err)
+	}
+}
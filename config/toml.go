@@ -0,0 +1,32 @@
+package config
+
+import (
+	"os"
+
+	"github.com/BurntSushi/toml"
+)
+
+// LoadTOML loads a TOML configuration file into the given struct, using its
+// `toml` tags. Defaults are applied first, see ApplyDefaults.
+func LoadTOML(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return ParseTOML(data, v)
+}
+
+// ParseTOML parses TOML bytes into the given struct.
+// Defaults are applied first, see ApplyDefaults.
+func ParseTOML(data []byte, v interface{}) error {
+	if err := applyDefaultsIfStruct(v); err != nil {
+		return err
+	}
+	if _, err := toml.Decode(string(data), v); err != nil {
+		return err
+	}
+	return applyDeprecations(v, func(p interface{}) error {
+		_, err := toml.Decode(string(data), p)
+		return err
+	})
+}
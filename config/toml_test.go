@@ -0,0 +1,38 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTOML(t *testing.T) {
+	var cfg struct {
+		Name    string        `toml:"name"`
+		Workers int           `toml:"workers" default:"4"`
+		Timeout time.Duration `toml:"timeout"`
+		Sink    struct {
+			Endpoint string   `toml:"endpoint"`
+			Hosts    []string `toml:"hosts"`
+		} `toml:"sink"`
+	}
+	data := []byte(`
+name = "engine"
+timeout = "5s"
+
+[sink]
+endpoint = "http://sink"
+hosts = ["a", "b"]
+`)
+	if err := ParseTOML(data, &cfg); err != nil {
+		t.Fatalf("ParseTOML: %v", err)
+	}
+	if cfg.Name != "engine" || cfg.Workers != 4 || cfg.Timeout != 5*time.Second {
+		t.Fatalf("got %+v", cfg)
+	}
+	if cfg.Sink.Endpoint != "http://sink" || len(cfg.Sink.Hosts) != 2 {
+		t.Fatalf("got sink %+v", cfg.Sink)
+	}
+	if err := ParseTOML([]byte("name = "), &cfg); err == nil {
+		t.Fatal("expected a parse error")
+	}
+}
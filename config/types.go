@@ -0,0 +1,214 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Duration is a time.Duration that decodes from strings such as "10s" or
+// "1m30s". Bare numbers other than 0 are rejected, so a unit is always
+// spelled out, and so are negative durations.
+type Duration time.Duration
+
+// Std returns d as a time.Duration.
+func (d Duration) Std() time.Duration { return time.Duration(d) }
+
+func (d Duration) String() string { return time.Duration(d).String() }
+
+// MarshalText implements encoding.TextMarshaler.
+func (d Duration) MarshalText() ([]byte, error) { return []byte(d.String()), nil }
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (d *Duration) UnmarshalText(text []byte) error {
+	s := strings.TrimSpace(string(text))
+	if s == "0" {
+		*d = 0
+		return nil
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q: want a number with a unit such as \"10s\"", s)
+	}
+	if parsed < 0 {
+		return fmt.Errorf("invalid duration %q: must not be negative", s)
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// UnmarshalJSON accepts a duration string, or the number 0.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	return unmarshalJSONText(data, d.UnmarshalText)
+}
+
+// UnmarshalYAML accepts a duration scalar.
+func (d *Duration) UnmarshalYAML(node *yaml.Node) error {
+	return unmarshalYAMLText(node, d.UnmarshalText)
+}
+
+// ByteSize is a size in bytes that decodes from strings such as "512KiB",
+// "1.5GB" or "100". Binary units (KiB, MiB, GiB, TiB) are powers of 1024,
+// decimal units (KB, MB, GB, TB) powers of 1000; units are case-insensitive
+// and a bare number is in bytes.
+type ByteSize int64
+
+// Common sizes.
+const (
+	Byte ByteSize = 1
+	KiB           = 1024 * Byte
+	MiB           = 1024 * KiB
+	GiB           = 1024 * MiB
+	TiB           = 1024 * GiB
+	KB            = 1000 * Byte
+	MB            = 1000 * KB
+	GB            = 1000 * MB
+	TB            = 1000 * GB
+)
+
+var byteUnits = map[string]ByteSize{
+	"": Byte, "b": Byte,
+	"k": KB, "kb": KB, "kib": KiB,
+	"m": MB, "mb": MB, "mib": MiB,
+	"g": GB, "gb": GB, "gib": GiB,
+	"t": TB, "tb": TB, "tib": TiB,
+}
+
+// ParseByteSize parses a size such as "512KiB" or "1.5GB". See ByteSize.
+func ParseByteSize(s string) (ByteSize, error) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if i < 0 {
+		i = len(s)
+	}
+	num, unit := s[:i], strings.ToLower(strings.TrimSpace(s[i:]))
+	n, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q: want a number with an optional unit such as \"512KiB\"", s)
+	}
+	mult, ok := byteUnits[unit]
+	if !ok {
+		return 0, fmt.Errorf("invalid size %q: unknown unit %q", s, s[i:])
+	}
+	size := n * float64(mult)
+	if size >= math.MaxInt64 {
+		return 0, fmt.Errorf("invalid size %q: too large", s)
+	}
+	return ByteSize(math.Round(size)), nil
+}
+
+// Bytes returns b as an int64.
+func (b ByteSize) Bytes() int64 { return int64(b) }
+
+// String formats b in the largest binary unit that represents it exactly,
+// e.g. "512KiB", falling back to bytes.
+func (b ByteSize) String() string {
+	for _, u := range []struct {
+		size ByteSize
+		name string
+	}{{TiB, "TiB"}, {GiB, "GiB"}, {MiB, "MiB"}, {KiB, "KiB"}} {
+		if b != 0 && b%u.size == 0 {
+			return strconv.FormatInt(int64(b/u.size), 10) + u.name
+		}
+	}
+	return strconv.FormatInt(int64(b), 10) + "B"
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (b ByteSize) MarshalText() ([]byte, error) { return []byte(b.String()), nil }
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (b *ByteSize) UnmarshalText(text []byte) error {
+	size, err := ParseByteSize(string(text))
+	if err != nil {
+		return err
+	}
+	*b = size
+	return nil
+}
+
+// UnmarshalJSON accepts a size string or a number of bytes.
+func (b *ByteSize) UnmarshalJSON(data []byte) error {
+	return unmarshalJSONText(data, b.UnmarshalText)
+}
+
+// UnmarshalYAML accepts a size scalar.
+func (b *ByteSize) UnmarshalYAML(node *yaml.Node) error {
+	return unmarshalYAMLText(node, b.UnmarshalText)
+}
+
+// URL is a url.URL that decodes from a string and must be absolute, with a
+// scheme and a host or path. Opaque forms are rejected, which catches the
+// common "host:port" mistake (parsed as scheme "host"). The empty string decodes to the zero URL, which
+// a `validate:"required"` tag rejects.
+type URL struct {
+	url.URL
+}
+
+// ParseURL parses and validates an absolute URL. See URL.
+func ParseURL(s string) (URL, error) {
+	u, err := url.Parse(strings.TrimSpace(s))
+	if err != nil {
+		return URL{}, fmt.Errorf("invalid URL %q: %w", s, err)
+	}
+	if u.Scheme == "" || u.Opaque != "" || (u.Host == "" && u.Path == "") {
+		return URL{}, fmt.Errorf("invalid URL %q: want an absolute URL such as \"https://host:443\"", s)
+	}
+	return URL{*u}, nil
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (u URL) MarshalText() ([]byte, error) { return []byte(u.String()), nil }
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (u *URL) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*u = URL{}
+		return nil
+	}
+	parsed, err := ParseURL(string(text))
+	if err != nil {
+		return err
+	}
+	*u = parsed
+	return nil
+}
+
+// UnmarshalJSON accepts a URL string.
+func (u *URL) UnmarshalJSON(data []byte) error {
+	return unmarshalJSONText(data, u.UnmarshalText)
+}
+
+// UnmarshalYAML accepts a URL scalar.
+func (u *URL) UnmarshalYAML(node *yaml.Node) error {
+	return unmarshalYAMLText(node, u.UnmarshalText)
+}
+
+// unmarshalJSONText passes a JSON string, or the literal text of a JSON
+// number, to fn.
+func unmarshalJSONText(data []byte, fn func([]byte) error) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		return fn([]byte(s))
+	}
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return fmt.Errorf("want a string, got %s", data)
+	}
+	return fn([]byte(n))
+}
+
+func unmarshalYAMLText(node *yaml.Node, fn func([]byte) error) error {
+	if node.Kind != yaml.ScalarNode {
+		return fmt.Errorf("line %d: want a scalar", node.Line)
+	}
+	return fn([]byte(node.Value))
+}
@@ -0,0 +1,130 @@
+package config
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want ByteSize
+	}{
+		{"0", 0},
+		{"100", 100},
+		{"100B", 100},
+		{"512KiB", 512 * KiB},
+		{"512kib", 512 * KiB},
+		{"1.5GB", 1500 * MB},
+		{"1.5 GiB", 1536 * MiB},
+		{"2M", 2 * MB},
+		{"1TiB", TiB},
+	}
+	for _, tt := range tests {
+		got, err := ParseByteSize(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("%q: got %d, %v; want %d", tt.in, got, err, tt.want)
+		}
+	}
+	for _, in := range []string{"", "-1", "1.5XB", "KiB", "1e30TiB"} {
+		if _, err := ParseByteSize(in); err == nil {
+			t.Errorf("%q: expected an error", in)
+		}
+	}
+}
+
+func TestByteSize_String(t *testing.T) {
+	for size, want := range map[ByteSize]string{
+		0:          "0B",
+		100:        "100B",
+		512 * KiB:  "512KiB",
+		1536 * MiB: "1536MiB",
+		2 * GiB:    "2GiB",
+		1500 * MB:  "1500000000B",
+	} {
+		if got := size.String(); got != want {
+			t.Errorf("%d: got %q, want %q", int64(size), got, want)
+		}
+	}
+}
+
+func TestDuration_Unmarshal(t *testing.T) {
+	var d Duration
+	if err := d.UnmarshalText([]byte("1m30s")); err != nil || d.Std() != 90*time.Second {
+		t.Fatalf("got %v, %v", d, err)
+	}
+	for _, in := range []string{"10", "-1s", "soon"} {
+		if err := d.UnmarshalText([]byte(in)); err == nil {
+			t.Errorf("%q: expected an error", in)
+		}
+	}
+	if err := d.UnmarshalText([]byte("0")); err != nil || d != 0 {
+		t.Fatalf("0: got %v, %v", d, err)
+	}
+}
+
+func TestParseURL(t *testing.T) {
+	for _, in := range []string{"https://sink:4317/v1", "unix:///run/planx.sock"} {
+		if _, err := ParseURL(in); err != nil {
+			t.Errorf("%q: %v", in, err)
+		}
+	}
+	for _, in := range []string{"localhost:4317", "/relative/path", "://missing", "http://"} {
+		if _, err := ParseURL(in); err == nil {
+			t.Errorf("%q: expected an error", in)
+		}
+	}
+}
+
+type typedConfig struct {
+	Timeout  Duration `yaml:"timeout" json:"timeout" default:"5s"`
+	MaxBatch ByteSize `yaml:"max_batch" json:"max_batch" validate:"min=1KiB,max=16MiB"`
+	Endpoint URL      `yaml:"endpoint" json:"endpoint" validate:"required"`
+}
+
+func TestTypedFields_YAML(t *testing.T) {
+	var cfg typedConfig
+	data := []byte("max_batch: 512KiB\nendpoint: http://sink:4317\n")
+	if err := ParseYAML(data, &cfg); err != nil {
+		t.Fatalf("ParseYAML: %v", err)
+	}
+	if cfg.Timeout.Std() != 5*time.Second || cfg.MaxBatch != 512*KiB || cfg.Endpoint.Host != "sink:4317" {
+		t.Fatalf("got %+v", cfg)
+	}
+	if err := Validate(&cfg); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	cfg.MaxBatch = 32 * MiB
+	if got := Violations(Validate(&cfg)); len(got) != 1 || got[0].Message != "must be at most 16MiB" {
+		t.Fatalf("got %v", got)
+	}
+
+	if err := ParseYAML([]byte("timeout: 30\n"), &cfg); err == nil {
+		t.Fatal("expected an error for a duration without a unit")
+	}
+	if err := ParseYAML([]byte("endpoint: [a]\n"), &cfg); err == nil {
+		t.Fatal("expected an error for a non-scalar")
+	}
+}
+
+func TestTypedFields_JSON(t *testing.T) {
+	var cfg typedConfig
+	data := []byte(`{"timeout":"2s","max_batch":2048,"endpoint":"grpc://sink:4317"}`)
+	if err := ParseJSON(data, &cfg); err != nil {
+		t.Fatalf("ParseJSON: %v", err)
+	}
+	if cfg.Timeout.Std() != 2*time.Second || cfg.MaxBatch != 2*KiB || cfg.Endpoint.Scheme != "grpc" {
+		t.Fatalf("got %+v", cfg)
+	}
+	out, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if string(out) != `{"timeout":"2s","max_batch":"2KiB","endpoint":"grpc://sink:4317"}` {
+		t.Fatalf("got %s", out)
+	}
+	if err := ParseJSON([]byte(`{"endpoint":"not a url"}`), &cfg); err == nil {
+		t.Fatal("expected an error for a relative URL")
+	}
+}
@@ -0,0 +1,287 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/planx-lab/planx-common/errors"
+	"github.com/planx-lab/planx-common/validate"
+)
+
+// Validator is implemented by configuration structs that need checks the
+// validate tags cannot express. Validate calls it on every struct it walks,
+// after the tag rules of that struct's fields.
+type Validator interface {
+	Validate() error
+}
+
+// Violation is a single failed rule. Field is the dotted path of the field
+// using its yaml (or json) name, e.g. "sinks[0].endpoint"; it is empty for
+// violations reported by the root struct's Validator.
+type Violation = validate.Violation
+
+// Validate checks v, a struct or pointer to a struct, against its
+// `validate` tags and Validator hooks. Rules are comma-separated:
+//
+//	Endpoint string        `yaml:"endpoint" validate:"required"`
+//	Protocol string        `yaml:"protocol" validate:"oneof=grpc http"`
+//	Workers  int           `yaml:"workers" validate:"min=1,max=64"`
+//	Timeout  time.Duration `yaml:"timeout" validate:"omitempty,min=100ms"`
+//
+//	required   the value must not be zero (non-nil, non-empty)
+//	omitempty  skip the remaining rules if the value is zero
+//	min, max   bounds on numbers, durations and sizes ("min=1MiB"), or on
+//	           the length of strings, slices and maps
+//	oneof      space-separated allowed values
+//
+// String fields also take the validators of package validate as rules:
+// hostname, hostport, url (or "url=https" to restrict the scheme), cidr,
+// cron, identifier and slug.
+//
+// Cross-field rules name a sibling field of the same struct, by Go name or
+// yaml name:
+//
+//	CertFile string `yaml:"cert_file" validate:"required_if=TLS true"`
+//	MinBatch int    `yaml:"min_batch" validate:"ltefield=MaxBatch"`
+//
+//	required_if=F V       required when F equals V (compared as text)
+//	required_unless=F V   required unless F equals V
+//	required_with=F       required when F is set
+//	required_without=F    required when F is not set
+//	excluded_with=F       must not be set when F is set
+//	eqfield, nefield=F    must equal / differ from F
+//	gtfield, gtefield, ltfield, ltefield=F
+//	                      ordered against F (numbers, durations, sizes)
+//
+// Nested structs, pointers to structs and slices or maps of structs are
+// walked. All violations are collected and returned as a single
+// *errors.ConfigError whose "violations" field holds them; use Violations
+// to read them back. Validate returns nil if v is valid.
+func Validate(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return errors.NewConfigError("config: cannot validate a nil pointer")
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return errors.NewConfigErrorf("config: expected a struct, got %T", v)
+	}
+
+	var vs []Violation
+	validateStruct(rv, "", &vs)
+	return violationsError(vs)
+}
+
+// violationsError aggregates vs into one *errors.ConfigError, or returns nil
+// if vs is empty.
+func violationsError(vs []Violation) error {
+	return validate.Error(vs...)
+}
+
+// Violations returns the violations carried by an error from Validate, one
+// of the strict loaders or package validate.
+func Violations(err error) []Violation {
+	return validate.Violations(err)
+}
+
+func validateStruct(rv reflect.Value, path string, vs *[]Violation) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fv := rv.Field(i)
+		fpath := path
+		if !field.Anonymous || fv.Kind() != reflect.Struct {
+			fpath = joinPath(path, fieldName(field))
+		}
+		if tag := field.Tag.Get("validate"); tag != "" && tag != "-" {
+			if !checkRules(fv, rv, tag, fpath, vs) {
+				continue
+			}
+		}
+		validateNested(fv, fpath, vs)
+	}
+	runValidator(rv, path, vs)
+}
+
+// validateNested descends into struct-typed values.
+func validateNested(v reflect.Value, path string, vs *[]Violation) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			validateNested(v.Elem(), path, vs)
+		}
+	case reflect.Struct:
+		if v.Type() != reflect.TypeOf(time.Time{}) {
+			validateStruct(v, path, vs)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			validateNested(v.Index(i), fmt.Sprintf("%s[%d]", path, i), vs)
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			validateNested(iter.Value(), fmt.Sprintf("%s[%v]", path, iter.Key()), vs)
+		}
+	}
+}
+
+func runValidator(rv reflect.Value, path string, vs *[]Violation) {
+	var target interface{}
+	if rv.CanAddr() {
+		target = rv.Addr().Interface()
+	} else {
+		target = rv.Interface()
+	}
+	val, ok := target.(Validator)
+	if !ok {
+		return
+	}
+	if err := val.Validate(); err != nil {
+		*vs = append(*vs, Violation{Field: path, Message: err.Error()})
+	}
+}
+
+// checkRules applies the rules in tag to v, a field of the struct parent.
+// It reports whether nested validation should continue, which it should not
+// for an absent optional value.
+func checkRules(v, parent reflect.Value, tag, path string, vs *[]Violation) bool {
+	for _, rule := range strings.Split(tag, ",") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		if msg, ok := checkRequiredWhen(v, parent, name, arg); ok {
+			if msg != "" {
+				*vs = append(*vs, Violation{Field: path, Message: msg})
+				return false
+			}
+			continue
+		}
+		if msg, ok := checkFieldRule(v, parent, name, arg); ok {
+			if msg != "" {
+				*vs = append(*vs, Violation{Field: path, Message: msg})
+			}
+			continue
+		}
+		switch name {
+		case "":
+			continue
+		case "required":
+			if isEmpty(v) {
+				*vs = append(*vs, Violation{Field: path, Message: "is required"})
+				return false
+			}
+			continue
+		case "omitempty":
+			if v.IsZero() {
+				return false
+			}
+			continue
+		}
+
+		target := v
+		for target.Kind() == reflect.Ptr {
+			if target.IsNil() {
+				return false
+			}
+			target = target.Elem()
+		}
+		if msg := checkRule(target, name, arg); msg != "" {
+			*vs = append(*vs, Violation{Field: path, Message: msg})
+		}
+	}
+	return true
+}
+
+// checkRule returns a violation message, or "" if v satisfies the rule.
+func checkRule(v reflect.Value, name, arg string) string {
+	switch name {
+	case "min", "max":
+		got, limit, unit, err := bound(v, arg)
+		if err != nil {
+			return fmt.Sprintf("invalid %s rule: %v", name, err)
+		}
+		if name == "min" && got < limit {
+			return fmt.Sprintf("must be at least %s%s", arg, unit)
+		}
+		if name == "max" && got > limit {
+			return fmt.Sprintf("must be at most %s%s", arg, unit)
+		}
+	case "oneof":
+		allowed := strings.Fields(arg)
+		got := fmt.Sprint(v.Interface())
+		for _, a := range allowed {
+			if got == a {
+				return ""
+			}
+		}
+		return fmt.Sprintf("must be one of [%s], got %q", strings.Join(allowed, " "), got)
+	default:
+		if v.Kind() == reflect.String {
+			if msg, ok := validate.CheckRule(name, arg, v.String()); ok {
+				return msg
+			}
+		}
+		return fmt.Sprintf("unknown rule %q", name)
+	}
+	return ""
+}
+
+// bound returns the value compared by min and max along with the parsed
+// limit. unit describes what is compared when it is not the value itself.
+func bound(v reflect.Value, arg string) (got, limit float64, unit string, err error) {
+	switch v.Type() {
+	case durationType, reflect.TypeOf(Duration(0)):
+		d, err := time.ParseDuration(arg)
+		return float64(v.Int()), float64(d), "", err
+	case reflect.TypeOf(ByteSize(0)):
+		size, err := ParseByteSize(arg)
+		return float64(v.Int()), float64(size), "", err
+	}
+	limit, err = strconv.ParseFloat(arg, 64)
+	if isLengthKind(v.Kind()) {
+		return float64(v.Len()), limit, " in length", err
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), limit, "", err
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), limit, "", err
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), limit, "", err
+	}
+	return 0, 0, "", fmt.Errorf("unsupported type %s", v.Type())
+}
+
+// isEmpty reports whether v is zero or an empty string, slice or map.
+func isEmpty(v reflect.Value) bool {
+	return v.IsZero() || (isLengthKind(v.Kind()) && v.Len() == 0)
+}
+
+func isLengthKind(k reflect.Kind) bool {
+	return k == reflect.String || k == reflect.Slice || k == reflect.Map || k == reflect.Array
+}
+
+// fieldName returns the configuration name of field: its yaml name, else its
+// json name, else the Go name.
+func fieldName(field reflect.StructField) string {
+	for _, key := range []string{"yaml", "json"} {
+		if name, _, _ := strings.Cut(field.Tag.Get(key), ","); name != "" && name != "-" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
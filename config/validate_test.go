@@ -0,0 +1,158 @@
+package config
+
+import (
+	stderrors "errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/planx-lab/planx-common/errors"
+)
+
+type validSink struct {
+	Endpoint string `yaml:"endpoint" validate:"required"`
+	Protocol string `yaml:"protocol" validate:"oneof=grpc http"`
+}
+
+type validTLS struct {
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+}
+
+func (t *validTLS) Validate() error {
+	if (t.CertFile == "") != (t.KeyFile == "") {
+		return stderrors.New("cert_file and key_file must be set together")
+	}
+	return nil
+}
+
+type validConfig struct {
+	Name    string        `yaml:"name" validate:"required,max=8"`
+	Workers int           `yaml:"workers" validate:"min=1,max=64"`
+	Timeout time.Duration `yaml:"timeout" validate:"omitempty,min=100ms"`
+	Tags    []string      `json:"tags" validate:"max=2"`
+	Sinks   []validSink   `yaml:"sinks" validate:"required"`
+	TLS     *validTLS     `yaml:"tls"`
+	Retries *int          `yaml:"retries" validate:"min=0"`
+}
+
+func validBase() validConfig {
+	return validConfig{
+		Name:    "engine",
+		Workers: 4,
+		Sinks:   []validSink{{Endpoint: "http://sink", Protocol: "grpc"}},
+	}
+}
+
+func TestValidate_OK(t *testing.T) {
+	cfg := validBase()
+	if err := Validate(&cfg); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if err := Validate(cfg); err != nil {
+		t.Fatalf("Validate by value: %v", err)
+	}
+}
+
+func TestValidate_Violations(t *testing.T) {
+	neg := -1
+	cfg := validConfig{
+		Name:    "much-too-long",
+		Timeout: time.Millisecond,
+		Tags:    []string{"a", "b", "c"},
+		Sinks:   []validSink{{Protocol: "kafka"}},
+		TLS:     &validTLS{CertFile: "/tls.crt"},
+		Retries: &neg,
+	}
+	err := Validate(&cfg)
+	var cfgErr *errors.ConfigError
+	if !stderrors.As(err, &cfgErr) {
+		t.Fatalf("expected *errors.ConfigError, got %T", err)
+	}
+
+	want := map[string]string{
+		"name":              "must be at most 8 in length",
+		"workers":           "must be at least 1",
+		"timeout":           "must be at least 100ms",
+		"tags":              "must be at most 2 in length",
+		"sinks[0].endpoint": "is required",
+		"sinks[0].protocol": `must be one of [grpc http], got "kafka"`,
+		"tls":               "cert_file and key_file must be set together",
+		"retries":           "must be at least 0",
+	}
+	got := Violations(err)
+	if len(got) != len(want) {
+		t.Fatalf("got %d violations, want %d: %v", len(got), len(want), got)
+	}
+	for _, v := range got {
+		if want[v.Field] != v.Message {
+			t.Errorf("%s: got %q, want %q", v.Field, v.Message, want[v.Field])
+		}
+		if !strings.Contains(err.Error(), v.String()) {
+			t.Errorf("message %q does not list %q", err.Error(), v)
+		}
+	}
+}
+
+func TestValidate_Required(t *testing.T) {
+	cfg := validBase()
+	cfg.Name = ""
+	cfg.Sinks = []validSink{}
+	got := Violations(Validate(&cfg))
+	if len(got) != 2 || got[0].Field != "name" || got[1].Field != "sinks" {
+		t.Fatalf("got %v", got)
+	}
+}
+
+type rootChecked struct {
+	Mode string `yaml:"mode"`
+}
+
+func (r rootChecked) Validate() error {
+	if r.Mode == "" {
+		return stderrors.New("mode must be set")
+	}
+	return nil
+}
+
+func TestValidate_RootValidator(t *testing.T) {
+	got := Violations(Validate(rootChecked{}))
+	if len(got) != 1 || got[0].Field != "" || got[0].String() != "mode must be set" {
+		t.Fatalf("got %v", got)
+	}
+}
+
+func TestValidate_StringRules(t *testing.T) {
+	type endpoints struct {
+		Broker   string `yaml:"broker" validate:"hostport"`
+		Webhook  string `yaml:"webhook" validate:"omitempty,url=https"`
+		Allow    string `yaml:"allow" validate:"cidr"`
+		Schedule string `yaml:"schedule" validate:"cron"`
+		Tenant   string `yaml:"tenant" validate:"slug"`
+	}
+	ok := endpoints{Broker: "kafka:9092", Allow: "10.0.0.0/8", Schedule: "@hourly", Tenant: "acme"}
+	if err := Validate(ok); err != nil {
+		t.Fatalf("valid: %v", err)
+	}
+	got := Violations(Validate(endpoints{Broker: "kafka", Webhook: "http://x", Allow: "10.0.0.1/8", Schedule: "* *", Tenant: "Acme"}))
+	if len(got) != 5 || got[0].Field != "broker" || got[4].Field != "tenant" {
+		t.Fatalf("got %v", got)
+	}
+}
+
+func TestValidate_BadInput(t *testing.T) {
+	if err := Validate(42); err == nil {
+		t.Fatal("expected an error for a non-struct")
+	}
+	var cfg *validConfig
+	if err := Validate(cfg); err == nil {
+		t.Fatal("expected an error for a nil pointer")
+	}
+	bad := struct {
+		Name string `validate:"uppercase"`
+	}{}
+	got := Violations(Validate(bad))
+	if len(got) != 1 || got[0].Field != "Name" || !strings.Contains(got[0].Message, "unknown rule") {
+		t.Fatalf("got %v", got)
+	}
+}
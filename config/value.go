@@ -0,0 +1,23 @@
+package config
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// structPtr returns the struct v points to, or an error if v is not a
+// non-nil pointer to a struct.
+func structPtr(v interface{}) (reflect.Value, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("config: expected a non-nil pointer to a struct, got %T", v)
+	}
+	return rv.Elem(), nil
+}
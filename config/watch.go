@@ -0,0 +1,134 @@
+package config
+
+import (
+	"crypto/sha256"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/planx-lab/planx-common/logger"
+)
+
+// DefaultWatchInterval is the polling interval Watch uses when given none.
+const DefaultWatchInterval = 2 * time.Second
+
+// Watch calls onChange when the contents of any of paths change, until the
+// returned stop function is called. Paths may be files or directories; for
+// a directory every regular entry not starting with "." is watched.
+//
+// Watch polls file contents instead of relying on inotify, which is what
+// makes it work for Kubernetes ConfigMap and Secret volumes: the kubelet
+// writes a new timestamped directory and atomically swaps the "..data"
+// symlink that the projected files point through, so the files themselves
+// never see a write event. Reading through the symlinks, Watch sees one
+// change per swap and calls onChange once for it, however many files were
+// updated:
+//
+//	stop := config.Watch(0, func() { reload() }, "/etc/planx/engine.yaml")
+//	defer stop()
+//
+// onChange runs on the watcher goroutine, one call at a time. A path that
+// cannot be read, e.g. during a manual edit, is skipped with a warning and
+// compared again once it reappears.
+func Watch(interval time.Duration, onChange func(), paths ...string) (stop func()) {
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+	w := &watcher{paths: paths, sums: map[string][sha256.Size]byte{}, failing: map[string]bool{}}
+	w.poll() // baseline
+	done := make(chan struct{})
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if w.poll() {
+					onChange()
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }
+}
+
+type watcher struct {
+	paths   []string
+	sums    map[string][sha256.Size]byte
+	failing map[string]bool
+}
+
+// poll hashes every watched file and reports whether any changed since the
+// previous poll.
+func (w *watcher) poll() bool {
+	changed := false
+	files := w.files()
+	seen := make(map[string]bool, len(files))
+	for _, file := range files {
+		seen[file] = true
+	}
+	for file := range w.sums {
+		if !seen[file] {
+			// Removed from a watched directory.
+			delete(w.sums, file)
+			changed = true
+		}
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			if !w.failing[file] {
+				logger.Warn().Err(err).Str("path", file).Msg("config watch: cannot read file")
+				w.failing[file] = true
+			}
+			continue
+		}
+		delete(w.failing, file)
+		sum := sha256.Sum256(data)
+		if prev, ok := w.sums[file]; !ok || prev != sum {
+			w.sums[file] = sum
+			changed = true
+		}
+	}
+	return changed
+}
+
+// files expands the watched directories.
+func (w *watcher) files() []string {
+	var files []string
+	for _, path := range w.paths {
+		info, err := os.Stat(path)
+		if err != nil || !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			files = append(files, path)
+			continue
+		}
+		var names []string
+		for _, e := range entries {
+			// Skips the kubelet's ..data link and timestamped directories.
+			if strings.HasPrefix(e.Name(), ".") {
+				continue
+			}
+			if info, err := os.Stat(filepath.Join(path, e.Name())); err == nil && info.Mode().IsRegular() {
+				names = append(names, e.Name())
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			files = append(files, filepath.Join(path, name))
+		}
+	}
+	return files
+}
@@ -0,0 +1,124 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const watchInterval = 10 * time.Millisecond
+
+// waitChange waits for a value on ch, or fails after a generous timeout.
+func waitChange(t *testing.T, ch <-chan struct{}, want bool) {
+	t.Helper()
+	select {
+	case <-ch:
+		if !want {
+			t.Fatal("unexpected change")
+		}
+	case <-time.After(20 * watchInterval):
+		if want {
+			t.Fatal("change not detected")
+		}
+	}
+}
+
+func notify() (chan struct{}, func()) {
+	ch := make(chan struct{}, 10)
+	return ch, func() { ch <- struct{}{} }
+}
+
+func TestWatch_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "engine.yaml")
+	if err := os.WriteFile(path, []byte("a: 1\n"), 0644); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	ch, onChange := notify()
+	stop := Watch(watchInterval, onChange, path)
+	defer stop()
+
+	waitChange(t, ch, false)
+	if err := os.WriteFile(path, []byte("a: 2\n"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	waitChange(t, ch, true)
+
+	// Rewriting identical contents is not a change.
+	if err := os.WriteFile(path, []byte("a: 2\n"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	waitChange(t, ch, false)
+}
+
+// projectVolume mimics the kubelet's atomic update of a ConfigMap volume:
+// write a new timestamped directory, then swap the ..data symlink to it.
+func projectVolume(t *testing.T, dir, version string, files map[string]string) {
+	t.Helper()
+	ts := filepath.Join(dir, "..ts_"+version)
+	if err := os.Mkdir(ts, 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(ts, name), []byte(content), 0644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+		link := filepath.Join(dir, name)
+		if _, err := os.Lstat(link); os.IsNotExist(err) {
+			if err := os.Symlink(filepath.Join("..data", name), link); err != nil {
+				t.Fatalf("symlink: %v", err)
+			}
+		}
+	}
+	tmp := filepath.Join(dir, "..data_tmp")
+	if err := os.Symlink(filepath.Base(ts), tmp); err != nil {
+		t.Fatalf("symlink: %v", err)
+	}
+	if err := os.Rename(tmp, filepath.Join(dir, "..data")); err != nil {
+		t.Fatalf("rename: %v", err)
+	}
+}
+
+func TestWatch_ProjectedVolume(t *testing.T) {
+	dir := t.TempDir()
+	projectVolume(t, dir, "1", map[string]string{"engine.yaml": "a: 1\n", "token": "x"})
+
+	ch, onChange := notify()
+	stop := Watch(watchInterval, onChange, dir)
+	defer stop()
+	waitChange(t, ch, false)
+
+	projectVolume(t, dir, "2", map[string]string{"engine.yaml": "a: 2\n", "token": "y"})
+	waitChange(t, ch, true)
+	// Both files changed in one swap: a single callback.
+	waitChange(t, ch, false)
+}
+
+func TestWatch_ProjectedFile(t *testing.T) {
+	dir := t.TempDir()
+	projectVolume(t, dir, "1", map[string]string{"engine.yaml": "a: 1\n"})
+
+	ch, onChange := notify()
+	stop := Watch(watchInterval, onChange, filepath.Join(dir, "engine.yaml"))
+	defer stop()
+	waitChange(t, ch, false)
+
+	projectVolume(t, dir, "2", map[string]string{"engine.yaml": "a: 2\n"})
+	waitChange(t, ch, true)
+}
+
+func TestWatch_Stop(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "engine.yaml")
+	if err := os.WriteFile(path, []byte("a: 1\n"), 0644); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	ch, onChange := notify()
+	stop := Watch(watchInterval, onChange, path)
+	stop()
+	stop() // idempotent
+
+	if err := os.WriteFile(path, []byte("a: 2\n"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	waitChange(t, ch, false)
+}
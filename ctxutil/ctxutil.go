@@ -0,0 +1,127 @@
+// Package ctxutil provides typed context values for the IDs a request or
+// session carries through the engine — tenant, session, batch and
+// principal — and Detach for work that outlives its request.
+// Engine-side utilities only — must not be imported by SDK or plugins.
+//
+// Each setter also adds the value to context-aware log lines and tags the
+// span in ctx, as requestid.NewContext does for request IDs:
+//
+//	ctx = ctxutil.WithTenant(ctx, tenant)
+//	ctx = ctxutil.WithSession(ctx, session)
+//	...
+//	tenant, ok := ctxutil.TenantFrom(ctx)
+//
+// Keys are unexported types, so values cannot collide with or be forged
+// through string keys.
+package ctxutil
+
+import (
+	"context"
+	"time"
+
+	"github.com/planx-lab/planx-common/id"
+	"github.com/planx-lab/planx-common/logger"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Log fields naming the values in context-aware log lines.
+const (
+	TenantField    = "tenant_id"
+	SessionField   = "session_id"
+	BatchField     = "batch_id"
+	PrincipalField = "principal"
+)
+
+type key int
+
+const (
+	tenantKey key = iota
+	sessionKey
+	batchKey
+	principalKey
+)
+
+// with stores v in ctx under k, adding it to log lines as field and to the
+// span in ctx as planx.<field>.
+func with(ctx context.Context, k key, field, text string, v interface{}) context.Context {
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String("planx."+field, text))
+	ctx = logger.ContextWithFields(ctx, map[string]interface{}{field: text})
+	return context.WithValue(ctx, k, v)
+}
+
+func value[T any](ctx context.Context, k key) (T, bool) {
+	v, ok := ctx.Value(k).(T)
+	return v, ok
+}
+
+// WithTenant returns a copy of ctx carrying tenant.
+func WithTenant(ctx context.Context, tenant id.Tenant) context.Context {
+	return with(ctx, tenantKey, TenantField, tenant.String(), tenant)
+}
+
+// TenantFrom returns the tenant in ctx, if any.
+func TenantFrom(ctx context.Context) (id.Tenant, bool) {
+	return value[id.Tenant](ctx, tenantKey)
+}
+
+// WithSession returns a copy of ctx carrying session.
+func WithSession(ctx context.Context, session id.Session) context.Context {
+	return with(ctx, sessionKey, SessionField, session.String(), session)
+}
+
+// SessionFrom returns the session in ctx, if any.
+func SessionFrom(ctx context.Context) (id.Session, bool) {
+	return value[id.Session](ctx, sessionKey)
+}
+
+// WithBatch returns a copy of ctx carrying batch.
+func WithBatch(ctx context.Context, batch id.Batch) context.Context {
+	return with(ctx, batchKey, BatchField, batch.String(), batch)
+}
+
+// BatchFrom returns the batch in ctx, if any.
+func BatchFrom(ctx context.Context) (id.Batch, bool) {
+	return value[id.Batch](ctx, batchKey)
+}
+
+// Principal kinds.
+const (
+	PrincipalUser    = "user"
+	PrincipalService = "service"
+)
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	Subject string // user name, or a service's SPIFFE ID
+	Kind    string // PrincipalUser or PrincipalService
+}
+
+// String returns the principal's subject.
+func (p Principal) String() string { return p.Subject }
+
+// WithPrincipal returns a copy of ctx carrying p. Only its subject is
+// logged.
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return with(ctx, principalKey, PrincipalField, p.Subject, p)
+}
+
+// PrincipalFrom returns the principal in ctx, if any.
+func PrincipalFrom(ctx context.Context) (Principal, bool) {
+	return value[Principal](ctx, principalKey)
+}
+
+// Detach returns a context with the values of ctx, including its span and
+// log fields, that is never canceled and has no deadline. Use it for work
+// that must finish after the request that started it, such as an
+// asynchronous ack, so it is still traced and logged with the request's
+// IDs.
+func Detach(ctx context.Context) context.Context {
+	return context.WithoutCancel(ctx)
+}
+
+// DetachWithTimeout is Detach with a fresh timeout, so detached work
+// cannot run forever.
+func DetachWithTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.WithoutCancel(ctx), timeout)
+}
@@ -0,0 +1,93 @@
+package ctxutil
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/planx-lab/planx-common/id"
+	"github.com/planx-lab/planx-common/logger"
+	"github.com/planx-lab/planx-common/logger/logtest"
+)
+
+func TestValues(t *testing.T) {
+	ctx := context.Background()
+	if _, ok := TenantFrom(ctx); ok {
+		t.Fatalf("TenantFrom on an empty context reported a tenant")
+	}
+	session, batch := id.NewSession(), id.NewBatch()
+	p := Principal{Subject: "spiffe://planx.internal/engine", Kind: PrincipalService}
+	ctx = WithTenant(ctx, "acme")
+	ctx = WithSession(ctx, session)
+	ctx = WithBatch(ctx, batch)
+	ctx = WithPrincipal(ctx, p)
+
+	if got, ok := TenantFrom(ctx); !ok || got != "acme" {
+		t.Fatalf("TenantFrom = %q, %v", got, ok)
+	}
+	if got, ok := SessionFrom(ctx); !ok || got != session {
+		t.Fatalf("SessionFrom = %v, %v", got, ok)
+	}
+	if got, ok := BatchFrom(ctx); !ok || got != batch {
+		t.Fatalf("BatchFrom = %v, %v", got, ok)
+	}
+	if got, ok := PrincipalFrom(ctx); !ok || got != p {
+		t.Fatalf("PrincipalFrom = %v, %v", got, ok)
+	}
+	// A string key with the same text must not alias the typed value.
+	if v := ctx.Value("tenant_id"); v != nil {
+		t.Fatalf("string key returned %v", v)
+	}
+}
+
+func TestValues_LogAndSpan(t *testing.T) {
+	rec := logtest.Capture(t)
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	ctx, span := tp.Tracer("test").Start(context.Background(), "op")
+
+	ctx = WithTenant(ctx, "acme")
+	ctx = WithPrincipal(ctx, Principal{Subject: "alice", Kind: PrincipalUser})
+	logger.InfoCtx(ctx).Msg("with ids")
+	span.End()
+
+	entries := rec.Find(zerolog.InfoLevel, "with ids")
+	if len(entries) != 1 || entries[0].Str(TenantField) != "acme" || entries[0].Str(PrincipalField) != "alice" {
+		t.Fatalf("log entries = %v", entries)
+	}
+	attrs := map[string]string{}
+	for _, kv := range exporter.GetSpans()[0].Attributes {
+		attrs[string(kv.Key)] = kv.Value.AsString()
+	}
+	if attrs["planx.tenant_id"] != "acme" || attrs["planx.principal"] != "alice" {
+		t.Fatalf("span attributes = %v", attrs)
+	}
+}
+
+func TestDetach(t *testing.T) {
+	parent, cancel := context.WithTimeout(WithTenant(context.Background(), "acme"), time.Millisecond)
+	cancel()
+	ctx := Detach(parent)
+	if ctx.Err() != nil {
+		t.Fatalf("detached context canceled: %v", ctx.Err())
+	}
+	if _, ok := ctx.Deadline(); ok {
+		t.Fatalf("detached context kept the deadline")
+	}
+	if got, _ := TenantFrom(ctx); got != "acme" {
+		t.Fatalf("detached context lost the tenant: %q", got)
+	}
+
+	ctx, cancelDetached := DetachWithTimeout(parent, time.Hour)
+	defer cancelDetached()
+	if ctx.Err() != nil {
+		t.Fatalf("DetachWithTimeout context canceled: %v", ctx.Err())
+	}
+	if deadline, ok := ctx.Deadline(); !ok || time.Until(deadline) < 59*time.Minute {
+		t.Fatalf("DetachWithTimeout deadline = %v, %v", deadline, ok)
+	}
+}
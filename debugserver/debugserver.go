@@ -0,0 +1,226 @@
+// Package debugserver provides the private operational HTTP surface every
+// Planx process exposes: pprof, expvar, health checks, the log level,
+// supervised goroutines, the effective configuration and environment
+// variables read, and a metrics snapshot.
+// Engine-side utilities only — must not be imported by SDK or plugins.
+//
+//	srv, err := debugserver.Start(cfg.DebugAddr,
+//		debugserver.WithConfig(&cfg),
+//		debugserver.WithMetrics(provider),
+//		debugserver.WithToken(cfg.DebugToken))
+//	if err != nil { ... }
+//	lc.Register(lifecycle.Hook{Name: "debugserver", Stop: srv.Shutdown})
+//
+// The server exposes profiles and configuration, so bind it to a private
+// address and set a token anywhere it is reachable from outside the pod.
+package debugserver
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"expvar"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"time"
+
+	"github.com/planx-lab/planx-common/config"
+	"github.com/planx-lab/planx-common/env"
+	"github.com/planx-lab/planx-common/health"
+	"github.com/planx-lab/planx-common/logger"
+	"github.com/planx-lab/planx-common/metrics"
+	"github.com/planx-lab/planx-common/recovery"
+)
+
+// DefaultAddr is the conventional debug address: loopback only.
+const DefaultAddr = "127.0.0.1:6060"
+
+// Paths served by Handler.
+const (
+	PathPprof      = "/debug/pprof/"
+	PathVars       = "/debug/vars"
+	PathLogLevel   = "/debug/loglevel"
+	PathGoroutines = "/debug/goroutines"
+	PathConfig     = "/debug/config"
+	PathEnv        = "/debug/env"
+	PathMetrics    = "/debug/metrics"
+	PathHealthz    = "/healthz"
+	PathReadyz     = "/readyz"
+)
+
+// Option configures Handler and Start.
+type Option func(*options)
+
+type options struct {
+	health   *health.Registry
+	config   interface{}
+	metrics  metrics.Provider
+	token    string
+	handlers []route
+}
+
+type route struct {
+	path    string
+	handler http.Handler
+}
+
+// WithHealth serves r at /healthz and /readyz instead of health.Default.
+func WithHealth(r *health.Registry) Option {
+	return func(o *options) { o.health = r }
+}
+
+// WithConfig serves the redacted configuration v at /debug/config; see
+// config.DebugHandler. Without it the path is not served.
+func WithConfig(v interface{}) Option {
+	return func(o *options) { o.config = v }
+}
+
+// WithMetrics serves a snapshot of p at /debug/metrics; see
+// metrics.DebugHandler. Without it the path is not served.
+func WithMetrics(p metrics.Provider) Option {
+	return func(o *options) { o.metrics = p }
+}
+
+// WithToken requires "Authorization: Bearer <token>" on every path but
+// /healthz and /readyz, which stay open for probes. An empty token
+// disables auth.
+func WithToken(token string) Option {
+	return func(o *options) { o.token = token }
+}
+
+// WithHandler serves h at path as well, for process-specific endpoints.
+// It is subject to the token like the built-in paths.
+func WithHandler(path string, h http.Handler) Option {
+	return func(o *options) { o.handlers = append(o.handlers, route{path, h}) }
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{health: health.Default}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// Handler returns the debug endpoints as a handler, for mounting on an
+// existing admin server. "/" lists the paths served.
+func Handler(opts ...Option) http.Handler {
+	o := newOptions(opts)
+	debug := http.NewServeMux()
+	var paths []string
+	handle := func(path string, h http.Handler) {
+		debug.Handle(path, h)
+		paths = append(paths, path)
+	}
+
+	handle(PathPprof, http.HandlerFunc(pprof.Index))
+	debug.HandleFunc(PathPprof+"cmdline", pprof.Cmdline)
+	debug.HandleFunc(PathPprof+"profile", pprof.Profile)
+	debug.HandleFunc(PathPprof+"symbol", pprof.Symbol)
+	debug.HandleFunc(PathPprof+"trace", pprof.Trace)
+	handle(PathVars, expvar.Handler())
+	handle(PathLogLevel, logger.LevelHandler())
+	handle(PathGoroutines, recovery.Handler())
+	handle(PathEnv, env.Handler())
+	if o.config != nil {
+		handle(PathConfig, config.DebugHandler(o.config))
+	}
+	if o.metrics != nil {
+		handle(PathMetrics, metrics.DebugHandler(o.metrics))
+	}
+	for _, r := range o.handlers {
+		handle(r.path, r.handler)
+	}
+	debug.HandleFunc("/{$}", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprintln(w, PathHealthz)
+		fmt.Fprintln(w, PathReadyz)
+		for _, p := range paths {
+			fmt.Fprintln(w, p)
+		}
+	})
+
+	mux := http.NewServeMux()
+	mux.Handle(PathHealthz, o.health.LivenessHandler())
+	mux.Handle(PathReadyz, o.health.ReadinessHandler())
+	mux.Handle("/", requireToken(o.token, debug))
+	return mux
+}
+
+// requireToken rejects requests without the bearer token, if one is set.
+func requireToken(token string, next http.Handler) http.Handler {
+	if token == "" {
+		return next
+	}
+	want := []byte("Bearer " + token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got := []byte(r.Header.Get("Authorization"))
+		if subtle.ConstantTimeCompare(got, want) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="planx-debug"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Server is a running debug server.
+type Server struct {
+	srv  *http.Server
+	lis  net.Listener
+	done chan struct{}
+}
+
+// Start listens on addr (DefaultAddr if empty) and serves Handler(opts...)
+// until Shutdown. It logs a warning when addr is not loopback and no token
+// is set.
+func Start(addr string, opts ...Option) (*Server, error) {
+	if addr == "" {
+		addr = DefaultAddr
+	}
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("debugserver: listen on %s: %w", addr, err)
+	}
+	s := &Server{
+		srv: &http.Server{
+			Handler:           Handler(opts...),
+			ReadHeaderTimeout: 10 * time.Second,
+		},
+		lis:  lis,
+		done: make(chan struct{}),
+	}
+	if newOptions(opts).token == "" && !isLoopback(lis.Addr()) {
+		logger.Warn().Str("addr", s.Addr()).Msg("debugserver: serving without a token on a non-loopback address")
+	}
+	logger.Info().Str("addr", s.Addr()).Msg("debugserver: listening")
+	go func() {
+		defer close(s.done)
+		if err := s.srv.Serve(lis); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error().Err(err).Str("addr", s.Addr()).Msg("debugserver: serve failed")
+		}
+	}()
+	return s, nil
+}
+
+// Addr returns the address the server listens on, with the actual port
+// when Start was given port 0.
+func (s *Server) Addr() string { return s.lis.Addr().String() }
+
+// Shutdown stops the server, waiting for in-flight requests until ctx is
+// done. Long profiles are cut short at that point.
+func (s *Server) Shutdown(ctx context.Context) error {
+	err := s.srv.Shutdown(ctx)
+	if err != nil {
+		_ = s.srv.Close()
+	}
+	<-s.done
+	return err
+}
+
+func isLoopback(addr net.Addr) bool {
+	tcp, ok := addr.(*net.TCPAddr)
+	return ok && tcp.IP.IsLoopback()
+}
@@ -0,0 +1,140 @@
+package debugserver
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/planx-lab/planx-common/health"
+	"github.com/planx-lab/planx-common/logger/logtest"
+	"github.com/planx-lab/planx-common/metrics"
+)
+
+type testConfig struct {
+	Endpoint string `yaml:"endpoint"`
+	Token    string `yaml:"token" secret:"true"`
+}
+
+func get(t *testing.T, h http.Handler, path, token string) (int, string) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec.Code, rec.Body.String()
+}
+
+func TestHandler_Endpoints(t *testing.T) {
+	reg := health.NewRegistry()
+	reg.RegisterReadiness("db", func(context.Context) error { return nil })
+	p := metrics.NewMemoryProvider()
+	p.Counter("planx_test_total", nil).Inc()
+	h := Handler(
+		WithHealth(reg),
+		WithConfig(&testConfig{Endpoint: "sink:443", Token: "s3cret"}),
+		WithMetrics(p),
+		WithHandler("/debug/extra", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = io.WriteString(w, "extra")
+		})),
+	)
+
+	tests := []struct {
+		path, want string
+	}{
+		{PathHealthz, `"status":"ok"`},
+		{PathReadyz, `"db"`},
+		{PathPprof, "goroutine"},
+		{PathPprof + "goroutine?debug=1", "goroutine profile"},
+		{PathVars, `"memstats"`},
+		{PathLogLevel, `"level"`},
+		{PathGoroutines, "["},
+		{PathEnv, "["},
+		{PathConfig, `"sink:443"`},
+		{PathMetrics, "planx_test_total"},
+		{"/debug/extra", "extra"},
+		{"/", PathMetrics},
+	}
+	for _, tt := range tests {
+		code, body := get(t, h, tt.path, "")
+		if code != http.StatusOK || !strings.Contains(body, tt.want) {
+			t.Fatalf("GET %s: %d %q, want 200 containing %q", tt.path, code, body, tt.want)
+		}
+	}
+	if _, body := get(t, h, PathConfig, ""); strings.Contains(body, "s3cret") {
+		t.Fatalf("config dump leaks secret: %s", body)
+	}
+	if code, _ := get(t, h, "/nope", ""); code != http.StatusNotFound {
+		t.Fatalf("GET /nope: %d", code)
+	}
+}
+
+func TestHandler_OptionalPaths(t *testing.T) {
+	h := Handler(WithHealth(health.NewRegistry()))
+	for _, path := range []string{PathConfig, PathMetrics} {
+		if code, _ := get(t, h, path, ""); code != http.StatusNotFound {
+			t.Fatalf("GET %s without option: %d", path, code)
+		}
+	}
+}
+
+func TestHandler_Token(t *testing.T) {
+	h := Handler(WithHealth(health.NewRegistry()), WithToken("t0ken"))
+	if code, _ := get(t, h, PathVars, ""); code != http.StatusUnauthorized {
+		t.Fatalf("no token: %d", code)
+	}
+	if code, _ := get(t, h, PathVars, "wrong"); code != http.StatusUnauthorized {
+		t.Fatalf("wrong token: %d", code)
+	}
+	if code, _ := get(t, h, PathVars, "t0ken"); code != http.StatusOK {
+		t.Fatalf("right token: %d", code)
+	}
+	if code, _ := get(t, h, PathHealthz, ""); code != http.StatusOK {
+		t.Fatalf("healthz without token: %d", code)
+	}
+}
+
+func TestStart(t *testing.T) {
+	rec := logtest.Capture(t)
+	srv, err := Start("127.0.0.1:0", WithHealth(health.NewRegistry()))
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	resp, err := http.Get("http://" + srv.Addr() + PathHealthz)
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status %d", resp.StatusCode)
+	}
+	rec.AssertLogged(zerolog.InfoLevel, "listening")
+	rec.AssertNotLogged(zerolog.WarnLevel, "without a token")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if _, err := http.Get("http://" + srv.Addr() + PathHealthz); err == nil {
+		t.Fatalf("server still serving after Shutdown")
+	}
+}
+
+func TestStart_ListenError(t *testing.T) {
+	srv, err := Start("127.0.0.1:0", WithHealth(health.NewRegistry()))
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer srv.Shutdown(context.Background())
+	if _, err := Start(srv.Addr()); err == nil || !strings.Contains(err.Error(), srv.Addr()) {
+		t.Fatalf("Start on a used address: %v", err)
+	}
+}
@@ -0,0 +1,124 @@
+// Package dedup provides idempotency tracking for at-least-once sinks: a
+// TTL-bounded set of batch or record keys already processed, so that a
+// batch redelivered after a retry is not written twice.
+// Engine-side utilities only — must not be imported by SDK or plugins.
+//
+// A sink checks a key before writing and marks it once the write is
+// durable:
+//
+//	key := dedup.BatchKey(tenant, b.ID)
+//	if tracker.Seen(ctx, key) {
+//		return nil // already written
+//	}
+//	if err := write(ctx, b); err != nil {
+//		return err
+//	}
+//	return tracker.MarkProcessed(ctx, key)
+//
+// Keys live in a Store: an in-memory LRU by default, or Redis to share
+// them across engine replicas.
+package dedup
+
+import (
+	"context"
+	"encoding/hex"
+	"time"
+
+	"github.com/planx-lab/planx-common/config"
+	"github.com/planx-lab/planx-common/id"
+	"github.com/planx-lab/planx-common/logger"
+	"github.com/planx-lab/planx-common/telemetry"
+)
+
+// Defaults for a Tracker.
+const (
+	DefaultTTL      = 24 * time.Hour
+	DefaultCapacity = 100_000
+)
+
+// Store holds the processed keys of a Tracker. Implementations must be
+// safe for concurrent use.
+type Store interface {
+	// Contains reports whether key was added and has not expired.
+	Contains(ctx context.Context, key string) (bool, error)
+	// Add records key for ttl.
+	Add(ctx context.Context, key string, ttl time.Duration) error
+}
+
+// Config configures a Tracker:
+//
+//	name: s3-sink
+//	ttl: 48h
+//	capacity: 500000
+type Config struct {
+	// Name labels the tracker's metrics and logs.
+	Name string `yaml:"name" json:"name"`
+
+	// TTL is how long a processed key is remembered; it should outlast the
+	// longest redelivery window. Defaults to DefaultTTL.
+	TTL config.Duration `yaml:"ttl" json:"ttl" validate:"omitempty,min=1s"`
+
+	// Capacity bounds the keys held by the default in-memory store, least
+	// recently used first out. Defaults to DefaultCapacity; ignored with
+	// another store.
+	Capacity int `yaml:"capacity" json:"capacity" validate:"min=0"`
+}
+
+// Tracker suppresses duplicates by remembering processed keys. It is safe
+// for concurrent use.
+type Tracker struct {
+	name  string
+	ttl   time.Duration
+	store Store
+}
+
+// New returns a tracker keeping its keys in store, or in a Memory of
+// cfg.Capacity keys if store is nil.
+func New(cfg Config, store Store) *Tracker {
+	ttl := cfg.TTL.Std()
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	if store == nil {
+		capacity := cfg.Capacity
+		if capacity <= 0 {
+			capacity = DefaultCapacity
+		}
+		store = NewMemory(capacity)
+	}
+	return &Tracker{name: cfg.Name, ttl: ttl, store: store}
+}
+
+// Seen reports whether key was marked processed within the TTL. If the
+// store fails, Seen logs a warning and reports false: processing a
+// duplicate is what an at-least-once sink tolerates, losing data is not.
+func (t *Tracker) Seen(ctx context.Context, key string) bool {
+	seen, err := t.store.Contains(ctx, key)
+	result := "miss"
+	switch {
+	case err != nil:
+		result = "error"
+		logger.WarnCtx(ctx).Err(err).Str("tracker", t.name).
+			Msg("dedup: lookup failed, treating key as unseen")
+	case seen:
+		result = "hit"
+	}
+	telemetry.RecordDedupLookup(ctx, t.name, result)
+	return seen && err == nil
+}
+
+// MarkProcessed records key as processed for the tracker's TTL.
+func (t *Tracker) MarkProcessed(ctx context.Context, key string) error {
+	return t.store.Add(ctx, key, t.ttl)
+}
+
+// BatchKey returns the idempotency key of a batch of tenant.
+func BatchKey(tenant id.Tenant, b id.Batch) string {
+	return string(tenant) + "/batch/" + b.String()
+}
+
+// RecordKey returns the idempotency key of a record of tenant, from the
+// record's own key (batch.Record.Key), hex-encoded as it may be binary.
+func RecordKey(tenant id.Tenant, key []byte) string {
+	return string(tenant) + "/record/" + hex.EncodeToString(key)
+}
@@ -0,0 +1,83 @@
+package dedup
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/planx-lab/planx-common/clock"
+	"github.com/planx-lab/planx-common/config"
+	"github.com/planx-lab/planx-common/id"
+	"github.com/planx-lab/planx-common/logger/logtest"
+)
+
+func TestTracker_SeenMarkProcessed(t *testing.T) {
+	fake := clock.NewFake(time.Unix(0, 0))
+	tr := New(Config{Name: "sink", TTL: config.Duration(time.Hour)}, NewMemory(10, WithClock(fake)))
+	ctx := context.Background()
+
+	if tr.Seen(ctx, "k") {
+		t.Fatal("Seen before MarkProcessed")
+	}
+	if err := tr.MarkProcessed(ctx, "k"); err != nil {
+		t.Fatalf("MarkProcessed: %v", err)
+	}
+	if !tr.Seen(ctx, "k") {
+		t.Fatal("not Seen after MarkProcessed")
+	}
+	fake.Add(time.Hour)
+	if tr.Seen(ctx, "k") {
+		t.Fatal("Seen after the TTL")
+	}
+}
+
+func TestTracker_Defaults(t *testing.T) {
+	tr := New(Config{}, nil)
+	if tr.ttl != DefaultTTL {
+		t.Fatalf("ttl: got %v", tr.ttl)
+	}
+	if m, ok := tr.store.(*Memory); !ok || m.capacity != DefaultCapacity {
+		t.Fatalf("store: got %#v", tr.store)
+	}
+}
+
+type failingStore struct{}
+
+func (failingStore) Contains(context.Context, string) (bool, error) {
+	return true, stderrors.New("down")
+}
+
+func (failingStore) Add(context.Context, string, time.Duration) error {
+	return stderrors.New("down")
+}
+
+func TestTracker_StoreErrorFailsOpen(t *testing.T) {
+	logs := logtest.Capture(t)
+	tr := New(Config{Name: "sink"}, failingStore{})
+	if tr.Seen(context.Background(), "k") {
+		t.Fatal("Seen with a failing store")
+	}
+	entries := logs.Find(zerolog.WarnLevel, "dedup: lookup failed")
+	if len(entries) != 1 || entries[0].Str("tracker") != "sink" {
+		t.Fatalf("entries: %v", entries)
+	}
+	if err := tr.MarkProcessed(context.Background(), "k"); err == nil {
+		t.Fatal("MarkProcessed with a failing store succeeded")
+	}
+}
+
+func TestKeys(t *testing.T) {
+	b, err := id.ParseBatch("01ARZ3NDEKTSV4RRFFQ69G5FAV")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := BatchKey("acme", b); got != "acme/batch/01ARZ3NDEKTSV4RRFFQ69G5FAV" {
+		t.Fatalf("BatchKey: got %q", got)
+	}
+	if got := RecordKey("acme", []byte{0x01, 0xff}); got != "acme/record/01ff" {
+		t.Fatalf("RecordKey: got %q", got)
+	}
+}
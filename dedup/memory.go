@@ -0,0 +1,100 @@
+package dedup
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/planx-lab/planx-common/clock"
+)
+
+// Option configures a Memory store.
+type Option func(*options)
+
+type options struct {
+	clock clock.Clock
+}
+
+// WithClock sets the clock expiries are measured on. The default is
+// clock.Real; tests pass a clock.Fake.
+func WithClock(c clock.Clock) Option {
+	return func(o *options) { o.clock = c }
+}
+
+// Memory is an in-memory Store holding at most a fixed number of keys;
+// when full, adding a key evicts the least recently used one. It never
+// returns errors.
+type Memory struct {
+	capacity int
+	clock    clock.Clock
+
+	mu    sync.Mutex
+	items map[string]*list.Element // of *entry
+	order list.List                // most recently used first
+}
+
+type entry struct {
+	key     string
+	expires time.Time
+}
+
+// NewMemory returns a store holding up to capacity keys. It panics if
+// capacity is not positive.
+func NewMemory(capacity int, opts ...Option) *Memory {
+	if capacity <= 0 {
+		panic("dedup: NewMemory with non-positive capacity")
+	}
+	o := options{clock: clock.Real}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Memory{capacity: capacity, clock: o.clock, items: map[string]*list.Element{}}
+}
+
+// Contains reports whether key is held and not expired.
+func (m *Memory) Contains(_ context.Context, key string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	elem, ok := m.items[key]
+	if !ok {
+		return false, nil
+	}
+	if !m.clock.Now().Before(elem.Value.(*entry).expires) {
+		m.remove(elem)
+		return false, nil
+	}
+	m.order.MoveToFront(elem)
+	return true, nil
+}
+
+// Add holds key for ttl, evicting the least recently used key if full.
+func (m *Memory) Add(_ context.Context, key string, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	expires := m.clock.Now().Add(ttl)
+	if elem, ok := m.items[key]; ok {
+		elem.Value.(*entry).expires = expires
+		m.order.MoveToFront(elem)
+		return nil
+	}
+	m.items[key] = m.order.PushFront(&entry{key: key, expires: expires})
+	for m.order.Len() > m.capacity {
+		m.remove(m.order.Back())
+	}
+	return nil
+}
+
+// Len returns the number of keys held, including expired keys not yet
+// evicted.
+func (m *Memory) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.order.Len()
+}
+
+// remove drops elem. m.mu must be held.
+func (m *Memory) remove(elem *list.Element) {
+	m.order.Remove(elem)
+	delete(m.items, elem.Value.(*entry).key)
+}
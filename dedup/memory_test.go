@@ -0,0 +1,62 @@
+package dedup
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/planx-lab/planx-common/clock"
+)
+
+func TestMemory_EvictsLeastRecentlyUsed(t *testing.T) {
+	m := NewMemory(2)
+	ctx := context.Background()
+	_ = m.Add(ctx, "a", time.Hour)
+	_ = m.Add(ctx, "b", time.Hour)
+	// Touch a so b is the least recently used.
+	if ok, _ := m.Contains(ctx, "a"); !ok {
+		t.Fatal("a missing")
+	}
+	_ = m.Add(ctx, "c", time.Hour)
+	if ok, _ := m.Contains(ctx, "b"); ok {
+		t.Fatal("b not evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if ok, _ := m.Contains(ctx, key); !ok {
+			t.Fatalf("%s evicted", key)
+		}
+	}
+	if got := m.Len(); got != 2 {
+		t.Fatalf("Len: got %d", got)
+	}
+}
+
+func TestMemory_Expiry(t *testing.T) {
+	fake := clock.NewFake(time.Unix(0, 0))
+	m := NewMemory(10, WithClock(fake))
+	ctx := context.Background()
+	_ = m.Add(ctx, "a", time.Minute)
+	fake.Add(30 * time.Second)
+	// Adding again extends the expiry.
+	_ = m.Add(ctx, "a", time.Minute)
+	fake.Add(45 * time.Second)
+	if ok, _ := m.Contains(ctx, "a"); !ok {
+		t.Fatal("a expired early")
+	}
+	fake.Add(15 * time.Second)
+	if ok, _ := m.Contains(ctx, "a"); ok {
+		t.Fatal("a not expired")
+	}
+	if got := m.Len(); got != 0 {
+		t.Fatalf("Len after expiry: got %d", got)
+	}
+}
+
+func TestNewMemory_PanicsOnZeroCapacity(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("no panic")
+		}
+	}()
+	NewMemory(0)
+}
@@ -0,0 +1,58 @@
+package dedup
+
+import (
+	"context"
+	"time"
+
+	"github.com/planx-lab/planx-common/errors"
+)
+
+// RedisClient is the part of a Redis client the Redis store uses. It keeps
+// this module free of a Redis driver; adapting go-redis takes a few lines:
+//
+//	type goRedis struct{ c *redis.Client }
+//
+//	func (r goRedis) Exists(ctx context.Context, key string) (bool, error) {
+//		n, err := r.c.Exists(ctx, key).Result()
+//		return n > 0, err
+//	}
+//
+//	func (r goRedis) Set(ctx context.Context, key string, ttl time.Duration) error {
+//		return r.c.Set(ctx, key, 1, ttl).Err()
+//	}
+type RedisClient interface {
+	// Exists reports whether key exists.
+	Exists(ctx context.Context, key string) (bool, error)
+	// Set sets key, with any value, to expire after ttl.
+	Set(ctx context.Context, key string, ttl time.Duration) error
+}
+
+// Redis is a Store keeping keys in Redis, shared by every tracker using
+// the same prefix, with expiry left to Redis.
+type Redis struct {
+	client RedisClient
+	prefix string
+}
+
+// NewRedis returns a store on client whose keys are prefixed with prefix,
+// e.g. "planx:dedup:s3-sink:".
+func NewRedis(client RedisClient, prefix string) *Redis {
+	return &Redis{client: client, prefix: prefix}
+}
+
+// Contains reports whether key exists in Redis.
+func (r *Redis) Contains(ctx context.Context, key string) (bool, error) {
+	ok, err := r.client.Exists(ctx, r.prefix+key)
+	if err != nil {
+		return false, errors.FromContext(ctx, errors.WrapTransportError(err, "dedup: redis exists", true))
+	}
+	return ok, nil
+}
+
+// Add sets key in Redis to expire after ttl.
+func (r *Redis) Add(ctx context.Context, key string, ttl time.Duration) error {
+	if err := r.client.Set(ctx, r.prefix+key, ttl); err != nil {
+		return errors.FromContext(ctx, errors.WrapTransportError(err, "dedup: redis set", true))
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package dedup
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+	"time"
+
+	"github.com/planx-lab/planx-common/errors"
+)
+
+type fakeRedis struct {
+	keys map[string]time.Duration
+	err  error
+}
+
+func (f *fakeRedis) Exists(_ context.Context, key string) (bool, error) {
+	_, ok := f.keys[key]
+	return ok, f.err
+}
+
+func (f *fakeRedis) Set(_ context.Context, key string, ttl time.Duration) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.keys[key] = ttl
+	return nil
+}
+
+func TestRedis(t *testing.T) {
+	client := &fakeRedis{keys: map[string]time.Duration{}}
+	tr := New(Config{}, NewRedis(client, "planx:dedup:sink:"))
+	ctx := context.Background()
+	if err := tr.MarkProcessed(ctx, "k"); err != nil {
+		t.Fatalf("MarkProcessed: %v", err)
+	}
+	if ttl := client.keys["planx:dedup:sink:k"]; ttl != DefaultTTL {
+		t.Fatalf("keys: %v", client.keys)
+	}
+	if !tr.Seen(ctx, "k") {
+		t.Fatal("not Seen")
+	}
+}
+
+func TestRedis_ErrorsAreRetryable(t *testing.T) {
+	store := NewRedis(&fakeRedis{err: stderrors.New("connection refused")}, "")
+	_, err := store.Contains(context.Background(), "k")
+	if !errors.IsRetryable(err) {
+		t.Fatalf("Contains: got %v", err)
+	}
+	if err := store.Add(context.Background(), "k", time.Minute); !errors.IsRetryable(err) {
+		t.Fatalf("Add: got %v", err)
+	}
+}
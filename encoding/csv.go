@@ -0,0 +1,327 @@
+package encoding
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	stderrors "errors"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/planx-lab/planx-common/batch"
+	"github.com/planx-lab/planx-common/errors"
+)
+
+// CSVMapping maps CSV columns to batch.Record fields:
+//
+//	csv:
+//	  key: order_id
+//	  event_time: created_at
+//	  time_layout: "2006-01-02 15:04:05"
+//	  headers: [region]
+//
+// Every column, mapped or not, also goes into the record's payload as a
+// JSON object of strings in column order, so no data is lost. Columns
+// named here must be in the file's header row.
+type CSVMapping struct {
+	Key        string   `yaml:"key" json:"key"`
+	EventTime  string   `yaml:"event_time" json:"event_time"`
+	TimeLayout string   `yaml:"time_layout" json:"time_layout"` // default time.RFC3339Nano
+	Headers    []string `yaml:"headers" json:"headers"`
+	Comma      string   `yaml:"comma" json:"comma" validate:"omitempty,max=1"` // default ","
+}
+
+func (m CSVMapping) layout() string {
+	if m.TimeLayout == "" {
+		return time.RFC3339Nano
+	}
+	return m.TimeLayout
+}
+
+func (m CSVMapping) comma() rune {
+	if m.Comma == "" {
+		return ','
+	}
+	return rune(m.Comma[0])
+}
+
+// csvBufferSize is the buffer csv.Reader reads through, which a row may
+// pull in on top of its own bytes.
+const csvBufferSize = 4096
+
+// CSVReader reads CSV rows as records. The first row is the header.
+type CSVReader struct {
+	cr        *csv.Reader
+	limit     *rowLimiter
+	mapping   CSVMapping
+	maxSize   int
+	columns   []string
+	headerErr error // sticky: without a header no row can be read
+	key       int   // column indexes, -1 if unmapped
+	time      int
+	headers   []int
+	line      int // line of the last row read
+}
+
+// NewCSVReader returns a reader of r mapping columns per mapping, with
+// rows of up to DefaultMaxLineSize bytes unless WithMaxSize says
+// otherwise. The limit also bounds what one row may make the reader
+// buffer, so an unterminated quote does not pull in the rest of the input.
+func NewCSVReader(r io.Reader, mapping CSVMapping, opts ...Option) *CSVReader {
+	o := newOptions(DefaultMaxLineSize, opts)
+	limit := &rowLimiter{br: bufio.NewReader(r), max: int64(o.maxSize) + csvBufferSize}
+	cr := csv.NewReader(bufio.NewReaderSize(limit, csvBufferSize))
+	cr.Comma = mapping.comma()
+	cr.FieldsPerRecord = -1
+	return &CSVReader{cr: cr, limit: limit, mapping: mapping, maxSize: o.maxSize}
+}
+
+// errRowTooLarge is returned by rowLimiter once a row reads past its
+// limit; CSVReader reports it as a Skippable error.
+var errRowTooLarge = stderrors.New("encoding: CSV row exceeds the size limit")
+
+// rowLimiter fails reads once the row being parsed has read more than max
+// bytes, then skips the rest of the physical line so parsing resumes on
+// the next one. CSVReader calls start before each row.
+type rowLimiter struct {
+	br      *bufio.Reader
+	max     int64
+	n       int64
+	tripped bool
+}
+
+func (l *rowLimiter) start() {
+	l.n = 0
+	l.tripped = false
+}
+
+func (l *rowLimiter) Read(p []byte) (int, error) {
+	if l.tripped {
+		return 0, errRowTooLarge
+	}
+	if rest := l.max - l.n; int64(len(p)) > rest {
+		p = p[:max(rest, 0)]
+	}
+	if len(p) == 0 {
+		l.tripped = true
+		for {
+			if _, err := l.br.ReadSlice('\n'); err != bufio.ErrBufferFull {
+				break
+			}
+		}
+		return 0, errRowTooLarge
+	}
+	n, err := l.br.Read(p)
+	l.n += int64(n)
+	return n, err
+}
+
+// Columns returns the header row, once the first record has been read.
+func (r *CSVReader) Columns() []string { return r.columns }
+
+// Next returns the next row as a record. A row that does not parse, has
+// the wrong number of fields, holds an invalid event time or exceeds the
+// size limit yields a Skippable error naming its line; the following call
+// reads on. A missing header row or mapped column is a config error. Next
+// returns io.EOF at the end of the input.
+func (r *CSVReader) Next() (batch.Record, error) {
+	if r.headerErr != nil {
+		return batch.Record{}, r.headerErr
+	}
+	if r.columns == nil {
+		if err := r.readHeader(); err != nil {
+			r.headerErr = err
+			return batch.Record{}, err
+		}
+	}
+	fields, err := r.read()
+	if err != nil {
+		return batch.Record{}, err
+	}
+	line := r.line
+	if len(fields) != len(r.columns) {
+		return batch.Record{}, malformed(nil, "line", line).
+			WithField("fields", len(fields)).
+			WithField("columns", len(r.columns))
+	}
+
+	var rec batch.Record
+	var payload bytes.Buffer
+	payload.WriteByte('{')
+	for i, col := range r.columns {
+		if i > 0 {
+			payload.WriteByte(',')
+		}
+		writeJSONString(&payload, col)
+		payload.WriteByte(':')
+		writeJSONString(&payload, fields[i])
+	}
+	payload.WriteByte('}')
+	rec.Payload = payload.Bytes()
+	if r.key >= 0 {
+		rec.Key = []byte(fields[r.key])
+	}
+	if r.time >= 0 && fields[r.time] != "" {
+		t, err := time.Parse(r.mapping.layout(), fields[r.time])
+		if err != nil {
+			return batch.Record{}, malformed(err, "line", line).WithField("column", r.mapping.EventTime)
+		}
+		rec.EventTime = t
+	}
+	if len(r.headers) > 0 {
+		rec.Headers = make(map[string]string, len(r.headers))
+		for _, i := range r.headers {
+			rec.Headers[r.columns[i]] = fields[i]
+		}
+	}
+	if rec.Size() > int64(r.maxSize) {
+		return batch.Record{}, tooLarge("line", line, r.maxSize)
+	}
+	return rec, nil
+}
+
+// read reads the next row, tracking its line. Errors other than io.EOF
+// are Skippable.
+func (r *CSVReader) read() ([]string, error) {
+	r.limit.start()
+	fields, err := r.cr.Read()
+	if r.limit.tripped {
+		r.line++
+		return nil, tooLarge("line", r.line, r.maxSize)
+	}
+	if err == io.EOF {
+		return nil, err
+	}
+	if err != nil {
+		// FieldPos is not usable after an error, as the row may have no
+		// fields at all.
+		r.line++
+		if pe, ok := err.(*csv.ParseError); ok {
+			r.line = pe.Line
+		}
+		return nil, malformed(err, "line", r.line)
+	}
+	r.line, _ = r.cr.FieldPos(0)
+	return fields, nil
+}
+
+func (r *CSVReader) readHeader() error {
+	header, err := r.read()
+	if err == io.EOF {
+		return errors.NewConfigError("encoding: CSV input has no header row")
+	}
+	if err != nil {
+		return errors.WrapConfigError(err, "encoding: reading CSV header row")
+	}
+	r.columns = slices.Clone(header)
+	index := func(col string) (int, error) {
+		if col == "" {
+			return -1, nil
+		}
+		if i := slices.Index(r.columns, col); i >= 0 {
+			return i, nil
+		}
+		return -1, errors.NewConfigErrorf("encoding: CSV header has no column %q", col).WithField("columns", r.columns)
+	}
+	if r.key, err = index(r.mapping.Key); err != nil {
+		return err
+	}
+	if r.time, err = index(r.mapping.EventTime); err != nil {
+		return err
+	}
+	for _, col := range r.mapping.Headers {
+		i, err := index(col)
+		if err != nil {
+			return err
+		}
+		r.headers = append(r.headers, i)
+	}
+	return nil
+}
+
+func writeJSONString(buf *bytes.Buffer, s string) {
+	data, _ := json.Marshal(s) // cannot fail for a string
+	buf.Write(data)
+}
+
+// CSVWriter writes records as CSV rows under a header row, the inverse of
+// CSVReader: mapped columns come from the record's key, event time and
+// headers, and the rest from the fields of its payload, a JSON object.
+// Output is buffered; call Flush when done.
+type CSVWriter struct {
+	cw          *csv.Writer
+	columns     []string
+	mapping     CSVMapping
+	wroteHeader bool
+	fields      []string
+}
+
+// NewCSVWriter returns a writer to w of the given columns.
+func NewCSVWriter(w io.Writer, columns []string, mapping CSVMapping) *CSVWriter {
+	cw := csv.NewWriter(w)
+	cw.Comma = mapping.comma()
+	return &CSVWriter{cw: cw, columns: columns, mapping: mapping, fields: make([]string, len(columns))}
+}
+
+// Write writes rec as one row. Payload fields missing from the record are
+// written empty; strings are written as is and other values as JSON.
+func (w *CSVWriter) Write(rec batch.Record) error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	var payload map[string]json.RawMessage
+	if len(rec.Payload) > 0 {
+		if err := json.Unmarshal(rec.Payload, &payload); err != nil {
+			return errors.Wrap(err, "encoding: record payload is not a JSON object").WithCode(CodeMalformed)
+		}
+	}
+	for i, col := range w.columns {
+		switch {
+		case col == w.mapping.Key && col != "":
+			w.fields[i] = string(rec.Key)
+		case col == w.mapping.EventTime && col != "":
+			w.fields[i] = ""
+			if !rec.EventTime.IsZero() {
+				w.fields[i] = rec.EventTime.Format(w.mapping.layout())
+			}
+		case slices.Contains(w.mapping.Headers, col):
+			w.fields[i] = rec.Headers[col]
+		default:
+			w.fields[i] = jsonText(payload[col])
+		}
+	}
+	return w.cw.Write(w.fields)
+}
+
+func (w *CSVWriter) writeHeader() error {
+	if w.wroteHeader {
+		return nil
+	}
+	w.wroteHeader = true
+	return w.cw.Write(w.columns)
+}
+
+// jsonText returns a JSON value as CSV text: strings unquoted, null empty
+// and anything else as JSON.
+func jsonText(v json.RawMessage) string {
+	if len(v) == 0 || string(v) == "null" {
+		return ""
+	}
+	var s string
+	if v[0] == '"' && json.Unmarshal(v, &s) == nil {
+		return s
+	}
+	return string(v)
+}
+
+// Flush writes the header row, if no record was written, and buffered
+// rows to the underlying writer.
+func (w *CSVWriter) Flush() error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	w.cw.Flush()
+	return w.cw.Error()
+}
@@ -0,0 +1,187 @@
+package encoding
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/planx-lab/planx-common/batch"
+)
+
+var orderMapping = CSVMapping{Key: "id", EventTime: "at", Headers: []string{"region"}}
+
+func TestCSVReader(t *testing.T) {
+	input := "id,at,region,amount\n" +
+		"o-1,2024-05-01T10:00:00Z,eu,12.5\n" +
+		"o-2,,us,\"1,000\"\n"
+	r := NewCSVReader(strings.NewReader(input), orderMapping)
+
+	rec, err := r.Next()
+	if err != nil {
+		t.Fatalf("Next: %v", err)
+	}
+	if string(rec.Key) != "o-1" || !rec.EventTime.Equal(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)) || rec.Headers["region"] != "eu" {
+		t.Fatalf("record = %+v", rec)
+	}
+	if got := string(rec.Payload); got != `{"id":"o-1","at":"2024-05-01T10:00:00Z","region":"eu","amount":"12.5"}` {
+		t.Fatalf("payload = %s", got)
+	}
+
+	rec, err = r.Next()
+	if err != nil || !rec.EventTime.IsZero() {
+		t.Fatalf("second row = %+v, %v", rec, err)
+	}
+	var payload map[string]string
+	if err := json.Unmarshal(rec.Payload, &payload); err != nil || payload["amount"] != "1,000" {
+		t.Fatalf("payload = %s, %v", rec.Payload, err)
+	}
+	if _, err := r.Next(); err != io.EOF {
+		t.Fatalf("Next at end = %v", err)
+	}
+	if strings.Join(r.Columns(), ",") != "id,at,region,amount" {
+		t.Fatalf("Columns = %v", r.Columns())
+	}
+}
+
+func TestCSVReader_Recovers(t *testing.T) {
+	input := "id,at,region\n" +
+		"o-1,yesterday,eu\n" +
+		"o-2,2024-05-01T10:00:00Z\n" +
+		"o-3,2024-05-01T10:00:00Z,\"" + strings.Repeat("x", 100) + "\"\n" +
+		"o-4,,eu\n"
+	r := NewCSVReader(strings.NewReader(input), orderMapping, WithMaxSize(64))
+	var keys []string
+	var errs []error
+	for {
+		rec, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if !Skippable(err) {
+				t.Fatalf("Next: %v", err)
+			}
+			errs = append(errs, err)
+			continue
+		}
+		keys = append(keys, string(rec.Key))
+	}
+	if strings.Join(keys, ",") != "o-4" || len(errs) != 3 {
+		t.Fatalf("keys %v, errors %v", keys, errs)
+	}
+}
+
+// countingReader counts the bytes read from it.
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += n
+	return n, err
+}
+
+func TestCSVReader_LongRowBounded(t *testing.T) {
+	input := "id,note\n" +
+		"o-1," + strings.Repeat("x", 1<<20) + "\n" +
+		"o-2,ok\n"
+	src := &countingReader{r: strings.NewReader(input)}
+	r := NewCSVReader(src, CSVMapping{Key: "id"}, WithMaxSize(64))
+	if _, err := r.Next(); !Skippable(err) {
+		t.Fatalf("long row = %v, want a Skippable error", err)
+	}
+	rec, err := r.Next()
+	if err != nil || string(rec.Key) != "o-2" {
+		t.Fatalf("row after the long one = %+v, %v", rec, err)
+	}
+}
+
+func TestCSVReader_UnterminatedQuote(t *testing.T) {
+	input := "id,note\n" +
+		"o-1,\"never closed\n" +
+		strings.Repeat("o-x,y\n", 1<<16)
+	src := &countingReader{r: strings.NewReader(input)}
+	r := NewCSVReader(src, CSVMapping{Key: "id"}, WithMaxSize(64))
+	if _, err := r.Next(); !Skippable(err) {
+		t.Fatalf("unterminated quote = %v, want a Skippable error", err)
+	}
+	if src.n > 16<<10 {
+		t.Fatalf("read %d bytes for one bad row", src.n)
+	}
+	for {
+		_, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil && !Skippable(err) {
+			t.Fatalf("Next: %v", err)
+		}
+	}
+}
+
+func TestCSVReader_BareQuote(t *testing.T) {
+	r := NewCSVReader(strings.NewReader("id,note\nx\"y,z\no-2,ok\n"), CSVMapping{Key: "id"})
+	if _, err := r.Next(); !Skippable(err) {
+		t.Fatalf("bare quote = %v, want a Skippable error", err)
+	}
+	if rec, err := r.Next(); err != nil || string(rec.Key) != "o-2" {
+		t.Fatalf("next row = %+v, %v", rec, err)
+	}
+}
+
+func TestCSVReader_MissingColumn(t *testing.T) {
+	r := NewCSVReader(strings.NewReader("a,b\nid,2\n"), CSVMapping{Key: "id"})
+	_, err := r.Next()
+	if err == nil || Skippable(err) || !strings.Contains(err.Error(), `"id"`) {
+		t.Fatalf("Next = %v, want a config error naming the column", err)
+	}
+	// The header error sticks rather than the next row becoming the header.
+	if _, again := r.Next(); again != err {
+		t.Fatalf("second Next = %v, want %v", again, err)
+	}
+	if _, err := NewCSVReader(strings.NewReader(""), CSVMapping{}).Next(); err == nil || err == io.EOF {
+		t.Fatalf("empty input = %v, want a missing header error", err)
+	}
+}
+
+func TestCSVWriter_RoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewCSVWriter(&buf, []string{"id", "at", "region", "amount", "note"}, orderMapping)
+	rec := batch.Record{
+		Key:       []byte("o-1"),
+		EventTime: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+		Headers:   map[string]string{"region": "eu"},
+		Payload:   []byte(`{"amount":12.5,"note":"a, b","extra":true}`),
+	}
+	if err := w.Write(rec); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := w.Write(batch.Record{Payload: []byte("[1]")}); !Skippable(err) {
+		t.Fatalf("Write of a non-object payload = %v", err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	want := "id,at,region,amount,note\no-1,2024-05-01T10:00:00Z,eu,12.5,\"a, b\"\n"
+	if buf.String() != want {
+		t.Fatalf("output = %q, want %q", buf.String(), want)
+	}
+
+	got, err := NewCSVReader(&buf, orderMapping).Next()
+	if err != nil || string(got.Key) != "o-1" || got.Headers["region"] != "eu" || !got.EventTime.Equal(rec.EventTime) {
+		t.Fatalf("read back %+v, %v", got, err)
+	}
+}
+
+func TestCSVWriter_HeaderOnly(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewCSVWriter(&buf, []string{"a", "b"}, CSVMapping{Comma: ";"})
+	if err := w.Flush(); err != nil || buf.String() != "a;b\n" {
+		t.Fatalf("output = %q, %v", buf.String(), err)
+	}
+}
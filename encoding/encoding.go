@@ -0,0 +1,92 @@
+// Package encoding provides streaming readers and writers for the
+// line-based and framed formats file and socket sources and sinks
+// exchange: JSON Lines, CSV mapped to batch.Record, and length-prefixed
+// binary frames.
+// Engine-side utilities only — must not be imported by SDK or plugins.
+//
+// Readers enforce a size limit per line, row or frame and recover from bad
+// input: a line that is too long or malformed is reported with its line
+// number and skipped, and the next call continues with the following one.
+// Skippable tells such errors apart from those that end the stream:
+//
+//	for {
+//		line, err := r.Next()
+//		if err == io.EOF {
+//			break
+//		}
+//		if encoding.Skippable(err) {
+//			deadLetter(err)
+//			continue
+//		}
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+package encoding
+
+import (
+	"github.com/planx-lab/planx-common/errors"
+)
+
+// Default size limits.
+const (
+	DefaultMaxLineSize  = 1 << 20  // JSON Lines lines and CSV rows
+	DefaultMaxFrameSize = 16 << 20 // binary frames
+)
+
+// Error codes of skippable input errors.
+const (
+	CodeTooLarge  errors.Code = "PLX-ENCODING-TOO-LARGE"
+	CodeMalformed errors.Code = "PLX-ENCODING-MALFORMED"
+)
+
+func init() {
+	errors.RegisterCode(errors.CodeInfo{Code: CodeTooLarge, Description: "line, row or frame exceeds the size limit"})
+	errors.RegisterCode(errors.CodeInfo{Code: CodeMalformed, Description: "line, row or frame is malformed"})
+}
+
+// Skippable reports whether err concerns a single line, row or frame that
+// the reader has skipped, so reading may continue.
+func Skippable(err error) bool {
+	code := errors.CodeOf(err)
+	return code == CodeTooLarge || code == CodeMalformed
+}
+
+// Option configures a reader.
+type Option func(*options)
+
+type options struct {
+	maxSize int
+}
+
+// WithMaxSize sets the largest line, row or frame accepted, in bytes.
+func WithMaxSize(n int) Option {
+	return func(o *options) {
+		if n > 0 {
+			o.maxSize = n
+		}
+	}
+}
+
+func newOptions(def int, opts []Option) options {
+	o := options{maxSize: def}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+func tooLarge(what string, pos int, max int) *errors.Error {
+	return errors.NewWithCode(CodeTooLarge, "encoding: "+what+" exceeds the size limit").
+		WithField(what, pos).
+		WithField("max_size", max)
+}
+
+func malformed(err error, what string, pos int) *errors.Error {
+	msg := "encoding: malformed " + what
+	if err == nil {
+		return errors.NewWithCode(CodeMalformed, msg).WithField(what, pos)
+	}
+	return errors.Wrap(err, msg).WithCode(CodeMalformed).WithField(what, pos)
+}
@@ -0,0 +1,27 @@
+package encoding
+
+import (
+	"io"
+	"testing"
+
+	"github.com/planx-lab/planx-common/errors"
+)
+
+func TestSkippable(t *testing.T) {
+	if !Skippable(tooLarge("line", 3, 10)) || !Skippable(malformed(nil, "line", 3)) {
+		t.Fatalf("input errors not skippable")
+	}
+	if Skippable(io.ErrUnexpectedEOF) || Skippable(nil) || Skippable(errors.New("other")) {
+		t.Fatalf("other errors reported skippable")
+	}
+}
+
+func TestErrorFields(t *testing.T) {
+	err := malformed(io.ErrUnexpectedEOF, "frame", 7)
+	if got := errors.Fields(err)["frame"]; got != 7 {
+		t.Fatalf("frame field = %v", got)
+	}
+	if errors.CodeOf(err) != CodeMalformed {
+		t.Fatalf("code = %s", errors.CodeOf(err))
+	}
+}
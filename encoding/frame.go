@@ -0,0 +1,106 @@
+package encoding
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"math"
+
+	"github.com/planx-lab/planx-common/errors"
+)
+
+// FrameHeaderSize is the size of a frame's length prefix: a big-endian
+// uint32.
+const FrameHeaderSize = 4
+
+// FrameReader reads length-prefixed frames: a 4-byte big-endian length
+// followed by that many bytes.
+type FrameReader struct {
+	br      *bufio.Reader
+	maxSize int
+	header  [FrameHeaderSize]byte
+	buf     []byte
+	frame   int
+}
+
+// NewFrameReader returns a reader of r with frames of up to
+// DefaultMaxFrameSize bytes unless WithMaxSize says otherwise.
+func NewFrameReader(r io.Reader, opts ...Option) *FrameReader {
+	o := newOptions(DefaultMaxFrameSize, opts)
+	return &FrameReader{br: bufio.NewReader(r), maxSize: o.maxSize}
+}
+
+// Next returns the next frame's payload. The slice is only valid until the
+// next call. A frame over the size limit is skipped without being buffered
+// and yields a Skippable error; the following call reads the next frame.
+// Next returns io.EOF at the end of the input, and a malformed error if
+// the input ends inside a frame, which short reads from a socket do not
+// cause.
+func (r *FrameReader) Next() ([]byte, error) {
+	if _, err := io.ReadFull(r.br, r.header[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, malformed(err, "frame", r.frame+1)
+		}
+		return nil, err
+	}
+	r.frame++
+	n := int64(binary.BigEndian.Uint32(r.header[:]))
+	if n > int64(r.maxSize) {
+		if _, err := io.CopyN(io.Discard, r.br, n); err != nil {
+			return nil, malformed(eofUnexpected(err), "frame", r.frame)
+		}
+		return nil, tooLarge("frame", r.frame, r.maxSize)
+	}
+	if cap(r.buf) < int(n) {
+		r.buf = make([]byte, n)
+	}
+	r.buf = r.buf[:n]
+	if _, err := io.ReadFull(r.br, r.buf); err != nil {
+		return nil, malformed(eofUnexpected(err), "frame", r.frame)
+	}
+	return r.buf, nil
+}
+
+// Frame returns the number of the last frame read, from 1.
+func (r *FrameReader) Frame() int { return r.frame }
+
+func eofUnexpected(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// FrameWriter writes length-prefixed frames. Output is buffered; call
+// Flush when done or to push frames out on a socket.
+type FrameWriter struct {
+	bw      *bufio.Writer
+	maxSize int64 // int64 so it holds math.MaxUint32 on 32-bit targets
+	header  [FrameHeaderSize]byte
+}
+
+// NewFrameWriter returns a writer to w that refuses frames above
+// DefaultMaxFrameSize bytes unless WithMaxSize says otherwise, so it never
+// writes what the peer's reader would skip.
+func NewFrameWriter(w io.Writer, opts ...Option) *FrameWriter {
+	o := newOptions(DefaultMaxFrameSize, opts)
+	return &FrameWriter{bw: bufio.NewWriter(w), maxSize: min(int64(o.maxSize), math.MaxUint32)}
+}
+
+// Write writes p as one frame.
+func (w *FrameWriter) Write(p []byte) error {
+	if int64(len(p)) > w.maxSize {
+		return errors.NewWithCode(CodeTooLarge, "encoding: frame exceeds the size limit").
+			WithField("size", len(p)).
+			WithField("max_size", w.maxSize)
+	}
+	binary.BigEndian.PutUint32(w.header[:], uint32(len(p)))
+	if _, err := w.bw.Write(w.header[:]); err != nil {
+		return err
+	}
+	_, err := w.bw.Write(p)
+	return err
+}
+
+// Flush writes buffered frames to the underlying writer.
+func (w *FrameWriter) Flush() error { return w.bw.Flush() }
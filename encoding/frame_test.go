@@ -0,0 +1,79 @@
+package encoding
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+	"testing/iotest"
+)
+
+func TestFrames_RoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewFrameWriter(&buf)
+	frames := [][]byte{[]byte("first"), {}, bytes.Repeat([]byte("z"), 70000)}
+	for _, f := range frames {
+		if err := w.Write(f); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	// One byte per read, as a slow socket might deliver.
+	r := NewFrameReader(iotest.OneByteReader(&buf))
+	for i, want := range frames {
+		got, err := r.Next()
+		if err != nil || !bytes.Equal(got, want) {
+			t.Fatalf("frame %d: %d bytes, %v", i, len(got), err)
+		}
+	}
+	if _, err := r.Next(); err != io.EOF {
+		t.Fatalf("Next at end = %v", err)
+	}
+}
+
+func TestFrameReader_SkipsOversized(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewFrameWriter(&buf)
+	_ = w.Write([]byte("ok"))
+	_ = w.Write(bytes.Repeat([]byte("x"), 100))
+	_ = w.Write([]byte("after"))
+	_ = w.Flush()
+
+	r := NewFrameReader(&buf, WithMaxSize(10))
+	if got, err := r.Next(); err != nil || string(got) != "ok" {
+		t.Fatalf("frame 1 = %q, %v", got, err)
+	}
+	if _, err := r.Next(); !Skippable(err) {
+		t.Fatalf("oversized frame = %v, want skippable", err)
+	}
+	if got, err := r.Next(); err != nil || string(got) != "after" {
+		t.Fatalf("frame 3 = %q, %v", got, err)
+	}
+	if r.Frame() != 3 {
+		t.Fatalf("Frame = %d", r.Frame())
+	}
+}
+
+func TestFrameReader_Truncated(t *testing.T) {
+	header := make([]byte, FrameHeaderSize)
+	binary.BigEndian.PutUint32(header, 10)
+	for name, input := range map[string][]byte{
+		"header": header[:2],
+		"body":   append(header, "short"...),
+	} {
+		_, err := NewFrameReader(bytes.NewReader(input)).Next()
+		if !Skippable(err) || !bytes.Contains([]byte(err.Error()), []byte("malformed")) {
+			t.Fatalf("truncated %s: %v", name, err)
+		}
+	}
+}
+
+func TestFrameWriter_RefusesOversized(t *testing.T) {
+	w := NewFrameWriter(io.Discard, WithMaxSize(4))
+	if err := w.Write([]byte("too long")); !Skippable(err) {
+		t.Fatalf("Write = %v, want a size error", err)
+	}
+}
@@ -0,0 +1,142 @@
+package encoding
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+
+	"github.com/planx-lab/planx-common/errors"
+)
+
+// JSONLReader reads JSON Lines: one JSON value per line. Blank lines are
+// skipped, and a trailing "\r" is dropped.
+type JSONLReader struct {
+	br      *bufio.Reader
+	maxSize int
+	buf     []byte
+	line    int
+}
+
+// NewJSONLReader returns a reader of r with lines of up to
+// DefaultMaxLineSize bytes unless WithMaxSize says otherwise.
+func NewJSONLReader(r io.Reader, opts ...Option) *JSONLReader {
+	o := newOptions(DefaultMaxLineSize, opts)
+	return &JSONLReader{br: bufio.NewReader(r), maxSize: o.maxSize}
+}
+
+// Next returns the next value. The slice is only valid until the next
+// call. A line over the size limit or that is not valid JSON yields a
+// Skippable error naming the line; the following call reads on. Next
+// returns io.EOF at the end of the input.
+func (r *JSONLReader) Next() (json.RawMessage, error) {
+	for {
+		line, err := r.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		if !json.Valid(line) {
+			return nil, malformed(nil, "line", r.line)
+		}
+		return line, nil
+	}
+}
+
+// Decode reads the next value into v. Errors are those of Next, and a
+// value that does not fit v is Skippable too.
+func (r *JSONLReader) Decode(v interface{}) error {
+	line, err := r.Next()
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(line, v); err != nil {
+		return malformed(err, "line", r.line)
+	}
+	return nil
+}
+
+// Line returns the number of the last line read, from 1.
+func (r *JSONLReader) Line() int { return r.line }
+
+// readLine returns the next line without its line ending, discarding the
+// rest of a line once it exceeds the limit so memory stays bounded.
+func (r *JSONLReader) readLine() ([]byte, error) {
+	r.buf = r.buf[:0]
+	over := false
+	for {
+		chunk, err := r.br.ReadSlice('\n')
+		if !over {
+			// Allow for the "\r\n" still to be trimmed.
+			if len(r.buf)+len(chunk) > r.maxSize+2 {
+				over = true
+				r.buf = r.buf[:0]
+			} else {
+				r.buf = append(r.buf, chunk...)
+			}
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err == io.EOF {
+			if len(r.buf) == 0 && !over {
+				return nil, io.EOF
+			}
+		} else if err != nil {
+			return nil, err
+		}
+		break
+	}
+	r.line++
+	line := bytes.TrimSuffix(bytes.TrimSuffix(r.buf, []byte("\n")), []byte("\r"))
+	if over || len(line) > r.maxSize {
+		return nil, tooLarge("line", r.line, r.maxSize)
+	}
+	return line, nil
+}
+
+// JSONLWriter writes JSON Lines. Output is buffered; call Flush when done.
+type JSONLWriter struct {
+	bw *bufio.Writer
+}
+
+// NewJSONLWriter returns a writer to w.
+func NewJSONLWriter(w io.Writer) *JSONLWriter {
+	return &JSONLWriter{bw: bufio.NewWriter(w)}
+}
+
+// Write writes v, encoded as JSON, as one line.
+func (w *JSONLWriter) Write(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return w.writeLine(data)
+}
+
+// WriteRaw writes the JSON value data as one line, compacting it if it
+// spans several lines.
+func (w *JSONLWriter) WriteRaw(data []byte) error {
+	if bytes.ContainsAny(data, "\r\n") {
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, data); err != nil {
+			return errors.Wrap(err, "encoding: invalid JSON value").WithCode(CodeMalformed)
+		}
+		data = buf.Bytes()
+	} else if !json.Valid(data) {
+		return errors.NewWithCode(CodeMalformed, "encoding: invalid JSON value")
+	}
+	return w.writeLine(data)
+}
+
+func (w *JSONLWriter) writeLine(data []byte) error {
+	if _, err := w.bw.Write(data); err != nil {
+		return err
+	}
+	return w.bw.WriteByte('\n')
+}
+
+// Flush writes buffered lines to the underlying writer.
+func (w *JSONLWriter) Flush() error { return w.bw.Flush() }
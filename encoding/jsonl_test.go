@@ -0,0 +1,102 @@
+package encoding
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func readAll(t *testing.T, r *JSONLReader) (lines []string, errs []error) {
+	t.Helper()
+	for {
+		line, err := r.Next()
+		if err == io.EOF {
+			return lines, errs
+		}
+		if err != nil {
+			if !Skippable(err) {
+				t.Fatalf("Next: %v", err)
+			}
+			errs = append(errs, err)
+			continue
+		}
+		lines = append(lines, string(line))
+	}
+}
+
+func TestJSONLReader(t *testing.T) {
+	input := "{\"a\":1}\r\n\n  \n[1,2]\n\"last\""
+	lines, errs := readAll(t, NewJSONLReader(strings.NewReader(input)))
+	if len(errs) != 0 {
+		t.Fatalf("errors: %v", errs)
+	}
+	want := []string{`{"a":1}`, `[1,2]`, `"last"`}
+	if strings.Join(lines, "|") != strings.Join(want, "|") {
+		t.Fatalf("lines = %q, want %q", lines, want)
+	}
+}
+
+func TestJSONLReader_Recovers(t *testing.T) {
+	long := `{"pad":"` + strings.Repeat("x", 100) + `"}`
+	input := "{\"a\":1}\n{broken\n" + long + "\n{\"b\":2}\n"
+	r := NewJSONLReader(strings.NewReader(input), WithMaxSize(64))
+	lines, errs := readAll(t, r)
+	if strings.Join(lines, "|") != `{"a":1}|{"b":2}` {
+		t.Fatalf("lines = %q", lines)
+	}
+	if len(errs) != 2 {
+		t.Fatalf("errors = %v, want 2", errs)
+	}
+	if !strings.Contains(errs[0].Error(), "malformed") || !strings.Contains(errs[1].Error(), "size limit") {
+		t.Fatalf("errors = %v", errs)
+	}
+	if r.Line() != 4 {
+		t.Fatalf("Line = %d, want 4", r.Line())
+	}
+}
+
+func TestJSONLReader_LongLineSmallBuffer(t *testing.T) {
+	// Lines longer than bufio's buffer but within the limit are read whole.
+	value := `"` + strings.Repeat("y", 10000) + `"`
+	r := NewJSONLReader(strings.NewReader(value+"\n1\n"), WithMaxSize(20000))
+	lines, errs := readAll(t, r)
+	if len(errs) != 0 || len(lines) != 2 || lines[0] != value {
+		t.Fatalf("got %d lines, errors %v", len(lines), errs)
+	}
+}
+
+func TestJSONLReader_Decode(t *testing.T) {
+	r := NewJSONLReader(strings.NewReader("{\"n\":1}\n{\"n\":\"x\"}\n"))
+	var v struct{ N int }
+	if err := r.Decode(&v); err != nil || v.N != 1 {
+		t.Fatalf("Decode = %v, %+v", err, v)
+	}
+	if err := r.Decode(&v); !Skippable(err) {
+		t.Fatalf("Decode of a mismatched value = %v, want skippable", err)
+	}
+	if err := r.Decode(&v); !errors.Is(err, io.EOF) {
+		t.Fatalf("Decode at end = %v", err)
+	}
+}
+
+func TestJSONLWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewJSONLWriter(&buf)
+	if err := w.Write(map[string]string{"msg": "a\nb"}); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := w.WriteRaw([]byte("{\n  \"x\": 1\n}")); err != nil {
+		t.Fatalf("WriteRaw: %v", err)
+	}
+	if err := w.WriteRaw([]byte("{nope")); !Skippable(err) {
+		t.Fatalf("WriteRaw of invalid JSON = %v", err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if got, want := buf.String(), "{\"msg\":\"a\\nb\"}\n{\"x\":1}\n"; got != want {
+		t.Fatalf("output = %q, want %q", got, want)
+	}
+}
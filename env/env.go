@@ -0,0 +1,209 @@
+// Package env provides typed access to environment variables, replacing
+// bare os.Getenv calls whose typos and bad values go unnoticed.
+// Engine-side utilities only — must not be imported by SDK or plugins.
+//
+// Accessors take the default used when the variable is unset; a variable
+// that is set but does not parse is logged, by name and length only, and
+// also falls back to the default. The Required variants return a
+// *errors.ConfigError instead, which likewise leaves out the value:
+//
+//	workers := env.Int("PLANX_WORKERS", 4)
+//	token, err := env.RequiredString("PLANX_ADMIN_TOKEN")
+//
+// WithPrefix namespaces the names, and Parse fills a struct from `env`
+// tags. Every variable read through this package, including config's
+// environment overrides and ${VAR} expansion, is recorded by name without
+// its value, so Reads and Handler can show which ones a process consulted.
+package env
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/planx-lab/planx-common/errors"
+	"github.com/planx-lab/planx-common/internal/reflectset"
+	"github.com/planx-lab/planx-common/logger"
+)
+
+// Env reads variables whose names share a prefix. The zero value reads
+// names as given.
+type Env struct {
+	prefix string
+}
+
+// WithPrefix returns an Env that reads prefix+name, e.g. with "PLANX_" the
+// name "WORKERS" reads PLANX_WORKERS.
+func WithPrefix(prefix string) Env { return Env{prefix: prefix} }
+
+// Prefix returns the prefix of e.
+func (e Env) Prefix() string { return e.prefix }
+
+// Lookup returns the value of the variable and whether it is set.
+func (e Env) Lookup(name string) (string, bool) {
+	return Lookup(e.prefix + name)
+}
+
+// String returns the value of the variable, or def if it is unset.
+func (e Env) String(name, def string) string {
+	if v, ok := e.Lookup(name); ok {
+		return v
+	}
+	return def
+}
+
+// Int returns the variable parsed as an integer, or def.
+func (e Env) Int(name string, def int) int {
+	return lookupParsed(e, name, def, strconv.Atoi)
+}
+
+// Bool returns the variable parsed by strconv.ParseBool, or def.
+func (e Env) Bool(name string, def bool) bool {
+	return lookupParsed(e, name, def, strconv.ParseBool)
+}
+
+// Duration returns the variable parsed by time.ParseDuration, or def.
+func (e Env) Duration(name string, def time.Duration) time.Duration {
+	return lookupParsed(e, name, def, time.ParseDuration)
+}
+
+// RequiredString returns the value of the variable, or an error if it is
+// unset or empty.
+func (e Env) RequiredString(name string) (string, error) {
+	v, ok := e.Lookup(name)
+	if !ok || v == "" {
+		return "", notSet(e.prefix + name)
+	}
+	return v, nil
+}
+
+// RequiredInt returns the variable parsed as an integer, or an error if
+// it is unset or invalid.
+func (e Env) RequiredInt(name string) (int, error) {
+	return requireParsed(e, name, strconv.Atoi)
+}
+
+// RequiredBool returns the variable parsed by strconv.ParseBool, or an
+// error if it is unset or invalid.
+func (e Env) RequiredBool(name string) (bool, error) {
+	return requireParsed(e, name, strconv.ParseBool)
+}
+
+// RequiredDuration returns the variable parsed by time.ParseDuration, or
+// an error if it is unset or invalid.
+func (e Env) RequiredDuration(name string) (time.Duration, error) {
+	return requireParsed(e, name, time.ParseDuration)
+}
+
+func lookupParsed[T any](e Env, name string, def T, parse func(string) (T, error)) T {
+	v, ok := e.Lookup(name)
+	if !ok {
+		return def
+	}
+	out, err := parse(v)
+	if err != nil {
+		logger.Warn().Str("name", e.prefix+name).Int("length", len(v)).Err(reflectset.Redact(err)).
+			Msg("env: invalid value, using default")
+		return def
+	}
+	return out
+}
+
+func requireParsed[T any](e Env, name string, parse func(string) (T, error)) (T, error) {
+	var zero T
+	v, ok := e.Lookup(name)
+	if !ok || v == "" {
+		return zero, notSet(e.prefix + name)
+	}
+	out, err := parse(v)
+	if err != nil {
+		return zero, invalid(e.prefix+name, err)
+	}
+	return out, nil
+}
+
+func notSet(name string) error {
+	return errors.NewConfigErrorf("env: %s is not set", name).WithField("env", name)
+}
+
+func invalid(name string, err error) error {
+	return errors.WrapConfigError(reflectset.Redact(err), "env: "+name+" is invalid").WithField("env", name)
+}
+
+// Lookup returns the value of the variable name and whether it is set,
+// recording the read. Use it in place of os.LookupEnv.
+func Lookup(name string) (string, bool) {
+	v, ok := os.LookupEnv(name)
+	record(name, ok)
+	return v, ok
+}
+
+// String returns the value of name, or def if it is unset.
+func String(name, def string) string { return Env{}.String(name, def) }
+
+// Int returns name parsed as an integer, or def.
+func Int(name string, def int) int { return Env{}.Int(name, def) }
+
+// Bool returns name parsed by strconv.ParseBool, or def.
+func Bool(name string, def bool) bool { return Env{}.Bool(name, def) }
+
+// Duration returns name parsed by time.ParseDuration, or def.
+func Duration(name string, def time.Duration) time.Duration { return Env{}.Duration(name, def) }
+
+// RequiredString returns the value of name, or an error if it is unset or
+// empty.
+func RequiredString(name string) (string, error) { return Env{}.RequiredString(name) }
+
+// RequiredInt returns name parsed as an integer, or an error.
+func RequiredInt(name string) (int, error) { return Env{}.RequiredInt(name) }
+
+// RequiredBool returns name parsed by strconv.ParseBool, or an error.
+func RequiredBool(name string) (bool, error) { return Env{}.RequiredBool(name) }
+
+// RequiredDuration returns name parsed by time.ParseDuration, or an error.
+func RequiredDuration(name string) (time.Duration, error) { return Env{}.RequiredDuration(name) }
+
+// Read records that a variable was consulted. Values are not kept, as
+// they may be secrets.
+type Read struct {
+	Name string `json:"name"`
+	Set  bool   `json:"set"` // whether it was set at the last read
+}
+
+var (
+	readsMu sync.Mutex
+	reads   = map[string]bool{}
+)
+
+func record(name string, set bool) {
+	readsMu.Lock()
+	reads[name] = set
+	readsMu.Unlock()
+}
+
+// Reads returns the variables read through this package so far, sorted by
+// name, for the config dump.
+func Reads() []Read {
+	readsMu.Lock()
+	defer readsMu.Unlock()
+	out := make([]Read, 0, len(reads))
+	for name, set := range reads {
+		out = append(out, Read{Name: name, Set: set})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Handler serves Reads as JSON, for a /debug/env endpoint next to the
+// config dump.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		_ = json.NewEncoder(w).Encode(Reads())
+	})
+}
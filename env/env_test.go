@@ -0,0 +1,132 @@
+package env
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/planx-lab/planx-common/errors"
+	"github.com/planx-lab/planx-common/logger/logtest"
+)
+
+func TestAccessors(t *testing.T) {
+	t.Setenv("ENV_TEST_STRING", "hello")
+	t.Setenv("ENV_TEST_INT", "42")
+	t.Setenv("ENV_TEST_BOOL", "true")
+	t.Setenv("ENV_TEST_DURATION", "250ms")
+	t.Setenv("ENV_TEST_EMPTY", "")
+
+	if got := String("ENV_TEST_STRING", "x"); got != "hello" {
+		t.Fatalf("String = %q", got)
+	}
+	if got := String("ENV_TEST_EMPTY", "x"); got != "" {
+		t.Fatalf("String of set but empty variable = %q, want empty", got)
+	}
+	if got := String("ENV_TEST_UNSET", "x"); got != "x" {
+		t.Fatalf("String of unset variable = %q", got)
+	}
+	if got := Int("ENV_TEST_INT", 1); got != 42 {
+		t.Fatalf("Int = %d", got)
+	}
+	if !Bool("ENV_TEST_BOOL", false) {
+		t.Fatalf("Bool = false")
+	}
+	if got := Duration("ENV_TEST_DURATION", time.Second); got != 250*time.Millisecond {
+		t.Fatalf("Duration = %v", got)
+	}
+	if got := Duration("ENV_TEST_UNSET", time.Second); got != time.Second {
+		t.Fatalf("Duration of unset variable = %v", got)
+	}
+}
+
+func TestAccessors_InvalidValueLogsAndDefaults(t *testing.T) {
+	rec := logtest.Capture(t)
+	t.Setenv("ENV_TEST_INT", "many")
+	if got := Int("ENV_TEST_INT", 7); got != 7 {
+		t.Fatalf("Int = %d, want default", got)
+	}
+	entries := rec.Find(zerolog.WarnLevel, "invalid value")
+	if len(entries) != 1 || entries[0].Str("name") != "ENV_TEST_INT" || strings.Contains(entries[0].Raw, "many") {
+		t.Fatalf("warning = %v", entries)
+	}
+}
+
+func isConfigError(err error) bool {
+	var cfgErr *errors.ConfigError
+	return stderrors.As(err, &cfgErr)
+}
+
+func TestRequired(t *testing.T) {
+	t.Setenv("ENV_TEST_INT", "42")
+	t.Setenv("ENV_TEST_BAD", "s3cret")
+	t.Setenv("ENV_TEST_EMPTY", "")
+
+	if n, err := RequiredInt("ENV_TEST_INT"); err != nil || n != 42 {
+		t.Fatalf("RequiredInt = %d, %v", n, err)
+	}
+	for _, name := range []string{"ENV_TEST_UNSET", "ENV_TEST_EMPTY"} {
+		_, err := RequiredString(name)
+		if !isConfigError(err) || !strings.Contains(err.Error(), name+" is not set") {
+			t.Fatalf("RequiredString(%s) error = %v", name, err)
+		}
+	}
+	if _, err := RequiredDuration("ENV_TEST_BAD"); !isConfigError(err) || !strings.Contains(err.Error(), "ENV_TEST_BAD is invalid") || strings.Contains(err.Error(), "s3cret") {
+		t.Fatalf("RequiredDuration error = %v", err)
+	}
+	if _, err := RequiredBool("ENV_TEST_BAD"); err == nil || strings.Contains(err.Error(), "s3cret") {
+		t.Fatalf("RequiredBool error = %v", err)
+	}
+}
+
+func TestWithPrefix(t *testing.T) {
+	t.Setenv("PLANX_ENVTEST_WORKERS", "8")
+	e := WithPrefix("PLANX_ENVTEST_")
+	if got := e.Int("WORKERS", 1); got != 8 {
+		t.Fatalf("Int = %d", got)
+	}
+	if _, err := e.RequiredString("MISSING"); err == nil || !strings.Contains(err.Error(), "PLANX_ENVTEST_MISSING") {
+		t.Fatalf("error does not name the full variable: %v", err)
+	}
+	if e.Prefix() != "PLANX_ENVTEST_" {
+		t.Fatalf("Prefix = %q", e.Prefix())
+	}
+}
+
+func TestReads(t *testing.T) {
+	t.Setenv("ENV_TEST_READ_SET", "1")
+	Bool("ENV_TEST_READ_SET", false)
+	String("ENV_TEST_READ_UNSET", "")
+
+	got := map[string]bool{}
+	for _, r := range Reads() {
+		got[r.Name] = r.Set
+	}
+	if set, ok := got["ENV_TEST_READ_SET"]; !ok || !set {
+		t.Fatalf("set variable not recorded as set: %v", Reads())
+	}
+	if set, ok := got["ENV_TEST_READ_UNSET"]; !ok || set {
+		t.Fatalf("unset variable not recorded as unset: %v", Reads())
+	}
+}
+
+func TestHandler(t *testing.T) {
+	String("ENV_TEST_HANDLER", "")
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/env", nil))
+	var reads []Read
+	if err := json.NewDecoder(rec.Body).Decode(&reads); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	for _, r := range reads {
+		if r.Name == "ENV_TEST_HANDLER" {
+			return
+		}
+	}
+	t.Fatalf("Handler served %v without ENV_TEST_HANDLER", reads)
+}
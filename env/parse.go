@@ -0,0 +1,80 @@
+package env
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/planx-lab/planx-common/internal/reflectset"
+	"github.com/planx-lab/planx-common/validate"
+)
+
+// Parse fills the fields of v, a pointer to a struct, tagged `env:"NAME"`
+// from the environment:
+//
+//	type Settings struct {
+//		Addr    string        `env:"DEBUG_ADDR" default:"127.0.0.1:6060"`
+//		Token   string        `env:"DEBUG_TOKEN,required"`
+//		Timeout time.Duration `env:"TIMEOUT" default:"10s"`
+//		Hosts   []string      `env:"HOSTS"` // comma-separated
+//	}
+//
+// Unset variables take the `default` tag if present and otherwise leave
+// the field alone; required ones must be set and non-empty. Fields may be
+// strings, bools, numbers, time.Durations, string slices, pointers to
+// those, or encoding.TextUnmarshalers. Nested structs and non-nil pointers
+// to structs are walked. All problems are returned together as one
+// *errors.ConfigError listing the variables; see validate.Violations.
+func Parse(v interface{}) error { return Env{}.Parse(v) }
+
+// Parse is the package-level Parse with e's prefix on every name.
+func (e Env) Parse(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("env: expected a non-nil pointer to a struct, got %T", v)
+	}
+	var vs []validate.Violation
+	e.parseStruct(rv.Elem(), &vs)
+	return validate.Error(vs...)
+}
+
+func (e Env) parseStruct(rv reflect.Value, vs *[]validate.Violation) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fv := rv.Field(i)
+		tag, ok := field.Tag.Lookup("env")
+		if !ok || tag == "-" {
+			switch {
+			case fv.Kind() == reflect.Struct:
+				e.parseStruct(fv, vs)
+			case fv.Kind() == reflect.Ptr && !fv.IsNil() && fv.Elem().Kind() == reflect.Struct:
+				e.parseStruct(fv.Elem(), vs)
+			}
+			continue
+		}
+
+		name, opts, _ := strings.Cut(tag, ",")
+		full := e.prefix + name
+		val, set := e.Lookup(name)
+		if !set || val == "" {
+			if opts == "required" {
+				*vs = append(*vs, validate.Violation{Field: full, Message: "is required"})
+				continue
+			}
+		}
+		if !set {
+			def, ok := field.Tag.Lookup("default")
+			if !ok {
+				continue
+			}
+			val = def
+		}
+		if err := reflectset.Set(fv, val); err != nil {
+			*vs = append(*vs, validate.Violation{Field: full, Message: "invalid value: " + reflectset.Redact(err).Error()})
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package env
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/planx-lab/planx-common/validate"
+)
+
+// level is a TextUnmarshaler.
+type level int
+
+func (l *level) UnmarshalText(b []byte) error {
+	n, ok := map[string]level{"low": 1, "high": 2}[string(b)]
+	if !ok {
+		return fmt.Errorf("unknown level %q", b)
+	}
+	*l = n
+	return nil
+}
+
+type parseSink struct {
+	Endpoint string `env:"SINK_ENDPOINT"`
+}
+
+type parseSettings struct {
+	Addr    string        `env:"ADDR" default:"127.0.0.1:6060"`
+	Token   string        `env:"TOKEN,required"`
+	Timeout time.Duration `env:"TIMEOUT" default:"10s"`
+	Level   level         `env:"LEVEL"`
+	Workers *int          `env:"WORKERS"`
+	Hosts   []string      `env:"HOSTS"`
+	Ratio   float64       `env:"RATIO"`
+	Kept    string        `env:"KEPT"`
+	Ignored string
+	Sink    parseSink
+}
+
+func TestParse(t *testing.T) {
+	t.Setenv("PARSETEST_TOKEN", "s3cret")
+	t.Setenv("PARSETEST_LEVEL", "high")
+	t.Setenv("PARSETEST_WORKERS", "0x10")
+	t.Setenv("PARSETEST_HOSTS", "a, b,,c")
+	t.Setenv("PARSETEST_RATIO", "0.25")
+	t.Setenv("PARSETEST_SINK_ENDPOINT", "sink:443")
+
+	s := parseSettings{Kept: "from file"}
+	if err := WithPrefix("PARSETEST_").Parse(&s); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if s.Addr != "127.0.0.1:6060" || s.Token != "s3cret" || s.Timeout != 10*time.Second ||
+		s.Level != 2 || s.Workers == nil || *s.Workers != 16 ||
+		!reflect.DeepEqual(s.Hosts, []string{"a", "b", "c"}) || s.Ratio != 0.25 ||
+		s.Kept != "from file" || s.Sink.Endpoint != "sink:443" {
+		t.Fatalf("Parse = %+v", s)
+	}
+}
+
+func TestParse_Violations(t *testing.T) {
+	t.Setenv("PARSETEST_TIMEOUT", "soon")
+	t.Setenv("PARSETEST_RATIO", "half")
+
+	var s parseSettings
+	err := WithPrefix("PARSETEST_").Parse(&s)
+	vs := validate.Violations(err)
+	if len(vs) != 3 {
+		t.Fatalf("violations = %v, want 3", vs)
+	}
+	for i, field := range []string{"PARSETEST_TOKEN", "PARSETEST_TIMEOUT", "PARSETEST_RATIO"} {
+		if vs[i].Field != field {
+			t.Fatalf("violation %d = %v, want field %s", i, vs[i], field)
+		}
+	}
+	if strings.Contains(err.Error(), "soon") {
+		t.Fatalf("error shows the bad value: %v", err)
+	}
+}
+
+func TestParse_NotAStructPointer(t *testing.T) {
+	var s parseSettings
+	if err := Parse(s); err == nil {
+		t.Fatalf("Parse accepted a struct value")
+	}
+}
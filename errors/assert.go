@@ -0,0 +1,36 @@
+package errors
+
+import "fmt"
+
+// Assertf returns nil if cond holds, and otherwise an internal error
+// (CodeInternal) with the formatted message and a stack, for "should never
+// happen" states:
+//
+//	if err := errors.Assertf(n <= len(buf), "ack %d beyond window %d", n, len(buf)); err != nil {
+//		return err
+//	}
+//
+// Built with the planxdebug tag, a failed assertion panics instead so it is
+// caught at the source during development.
+func Assertf(cond bool, format string, args ...interface{}) error {
+	if cond {
+		return nil
+	}
+	e := newWithCode(CodeInternal, "assertion failed: "+fmt.Sprintf(format, args...))
+	if panicOnAssert {
+		panic(e)
+	}
+	return e
+}
+
+// Invariant is Assertf with a fixed message.
+func Invariant(cond bool, message string) error {
+	if cond {
+		return nil
+	}
+	e := newWithCode(CodeInternal, "invariant violated: "+message)
+	if panicOnAssert {
+		panic(e)
+	}
+	return e
+}
@@ -0,0 +1,17 @@
+//go:build planxdebug
+
+package errors
+
+import "testing"
+
+func TestAssertf_PanicsInDebugBuilds(t *testing.T) {
+	defer func() {
+		r := recover()
+		e, ok := r.(*Error)
+		if !ok || e.Code != CodeInternal {
+			t.Fatalf("expected an internal *Error panic, got %v", r)
+		}
+	}()
+	_ = Assertf(false, "boom")
+	t.Fatal("expected a panic")
+}
@@ -0,0 +1,6 @@
+//go:build !planxdebug
+
+package errors
+
+// panicOnAssert makes failed assertions panic in debug builds.
+const panicOnAssert = false
@@ -0,0 +1,44 @@
+//go:build !planxdebug
+
+package errors
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAssertf(t *testing.T) {
+	if err := Assertf(true, "unused %d", 1); err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	err := Assertf(false, "ack %d beyond window %d", 9, 8)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if err.Error() != "assertion failed: ack 9 beyond window 8" {
+		t.Fatalf("got %q", err.Error())
+	}
+	if CodeOf(err) != CodeInternal {
+		t.Fatalf("expected internal code, got %s", CodeOf(err))
+	}
+	if IsRetryable(err) {
+		t.Fatal("assertion failures should not be retryable")
+	}
+	if !strings.Contains(err.(*Error).StackTrace(), "TestAssertf") {
+		t.Fatalf("stack should start at the caller:\n%s", err.(*Error).StackTrace())
+	}
+}
+
+func TestInvariant(t *testing.T) {
+	if Invariant(true, "x") != nil {
+		t.Fatal("expected nil")
+	}
+	err := Invariant(false, "window negative")
+	if err == nil || err.Error() != "invariant violated: window negative" {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if frames := err.(*Error).Frames(); !strings.HasSuffix(frames[0].Function, "TestInvariant") {
+		t.Fatalf("stack should start at the caller, got %s", frames[0].Function)
+	}
+}
@@ -0,0 +1,89 @@
+package errors
+
+import "sort"
+
+// Failed returns the records at e's failed indices, in index order.
+// Out-of-range indices are ignored; a nil e yields nil.
+func Failed[T any](e *BatchError, records []T) []T {
+	if e == nil {
+		return nil
+	}
+	out := make([]T, 0, len(e.FailedIndices))
+	for _, idx := range normalizeIndices(e.FailedIndices) {
+		if idx >= 0 && idx < len(records) {
+			out = append(out, records[idx])
+		}
+	}
+	return out
+}
+
+// Succeeded returns the indices in [0, n) that did not fail.
+func (e *BatchError) Succeeded(n int) []int {
+	failed := make(map[int]struct{}, len(e.FailedIndices))
+	for _, idx := range e.FailedIndices {
+		failed[idx] = struct{}{}
+	}
+	out := make([]int, 0, n)
+	for i := 0; i < n; i++ {
+		if _, ok := failed[i]; !ok {
+			out = append(out, i)
+		}
+	}
+	return out
+}
+
+// Merge adds other's failed indices shifted by offset, as when a batch was
+// sent in chunks and other reports on the chunk starting at offset.
+// The result is sorted and free of duplicates.
+func (e *BatchError) Merge(other *BatchError, offset int) {
+	if other == nil {
+		return
+	}
+	for _, idx := range other.FailedIndices {
+		e.FailedIndices = append(e.FailedIndices, idx+offset)
+	}
+	e.FailedIndices = normalizeIndices(e.FailedIndices)
+}
+
+// SplitAt splits e at record n: head keeps the failures before n, tail the
+// failures from n on, re-based to start at zero. A side without failures is
+// nil. Both sides share e's message, code and cause.
+func (e *BatchError) SplitAt(n int) (head, tail *BatchError) {
+	var h, t []int
+	for _, idx := range normalizeIndices(e.FailedIndices) {
+		if idx < n {
+			h = append(h, idx)
+		} else {
+			t = append(t, idx-n)
+		}
+	}
+	if len(h) > 0 {
+		head = e.withIndices(h)
+	}
+	if len(t) > 0 {
+		tail = e.withIndices(t)
+	}
+	return head, tail
+}
+
+func (e *BatchError) withIndices(indices []int) *BatchError {
+	base := *e.Base
+	return &BatchError{Base: &base, FailedIndices: indices}
+}
+
+// normalizeIndices returns a sorted, de-duplicated copy of indices.
+func normalizeIndices(indices []int) []int {
+	if len(indices) == 0 {
+		return indices
+	}
+	out := append([]int(nil), indices...)
+	sort.Ints(out)
+	n := 1
+	for i := 1; i < len(out); i++ {
+		if out[i] != out[n-1] {
+			out[n] = out[i]
+			n++
+		}
+	}
+	return out[:n]
+}
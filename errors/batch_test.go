@@ -0,0 +1,65 @@
+package errors
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestFailed(t *testing.T) {
+	records := []string{"a", "b", "c", "d", "e"}
+	e := NewBatchError("partial", []int{3, 1, 9, 1})
+	got := Failed(e, records)
+	if fmt.Sprint(got) != "[b d]" {
+		t.Fatalf("got %v", got)
+	}
+}
+
+func TestFailed_Nil(t *testing.T) {
+	if Failed[int](nil, []int{1, 2}) != nil {
+		t.Fatal("expected nil")
+	}
+}
+
+func TestSucceeded(t *testing.T) {
+	e := NewBatchError("partial", []int{0, 2})
+	if got := e.Succeeded(5); fmt.Sprint(got) != "[1 3 4]" {
+		t.Fatalf("got %v", got)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	e := NewBatchError("partial", []int{1})
+	e.Merge(NewBatchError("chunk", []int{0, 2}), 10)
+	e.Merge(NewBatchError("chunk", []int{1}), 0)
+	e.Merge(nil, 5)
+	if fmt.Sprint(e.FailedIndices) != "[1 10 12]" {
+		t.Fatalf("got %v", e.FailedIndices)
+	}
+}
+
+func TestSplitAt(t *testing.T) {
+	e := NewBatchError("partial", []int{7, 1, 4}).WithCode(CodeBatchPartial)
+	head, tail := e.SplitAt(4)
+	if head == nil || fmt.Sprint(head.FailedIndices) != "[1]" {
+		t.Fatalf("head: got %+v", head)
+	}
+	if tail == nil || fmt.Sprint(tail.FailedIndices) != "[0 3]" {
+		t.Fatalf("tail: got %+v", tail)
+	}
+	if tail.Message != "partial" || tail.Code != CodeBatchPartial {
+		t.Fatalf("tail should keep message and code: %+v", tail.Base)
+	}
+	if fmt.Sprint(e.FailedIndices) != "[7 1 4]" {
+		t.Fatalf("original should be untouched, got %v", e.FailedIndices)
+	}
+}
+
+func TestSplitAt_EmptySide(t *testing.T) {
+	head, tail := NewBatchError("partial", []int{5}).SplitAt(3)
+	if head != nil {
+		t.Fatalf("head: got %+v, want nil", head)
+	}
+	if tail == nil || fmt.Sprint(tail.FailedIndices) != "[2]" {
+		t.Fatalf("tail: got %+v", tail)
+	}
+}
@@ -0,0 +1,63 @@
+package errors
+
+import stderrors "errors"
+
+// Walk calls fn for err and every error in its chain, outermost first, until
+// fn returns false. Joined errors (Unwrap() []error) are visited depth first
+// in order. It works on chains mixing our types and stdlib-wrapped errors.
+func Walk(err error, fn func(error) bool) {
+	walk(err, fn)
+}
+
+func walk(err error, fn func(error) bool) bool {
+	for err != nil {
+		if !fn(err) {
+			return false
+		}
+		if u, ok := err.(interface{ Unwrap() []error }); ok {
+			for _, e := range u.Unwrap() {
+				if !walk(e, fn) {
+					return false
+				}
+			}
+			return true
+		}
+		err = stderrors.Unwrap(err)
+	}
+	return true
+}
+
+// RootCause returns the innermost error in err's chain. For joined errors it
+// follows the first branch.
+func RootCause(err error) error {
+	for err != nil {
+		var next error
+		if u, ok := err.(interface{ Unwrap() []error }); ok {
+			if errs := u.Unwrap(); len(errs) > 0 {
+				next = errs[0]
+			}
+		} else {
+			next = stderrors.Unwrap(err)
+		}
+		if next == nil {
+			return err
+		}
+		err = next
+	}
+	return nil
+}
+
+// Has finds the first error in err's chain assignable to T, as errors.As does,
+// without the target-variable boilerplate:
+//
+//	if be, ok := errors.Has[*errors.BatchError](err); ok { ... }
+//
+// As with errors.As, T must be an interface or implement error.
+func Has[T any](err error) (T, bool) {
+	var target T
+	if err == nil {
+		return target, false
+	}
+	ok := stderrors.As(err, &target)
+	return target, ok
+}
@@ -0,0 +1,84 @@
+package errors
+
+import (
+	stderrors "errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func TestWalkOrderAndStop(t *testing.T) {
+	root := io.EOF
+	err := Wrap(fmt.Errorf("mid: %w", root), "outer")
+
+	var seen []error
+	Walk(err, func(e error) bool {
+		seen = append(seen, e)
+		return true
+	})
+	if len(seen) != 3 || seen[0] != err || seen[2] != root {
+		t.Fatalf("unexpected walk order: %v", seen)
+	}
+
+	n := 0
+	Walk(err, func(error) bool {
+		n++
+		return false
+	})
+	if n != 1 {
+		t.Fatalf("expected walk to stop after first error, visited %d", n)
+	}
+}
+
+func TestWalkJoined(t *testing.T) {
+	a, b := New("a"), New("b")
+	var seen []error
+	Walk(stderrors.Join(a, b), func(e error) bool {
+		seen = append(seen, e)
+		return true
+	})
+	if len(seen) != 3 || seen[1] != a || seen[2] != b {
+		t.Fatalf("unexpected walk over joined errors: %v", seen)
+	}
+}
+
+func TestRootCause(t *testing.T) {
+	if RootCause(nil) != nil {
+		t.Fatal("expected nil root cause for nil error")
+	}
+	root := io.EOF
+	err := Wrap(fmt.Errorf("mid: %w", root), "outer")
+	if got := RootCause(err); got != root {
+		t.Fatalf("expected io.EOF, got %v", got)
+	}
+
+	se := NewStreamError("broken")
+	if got := RootCause(Wrap(se, "outer")); got != se.Base {
+		t.Fatalf("expected the stream error's base, got %v", got)
+	}
+
+	if got := RootCause(stderrors.Join(fmt.Errorf("x: %w", root), New("other"))); got != root {
+		t.Fatalf("expected first branch root, got %v", got)
+	}
+}
+
+func TestHas(t *testing.T) {
+	be := NewBatchError("partial", []int{1})
+	err := Wrap(fmt.Errorf("ctx: %w", be), "outer")
+
+	got, ok := Has[*BatchError](err)
+	if !ok || got != be {
+		t.Fatalf("expected to find batch error, got %v %v", got, ok)
+	}
+	if _, ok := Has[*ConfigError](err); ok {
+		t.Fatal("did not expect a config error")
+	}
+	if _, ok := Has[*BatchError](nil); ok {
+		t.Fatal("did not expect a match on nil")
+	}
+
+	type timeout interface{ Timeout() bool }
+	if _, ok := Has[timeout](err); ok {
+		t.Fatal("did not expect a timeout")
+	}
+}
@@ -0,0 +1,125 @@
+package errors
+
+import (
+	stderrors "errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Code is a stable, machine-readable error code such as "PLX-CONFIG-001".
+// The engine branches on codes instead of matching messages.
+type Code string
+
+// Well-known codes.
+const (
+	CodeUnknown              Code = ""
+	CodeInternal             Code = "PLX-INTERNAL-001"
+	CodePanic                Code = "PLX-INTERNAL-PANIC"
+	CodeConfigInvalid        Code = "PLX-CONFIG-001"
+	CodeConfigMissing        Code = "PLX-CONFIG-002"
+	CodeStreamBroken         Code = "PLX-STREAM-001"
+	CodeBatchPartial         Code = "PLX-BATCH-001"
+	CodeTransportUnavailable Code = "PLX-TRANSPORT-UNAVAILABLE"
+	CodeTransportTimeout     Code = "PLX-TRANSPORT-TIMEOUT"
+	CodeRateLimited          Code = "PLX-RATE-LIMITED"
+	CodeBackpressure         Code = "PLX-BACKPRESSURE"
+	CodeCanceled             Code = "PLX-CANCELED"
+	CodeDeadlineExceeded     Code = "PLX-DEADLINE-EXCEEDED"
+)
+
+// CodeInfo describes a registered code.
+type CodeInfo struct {
+	Code        Code
+	Description string
+}
+
+var (
+	codesMu sync.RWMutex
+	codes   = map[Code]CodeInfo{}
+)
+
+func init() {
+	for _, info := range []CodeInfo{
+		{CodeInternal, "internal invariant violated"},
+		{CodePanic, "recovered from a panic"},
+		{CodeConfigInvalid, "configuration is invalid"},
+		{CodeConfigMissing, "required configuration is missing"},
+		{CodeStreamBroken, "stream terminated unexpectedly"},
+		{CodeBatchPartial, "batch partially failed"},
+		{CodeTransportUnavailable, "transport endpoint unavailable"},
+		{CodeTransportTimeout, "transport operation timed out"},
+		{CodeRateLimited, "throttled by a downstream rate limit"},
+		{CodeBackpressure, "rejected by a full queue or window"},
+		{CodeCanceled, "operation canceled, e.g. by shutdown"},
+		{CodeDeadlineExceeded, "operation deadline exceeded"},
+	} {
+		RegisterCode(info)
+	}
+}
+
+// RegisterCode adds a code to the registry. Like http.Handle it is meant to
+// be called from init and panics if the code is empty or already registered.
+func RegisterCode(info CodeInfo) {
+	if info.Code == CodeUnknown {
+		panic("errors: RegisterCode with empty code")
+	}
+	codesMu.Lock()
+	defer codesMu.Unlock()
+	if _, dup := codes[info.Code]; dup {
+		panic(fmt.Sprintf("errors: code %q registered twice", info.Code))
+	}
+	codes[info.Code] = info
+}
+
+// LookupCode returns the registry entry for code.
+func LookupCode(code Code) (CodeInfo, bool) {
+	codesMu.RLock()
+	defer codesMu.RUnlock()
+	info, ok := codes[code]
+	return info, ok
+}
+
+// Codes returns all registered codes sorted by code.
+func Codes() []CodeInfo {
+	codesMu.RLock()
+	defer codesMu.RUnlock()
+	out := make([]CodeInfo, 0, len(codes))
+	for _, info := range codes {
+		out = append(out, info)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Code < out[j].Code })
+	return out
+}
+
+// NewWithCode creates a new error carrying code.
+func NewWithCode(code Code, message string) *Error {
+	return &Error{
+		Message: message,
+		Code:    code,
+		Stack:   captureStack(2),
+	}
+}
+
+// WithCode sets the code on e and returns it.
+func (e *Error) WithCode(code Code) *Error {
+	if e != nil {
+		e.Code = code
+	}
+	return e
+}
+
+// CodeOf returns the outermost code found in err's chain, or CodeUnknown.
+func CodeOf(err error) Code {
+	for err != nil {
+		var e *Error
+		if !stderrors.As(err, &e) {
+			return CodeUnknown
+		}
+		if e.Code != CodeUnknown {
+			return e.Code
+		}
+		err = e.Cause
+	}
+	return CodeUnknown
+}
@@ -0,0 +1,116 @@
+package errors
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestNewWithCode(t *testing.T) {
+	e := NewWithCode(CodeConfigMissing, "endpoint not set")
+	if e.Code != CodeConfigMissing {
+		t.Fatalf("code: got %q", e.Code)
+	}
+	if len(e.Stack) == 0 {
+		t.Fatal("stack should not be empty")
+	}
+}
+
+func TestCodeOf(t *testing.T) {
+	e := NewWithCode(CodeTransportTimeout, "dial")
+	if got := CodeOf(e); got != CodeTransportTimeout {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestCodeOf_ThroughWrap(t *testing.T) {
+	inner := NewWithCode(CodeConfigInvalid, "bad")
+	outer := fmt.Errorf("loading: %w", Wrap(inner, "session"))
+	if got := CodeOf(outer); got != CodeConfigInvalid {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestCodeOf_OutermostWins(t *testing.T) {
+	inner := NewWithCode(CodeConfigInvalid, "bad")
+	outer := Wrap(inner, "dial").WithCode(CodeTransportUnavailable)
+	if got := CodeOf(outer); got != CodeTransportUnavailable {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestCodeOf_Unknown(t *testing.T) {
+	if got := CodeOf(fmt.Errorf("plain")); got != CodeUnknown {
+		t.Fatalf("got %q", got)
+	}
+	if got := CodeOf(nil); got != CodeUnknown {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestTypedErrors_DefaultCodes(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want Code
+	}{
+		{"config", NewConfigError("x"), CodeConfigInvalid},
+		{"stream", NewStreamError("x"), CodeStreamBroken},
+		{"batch", NewBatchError("x", nil), CodeBatchPartial},
+		{"transport", NewTransportError("x", true), CodeTransportUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CodeOf(tt.err); got != tt.want {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLookupCode(t *testing.T) {
+	info, ok := LookupCode(CodeConfigInvalid)
+	if !ok || info.Description == "" {
+		t.Fatalf("got %+v, %v", info, ok)
+	}
+	if _, ok := LookupCode("PLX-NOPE"); ok {
+		t.Fatal("unregistered code should not be found")
+	}
+}
+
+func TestRegisterCode(t *testing.T) {
+	code := Code("PLX-TEST-001")
+	RegisterCode(CodeInfo{Code: code, Description: "test"})
+	defer func() {
+		codesMu.Lock()
+		delete(codes, code)
+		codesMu.Unlock()
+	}()
+
+	found := false
+	for _, info := range Codes() {
+		if info.Code == code {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatal("registered code missing from Codes()")
+	}
+}
+
+func TestRegisterCode_DuplicatePanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic on duplicate registration")
+		}
+	}()
+	RegisterCode(CodeInfo{Code: CodeConfigInvalid})
+}
+
+func TestRegisterCode_EmptyPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic on empty code")
+		}
+	}()
+	RegisterCode(CodeInfo{})
+}
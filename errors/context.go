@@ -0,0 +1,59 @@
+package errors
+
+import (
+	"context"
+	stderrors "errors"
+)
+
+// Class names reported for context-induced errors (see FromContext).
+const (
+	classCanceled = "canceled"
+	classDeadline = "deadline"
+)
+
+// FromContext reclassifies err when ctx is done, so failures caused by
+// shutdown or an expired deadline are not mistaken for what they surfaced as
+// (typically a TransportError). If ctx is not done, err is returned as is.
+//
+// Otherwise the result is an *Error coded CodeCanceled (not retryable) or
+// CodeDeadlineExceeded (retryable) whose chain holds both err and ctx.Err(),
+// so errors.Is(result, context.Canceled) and errors.As on err's types keep
+// working. It returns nil if err is nil.
+func FromContext(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	ctxErr := ctx.Err()
+	if ctxErr == nil {
+		return err
+	}
+
+	code := CodeCanceled
+	if stderrors.Is(ctxErr, context.DeadlineExceeded) {
+		code = CodeDeadlineExceeded
+	}
+
+	var cause error = err
+	if !stderrors.Is(err, ctxErr) {
+		cause = &contextCause{err: err, ctxErr: ctxErr}
+	}
+	e := &Error{
+		Message: ctxErr.Error(),
+		Code:    code,
+		Cause:   cause,
+	}
+	if DeepestStack(err) == nil {
+		e.Stack = captureStack(2)
+	}
+	return e
+}
+
+// contextCause joins the original error with the context error while keeping
+// the original message.
+type contextCause struct {
+	err    error
+	ctxErr error
+}
+
+func (c *contextCause) Error() string   { return c.err.Error() }
+func (c *contextCause) Unwrap() []error { return []error{c.err, c.ctxErr} }
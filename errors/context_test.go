@@ -0,0 +1,78 @@
+package errors
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+	"time"
+)
+
+func TestFromContext_NotDone(t *testing.T) {
+	err := NewTransportError("reset", true)
+	if got := FromContext(context.Background(), err); got != err {
+		t.Fatalf("expected err unchanged, got %v", got)
+	}
+	if FromContext(context.Background(), nil) != nil {
+		t.Fatal("expected nil for nil error")
+	}
+}
+
+func TestFromContext_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tr := NewTransportError("connection reset", true)
+	err := FromContext(ctx, tr)
+
+	if CodeOf(err) != CodeCanceled {
+		t.Fatalf("expected canceled code, got %s", CodeOf(err))
+	}
+	if IsRetryable(err) {
+		t.Fatal("canceled errors should not be retryable")
+	}
+	if !stderrors.Is(err, context.Canceled) {
+		t.Fatal("errors.Is should find context.Canceled")
+	}
+	var got *TransportError
+	if !stderrors.As(err, &got) || got != tr {
+		t.Fatal("errors.As should still find the transport error")
+	}
+	if err.Error() != "context canceled: connection reset" {
+		t.Fatalf("got %q", err.Error())
+	}
+	if classOf(err) != classCanceled {
+		t.Fatalf("expected canceled class, got %q", classOf(err))
+	}
+}
+
+func TestFromContext_Deadline(t *testing.T) {
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	err := FromContext(ctx, NewTransportError("write failed", false))
+	if CodeOf(err) != CodeDeadlineExceeded {
+		t.Fatalf("expected deadline code, got %s", CodeOf(err))
+	}
+	if !IsRetryable(err) {
+		t.Fatal("deadline errors should be retryable")
+	}
+	if !stderrors.Is(err, context.DeadlineExceeded) || !IsTimeout(err) {
+		t.Fatal("expected a deadline/timeout error")
+	}
+	if classOf(err) != classDeadline {
+		t.Fatalf("expected deadline class, got %q", classOf(err))
+	}
+}
+
+func TestFromContext_AlreadyContextError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := FromContext(ctx, ctx.Err())
+	if err.Error() != "context canceled: context canceled" {
+		t.Fatalf("got %q", err.Error())
+	}
+	if len(err.(*Error).Stack) == 0 {
+		t.Fatal("expected a stack when the chain has none")
+	}
+}
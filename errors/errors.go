@@ -14,8 +14,12 @@ import (
 // Error represents an error with a stack trace and optional cause.
 type Error struct {
 	Message string
+	Code    Code
 	Cause   error
 	Stack   []uintptr
+	Fields  map[string]interface{} // structured metadata, see WithField
+
+	frames []Frame // stack decoded from JSON, used when Stack is empty
 }
 
 // New creates a new error with a stack trace.
@@ -27,27 +31,71 @@ func New(message string) *Error {
 }
 
 // Wrap wraps an existing error with additional context and a stack trace.
+// If err's chain already carries a stack, no new stack is captured; the
+// wrapper reports the inner one (see DeepestStack).
 func Wrap(err error, message string) *Error {
 	if err == nil {
 		return nil
 	}
-	return &Error{
+	e := &Error{
 		Message: message,
 		Cause:   err,
-		Stack:   captureStack(2),
 	}
+	if DeepestStack(err) == nil {
+		e.Stack = captureStack(2)
+	}
+	return e
 }
 
 // Wrapf wraps an existing error with formatted context.
+// Like Wrap, it only captures a stack if err's chain has none.
 func Wrapf(err error, format string, args ...interface{}) *Error {
 	if err == nil {
 		return nil
 	}
-	return &Error{
+	e := &Error{
 		Message: fmt.Sprintf(format, args...),
 		Cause:   err,
-		Stack:   captureStack(2),
 	}
+	if DeepestStack(err) == nil {
+		e.Stack = captureStack(2)
+	}
+	return e
+}
+
+// WrapNoStack wraps an existing error with context but never captures a
+// stack, for hot retry loops.
+func WrapNoStack(err error, message string) *Error {
+	if err == nil {
+		return nil
+	}
+	return &Error{
+		Message: message,
+		Cause:   err,
+	}
+}
+
+// DeepestStack returns the stack captured by the innermost *Error in err's
+// chain, which is the closest to where the failure originated. It returns
+// nil if no error in the chain captured a stack.
+func DeepestStack(err error) []uintptr {
+	if e := deepestWithStack(err); e != nil {
+		return e.Stack
+	}
+	return nil
+}
+
+// deepestWithStack returns the innermost *Error in err's chain that carries
+// a captured or decoded stack.
+func deepestWithStack(err error) *Error {
+	var deepest *Error
+	Walk(err, func(e error) bool {
+		if base, ok := e.(*Error); ok && (len(base.Stack) > 0 || len(base.frames) > 0) {
+			deepest = base
+		}
+		return true
+	})
+	return deepest
 }
 
 // Error implements the error interface.
@@ -69,26 +117,43 @@ func (e *Error) Unwrap() error {
 	return e.Cause
 }
 
-// StackTrace returns a formatted stack trace.
+// StackTrace returns a formatted stack trace. Errors created by Wrap over an
+// error that already had a stack report that deeper stack. The output follows
+// the current StackOptions.
 func (e *Error) StackTrace() string {
 	if e == nil {
 		return ""
 	}
 	var sb strings.Builder
-	frames := runtime.CallersFrames(e.Stack)
-	for {
-		frame, more := frames.Next()
-		if frame.Function == "" {
-			break
-		}
+	for _, frame := range e.Frames() {
 		sb.WriteString(fmt.Sprintf("  %s\n    %s:%d\n", frame.Function, frame.File, frame.Line))
-		if !more {
-			break
-		}
 	}
 	return sb.String()
 }
 
+// newWithCode is used by the typed constructors; the stack starts at their caller.
+func newWithCode(code Code, message string) *Error {
+	return &Error{
+		Message: message,
+		Code:    code,
+		Stack:   captureStack(3),
+	}
+}
+
+// wrapWithCode is used by the typed Wrap constructors. Like Wrap, it only
+// captures a stack (starting at their caller) if err's chain has none.
+func wrapWithCode(code Code, err error, message string) *Error {
+	e := &Error{
+		Message: message,
+		Code:    code,
+		Cause:   err,
+	}
+	if DeepestStack(err) == nil {
+		e.Stack = captureStack(3)
+	}
+	return e
+}
+
 func captureStack(skip int) []uintptr {
 	pcs := make([]uintptr, 32)
 	n := runtime.Callers(skip+1, pcs)
@@ -97,50 +162,139 @@ func captureStack(skip int) []uintptr {
 
 // Error types for categorization
 
+// Base is an alias of Error for embedding in the typed errors. Embedding
+// *Error directly would name the field Error and hide the Error method, so
+// the typed errors would not satisfy the error interface.
+type Base = Error
+
+// unwrapBase returns b as an error, avoiding a typed-nil interface.
+func unwrapBase(b *Base) error {
+	if b == nil {
+		return nil
+	}
+	return b
+}
+
 // ConfigError represents a configuration error (fatal on CreateSession).
 type ConfigError struct {
-	*Error
+	*Base
 }
 
 // NewConfigError creates a new configuration error.
 func NewConfigError(message string) *ConfigError {
-	return &ConfigError{Error: New(message)}
+	return &ConfigError{Base: newWithCode(CodeConfigInvalid, message)}
+}
+
+// NewConfigErrorf creates a new configuration error with a formatted message.
+func NewConfigErrorf(format string, args ...interface{}) *ConfigError {
+	return &ConfigError{Base: newWithCode(CodeConfigInvalid, fmt.Sprintf(format, args...))}
 }
 
+// WrapConfigError wraps err as a configuration error. It returns nil if err is nil.
+func WrapConfigError(err error, message string) *ConfigError {
+	if err == nil {
+		return nil
+	}
+	return &ConfigError{Base: wrapWithCode(CodeConfigInvalid, err, message)}
+}
+
+// Unwrap returns the embedded *Error so errors.As can reach it.
+func (e *ConfigError) Unwrap() error { return unwrapBase(e.Base) }
+
 // StreamError represents a stream error (terminate session).
 type StreamError struct {
-	*Error
+	*Base
 }
 
 // NewStreamError creates a new stream error.
 func NewStreamError(message string) *StreamError {
-	return &StreamError{Error: New(message)}
+	return &StreamError{Base: newWithCode(CodeStreamBroken, message)}
 }
 
+// NewStreamErrorf creates a new stream error with a formatted message.
+func NewStreamErrorf(format string, args ...interface{}) *StreamError {
+	return &StreamError{Base: newWithCode(CodeStreamBroken, fmt.Sprintf(format, args...))}
+}
+
+// WrapStreamError wraps err as a stream error. It returns nil if err is nil.
+func WrapStreamError(err error, message string) *StreamError {
+	if err == nil {
+		return nil
+	}
+	return &StreamError{Base: wrapWithCode(CodeStreamBroken, err, message)}
+}
+
+// Unwrap returns the embedded *Error so errors.As can reach it.
+func (e *StreamError) Unwrap() error { return unwrapBase(e.Base) }
+
 // BatchError represents a batch-level error (partial failure allowed).
 type BatchError struct {
-	*Error
+	*Base
 	FailedIndices []int
 }
 
 // NewBatchError creates a new batch error with failed record indices.
 func NewBatchError(message string, failedIndices []int) *BatchError {
 	return &BatchError{
-		Error:         New(message),
+		Base:          newWithCode(CodeBatchPartial, message),
+		FailedIndices: failedIndices,
+	}
+}
+
+// NewBatchErrorf creates a new batch error with a formatted message.
+func NewBatchErrorf(failedIndices []int, format string, args ...interface{}) *BatchError {
+	return &BatchError{
+		Base:          newWithCode(CodeBatchPartial, fmt.Sprintf(format, args...)),
+		FailedIndices: failedIndices,
+	}
+}
+
+// WrapBatchError wraps err as a batch error. It returns nil if err is nil.
+func WrapBatchError(err error, message string, failedIndices []int) *BatchError {
+	if err == nil {
+		return nil
+	}
+	return &BatchError{
+		Base:          wrapWithCode(CodeBatchPartial, err, message),
 		FailedIndices: failedIndices,
 	}
 }
 
+// Unwrap returns the embedded *Error so errors.As can reach it.
+func (e *BatchError) Unwrap() error { return unwrapBase(e.Base) }
+
 // TransportError represents a transport error (retry connection).
 type TransportError struct {
-	*Error
+	*Base
 	Retryable bool
 }
 
 // NewTransportError creates a new transport error.
 func NewTransportError(message string, retryable bool) *TransportError {
 	return &TransportError{
-		Error:     New(message),
+		Base:      newWithCode(CodeTransportUnavailable, message),
 		Retryable: retryable,
 	}
 }
+
+// NewTransportErrorf creates a new transport error with a formatted message.
+func NewTransportErrorf(retryable bool, format string, args ...interface{}) *TransportError {
+	return &TransportError{
+		Base:      newWithCode(CodeTransportUnavailable, fmt.Sprintf(format, args...)),
+		Retryable: retryable,
+	}
+}
+
+// WrapTransportError wraps err as a transport error. It returns nil if err is nil.
+func WrapTransportError(err error, message string, retryable bool) *TransportError {
+	if err == nil {
+		return nil
+	}
+	return &TransportError{
+		Base:      wrapWithCode(CodeTransportUnavailable, err, message),
+		Retryable: retryable,
+	}
+}
+
+// Unwrap returns the embedded *Error so errors.As can reach it.
+func (e *TransportError) Unwrap() error { return unwrapBase(e.Base) }
@@ -118,26 +118,26 @@ func TestStackTrace_ContainsFunction(t *testing.T) {
 
 func TestNewConfigError(t *testing.T) {
 	e := NewConfigError("bad config")
-	if e.Error.Message != "bad config" {
-		t.Fatalf("message: got %q", e.Error.Message)
+	if e.Base.Message != "bad config" {
+		t.Fatalf("message: got %q", e.Base.Message)
 	}
-	if e.Error == nil {
+	if e.Base == nil {
 		t.Fatal("embedded Error should not be nil")
 	}
 }
 
 func TestNewStreamError(t *testing.T) {
 	e := NewStreamError("stream broke")
-	if e.Error.Message != "stream broke" {
-		t.Fatalf("message: got %q", e.Error.Message)
+	if e.Base.Message != "stream broke" {
+		t.Fatalf("message: got %q", e.Base.Message)
 	}
 }
 
 func TestNewBatchError(t *testing.T) {
 	indices := []int{2, 5, 7}
 	e := NewBatchError("partial fail", indices)
-	if e.Error.Message != "partial fail" {
-		t.Fatalf("message: got %q", e.Error.Message)
+	if e.Base.Message != "partial fail" {
+		t.Fatalf("message: got %q", e.Base.Message)
 	}
 	if len(e.FailedIndices) != 3 || e.FailedIndices[0] != 2 {
 		t.Fatalf("indices: got %v", e.FailedIndices)
@@ -153,8 +153,8 @@ func TestNewBatchError_EmptyIndices(t *testing.T) {
 
 func TestNewTransportError(t *testing.T) {
 	e := NewTransportError("timeout", true)
-	if e.Error.Message != "timeout" {
-		t.Fatalf("message: got %q", e.Error.Message)
+	if e.Base.Message != "timeout" {
+		t.Fatalf("message: got %q", e.Base.Message)
 	}
 	if !e.Retryable {
 		t.Fatal("should be retryable")
@@ -182,7 +182,7 @@ func TestWrappedError_Unwrap(t *testing.T) {
 
 func TestConfigError_CallsEmbedded(t *testing.T) {
 	e := NewConfigError("cfg")
-	got := e.Error.Error()
+	got := e.Base.Error()
 	if got != "cfg" {
 		t.Fatalf("got %q", got)
 	}
@@ -190,7 +190,7 @@ func TestConfigError_CallsEmbedded(t *testing.T) {
 
 func TestBatchError_CallsEmbedded(t *testing.T) {
 	e := NewBatchError("batch", []int{1})
-	got := e.Error.Error()
+	got := e.Base.Error()
 	if got != "batch" {
 		t.Fatalf("got %q", got)
 	}
@@ -198,8 +198,109 @@ func TestBatchError_CallsEmbedded(t *testing.T) {
 
 func TestTransportError_CallsEmbedded(t *testing.T) {
 	e := NewTransportError("trans", false)
-	got := e.Error.Error()
+	got := e.Base.Error()
 	if got != "trans" {
 		t.Fatalf("got %q", got)
 	}
 }
+
+func TestTypedErrors_ImplementError(t *testing.T) {
+	var _ error = NewConfigError("x")
+	var _ error = NewStreamError("x")
+	var _ error = NewBatchError("x", nil)
+	var _ error = NewTransportError("x", false)
+}
+
+func TestTypedErrors_As(t *testing.T) {
+	cfg := NewConfigError("missing endpoint")
+	err := fmt.Errorf("create session: %w", Wrap(cfg, "validate"))
+
+	var got *ConfigError
+	if !errors.As(err, &got) {
+		t.Fatal("errors.As should find *ConfigError")
+	}
+	if got != cfg {
+		t.Fatal("errors.As returned a different wrapper")
+	}
+
+	var base *Error
+	if !errors.As(cfg, &base) || base != cfg.Base {
+		t.Fatal("errors.As should reach the embedded *Error")
+	}
+
+	var be *BatchError
+	if errors.As(err, &be) {
+		t.Fatal("errors.As should not match *BatchError")
+	}
+}
+
+func TestTypedErrors_ErrorString(t *testing.T) {
+	e := NewTransportError("dial failed", true)
+	if e.Error() != "dial failed" {
+		t.Fatalf("got %q", e.Error())
+	}
+}
+
+func TestTypedErrors_Wrap(t *testing.T) {
+	cause := fmt.Errorf("parse port: %w", errors.New("invalid syntax"))
+	cfg := WrapConfigError(cause, "load config")
+	if cfg.Error() != "load config: parse port: invalid syntax" {
+		t.Fatalf("got %q", cfg.Error())
+	}
+	if !errors.Is(cfg, cause) {
+		t.Fatal("errors.Is should reach the cause")
+	}
+	if CodeOf(cfg) != CodeConfigInvalid {
+		t.Fatalf("expected config code, got %s", CodeOf(cfg))
+	}
+	if len(cfg.Stack) == 0 {
+		t.Fatal("expected a stack when the cause has none")
+	}
+
+	inner := New("reset by peer")
+	tr := WrapTransportError(inner, "send batch", true)
+	if len(tr.Stack) != 0 || !tr.Retryable {
+		t.Fatal("expected no new stack over a stacked cause and retryable set")
+	}
+	be := WrapBatchError(inner, "write", []int{2})
+	if len(be.FailedIndices) != 1 || be.FailedIndices[0] != 2 {
+		t.Fatalf("unexpected failed indices %v", be.FailedIndices)
+	}
+
+	if WrapConfigError(nil, "x") != nil || WrapStreamError(nil, "x") != nil ||
+		WrapBatchError(nil, "x", nil) != nil || WrapTransportError(nil, "x", false) != nil {
+		t.Fatal("wrapping nil should return nil")
+	}
+}
+
+func TestTypedErrors_Newf(t *testing.T) {
+	if got := NewConfigErrorf("missing %s", "endpoint").Error(); got != "missing endpoint" {
+		t.Fatalf("got %q", got)
+	}
+	if got := NewStreamErrorf("stream %d closed", 7).Error(); got != "stream 7 closed" {
+		t.Fatalf("got %q", got)
+	}
+	be := NewBatchErrorf([]int{0}, "%d records failed", 1)
+	if be.Error() != "1 records failed" || len(be.FailedIndices) != 1 {
+		t.Fatalf("unexpected batch error %q %v", be.Error(), be.FailedIndices)
+	}
+	tr := NewTransportErrorf(true, "dial %s", "db:5432")
+	if tr.Error() != "dial db:5432" || !tr.Retryable {
+		t.Fatalf("unexpected transport error %q", tr.Error())
+	}
+}
+
+func TestTypedErrors_AsThroughChains(t *testing.T) {
+	se := WrapStreamError(errors.New("eof"), "recv")
+	chains := []error{
+		Wrap(fmt.Errorf("a: %w", se), "b"),
+		errors.Join(New("other"), fmt.Errorf("x: %w", se)),
+		WrapConfigError(se, "outer"),
+	}
+	for i, err := range chains {
+		var got *StreamError
+		if !errors.As(err, &got) || got != se {
+			t.Fatalf("chain %d: errors.As should find the stream error", i)
+		}
+	}
+}
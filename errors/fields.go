@@ -0,0 +1,120 @@
+package errors
+
+// WithField attaches a structured field (tenant_id, batch_id, endpoint, ...)
+// that travels with the error, and returns e.
+func (e *Error) WithField(key string, value interface{}) *Error {
+	if e == nil {
+		return nil
+	}
+	if e.Fields == nil {
+		e.Fields = make(map[string]interface{}, 1)
+	}
+	e.Fields[key] = value
+	return e
+}
+
+// WithFields attaches several structured fields and returns e.
+func (e *Error) WithFields(fields map[string]interface{}) *Error {
+	if e == nil {
+		return nil
+	}
+	if e.Fields == nil {
+		e.Fields = make(map[string]interface{}, len(fields))
+	}
+	for k, v := range fields {
+		e.Fields[k] = v
+	}
+	return e
+}
+
+// Fields returns the fields of every *Error in err's chain merged into one
+// map, for logging and metrics. When a key appears more than once, the
+// outermost value wins. Timings attached by WithTiming are added under
+// "timings". It returns nil if there are no fields.
+func Fields(err error) map[string]interface{} {
+	var chain []*Error
+	Walk(err, func(e error) bool {
+		if base, ok := e.(*Error); ok && len(base.Fields) > 0 {
+			chain = append(chain, base)
+		}
+		return true
+	})
+	timings := Timings(err)
+	if len(chain) == 0 && len(timings) == 0 {
+		return nil
+	}
+
+	merged := make(map[string]interface{})
+	for i := len(chain) - 1; i >= 0; i-- {
+		for k, v := range chain[i].Fields {
+			merged[k] = v
+		}
+	}
+	if len(timings) > 0 {
+		merged["timings"] = timingFields(timings)
+	}
+	return merged
+}
+
+// The typed errors override the chaining helpers so that they return the
+// typed error instead of the embedded *Error, keeping the class in the chain.
+
+// WithCode sets the code and returns e.
+func (e *ConfigError) WithCode(code Code) *ConfigError { e.Base.WithCode(code); return e }
+
+// WithField attaches a structured field and returns e.
+func (e *ConfigError) WithField(key string, value interface{}) *ConfigError {
+	e.Base.WithField(key, value)
+	return e
+}
+
+// WithFields attaches several structured fields and returns e.
+func (e *ConfigError) WithFields(fields map[string]interface{}) *ConfigError {
+	e.Base.WithFields(fields)
+	return e
+}
+
+// WithCode sets the code and returns e.
+func (e *StreamError) WithCode(code Code) *StreamError { e.Base.WithCode(code); return e }
+
+// WithField attaches a structured field and returns e.
+func (e *StreamError) WithField(key string, value interface{}) *StreamError {
+	e.Base.WithField(key, value)
+	return e
+}
+
+// WithFields attaches several structured fields and returns e.
+func (e *StreamError) WithFields(fields map[string]interface{}) *StreamError {
+	e.Base.WithFields(fields)
+	return e
+}
+
+// WithCode sets the code and returns e.
+func (e *BatchError) WithCode(code Code) *BatchError { e.Base.WithCode(code); return e }
+
+// WithField attaches a structured field and returns e.
+func (e *BatchError) WithField(key string, value interface{}) *BatchError {
+	e.Base.WithField(key, value)
+	return e
+}
+
+// WithFields attaches several structured fields and returns e.
+func (e *BatchError) WithFields(fields map[string]interface{}) *BatchError {
+	e.Base.WithFields(fields)
+	return e
+}
+
+// WithCode sets the code and returns e.
+func (e *TransportError) WithCode(code Code) *TransportError { e.Base.WithCode(code); return e }
+
+// WithField attaches a structured field and returns e.
+func (e *TransportError) WithField(key string, value interface{}) *TransportError {
+	e.Base.WithField(key, value)
+	return e
+}
+
+// WithFields attaches several structured fields and returns e.
+func (e *TransportError) WithFields(fields map[string]interface{}) *TransportError {
+	e.Base.WithFields(fields)
+	return e
+}
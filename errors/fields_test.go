@@ -0,0 +1,80 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestWithField(t *testing.T) {
+	e := New("write failed").WithField("tenant_id", "t-1").WithField("batch_id", "b-9")
+	if e.Fields["tenant_id"] != "t-1" || e.Fields["batch_id"] != "b-9" {
+		t.Fatalf("got %v", e.Fields)
+	}
+}
+
+func TestWithFields(t *testing.T) {
+	e := New("x").WithFields(map[string]interface{}{"endpoint": "https://sink", "attempt": 3})
+	if e.Fields["endpoint"] != "https://sink" || e.Fields["attempt"] != 3 {
+		t.Fatalf("got %v", e.Fields)
+	}
+}
+
+func TestWithField_Nil(t *testing.T) {
+	var e *Error
+	if e.WithField("k", "v") != nil || e.WithFields(map[string]interface{}{"k": "v"}) != nil {
+		t.Fatal("nil receiver should return nil")
+	}
+}
+
+func TestFields_MergedOutermostWins(t *testing.T) {
+	inner := New("dial").WithFields(map[string]interface{}{"endpoint": "a", "attempt": 1})
+	outer := Wrap(fmt.Errorf("flush: %w", inner), "sink").WithFields(map[string]interface{}{"attempt": 2, "tenant_id": "t"})
+
+	got := Fields(outer)
+	if got["endpoint"] != "a" || got["attempt"] != 2 || got["tenant_id"] != "t" {
+		t.Fatalf("got %v", got)
+	}
+}
+
+func TestFields_None(t *testing.T) {
+	if Fields(fmt.Errorf("plain")) != nil || Fields(nil) != nil {
+		t.Fatal("expected nil")
+	}
+}
+
+func TestTypedWithField_KeepsClass(t *testing.T) {
+	err := error(NewConfigError("bad").WithField("field", "sink.endpoint").WithCode(CodeConfigMissing))
+
+	var cfg *ConfigError
+	if !errors.As(err, &cfg) {
+		t.Fatalf("expected *ConfigError, got %T", err)
+	}
+	if Fields(err)["field"] != "sink.endpoint" || CodeOf(err) != CodeConfigMissing {
+		t.Fatalf("fields %v code %q", Fields(err), CodeOf(err))
+	}
+
+	var be *BatchError
+	if !errors.As(error(NewBatchError("x", nil).WithFields(map[string]interface{}{"k": 1})), &be) {
+		t.Fatal("expected *BatchError")
+	}
+	var se *StreamError
+	if !errors.As(error(NewStreamError("x").WithField("k", 1)), &se) {
+		t.Fatal("expected *StreamError")
+	}
+	var te *TransportError
+	if !errors.As(error(NewTransportError("x", true).WithField("k", 1)), &te) {
+		t.Fatal("expected *TransportError")
+	}
+}
+
+func TestFields_JSONRoundTrip(t *testing.T) {
+	data, err := MarshalJSON(NewTransportError("x", true).WithField("endpoint", "h:1"), false)
+	if err != nil {
+		t.Fatalf("MarshalJSON: %v", err)
+	}
+	got, _ := UnmarshalJSON(data)
+	if Fields(got)["endpoint"] != "h:1" {
+		t.Fatalf("got %v", Fields(got))
+	}
+}
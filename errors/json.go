@@ -0,0 +1,281 @@
+package errors
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"runtime"
+	"time"
+)
+
+// Error class names used in the JSON "type" member.
+const (
+	typeError        = "error"
+	typeConfig       = "config"
+	typeStream       = "stream"
+	typeBatch        = "batch"
+	typeTransport    = "transport"
+	typeRateLimit    = "rate_limit"
+	typeBackpressure = "backpressure"
+)
+
+// Frame is a single resolved stack frame.
+type Frame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+// jsonError is the wire form of an error chain. Errors from other packages
+// are encoded with an empty type and only their message (and cause).
+type jsonError struct {
+	Type          string                 `json:"type,omitempty"`
+	Message       string                 `json:"message"`
+	Code          Code                   `json:"code,omitempty"`
+	Retryable     *bool                  `json:"retryable,omitempty"`
+	FailedIndices []int                  `json:"failed_indices,omitempty"`
+	RetryAfterMs  int64                  `json:"retry_after_ms,omitempty"`
+	QueueDepth    int                    `json:"queue_depth,omitempty"`
+	Fields        map[string]interface{} `json:"fields,omitempty"`
+	Stack         []Frame                `json:"stack,omitempty"`
+	Cause         *jsonError             `json:"cause,omitempty"`
+}
+
+// MarshalJSON encodes err and its cause chain, preserving the error class,
+// code, fields and class-specific members (retryability, failed indices,
+// retry delay, queue depth) so UnmarshalJSON can rebuild it on
+// the other side of a process boundary. Stacks are included if withStack.
+func MarshalJSON(err error, withStack bool) ([]byte, error) {
+	return json.Marshal(toJSON(err, withStack))
+}
+
+// UnmarshalJSON rebuilds an error chain encoded by MarshalJSON. The returned
+// error is the typed wrapper (*ConfigError, ...) when one was encoded.
+func UnmarshalJSON(data []byte) (error, error) {
+	if string(data) == "null" {
+		return nil, nil
+	}
+	var j jsonError
+	if err := json.Unmarshal(data, &j); err != nil {
+		return nil, err
+	}
+	return fromJSON(&j), nil
+}
+
+// MarshalJSON implements json.Marshaler. Stacks are omitted; use the
+// package-level MarshalJSON to include them.
+func (e *Error) MarshalJSON() ([]byte, error) { return MarshalJSON(e, false) }
+
+// MarshalJSON implements json.Marshaler.
+func (e *ConfigError) MarshalJSON() ([]byte, error) { return MarshalJSON(e, false) }
+
+// MarshalJSON implements json.Marshaler.
+func (e *StreamError) MarshalJSON() ([]byte, error) { return MarshalJSON(e, false) }
+
+// MarshalJSON implements json.Marshaler.
+func (e *BatchError) MarshalJSON() ([]byte, error) { return MarshalJSON(e, false) }
+
+// MarshalJSON implements json.Marshaler.
+func (e *TransportError) MarshalJSON() ([]byte, error) { return MarshalJSON(e, false) }
+
+// MarshalJSON implements json.Marshaler.
+func (e *RateLimitError) MarshalJSON() ([]byte, error) { return MarshalJSON(e, false) }
+
+// MarshalJSON implements json.Marshaler.
+func (e *BackpressureError) MarshalJSON() ([]byte, error) { return MarshalJSON(e, false) }
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (e *Error) UnmarshalJSON(data []byte) error {
+	var j jsonError
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	*e = *baseFromJSON(&j)
+	return nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (e *ConfigError) UnmarshalJSON(data []byte) error {
+	e.Base = &Error{}
+	return e.Base.UnmarshalJSON(data)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (e *StreamError) UnmarshalJSON(data []byte) error {
+	e.Base = &Error{}
+	return e.Base.UnmarshalJSON(data)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (e *BatchError) UnmarshalJSON(data []byte) error {
+	var j jsonError
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	e.Base = baseFromJSON(&j)
+	e.FailedIndices = j.FailedIndices
+	return nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (e *TransportError) UnmarshalJSON(data []byte) error {
+	var j jsonError
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	e.Base = baseFromJSON(&j)
+	e.Retryable = j.Retryable != nil && *j.Retryable
+	return nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (e *RateLimitError) UnmarshalJSON(data []byte) error {
+	var j jsonError
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	e.Base = baseFromJSON(&j)
+	e.RetryAfter = time.Duration(j.RetryAfterMs) * time.Millisecond
+	return nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (e *BackpressureError) UnmarshalJSON(data []byte) error {
+	var j jsonError
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	e.Base = baseFromJSON(&j)
+	e.RetryAfter = time.Duration(j.RetryAfterMs) * time.Millisecond
+	e.QueueDepth = j.QueueDepth
+	return nil
+}
+
+func toJSON(err error, withStack bool) *jsonError {
+	if err == nil {
+		return nil
+	}
+
+	var j *jsonError
+	switch e := err.(type) {
+	case *ConfigError:
+		j = baseToJSON(e.Base, withStack)
+		j.Type = typeConfig
+	case *StreamError:
+		j = baseToJSON(e.Base, withStack)
+		j.Type = typeStream
+	case *BatchError:
+		j = baseToJSON(e.Base, withStack)
+		j.Type = typeBatch
+		j.FailedIndices = e.FailedIndices
+	case *TransportError:
+		j = baseToJSON(e.Base, withStack)
+		j.Type = typeTransport
+		retryable := e.Retryable
+		j.Retryable = &retryable
+	case *RateLimitError:
+		j = baseToJSON(e.Base, withStack)
+		j.Type = typeRateLimit
+		j.RetryAfterMs = e.RetryAfter.Milliseconds()
+	case *BackpressureError:
+		j = baseToJSON(e.Base, withStack)
+		j.Type = typeBackpressure
+		j.RetryAfterMs = e.RetryAfter.Milliseconds()
+		j.QueueDepth = e.QueueDepth
+	case *Error:
+		j = baseToJSON(e, withStack)
+		j.Type = typeError
+	default:
+		// Foreign error: its message already includes any wrapped text, but
+		// the cause is still encoded so typed errors inside survive.
+		j = &jsonError{Message: err.Error()}
+		j.Cause = toJSON(stderrors.Unwrap(err), withStack)
+	}
+	return j
+}
+
+func baseToJSON(e *Error, withStack bool) *jsonError {
+	if e == nil {
+		return &jsonError{}
+	}
+	j := &jsonError{
+		Message: e.Message,
+		Code:    e.Code,
+		Fields:  e.Fields,
+		Cause:   toJSON(e.Cause, withStack),
+	}
+	if withStack {
+		j.Stack = filterFrames(e.frameList())
+	}
+	return j
+}
+
+func fromJSON(j *jsonError) error {
+	if j == nil {
+		return nil
+	}
+	switch j.Type {
+	case typeConfig:
+		return &ConfigError{Base: baseFromJSON(j)}
+	case typeStream:
+		return &StreamError{Base: baseFromJSON(j)}
+	case typeBatch:
+		return &BatchError{Base: baseFromJSON(j), FailedIndices: j.FailedIndices}
+	case typeTransport:
+		return &TransportError{Base: baseFromJSON(j), Retryable: j.Retryable != nil && *j.Retryable}
+	case typeRateLimit:
+		return &RateLimitError{
+			Base:       baseFromJSON(j),
+			RetryAfter: time.Duration(j.RetryAfterMs) * time.Millisecond,
+		}
+	case typeBackpressure:
+		return &BackpressureError{
+			Base:       baseFromJSON(j),
+			RetryAfter: time.Duration(j.RetryAfterMs) * time.Millisecond,
+			QueueDepth: j.QueueDepth,
+		}
+	case typeError:
+		return baseFromJSON(j)
+	default:
+		return &remoteError{msg: j.Message, cause: fromJSON(j.Cause)}
+	}
+}
+
+func baseFromJSON(j *jsonError) *Error {
+	return &Error{
+		Message: j.Message,
+		Code:    j.Code,
+		Fields:  j.Fields,
+		Cause:   fromJSON(j.Cause),
+		frames:  j.Stack,
+	}
+}
+
+// frameList resolves the captured stack, or returns the decoded remote stack.
+func (e *Error) frameList() []Frame {
+	if len(e.Stack) == 0 {
+		return e.frames
+	}
+	var out []Frame
+	frames := runtime.CallersFrames(e.Stack)
+	for {
+		frame, more := frames.Next()
+		if frame.Function == "" {
+			break
+		}
+		out = append(out, Frame{Function: frame.Function, File: frame.File, Line: frame.Line})
+		if !more {
+			break
+		}
+	}
+	return out
+}
+
+// remoteError stands in for a decoded error that was not one of ours.
+type remoteError struct {
+	msg   string
+	cause error
+}
+
+func (e *remoteError) Error() string { return e.msg }
+
+func (e *remoteError) Unwrap() error { return e.cause }
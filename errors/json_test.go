@@ -0,0 +1,157 @@
+package errors
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestMarshalJSON_RoundTripTyped(t *testing.T) {
+	tests := []struct {
+		name  string
+		err   error
+		check func(t *testing.T, got error)
+	}{
+		{"config", NewConfigError("bad dsn"), func(t *testing.T, got error) {
+			var e *ConfigError
+			if !errors.As(got, &e) || e.Message != "bad dsn" {
+				t.Fatalf("got %#v", got)
+			}
+		}},
+		{"stream", NewStreamError("eof"), func(t *testing.T, got error) {
+			var e *StreamError
+			if !errors.As(got, &e) {
+				t.Fatalf("got %#v", got)
+			}
+		}},
+		{"batch", NewBatchError("partial", []int{1, 4}), func(t *testing.T, got error) {
+			var e *BatchError
+			if !errors.As(got, &e) || fmt.Sprint(e.FailedIndices) != "[1 4]" {
+				t.Fatalf("got %#v", got)
+			}
+		}},
+		{"transport", NewTransportError("refused", true), func(t *testing.T, got error) {
+			var e *TransportError
+			if !errors.As(got, &e) || !e.Retryable {
+				t.Fatalf("got %#v", got)
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := MarshalJSON(tt.err, false)
+			if err != nil {
+				t.Fatalf("MarshalJSON: %v", err)
+			}
+			got, err := UnmarshalJSON(data)
+			if err != nil {
+				t.Fatalf("UnmarshalJSON: %v", err)
+			}
+			if got.Error() != tt.err.Error() {
+				t.Fatalf("message: got %q, want %q", got.Error(), tt.err.Error())
+			}
+			if CodeOf(got) != CodeOf(tt.err) {
+				t.Fatalf("code: got %q, want %q", CodeOf(got), CodeOf(tt.err))
+			}
+			tt.check(t, got)
+		})
+	}
+}
+
+func TestMarshalJSON_Chain(t *testing.T) {
+	root := NewTransportError("connection reset", true)
+	err := Wrap(fmt.Errorf("flush: %w", root), "sink write")
+
+	data, merr := MarshalJSON(err, false)
+	if merr != nil {
+		t.Fatalf("MarshalJSON: %v", merr)
+	}
+	got, uerr := UnmarshalJSON(data)
+	if uerr != nil {
+		t.Fatalf("UnmarshalJSON: %v", uerr)
+	}
+	if got.Error() != err.Error() {
+		t.Fatalf("message: got %q, want %q", got.Error(), err.Error())
+	}
+	var te *TransportError
+	if !errors.As(got, &te) {
+		t.Fatal("transport error should survive through a foreign wrapper")
+	}
+}
+
+func TestMarshalJSON_Stack(t *testing.T) {
+	data, err := MarshalJSON(New("with stack"), true)
+	if err != nil {
+		t.Fatalf("MarshalJSON: %v", err)
+	}
+	if !strings.Contains(string(data), "TestMarshalJSON_Stack") {
+		t.Fatalf("expected stack frames, got %s", data)
+	}
+
+	got, _ := UnmarshalJSON(data)
+	var e *Error
+	if !errors.As(got, &e) {
+		t.Fatalf("got %T", got)
+	}
+	if !strings.Contains(e.StackTrace(), "TestMarshalJSON_Stack") {
+		t.Fatalf("decoded stack trace missing frames:\n%s", e.StackTrace())
+	}
+}
+
+func TestMarshalJSON_NoStackByDefault(t *testing.T) {
+	data, err := json.Marshal(New("no stack"))
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+	if strings.Contains(string(data), `"stack"`) {
+		t.Fatalf("stack should be omitted, got %s", data)
+	}
+}
+
+func TestJSON_StructField(t *testing.T) {
+	type ack struct {
+		Err *BatchError `json:"err"`
+	}
+	in := ack{Err: NewBatchError("rejected", []int{3})}
+	data, err := json.Marshal(in)
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+
+	var out ack
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatalf("json.Unmarshal: %v", err)
+	}
+	if out.Err.Message != "rejected" || len(out.Err.FailedIndices) != 1 {
+		t.Fatalf("got %+v", out.Err)
+	}
+	if out.Err.Code != CodeBatchPartial {
+		t.Fatalf("code: got %q", out.Err.Code)
+	}
+}
+
+func TestJSON_TransportStructField(t *testing.T) {
+	data, _ := json.Marshal(NewTransportError("refused", false))
+	var te TransportError
+	if err := json.Unmarshal(data, &te); err != nil {
+		t.Fatalf("json.Unmarshal: %v", err)
+	}
+	if te.Retryable || te.Message != "refused" {
+		t.Fatalf("got %+v", te)
+	}
+}
+
+func TestUnmarshalJSON_Null(t *testing.T) {
+	got, err := UnmarshalJSON([]byte("null"))
+	if got != nil || err != nil {
+		t.Fatalf("got %v, %v", got, err)
+	}
+}
+
+func TestUnmarshalJSON_Invalid(t *testing.T) {
+	if _, err := UnmarshalJSON([]byte("{bad")); err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -0,0 +1,51 @@
+package errors
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MultiError holds independent failures, such as those of the tasks of a
+// fan-out stage. errors.Is and errors.As match any of them, and Walk visits
+// each in order.
+type MultiError struct {
+	Errors []error
+}
+
+// NewMultiError returns a *MultiError holding the non-nil errs, or nil if
+// there are none.
+func NewMultiError(errs ...error) error {
+	var kept []error
+	for _, err := range errs {
+		if err != nil {
+			kept = append(kept, err)
+		}
+	}
+	if len(kept) == 0 {
+		return nil
+	}
+	return &MultiError{Errors: kept}
+}
+
+// Error implements the error interface.
+func (m *MultiError) Error() string {
+	if m == nil || len(m.Errors) == 0 {
+		return ""
+	}
+	if len(m.Errors) == 1 {
+		return m.Errors[0].Error()
+	}
+	msgs := make([]string, len(m.Errors))
+	for i, err := range m.Errors {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("%d errors: %s", len(m.Errors), strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the held errors.
+func (m *MultiError) Unwrap() []error {
+	if m == nil {
+		return nil
+	}
+	return m.Errors
+}
@@ -0,0 +1,24 @@
+package errors
+
+import (
+	stderrors "errors"
+	"testing"
+)
+
+func TestNewMultiError(t *testing.T) {
+	if err := NewMultiError(nil, nil); err != nil {
+		t.Fatalf("got %v", err)
+	}
+	cfg := NewConfigError("bad endpoint")
+	err := NewMultiError(nil, stderrors.New("timeout"), cfg)
+	if err.Error() != "2 errors: timeout; bad endpoint" {
+		t.Fatalf("got %q", err)
+	}
+	var target *ConfigError
+	if !stderrors.As(err, &target) || target != cfg {
+		t.Fatal("As should reach a held error")
+	}
+	if single := NewMultiError(cfg); single.Error() != "bad endpoint" {
+		t.Fatalf("single: got %q", single)
+	}
+}
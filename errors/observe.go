@@ -0,0 +1,57 @@
+package errors
+
+import (
+	"context"
+
+	"github.com/planx-lab/planx-common/logger"
+	"github.com/planx-lab/planx-common/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Observe reports err in one call: it records it on span (the span in ctx if
+// span is nil) and marks the span failed, increments planx.errors.total with
+// the error class, and logs it at error level with its code, fields and
+// stack. It does nothing if err is nil.
+func Observe(ctx context.Context, span trace.Span, err error, tenantID, stage string) {
+	if err == nil {
+		return
+	}
+	if span == nil {
+		span = trace.SpanFromContext(ctx)
+	}
+
+	class := classOf(err)
+	if class == "" {
+		class = "unknown"
+	}
+	code := CodeOf(err)
+
+	if span.IsRecording() {
+		attrs := []attribute.KeyValue{attribute.String("error.class", class)}
+		if code != CodeUnknown {
+			attrs = append(attrs, attribute.String("error.code", string(code)))
+		}
+		span.RecordError(err, trace.WithAttributes(attrs...))
+		span.SetStatus(otelcodes.Error, err.Error())
+	}
+
+	telemetry.RecordError(ctx, tenantID, stage, class)
+
+	ev := logger.ErrorCtx(ctx).
+		Err(err).
+		Str("tenant_id", tenantID).
+		Str("stage", stage).
+		Str("error_class", class)
+	if code != CodeUnknown {
+		ev = ev.Str("code", string(code))
+	}
+	if fields := Fields(err); len(fields) > 0 {
+		ev = ev.Fields(fields)
+	}
+	if e := deepestWithStack(err); e != nil {
+		ev = ev.Str("stack", e.StackTrace())
+	}
+	ev.Msg("stage failed")
+}
@@ -0,0 +1,68 @@
+package errors
+
+import (
+	"context"
+	"testing"
+
+	"github.com/planx-lab/planx-common/logger/logtest"
+	"github.com/rs/zerolog"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestObserve(t *testing.T) {
+	rec := logtest.Capture(t)
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	ctx, span := provider.Tracer("test").Start(context.Background(), "write")
+
+	err := NewTransportError("sink unavailable", true).WithField("endpoint", "db:5432")
+	Observe(ctx, nil, err, "tenant-1", "sink")
+	span.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	if spans[0].Status().Code != otelcodes.Error {
+		t.Fatal("expected span status error")
+	}
+	events := spans[0].Events()
+	if len(events) != 1 || events[0].Name != "exception" {
+		t.Fatalf("expected an exception event, got %v", events)
+	}
+
+	entries := rec.Find(zerolog.ErrorLevel, "stage failed")
+	if len(entries) != 1 {
+		t.Fatalf("expected one log entry, got %d", len(entries))
+	}
+	e := entries[0]
+	if e.Str("tenant_id") != "tenant-1" || e.Str("stage") != "sink" || e.Str("error_class") != typeTransport {
+		t.Fatalf("unexpected log entry %s", e.Raw)
+	}
+	if e.Str("code") != string(CodeTransportUnavailable) || e.Str("endpoint") != "db:5432" || e.Str("stack") == "" {
+		t.Fatalf("expected code, fields and stack in %s", e.Raw)
+	}
+	if e.Str("trace_id") != span.SpanContext().TraceID().String() {
+		t.Fatal("expected the log to carry the trace id")
+	}
+}
+
+func TestObserve_ExplicitSpanAndNil(t *testing.T) {
+	rec := logtest.Capture(t)
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	_, span := provider.Tracer("test").Start(context.Background(), "write")
+
+	Observe(context.Background(), span, nil, "tenant-1", "sink")
+	Observe(context.Background(), span, New("boom"), "tenant-1", "sink")
+	span.End()
+
+	if got := recorder.Ended()[0].Events(); len(got) != 1 {
+		t.Fatalf("expected one recorded error, got %d", len(got))
+	}
+	if len(rec.Entries()) != 1 {
+		t.Fatalf("expected one log entry, got %d", len(rec.Entries()))
+	}
+}
@@ -0,0 +1,42 @@
+package errors
+
+import "fmt"
+
+// Recover converts a value returned by recover() into an *Error carrying the
+// panic stack. Call it from the deferred function itself so the stack still
+// contains the panicking frames:
+//
+//	defer func() {
+//		if r := recover(); r != nil {
+//			err = errors.Recover(r)
+//		}
+//	}()
+//
+// It returns nil if recovered is nil. Panics with an error value keep it as
+// the cause.
+func Recover(recovered interface{}) *Error {
+	if recovered == nil {
+		return nil
+	}
+	e := &Error{
+		Message: fmt.Sprintf("panic: %v", recovered),
+		Code:    CodePanic,
+		Stack:   captureStack(2),
+	}
+	if cause, ok := recovered.(error); ok {
+		e.Message = "panic"
+		e.Cause = cause
+	}
+	return e
+}
+
+// Safe runs fn and converts a panic into an error, so a misbehaving
+// processor cannot crash the session goroutine.
+func Safe(fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = Recover(r)
+		}
+	}()
+	return fn()
+}
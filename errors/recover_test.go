@@ -0,0 +1,71 @@
+package errors
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRecover_Nil(t *testing.T) {
+	if Recover(nil) != nil {
+		t.Fatal("expected nil")
+	}
+}
+
+func TestRecover_Value(t *testing.T) {
+	var got *Error
+	func() {
+		defer func() {
+			got = Recover(recover())
+		}()
+		panicWith("index out of range")
+	}()
+
+	if got == nil {
+		t.Fatal("expected error")
+	}
+	if got.Message != "panic: index out of range" {
+		t.Fatalf("message: got %q", got.Message)
+	}
+	if got.Code != CodePanic {
+		t.Fatalf("code: got %q", got.Code)
+	}
+	if !strings.Contains(got.StackTrace(), "panicWith") {
+		t.Fatalf("stack should include the panicking frame:\n%s", got.StackTrace())
+	}
+}
+
+func TestRecover_ErrorValue(t *testing.T) {
+	cause := errors.New("nil map write")
+	got := Recover(cause)
+	if !errors.Is(got, cause) {
+		t.Fatal("panic error should be the cause")
+	}
+}
+
+func TestSafe(t *testing.T) {
+	err := Safe(func() error {
+		panicWith("boom")
+		return nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Fatalf("got %v", err)
+	}
+	if CodeOf(err) != CodePanic {
+		t.Fatalf("code: got %q", CodeOf(err))
+	}
+}
+
+func TestSafe_PassesThrough(t *testing.T) {
+	want := New("regular")
+	if err := Safe(func() error { return want }); err != want {
+		t.Fatalf("got %v", err)
+	}
+	if err := Safe(func() error { return nil }); err != nil {
+		t.Fatalf("got %v", err)
+	}
+}
+
+func panicWith(v interface{}) {
+	panic(v)
+}
@@ -0,0 +1,253 @@
+package errors
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/planx-lab/planx-common/logger"
+	"github.com/planx-lab/planx-common/telemetry"
+)
+
+// Reporter defaults.
+const (
+	DefaultReportWindow    = time.Minute
+	DefaultMaxFingerprints = 1000
+)
+
+// Summary aggregates the occurrences of one error fingerprint over a
+// reporting window.
+type Summary struct {
+	Fingerprint string
+	Class       string // config, stream, batch, ... (see MarshalJSON)
+	Code        Code
+	Message     string // message of the first error seen
+	Count       int64
+	FirstSeen   time.Time
+	LastSeen    time.Time
+	Stack       string                 // stack of the first error seen, if any
+	Fields      map[string]interface{} // fields of the first error seen
+}
+
+// ReporterConfig holds Reporter configuration.
+type ReporterConfig struct {
+	Window          time.Duration // summary interval, DefaultReportWindow if zero
+	MaxFingerprints int           // distinct fingerprints kept per window, DefaultMaxFingerprints if zero
+	OnSummary       func(Summary) // optional extra sink, called after logging
+}
+
+// Reporter collects errors by fingerprint and emits one summary per
+// fingerprint and window instead of one report per occurrence, so a flapping
+// sink does not flood the logs. Summaries are logged at warn level and
+// counted in planx.errors.total.
+type Reporter struct {
+	cfg ReporterConfig
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*Summary
+	order   []string
+	dropped int64
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewReporter creates a reporter and starts its flush loop. Call Close to
+// stop it and emit what is pending.
+func NewReporter(cfg ReporterConfig) *Reporter {
+	if cfg.Window <= 0 {
+		cfg.Window = DefaultReportWindow
+	}
+	if cfg.MaxFingerprints <= 0 {
+		cfg.MaxFingerprints = DefaultMaxFingerprints
+	}
+	r := &Reporter{
+		cfg:     cfg,
+		now:     time.Now,
+		entries: make(map[string]*Summary),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go r.loop()
+	return r
+}
+
+func (r *Reporter) loop() {
+	defer close(r.done)
+	ticker := time.NewTicker(r.cfg.Window)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			r.Flush()
+		case <-r.stop:
+			return
+		}
+	}
+}
+
+// Report records one occurrence of err. nil errors are ignored.
+func (r *Reporter) Report(err error) {
+	if err == nil {
+		return
+	}
+	fp := Fingerprint(err)
+	now := r.now()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if s, ok := r.entries[fp]; ok {
+		s.Count++
+		s.LastSeen = now
+		return
+	}
+	if len(r.entries) >= r.cfg.MaxFingerprints {
+		r.dropped++
+		return
+	}
+	s := &Summary{
+		Fingerprint: fp,
+		Class:       classOf(err),
+		Code:        CodeOf(err),
+		Message:     err.Error(),
+		Count:       1,
+		FirstSeen:   now,
+		LastSeen:    now,
+		Fields:      Fields(err),
+	}
+	if e := deepestWithStack(err); e != nil {
+		s.Stack = e.StackTrace()
+	}
+	r.entries[fp] = s
+	r.order = append(r.order, fp)
+}
+
+// Flush emits a summary for every fingerprint seen since the last flush and
+// resets the window.
+func (r *Reporter) Flush() {
+	r.mu.Lock()
+	summaries := make([]Summary, 0, len(r.order))
+	for _, fp := range r.order {
+		summaries = append(summaries, *r.entries[fp])
+	}
+	dropped := r.dropped
+	r.entries = make(map[string]*Summary)
+	r.order = nil
+	r.dropped = 0
+	r.mu.Unlock()
+
+	for _, s := range summaries {
+		r.emit(s)
+	}
+	if dropped > 0 {
+		logger.Warn().
+			Int64("dropped", dropped).
+			Int("max_fingerprints", r.cfg.MaxFingerprints).
+			Msg("error reporter dropped errors with new fingerprints")
+	}
+}
+
+func (r *Reporter) emit(s Summary) {
+	ev := logger.Warn().
+		Str("fingerprint", s.Fingerprint).
+		Str("error_class", s.Class).
+		Int64("count", s.Count).
+		Time("first_seen", s.FirstSeen).
+		Time("last_seen", s.LastSeen).
+		Str("error", s.Message)
+	if s.Code != CodeUnknown {
+		ev = ev.Str("code", string(s.Code))
+	}
+	if len(s.Fields) > 0 {
+		ev = ev.Fields(s.Fields)
+	}
+	if s.Stack != "" {
+		ev = ev.Str("stack", s.Stack)
+	}
+	ev.Msg("error summary")
+
+	tenantID, _ := s.Fields["tenant_id"].(string)
+	stage, _ := s.Fields["stage"].(string)
+	telemetry.RecordErrors(context.Background(), tenantID, stage, s.Class, s.Count)
+
+	if r.cfg.OnSummary != nil {
+		r.cfg.OnSummary(s)
+	}
+}
+
+// Close stops the flush loop and emits pending summaries.
+func (r *Reporter) Close() {
+	r.closeOnce.Do(func() {
+		close(r.stop)
+		<-r.done
+		r.Flush()
+	})
+}
+
+// Fingerprint identifies errors that should be aggregated together: same
+// class, code, tenant_id and stage fields, and origin. The origin is the
+// first frame of the deepest stack, or the root cause message when no stack
+// was captured.
+func Fingerprint(err error) string {
+	if err == nil {
+		return ""
+	}
+	fields := Fields(err)
+	origin := ""
+	if e := deepestWithStack(err); e != nil {
+		if frames := e.frameList(); len(frames) > 0 {
+			origin = fmt.Sprintf("%s:%d", frames[0].Function, frames[0].Line)
+		}
+	}
+	if origin == "" {
+		origin = RootCause(err).Error()
+	}
+
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%s|%s|%v|%v|%s", classOf(err), CodeOf(err), fields["tenant_id"], fields["stage"], origin)
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+// classOf returns the class name of the outermost typed error in err's chain,
+// "canceled" or "deadline" for errors from FromContext, "error" for a plain
+// *Error, or "" for foreign errors.
+func classOf(err error) string {
+	class := ""
+	Walk(err, func(e error) bool {
+		switch v := e.(type) {
+		case *ConfigError:
+			class = typeConfig
+		case *StreamError:
+			class = typeStream
+		case *BatchError:
+			class = typeBatch
+		case *TransportError:
+			class = typeTransport
+		case *RateLimitError:
+			class = typeRateLimit
+		case *BackpressureError:
+			class = typeBackpressure
+		case *Error:
+			switch v.Code {
+			case CodeCanceled:
+				class = classCanceled
+				return false
+			case CodeDeadlineExceeded:
+				class = classDeadline
+				return false
+			}
+			if class == "" {
+				class = typeError
+			}
+			return true
+		default:
+			return true
+		}
+		return false
+	})
+	return class
+}
@@ -0,0 +1,127 @@
+package errors
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/planx-lab/planx-common/logger/logtest"
+	"github.com/rs/zerolog"
+)
+
+func newTestReporter(t *testing.T, cfg ReporterConfig) (*Reporter, *[]Summary) {
+	t.Helper()
+	var got []Summary
+	cfg.Window = time.Hour
+	cfg.OnSummary = func(s Summary) { got = append(got, s) }
+	r := NewReporter(cfg)
+	t.Cleanup(r.Close)
+	return r, &got
+}
+
+func sinkError() error {
+	return NewTransportError("sink unavailable", true).WithField("tenant_id", "t1")
+}
+
+func TestReporter_Aggregates(t *testing.T) {
+	rec := logtest.Capture(t)
+	r, got := newTestReporter(t, ReporterConfig{})
+
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	tick := base
+	r.now = func() time.Time { tick = tick.Add(time.Second); return tick }
+
+	for i := 0; i < 50; i++ {
+		r.Report(fmt.Errorf("write batch %d: %w", i, sinkError()))
+	}
+	r.Report(NewConfigError("bad endpoint"))
+	r.Report(nil)
+	r.Flush()
+
+	if len(*got) != 2 {
+		t.Fatalf("expected 2 summaries, got %d", len(*got))
+	}
+	s := (*got)[0]
+	if s.Count != 50 || s.Class != typeTransport || s.Code != CodeTransportUnavailable {
+		t.Fatalf("unexpected summary %+v", s)
+	}
+	if !s.FirstSeen.Equal(base.Add(time.Second)) || !s.LastSeen.Equal(base.Add(50*time.Second)) {
+		t.Fatalf("unexpected first/last seen %v %v", s.FirstSeen, s.LastSeen)
+	}
+	if s.Message != "write batch 0: sink unavailable" || s.Stack == "" || s.Fields["tenant_id"] != "t1" {
+		t.Fatalf("expected sample of the first error, got %+v", s)
+	}
+	if (*got)[1].Class != typeConfig || (*got)[1].Count != 1 {
+		t.Fatalf("unexpected second summary %+v", (*got)[1])
+	}
+
+	rec.AssertLogged(zerolog.WarnLevel, "error summary")
+	entries := rec.Find(zerolog.WarnLevel, "error summary")
+	if entries[0].Str("fingerprint") != s.Fingerprint || entries[0].Fields["count"] != float64(50) {
+		t.Fatalf("unexpected log entry %s", entries[0].Raw)
+	}
+
+	r.Flush()
+	if len(*got) != 2 {
+		t.Fatal("flush should reset the window")
+	}
+}
+
+func TestReporter_MaxFingerprints(t *testing.T) {
+	rec := logtest.Capture(t)
+	r, got := newTestReporter(t, ReporterConfig{MaxFingerprints: 1})
+
+	r.Report(New("first"))
+	r.Report(New("second"))
+	r.Flush()
+
+	if len(*got) != 1 {
+		t.Fatalf("expected 1 summary, got %d", len(*got))
+	}
+	rec.AssertLogged(zerolog.WarnLevel, "dropped")
+}
+
+func TestReporter_CloseFlushes(t *testing.T) {
+	logtest.Capture(t)
+	var got []Summary
+	r := NewReporter(ReporterConfig{Window: time.Hour, OnSummary: func(s Summary) { got = append(got, s) }})
+	r.Report(New("x"))
+	r.Close()
+	r.Close()
+	if len(got) != 1 {
+		t.Fatalf("expected close to flush, got %d summaries", len(got))
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	if Fingerprint(nil) != "" {
+		t.Fatal("nil error should have an empty fingerprint")
+	}
+	a := fmt.Errorf("attempt 1: %w", sinkError())
+	b := fmt.Errorf("attempt 2: %w", sinkError())
+	if Fingerprint(a) != Fingerprint(b) {
+		t.Fatal("errors from the same origin should share a fingerprint")
+	}
+	other := NewTransportError("sink unavailable", true).WithField("tenant_id", "t2")
+	if Fingerprint(other) == Fingerprint(a) {
+		t.Fatal("different tenants should not share a fingerprint")
+	}
+	if Fingerprint(fmt.Errorf("x")) == Fingerprint(fmt.Errorf("y")) {
+		t.Fatal("foreign errors should be fingerprinted by message")
+	}
+}
+
+func TestClassOf(t *testing.T) {
+	cases := map[error]string{
+		New("x"):                           typeError,
+		Wrap(NewStreamError("x"), "outer"): typeStream,
+		fmt.Errorf("a: %w", NewBatchError("x", nil)): typeBatch,
+		fmt.Errorf("plain"):                          "",
+		NewRateLimitError("x", 0):                    typeRateLimit,
+	}
+	for err, want := range cases {
+		if got := classOf(err); got != want {
+			t.Errorf("classOf(%v) = %q, want %q", err, got, want)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package errors
+
+import (
+	"context"
+	"io"
+	"net"
+	"sync"
+	"syscall"
+)
+
+// Classifier decides whether err is retryable. It returns ok=false when it
+// has no opinion, letting the next classifier or the built-in rules decide.
+type Classifier func(err error) (retryable, ok bool)
+
+var (
+	classifiersMu sync.RWMutex
+	classifiers   []Classifier
+)
+
+// RegisterClassifier adds a classifier consulted by IsRetryable before the
+// built-in rules. Classifiers run in registration order.
+func RegisterClassifier(c Classifier) {
+	classifiersMu.Lock()
+	defer classifiersMu.Unlock()
+	classifiers = append(classifiers, c)
+}
+
+// IsRetryable reports whether the operation that produced err may succeed if
+// retried. It walks the chain outermost first and the first error with a
+// verdict decides:
+//
+//   - registered classifiers, in order
+//   - TransportError: its Retryable flag
+//   - ConfigError, StreamError: never retryable
+//   - BatchError: retryable (the failed records may be resent)
+//   - RateLimitError, BackpressureError: retryable (after RetryAfter)
+//   - errors from FromContext: CodeDeadlineExceeded retryable, CodeCanceled not
+//   - context.DeadlineExceeded and net timeouts: retryable
+//   - context.Canceled: not retryable
+//   - connection refused/reset/aborted, broken pipe, unexpected EOF: retryable
+//
+// Errors with no verdict anywhere in the chain are not retryable.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	classifiersMu.RLock()
+	cs := classifiers
+	classifiersMu.RUnlock()
+
+	var retryable, decided bool
+	Walk(err, func(e error) bool {
+		retryable, decided = classify(e, cs)
+		return !decided
+	})
+	return decided && retryable
+}
+
+func classify(err error, cs []Classifier) (retryable, ok bool) {
+	for _, c := range cs {
+		if r, ok := c(err); ok {
+			return r, true
+		}
+	}
+
+	switch e := err.(type) {
+	case *TransportError:
+		return e.Retryable, true
+	case *ConfigError, *StreamError:
+		return false, true
+	case *BatchError, *RateLimitError, *BackpressureError:
+		return true, true
+	case *Error:
+		switch e.Code {
+		case CodeCanceled:
+			return false, true
+		case CodeDeadlineExceeded:
+			return true, true
+		}
+	}
+
+	switch err {
+	case context.DeadlineExceeded, io.ErrUnexpectedEOF:
+		return true, true
+	case context.Canceled:
+		return false, true
+	}
+
+	if errno, isErrno := err.(syscall.Errno); isErrno {
+		switch errno {
+		case syscall.ECONNREFUSED, syscall.ECONNRESET, syscall.ECONNABORTED, syscall.EPIPE:
+			return true, true
+		}
+	}
+
+	if ne, isNet := err.(net.Error); isNet && ne.Timeout() {
+		return true, true
+	}
+
+	return false, false
+}
@@ -0,0 +1,77 @@
+package errors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"plain", fmt.Errorf("plain"), false},
+		{"transport retryable", NewTransportError("reset", true), true},
+		{"transport fatal", NewTransportError("auth", false), false},
+		{"config", NewConfigError("bad"), false},
+		{"stream", NewStreamError("eof"), false},
+		{"batch", NewBatchError("partial", []int{1}), true},
+		{"deadline", context.DeadlineExceeded, true},
+		{"canceled", context.Canceled, false},
+		{"wrapped deadline", Wrap(context.DeadlineExceeded, "send"), true},
+		{"unexpected eof", fmt.Errorf("read: %w", io.ErrUnexpectedEOF), true},
+		{"conn refused", &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}, true},
+		{"conn reset", fmt.Errorf("write: %w", syscall.ECONNRESET), true},
+		{"net timeout", &net.DNSError{Err: "timeout", IsTimeout: true}, true},
+		{"dns not found", &net.DNSError{Err: "no such host", IsNotFound: true}, false},
+		{"outermost wins", Wrap(NewConfigError("bad"), "dial").WithCode(CodeTransportTimeout), false},
+		{"config wraps deadline", fmt.Errorf("x: %w", errors.Join(NewConfigError("bad"), context.DeadlineExceeded)), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryable(tt.err); got != tt.want {
+				t.Fatalf("IsRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsRetryable_TypedWrapsRetryableCause(t *testing.T) {
+	// The outer class decides even when the cause would be retryable.
+	e := NewStreamError("session closed")
+	e.Cause = context.DeadlineExceeded
+	if IsRetryable(e) {
+		t.Fatal("stream error should not be retryable")
+	}
+}
+
+var errThrottled = errors.New("throttled")
+
+func TestRegisterClassifier(t *testing.T) {
+	RegisterClassifier(func(err error) (bool, bool) {
+		if err == errThrottled {
+			return true, true
+		}
+		return false, false
+	})
+	defer func() {
+		classifiersMu.Lock()
+		classifiers = nil
+		classifiersMu.Unlock()
+	}()
+
+	if !IsRetryable(fmt.Errorf("sink: %w", errThrottled)) {
+		t.Fatal("custom classifier should mark errThrottled retryable")
+	}
+	if IsRetryable(fmt.Errorf("other")) {
+		t.Fatal("unclassified errors should stay non-retryable")
+	}
+}
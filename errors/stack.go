@@ -0,0 +1,94 @@
+package errors
+
+import (
+	"path"
+	"strings"
+	"sync"
+)
+
+// StackOptions controls how captured stacks are rendered by StackTrace,
+// Frames and the JSON encoding. The zero value renders every frame verbatim.
+type StackOptions struct {
+	MaxDepth     int      // keep at most this many frames; 0 means no limit
+	SkipRuntime  bool     // drop runtime.* and internal/* frames
+	ShortPaths   bool     // render files as <import path>/<file>, dropping GOPATH/module cache prefixes
+	TrimPrefixes []string // prefixes stripped from file paths (e.g. a checkout root)
+}
+
+var (
+	stackOptsMu sync.RWMutex
+	stackOpts   StackOptions
+)
+
+// SetStackOptions sets the rendering options for all errors. Stacks are still
+// captured in full; the options only apply when they are resolved.
+func SetStackOptions(opts StackOptions) {
+	stackOptsMu.Lock()
+	defer stackOptsMu.Unlock()
+	opts.TrimPrefixes = append([]string(nil), opts.TrimPrefixes...)
+	stackOpts = opts
+}
+
+// Frames returns the error's stack as structured frames with the current
+// StackOptions applied. Like StackTrace, it falls back to the deepest stack in
+// the chain when e did not capture one.
+func (e *Error) Frames() []Frame {
+	if e == nil {
+		return nil
+	}
+	frames := e.frameList()
+	if len(frames) == 0 {
+		if inner := deepestWithStack(e.Cause); inner != nil {
+			frames = inner.frameList()
+		}
+	}
+	return filterFrames(frames)
+}
+
+// filterFrames applies the current StackOptions to frames.
+func filterFrames(frames []Frame) []Frame {
+	stackOptsMu.RLock()
+	opts := stackOpts
+	stackOptsMu.RUnlock()
+
+	if len(frames) == 0 {
+		return frames
+	}
+	out := make([]Frame, 0, len(frames))
+	for _, f := range frames {
+		if opts.SkipRuntime && isRuntimeFrame(f.Function) {
+			continue
+		}
+		if opts.ShortPaths {
+			if pkg := packagePath(f.Function); pkg != "" {
+				f.File = pkg + "/" + path.Base(f.File)
+			}
+		}
+		for _, prefix := range opts.TrimPrefixes {
+			if strings.HasPrefix(f.File, prefix) {
+				f.File = strings.TrimPrefix(f.File[len(prefix):], "/")
+				break
+			}
+		}
+		out = append(out, f)
+		if opts.MaxDepth > 0 && len(out) == opts.MaxDepth {
+			break
+		}
+	}
+	return out
+}
+
+func isRuntimeFrame(function string) bool {
+	return strings.HasPrefix(function, "runtime.") || strings.HasPrefix(function, "internal/")
+}
+
+// packagePath extracts the import path from a fully qualified function name
+// such as "github.com/planx-lab/planx-common/errors.(*Error).Wrap".
+func packagePath(function string) string {
+	slash := strings.LastIndex(function, "/")
+	dot := strings.Index(function[slash+1:], ".")
+	if dot < 0 {
+		return ""
+	}
+	return function[:slash+1+dot]
+}
@@ -0,0 +1,166 @@
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestWrap_SkipsStackWhenChainHasOne(t *testing.T) {
+	inner := New("root")
+	outer := Wrap(fmt.Errorf("mid: %w", inner), "outer")
+	if outer.Stack != nil {
+		t.Fatal("Wrap should not capture a second stack")
+	}
+	if !strings.Contains(outer.StackTrace(), "TestWrap_SkipsStackWhenChainHasOne") {
+		t.Fatalf("StackTrace should report the inner stack:\n%s", outer.StackTrace())
+	}
+}
+
+func TestWrapf_SkipsStackWhenChainHasOne(t *testing.T) {
+	outer := Wrapf(NewTransportError("x", true), "attempt %d", 3)
+	if outer.Stack != nil {
+		t.Fatal("Wrapf should not capture a second stack")
+	}
+}
+
+func TestWrap_CapturesForForeignError(t *testing.T) {
+	outer := Wrap(fmt.Errorf("plain"), "outer")
+	if len(outer.Stack) == 0 {
+		t.Fatal("Wrap should capture a stack when the chain has none")
+	}
+}
+
+func TestWrapNoStack(t *testing.T) {
+	e := WrapNoStack(fmt.Errorf("plain"), "retry")
+	if e.Stack != nil {
+		t.Fatal("WrapNoStack should never capture a stack")
+	}
+	if e.Error() != "retry: plain" {
+		t.Fatalf("got %q", e.Error())
+	}
+	if WrapNoStack(nil, "x") != nil {
+		t.Fatal("expected nil")
+	}
+}
+
+func TestDeepestStack(t *testing.T) {
+	inner := New("root")
+	mid := Wrap(fmt.Errorf("plain"), "mid")
+	joined := fmt.Errorf("%w / %w", mid, inner)
+
+	got := DeepestStack(Wrap(joined, "outer"))
+	if len(got) == 0 || &got[0] != &inner.Stack[0] {
+		t.Fatal("expected the innermost stack")
+	}
+	if DeepestStack(fmt.Errorf("plain")) != nil {
+		t.Fatal("expected nil for chains without a stack")
+	}
+}
+
+func TestWrap_JSONStackNotDuplicated(t *testing.T) {
+	data, err := MarshalJSON(Wrap(New("root"), "outer"), true)
+	if err != nil {
+		t.Fatalf("MarshalJSON: %v", err)
+	}
+	if n := strings.Count(string(data), `"stack"`); n != 1 {
+		t.Fatalf("expected one stack, got %d in %s", n, data)
+	}
+}
+
+func withStackOptions(t *testing.T, opts StackOptions) {
+	t.Helper()
+	SetStackOptions(opts)
+	t.Cleanup(func() { SetStackOptions(StackOptions{}) })
+}
+
+func TestFrames_Default(t *testing.T) {
+	frames := New("x").Frames()
+	if len(frames) == 0 {
+		t.Fatal("expected frames")
+	}
+	if !strings.HasSuffix(frames[0].Function, "TestFrames_Default") {
+		t.Fatalf("expected caller as first frame, got %s", frames[0].Function)
+	}
+	if frames[0].Line == 0 || !strings.HasSuffix(frames[0].File, "stack_test.go") {
+		t.Fatalf("unexpected first frame %+v", frames[0])
+	}
+}
+
+func TestFrames_FallsBackToDeepest(t *testing.T) {
+	inner := New("root")
+	outer := Wrap(inner, "outer")
+	if len(outer.Frames()) != len(inner.Frames()) {
+		t.Fatal("wrapper should report the inner frames")
+	}
+	var nilErr *Error
+	if nilErr.Frames() != nil {
+		t.Fatal("nil error should have no frames")
+	}
+}
+
+func TestFrames_SkipRuntimeAndDepth(t *testing.T) {
+	withStackOptions(t, StackOptions{SkipRuntime: true, MaxDepth: 1})
+	frames := New("x").Frames()
+	if len(frames) != 1 {
+		t.Fatalf("expected 1 frame, got %d", len(frames))
+	}
+
+	SetStackOptions(StackOptions{SkipRuntime: true})
+	for _, f := range New("x").Frames() {
+		if strings.HasPrefix(f.Function, "runtime.") {
+			t.Fatalf("runtime frame not skipped: %s", f.Function)
+		}
+	}
+}
+
+func TestFrames_ShortPaths(t *testing.T) {
+	withStackOptions(t, StackOptions{ShortPaths: true})
+	f := New("x").Frames()[0]
+	if f.File != "github.com/planx-lab/planx-common/errors/stack_test.go" {
+		t.Fatalf("unexpected short path %q", f.File)
+	}
+	if !strings.Contains(New("x").StackTrace(), "planx-common/errors/stack_test.go:") {
+		t.Fatal("StackTrace should use the short path")
+	}
+}
+
+func TestFrames_TrimPrefixes(t *testing.T) {
+	full := New("x").Frames()[0].File
+	dir := full[:strings.LastIndex(full, "/")]
+	withStackOptions(t, StackOptions{TrimPrefixes: []string{dir}})
+	if got := New("x").Frames()[0].File; got != "stack_test.go" {
+		t.Fatalf("expected trimmed path, got %q", got)
+	}
+}
+
+func TestPackagePath(t *testing.T) {
+	cases := map[string]string{
+		"github.com/planx-lab/planx-common/errors.(*Error).Frames": "github.com/planx-lab/planx-common/errors",
+		"github.com/planx-lab/planx-common/errors.New.func1":       "github.com/planx-lab/planx-common/errors",
+		"runtime.goexit": "runtime",
+		"main.main":      "main",
+		"weird":          "",
+	}
+	for fn, want := range cases {
+		if got := packagePath(fn); got != want {
+			t.Errorf("packagePath(%q) = %q, want %q", fn, got, want)
+		}
+	}
+}
+
+func TestMarshalJSON_AppliesStackOptions(t *testing.T) {
+	withStackOptions(t, StackOptions{MaxDepth: 2})
+	data, err := MarshalJSON(New("x"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var j jsonError
+	if err := json.Unmarshal(data, &j); err != nil {
+		t.Fatal(err)
+	}
+	if len(j.Stack) != 2 {
+		t.Fatalf("expected 2 frames in JSON, got %d", len(j.Stack))
+	}
+}
@@ -0,0 +1,87 @@
+package errors
+
+import (
+	stderrors "errors"
+	"time"
+)
+
+// RateLimitError represents a downstream throttling the caller (retry later).
+type RateLimitError struct {
+	*Base
+	RetryAfter time.Duration // zero if the downstream gave no hint
+}
+
+// NewRateLimitError creates a new rate limit error.
+func NewRateLimitError(message string, retryAfter time.Duration) *RateLimitError {
+	return &RateLimitError{
+		Base:       newWithCode(CodeRateLimited, message),
+		RetryAfter: retryAfter,
+	}
+}
+
+// Unwrap returns the embedded *Error so errors.As can reach it.
+func (e *RateLimitError) Unwrap() error { return unwrapBase(e.Base) }
+
+// WithCode sets the code and returns e.
+func (e *RateLimitError) WithCode(code Code) *RateLimitError { e.Base.WithCode(code); return e }
+
+// WithField attaches a structured field and returns e.
+func (e *RateLimitError) WithField(key string, value interface{}) *RateLimitError {
+	e.Base.WithField(key, value)
+	return e
+}
+
+// WithFields attaches several structured fields and returns e.
+func (e *RateLimitError) WithFields(fields map[string]interface{}) *RateLimitError {
+	e.Base.WithFields(fields)
+	return e
+}
+
+// BackpressureError represents a local queue or window being full, so the
+// producer must slow down (retry later).
+type BackpressureError struct {
+	*Base
+	RetryAfter time.Duration // zero if unknown
+	QueueDepth int           // depth observed when the work was rejected
+}
+
+// NewBackpressureError creates a new backpressure error.
+func NewBackpressureError(message string, queueDepth int, retryAfter time.Duration) *BackpressureError {
+	return &BackpressureError{
+		Base:       newWithCode(CodeBackpressure, message),
+		RetryAfter: retryAfter,
+		QueueDepth: queueDepth,
+	}
+}
+
+// Unwrap returns the embedded *Error so errors.As can reach it.
+func (e *BackpressureError) Unwrap() error { return unwrapBase(e.Base) }
+
+// WithCode sets the code and returns e.
+func (e *BackpressureError) WithCode(code Code) *BackpressureError { e.Base.WithCode(code); return e }
+
+// WithField attaches a structured field and returns e.
+func (e *BackpressureError) WithField(key string, value interface{}) *BackpressureError {
+	e.Base.WithField(key, value)
+	return e
+}
+
+// WithFields attaches several structured fields and returns e.
+func (e *BackpressureError) WithFields(fields map[string]interface{}) *BackpressureError {
+	e.Base.WithFields(fields)
+	return e
+}
+
+// RetryAfter returns the retry delay hinted by a RateLimitError or
+// BackpressureError in err's chain. ok is false if there is none.
+func RetryAfter(err error) (d time.Duration, ok bool) {
+	var rl *RateLimitError
+	if stderrors.As(err, &rl) {
+		return rl.RetryAfter, true
+	}
+	var bp *BackpressureError
+	if stderrors.As(err, &bp) {
+		return bp.RetryAfter, true
+	}
+	return 0, false
+}
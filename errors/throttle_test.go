@@ -0,0 +1,76 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestNewRateLimitError(t *testing.T) {
+	e := NewRateLimitError("429 from sink", 3*time.Second)
+	if e.Message != "429 from sink" || e.RetryAfter != 3*time.Second {
+		t.Fatalf("got %+v", e)
+	}
+	if CodeOf(e) != CodeRateLimited {
+		t.Fatalf("code: got %q", CodeOf(e))
+	}
+	if !IsRetryable(e) {
+		t.Fatal("rate limit errors should be retryable")
+	}
+}
+
+func TestNewBackpressureError(t *testing.T) {
+	e := NewBackpressureError("window full", 256, 0)
+	if e.QueueDepth != 256 {
+		t.Fatalf("depth: got %d", e.QueueDepth)
+	}
+	if CodeOf(e) != CodeBackpressure {
+		t.Fatalf("code: got %q", CodeOf(e))
+	}
+	if !IsRetryable(fmt.Errorf("enqueue: %w", e)) {
+		t.Fatal("backpressure errors should be retryable")
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	if d, ok := RetryAfter(Wrap(NewRateLimitError("x", time.Second), "send")); !ok || d != time.Second {
+		t.Fatalf("got %v, %v", d, ok)
+	}
+	if d, ok := RetryAfter(NewBackpressureError("x", 1, 2*time.Second)); !ok || d != 2*time.Second {
+		t.Fatalf("got %v, %v", d, ok)
+	}
+	if _, ok := RetryAfter(New("x")); ok {
+		t.Fatal("expected no retry hint")
+	}
+}
+
+func TestThrottleErrors_As(t *testing.T) {
+	var rl *RateLimitError
+	if !errors.As(error(NewRateLimitError("x", 0).WithField("tenant_id", "t")), &rl) {
+		t.Fatal("expected *RateLimitError")
+	}
+	var bp *BackpressureError
+	if !errors.As(error(NewBackpressureError("x", 0, 0).WithCode(CodeInternal)), &bp) {
+		t.Fatal("expected *BackpressureError")
+	}
+}
+
+func TestThrottleErrors_JSONRoundTrip(t *testing.T) {
+	data, err := MarshalJSON(NewBackpressureError("full", 42, 250*time.Millisecond), false)
+	if err != nil {
+		t.Fatalf("MarshalJSON: %v", err)
+	}
+	got, _ := UnmarshalJSON(data)
+	var bp *BackpressureError
+	if !errors.As(got, &bp) || bp.QueueDepth != 42 || bp.RetryAfter != 250*time.Millisecond {
+		t.Fatalf("got %#v", got)
+	}
+
+	data, _ = MarshalJSON(NewRateLimitError("slow", time.Second), false)
+	got, _ = UnmarshalJSON(data)
+	var rl *RateLimitError
+	if !errors.As(got, &rl) || rl.RetryAfter != time.Second {
+		t.Fatalf("got %#v", got)
+	}
+}
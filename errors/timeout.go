@@ -0,0 +1,40 @@
+package errors
+
+// NewDeadlineError creates a retryable transport error for an operation that
+// ran out of time. It reports Timeout() == true, so net.Error and os.IsTimeout
+// style checks recognise it.
+func NewDeadlineError(message string) *TransportError {
+	return &TransportError{
+		Base:      newWithCode(CodeTransportTimeout, message),
+		Retryable: true,
+	}
+}
+
+// Timeout reports whether the transport error is a timeout, i.e. carries
+// CodeTransportTimeout. It makes TransportError compatible with net.Error.
+func (e *TransportError) Timeout() bool {
+	return e != nil && e.Base != nil && e.Code == CodeTransportTimeout
+}
+
+// Temporary mirrors the Retryable flag for callers still using the
+// deprecated net.Error Temporary check.
+func (e *TransportError) Temporary() bool {
+	return e != nil && e.Retryable
+}
+
+// IsTimeout reports whether any error in err's chain is a timeout: an error
+// with a Timeout() bool method returning true (net.Error, os.ErrDeadlineExceeded,
+// context.DeadlineExceeded, TransportError) or an *Error coded
+// CodeTransportTimeout.
+func IsTimeout(err error) bool {
+	found := false
+	Walk(err, func(e error) bool {
+		if t, ok := e.(interface{ Timeout() bool }); ok && t.Timeout() {
+			found = true
+		} else if base, ok := e.(*Error); ok && base != nil && base.Code == CodeTransportTimeout {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
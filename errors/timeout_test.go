@@ -0,0 +1,64 @@
+package errors
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"net"
+	"os"
+	"testing"
+)
+
+func TestDeadlineErrorIsNetError(t *testing.T) {
+	err := NewDeadlineError("read timed out")
+
+	var ne net.Error = err
+	if !ne.Timeout() {
+		t.Fatal("expected Timeout() to be true")
+	}
+	if !os.IsTimeout(err) {
+		t.Fatal("expected os.IsTimeout to recognise the deadline error")
+	}
+	if CodeOf(err) != CodeTransportTimeout {
+		t.Fatalf("expected timeout code, got %s", CodeOf(err))
+	}
+	if !IsRetryable(err) {
+		t.Fatal("expected deadline error to be retryable")
+	}
+}
+
+func TestTransportErrorTimeout(t *testing.T) {
+	err := NewTransportError("connection refused", true)
+	if err.Timeout() {
+		t.Fatal("plain transport error should not be a timeout")
+	}
+	if !err.Temporary() {
+		t.Fatal("expected Temporary to mirror Retryable")
+	}
+	if !err.WithCode(CodeTransportTimeout).Timeout() {
+		t.Fatal("expected timeout after setting the timeout code")
+	}
+}
+
+func TestIsTimeout(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"plain", New("boom"), false},
+		{"deadline error", Wrap(NewDeadlineError("slow"), "outer"), true},
+		{"context deadline", fmt.Errorf("call: %w", context.DeadlineExceeded), true},
+		{"os deadline", Wrap(os.ErrDeadlineExceeded, "read"), true},
+		{"coded base", NewWithCode(CodeTransportTimeout, "slow"), true},
+		{"transport over net timeout", &TransportError{Base: Wrap(&net.DNSError{IsTimeout: true}, "dial")}, true},
+		{"joined", stderrors.Join(New("a"), context.DeadlineExceeded), true},
+		{"canceled", context.Canceled, false},
+	}
+	for _, tc := range cases {
+		if got := IsTimeout(tc.err); got != tc.want {
+			t.Errorf("%s: IsTimeout = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package errors
+
+import "time"
+
+// Timing records how much of a deadline budget one stage consumed.
+type Timing struct {
+	Stage   string
+	Elapsed time.Duration
+	Budget  time.Duration
+}
+
+// Consumed returns Elapsed as a fraction of Budget, or 0 without a budget.
+func (t Timing) Consumed() float64 {
+	if t.Budget <= 0 {
+		return 0
+	}
+	return float64(t.Elapsed) / float64(t.Budget)
+}
+
+// WithTiming annotates err with the time stage spent out of budget, so a
+// deadline failure shows which stage ate the timeout. The error message is
+// unchanged; the timings appear in Fields (as "timings") and in gRPC status
+// details via grpcutil.ToStatus. It returns nil if err is nil.
+func WithTiming(err error, stage string, elapsed, budget time.Duration) error {
+	if err == nil {
+		return nil
+	}
+	return &timingError{err: err, timing: Timing{Stage: stage, Elapsed: elapsed, Budget: budget}}
+}
+
+// Timings returns the timings attached to err's chain by WithTiming, in the
+// order they were attached (innermost first).
+func Timings(err error) []Timing {
+	var out []Timing
+	Walk(err, func(e error) bool {
+		if te, ok := e.(*timingError); ok {
+			out = append(out, te.timing)
+		}
+		return true
+	})
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return out
+}
+
+type timingError struct {
+	err    error
+	timing Timing
+}
+
+func (e *timingError) Error() string { return e.err.Error() }
+func (e *timingError) Unwrap() error { return e.err }
+
+// timingFields renders timings for Fields.
+func timingFields(timings []Timing) []map[string]interface{} {
+	out := make([]map[string]interface{}, len(timings))
+	for i, t := range timings {
+		out[i] = map[string]interface{}{
+			"stage":      t.Stage,
+			"elapsed_ms": t.Elapsed.Milliseconds(),
+			"budget_ms":  t.Budget.Milliseconds(),
+		}
+	}
+	return out
+}
@@ -0,0 +1,56 @@
+package errors
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+	"time"
+)
+
+func TestWithTiming(t *testing.T) {
+	if WithTiming(nil, "sink", time.Second, time.Second) != nil {
+		t.Fatal("expected nil for nil error")
+	}
+
+	tr := NewTransportError("write timed out", true)
+	err := WithTiming(tr, "sink", 800*time.Millisecond, time.Second)
+	err = WithTiming(Wrap(err, "flush"), "pipeline", 1200*time.Millisecond, time.Second)
+
+	if err.Error() != "flush: write timed out" {
+		t.Fatalf("message should be unchanged, got %q", err.Error())
+	}
+	var got *TransportError
+	if !stderrors.As(err, &got) || got != tr {
+		t.Fatal("errors.As should see through the timing annotation")
+	}
+
+	timings := Timings(err)
+	if len(timings) != 2 || timings[0].Stage != "sink" || timings[1].Stage != "pipeline" {
+		t.Fatalf("unexpected timings %+v", timings)
+	}
+	if c := timings[0].Consumed(); c != 0.8 {
+		t.Fatalf("expected 0.8 consumed, got %v", c)
+	}
+	if (Timing{Elapsed: time.Second}).Consumed() != 0 {
+		t.Fatal("expected 0 consumed without a budget")
+	}
+}
+
+func TestWithTiming_Fields(t *testing.T) {
+	err := WithTiming(New("x").WithField("tenant_id", "t1"), "source", 30*time.Millisecond, 100*time.Millisecond)
+	fields := Fields(err)
+	if fields["tenant_id"] != "t1" {
+		t.Fatalf("expected error fields to be kept, got %v", fields)
+	}
+	ts, ok := fields["timings"].([]map[string]interface{})
+	if !ok || len(ts) != 1 {
+		t.Fatalf("expected one timing field, got %v", fields["timings"])
+	}
+	if ts[0]["stage"] != "source" || ts[0]["elapsed_ms"] != int64(30) || ts[0]["budget_ms"] != int64(100) {
+		t.Fatalf("unexpected timing field %v", ts[0])
+	}
+
+	if Fields(WithTiming(context.DeadlineExceeded, "sink", 0, 0))["timings"] == nil {
+		t.Fatal("expected timings on a foreign error")
+	}
+}
@@ -0,0 +1,51 @@
+package eventtime
+
+import "time"
+
+// Lateness classifies an event against the current watermark.
+type Lateness int
+
+const (
+	// OnTime events are after the watermark.
+	OnTime Lateness = iota
+	// Late events are at or before the watermark but within the allowed
+	// lateness; windows they belong to should still accept them.
+	Late
+	// Dropped events are later than the allowed lateness and should be
+	// discarded or sent to a side output.
+	Dropped
+)
+
+func (l Lateness) String() string {
+	switch l {
+	case OnTime:
+		return "on_time"
+	case Late:
+		return "late"
+	case Dropped:
+		return "dropped"
+	}
+	return "unknown"
+}
+
+// Classify returns how late eventTime is relative to watermark, given the
+// lateness a processor still accepts. Before the first watermark (the zero
+// time) every event is on time.
+func Classify(eventTime, watermark time.Time, allowedLateness time.Duration) Lateness {
+	switch {
+	case watermark.IsZero() || eventTime.After(watermark):
+		return OnTime
+	case !eventTime.Before(watermark.Add(-allowedLateness)):
+		return Late
+	default:
+		return Dropped
+	}
+}
+
+// Lag returns how far eventTime trails watermark, or zero if it does not.
+func Lag(eventTime, watermark time.Time) time.Duration {
+	if watermark.IsZero() || !watermark.After(eventTime) {
+		return 0
+	}
+	return watermark.Sub(eventTime)
+}
@@ -0,0 +1,48 @@
+package eventtime
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClassify(t *testing.T) {
+	wm := epoch.Add(time.Minute)
+	tests := []struct {
+		name      string
+		eventTime time.Time
+		watermark time.Time
+		want      Lateness
+	}{
+		{"no watermark", epoch, time.Time{}, OnTime},
+		{"after", wm.Add(time.Second), wm, OnTime},
+		{"at watermark", wm, wm, Late},
+		{"within lateness", wm.Add(-10 * time.Second), wm, Late},
+		{"at lateness bound", wm.Add(-30 * time.Second), wm, Late},
+		{"beyond lateness", wm.Add(-31 * time.Second), wm, Dropped},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Classify(tt.eventTime, tt.watermark, 30*time.Second); got != tt.want {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLag(t *testing.T) {
+	if got := Lag(epoch, epoch.Add(time.Second)); got != time.Second {
+		t.Fatalf("got %v", got)
+	}
+	if got := Lag(epoch.Add(time.Second), epoch); got != 0 {
+		t.Fatalf("event ahead of the watermark: got %v", got)
+	}
+	if got := Lag(epoch, time.Time{}); got != 0 {
+		t.Fatalf("no watermark: got %v", got)
+	}
+}
+
+func TestLateness_String(t *testing.T) {
+	if OnTime.String() != "on_time" || Late.String() != "late" || Dropped.String() != "dropped" {
+		t.Fatal("unexpected names")
+	}
+}
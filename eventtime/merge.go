@@ -0,0 +1,135 @@
+package eventtime
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Merger combines per-partition watermarks into one: the minimum over the
+// active partitions, since an operator reading several partitions can only
+// be as far along as the slowest. Idle partitions are left out so a quiet
+// partition does not stall the rest; once every partition is idle the
+// merged watermark is the largest any of them reached.
+//
+// The merged watermark never moves backwards, including when a partition
+// comes back from idle behind it. A Merger is safe for concurrent use.
+type Merger struct {
+	mu         sync.Mutex
+	partitions map[string]*partition
+	current    time.Time
+}
+
+type partition struct {
+	watermark time.Time
+	idle      bool
+}
+
+// NewMerger returns a Merger with no partitions.
+func NewMerger() *Merger {
+	return &Merger{partitions: map[string]*partition{}}
+}
+
+// Update sets the watermark of a partition, adding it if it is new and
+// marking it active, and returns the merged watermark. A watermark behind
+// the partition's previous one is ignored.
+func (m *Merger) Update(name string, watermark time.Time) time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p := m.partition(name)
+	p.idle = false
+	if watermark.After(p.watermark) {
+		p.watermark = watermark
+	}
+	return m.advance()
+}
+
+// MarkIdle excludes a partition from the merge until its next Update and
+// returns the merged watermark.
+func (m *Merger) MarkIdle(name string) time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.partition(name).idle = true
+	return m.advance()
+}
+
+// Observe updates a partition from its Generator: the generator's
+// watermark, then MarkIdle if the generator is idle.
+func (m *Merger) Observe(name string, g *Generator) time.Time {
+	wm := g.Current()
+	idle := g.Idle()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p := m.partition(name)
+	p.idle = idle
+	if wm.After(p.watermark) {
+		p.watermark = wm
+	}
+	return m.advance()
+}
+
+// Remove drops a partition, for example after a rebalance, and returns the
+// merged watermark.
+func (m *Merger) Remove(name string) time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.partitions, name)
+	return m.advance()
+}
+
+// Current returns the merged watermark, or the zero time if none has been
+// established yet.
+func (m *Merger) Current() time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.current
+}
+
+// Partitions returns the known partition names, sorted.
+func (m *Merger) Partitions() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	names := make([]string, 0, len(m.partitions))
+	for name := range m.partitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (m *Merger) partition(name string) *partition {
+	p, ok := m.partitions[name]
+	if !ok {
+		p = &partition{}
+		m.partitions[name] = p
+	}
+	return p
+}
+
+// advance recomputes the merged watermark, keeping it monotonic.
+func (m *Merger) advance() time.Time {
+	var (
+		low, high time.Time
+		active    bool
+	)
+	for _, p := range m.partitions {
+		if p.watermark.After(high) {
+			high = p.watermark
+		}
+		if p.idle {
+			continue
+		}
+		if !active || p.watermark.Before(low) {
+			low = p.watermark
+		}
+		active = true
+	}
+	next := low
+	if !active {
+		next = high
+	}
+	if next.After(m.current) {
+		m.current = next
+	}
+	return m.current
+}
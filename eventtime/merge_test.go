@@ -0,0 +1,84 @@
+package eventtime
+
+import (
+	"testing"
+	"time"
+
+	"github.com/planx-lab/planx-common/clock"
+)
+
+func at(s int) time.Time { return epoch.Add(time.Duration(s) * time.Second) }
+
+func TestMerger_Minimum(t *testing.T) {
+	m := NewMerger()
+	if got := m.Update("p0", at(10)); !got.Equal(at(10)) {
+		t.Fatalf("single partition: got %v", got)
+	}
+	// A new partition without a watermark holds the merge where it is.
+	m.Update("p1", time.Time{})
+	if got := m.Update("p0", at(20)); !got.Equal(at(10)) {
+		t.Fatalf("with empty partition: got %v", got)
+	}
+	if got := m.Update("p1", at(15)); !got.Equal(at(15)) {
+		t.Fatalf("min of 20 and 15: got %v", got)
+	}
+	// Partition watermarks do not go backwards.
+	if got := m.Update("p1", at(5)); !got.Equal(at(15)) {
+		t.Fatalf("regressed partition: got %v", got)
+	}
+}
+
+func TestMerger_Idle(t *testing.T) {
+	m := NewMerger()
+	m.Update("p0", at(10))
+	m.Update("p1", at(30))
+	if got := m.MarkIdle("p0"); !got.Equal(at(30)) {
+		t.Fatalf("p0 idle: got %v", got)
+	}
+	// p0 resumes behind the merged watermark, which stays put.
+	if got := m.Update("p0", at(12)); !got.Equal(at(30)) {
+		t.Fatalf("p0 resumed: got %v", got)
+	}
+	m.Update("p0", at(40))
+	if got := m.Update("p1", at(35)); !got.Equal(at(35)) {
+		t.Fatalf("both active: got %v", got)
+	}
+	m.MarkIdle("p0")
+	if got := m.MarkIdle("p1"); !got.Equal(at(40)) {
+		t.Fatalf("all idle: got %v", got)
+	}
+}
+
+func TestMerger_Remove(t *testing.T) {
+	m := NewMerger()
+	m.Update("p0", at(10))
+	m.Update("p1", at(20))
+	if got := m.Remove("p0"); !got.Equal(at(20)) {
+		t.Fatalf("got %v", got)
+	}
+	if names := m.Partitions(); len(names) != 1 || names[0] != "p1" {
+		t.Fatalf("partitions: got %v", names)
+	}
+}
+
+func TestMerger_Observe(t *testing.T) {
+	clk := clock.NewFake(epoch)
+	fast := NewGenerator(0, WithIdleTimeout(time.Minute), WithClock(clk))
+	slow := NewGenerator(0, WithIdleTimeout(time.Minute), WithClock(clk))
+	m := NewMerger()
+
+	fast.Observe(at(50))
+	slow.Observe(at(10))
+	m.Observe("slow", slow)
+	if got := m.Observe("fast", fast); !got.Equal(at(10)) {
+		t.Fatalf("got %v", got)
+	}
+
+	clk.Add(30 * time.Second)
+	fast.Observe(at(80))
+	clk.Add(30 * time.Second)
+	m.Observe("fast", fast)
+	if got := m.Observe("slow", slow); !got.Equal(at(80)) {
+		t.Fatalf("slow partition idle: got %v", got)
+	}
+}
@@ -0,0 +1,116 @@
+// Package eventtime provides watermark generation, lateness classification
+// and per-partition watermark merging for event-time processing.
+// Engine-side utilities only — must not be imported by SDK or plugins.
+//
+// A watermark W asserts that no more events with an event time at or before
+// W are expected. The zero time.Time means no watermark has been emitted.
+package eventtime
+
+import (
+	"sync"
+	"time"
+
+	"github.com/planx-lab/planx-common/batch"
+	"github.com/planx-lab/planx-common/clock"
+)
+
+// Generator derives a watermark from observed event times, allowing events
+// to arrive up to a fixed delay out of order: the watermark trails the
+// largest event time seen by that delay. It never moves backwards.
+//
+// With an idle timeout, a source that has not produced an event for that
+// long reports Idle, so a Merger can stop holding back the other partitions
+// for it. A Generator is safe for concurrent use.
+type Generator struct {
+	maxDelay time.Duration
+	idle     time.Duration
+	clock    clock.Clock
+
+	mu       sync.Mutex
+	maxEvent time.Time
+	current  time.Time
+	lastSeen time.Time
+}
+
+// GeneratorOption configures a Generator.
+type GeneratorOption func(*Generator)
+
+// WithIdleTimeout marks the source idle after d without events. Zero, the
+// default, never marks it idle.
+func WithIdleTimeout(d time.Duration) GeneratorOption {
+	return func(g *Generator) { g.idle = d }
+}
+
+// WithClock sets the clock idleness is measured on. The default is
+// clock.Real.
+func WithClock(c clock.Clock) GeneratorOption {
+	return func(g *Generator) { g.clock = c }
+}
+
+// NewGenerator returns a Generator tolerating events up to maxDelay out of
+// order. A negative maxDelay is treated as zero.
+func NewGenerator(maxDelay time.Duration, opts ...GeneratorOption) *Generator {
+	g := &Generator{maxDelay: max(maxDelay, 0), clock: clock.Real}
+	for _, opt := range opts {
+		opt(g)
+	}
+	g.lastSeen = g.clock.Now()
+	return g
+}
+
+// Observe records an event time and returns the resulting watermark.
+// Zero event times are ignored but still count as activity.
+func (g *Generator) Observe(eventTime time.Time) time.Time {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.lastSeen = g.clock.Now()
+	g.observe(eventTime)
+	return g.current
+}
+
+// ObserveBatch records the event times of b's records and returns the
+// resulting watermark.
+func (g *Generator) ObserveBatch(b *batch.Batch) time.Time {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.lastSeen = g.clock.Now()
+	for _, r := range b.Records {
+		g.observe(r.EventTime)
+	}
+	return g.current
+}
+
+func (g *Generator) observe(eventTime time.Time) {
+	if eventTime.IsZero() || !eventTime.After(g.maxEvent) {
+		return
+	}
+	g.maxEvent = eventTime
+	if wm := eventTime.Add(-g.maxDelay); wm.After(g.current) {
+		g.current = wm
+	}
+}
+
+// Current returns the watermark, or the zero time before the first event.
+func (g *Generator) Current() time.Time {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.current
+}
+
+// MaxEventTime returns the largest event time observed.
+func (g *Generator) MaxEventTime() time.Time {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.maxEvent
+}
+
+// Idle reports whether the idle timeout has passed since the last event.
+// It is always false without WithIdleTimeout.
+func (g *Generator) Idle() bool {
+	if g.idle <= 0 {
+		return false
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.clock.Since(g.lastSeen) >= g.idle
+}
@@ -0,0 +1,71 @@
+package eventtime
+
+import (
+	"testing"
+	"time"
+
+	"github.com/planx-lab/planx-common/batch"
+	"github.com/planx-lab/planx-common/clock"
+)
+
+var epoch = time.Unix(1700000000, 0)
+
+func TestGenerator_BoundedOutOfOrderness(t *testing.T) {
+	g := NewGenerator(5 * time.Second)
+	if !g.Current().IsZero() {
+		t.Fatalf("initial watermark: got %v", g.Current())
+	}
+	if got := g.Observe(epoch.Add(10 * time.Second)); !got.Equal(epoch.Add(5 * time.Second)) {
+		t.Fatalf("after first event: got %v", got)
+	}
+	// An out-of-order event does not move the watermark back.
+	if got := g.Observe(epoch.Add(2 * time.Second)); !got.Equal(epoch.Add(5 * time.Second)) {
+		t.Fatalf("after late event: got %v", got)
+	}
+	g.Observe(epoch.Add(20 * time.Second))
+	if got := g.Current(); !got.Equal(epoch.Add(15 * time.Second)) {
+		t.Fatalf("after advance: got %v", got)
+	}
+	if got := g.MaxEventTime(); !got.Equal(epoch.Add(20 * time.Second)) {
+		t.Fatalf("max event time: got %v", got)
+	}
+	g.Observe(time.Time{})
+	if got := g.Current(); !got.Equal(epoch.Add(15 * time.Second)) {
+		t.Fatalf("zero event time moved the watermark to %v", got)
+	}
+}
+
+func TestGenerator_ObserveBatch(t *testing.T) {
+	g := NewGenerator(time.Second)
+	b := batch.New("t1", "s1", []batch.Record{
+		{EventTime: epoch.Add(3 * time.Second)},
+		{EventTime: epoch.Add(7 * time.Second)},
+		{},
+		{EventTime: epoch.Add(5 * time.Second)},
+	})
+	if got := g.ObserveBatch(b); !got.Equal(epoch.Add(6 * time.Second)) {
+		t.Fatalf("got %v", got)
+	}
+}
+
+func TestGenerator_Idle(t *testing.T) {
+	clk := clock.NewFake(epoch)
+	g := NewGenerator(0, WithIdleTimeout(time.Minute), WithClock(clk))
+	if g.Idle() {
+		t.Fatal("new generator idle")
+	}
+	clk.Add(59 * time.Second)
+	g.Observe(epoch)
+	clk.Add(59 * time.Second)
+	if g.Idle() {
+		t.Fatal("idle although an event arrived within the timeout")
+	}
+	clk.Add(time.Second)
+	if !g.Idle() {
+		t.Fatal("not idle after the timeout")
+	}
+
+	if NewGenerator(0, WithClock(clk)).Idle() {
+		t.Fatal("idle without an idle timeout")
+	}
+}
@@ -0,0 +1,208 @@
+// Package featureflag provides feature flags for rolling out risky engine
+// changes gradually: static flags from config, a Provider interface for
+// dynamic backends, per-tenant targeting and evaluation metrics.
+// Engine-side utilities only — must not be imported by SDK or plugins.
+//
+// Flags are read with typed accessors that take the value to use when the
+// flag is unset, of the wrong type or its provider fails, so a missing
+// backend never changes behavior:
+//
+//	featureflag.SetDefault(featureflag.New(featureflag.NewStatic(cfg.Flags)))
+//	...
+//	ctx = ctxutil.WithTenant(ctx, tenant)
+//	if featureflag.Bool(ctx, "engine.new_router", false) { ... }
+//
+// Flags target the tenant set with ctxutil.WithTenant. Every evaluation is
+// counted in planx.featureflag.evaluations (see
+// telemetry.RecordFlagEvaluation).
+package featureflag
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync/atomic"
+	"time"
+
+	"github.com/planx-lab/planx-common/ctxutil"
+	"github.com/planx-lab/planx-common/logger"
+	"github.com/planx-lab/planx-common/telemetry"
+)
+
+// Provider is a source of flag values, such as Static or a client for a
+// dynamic flag service.
+type Provider interface {
+	// Value returns the value of flag for tenant, which is empty when the
+	// context carries none. ok is false if the provider does not set the
+	// flag for tenant.
+	Value(ctx context.Context, flag, tenant string) (value interface{}, ok bool, err error)
+}
+
+// ProviderFunc adapts a function to a Provider.
+type ProviderFunc func(ctx context.Context, flag, tenant string) (interface{}, bool, error)
+
+// Value calls f.
+func (f ProviderFunc) Value(ctx context.Context, flag, tenant string) (interface{}, bool, error) {
+	return f(ctx, flag, tenant)
+}
+
+// Evaluation results recorded in planx.featureflag.evaluations.
+const (
+	ResultProvider     = "provider"
+	ResultDefault      = "default"
+	ResultError        = "error"
+	ResultTypeMismatch = "type_mismatch"
+)
+
+// Flags evaluates flags against a list of providers, the first that sets
+// a flag winning. The zero value has no providers. It is safe for
+// concurrent use.
+type Flags struct {
+	providers []Provider
+}
+
+// New returns flags backed by providers, in order of precedence.
+func New(providers ...Provider) *Flags {
+	return &Flags{providers: providers}
+}
+
+// Bool returns the bool value of flag, or def.
+func (f *Flags) Bool(ctx context.Context, flag string, def bool) bool {
+	return evaluate(ctx, f, flag, def, func(v interface{}) (bool, bool) {
+		b, ok := v.(bool)
+		return b, ok
+	})
+}
+
+// String returns the string value of flag, or def.
+func (f *Flags) String(ctx context.Context, flag, def string) string {
+	return evaluate(ctx, f, flag, def, func(v interface{}) (string, bool) {
+		s, ok := v.(string)
+		return s, ok
+	})
+}
+
+// Int returns the integer value of flag, or def. Floats with no
+// fractional part, as JSON decodes numbers, are accepted.
+func (f *Flags) Int(ctx context.Context, flag string, def int) int {
+	return evaluate(ctx, f, flag, def, asInt)
+}
+
+// Float returns the numeric value of flag, or def.
+func (f *Flags) Float(ctx context.Context, flag string, def float64) float64 {
+	return evaluate(ctx, f, flag, def, asFloat)
+}
+
+// Duration returns the value of flag, a duration string such as "250ms",
+// or def.
+func (f *Flags) Duration(ctx context.Context, flag string, def time.Duration) time.Duration {
+	return evaluate(ctx, f, flag, def, func(v interface{}) (time.Duration, bool) {
+		switch v := v.(type) {
+		case time.Duration:
+			return v, true
+		case string:
+			d, err := time.ParseDuration(v)
+			return d, err == nil
+		}
+		return 0, false
+	})
+}
+
+// lookup returns the first value a provider sets for flag.
+func (f *Flags) lookup(ctx context.Context, flag string) (interface{}, bool, error) {
+	if f == nil {
+		return nil, false, nil
+	}
+	tenant, _ := ctxutil.TenantFrom(ctx)
+	for _, p := range f.providers {
+		v, ok, err := p.Value(ctx, flag, tenant.String())
+		if err != nil {
+			return nil, false, err
+		}
+		if ok {
+			return v, true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+func evaluate[T any](ctx context.Context, f *Flags, flag string, def T, convert func(interface{}) (T, bool)) T {
+	v, ok, err := f.lookup(ctx, flag)
+	switch {
+	case err != nil:
+		logger.WarnCtx(ctx).Err(err).Str("flag", flag).Msg("featureflag: provider failed, using default")
+		telemetry.RecordFlagEvaluation(ctx, flag, ResultError)
+		return def
+	case !ok:
+		telemetry.RecordFlagEvaluation(ctx, flag, ResultDefault)
+		return def
+	}
+	out, ok := convert(v)
+	if !ok {
+		logger.WarnCtx(ctx).Str("flag", flag).Str("value", fmt.Sprint(v)).
+			Str("want", fmt.Sprintf("%T", def)).Msg("featureflag: value has wrong type, using default")
+		telemetry.RecordFlagEvaluation(ctx, flag, ResultTypeMismatch)
+		return def
+	}
+	telemetry.RecordFlagEvaluation(ctx, flag, ResultProvider)
+	return out
+}
+
+func asFloat(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+func asInt(v interface{}) (int, bool) {
+	switch v := v.(type) {
+	case int:
+		return v, true
+	case int64:
+		return int(v), true
+	case uint64:
+		return int(v), true
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) <= 1<<53 {
+			return int(v), true
+		}
+	}
+	return 0, false
+}
+
+var defaultFlags atomic.Pointer[Flags]
+
+// SetDefault sets the flags used by the package-level accessors. Until it
+// is called they return their defaults.
+func SetDefault(f *Flags) { defaultFlags.Store(f) }
+
+// Default returns the flags set with SetDefault, or nil.
+func Default() *Flags { return defaultFlags.Load() }
+
+// Bool returns the bool value of flag from the default flags, or def.
+func Bool(ctx context.Context, flag string, def bool) bool { return Default().Bool(ctx, flag, def) }
+
+// String returns the string value of flag from the default flags, or def.
+func String(ctx context.Context, flag, def string) string { return Default().String(ctx, flag, def) }
+
+// Int returns the integer value of flag from the default flags, or def.
+func Int(ctx context.Context, flag string, def int) int { return Default().Int(ctx, flag, def) }
+
+// Float returns the numeric value of flag from the default flags, or def.
+func Float(ctx context.Context, flag string, def float64) float64 {
+	return Default().Float(ctx, flag, def)
+}
+
+// Duration returns the duration value of flag from the default flags, or
+// def.
+func Duration(ctx context.Context, flag string, def time.Duration) time.Duration {
+	return Default().Duration(ctx, flag, def)
+}
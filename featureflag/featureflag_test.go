@@ -0,0 +1,122 @@
+package featureflag
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/planx-lab/planx-common/ctxutil"
+	"github.com/planx-lab/planx-common/logger/logtest"
+)
+
+func mapProvider(values map[string]interface{}) Provider {
+	return ProviderFunc(func(_ context.Context, flag, _ string) (interface{}, bool, error) {
+		v, ok := values[flag]
+		return v, ok, nil
+	})
+}
+
+func TestFlags_TypedAccessors(t *testing.T) {
+	f := New(mapProvider(map[string]interface{}{
+		"b":     true,
+		"s":     "fast",
+		"i":     7,
+		"ijson": float64(8),
+		"fl":    0.5,
+		"d":     "250ms",
+	}))
+	ctx := context.Background()
+	if !f.Bool(ctx, "b", false) {
+		t.Fatalf("Bool = false")
+	}
+	if got := f.String(ctx, "s", "slow"); got != "fast" {
+		t.Fatalf("String = %q", got)
+	}
+	if got := f.Int(ctx, "i", 0); got != 7 {
+		t.Fatalf("Int = %d", got)
+	}
+	if got := f.Int(ctx, "ijson", 0); got != 8 {
+		t.Fatalf("Int(float64) = %d", got)
+	}
+	if got := f.Float(ctx, "i", 0); got != 7 {
+		t.Fatalf("Float(int) = %v", got)
+	}
+	if got := f.Float(ctx, "fl", 0); got != 0.5 {
+		t.Fatalf("Float = %v", got)
+	}
+	if got := f.Duration(ctx, "d", 0); got != 250*time.Millisecond {
+		t.Fatalf("Duration = %v", got)
+	}
+	if got := f.Int(ctx, "missing", 3); got != 3 {
+		t.Fatalf("Int(missing) = %d", got)
+	}
+}
+
+func TestFlags_TypeMismatch(t *testing.T) {
+	rec := logtest.Capture(t)
+	f := New(mapProvider(map[string]interface{}{"b": "yes", "i": 1.5}))
+	if !f.Bool(context.Background(), "b", true) {
+		t.Fatalf("Bool with string value did not return default")
+	}
+	if got := f.Int(context.Background(), "i", 2); got != 2 {
+		t.Fatalf("Int with fractional value = %d", got)
+	}
+	rec.AssertLogged(zerolog.WarnLevel, "wrong type")
+}
+
+func TestFlags_ProviderOrderAndErrors(t *testing.T) {
+	rec := logtest.Capture(t)
+	first := mapProvider(map[string]interface{}{"a": 1})
+	second := mapProvider(map[string]interface{}{"a": 2, "b": 3})
+	f := New(first, second)
+	ctx := context.Background()
+	if got := f.Int(ctx, "a", 0); got != 1 {
+		t.Fatalf("a = %d, want first provider's value", got)
+	}
+	if got := f.Int(ctx, "b", 0); got != 3 {
+		t.Fatalf("b = %d, want fallback to second provider", got)
+	}
+
+	failing := ProviderFunc(func(context.Context, string, string) (interface{}, bool, error) {
+		return nil, false, stderrors.New("backend down")
+	})
+	if got := New(failing, second).Int(ctx, "b", 9); got != 9 {
+		t.Fatalf("b = %d, want default on provider error", got)
+	}
+	rec.AssertLogged(zerolog.WarnLevel, "provider failed")
+}
+
+func TestFlags_Tenant(t *testing.T) {
+	var seen string
+	f := New(ProviderFunc(func(_ context.Context, _, tenant string) (interface{}, bool, error) {
+		seen = tenant
+		return nil, false, nil
+	}))
+	f.Bool(ctxutil.WithTenant(context.Background(), "acme"), "x", false)
+	if seen != "acme" {
+		t.Fatalf("provider saw tenant %q", seen)
+	}
+	f.Bool(context.Background(), "x", false)
+	if seen != "" {
+		t.Fatalf("provider saw tenant %q without one in the context", seen)
+	}
+}
+
+func TestDefault(t *testing.T) {
+	t.Cleanup(func() { SetDefault(nil) })
+	ctx := context.Background()
+	SetDefault(nil)
+	if Bool(ctx, "engine.new_router", false) {
+		t.Fatalf("Bool without default flags = true")
+	}
+	SetDefault(New(mapProvider(map[string]interface{}{"engine.new_router": true, "n": 4})))
+	if !Bool(ctx, "engine.new_router", false) {
+		t.Fatalf("Bool = false")
+	}
+	if String(ctx, "s", "x") != "x" || Int(ctx, "n", 0) != 4 || Float(ctx, "n", 0) != 4 || Duration(ctx, "d", time.Second) != time.Second {
+		t.Fatalf("package accessors did not use default flags")
+	}
+}
@@ -0,0 +1,94 @@
+package featureflag
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"sync/atomic"
+)
+
+// Config configures static flags, keyed by flag name:
+//
+//	flags:
+//	  engine.new_router:
+//	    value: false
+//	    rollout: 10      # percent of tenants that get true
+//	    tenants:
+//	      acme: true     # always on for acme
+//	  engine.batch_size:
+//	    value: 500
+type Config struct {
+	Flags map[string]Flag `yaml:"flags" json:"flags"`
+}
+
+// Flag configures one static flag. A tenant's entry in Tenants wins; else,
+// for bool flags, tenants in the Rollout percentage get true; else the flag
+// is Value. A flag with no Value and no match is unset, and the accessors
+// return their defaults.
+type Flag struct {
+	Value   interface{}            `yaml:"value" json:"value"`
+	Tenants map[string]interface{} `yaml:"tenants" json:"tenants"`
+	Rollout float64                `yaml:"rollout" json:"rollout" validate:"min=0,max=100"`
+}
+
+// Validate checks that Rollout is only set on bool flags.
+func (f Flag) Validate() error {
+	if f.Rollout == 0 {
+		return nil
+	}
+	if _, ok := f.Value.(bool); !ok && f.Value != nil {
+		return errors.New("rollout is only valid for bool flags")
+	}
+	return nil
+}
+
+// Static is a Provider of flags from a Config. Update replaces the flags,
+// for use from a config.Reloadable hook.
+type Static struct {
+	cfg atomic.Pointer[Config]
+}
+
+// NewStatic returns a provider of the flags in cfg.
+func NewStatic(cfg Config) *Static {
+	s := &Static{}
+	s.Update(cfg)
+	return s
+}
+
+// Update replaces the flags with those in cfg.
+func (s *Static) Update(cfg Config) { s.cfg.Store(&cfg) }
+
+// Value returns the value of flag for tenant.
+func (s *Static) Value(_ context.Context, flag, tenant string) (interface{}, bool, error) {
+	f, ok := s.cfg.Load().Flags[flag]
+	if !ok {
+		return nil, false, nil
+	}
+	if tenant != "" {
+		if v, ok := f.Tenants[tenant]; ok {
+			return v, true, nil
+		}
+		if f.Rollout > 0 && InRollout(flag, tenant, f.Rollout) {
+			return true, true, nil
+		}
+	}
+	if f.Value == nil {
+		if f.Rollout > 0 {
+			return false, true, nil
+		}
+		return nil, false, nil
+	}
+	return f.Value, true, nil
+}
+
+// InRollout reports whether tenant is among the percent of tenants a
+// rollout of flag reaches. Buckets are a hash of flag and tenant, so a
+// tenant stays in as percent grows and different flags reach different
+// tenants first.
+func InRollout(flag, tenant string, percent float64) bool {
+	h := fnv.New32a()
+	h.Write([]byte(flag))
+	h.Write([]byte{0})
+	h.Write([]byte(tenant))
+	return float64(h.Sum32()%10000) < percent*100
+}
@@ -0,0 +1,102 @@
+package featureflag
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/planx-lab/planx-common/config"
+	"github.com/planx-lab/planx-common/ctxutil"
+)
+
+func TestStatic_Targeting(t *testing.T) {
+	var cfg Config
+	err := config.ParseYAML([]byte(`
+flags:
+  engine.new_router:
+    value: false
+    tenants:
+      acme: true
+  engine.batch_size:
+    value: 500
+    tenants:
+      big: 5000
+`), &cfg)
+	if err != nil {
+		t.Fatalf("ParseYAML: %v", err)
+	}
+	f := New(NewStatic(cfg))
+	ctx := context.Background()
+	acme := ctxutil.WithTenant(ctx, "acme")
+	if f.Bool(ctx, "engine.new_router", true) {
+		t.Fatalf("new_router without tenant = true")
+	}
+	if !f.Bool(acme, "engine.new_router", false) {
+		t.Fatalf("new_router for acme = false")
+	}
+	if got := f.Int(acme, "engine.batch_size", 0); got != 500 {
+		t.Fatalf("batch_size for acme = %d", got)
+	}
+	if got := f.Int(ctxutil.WithTenant(ctx, "big"), "engine.batch_size", 0); got != 5000 {
+		t.Fatalf("batch_size for big = %d", got)
+	}
+}
+
+func TestStatic_Rollout(t *testing.T) {
+	s := NewStatic(Config{Flags: map[string]Flag{"r": {Rollout: 30}}})
+	on := 0
+	for i := 0; i < 1000; i++ {
+		v, ok, err := s.Value(context.Background(), "r", fmt.Sprintf("tenant-%d", i))
+		if err != nil || !ok {
+			t.Fatalf("Value = %v, %v, %v", v, ok, err)
+		}
+		if v.(bool) {
+			on++
+		}
+	}
+	if on < 250 || on > 350 {
+		t.Fatalf("%d of 1000 tenants in a 30%% rollout", on)
+	}
+	if v, _, _ := s.Value(context.Background(), "r", ""); v != false {
+		t.Fatalf("rollout without tenant = %v", v)
+	}
+	if _, ok, _ := s.Value(context.Background(), "unset", "acme"); ok {
+		t.Fatalf("unknown flag reported as set")
+	}
+}
+
+func TestInRollout_Monotonic(t *testing.T) {
+	for i := 0; i < 200; i++ {
+		tenant := fmt.Sprintf("tenant-%d", i)
+		if InRollout("f", tenant, 10) && !InRollout("f", tenant, 50) {
+			t.Fatalf("%s in 10%% rollout but not 50%%", tenant)
+		}
+		if InRollout("f", tenant, 0) || !InRollout("f", tenant, 100) {
+			t.Fatalf("%s: 0%% or 100%% rollout wrong", tenant)
+		}
+	}
+}
+
+func TestStatic_Update(t *testing.T) {
+	s := NewStatic(Config{})
+	f := New(s)
+	if f.Bool(context.Background(), "x", false) {
+		t.Fatalf("x = true before update")
+	}
+	s.Update(Config{Flags: map[string]Flag{"x": {Value: true}}})
+	if !f.Bool(context.Background(), "x", false) {
+		t.Fatalf("x = false after update")
+	}
+}
+
+func TestFlag_Validate(t *testing.T) {
+	cfg := Config{Flags: map[string]Flag{
+		"ok":      {Value: false, Rollout: 10},
+		"bad":     {Value: 3, Rollout: 10},
+		"toohigh": {Rollout: 150},
+	}}
+	vs := config.Violations(config.Validate(&cfg))
+	if len(vs) != 2 {
+		t.Fatalf("violations = %v, want 2", vs)
+	}
+}
@@ -0,0 +1,348 @@
+package grpcutil
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	stderrors "errors"
+	"io"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/planx-lab/planx-common/config"
+	"github.com/planx-lab/planx-common/errors"
+	"github.com/planx-lab/planx-common/logger"
+	"github.com/planx-lab/planx-common/metrics"
+	"github.com/planx-lab/planx-common/requestid"
+	"github.com/planx-lab/planx-common/retry"
+	"github.com/planx-lab/planx-common/telemetry"
+	"github.com/planx-lab/planx-common/tlsutil"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Client keepalive defaults. Servers built with ServerInterceptors accept
+// pings this often.
+const (
+	DefaultKeepaliveTime    = time.Minute
+	DefaultKeepaliveTimeout = 20 * time.Second
+)
+
+// DialOptions configures Dial. The zero value dials with TLS against the
+// system roots, the keepalive defaults above and retry.PolicyConfig's
+// defaults for retries.
+type DialOptions struct {
+	// Insecure dials without TLS, for local development and tests.
+	Insecure bool `yaml:"insecure" json:"insecure"`
+	// TLS is the client TLS configuration, typically from
+	// tlsutil.LoadClientConfig; nil uses the system roots.
+	TLS *tls.Config `yaml:"-" json:"-"`
+
+	KeepaliveTime    config.Duration `yaml:"keepalive_time" json:"keepalive_time" validate:"omitempty,min=10s"`
+	KeepaliveTimeout config.Duration `yaml:"keepalive_timeout" json:"keepalive_timeout"`
+
+	// Retry becomes the gRPC retry policy of every method. Backoff doubles
+	// per attempt with full jitter whatever Retry.Jitter says. Retryable
+	// codes are translated with the ToStatus mapping; by default only
+	// UNAVAILABLE is retried.
+	Retry retry.PolicyConfig `yaml:"retry" json:"retry"`
+
+	MaxRecvMsgSize config.ByteSize `yaml:"max_recv_msg_size" json:"max_recv_msg_size"`
+	MaxSendMsgSize config.ByteSize `yaml:"max_send_msg_size" json:"max_send_msg_size"`
+
+	// Block makes Dial wait until the connection is ready or ctx is done.
+	Block bool `yaml:"block" json:"block"`
+
+	// Metrics, if set, records count, latency and in-flight calls per
+	// method and status, under the protocol "grpc-client".
+	Metrics metrics.RequestRecorder `yaml:"-" json:"-"`
+
+	// Extra options are appended after the ones Dial builds.
+	Extra []grpc.DialOption `yaml:"-" json:"-"`
+}
+
+// Dial creates a client connection to target configured the same way for
+// every Planx component: TLS, keepalive, a retry service config, tracing
+// and, with opts.Metrics, RED metrics on every call, and a log line
+// whenever the connection changes state.
+//
+// Like grpc.NewClient, Dial does not connect until the first call unless
+// opts.Block is set, in which case it connects and waits for the
+// connection to become ready, returning a TransportError if ctx ends first.
+func Dial(ctx context.Context, target string, opts DialOptions) (*grpc.ClientConn, error) {
+	dialOpts, err := opts.dialOptions()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := grpc.NewClient(target, dialOpts...)
+	if err != nil {
+		return nil, errors.WrapConfigError(err, "grpcutil: invalid dial target "+strconv.Quote(target))
+	}
+	go logStateChanges(conn, target)
+
+	if opts.Block {
+		if err := waitReady(ctx, conn); err != nil {
+			_ = conn.Close()
+			return nil, errors.FromContext(ctx, errors.WrapTransportError(err, "grpcutil: dial "+target, true))
+		}
+	}
+	return conn, nil
+}
+
+func (o DialOptions) dialOptions() ([]grpc.DialOption, error) {
+	creds := insecure.NewCredentials()
+	if !o.Insecure {
+		cfg := o.TLS
+		if cfg == nil {
+			cfg = &tls.Config{MinVersion: tlsutil.MinVersion}
+		}
+		creds = credentials.NewTLS(cfg)
+	}
+	serviceConfig, err := retryServiceConfig(o.Retry)
+	if err != nil {
+		return nil, err
+	}
+
+	ka := keepalive.ClientParameters{
+		Time:                o.KeepaliveTime.Std(),
+		Timeout:             o.KeepaliveTimeout.Std(),
+		PermitWithoutStream: true,
+	}
+	if ka.Time <= 0 {
+		ka.Time = DefaultKeepaliveTime
+	}
+	if ka.Timeout <= 0 {
+		ka.Timeout = DefaultKeepaliveTimeout
+	}
+
+	out := []grpc.DialOption{
+		grpc.WithTransportCredentials(creds),
+		grpc.WithKeepaliveParams(ka),
+		grpc.WithChainUnaryInterceptor(unaryClientObserve(o.Metrics)),
+		grpc.WithChainStreamInterceptor(streamClientObserve(o.Metrics)),
+	}
+	if serviceConfig != "" {
+		out = append(out, grpc.WithDefaultServiceConfig(serviceConfig))
+	}
+	var callOpts []grpc.CallOption
+	if o.MaxRecvMsgSize > 0 {
+		callOpts = append(callOpts, grpc.MaxCallRecvMsgSize(int(o.MaxRecvMsgSize)))
+	}
+	if o.MaxSendMsgSize > 0 {
+		callOpts = append(callOpts, grpc.MaxCallSendMsgSize(int(o.MaxSendMsgSize)))
+	}
+	if len(callOpts) > 0 {
+		out = append(out, grpc.WithDefaultCallOptions(callOpts...))
+	}
+	return append(out, o.Extra...), nil
+}
+
+// grpcCodes maps Planx codes to the service config spelling of the status
+// codes ToStatus gives them.
+var grpcCodes = map[errors.Code]string{
+	errors.CodeTransportUnavailable: "UNAVAILABLE",
+	errors.CodeTransportTimeout:     "UNAVAILABLE",
+	errors.CodeRateLimited:          "RESOURCE_EXHAUSTED",
+	errors.CodeBackpressure:         "RESOURCE_EXHAUSTED",
+	errors.CodeStreamBroken:         "ABORTED",
+	errors.CodeBatchPartial:         "ABORTED",
+	errors.CodeDeadlineExceeded:     "DEADLINE_EXCEEDED",
+}
+
+// retryServiceConfig renders cfg as a gRPC service config applying to all
+// methods. It returns "" if cfg allows a single attempt, which gRPC cannot
+// express as a retry policy.
+func retryServiceConfig(cfg retry.PolicyConfig) (string, error) {
+	type retryPolicy struct {
+		MaxAttempts          int      `json:"maxAttempts"`
+		InitialBackoff       string   `json:"initialBackoff"`
+		MaxBackoff           string   `json:"maxBackoff"`
+		BackoffMultiplier    float64  `json:"backoffMultiplier"`
+		RetryableStatusCodes []string `json:"retryableStatusCodes"`
+	}
+	type methodConfig struct {
+		Name        []struct{}  `json:"name"`
+		RetryPolicy retryPolicy `json:"retryPolicy"`
+	}
+
+	policy := retryPolicy{
+		MaxAttempts:       cfg.MaxAttempts,
+		InitialBackoff:    protoDuration(cfg.BaseBackoff.Std(), retry.DefaultBaseBackoff),
+		MaxBackoff:        protoDuration(cfg.MaxBackoff.Std(), retry.DefaultMaxBackoff),
+		BackoffMultiplier: 2,
+	}
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = retry.DefaultMaxAttempts
+	}
+	if policy.MaxAttempts == 1 {
+		return "", nil
+	}
+	for _, code := range cfg.RetryableCodes {
+		name, ok := grpcCodes[code]
+		if !ok {
+			return "", errors.NewConfigErrorf("grpcutil: retryable code %q has no gRPC equivalent", code)
+		}
+		if !slices.Contains(policy.RetryableStatusCodes, name) {
+			policy.RetryableStatusCodes = append(policy.RetryableStatusCodes, name)
+		}
+	}
+	if len(policy.RetryableStatusCodes) == 0 {
+		policy.RetryableStatusCodes = []string{"UNAVAILABLE"}
+	}
+
+	data, err := json.Marshal(struct {
+		MethodConfig []methodConfig `json:"methodConfig"`
+	}{[]methodConfig{{Name: []struct{}{{}}, RetryPolicy: policy}}})
+	return string(data), err
+}
+
+// protoDuration formats d, or def if d is not positive, the way service
+// configs spell durations ("0.1s").
+func protoDuration(d, def time.Duration) string {
+	if d <= 0 {
+		d = def
+	}
+	return strconv.FormatFloat(d.Seconds(), 'f', -1, 64) + "s"
+}
+
+func waitReady(ctx context.Context, conn *grpc.ClientConn) error {
+	conn.Connect()
+	for {
+		state := conn.GetState()
+		if state == connectivity.Ready {
+			return nil
+		}
+		if !conn.WaitForStateChange(ctx, state) {
+			return ctx.Err()
+		}
+	}
+}
+
+// logStateChanges logs every connectivity change of conn until it is
+// closed.
+func logStateChanges(conn *grpc.ClientConn, target string) {
+	state := conn.GetState()
+	for state != connectivity.Shutdown {
+		if !conn.WaitForStateChange(context.Background(), state) {
+			return
+		}
+		state = conn.GetState()
+		ev := logger.Debug()
+		switch state {
+		case connectivity.Ready:
+			ev = logger.Info()
+		case connectivity.TransientFailure:
+			ev = logger.Warn()
+		}
+		ev.Str("target", target).Str("state", state.String()).Msg("grpc connection state changed")
+	}
+}
+
+// clientProtocol is the protocol label of calls made by Dial's clients.
+const clientProtocol = "grpc-client"
+
+func unaryClientObserve(rec metrics.RequestRecorder) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx, span := startClientSpan(ctx, method)
+		defer span.End()
+		start := startClientCall(ctx, rec, method)
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		finishClientCall(ctx, rec, span, method, start, err)
+		return err
+	}
+}
+
+func streamClientObserve(rec metrics.RequestRecorder) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		ctx, span := startClientSpan(ctx, method)
+		start := startClientCall(ctx, rec, method)
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			finishClientCall(ctx, rec, span, method, start, err)
+			span.End()
+			return nil, err
+		}
+		return &clientStream{ClientStream: cs, finish: func(err error) {
+			finishClientCall(ctx, rec, span, method, start, err)
+			span.End()
+		}}, nil
+	}
+}
+
+// startClientSpan starts a client span for method and injects its context
+// into the outgoing metadata, along with the request ID in ctx unless the
+// caller set one.
+func startClientSpan(ctx context.Context, method string) (context.Context, trace.Span) {
+	ctx, span := telemetry.Tracer().Start(ctx, method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("rpc.system", "grpc"),
+			attribute.String("rpc.method", method),
+		))
+	md, ok := metadata.FromOutgoingContext(ctx)
+	if ok {
+		md = md.Copy()
+	} else {
+		md = metadata.MD{}
+	}
+	otel.GetTextMapPropagator().Inject(ctx, metadataCarrier(md))
+	if reqID := requestid.FromContext(ctx); reqID != "" && len(md.Get(requestid.MetadataKey)) == 0 {
+		md.Set(requestid.MetadataKey, reqID)
+	}
+	return metadata.NewOutgoingContext(ctx, md), span
+}
+
+// startClientCall counts a call in flight and returns its start time.
+func startClientCall(ctx context.Context, rec metrics.RequestRecorder, method string) time.Time {
+	if rec != nil {
+		rec.RecordRequestInFlight(ctx, clientProtocol, method, 1)
+	}
+	return time.Now()
+}
+
+func finishClientCall(ctx context.Context, rec metrics.RequestRecorder, span trace.Span, method string, start time.Time, err error) {
+	elapsed := time.Since(start)
+	code := status.Code(err)
+	endSpan(span, err)
+	if rec != nil {
+		rec.RecordRequestInFlight(ctx, clientProtocol, method, -1)
+		rec.RecordRequest(ctx, clientProtocol, method, code.String(), float64(elapsed)/float64(time.Millisecond))
+	}
+	logger.DebugCtx(ctx).Err(err).
+		Str("method", method).
+		Str("grpc_code", code.String()).
+		EmbedObject(logger.Dur("duration", elapsed)).
+		Msg("grpc client call")
+}
+
+// clientStream finishes the call's span and metric when the stream ends,
+// which the caller observes as an error from RecvMsg (io.EOF on success).
+type clientStream struct {
+	grpc.ClientStream
+	once   sync.Once
+	finish func(error)
+}
+
+func (s *clientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err != nil {
+		s.once.Do(func() {
+			if stderrors.Is(err, io.EOF) {
+				s.finish(nil)
+			} else {
+				s.finish(err)
+			}
+		})
+	}
+	return err
+}
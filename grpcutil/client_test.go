@@ -0,0 +1,190 @@
+package grpcutil
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/planx-lab/planx-common/config"
+	"github.com/planx-lab/planx-common/errors"
+	"github.com/planx-lab/planx-common/logger/logtest"
+	"github.com/planx-lab/planx-common/requestid"
+	"github.com/planx-lab/planx-common/retry"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// flakyServer fails Check with a retryable transport error until it has
+// been called failures+1 times.
+type flakyServer struct {
+	healthpb.UnimplementedHealthServer
+	failures int32
+	calls    atomic.Int32
+}
+
+func (s *flakyServer) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	if s.calls.Add(1) <= s.failures {
+		return nil, errors.NewTransportError("warming up", true)
+	}
+	return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING}, nil
+}
+
+func TestDial(t *testing.T) {
+	prev := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTextMapPropagator(prev) })
+	rec := logtest.Capture(t)
+
+	srv := &healthServer{traceID: make(chan string, 1), requestID: make(chan string, 1)}
+	lis := serve(t, ServerOptions{}, srv)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, err := Dial(ctx, "passthrough:///bufnet", DialOptions{Insecure: true, Block: true, Extra: []grpc.DialOption{bufDialer(lis)}})
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer conn.Close()
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx = trace.ContextWithSpanContext(ctx, trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID, SpanID: spanID, TraceFlags: trace.FlagsSampled,
+	}))
+	ctx = requestid.NewContext(ctx, "req-3")
+	if _, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if got := <-srv.traceID; got != traceID.String() {
+		t.Fatalf("trace ID: got %q", got)
+	}
+	if got := <-srv.requestID; got != "req-3" {
+		t.Fatalf("request ID: got %q", got)
+	}
+
+	for i := 0; len(rec.Find(zerolog.InfoLevel, "grpc connection state changed")) == 0; i++ {
+		if i == 100 {
+			t.Fatal("ready state not logged")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestDial_Metrics(t *testing.T) {
+	logtest.Capture(t)
+	lis := serve(t, ServerOptions{}, &healthServer{})
+	reqs := newRequests()
+	conn, err := Dial(context.Background(), "passthrough:///bufnet", DialOptions{
+		Insecure: true,
+		Metrics:  reqs,
+		Extra:    []grpc.DialOption{bufDialer(lis)},
+	})
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer conn.Close()
+
+	client := healthpb.NewHealthClient(conn)
+	_, _ = client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "bad"})
+	stream, err := client.Watch(context.Background(), &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+	for err == nil {
+		_, err = stream.Recv()
+	}
+
+	if check := reqs.get("grpc-client /grpc.health.v1.Health/Check"); len(check) != 1 || check[0] != "InvalidArgument" {
+		t.Fatalf("Check statuses = %v", check)
+	}
+	if watch := reqs.get("grpc-client /grpc.health.v1.Health/Watch"); len(watch) != 1 || watch[0] != "OK" {
+		t.Fatalf("Watch statuses = %v", watch)
+	}
+	reqs.mu.Lock()
+	defer reqs.mu.Unlock()
+	if reqs.inFlight != 0 {
+		t.Fatalf("in flight = %d", reqs.inFlight)
+	}
+}
+
+func TestDial_Retry(t *testing.T) {
+	logtest.Capture(t)
+	srv := &flakyServer{failures: 2}
+	lis := serve(t, ServerOptions{}, srv)
+	conn, err := Dial(context.Background(), "passthrough:///bufnet", DialOptions{
+		Insecure: true,
+		Retry:    retry.PolicyConfig{BaseBackoff: config.Duration(time.Millisecond)},
+		Extra:    []grpc.DialOption{bufDialer(lis)},
+	})
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if got := srv.calls.Load(); got != 3 {
+		t.Fatalf("calls: got %d, want 3", got)
+	}
+}
+
+func TestDial_BlockTimeout(t *testing.T) {
+	logtest.Capture(t)
+	refuse := grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+		return nil, stderrors.New("connection refused")
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := Dial(ctx, "passthrough:///nowhere", DialOptions{Insecure: true, Block: true, Extra: []grpc.DialOption{refuse}})
+	var transportErr *errors.TransportError
+	if !stderrors.As(err, &transportErr) || errors.CodeOf(err) != errors.CodeDeadlineExceeded {
+		t.Fatalf("got %v", err)
+	}
+}
+
+func TestRetryServiceConfig(t *testing.T) {
+	got, err := retryServiceConfig(retry.PolicyConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sc struct {
+		MethodConfig []struct {
+			RetryPolicy struct {
+				MaxAttempts          int
+				InitialBackoff       string
+				MaxBackoff           string
+				RetryableStatusCodes []string
+			}
+		}
+	}
+	if err := json.Unmarshal([]byte(got), &sc); err != nil {
+		t.Fatalf("unmarshal %s: %v", got, err)
+	}
+	p := sc.MethodConfig[0].RetryPolicy
+	if p.MaxAttempts != 3 || p.InitialBackoff != "0.1s" || p.MaxBackoff != "10s" || strings.Join(p.RetryableStatusCodes, ",") != "UNAVAILABLE" {
+		t.Fatalf("defaults: got %s", got)
+	}
+
+	got, err = retryServiceConfig(retry.PolicyConfig{RetryableCodes: []errors.Code{
+		errors.CodeRateLimited, errors.CodeBackpressure, errors.CodeTransportUnavailable,
+	}})
+	if err != nil || !strings.Contains(got, `["RESOURCE_EXHAUSTED","UNAVAILABLE"]`) {
+		t.Fatalf("codes: got %s, %v", got, err)
+	}
+
+	if _, err := retryServiceConfig(retry.PolicyConfig{RetryableCodes: []errors.Code{errors.CodeConfigInvalid}}); err == nil {
+		t.Fatal("unmappable code accepted")
+	}
+	if got, err := retryServiceConfig(retry.PolicyConfig{MaxAttempts: 1}); got != "" || err != nil {
+		t.Fatalf("single attempt: got %q, %v", got, err)
+	}
+}
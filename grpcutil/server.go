@@ -0,0 +1,355 @@
+package grpcutil
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/planx-lab/planx-common/config"
+	"github.com/planx-lab/planx-common/errors"
+	"github.com/planx-lab/planx-common/logger"
+	"github.com/planx-lab/planx-common/metrics"
+	"github.com/planx-lab/planx-common/requestid"
+	"github.com/planx-lab/planx-common/telemetry"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// MinKeepaliveTime is the shortest client keepalive interval servers built
+// with ServerInterceptors accept. gRPC's default of five minutes would make
+// them drop clients using Dial's defaults.
+const MinKeepaliveTime = 30 * time.Second
+
+// ServerOptions configures ServerInterceptors. The zero value traces and
+// logs every call but records no metrics and enforces no deadlines or
+// message sizes beyond gRPC's own.
+type ServerOptions struct {
+	// DefaultTimeout bounds unary calls that arrive without a deadline.
+	DefaultTimeout config.Duration `yaml:"default_timeout" json:"default_timeout"`
+	// MaxTimeout caps the deadline a client may ask for on unary calls.
+	// Streams are long-lived and keep the client's deadline.
+	MaxTimeout config.Duration `yaml:"max_timeout" json:"max_timeout" validate:"omitempty,gtefield=DefaultTimeout"`
+
+	// MaxRecvMsgSize is enforced by the transport, before a request is
+	// decoded. MaxSendMsgSize is checked by the interceptors, so an
+	// oversized response is logged and counted like any other failure.
+	MaxRecvMsgSize config.ByteSize `yaml:"max_recv_msg_size" json:"max_recv_msg_size"`
+	MaxSendMsgSize config.ByteSize `yaml:"max_send_msg_size" json:"max_send_msg_size"`
+
+	// QuietMethods lists full method names, such as the health check, that
+	// are traced and measured but only logged when they fail.
+	QuietMethods []string `yaml:"quiet_methods" json:"quiet_methods"`
+
+	// Metrics, if set, records request count, latency and in-flight calls
+	// per method and status with metrics.UnaryServerInterceptor and
+	// metrics.StreamServerInterceptor, e.g. a metrics.NewRequestRecorder.
+	Metrics metrics.RequestRecorder `yaml:"-" json:"-"`
+}
+
+// ServerInterceptors returns the server options every Planx gRPC server is
+// built with:
+//
+//	srv := grpc.NewServer(grpcutil.ServerInterceptors(opts)...)
+//
+// Unary and stream calls pass, outermost first, through tracing (continuing
+// the trace in the incoming metadata), request ID assignment, RED metrics
+// if opts.Metrics is set, logging, deadline enforcement, the response size
+// check and panic recovery.
+// The server also accepts keepalive pings down to MinKeepaliveTime.
+// Errors returned by handlers are converted with ToStatus, so handlers
+// return Planx errors and clients get them back with FromStatus.
+func ServerInterceptors(opts ServerOptions) []grpc.ServerOption {
+	out := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(UnaryServerInterceptors(opts)...),
+		grpc.ChainStreamInterceptor(StreamServerInterceptors(opts)...),
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             MinKeepaliveTime,
+			PermitWithoutStream: true,
+		}),
+	}
+	if opts.MaxRecvMsgSize > 0 {
+		out = append(out, grpc.MaxRecvMsgSize(int(opts.MaxRecvMsgSize)))
+	}
+	return out
+}
+
+// UnaryServerInterceptors returns the unary interceptors of
+// ServerInterceptors, outermost first, for servers that chain their own.
+func UnaryServerInterceptors(opts ServerOptions) []grpc.UnaryServerInterceptor {
+	out := []grpc.UnaryServerInterceptor{unaryTrace, unaryRequestID}
+	if opts.Metrics != nil {
+		// Inside tracing but outside observe, so it sees the status the
+		// client gets.
+		out = append(out, metrics.UnaryServerInterceptor(opts.Metrics))
+	}
+	return append(out,
+		unaryObserve(opts.QuietMethods),
+		unaryDeadline(opts.DefaultTimeout.Std(), opts.MaxTimeout.Std()),
+		unarySendSize(int(opts.MaxSendMsgSize)),
+		unaryRecover,
+	)
+}
+
+// StreamServerInterceptors returns the stream interceptors of
+// ServerInterceptors, outermost first, for servers that chain their own.
+func StreamServerInterceptors(opts ServerOptions) []grpc.StreamServerInterceptor {
+	out := []grpc.StreamServerInterceptor{streamTrace, streamRequestID}
+	if opts.Metrics != nil {
+		out = append(out, metrics.StreamServerInterceptor(opts.Metrics))
+	}
+	return append(out,
+		streamObserve(opts.QuietMethods),
+		streamDeadline,
+		streamSendSize(int(opts.MaxSendMsgSize)),
+		streamRecover,
+	)
+}
+
+func unaryTrace(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, span := startServerSpan(ctx, info.FullMethod)
+	defer span.End()
+	resp, err := handler(ctx, req)
+	endSpan(span, err)
+	return resp, err
+}
+
+func streamTrace(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, span := startServerSpan(ss.Context(), info.FullMethod)
+	defer span.End()
+	err := handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	endSpan(span, err)
+	return err
+}
+
+func startServerSpan(ctx context.Context, method string) (context.Context, trace.Span) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	ctx = otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+	return telemetry.Tracer().Start(ctx, method,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("rpc.system", "grpc"),
+			attribute.String("rpc.method", method),
+		))
+}
+
+// endSpan records the status of a call. err has already been converted to
+// a status by the observe interceptor.
+func endSpan(span trace.Span, err error) {
+	code := status.Code(err)
+	span.SetAttributes(attribute.Int("rpc.grpc.status_code", int(code)))
+	if err != nil {
+		span.SetStatus(otelcodes.Error, err.Error())
+	}
+}
+
+// unaryRequestID and streamRequestID keep the caller's x-request-id, or
+// assign one, store it with requestid.NewContext and return it in the
+// response trailer. Not the header: a response with headers is committed,
+// which would keep the client from retrying the call.
+func unaryRequestID(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, reqID := incomingRequestID(ctx)
+	_ = grpc.SetTrailer(ctx, metadata.Pairs(requestid.MetadataKey, reqID))
+	return handler(ctx, req)
+}
+
+func streamRequestID(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, reqID := incomingRequestID(ss.Context())
+	ss.SetTrailer(metadata.Pairs(requestid.MetadataKey, reqID))
+	return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+}
+
+func incomingRequestID(ctx context.Context) (context.Context, string) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	reqID := requestid.Ensure(metadataCarrier(md).Get(requestid.MetadataKey))
+	return requestid.NewContext(ctx, reqID), reqID
+}
+
+func unaryObserve(quiet []string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		err = toStatusError(err)
+		observeCall(ctx, info.FullMethod, start, err, slices.Contains(quiet, info.FullMethod))
+		return resp, err
+	}
+}
+
+func streamObserve(quiet []string) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := toStatusError(handler(srv, ss))
+		observeCall(ss.Context(), info.FullMethod, start, err, slices.Contains(quiet, info.FullMethod))
+		return err
+	}
+}
+
+func toStatusError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return ToStatus(err).Err()
+}
+
+// observeCall logs a finished call. Successful calls log at debug level,
+// failed ones at warn.
+func observeCall(ctx context.Context, method string, start time.Time, err error, quiet bool) {
+	elapsed := time.Since(start)
+	code := status.Code(err)
+	if err == nil && quiet {
+		return
+	}
+	ev := logger.DebugCtx(ctx)
+	if err != nil {
+		ev = logger.WarnCtx(ctx).Err(err)
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		ev = ev.Str("peer", p.Addr.String())
+	}
+	ev.Str("method", method).
+		Str("grpc_code", code.String()).
+		EmbedObject(logger.Dur("duration", elapsed)).
+		Msg("grpc call")
+}
+
+func unaryDeadline(defaultTimeout, maxTimeout time.Duration) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, errors.FromContext(ctx, err)
+		}
+		timeout := maxTimeout
+		if deadline, ok := ctx.Deadline(); ok {
+			if maxTimeout <= 0 || time.Until(deadline) <= maxTimeout {
+				timeout = 0
+			}
+		} else if defaultTimeout > 0 && (maxTimeout <= 0 || defaultTimeout < maxTimeout) {
+			timeout = defaultTimeout
+		}
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		return handler(ctx, req)
+	}
+}
+
+// streamDeadline only rejects streams whose deadline passed before they
+// were handled.
+func streamDeadline(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := ss.Context().Err(); err != nil {
+		return errors.FromContext(ss.Context(), err)
+	}
+	return handler(srv, ss)
+}
+
+func unarySendSize(limit int) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		if err == nil {
+			err = checkSize(resp, limit)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return resp, nil
+	}
+}
+
+func streamSendSize(limit int) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if limit <= 0 {
+			return handler(srv, ss)
+		}
+		return handler(srv, &sizedStream{ServerStream: ss, limit: limit})
+	}
+}
+
+// checkSize returns a ResourceExhausted status if msg, a protobuf message,
+// encodes to more than limit bytes. A limit of zero or less disables it.
+func checkSize(msg interface{}, limit int) error {
+	m, ok := msg.(proto.Message)
+	if limit <= 0 || !ok {
+		return nil
+	}
+	if n := proto.Size(m); n > limit {
+		return status.Errorf(codes.ResourceExhausted, "grpcutil: response of %d bytes exceeds the %d byte limit", n, limit)
+	}
+	return nil
+}
+
+func unaryRecover(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = recovered(ctx, r, info.FullMethod)
+		}
+	}()
+	return handler(ctx, req)
+}
+
+func streamRecover(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = recovered(ss.Context(), r, info.FullMethod)
+		}
+	}()
+	return handler(srv, ss)
+}
+
+// recovered reports a handler panic with its stack and returns it as an
+// error. It runs in the deferred function, so the stack still holds the
+// panicking frames.
+func recovered(ctx context.Context, r interface{}, method string) error {
+	err := errors.Recover(r).WithField("method", method)
+	errors.Observe(ctx, nil, err, "", method)
+	return err
+}
+
+// serverStream overrides the context of a wrapped stream.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context { return s.ctx }
+
+// sizedStream checks the size of every message sent on a stream.
+type sizedStream struct {
+	grpc.ServerStream
+	limit int
+}
+
+func (s *sizedStream) SendMsg(m interface{}) error {
+	if err := checkSize(m, s.limit); err != nil {
+		return err
+	}
+	return s.ServerStream.SendMsg(m)
+}
+
+// metadataCarrier adapts gRPC metadata to the OpenTelemetry propagators.
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	if v := metadata.MD(c).Get(key); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+func (c metadataCarrier) Set(key, value string) { metadata.MD(c).Set(key, value) }
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
@@ -0,0 +1,347 @@
+package grpcutil
+
+import (
+	"context"
+	stderrors "errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/planx-lab/planx-common/config"
+	"github.com/planx-lab/planx-common/errors"
+	"github.com/planx-lab/planx-common/logger/logtest"
+	"github.com/planx-lab/planx-common/requestid"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// healthServer answers Check by service name: "bad" fails with a config
+// error, "panic" panics and anything else is serving. Watch sends one
+// response, or panics for "panic". If set, traceID and requestID receive
+// the IDs in the context of every Check.
+type healthServer struct {
+	healthpb.UnimplementedHealthServer
+	traceID   chan string
+	requestID chan string
+}
+
+func (s *healthServer) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	if s.traceID != nil {
+		s.traceID <- trace.SpanContextFromContext(ctx).TraceID().String()
+	}
+	if s.requestID != nil {
+		s.requestID <- requestid.FromContext(ctx)
+	}
+	switch req.Service {
+	case "bad":
+		return nil, errors.NewConfigError("unknown service")
+	case "panic":
+		panic("boom")
+	}
+	return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING}, nil
+}
+
+func (s *healthServer) Watch(req *healthpb.HealthCheckRequest, stream healthpb.Health_WatchServer) error {
+	if req.Service == "panic" {
+		panic("boom")
+	}
+	return stream.Send(&healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING})
+}
+
+// serve serves srv with ServerInterceptors(opts) on an in-memory listener.
+func serve(t *testing.T, opts ServerOptions, srv healthpb.HealthServer) *bufconn.Listener {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer(ServerInterceptors(opts)...)
+	healthpb.RegisterHealthServer(s, srv)
+	go func() { _ = s.Serve(lis) }()
+	t.Cleanup(s.Stop)
+	return lis
+}
+
+// bufDialer returns a dial option connecting to lis.
+func bufDialer(lis *bufconn.Listener) grpc.DialOption {
+	return grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) })
+}
+
+// startServer serves a healthServer with ServerInterceptors(opts) and
+// returns a client connected to it.
+func startServer(t *testing.T, opts ServerOptions, srv *healthServer) healthpb.HealthClient {
+	t.Helper()
+	lis := serve(t, opts, srv)
+	conn, err := grpc.NewClient("passthrough:///bufnet", bufDialer(lis),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return healthpb.NewHealthClient(conn)
+}
+
+func TestServerInterceptors_Unary(t *testing.T) {
+	rec := logtest.Capture(t)
+	client := startServer(t, ServerOptions{}, &healthServer{})
+	ctx := context.Background()
+
+	if _, err := client.Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+
+	_, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: "bad"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("config error: got %v", err)
+	}
+	var cfgErr *errors.ConfigError
+	if !stderrors.As(FromStatus(status.Convert(err)), &cfgErr) {
+		t.Fatalf("config error did not round-trip: %v", err)
+	}
+	rec.AssertLogged(zerolog.WarnLevel, "grpc call")
+
+	_, err = client.Check(ctx, &healthpb.HealthCheckRequest{Service: "panic"})
+	if errors.CodeOf(FromStatus(status.Convert(err))) != errors.CodePanic {
+		t.Fatalf("panic: got %v", err)
+	}
+	entries := rec.Find(zerolog.ErrorLevel, "stage failed")
+	if len(entries) != 1 || entries[0].Str("stack") == "" {
+		t.Fatalf("panic not logged with a stack: %v", entries)
+	}
+}
+
+// requests is a metrics.RequestRecorder keeping the status of every request
+// by route.
+type requests struct {
+	mu       sync.Mutex
+	statuses map[string][]string
+	inFlight int
+}
+
+func newRequests() *requests { return &requests{statuses: map[string][]string{}} }
+
+func (r *requests) RecordRequest(_ context.Context, protocol, route, status string, _ float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.statuses[protocol+" "+route] = append(r.statuses[protocol+" "+route], status)
+}
+
+func (r *requests) RecordRequestInFlight(_ context.Context, _, _ string, delta int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.inFlight += delta
+}
+
+func (r *requests) get(key string) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.statuses[key]
+}
+
+func TestServerInterceptors_Metrics(t *testing.T) {
+	logtest.Capture(t)
+	reqs := newRequests()
+	client := startServer(t, ServerOptions{Metrics: reqs}, &healthServer{})
+	ctx := context.Background()
+	_, _ = client.Check(ctx, &healthpb.HealthCheckRequest{})
+	_, _ = client.Check(ctx, &healthpb.HealthCheckRequest{Service: "bad"})
+	stream, err := client.Watch(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+	for err == nil {
+		_, err = stream.Recv()
+	}
+
+	check := reqs.get("grpc /grpc.health.v1.Health/Check")
+	if len(check) != 2 || check[0] != "OK" || check[1] != "InvalidArgument" {
+		t.Fatalf("Check statuses = %v", check)
+	}
+	if watch := reqs.get("grpc /grpc.health.v1.Health/Watch"); len(watch) != 1 || watch[0] != "OK" {
+		t.Fatalf("Watch statuses = %v", watch)
+	}
+	reqs.mu.Lock()
+	defer reqs.mu.Unlock()
+	if reqs.inFlight != 0 {
+		t.Fatalf("in flight = %d", reqs.inFlight)
+	}
+}
+
+func TestServerInterceptors_Stream(t *testing.T) {
+	logtest.Capture(t)
+	client := startServer(t, ServerOptions{}, &healthServer{})
+
+	stream, err := client.Watch(context.Background(), &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+	if _, err := stream.Recv(); err != nil {
+		t.Fatalf("Recv: %v", err)
+	}
+
+	stream, err = client.Watch(context.Background(), &healthpb.HealthCheckRequest{Service: "panic"})
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+	if _, err := stream.Recv(); errors.CodeOf(FromStatus(status.Convert(err))) != errors.CodePanic {
+		t.Fatalf("panic: got %v", err)
+	}
+}
+
+func TestServerInterceptors_SendSize(t *testing.T) {
+	logtest.Capture(t)
+	client := startServer(t, ServerOptions{MaxSendMsgSize: 1}, &healthServer{})
+
+	if _, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{}); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("unary: got %v", err)
+	}
+	stream, err := client.Watch(context.Background(), &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+	if _, err := stream.Recv(); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("stream: got %v", err)
+	}
+}
+
+func TestServerInterceptors_Trace(t *testing.T) {
+	prev := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTextMapPropagator(prev) })
+	logtest.Capture(t)
+
+	srv := &healthServer{traceID: make(chan string, 1)}
+	client := startServer(t, ServerOptions{}, srv)
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	ctx := metadata.AppendToOutgoingContext(context.Background(),
+		"traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	if _, err := client.Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if got := <-srv.traceID; got != traceID {
+		t.Fatalf("trace ID: got %q", got)
+	}
+}
+
+func TestServerInterceptors_RequestID(t *testing.T) {
+	logtest.Capture(t)
+	srv := &healthServer{requestID: make(chan string, 1)}
+	client := startServer(t, ServerOptions{}, srv)
+
+	var trailer metadata.MD
+	ctx := metadata.AppendToOutgoingContext(context.Background(), requestid.MetadataKey, "req-7")
+	if _, err := client.Check(ctx, &healthpb.HealthCheckRequest{}, grpc.Trailer(&trailer)); err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if got := <-srv.requestID; got != "req-7" || trailer.Get(requestid.MetadataKey)[0] != "req-7" {
+		t.Fatalf("client ID not kept: %q, trailer %v", got, trailer)
+	}
+
+	if _, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{}, grpc.Trailer(&trailer)); err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if got := <-srv.requestID; len(got) != 26 || trailer.Get(requestid.MetadataKey)[0] != got {
+		t.Fatalf("generated ID: %q, trailer %v", got, trailer)
+	}
+}
+
+func TestServerInterceptors_QuietMethods(t *testing.T) {
+	prev := zerolog.GlobalLevel()
+	zerolog.SetGlobalLevel(zerolog.DebugLevel)
+	t.Cleanup(func() { zerolog.SetGlobalLevel(prev) })
+	rec := logtest.Capture(t)
+	client := startServer(t, ServerOptions{QuietMethods: []string{healthpb.Health_Check_FullMethodName}}, &healthServer{})
+
+	if _, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	rec.AssertNotLogged(zerolog.DebugLevel, "grpc call")
+	stream, err := client.Watch(context.Background(), &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+	_, _ = stream.Recv()
+	_, _ = stream.Recv() // wait for the stream to finish
+	rec.AssertLogged(zerolog.DebugLevel, "grpc call")
+	_, _ = client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "bad"})
+	rec.AssertLogged(zerolog.WarnLevel, "grpc call")
+}
+
+func TestUnaryDeadline(t *testing.T) {
+	tests := []struct {
+		name        string
+		deadline    time.Duration // 0 for none
+		def, max    time.Duration
+		want        time.Duration // 0 for no deadline
+		wantErrCode codes.Code
+	}{
+		{name: "unbounded", want: 0},
+		{name: "default", def: time.Second, want: time.Second},
+		{name: "max without deadline", max: 2 * time.Second, want: 2 * time.Second},
+		{name: "default under max", def: time.Second, max: 2 * time.Second, want: time.Second},
+		{name: "client deadline kept", deadline: time.Second, def: 5 * time.Second, max: 2 * time.Second, want: time.Second},
+		{name: "client deadline capped", deadline: time.Minute, max: 2 * time.Second, want: 2 * time.Second},
+		{name: "expired", deadline: -time.Second, wantErrCode: codes.DeadlineExceeded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.deadline != 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.deadline)
+				defer cancel()
+			}
+			var got time.Duration
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				if d, ok := ctx.Deadline(); ok {
+					got = time.Until(d)
+				}
+				return nil, nil
+			}
+			_, err := unaryDeadline(tt.def, tt.max)(ctx, nil, &grpc.UnaryServerInfo{}, handler)
+			if tt.wantErrCode != codes.OK {
+				if ToStatus(err).Code() != tt.wantErrCode {
+					t.Fatalf("got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.want == 0 && got != 0 || tt.want != 0 && (got > tt.want || got < tt.want-100*time.Millisecond) {
+				t.Fatalf("deadline in %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckSize(t *testing.T) {
+	msg := wrapperspb.Bytes(make([]byte, 100))
+	if err := checkSize(msg, 0); err != nil {
+		t.Fatalf("disabled: %v", err)
+	}
+	if err := checkSize(msg, 1024); err != nil {
+		t.Fatalf("under limit: %v", err)
+	}
+	if err := checkSize(msg, 64); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("over limit: got %v", err)
+	}
+	if err := checkSize("not a message", 1); err != nil {
+		t.Fatalf("non-proto: %v", err)
+	}
+}
+
+func TestServerOptions_Validate(t *testing.T) {
+	opts := ServerOptions{DefaultTimeout: config.Duration(time.Minute), MaxTimeout: config.Duration(time.Second)}
+	if err := config.Validate(opts); err == nil {
+		t.Fatal("max timeout below the default accepted")
+	}
+}
@@ -0,0 +1,287 @@
+// Package grpcutil provides gRPC helpers for Planx engine components.
+// It owns the mapping between Planx error classes and gRPC status, which
+// the errors package deliberately does not define.
+// Engine-side utilities only — must not be imported by SDK or plugins.
+package grpcutil
+
+import (
+	"context"
+	stderrors "errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/planx-lab/planx-common/errors"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// ErrorDomain is the ErrorInfo domain used for Planx error details.
+const ErrorDomain = "planx"
+
+// ErrorInfo reasons identifying the Planx error class carried by a status.
+const (
+	ReasonConfig       = "CONFIG_ERROR"
+	ReasonStream       = "STREAM_ERROR"
+	ReasonBatch        = "BATCH_ERROR"
+	ReasonTransport    = "TRANSPORT_ERROR"
+	ReasonRateLimit    = "RATE_LIMIT_ERROR"
+	ReasonBackpressure = "BACKPRESSURE_ERROR"
+	ReasonError        = "ERROR"
+)
+
+// ReasonTiming marks ErrorInfo details carrying one errors.WithTiming
+// annotation (stage, elapsed_ms, budget_ms) rather than an error class.
+const ReasonTiming = "DEADLINE_BUDGET"
+
+// ErrorInfo metadata keys.
+const (
+	metaCode          = "code"
+	metaRetryable     = "retryable"
+	metaFailedIndices = "failed_indices"
+	metaQueueDepth    = "queue_depth"
+	metaStage         = "stage"
+	metaElapsedMs     = "elapsed_ms"
+	metaBudgetMs      = "budget_ms"
+)
+
+// ToStatus converts err into a gRPC status. Planx error classes map to:
+//
+//	ConfigError    -> InvalidArgument
+//	StreamError    -> Aborted
+//	BatchError     -> Aborted (failed indices in details)
+//	TransportError -> Unavailable
+//	RateLimitError, BackpressureError -> ResourceExhausted
+//
+// The class, code and class-specific fields travel in an ErrorInfo detail so
+// FromStatus can rebuild the typed error on the other side of the RPC. Retry
+// delays are also sent as a standard RetryInfo detail, and errors.WithTiming
+// annotations as ErrorInfo details with ReasonTiming. Errors reclassified by
+// errors.FromContext map to Canceled or DeadlineExceeded whatever they wrap.
+// Errors that already carry a status are returned as-is; nil maps to OK.
+func ToStatus(err error) *status.Status {
+	if err == nil {
+		return status.New(codes.OK, "")
+	}
+	if st, ok := status.FromError(err); ok {
+		return st
+	}
+
+	c, reason := codes.Unknown, ""
+	meta := map[string]string{}
+	var retryAfter time.Duration
+
+	var (
+		cfgErr       *errors.ConfigError
+		streamErr    *errors.StreamError
+		batchErr     *errors.BatchError
+		transportErr *errors.TransportError
+		rateErr      *errors.RateLimitError
+		pressureErr  *errors.BackpressureError
+		base         *errors.Error
+	)
+	switch {
+	case errors.CodeOf(err) == errors.CodeCanceled:
+		c = codes.Canceled
+	case errors.CodeOf(err) == errors.CodeDeadlineExceeded:
+		c = codes.DeadlineExceeded
+	case stderrors.As(err, &cfgErr):
+		c, reason = codes.InvalidArgument, ReasonConfig
+	case stderrors.As(err, &streamErr):
+		c, reason = codes.Aborted, ReasonStream
+	case stderrors.As(err, &batchErr):
+		c, reason = codes.Aborted, ReasonBatch
+		meta[metaFailedIndices] = formatIndices(batchErr.FailedIndices)
+	case stderrors.As(err, &transportErr):
+		c, reason = codes.Unavailable, ReasonTransport
+		meta[metaRetryable] = strconv.FormatBool(transportErr.Retryable)
+	case stderrors.As(err, &rateErr):
+		c, reason = codes.ResourceExhausted, ReasonRateLimit
+		retryAfter = rateErr.RetryAfter
+	case stderrors.As(err, &pressureErr):
+		c, reason = codes.ResourceExhausted, ReasonBackpressure
+		retryAfter = pressureErr.RetryAfter
+		meta[metaQueueDepth] = strconv.Itoa(pressureErr.QueueDepth)
+	case stderrors.Is(err, context.Canceled):
+		c = codes.Canceled
+	case stderrors.Is(err, context.DeadlineExceeded):
+		c = codes.DeadlineExceeded
+	case stderrors.As(err, &base):
+		reason = ReasonError
+	}
+
+	st := status.New(c, err.Error())
+	var details []protoadapt.MessageV1
+	if reason != "" {
+		if code := errors.CodeOf(err); code != errors.CodeUnknown {
+			meta[metaCode] = string(code)
+		}
+		details = append(details, &errdetails.ErrorInfo{
+			Reason:   reason,
+			Domain:   ErrorDomain,
+			Metadata: meta,
+		})
+	}
+	if retryAfter > 0 {
+		details = append(details, &errdetails.RetryInfo{RetryDelay: durationpb.New(retryAfter)})
+	}
+	for _, t := range errors.Timings(err) {
+		details = append(details, &errdetails.ErrorInfo{
+			Reason: ReasonTiming,
+			Domain: ErrorDomain,
+			Metadata: map[string]string{
+				metaStage:     t.Stage,
+				metaElapsedMs: strconv.FormatInt(t.Elapsed.Milliseconds(), 10),
+				metaBudgetMs:  strconv.FormatInt(t.Budget.Milliseconds(), 10),
+			},
+		})
+	}
+	if len(details) == 0 {
+		return st
+	}
+	withDetails, detailErr := st.WithDetails(details...)
+	if detailErr != nil {
+		return st
+	}
+	return withDetails
+}
+
+// FromStatus rebuilds a Planx error from st. Statuses produced by ToStatus
+// round-trip to their typed error; foreign statuses are classified by code.
+// OK and nil statuses yield nil.
+func FromStatus(st *status.Status) error {
+	if st == nil || st.Code() == codes.OK {
+		return nil
+	}
+
+	result := fromDetails(st)
+	for _, t := range timings(st) {
+		result = errors.WithTiming(result, t.Stage, t.Elapsed, t.Budget)
+	}
+	return result
+}
+
+// fromDetails rebuilds the error class from the ErrorInfo detail.
+func fromDetails(st *status.Status) error {
+	info := errorInfo(st)
+	if info == nil {
+		return fromCode(st)
+	}
+
+	msg := st.Message()
+	code := errors.Code(info.GetMetadata()[metaCode])
+	var out *errors.Error
+	var result error
+	switch info.GetReason() {
+	case ReasonConfig:
+		e := errors.NewConfigError(msg)
+		out, result = e.Base, e
+	case ReasonStream:
+		e := errors.NewStreamError(msg)
+		out, result = e.Base, e
+	case ReasonBatch:
+		e := errors.NewBatchError(msg, parseIndices(info.GetMetadata()[metaFailedIndices]))
+		out, result = e.Base, e
+	case ReasonTransport:
+		retryable, _ := strconv.ParseBool(info.GetMetadata()[metaRetryable])
+		e := errors.NewTransportError(msg, retryable)
+		out, result = e.Base, e
+	case ReasonRateLimit:
+		e := errors.NewRateLimitError(msg, retryDelay(st))
+		out, result = e.Base, e
+	case ReasonBackpressure:
+		depth, _ := strconv.Atoi(info.GetMetadata()[metaQueueDepth])
+		e := errors.NewBackpressureError(msg, depth, retryDelay(st))
+		out, result = e.Base, e
+	default:
+		out = errors.New(msg)
+		result = out
+	}
+	if code != errors.CodeUnknown {
+		out.Code = code
+	}
+	return result
+}
+
+// fromCode classifies a status that carries no Planx details.
+func fromCode(st *status.Status) error {
+	msg := st.Message()
+	switch st.Code() {
+	case codes.InvalidArgument:
+		return errors.NewConfigError(msg)
+	case codes.Unavailable:
+		return errors.NewTransportError(msg, true)
+	case codes.ResourceExhausted:
+		return errors.NewRateLimitError(msg, retryDelay(st))
+	case codes.Canceled:
+		return errors.Wrap(context.Canceled, msg)
+	case codes.DeadlineExceeded:
+		return errors.Wrap(context.DeadlineExceeded, msg)
+	default:
+		return errors.New(msg)
+	}
+}
+
+func errorInfo(st *status.Status) *errdetails.ErrorInfo {
+	for _, d := range st.Details() {
+		if info, ok := d.(*errdetails.ErrorInfo); ok && info.GetDomain() == ErrorDomain && info.GetReason() != ReasonTiming {
+			return info
+		}
+	}
+	return nil
+}
+
+// timings decodes the ReasonTiming details, innermost first.
+func timings(st *status.Status) []errors.Timing {
+	var out []errors.Timing
+	for _, d := range st.Details() {
+		info, ok := d.(*errdetails.ErrorInfo)
+		if !ok || info.GetDomain() != ErrorDomain || info.GetReason() != ReasonTiming {
+			continue
+		}
+		meta := info.GetMetadata()
+		elapsed, _ := strconv.ParseInt(meta[metaElapsedMs], 10, 64)
+		budget, _ := strconv.ParseInt(meta[metaBudgetMs], 10, 64)
+		out = append(out, errors.Timing{
+			Stage:   meta[metaStage],
+			Elapsed: time.Duration(elapsed) * time.Millisecond,
+			Budget:  time.Duration(budget) * time.Millisecond,
+		})
+	}
+	return out
+}
+
+// retryDelay returns the delay from a RetryInfo detail, or zero.
+func retryDelay(st *status.Status) time.Duration {
+	for _, d := range st.Details() {
+		if info, ok := d.(*errdetails.RetryInfo); ok {
+			return info.GetRetryDelay().AsDuration()
+		}
+	}
+	return 0
+}
+
+func formatIndices(indices []int) string {
+	parts := make([]string, len(indices))
+	for i, idx := range indices {
+		parts[i] = strconv.Itoa(idx)
+	}
+	return strings.Join(parts, ",")
+}
+
+func parseIndices(s string) []int {
+	if s == "" {
+		return nil
+	}
+	parts := strings.Split(s, ",")
+	indices := make([]int, 0, len(parts))
+	for _, p := range parts {
+		if idx, err := strconv.Atoi(p); err == nil {
+			indices = append(indices, idx)
+		}
+	}
+	return indices
+}
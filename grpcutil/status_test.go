@@ -0,0 +1,234 @@
+package grpcutil
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/planx-lab/planx-common/errors"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func TestToStatus_Nil(t *testing.T) {
+	if st := ToStatus(nil); st.Code() != codes.OK {
+		t.Fatalf("got %v", st.Code())
+	}
+}
+
+func TestToStatus_Codes(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want codes.Code
+	}{
+		{"config", errors.NewConfigError("bad"), codes.InvalidArgument},
+		{"stream", errors.NewStreamError("broken"), codes.Aborted},
+		{"batch", errors.NewBatchError("partial", []int{1}), codes.Aborted},
+		{"transport", errors.NewTransportError("down", true), codes.Unavailable},
+		{"wrapped config", fmt.Errorf("session: %w", errors.NewConfigError("bad")), codes.InvalidArgument},
+		{"canceled", context.Canceled, codes.Canceled},
+		{"deadline", context.DeadlineExceeded, codes.DeadlineExceeded},
+		{"shutdown transport", errors.FromContext(doneContext(false), errors.NewTransportError("down", true)), codes.Canceled},
+		{"expired transport", errors.FromContext(doneContext(true), errors.NewTransportError("down", true)), codes.DeadlineExceeded},
+		{"plain", fmt.Errorf("plain"), codes.Unknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ToStatus(tt.err).Code(); got != tt.want {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToStatus_ExistingStatus(t *testing.T) {
+	err := status.Error(codes.PermissionDenied, "nope")
+	if got := ToStatus(err).Code(); got != codes.PermissionDenied {
+		t.Fatalf("got %v", got)
+	}
+}
+
+func TestRoundTrip_ConfigError(t *testing.T) {
+	orig := errors.NewConfigError("missing endpoint")
+	got := FromStatus(ToStatus(orig))
+
+	var cfgErr *errors.ConfigError
+	if !stderrors.As(got, &cfgErr) {
+		t.Fatalf("expected *ConfigError, got %T", got)
+	}
+	if cfgErr.Message != "missing endpoint" {
+		t.Fatalf("message: got %q", cfgErr.Message)
+	}
+	if errors.CodeOf(got) != errors.CodeConfigInvalid {
+		t.Fatalf("code: got %q", errors.CodeOf(got))
+	}
+}
+
+func TestRoundTrip_BatchError(t *testing.T) {
+	got := FromStatus(ToStatus(errors.NewBatchError("partial", []int{2, 5, 7})))
+
+	var be *errors.BatchError
+	if !stderrors.As(got, &be) {
+		t.Fatalf("expected *BatchError, got %T", got)
+	}
+	if fmt.Sprint(be.FailedIndices) != "[2 5 7]" {
+		t.Fatalf("indices: got %v", be.FailedIndices)
+	}
+}
+
+func TestRoundTrip_TransportError(t *testing.T) {
+	got := FromStatus(ToStatus(errors.NewTransportError("refused", false)))
+
+	var te *errors.TransportError
+	if !stderrors.As(got, &te) {
+		t.Fatalf("expected *TransportError, got %T", got)
+	}
+	if te.Retryable {
+		t.Fatal("retryable should round-trip as false")
+	}
+}
+
+func TestRoundTrip_StreamError(t *testing.T) {
+	got := FromStatus(ToStatus(errors.NewStreamError("eof")))
+
+	var se *errors.StreamError
+	if !stderrors.As(got, &se) {
+		t.Fatalf("expected *StreamError, got %T", got)
+	}
+}
+
+func TestRoundTrip_CustomCode(t *testing.T) {
+	got := FromStatus(ToStatus(errors.NewWithCode(errors.CodeConfigMissing, "no dsn")))
+	if errors.CodeOf(got) != errors.CodeConfigMissing {
+		t.Fatalf("code: got %q", errors.CodeOf(got))
+	}
+}
+
+func TestFromStatus_OK(t *testing.T) {
+	if err := FromStatus(status.New(codes.OK, "")); err != nil {
+		t.Fatalf("got %v", err)
+	}
+	if err := FromStatus(nil); err != nil {
+		t.Fatalf("got %v", err)
+	}
+}
+
+func TestFromStatus_Foreign(t *testing.T) {
+	got := FromStatus(status.New(codes.Unavailable, "connection reset"))
+	var te *errors.TransportError
+	if !stderrors.As(got, &te) || !te.Retryable {
+		t.Fatalf("expected retryable *TransportError, got %T", got)
+	}
+
+	got = FromStatus(status.New(codes.DeadlineExceeded, "slow"))
+	if !stderrors.Is(got, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", got)
+	}
+}
+
+func TestToStatus_Throttling(t *testing.T) {
+	st := ToStatus(errors.NewRateLimitError("slow down", 2*time.Second))
+	if st.Code() != codes.ResourceExhausted {
+		t.Fatalf("code: got %v", st.Code())
+	}
+	if d := retryDelay(st); d != 2*time.Second {
+		t.Fatalf("retry delay: got %v", d)
+	}
+
+	if got := ToStatus(errors.NewBackpressureError("queue full", 128, 0)).Code(); got != codes.ResourceExhausted {
+		t.Fatalf("code: got %v", got)
+	}
+}
+
+func TestRoundTrip_RateLimitError(t *testing.T) {
+	got := FromStatus(ToStatus(errors.NewRateLimitError("slow down", 1500*time.Millisecond)))
+
+	var rl *errors.RateLimitError
+	if !stderrors.As(got, &rl) {
+		t.Fatalf("expected *RateLimitError, got %T", got)
+	}
+	if rl.RetryAfter != 1500*time.Millisecond {
+		t.Fatalf("retry after: got %v", rl.RetryAfter)
+	}
+}
+
+func TestRoundTrip_BackpressureError(t *testing.T) {
+	got := FromStatus(ToStatus(errors.NewBackpressureError("queue full", 64, time.Second)))
+
+	var bp *errors.BackpressureError
+	if !stderrors.As(got, &bp) {
+		t.Fatalf("expected *BackpressureError, got %T", got)
+	}
+	if bp.QueueDepth != 64 || bp.RetryAfter != time.Second {
+		t.Fatalf("got depth %d retry %v", bp.QueueDepth, bp.RetryAfter)
+	}
+}
+
+func TestFromStatus_ForeignResourceExhausted(t *testing.T) {
+	st, _ := status.New(codes.ResourceExhausted, "quota").WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(3 * time.Second)})
+	got := FromStatus(st)
+	if d, ok := errors.RetryAfter(got); !ok || d != 3*time.Second {
+		t.Fatalf("got %v, %v", d, ok)
+	}
+}
+
+// doneContext returns a context that is already canceled, or past its
+// deadline if expired.
+func doneContext(expired bool) context.Context {
+	var (
+		ctx    context.Context
+		cancel context.CancelFunc
+	)
+	if expired {
+		ctx, cancel = context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	} else {
+		ctx, cancel = context.WithCancel(context.Background())
+	}
+	cancel()
+	return ctx
+}
+
+func TestToStatus_Timings(t *testing.T) {
+	err := errors.WithTiming(errors.NewTransportError("write timed out", true), "sink", 800*time.Millisecond, time.Second)
+	err = errors.WithTiming(err, "pipeline", 1200*time.Millisecond, time.Second)
+
+	st := ToStatus(err)
+	if st.Code() != codes.Unavailable {
+		t.Fatalf("got %v", st.Code())
+	}
+
+	var stages []string
+	for _, d := range st.Details() {
+		if info, ok := d.(*errdetails.ErrorInfo); ok && info.GetReason() == ReasonTiming {
+			stages = append(stages, info.GetMetadata()["stage"]+"="+info.GetMetadata()["elapsed_ms"])
+		}
+	}
+	if len(stages) != 2 || stages[0] != "sink=800" || stages[1] != "pipeline=1200" {
+		t.Fatalf("unexpected timing details %v", stages)
+	}
+
+	got := FromStatus(st)
+	var te *errors.TransportError
+	if !stderrors.As(got, &te) {
+		t.Fatalf("expected a transport error, got %T", got)
+	}
+	timings := errors.Timings(got)
+	if len(timings) != 2 || timings[0].Stage != "sink" || timings[1].Budget != time.Second {
+		t.Fatalf("timings did not round-trip: %+v", timings)
+	}
+}
+
+func TestToStatus_TimingsOnForeignError(t *testing.T) {
+	st := ToStatus(errors.WithTiming(context.DeadlineExceeded, "source", time.Second, time.Second))
+	if st.Code() != codes.DeadlineExceeded || len(st.Details()) != 1 {
+		t.Fatalf("expected DeadlineExceeded with one timing detail, got %v %v", st.Code(), st.Details())
+	}
+	if timings := errors.Timings(FromStatus(st)); len(timings) != 1 || timings[0].Stage != "source" {
+		t.Fatalf("unexpected timings %+v", timings)
+	}
+}
@@ -0,0 +1,12 @@
+//go:build !unix
+
+package health
+
+import (
+	"fmt"
+	"runtime"
+)
+
+func diskFree(string) (uint64, error) {
+	return 0, fmt.Errorf("health: disk space probe not supported on %s", runtime.GOOS)
+}
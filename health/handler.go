@@ -0,0 +1,50 @@
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Handler serves Default's checks; see Registry.Handler.
+func Handler() http.Handler {
+	return Default.Handler()
+}
+
+// Handler serves /healthz with the liveness report and /readyz with the
+// readiness report, as JSON:
+//
+//	{"status":"fail","checks":{"postgres":{"status":"fail","error":"dial tcp: connection refused","duration_ms":1.2,"checked_at":"..."}}}
+//
+// The status code is 200 when every check passes and 503 otherwise, which
+// is what Kubernetes probes look at. Mount it at the root of the admin
+// mux, or use LivenessHandler and ReadinessHandler to choose the paths.
+func (r *Registry) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/healthz", r.LivenessHandler())
+	mux.Handle("/readyz", r.ReadinessHandler())
+	return mux
+}
+
+// LivenessHandler serves the liveness report.
+func (r *Registry) LivenessHandler() http.Handler {
+	return r.kindHandler(Liveness)
+}
+
+// ReadinessHandler serves the readiness report.
+func (r *Registry) ReadinessHandler() http.Handler {
+	return r.kindHandler(Readiness)
+}
+
+func (r *Registry) kindHandler(kind Kind) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		report := r.Run(req.Context(), kind)
+		status := http.StatusOK
+		if report.Status != StatusOK {
+			status = http.StatusServiceUnavailable
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(report)
+	})
+}
@@ -0,0 +1,56 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	r := NewRegistry()
+	r.RegisterLiveness("loop", ok)
+	r.RegisterReadiness("db", func(context.Context) error { return stderrors.New("refused") })
+	h := r.Handler()
+
+	tests := []struct {
+		path   string
+		status int
+		want   Status
+	}{
+		{"/healthz", http.StatusOK, StatusOK},
+		{"/readyz", http.StatusServiceUnavailable, StatusFail},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != tt.status {
+				t.Fatalf("status: got %d", rec.Code)
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+				t.Fatalf("content type: got %q", ct)
+			}
+			var report Report
+			if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+				t.Fatalf("unmarshal: %v", err)
+			}
+			if report.Status != tt.want || len(report.Checks) != 1 {
+				t.Fatalf("got %+v", report)
+			}
+		})
+	}
+}
+
+func TestHandler_Default(t *testing.T) {
+	RegisterReadiness("handler-test", ok)
+	defer Default.Unregister("handler-test")
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status: got %d", rec.Code)
+	}
+}
@@ -0,0 +1,240 @@
+// Package health provides liveness and readiness checks for Planx
+// binaries: a registry components add named checks to, and the /healthz
+// and /readyz handlers that report them.
+// Engine-side utilities only — must not be imported by SDK or plugins.
+package health
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/planx-lab/planx-common/logger"
+)
+
+// DefaultTimeout bounds a check registered without WithTimeout.
+const DefaultTimeout = 5 * time.Second
+
+// Check reports a component healthy by returning nil. It must honor ctx,
+// which carries the check's timeout.
+type Check func(ctx context.Context) error
+
+// Kind says which endpoint a check belongs to.
+type Kind string
+
+// Check kinds. A failing liveness check means the process should be
+// restarted; a failing readiness check means it should get no traffic.
+const (
+	Liveness  Kind = "liveness"
+	Readiness Kind = "readiness"
+)
+
+// Status is the outcome of a check or of a whole report.
+type Status string
+
+// Statuses.
+const (
+	StatusOK   Status = "ok"
+	StatusFail Status = "fail"
+)
+
+// Option configures a registered check.
+type Option func(*registered)
+
+// WithTimeout bounds each run of the check. The default is DefaultTimeout.
+func WithTimeout(d time.Duration) Option {
+	return func(c *registered) { c.timeout = d }
+}
+
+// WithCacheTTL reuses a check's result for d, so frequent probes do not
+// hammer the dependency it checks. Results are not cached by default.
+func WithCacheTTL(d time.Duration) Option {
+	return func(c *registered) { c.ttl = d }
+}
+
+// Result is the outcome of one check.
+type Result struct {
+	Status     Status    `json:"status"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs float64   `json:"duration_ms"`
+	CheckedAt  time.Time `json:"checked_at"`
+}
+
+// Report is the outcome of all checks of a kind. Status is StatusFail if
+// any check failed.
+type Report struct {
+	Status Status            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+type registered struct {
+	name    string
+	kind    Kind
+	check   Check
+	timeout time.Duration
+	ttl     time.Duration
+
+	mu   sync.Mutex
+	last Result
+}
+
+// Registry holds named checks. It is safe for concurrent use.
+type Registry struct {
+	mu     sync.RWMutex
+	checks map[string]*registered
+	now    func() time.Time
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{checks: map[string]*registered{}, now: time.Now}
+}
+
+// Default is the registry used by the package-level functions.
+var Default = NewRegistry()
+
+// Register adds a check of the given kind. Names are shared across kinds.
+// Like errors.RegisterCode it panics if name is empty or already
+// registered.
+func (r *Registry) Register(kind Kind, name string, check Check, opts ...Option) {
+	if name == "" || check == nil {
+		panic("health: Register with empty name or nil check")
+	}
+	c := &registered{name: name, kind: kind, check: check, timeout: DefaultTimeout}
+	for _, opt := range opts {
+		opt(c)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, dup := r.checks[name]; dup {
+		panic(fmt.Sprintf("health: check %q registered twice", name))
+	}
+	r.checks[name] = c
+}
+
+// RegisterLiveness adds a liveness check.
+func (r *Registry) RegisterLiveness(name string, check Check, opts ...Option) {
+	r.Register(Liveness, name, check, opts...)
+}
+
+// RegisterReadiness adds a readiness check.
+func (r *Registry) RegisterReadiness(name string, check Check, opts ...Option) {
+	r.Register(Readiness, name, check, opts...)
+}
+
+// Unregister removes the named check, e.g. when its component shuts down.
+func (r *Registry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.checks, name)
+}
+
+// Names returns the names of the checks of kind, sorted.
+func (r *Registry) Names(kind Kind) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var names []string
+	for name, c := range r.checks {
+		if c.kind == kind {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Run runs the checks of kind concurrently, each under its own timeout, and
+// reports their results. A kind with no checks reports StatusOK.
+func (r *Registry) Run(ctx context.Context, kind Kind) Report {
+	r.mu.RLock()
+	var checks []*registered
+	for _, c := range r.checks {
+		if c.kind == kind {
+			checks = append(checks, c)
+		}
+	}
+	r.mu.RUnlock()
+
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = r.run(ctx, c)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]Result, len(checks))}
+	for i, c := range checks {
+		report.Checks[c.name] = results[i]
+		if results[i].Status != StatusOK {
+			report.Status = StatusFail
+		}
+	}
+	return report
+}
+
+// run runs c, or returns its cached result. Runs of the same check are
+// serialized so a slow dependency is not probed by every caller at once.
+func (r *Registry) run(ctx context.Context, c *registered) Result {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.ttl > 0 && !c.last.CheckedAt.IsZero() && r.now().Sub(c.last.CheckedAt) < c.ttl {
+		return c.last
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	start := r.now()
+	err := runCheck(ctx, c.check)
+	res := Result{
+		Status:     StatusOK,
+		DurationMs: float64(r.now().Sub(start).Microseconds()) / 1000,
+		CheckedAt:  start,
+	}
+	if err != nil {
+		res.Status = StatusFail
+		res.Error = err.Error()
+		if ctx.Err() == context.DeadlineExceeded {
+			res.Error = fmt.Sprintf("timed out after %v: %v", c.timeout, err)
+		}
+	}
+
+	if !c.last.CheckedAt.IsZero() && c.last.Status != res.Status {
+		event := logger.Info()
+		if res.Status == StatusFail {
+			event = logger.Warn().Str("error", res.Error)
+		}
+		event.Str("check", c.name).Str("kind", string(c.kind)).Str("status", string(res.Status)).
+			Msg("health check status changed")
+	}
+	c.last = res
+	return res
+}
+
+// runCheck runs check, returning when it does or when ctx is done, so a
+// check that ignores its context cannot hang the endpoint.
+func runCheck(ctx context.Context, check Check) error {
+	done := make(chan error, 1)
+	go func() { done <- check(ctx) }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// RegisterLiveness adds a liveness check to Default.
+func RegisterLiveness(name string, check Check, opts ...Option) {
+	Default.RegisterLiveness(name, check, opts...)
+}
+
+// RegisterReadiness adds a readiness check to Default.
+func RegisterReadiness(name string, check Check, opts ...Option) {
+	Default.RegisterReadiness(name, check, opts...)
+}
@@ -0,0 +1,110 @@
+package health
+
+import (
+	"context"
+	stderrors "errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/planx-lab/planx-common/logger/logtest"
+)
+
+func ok(context.Context) error { return nil }
+
+func TestRegistry_Run(t *testing.T) {
+	r := NewRegistry()
+	r.RegisterLiveness("loop", ok)
+	r.RegisterReadiness("db", func(context.Context) error { return stderrors.New("refused") })
+	r.RegisterReadiness("cache", ok)
+
+	live := r.Run(context.Background(), Liveness)
+	if live.Status != StatusOK || len(live.Checks) != 1 {
+		t.Fatalf("liveness: got %+v", live)
+	}
+	ready := r.Run(context.Background(), Readiness)
+	if ready.Status != StatusFail || ready.Checks["db"].Error != "refused" || ready.Checks["cache"].Status != StatusOK {
+		t.Fatalf("readiness: got %+v", ready)
+	}
+	if names := r.Names(Readiness); len(names) != 2 || names[0] != "cache" {
+		t.Fatalf("names: got %v", names)
+	}
+
+	r.Unregister("db")
+	if got := r.Run(context.Background(), Readiness); got.Status != StatusOK {
+		t.Fatalf("after Unregister: got %+v", got)
+	}
+}
+
+func TestRegistry_Timeout(t *testing.T) {
+	r := NewRegistry()
+	r.RegisterReadiness("stuck", func(context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	}, WithTimeout(10*time.Millisecond))
+
+	start := time.Now()
+	res := r.Run(context.Background(), Readiness).Checks["stuck"]
+	if res.Status != StatusFail || !strings.Contains(res.Error, "timed out after 10ms") {
+		t.Fatalf("got %+v", res)
+	}
+	if time.Since(start) > 500*time.Millisecond {
+		t.Fatal("a check ignoring its context should not block the report")
+	}
+}
+
+func TestRegistry_Cache(t *testing.T) {
+	r := NewRegistry()
+	clock := time.Unix(1700000000, 0)
+	r.now = func() time.Time { return clock }
+	var calls int32
+	r.RegisterReadiness("db", func(context.Context) error {
+		atomic.AddInt32(&calls, 1)
+		return nil
+	}, WithCacheTTL(time.Minute))
+
+	r.Run(context.Background(), Readiness)
+	r.Run(context.Background(), Readiness)
+	if calls != 1 {
+		t.Fatalf("cached: got %d calls", calls)
+	}
+	clock = clock.Add(time.Minute)
+	r.Run(context.Background(), Readiness)
+	if calls != 2 {
+		t.Fatalf("expired: got %d calls", calls)
+	}
+}
+
+func TestRegistry_LogsTransitions(t *testing.T) {
+	rec := logtest.Capture(t)
+	r := NewRegistry()
+	var failing atomic.Bool
+	r.RegisterReadiness("db", func(context.Context) error {
+		if failing.Load() {
+			return stderrors.New("down")
+		}
+		return nil
+	})
+	r.Run(context.Background(), Readiness)
+	failing.Store(true)
+	r.Run(context.Background(), Readiness)
+	r.Run(context.Background(), Readiness)
+
+	if got := rec.Find(zerolog.WarnLevel, "health check status changed"); len(got) != 1 || got[0].Str("check") != "db" {
+		t.Fatalf("got %v", got)
+	}
+}
+
+func TestRegister_Panics(t *testing.T) {
+	r := NewRegistry()
+	r.RegisterLiveness("loop", ok)
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic for a duplicate name")
+		}
+	}()
+	r.RegisterReadiness("loop", ok)
+}
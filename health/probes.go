@@ -0,0 +1,93 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/planx-lab/planx-common/telemetry"
+)
+
+// TCPProbe checks that addr ("host:port") accepts TCP connections.
+func TCPProbe(addr string) Check {
+	return func(ctx context.Context) error {
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+}
+
+// HTTPProbe checks that a GET of url answers with a status below 400.
+// client may be nil for http.DefaultClient.
+func HTTPProbe(url string, client *http.Client) Check {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+		if resp.StatusCode >= http.StatusBadRequest {
+			return fmt.Errorf("GET %s: %s", url, resp.Status)
+		}
+		return nil
+	}
+}
+
+// GRPCProbe checks service over conn with the standard gRPC health
+// protocol (grpc.health.v1.Health/Check). An empty service asks about the
+// server as a whole.
+func GRPCProbe(conn grpc.ClientConnInterface, service string) Check {
+	client := healthpb.NewHealthClient(conn)
+	return func(ctx context.Context) error {
+		resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+		if err != nil {
+			return err
+		}
+		if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+			return fmt.Errorf("grpc health of %q: %s", service, resp.GetStatus())
+		}
+		return nil
+	}
+}
+
+// DiskSpaceProbe checks that the filesystem holding path has at least
+// minFree bytes available to unprivileged users, e.g. for a spool or
+// checkpoint directory.
+func DiskSpaceProbe(path string, minFree uint64) Check {
+	return func(context.Context) error {
+		free, err := diskFree(path)
+		if err != nil {
+			return err
+		}
+		if free < minFree {
+			return fmt.Errorf("%s: %d bytes free, want at least %d", path, free, minFree)
+		}
+		return nil
+	}
+}
+
+// OTLPExporterProbe checks that the latest telemetry exports succeeded, see
+// telemetry.ExportHealth. Register it as a readiness check only where
+// telemetry delivery is required, with a cache TTL no shorter than the
+// export interval.
+func OTLPExporterProbe() Check {
+	return func(context.Context) error {
+		return telemetry.ExportHealth()
+	}
+}
@@ -0,0 +1,89 @@
+package health
+
+import (
+	"context"
+	"math"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestTCPProbe(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	if err := TCPProbe(addr)(context.Background()); err != nil {
+		t.Fatalf("open port: %v", err)
+	}
+	ln.Close()
+	if err := TCPProbe(addr)(context.Background()); err == nil {
+		t.Fatal("expected an error for a closed port")
+	}
+}
+
+func TestHTTPProbe(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/down" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	if err := HTTPProbe(srv.URL+"/up", nil)(context.Background()); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	if err := HTTPProbe(srv.URL+"/down", srv.Client())(context.Background()); err == nil {
+		t.Fatal("expected an error for a 503")
+	}
+}
+
+func TestGRPCProbe(t *testing.T) {
+	ln := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	hs := grpchealth.NewServer()
+	healthpb.RegisterHealthServer(srv, hs)
+	go func() { _ = srv.Serve(ln) }()
+	defer srv.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return ln.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	hs.SetServingStatus("sink", healthpb.HealthCheckResponse_SERVING)
+	if err := GRPCProbe(conn, "sink")(context.Background()); err != nil {
+		t.Fatalf("serving: %v", err)
+	}
+	hs.SetServingStatus("sink", healthpb.HealthCheckResponse_NOT_SERVING)
+	if err := GRPCProbe(conn, "sink")(context.Background()); err == nil {
+		t.Fatal("expected an error for NOT_SERVING")
+	}
+}
+
+func TestDiskSpaceProbe(t *testing.T) {
+	dir := t.TempDir()
+	if err := DiskSpaceProbe(dir, 1)(context.Background()); err != nil {
+		t.Fatalf("1 byte: %v", err)
+	}
+	if err := DiskSpaceProbe(dir, math.MaxUint64)(context.Background()); err == nil {
+		t.Fatal("expected an error for an impossible threshold")
+	}
+}
+
+func TestOTLPExporterProbe(t *testing.T) {
+	if err := OTLPExporterProbe()(context.Background()); err != nil {
+		t.Fatalf("no exports yet: %v", err)
+	}
+}
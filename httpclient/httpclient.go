@@ -0,0 +1,108 @@
+// Package httpclient provides the shared, instrumented HTTP client used by
+// HTTP sinks and sources.
+// Engine-side utilities only — must not be imported by SDK or plugins.
+package httpclient
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/planx-lab/planx-common/config"
+	"github.com/planx-lab/planx-common/metrics"
+	"github.com/planx-lab/planx-common/retry"
+)
+
+// Defaults for zero Config fields.
+const (
+	DefaultTimeout               = 30 * time.Second
+	DefaultDialTimeout           = 5 * time.Second
+	DefaultTLSHandshakeTimeout   = 5 * time.Second
+	DefaultIdleConnTimeout       = 90 * time.Second
+	DefaultMaxIdleConns          = 256
+	DefaultMaxIdleConnsPerHost   = 32
+	DefaultExpectContinueTimeout = time.Second
+)
+
+// Config configures New:
+//
+//	http:
+//	  timeout: 10s
+//	  max_idle_conns_per_host: 64
+//	  retry:
+//	    max_attempts: 4
+//
+// Zero fields take the defaults above; MaxConnsPerHost and
+// ResponseHeaderTimeout are unlimited unless set.
+type Config struct {
+	Timeout               config.Duration `yaml:"timeout" json:"timeout"`
+	DialTimeout           config.Duration `yaml:"dial_timeout" json:"dial_timeout"`
+	TLSHandshakeTimeout   config.Duration `yaml:"tls_handshake_timeout" json:"tls_handshake_timeout"`
+	ResponseHeaderTimeout config.Duration `yaml:"response_header_timeout" json:"response_header_timeout"`
+	IdleConnTimeout       config.Duration `yaml:"idle_conn_timeout" json:"idle_conn_timeout"`
+	MaxIdleConns          int             `yaml:"max_idle_conns" json:"max_idle_conns" validate:"min=0"`
+	MaxIdleConnsPerHost   int             `yaml:"max_idle_conns_per_host" json:"max_idle_conns_per_host" validate:"min=0"`
+	MaxConnsPerHost       int             `yaml:"max_conns_per_host" json:"max_conns_per_host" validate:"min=0"`
+
+	// Retry applies to idempotent requests only; see Transport.
+	Retry retry.PolicyConfig `yaml:"retry" json:"retry"`
+
+	// TLS is the client TLS configuration, typically from
+	// tlsutil.LoadClientConfig; nil uses the system roots.
+	TLS *tls.Config `yaml:"-" json:"-"`
+
+	// Metrics, if set, records every request; see Transport.
+	Metrics metrics.RequestRecorder `yaml:"-" json:"-"`
+}
+
+// New returns an *http.Client with a pooled transport tuned by cfg,
+// wrapped by Transport for retries, tracing and metrics. Clients are meant
+// to be shared: create one per downstream, not per request.
+func New(cfg Config) *http.Client {
+	timeout := cfg.Timeout.Std()
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	transport := NewTransport(newBaseTransport(cfg), cfg.Retry)
+	transport.Metrics = cfg.Metrics
+	return &http.Client{
+		Transport: transport,
+		Timeout:   timeout,
+	}
+}
+
+func newBaseTransport(cfg Config) *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   orDefault(cfg.DialTimeout.Std(), DefaultDialTimeout),
+		KeepAlive: 30 * time.Second,
+	}
+	maxIdle := cfg.MaxIdleConns
+	if maxIdle <= 0 {
+		maxIdle = DefaultMaxIdleConns
+	}
+	maxIdlePerHost := cfg.MaxIdleConnsPerHost
+	if maxIdlePerHost <= 0 {
+		maxIdlePerHost = DefaultMaxIdleConnsPerHost
+	}
+	return &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		TLSClientConfig:       cfg.TLS,
+		TLSHandshakeTimeout:   orDefault(cfg.TLSHandshakeTimeout.Std(), DefaultTLSHandshakeTimeout),
+		ResponseHeaderTimeout: cfg.ResponseHeaderTimeout.Std(),
+		IdleConnTimeout:       orDefault(cfg.IdleConnTimeout.Std(), DefaultIdleConnTimeout),
+		ExpectContinueTimeout: DefaultExpectContinueTimeout,
+		MaxIdleConns:          maxIdle,
+		MaxIdleConnsPerHost:   maxIdlePerHost,
+		MaxConnsPerHost:       cfg.MaxConnsPerHost,
+	}
+}
+
+func orDefault(d, def time.Duration) time.Duration {
+	if d <= 0 {
+		return def
+	}
+	return d
+}
@@ -0,0 +1,36 @@
+package httpclient
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/planx-lab/planx-common/config"
+)
+
+func TestNew_Defaults(t *testing.T) {
+	c := New(Config{})
+	if c.Timeout != DefaultTimeout {
+		t.Fatalf("timeout: got %v", c.Timeout)
+	}
+	base, ok := c.Transport.(*Transport).base.(*http.Transport)
+	if !ok {
+		t.Fatalf("base transport: got %T", c.Transport.(*Transport).base)
+	}
+	if base.MaxIdleConnsPerHost != DefaultMaxIdleConnsPerHost || base.IdleConnTimeout != DefaultIdleConnTimeout || base.MaxConnsPerHost != 0 {
+		t.Fatalf("got %+v", base)
+	}
+}
+
+func TestNew_Config(t *testing.T) {
+	c := New(Config{
+		Timeout:               config.Duration(time.Second),
+		ResponseHeaderTimeout: config.Duration(500 * time.Millisecond),
+		MaxIdleConnsPerHost:   4,
+		MaxConnsPerHost:       8,
+	})
+	base := c.Transport.(*Transport).base.(*http.Transport)
+	if c.Timeout != time.Second || base.ResponseHeaderTimeout != 500*time.Millisecond || base.MaxIdleConnsPerHost != 4 || base.MaxConnsPerHost != 8 {
+		t.Fatalf("got %v %+v", c.Timeout, base)
+	}
+}
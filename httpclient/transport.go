@@ -0,0 +1,209 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/planx-lab/planx-common/errors"
+	"github.com/planx-lab/planx-common/metrics"
+	"github.com/planx-lab/planx-common/requestid"
+	"github.com/planx-lab/planx-common/retry"
+	"github.com/planx-lab/planx-common/telemetry"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// IdempotencyKeyHeader marks a request as safe to retry whatever its method.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// clientProtocol is the protocol label of the requests Transport records.
+const clientProtocol = "http-client"
+
+// maxDrain bounds how much of a discarded response body is read so its
+// connection can be reused.
+const maxDrain = 64 << 10
+
+// Transport is an http.RoundTripper adding, around a base transport:
+//
+//   - a client span per request, with the trace context injected into the
+//     request headers
+//   - the X-Request-ID header, from the request ID in the request context
+//     unless already set
+//   - with Metrics set, count, latency and in-flight requests per attempt,
+//     under the protocol "http-client", by method and host
+//   - retries under a retry.Policy for idempotent requests (GET, HEAD,
+//     OPTIONS, TRACE, PUT, DELETE, or any with an Idempotency-Key header)
+//     whose body can be replayed
+//
+// Connection errors that errors.IsRetryable accepts are retried, as are
+// 429, 502, 503 and 504 responses; 429 and 503 honour Retry-After. When
+// attempts run out on a retryable status, the last response is returned
+// as-is rather than as an error.
+type Transport struct {
+	// Metrics, if set, records every attempt. The status label is the
+	// response status, or "error" if no response was received.
+	Metrics metrics.RequestRecorder
+
+	base   http.RoundTripper
+	policy *retry.Policy
+}
+
+// NewTransport wraps base, or http.DefaultTransport if nil, retrying with
+// the policy described by cfg.
+func NewTransport(base http.RoundTripper, cfg retry.PolicyConfig, opts ...retry.PolicyOption) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Transport{base: base, policy: retry.NewPolicy(cfg, opts...)}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, span := telemetry.Tracer().Start(req.Context(), "HTTP "+req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", req.Method),
+			attribute.String("server.address", req.URL.Host),
+		))
+	defer span.End()
+
+	var resp *http.Response
+	var err error
+	if retryable(req) {
+		resp, err = t.roundTripWithRetry(ctx, req)
+	} else {
+		resp, err = t.attempt(ctx, req)
+	}
+	switch {
+	case err != nil:
+		span.RecordError(err)
+		span.SetStatus(otelcodes.Error, err.Error())
+	default:
+		span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+		if resp.StatusCode >= http.StatusInternalServerError {
+			span.SetStatus(otelcodes.Error, resp.Status)
+		}
+	}
+	return resp, err
+}
+
+func (t *Transport) roundTripWithRetry(ctx context.Context, req *http.Request) (*http.Response, error) {
+	var (
+		last     *http.Response // response with a retryable status, kept in case attempts run out
+		attempts int
+	)
+	err := t.policy.Do(ctx, func(ctx context.Context) error {
+		if last != nil {
+			discard(last)
+			last = nil
+		}
+		attempts++
+		attemptReq := req
+		if attempts > 1 && req.Body != nil && req.Body != http.NoBody {
+			body, err := req.GetBody()
+			if err != nil {
+				return err
+			}
+			attemptReq = req.Clone(ctx)
+			attemptReq.Body = body
+		}
+		resp, err := t.attempt(ctx, attemptReq)
+		if err != nil {
+			return err
+		}
+		if statusErr := retryableStatus(resp); statusErr != nil {
+			last = resp
+			return statusErr
+		}
+		last = resp
+		return nil
+	})
+	if last != nil && ctx.Err() != nil {
+		discard(last)
+		return nil, err
+	}
+	if last != nil {
+		return last, nil
+	}
+	return nil, err
+}
+
+// attempt sends req once with the trace context and request ID injected
+// and records it with t.Metrics.
+func (t *Transport) attempt(ctx context.Context, req *http.Request) (*http.Response, error) {
+	out := req.Clone(ctx)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(out.Header))
+	if reqID := requestid.FromContext(ctx); reqID != "" && out.Header.Get(requestid.Header) == "" {
+		out.Header.Set(requestid.Header, reqID)
+	}
+	if t.Metrics == nil {
+		return t.base.RoundTrip(out)
+	}
+	route := req.Method + " " + req.URL.Host
+	t.Metrics.RecordRequestInFlight(ctx, clientProtocol, route, 1)
+	defer t.Metrics.RecordRequestInFlight(ctx, clientProtocol, route, -1)
+	start := time.Now()
+	resp, err := t.base.RoundTrip(out)
+	status := "error"
+	if err == nil {
+		status = strconv.Itoa(resp.StatusCode)
+	}
+	t.Metrics.RecordRequest(ctx, clientProtocol, route, status, float64(time.Since(start))/float64(time.Millisecond))
+	return resp, err
+}
+
+// retryable reports whether req may be sent more than once.
+func retryable(req *http.Request) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	if req.Header.Get(IdempotencyKeyHeader) != "" {
+		return true
+	}
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// retryableStatus returns the error a retryable response status stands
+// for, or nil.
+func retryableStatus(resp *http.Response) error {
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		if d, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+			return errors.NewRateLimitError(resp.Status, d)
+		}
+		return errors.NewTransportError(resp.Status, true)
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		return errors.NewTransportError(resp.Status, true)
+	}
+	return nil
+}
+
+// retryAfter parses a Retry-After header, in seconds or as an HTTP date.
+func retryAfter(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(time.Until(t), 0), true
+	}
+	return 0, false
+}
+
+// discard drains and closes a response that will not be returned.
+func discard(resp *http.Response) {
+	_, _ = io.CopyN(io.Discard, resp.Body, maxDrain)
+	_ = resp.Body.Close()
+}
@@ -0,0 +1,225 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/planx-lab/planx-common/config"
+	"github.com/planx-lab/planx-common/requestid"
+	"github.com/planx-lab/planx-common/retry"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+var fastRetry = retry.PolicyConfig{BaseBackoff: config.Duration(time.Millisecond), Jitter: retry.JitterNone}
+
+// flaky answers with status for the first failures requests, then 200 with
+// the request body echoed.
+func flaky(failures int32, status int) (http.Handler, *atomic.Int32) {
+	var calls atomic.Int32
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= failures {
+			w.WriteHeader(status)
+			return
+		}
+		_, _ = io.Copy(w, r.Body)
+	}), &calls
+}
+
+func TestTransport_RetriesIdempotent(t *testing.T) {
+	h, calls := flaky(2, http.StatusServiceUnavailable)
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+	client := &http.Client{Transport: NewTransport(nil, fastRetry)}
+
+	req, _ := http.NewRequest(http.MethodPut, srv.URL, strings.NewReader("payload"))
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || string(body) != "payload" || calls.Load() != 3 {
+		t.Fatalf("got %d %q after %d calls", resp.StatusCode, body, calls.Load())
+	}
+}
+
+func TestTransport_NoRetryForPost(t *testing.T) {
+	h, calls := flaky(1, http.StatusServiceUnavailable)
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+	client := &http.Client{Transport: NewTransport(nil, fastRetry)}
+
+	resp, err := client.Post(srv.URL, "text/plain", strings.NewReader("x"))
+	if err != nil {
+		t.Fatalf("Post: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable || calls.Load() != 1 {
+		t.Fatalf("got %d after %d calls", resp.StatusCode, calls.Load())
+	}
+
+	req, _ := http.NewRequest(http.MethodPost, srv.URL, strings.NewReader("x"))
+	req.Header.Set(IdempotencyKeyHeader, "k1")
+	resp, err = client.Do(req)
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || calls.Load() != 2 {
+		t.Fatalf("idempotency key: got %d after %d calls", resp.StatusCode, calls.Load())
+	}
+}
+
+func TestTransport_ReturnsLastResponse(t *testing.T) {
+	h, calls := flaky(10, http.StatusBadGateway)
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+	client := &http.Client{Transport: NewTransport(nil, fastRetry)}
+
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadGateway || calls.Load() != retry.DefaultMaxAttempts {
+		t.Fatalf("got %d after %d calls", resp.StatusCode, calls.Load())
+	}
+}
+
+func TestTransport_NotRetried(t *testing.T) {
+	h, calls := flaky(1, http.StatusBadRequest)
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+	client := &http.Client{Transport: NewTransport(nil, fastRetry)}
+
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest || calls.Load() != 1 {
+		t.Fatalf("got %d after %d calls", resp.StatusCode, calls.Load())
+	}
+}
+
+// requests records RecordRequest calls as "protocol route status".
+type requests struct {
+	mu       sync.Mutex
+	got      []string
+	inFlight int
+}
+
+func (r *requests) RecordRequest(_ context.Context, protocol, route, status string, _ float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.got = append(r.got, protocol+" "+route+" "+status)
+}
+
+func (r *requests) RecordRequestInFlight(_ context.Context, _, _ string, delta int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.inFlight += delta
+}
+
+func TestTransport_Metrics(t *testing.T) {
+	h, _ := flaky(1, http.StatusServiceUnavailable)
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+	reqs := &requests{}
+	transport := NewTransport(nil, fastRetry)
+	transport.Metrics = reqs
+	client := &http.Client{Transport: transport}
+
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	resp.Body.Close()
+	srv.Close()
+	if _, err := client.Post(srv.URL, "text/plain", strings.NewReader("x")); err == nil {
+		t.Fatal("Post to a closed server succeeded")
+	}
+
+	host := strings.TrimPrefix(srv.URL, "http://")
+	want := "http-client GET " + host + " 503,http-client GET " + host + " 200,http-client POST " + host + " error"
+	if got := strings.Join(reqs.got, ","); got != want || reqs.inFlight != 0 {
+		t.Fatalf("recorded %q, in flight %d", got, reqs.inFlight)
+	}
+}
+
+func TestTransport_InjectsTrace(t *testing.T) {
+	prev := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTextMapPropagator(prev) })
+
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("traceparent")
+	}))
+	defer srv.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	req = req.WithContext(otel.GetTextMapPropagator().Extract(req.Context(), propagation.MapCarrier{
+		"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	}))
+	resp, err := (&http.Client{Transport: NewTransport(nil, fastRetry)}).Do(req)
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	resp.Body.Close()
+	if !strings.Contains(got, "4bf92f3577b34da6a3ce929d0e0e4736") {
+		t.Fatalf("traceparent: got %q", got)
+	}
+	if req.Header.Get("traceparent") != "" {
+		t.Fatal("caller's request was modified")
+	}
+}
+
+func TestTransport_InjectsRequestID(t *testing.T) {
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get(requestid.Header))
+	}))
+	defer srv.Close()
+	client := &http.Client{Transport: NewTransport(nil, fastRetry)}
+
+	ctx := requestid.NewContext(context.Background(), "req-5")
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	resp.Body.Close()
+
+	req, _ = http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	req.Header.Set(requestid.Header, "caller")
+	resp, err = client.Do(req)
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	resp.Body.Close()
+
+	if len(got) != 2 || got[0] != "req-5" || got[1] != "caller" {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	if d, ok := retryAfter("3"); !ok || d != 3*time.Second {
+		t.Fatalf("seconds: got %v %v", d, ok)
+	}
+	if d, ok := retryAfter(time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)); !ok || d < 59*time.Minute {
+		t.Fatalf("date: got %v %v", d, ok)
+	}
+	if _, ok := retryAfter("soon"); ok {
+		t.Fatal("garbage accepted")
+	}
+}
@@ -0,0 +1,185 @@
+package httputil
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/planx-lab/planx-common/errors"
+	"github.com/planx-lab/planx-common/logger"
+	"github.com/planx-lab/planx-common/metrics"
+	"github.com/planx-lab/planx-common/requestid"
+	"github.com/planx-lab/planx-common/telemetry"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Middleware wraps an http.Handler.
+type Middleware func(http.Handler) http.Handler
+
+// Chain composes middlewares into one. The first is the outermost, so
+//
+//	Chain(Trace, RequestID, Log, Timeout(30*time.Second), Metrics(rec), Recover)
+//
+// starts the span before the request ID is assigned, so the ID is tagged on
+// it, and recovers panics before they reach logging and metrics, which then
+// see the 500. Metrics comes after every middleware that replaces the
+// request, so it sees the route the mux matched.
+func Chain(mws ...Middleware) Middleware {
+	return func(h http.Handler) http.Handler {
+		for i := len(mws) - 1; i >= 0; i-- {
+			h = mws[i](h)
+		}
+		return h
+	}
+}
+
+// Recover converts a handler panic into a problem+json 500 response, after
+// reporting it with errors.Observe. Panics with http.ErrAbortHandler are
+// re-raised, as net/http expects.
+func Recover(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := record(w)
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			if v == http.ErrAbortHandler {
+				panic(v)
+			}
+			err := errors.Recover(v).WithField("path", r.URL.Path)
+			errors.Observe(r.Context(), nil, err, "", r.Method+" "+r.URL.Path)
+			if !rec.wrote {
+				WriteProblem(rec, err, r.URL.Path)
+			}
+		}()
+		next.ServeHTTP(rec, r)
+	})
+}
+
+// Log logs every request once it has been served: at debug level, or at
+// warn level for 5xx responses. The request ID, if RequestID ran first, is
+// included through the context.
+func Log(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := record(w)
+		next.ServeHTTP(rec, r)
+
+		ev := logger.DebugCtx(r.Context())
+		if rec.status >= http.StatusInternalServerError {
+			ev = logger.WarnCtx(r.Context())
+		}
+		ev.Str("method", r.Method).
+			Str("path", r.URL.Path).
+			Int("status", rec.status).
+			Str("remote", r.RemoteAddr).
+			EmbedObject(logger.Bytes("response", rec.bytes)).
+			EmbedObject(logger.Dur("duration", time.Since(start))).
+			Msg("http request")
+	})
+}
+
+// Metrics records request count, latency and in-flight requests with rec,
+// as metrics.HTTPMiddleware does. The route label is the http.ServeMux
+// pattern, which the mux sets on the request it is given, so Metrics must
+// wrap the mux with no request-replacing middleware (Trace, RequestID,
+// Timeout) in between.
+func Metrics(rec metrics.RequestRecorder) Middleware {
+	return metrics.HTTPMiddleware(rec)
+}
+
+// Trace continues the trace in the request headers, or starts one, with a
+// server span around the request.
+func Trace(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := telemetry.Tracer().Start(ctx, "HTTP "+r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", r.Method),
+				attribute.String("url.path", r.URL.Path),
+			))
+		defer span.End()
+
+		rec := record(w)
+		next.ServeHTTP(rec, r.WithContext(ctx))
+		span.SetAttributes(attribute.Int("http.response.status_code", rec.status))
+		if rec.status >= http.StatusInternalServerError {
+			span.SetStatus(otelcodes.Error, http.StatusText(rec.status))
+		}
+	})
+}
+
+// RequestID makes sure every request has an ID: the client's X-Request-ID
+// if it sent a usable one, else a new one. The ID is stored in the request
+// context with requestid.NewContext and echoed in the response header.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reqID := requestid.Ensure(r.Header.Get(requestid.Header))
+		w.Header().Set(requestid.Header, reqID)
+		next.ServeHTTP(w, r.WithContext(requestid.NewContext(r.Context(), reqID)))
+	})
+}
+
+// Timeout gives each request a context deadline of d. Handlers are
+// expected to stop when the context is done; if one returns after the
+// deadline without having written a response, Timeout writes a 504
+// problem+json.
+func Timeout(d time.Duration) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+			rec := record(w)
+			next.ServeHTTP(rec, r.WithContext(ctx))
+			if !rec.wrote && ctx.Err() != nil {
+				WriteProblem(rec, errors.FromContext(ctx, ctx.Err()), r.URL.Path)
+			}
+		})
+	}
+}
+
+// responseRecorder tracks the status and size of a response. Nested
+// middlewares share one recorder rather than stacking wrappers.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+	wrote  bool
+}
+
+func record(w http.ResponseWriter) *responseRecorder {
+	if rec, ok := w.(*responseRecorder); ok {
+		return rec
+	}
+	return &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if !r.wrote {
+		r.status = status
+		r.wrote = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(p []byte) (int, error) {
+	r.wrote = true
+	n, err := r.ResponseWriter.Write(p)
+	r.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (r *responseRecorder) Unwrap() http.ResponseWriter { return r.ResponseWriter }
+
+// Flush implements http.Flusher for handlers that stream.
+func (r *responseRecorder) Flush() {
+	r.wrote = true
+	_ = http.NewResponseController(r.ResponseWriter).Flush()
+}
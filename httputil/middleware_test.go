@@ -0,0 +1,213 @@
+package httputil
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/planx-lab/planx-common/logger/logtest"
+	"github.com/planx-lab/planx-common/requestid"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func serve(h http.Handler, req *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestChain_Order(t *testing.T) {
+	var order []string
+	mw := func(name string) Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+	h := Chain(mw("a"), mw("b"), mw("c"))(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		order = append(order, "handler")
+	}))
+	serve(h, httptest.NewRequest(http.MethodGet, "/", nil))
+	if got := strings.Join(order, ","); got != "a,b,c,handler" {
+		t.Fatalf("got %s", got)
+	}
+}
+
+func TestRecover(t *testing.T) {
+	rec := logtest.Capture(t)
+	h := Chain(Log, Recover)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic("boom")
+	}))
+	resp := serve(h, httptest.NewRequest(http.MethodGet, "/v1/pipelines", nil))
+
+	if resp.Code != http.StatusInternalServerError || resp.Header().Get("Content-Type") != ProblemContentType {
+		t.Fatalf("got %d %q", resp.Code, resp.Header().Get("Content-Type"))
+	}
+	var p Problem
+	if err := json.Unmarshal(resp.Body.Bytes(), &p); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if p.Code != "PLX-INTERNAL-PANIC" || p.Instance != "/v1/pipelines" {
+		t.Fatalf("got %+v", p)
+	}
+	if entries := rec.Find(zerolog.ErrorLevel, "stage failed"); len(entries) != 1 || entries[0].Str("stack") == "" {
+		t.Fatalf("panic not reported: %v", entries)
+	}
+	if entries := rec.Find(zerolog.WarnLevel, "http request"); len(entries) != 1 {
+		t.Fatalf("500 not logged at warn: %v", entries)
+	}
+}
+
+func TestRecover_AbortHandler(t *testing.T) {
+	h := Recover(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+	defer func() {
+		if recover() != http.ErrAbortHandler {
+			t.Fatal("ErrAbortHandler not re-raised")
+		}
+	}()
+	serve(h, httptest.NewRequest(http.MethodGet, "/", nil))
+}
+
+func TestRequestID(t *testing.T) {
+	var got string
+	h := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = requestid.FromContext(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(requestid.Header, "req-123")
+	resp := serve(h, req)
+	if got != "req-123" || resp.Header().Get(requestid.Header) != "req-123" {
+		t.Fatalf("client ID not kept: %q, header %q", got, resp.Header().Get(requestid.Header))
+	}
+
+	for _, bad := range []string{"", "has space", strings.Repeat("x", 129)} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(requestid.Header, bad)
+		resp := serve(h, req)
+		if len(got) != 26 || got == bad || resp.Header().Get(requestid.Header) != got {
+			t.Fatalf("%q: got %q", bad, got)
+		}
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	slow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+	resp := serve(Timeout(10*time.Millisecond)(slow), httptest.NewRequest(http.MethodGet, "/slow", nil))
+	if resp.Code != http.StatusGatewayTimeout || resp.Header().Get("Content-Type") != ProblemContentType {
+		t.Fatalf("got %d", resp.Code)
+	}
+
+	written := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		w.WriteHeader(http.StatusAccepted)
+	})
+	if resp := serve(Timeout(10*time.Millisecond)(written), httptest.NewRequest(http.MethodGet, "/", nil)); resp.Code != http.StatusAccepted {
+		t.Fatalf("handler response replaced: %d", resp.Code)
+	}
+}
+
+func TestTrace(t *testing.T) {
+	prev := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTextMapPropagator(prev) })
+
+	var got string
+	h := Trace(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = trace.SpanContextFromContext(r.Context()).TraceID().String()
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	serve(h, req)
+	if got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Fatalf("trace ID: got %q", got)
+	}
+}
+
+func TestLog_Fields(t *testing.T) {
+	prev := zerolog.GlobalLevel()
+	zerolog.SetGlobalLevel(zerolog.DebugLevel)
+	t.Cleanup(func() { zerolog.SetGlobalLevel(prev) })
+	rec := logtest.Capture(t)
+
+	h := Chain(RequestID, Log)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("hello"))
+	}))
+	req := httptest.NewRequest(http.MethodPost, "/v1/tenants", nil)
+	req.Header.Set(requestid.Header, "req-1")
+	serve(h, req)
+
+	entries := rec.Find(zerolog.DebugLevel, "http request")
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries", len(entries))
+	}
+	e := entries[0]
+	if e.Str("request_id") != "req-1" || e.Str("path") != "/v1/tenants" || e.Fields["status"] != float64(201) || e.Fields["response_bytes"] != float64(5) {
+		t.Fatalf("got %s", e.Raw)
+	}
+}
+
+// requests records RecordRequest calls as "route status".
+type requests struct {
+	mu       sync.Mutex
+	got      []string
+	inFlight int
+}
+
+func (r *requests) RecordRequest(_ context.Context, protocol, route, status string, _ float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.got = append(r.got, protocol+" "+route+" "+status)
+}
+
+func (r *requests) RecordRequestInFlight(_ context.Context, _, _ string, delta int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.inFlight += delta
+}
+
+func TestMetrics_Route(t *testing.T) {
+	logtest.Capture(t)
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/tenants/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+	mux.HandleFunc("GET /panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+	reqs := &requests{}
+	h := Chain(Trace, RequestID, Log, Timeout(time.Second), Metrics(reqs), Recover)(mux)
+
+	serve(h, httptest.NewRequest(http.MethodPost, "/v1/tenants/t-1", nil))
+	serve(h, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	serve(h, httptest.NewRequest(http.MethodGet, "/nowhere", nil))
+
+	want := "http POST /v1/tenants/{id} 201,http GET /panic 500,http unmatched 404"
+	if got := strings.Join(reqs.got, ","); got != want || reqs.inFlight != 0 {
+		t.Fatalf("recorded %q, in flight %d", got, reqs.inFlight)
+	}
+}
+
+func TestResponseRecorder_Flush(t *testing.T) {
+	h := Log(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.(http.Flusher).Flush()
+	}))
+	if resp := serve(h, httptest.NewRequest(http.MethodGet, "/", nil)); !resp.Flushed {
+		t.Fatal("flush not passed through")
+	}
+}
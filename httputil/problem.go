@@ -0,0 +1,171 @@
+// Package httputil provides HTTP helpers for Planx engine components.
+// It owns the mapping between Planx error classes and HTTP responses,
+// which the errors package deliberately does not define.
+// Engine-side utilities only — must not be imported by SDK or plugins.
+package httputil
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/planx-lab/planx-common/errors"
+)
+
+// ProblemContentType is the media type of RFC 7807 problem documents.
+const ProblemContentType = "application/problem+json"
+
+// StatusClientClosedRequest is the non-standard status used for requests the
+// client cancelled, matching the gRPC Canceled mapping.
+const StatusClientClosedRequest = 499
+
+// Problem types, one per Planx error class.
+const (
+	ProblemTypeConfig       = "urn:planx:problem:config"
+	ProblemTypeStream       = "urn:planx:problem:stream"
+	ProblemTypeBatch        = "urn:planx:problem:batch"
+	ProblemTypeTransport    = "urn:planx:problem:transport"
+	ProblemTypeRateLimit    = "urn:planx:problem:rate-limit"
+	ProblemTypeBackpressure = "urn:planx:problem:backpressure"
+)
+
+// Problem is an RFC 7807 problem document with Planx extension members.
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+
+	Code          string `json:"code,omitempty"`
+	Retryable     *bool  `json:"retryable,omitempty"`
+	FailedIndices []int  `json:"failed_indices,omitempty"`
+	RetryAfterMs  int64  `json:"retry_after_ms,omitempty"`
+	QueueDepth    int    `json:"queue_depth,omitempty"`
+}
+
+// StatusCode returns the HTTP status for err. The mapping follows the gRPC
+// one in grpcutil so both APIs agree:
+//
+//	ConfigError    -> 400 Bad Request
+//	StreamError    -> 409 Conflict
+//	BatchError     -> 409 Conflict
+//	TransportError -> 503 Service Unavailable
+//	RateLimitError, BackpressureError -> 429 Too Many Requests
+//
+// context.DeadlineExceeded maps to 504, context.Canceled to 499, anything
+// else to 500. nil maps to 200. Errors reclassified by errors.FromContext map
+// to 504 or 499 whatever they wrap.
+func StatusCode(err error) int {
+	return classify(err).status
+}
+
+// NewProblem builds the problem document for err.
+func NewProblem(err error) Problem {
+	c := classify(err)
+	p := Problem{
+		Type:   c.problemType,
+		Title:  c.title,
+		Status: c.status,
+	}
+	if err == nil {
+		return p
+	}
+	p.Detail = err.Error()
+	if code := errors.CodeOf(err); code != errors.CodeUnknown {
+		p.Code = string(code)
+	}
+	var batchErr *errors.BatchError
+	if stderrors.As(err, &batchErr) {
+		p.FailedIndices = batchErr.FailedIndices
+	}
+	var transportErr *errors.TransportError
+	if stderrors.As(err, &transportErr) {
+		retryable := transportErr.Retryable
+		p.Retryable = &retryable
+	}
+	if d, ok := errors.RetryAfter(err); ok {
+		p.RetryAfterMs = d.Milliseconds()
+	}
+	var pressureErr *errors.BackpressureError
+	if stderrors.As(err, &pressureErr) {
+		p.QueueDepth = pressureErr.QueueDepth
+	}
+	return p
+}
+
+// ToProblemJSON encodes the problem document for err.
+func ToProblemJSON(err error) ([]byte, error) {
+	return json.Marshal(NewProblem(err))
+}
+
+// WriteProblem writes err as an application/problem+json response.
+// instance, if non-empty, identifies the failing request (usually its path).
+// Throttling errors with a retry delay also set the Retry-After header.
+func WriteProblem(w http.ResponseWriter, err error, instance string) {
+	p := NewProblem(err)
+	p.Instance = instance
+	if p.RetryAfterMs > 0 {
+		secs := int64(math.Ceil(float64(p.RetryAfterMs) / 1000))
+		w.Header().Set("Retry-After", strconv.FormatInt(secs, 10))
+	}
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(p.Status)
+	_ = json.NewEncoder(w).Encode(p)
+}
+
+type classification struct {
+	status      int
+	problemType string
+	title       string
+}
+
+func classify(err error) classification {
+	var (
+		cfgErr       *errors.ConfigError
+		streamErr    *errors.StreamError
+		batchErr     *errors.BatchError
+		transportErr *errors.TransportError
+		rateErr      *errors.RateLimitError
+		pressureErr  *errors.BackpressureError
+	)
+	switch {
+	case err == nil:
+		return generic(http.StatusOK)
+	case errors.CodeOf(err) == errors.CodeCanceled:
+		return clientClosed()
+	case errors.CodeOf(err) == errors.CodeDeadlineExceeded:
+		return generic(http.StatusGatewayTimeout)
+	case stderrors.As(err, &cfgErr):
+		return classification{http.StatusBadRequest, ProblemTypeConfig, "Configuration error"}
+	case stderrors.As(err, &streamErr):
+		return classification{http.StatusConflict, ProblemTypeStream, "Stream error"}
+	case stderrors.As(err, &batchErr):
+		return classification{http.StatusConflict, ProblemTypeBatch, "Batch partially failed"}
+	case stderrors.As(err, &transportErr):
+		return classification{http.StatusServiceUnavailable, ProblemTypeTransport, "Transport error"}
+	case stderrors.As(err, &rateErr):
+		return classification{http.StatusTooManyRequests, ProblemTypeRateLimit, "Rate limited"}
+	case stderrors.As(err, &pressureErr):
+		return classification{http.StatusTooManyRequests, ProblemTypeBackpressure, "Backpressure"}
+	case stderrors.Is(err, context.DeadlineExceeded):
+		return generic(http.StatusGatewayTimeout)
+	case stderrors.Is(err, context.Canceled):
+		return clientClosed()
+	default:
+		return generic(http.StatusInternalServerError)
+	}
+}
+
+func clientClosed() classification {
+	c := generic(StatusClientClosedRequest)
+	c.title = "Client closed request"
+	return c
+}
+
+func generic(status int) classification {
+	return classification{status, "about:blank", http.StatusText(status)}
+}
@@ -0,0 +1,150 @@
+package httputil
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/planx-lab/planx-common/errors"
+)
+
+func TestStatusCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"nil", nil, http.StatusOK},
+		{"config", errors.NewConfigError("bad"), http.StatusBadRequest},
+		{"stream", errors.NewStreamError("eof"), http.StatusConflict},
+		{"batch", errors.NewBatchError("partial", []int{1}), http.StatusConflict},
+		{"transport", errors.NewTransportError("down", true), http.StatusServiceUnavailable},
+		{"wrapped", fmt.Errorf("x: %w", errors.NewConfigError("bad")), http.StatusBadRequest},
+		{"deadline", context.DeadlineExceeded, http.StatusGatewayTimeout},
+		{"canceled", context.Canceled, StatusClientClosedRequest},
+		{"shutdown transport", errors.FromContext(doneContext(false), errors.NewTransportError("down", true)), StatusClientClosedRequest},
+		{"expired transport", errors.FromContext(doneContext(true), errors.NewTransportError("down", true)), http.StatusGatewayTimeout},
+		{"plain", fmt.Errorf("plain"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := StatusCode(tt.err); got != tt.want {
+				t.Fatalf("got %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewProblem_Batch(t *testing.T) {
+	p := NewProblem(errors.NewBatchError("3 records rejected", []int{0, 4, 9}))
+	if p.Type != ProblemTypeBatch || p.Status != http.StatusConflict {
+		t.Fatalf("got %+v", p)
+	}
+	if p.Detail != "3 records rejected" {
+		t.Fatalf("detail: got %q", p.Detail)
+	}
+	if p.Code != string(errors.CodeBatchPartial) {
+		t.Fatalf("code: got %q", p.Code)
+	}
+	if len(p.FailedIndices) != 3 {
+		t.Fatalf("indices: got %v", p.FailedIndices)
+	}
+}
+
+func TestNewProblem_Transport(t *testing.T) {
+	p := NewProblem(errors.NewTransportError("refused", false))
+	if p.Retryable == nil || *p.Retryable {
+		t.Fatalf("retryable: got %v", p.Retryable)
+	}
+}
+
+func TestNewProblem_Generic(t *testing.T) {
+	p := NewProblem(fmt.Errorf("boom"))
+	if p.Type != "about:blank" || p.Title != "Internal Server Error" {
+		t.Fatalf("got %+v", p)
+	}
+}
+
+func TestToProblemJSON(t *testing.T) {
+	data, err := ToProblemJSON(errors.NewConfigError("missing endpoint"))
+	if err != nil {
+		t.Fatalf("ToProblemJSON: %v", err)
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if m["status"].(float64) != 400 || m["detail"] != "missing endpoint" {
+		t.Fatalf("got %s", data)
+	}
+	if _, ok := m["failed_indices"]; ok {
+		t.Fatalf("failed_indices should be omitted, got %s", data)
+	}
+}
+
+func TestWriteProblem(t *testing.T) {
+	rec := httptest.NewRecorder()
+	WriteProblem(rec, errors.NewTransportError("upstream down", true), "/v1/pipelines")
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status: got %d", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != ProblemContentType {
+		t.Fatalf("content type: got %q", ct)
+	}
+	var p Problem
+	if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if p.Instance != "/v1/pipelines" || p.Type != ProblemTypeTransport {
+		t.Fatalf("got %+v", p)
+	}
+}
+
+func TestStatusCode_Throttling(t *testing.T) {
+	if got := StatusCode(errors.NewRateLimitError("slow", time.Second)); got != http.StatusTooManyRequests {
+		t.Fatalf("rate limit: got %d", got)
+	}
+	if got := StatusCode(errors.NewBackpressureError("full", 10, 0)); got != http.StatusTooManyRequests {
+		t.Fatalf("backpressure: got %d", got)
+	}
+}
+
+func TestWriteProblem_RetryAfter(t *testing.T) {
+	rec := httptest.NewRecorder()
+	WriteProblem(rec, errors.NewBackpressureError("queue full", 512, 1500*time.Millisecond), "")
+
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("status: got %d", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "2" {
+		t.Fatalf("Retry-After: got %q", got)
+	}
+	var p Problem
+	if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if p.QueueDepth != 512 || p.RetryAfterMs != 1500 || p.Type != ProblemTypeBackpressure {
+		t.Fatalf("got %+v", p)
+	}
+}
+
+// doneContext returns a context that is already canceled, or past its
+// deadline if expired.
+func doneContext(expired bool) context.Context {
+	var (
+		ctx    context.Context
+		cancel context.CancelFunc
+	)
+	if expired {
+		ctx, cancel = context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	} else {
+		ctx, cancel = context.WithCancel(context.Background())
+	}
+	cancel()
+	return ctx
+}
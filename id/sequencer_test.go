@@ -0,0 +1,166 @@
+package id
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/planx-lab/planx-common/clock"
+	"github.com/planx-lab/planx-common/errors"
+)
+
+func newTestSequencer(t *testing.T, node int64) (*Sequencer, *clock.Fake) {
+	t.Helper()
+	clk := clock.NewFake(DefaultEpoch.Add(time.Hour))
+	s, err := NewSequencer(node, WithClock(clk))
+	if err != nil {
+		t.Fatalf("NewSequencer: %v", err)
+	}
+	return s, clk
+}
+
+func TestSequencer_Layout(t *testing.T) {
+	s, clk := newTestSequencer(t, 42)
+	id, err := s.Next()
+	if err != nil {
+		t.Fatalf("Next: %v", err)
+	}
+	at, node, seq := s.Decompose(id)
+	if !at.Equal(clk.Now()) || node != 42 || seq != 0 {
+		t.Fatalf("got %v, %d, %d", at, node, seq)
+	}
+	next, _ := s.Next()
+	if _, _, seq := s.Decompose(next); seq != 1 || next <= id {
+		t.Fatalf("second ID %d: seq %d", next, seq)
+	}
+}
+
+func TestSequencer_SequenceExhausted(t *testing.T) {
+	s, clk := newTestSequencer(t, 1)
+	start := clk.Now()
+	go func() {
+		// The ID after the last of the millisecond waits for the next one.
+		clk.BlockUntil(1)
+		clk.Add(time.Millisecond)
+	}()
+	prev := int64(-1)
+	for i := 0; i <= maxSeq+1; i++ {
+		id, err := s.Next()
+		if err != nil || id <= prev {
+			t.Fatalf("ID %d: %d after %d, %v", i, id, prev, err)
+		}
+		prev = id
+	}
+	at, _, seq := s.Decompose(prev)
+	if !at.Equal(start.Add(time.Millisecond)) || seq != 0 {
+		t.Fatalf("got %v, seq %d; want the next millisecond", at, seq)
+	}
+}
+
+func TestSequencer_ClockRegression(t *testing.T) {
+	s, clk := newTestSequencer(t, 1)
+	first, _ := s.Next()
+
+	clk.Add(-50 * time.Millisecond)
+	go func() {
+		clk.BlockUntil(1)
+		clk.Add(50 * time.Millisecond)
+	}()
+	second, err := s.Next()
+	firstAt, _, _ := s.Decompose(first)
+	secondAt, _, _ := s.Decompose(second)
+	if err != nil || second <= first || !secondAt.Equal(firstAt) {
+		t.Fatalf("small regression: got %d after %d, %v", second, first, err)
+	}
+
+	clk.Add(-time.Second)
+	if _, err := s.Next(); errors.CodeOf(err) != CodeClockRegression {
+		t.Fatalf("large regression: got %v", err)
+	}
+}
+
+func TestSequencer_Concurrent(t *testing.T) {
+	s, err := NewSequencer(7)
+	if err != nil {
+		t.Fatalf("NewSequencer: %v", err)
+	}
+	var (
+		mu   sync.Mutex
+		seen = map[int64]bool{}
+		wg   sync.WaitGroup
+	)
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				id, err := s.Next()
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				mu.Lock()
+				if seen[id] {
+					t.Errorf("duplicate ID %d", id)
+				}
+				seen[id] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestNewSequencer_NodeRange(t *testing.T) {
+	for _, node := range []int64{-1, MaxNode + 1} {
+		if _, err := NewSequencer(node); errors.CodeOf(err) != errors.CodeConfigInvalid {
+			t.Fatalf("node %d: got %v", node, err)
+		}
+	}
+}
+
+func TestNodeHelpers(t *testing.T) {
+	if n, err := NodeFromOrdinal("planx-engine-12"); err != nil || n != 12 {
+		t.Fatalf("ordinal: got %d, %v", n, err)
+	}
+	for _, host := range []string{"engine", "engine-a", "engine-5000"} {
+		if _, err := NodeFromOrdinal(host); err == nil {
+			t.Fatalf("%q: expected an error", host)
+		}
+	}
+
+	t.Setenv("PLANX_NODE_ID", "17")
+	if n, err := NodeFromEnv("PLANX_NODE_ID"); err != nil || n != 17 {
+		t.Fatalf("env: got %d, %v", n, err)
+	}
+	t.Setenv("PLANX_NODE_ID", "4096")
+	if _, err := NodeFromEnv("PLANX_NODE_ID"); err == nil {
+		t.Fatal("env: expected an out-of-range error")
+	}
+	if _, err := NodeFromEnv("PLANX_UNSET_NODE_ID"); err == nil {
+		t.Fatal("env: expected an error for an unset variable")
+	}
+
+	if n := NodeFromIP(net.ParseIP("10.0.7.200")); n != (7<<8|200)&MaxNode {
+		t.Fatalf("ip: got %d", n)
+	}
+	if a, b := NodeFromHash("pod-a"), NodeFromHash("pod-a"); a != b || a > MaxNode {
+		t.Fatalf("hash: got %d, %d", a, b)
+	}
+}
+
+func BenchmarkSequencer(b *testing.B) {
+	s, _ := NewSequencer(1)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _ = s.Next()
+	}
+}
+
+func BenchmarkULID(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = NewULID().String()
+	}
+}
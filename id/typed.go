@@ -0,0 +1,71 @@
+package id
+
+import "regexp"
+
+// Session identifies a pipeline session. It is a ULID, so sessions sort by
+// start time.
+type Session struct{ ULID }
+
+// NewSession returns a new session ID.
+func NewSession() Session { return Session{NewULID()} }
+
+// ParseSession parses a session ID.
+func ParseSession(s string) (Session, error) {
+	u, err := ParseULID(s)
+	return Session{u}, err
+}
+
+// Batch identifies a batch. It is a ULID, so batches sort by creation time.
+type Batch struct{ ULID }
+
+// NewBatch returns a new batch ID.
+func NewBatch() Batch { return Batch{NewULID()} }
+
+// ParseBatch parses a batch ID.
+func ParseBatch(s string) (Batch, error) {
+	u, err := ParseULID(s)
+	return Batch{u}, err
+}
+
+// Tenant identifies a tenant. Unlike the generated IDs it is chosen by an
+// operator: 1 to 63 lowercase letters, digits and hyphens, starting with a
+// letter or digit, so it is safe in metric labels, paths and hostnames.
+type Tenant string
+
+var tenantPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+// ParseTenant validates s as a tenant ID.
+func ParseTenant(s string) (Tenant, error) {
+	t := Tenant(s)
+	return t, t.Validate()
+}
+
+// Validate reports whether t is well formed.
+func (t Tenant) Validate() error {
+	if !tenantPattern.MatchString(string(t)) {
+		return invalid("tenant", string(t), "must be 1-63 lowercase letters, digits or hyphens, not starting with a hyphen")
+	}
+	return nil
+}
+
+// String returns t as a string.
+func (t Tenant) String() string { return string(t) }
+
+// MarshalText implements encoding.TextMarshaler. It fails for a malformed
+// tenant so one is never written out.
+func (t Tenant) MarshalText() ([]byte, error) {
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+	return []byte(t), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (t *Tenant) UnmarshalText(text []byte) error {
+	parsed, err := ParseTenant(string(text))
+	if err != nil {
+		return err
+	}
+	*t = parsed
+	return nil
+}
@@ -0,0 +1,54 @@
+package id
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/planx-lab/planx-common/errors"
+)
+
+func TestTyped_JSON(t *testing.T) {
+	type ref struct {
+		Session Session `json:"session"`
+		Batch   Batch   `json:"batch"`
+		Tenant  Tenant  `json:"tenant"`
+	}
+	in := ref{Session: NewSession(), Batch: NewBatch(), Tenant: "acme-eu"}
+	data, err := json.Marshal(in)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	var out ref
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if out != in {
+		t.Fatalf("got %+v, want %+v", out, in)
+	}
+	if s, _ := ParseSession(in.Session.String()); s != in.Session {
+		t.Fatalf("ParseSession: got %v", s)
+	}
+	if b, _ := ParseBatch(in.Batch.String()); b != in.Batch {
+		t.Fatalf("ParseBatch: got %v", b)
+	}
+
+	if err := json.Unmarshal([]byte(`{"session":"nope"}`), &out); errors.CodeOf(err) != CodeInvalid {
+		t.Fatalf("bad session: got %v", err)
+	}
+	if _, err := json.Marshal(ref{Tenant: "Bad Tenant"}); errors.CodeOf(err) != CodeInvalid {
+		t.Fatalf("bad tenant: got %v", err)
+	}
+}
+
+func TestTenant(t *testing.T) {
+	for _, s := range []string{"acme", "a", "tenant-42", "0xdead"} {
+		if _, err := ParseTenant(s); err != nil {
+			t.Fatalf("%q: %v", s, err)
+		}
+	}
+	for _, s := range []string{"", "-acme", "Acme", "ac_me", "a.b", string(make([]byte, 64))} {
+		if _, err := ParseTenant(s); errors.CodeOf(err) != CodeInvalid {
+			t.Fatalf("%q: got %v", s, err)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+// Package id generates and validates the identifiers used across the
+// engine: monotonic ULIDs and UUIDv7s, and typed session, batch and tenant
+// IDs built on them.
+// Engine-side utilities only — must not be imported by SDK or plugins.
+package id
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/planx-lab/planx-common/clock"
+	"github.com/planx-lab/planx-common/errors"
+)
+
+// CodeInvalid is the code of errors for malformed IDs.
+const CodeInvalid errors.Code = "PLX-ID-INVALID"
+
+func init() {
+	errors.RegisterCode(errors.CodeInfo{Code: CodeInvalid, Description: "identifier is malformed"})
+}
+
+// ULID is a Universally Unique Lexicographically Sortable Identifier: a
+// 48-bit millisecond timestamp followed by 80 bits of entropy, written as
+// 26 Crockford base32 characters. ULIDs from this package are monotonic:
+// within a process each is greater than the one before.
+type ULID [16]byte
+
+// NewULID returns a new ULID for the current time.
+func NewULID() ULID {
+	ms, hi, lo := ulids.next(80)
+	var u ULID
+	putMillis(u[:], ms)
+	binary.BigEndian.PutUint16(u[6:], hi)
+	binary.BigEndian.PutUint64(u[8:], lo)
+	return u
+}
+
+// ULIDAt returns the ULID for t with the given entropy, for IDs derived
+// from content rather than drawn at random. It is not monotonic.
+func ULIDAt(t time.Time, entropy [10]byte) ULID {
+	var u ULID
+	putMillis(u[:], uint64(t.UnixMilli()))
+	copy(u[6:], entropy[:])
+	return u
+}
+
+// crockford is the Crockford base32 alphabet.
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// String returns the 26-character form of u.
+func (u ULID) String() string {
+	var dst [26]byte
+	// 128 bits in 26 characters: the first carries the top 3 bits.
+	hi := binary.BigEndian.Uint64(u[:8])
+	lo := binary.BigEndian.Uint64(u[8:])
+	for i := 25; i >= 0; i-- {
+		dst[i] = crockford[lo&31]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(dst[:])
+}
+
+// ParseULID parses the 26-character form of a ULID, case-insensitively.
+func ParseULID(s string) (ULID, error) {
+	var u ULID
+	if len(s) != 26 {
+		return u, invalid("ULID", s, "must be 26 characters")
+	}
+	var hi, lo uint64
+	for i := 0; i < 26; i++ {
+		v := decodeCrockford(s[i])
+		if v == 0xff {
+			return u, invalid("ULID", s, "has invalid characters")
+		}
+		if i == 0 && v > 7 {
+			return u, invalid("ULID", s, "overflows 128 bits")
+		}
+		hi = hi<<5 | lo>>59
+		lo = lo<<5 | uint64(v)
+	}
+	binary.BigEndian.PutUint64(u[:8], hi)
+	binary.BigEndian.PutUint64(u[8:], lo)
+	return u, nil
+}
+
+func decodeCrockford(c byte) byte {
+	switch {
+	case c >= '0' && c <= '9':
+		return c - '0'
+	case c >= 'a' && c <= 'z':
+		c -= 'a' - 'A'
+	}
+	switch c {
+	case 'O':
+		return 0
+	case 'I', 'L':
+		return 1
+	}
+	for i := 10; i < len(crockford); i++ {
+		if crockford[i] == c {
+			return byte(i)
+		}
+	}
+	return 0xff
+}
+
+// Time returns the timestamp encoded in u.
+func (u ULID) Time() time.Time { return millisTime(u[:]) }
+
+// IsZero reports whether u is the zero ULID.
+func (u ULID) IsZero() bool { return u == ULID{} }
+
+// MarshalText implements encoding.TextMarshaler.
+func (u ULID) MarshalText() ([]byte, error) { return []byte(u.String()), nil }
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (u *ULID) UnmarshalText(text []byte) error {
+	parsed, err := ParseULID(string(text))
+	if err != nil {
+		return err
+	}
+	*u = parsed
+	return nil
+}
+
+// generator hands out monotonic (millisecond, entropy) pairs: a new
+// millisecond draws fresh entropy, and repeats within one increment it.
+type generator struct {
+	clock clock.Clock
+	rand  io.Reader
+
+	mu     sync.Mutex
+	ms     uint64
+	hi, lo uint64 // entropy, up to 80 bits
+}
+
+var (
+	ulids = &generator{clock: clock.Real, rand: rand.Reader}
+	uuids = &generator{clock: clock.Real, rand: rand.Reader}
+)
+
+// next returns the timestamp and the top and bottom of bits of entropy.
+func (g *generator) next(bits uint) (ms uint64, hi uint16, lo uint64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	hiMask := uint64(1)<<(bits-64) - 1
+	now := uint64(g.clock.Now().UnixMilli())
+	if now > g.ms {
+		g.ms = now
+		g.reseed(hiMask)
+	} else {
+		// Same millisecond or a clock step back: count up from the last
+		// value. On overflow, borrow the next millisecond.
+		g.lo++
+		if g.lo == 0 {
+			g.hi++
+		}
+		if g.hi > hiMask {
+			g.ms++
+			g.reseed(hiMask)
+		}
+	}
+	return g.ms, uint16(g.hi), g.lo
+}
+
+func (g *generator) reseed(hiMask uint64) {
+	var b [10]byte
+	if _, err := io.ReadFull(g.rand, b[:]); err != nil {
+		panic("id: reading entropy: " + err.Error())
+	}
+	// Leave headroom for increments within the millisecond.
+	g.hi = uint64(binary.BigEndian.Uint16(b[:2])) & hiMask >> 1
+	g.lo = binary.BigEndian.Uint64(b[2:])
+}
+
+func putMillis(b []byte, ms uint64) {
+	b[0], b[1], b[2] = byte(ms>>40), byte(ms>>32), byte(ms>>24)
+	b[3], b[4], b[5] = byte(ms>>16), byte(ms>>8), byte(ms)
+}
+
+func millisTime(b []byte) time.Time {
+	ms := uint64(b[0])<<40 | uint64(b[1])<<32 | uint64(b[2])<<24 |
+		uint64(b[3])<<16 | uint64(b[4])<<8 | uint64(b[5])
+	return time.UnixMilli(int64(ms))
+}
+
+func invalid(kind, s, reason string) error {
+	if len(s) > 64 {
+		s = s[:64] + "..."
+	}
+	return errors.NewWithCode(CodeInvalid, fmt.Sprintf("id: invalid %s %q: %s", kind, s, reason))
+}
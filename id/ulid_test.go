@@ -0,0 +1,96 @@
+package id
+
+import (
+	"crypto/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/planx-lab/planx-common/clock"
+	"github.com/planx-lab/planx-common/errors"
+)
+
+// withClock makes the package generators read the time from a clock.Fake
+// set to now.
+func withClock(t *testing.T, now time.Time) *clock.Fake {
+	t.Helper()
+	prevULIDs, prevUUIDs := ulids, uuids
+	clk := clock.NewFake(now)
+	ulids = &generator{clock: clk, rand: rand.Reader}
+	uuids = &generator{clock: clk, rand: rand.Reader}
+	t.Cleanup(func() { ulids, uuids = prevULIDs, prevUUIDs })
+	return clk
+}
+
+func TestULID_RoundTrip(t *testing.T) {
+	u := NewULID()
+	s := u.String()
+	if len(s) != 26 || strings.Trim(s, crockford) != "" {
+		t.Fatalf("got %q", s)
+	}
+	parsed, err := ParseULID(strings.ToLower(s))
+	if err != nil || parsed != u {
+		t.Fatalf("ParseULID: got %v, %v", parsed, err)
+	}
+	if max, err := ParseULID("7ZZZZZZZZZZZZZZZZZZZZZZZZZ"); err != nil || max != (ULID{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}) {
+		t.Fatalf("max: got %v, %v", max, err)
+	}
+}
+
+func TestULID_Aliases(t *testing.T) {
+	a, errA := ParseULID("01ARZ3NDEKTSV4RRFFQ69G5FAV")
+	b, errB := ParseULID("olarz3ndektsv4rrffq69g5fav")
+	if errA != nil || errB != nil || a != b {
+		t.Fatalf("got %v (%v), %v (%v)", a, errA, b, errB)
+	}
+}
+
+func TestULID_Time(t *testing.T) {
+	now := time.UnixMilli(1700000000123)
+	withClock(t, now)
+	if got := NewULID().Time(); !got.Equal(now) {
+		t.Fatalf("got %v, want %v", got, now)
+	}
+}
+
+func TestULIDAt(t *testing.T) {
+	at := time.UnixMilli(1700000000123)
+	u := ULIDAt(at, [10]byte{1, 2, 3})
+	if !u.Time().Equal(at) || u != ULIDAt(at, [10]byte{1, 2, 3}) || u == ULIDAt(at, [10]byte{1, 2, 4}) {
+		t.Fatalf("got %v", u)
+	}
+}
+
+func TestULID_Monotonic(t *testing.T) {
+	clk := withClock(t, time.UnixMilli(1700000000000))
+	prev := NewULID().String()
+	for i := 0; i < 1000; i++ {
+		if i == 500 {
+			clk.Add(-time.Second) // clock stepped back
+		}
+		next := NewULID().String()
+		if next <= prev {
+			t.Fatalf("%s not after %s", next, prev)
+		}
+		prev = next
+	}
+}
+
+func TestGenerator_Overflow(t *testing.T) {
+	now := time.UnixMilli(1700000000000)
+	g := &generator{clock: clock.NewFake(now), rand: rand.Reader}
+	g.next(80)
+	g.hi, g.lo = 0xffff, ^uint64(0)
+	ms, _, _ := g.next(80)
+	if ms != uint64(now.UnixMilli())+1 {
+		t.Fatalf("got ms %d, want the next millisecond", ms)
+	}
+}
+
+func TestParseULID_Errors(t *testing.T) {
+	for _, s := range []string{"", "01ARZ3NDEK", "01ARZ3NDEKTSV4RRFFQ69G5FAU", "81ARZ3NDEKTSV4RRFFQ69G5FAV"} {
+		if _, err := ParseULID(s); errors.CodeOf(err) != CodeInvalid {
+			t.Fatalf("%q: got %v", s, err)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package id
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"time"
+)
+
+// UUID is an RFC 9562 UUID. NewUUIDv7 generates version 7 UUIDs, which
+// start with a millisecond timestamp and so sort by creation time; ParseUUID
+// accepts any version.
+type UUID [16]byte
+
+// NewUUIDv7 returns a new version 7 UUID for the current time. Like ULIDs,
+// they are monotonic within a process: the 74 random bits count up for
+// UUIDs created in the same millisecond.
+func NewUUIDv7() UUID {
+	ms, hi, lo := uuids.next(74)
+	randA := uint16(hi)<<2 | uint16(lo>>62) // top 12 of the 74 bits
+	var u UUID
+	putMillis(u[:], ms)
+	binary.BigEndian.PutUint16(u[6:], 0x7000|randA)
+	binary.BigEndian.PutUint64(u[8:], 0x8000000000000000|lo&(1<<62-1))
+	return u
+}
+
+// String returns the canonical form, e.g.
+// "018f3a5c-7b2e-7c4d-9e1f-0a1b2c3d4e5f".
+func (u UUID) String() string {
+	var dst [36]byte
+	hex.Encode(dst[0:8], u[0:4])
+	dst[8] = '-'
+	hex.Encode(dst[9:13], u[4:6])
+	dst[13] = '-'
+	hex.Encode(dst[14:18], u[6:8])
+	dst[18] = '-'
+	hex.Encode(dst[19:23], u[8:10])
+	dst[23] = '-'
+	hex.Encode(dst[24:], u[10:])
+	return string(dst[:])
+}
+
+// ParseUUID parses the canonical form of a UUID, in either case.
+func ParseUUID(s string) (UUID, error) {
+	var u UUID
+	if len(s) != 36 || s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+		return u, invalid("UUID", s, "must be in the 8-4-4-4-12 form")
+	}
+	groups := [][2]int{{0, 8}, {9, 13}, {14, 18}, {19, 23}, {24, 36}}
+	n := 0
+	for _, g := range groups {
+		m, err := hex.Decode(u[n:], []byte(s[g[0]:g[1]]))
+		if err != nil {
+			return UUID{}, invalid("UUID", s, "has invalid characters")
+		}
+		n += m
+	}
+	return u, nil
+}
+
+// Version returns the UUID version, 7 for UUIDs from NewUUIDv7.
+func (u UUID) Version() int { return int(u[6] >> 4) }
+
+// Time returns the timestamp of a version 7 UUID, or the zero time for
+// other versions.
+func (u UUID) Time() time.Time {
+	if u.Version() != 7 {
+		return time.Time{}
+	}
+	return millisTime(u[:])
+}
+
+// IsZero reports whether u is the nil UUID.
+func (u UUID) IsZero() bool { return u == UUID{} }
+
+// MarshalText implements encoding.TextMarshaler.
+func (u UUID) MarshalText() ([]byte, error) { return []byte(u.String()), nil }
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (u *UUID) UnmarshalText(text []byte) error {
+	parsed, err := ParseUUID(string(text))
+	if err != nil {
+		return err
+	}
+	*u = parsed
+	return nil
+}
@@ -0,0 +1,54 @@
+package id
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/planx-lab/planx-common/errors"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestUUIDv7(t *testing.T) {
+	now := time.UnixMilli(1700000000123)
+	withClock(t, now)
+	u := NewUUIDv7()
+	if !uuidPattern.MatchString(u.String()) {
+		t.Fatalf("got %s", u)
+	}
+	if u.Version() != 7 || !u.Time().Equal(now) {
+		t.Fatalf("version %d, time %v", u.Version(), u.Time())
+	}
+	parsed, err := ParseUUID(u.String())
+	if err != nil || parsed != u {
+		t.Fatalf("ParseUUID: got %v, %v", parsed, err)
+	}
+}
+
+func TestUUIDv7_Monotonic(t *testing.T) {
+	withClock(t, time.UnixMilli(1700000000000))
+	prev := NewUUIDv7().String()
+	for i := 0; i < 1000; i++ {
+		next := NewUUIDv7().String()
+		if next <= prev || !uuidPattern.MatchString(next) {
+			t.Fatalf("%s not after %s", next, prev)
+		}
+		prev = next
+	}
+}
+
+func TestParseUUID(t *testing.T) {
+	u, err := ParseUUID("123E4567-E89B-12D3-A456-426614174000")
+	if err != nil || u.Version() != 1 || !u.Time().IsZero() {
+		t.Fatalf("got %v, %v", u, err)
+	}
+	if u.String() != "123e4567-e89b-12d3-a456-426614174000" {
+		t.Fatalf("got %s", u)
+	}
+	for _, s := range []string{"", "123e4567e89b12d3a456426614174000", "123e4567-e89b-12d3-a456-42661417400g"} {
+		if _, err := ParseUUID(s); errors.CodeOf(err) != CodeInvalid {
+			t.Fatalf("%q: got %v", s, err)
+		}
+	}
+}
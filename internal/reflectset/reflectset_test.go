@@ -0,0 +1,90 @@
+package reflectset
+
+import (
+	"net/netip"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type target struct {
+	Name    string
+	On      bool
+	Count   int8
+	Size    uint
+	Ratio   float64
+	Timeout time.Duration
+	Hosts   []string
+	Port    *int
+	Addr    netip.Addr
+}
+
+func TestSet(t *testing.T) {
+	var got target
+	rv := reflect.ValueOf(&got).Elem()
+	for field, s := range map[string]string{
+		"Name":    "sink",
+		"On":      "true",
+		"Count":   "0x10",
+		"Size":    "7",
+		"Ratio":   "0.5",
+		"Timeout": "1m30s",
+		"Hosts":   " a, b,,c ,",
+		"Port":    "8080",
+		"Addr":    "10.0.0.1",
+	} {
+		if err := Set(rv.FieldByName(field), s); err != nil {
+			t.Fatalf("%s = %q: %v", field, s, err)
+		}
+	}
+	if got.Name != "sink" || !got.On || got.Count != 16 || got.Size != 7 || got.Ratio != 0.5 ||
+		got.Timeout != 90*time.Second || strings.Join(got.Hosts, "|") != "a|b|c" ||
+		got.Port == nil || *got.Port != 8080 || got.Addr.String() != "10.0.0.1" {
+		t.Fatalf("got %+v", got)
+	}
+}
+
+func TestSet_Errors(t *testing.T) {
+	var got struct {
+		Count int8
+		Ints  []int
+		Map   map[string]string
+	}
+	rv := reflect.ValueOf(&got).Elem()
+	for field, s := range map[string]string{"Count": "300", "Ints": "1", "Map": "a"} {
+		if err := Set(rv.FieldByName(field), s); err == nil {
+			t.Fatalf("%s = %q accepted", field, s)
+		}
+	}
+}
+
+func TestSupported(t *testing.T) {
+	for _, v := range []interface{}{"", 0, uint8(0), 0.0, false, time.Second, []string{}, new(int), netip.Addr{}} {
+		if !Supported(reflect.TypeOf(v)) {
+			t.Fatalf("%T not supported", v)
+		}
+	}
+	for _, v := range []interface{}{[]int{}, map[string]string{}, struct{}{}} {
+		if Supported(reflect.TypeOf(v)) {
+			t.Fatalf("%T supported", v)
+		}
+	}
+}
+
+func TestRedact(t *testing.T) {
+	var got struct {
+		Count   int
+		Timeout time.Duration
+	}
+	rv := reflect.ValueOf(&got).Elem()
+	for field, s := range map[string]string{"Count": "s3cret", "Timeout": "s3cret"} {
+		err := Set(rv.FieldByName(field), s)
+		if err == nil || !strings.Contains(err.Error(), "s3cret") {
+			t.Fatalf("%s: Set error = %v, want it to quote the input", field, err)
+		}
+		if redacted := Redact(err); strings.Contains(redacted.Error(), "s3cret") {
+			t.Fatalf("%s: Redact = %v", field, redacted)
+		}
+	}
+}
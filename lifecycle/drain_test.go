@@ -0,0 +1,74 @@
+package lifecycle
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/planx-lab/planx-common/errors"
+)
+
+func TestDrainer(t *testing.T) {
+	d := NewDrainer("batches")
+	release, err := d.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	if d.Leases() != 1 {
+		t.Fatalf("leases: got %d", d.Leases())
+	}
+
+	drained := make(chan error, 1)
+	go func() { drained <- d.Drain(context.Background()) }()
+	for !d.Draining() {
+		time.Sleep(time.Millisecond)
+	}
+
+	_, err = d.Acquire(context.Background())
+	if errors.CodeOf(err) != CodeDraining || !errors.IsRetryable(err) {
+		t.Fatalf("Acquire while draining: got %v", err)
+	}
+	select {
+	case <-drained:
+		t.Fatal("Drain returned with a lease held")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	release()
+	release()
+	if err := <-drained; err != nil {
+		t.Fatalf("Drain: %v", err)
+	}
+	if d.Leases() != 0 {
+		t.Fatalf("leases: got %d", d.Leases())
+	}
+}
+
+func TestDrainer_Deadline(t *testing.T) {
+	d := NewDrainer("batches")
+	if _, err := d.Acquire(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := d.Drain(ctx)
+	if errors.CodeOf(err) != errors.CodeDeadlineExceeded || !strings.Contains(err.Error(), "1 leases outstanding") {
+		t.Fatalf("got %v", err)
+	}
+}
+
+func TestDrainer_WithManager(t *testing.T) {
+	d := NewDrainer("batches")
+	m := New(Config{})
+	m.OnDrain("batches", d.Drain)
+	if err := m.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if !d.Draining() {
+		t.Fatal("Stop should drain")
+	}
+}
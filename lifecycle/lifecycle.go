@@ -0,0 +1,258 @@
+// Package lifecycle coordinates the startup and shutdown of the components
+// of a Planx binary: ordered start and stop hooks, drain callbacks, signal
+// handling and a global shutdown deadline.
+// Engine-side utilities only — must not be imported by SDK or plugins.
+package lifecycle
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/planx-lab/planx-common/logger"
+)
+
+// DefaultShutdownTimeout bounds Stop when Config.ShutdownTimeout is zero.
+const DefaultShutdownTimeout = 30 * time.Second
+
+// Hook is a component managed by a Manager. Start and Stop are optional.
+//
+// Hooks start in ascending Phase order and stop in the reverse order; hooks
+// sharing a phase start and stop concurrently. A typical layout is telemetry
+// in phase 0, storage and clients in 10, servers and listeners in 20, so
+// listeners close first and telemetry flushes last.
+type Hook struct {
+	Name  string
+	Phase int
+	Start func(ctx context.Context) error
+	Stop  func(ctx context.Context) error
+}
+
+// Config configures a Manager.
+type Config struct {
+	// ShutdownTimeout bounds the whole shutdown, drain included. Zero means
+	// DefaultShutdownTimeout.
+	ShutdownTimeout time.Duration
+
+	// Signals that trigger shutdown in Run. Nil means SIGINT and SIGTERM;
+	// an empty slice means none.
+	Signals []os.Signal
+}
+
+// Manager runs hooks in order. It is safe for concurrent use.
+type Manager struct {
+	cfg Config
+
+	mu      sync.Mutex
+	hooks   []Hook
+	drains  []drainFunc
+	started []Hook // in start order; nil until Start
+
+	shutdown     chan struct{}
+	shutdownOnce sync.Once
+	stopOnce     sync.Once
+	stopErr      error
+}
+
+type drainFunc struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// New returns a Manager with no hooks.
+func New(cfg Config) *Manager {
+	if cfg.ShutdownTimeout <= 0 {
+		cfg.ShutdownTimeout = DefaultShutdownTimeout
+	}
+	if cfg.Signals == nil {
+		cfg.Signals = []os.Signal{syscall.SIGINT, syscall.SIGTERM}
+	}
+	return &Manager{cfg: cfg, shutdown: make(chan struct{})}
+}
+
+// Register adds a hook. It panics if the name is empty or already
+// registered, or if the manager has already started.
+func (m *Manager) Register(h Hook) {
+	if h.Name == "" {
+		panic("lifecycle: Register with empty name")
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.started != nil {
+		panic(fmt.Sprintf("lifecycle: hook %q registered after Start", h.Name))
+	}
+	for _, existing := range m.hooks {
+		if existing.Name == h.Name {
+			panic(fmt.Sprintf("lifecycle: hook %q registered twice", h.Name))
+		}
+	}
+	m.hooks = append(m.hooks, h)
+}
+
+// OnDrain adds a callback run at the start of shutdown, before any Stop
+// hook, while every component is still up: flipping readiness, finishing
+// in-flight sessions and the like. Drain callbacks run concurrently.
+func (m *Manager) OnDrain(name string, fn func(ctx context.Context) error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.drains = append(m.drains, drainFunc{name, fn})
+}
+
+// Start runs the Start hooks phase by phase. If one fails, the hooks
+// already started are stopped in reverse order and the error is returned.
+func (m *Manager) Start(ctx context.Context) error {
+	m.mu.Lock()
+	if m.started != nil {
+		m.mu.Unlock()
+		return stderrors.New("lifecycle: already started")
+	}
+	m.started = []Hook{}
+	phases := byPhase(m.hooks)
+	m.mu.Unlock()
+
+	for _, phase := range phases {
+		err := runPhase(ctx, phase, "start", func(h Hook) func(context.Context) error { return h.Start })
+		m.mu.Lock()
+		m.started = append(m.started, phase...)
+		m.mu.Unlock()
+		if err != nil {
+			logger.Error().Err(err).Msg("startup failed, stopping started components")
+			return stderrors.Join(err, m.Stop(context.Background()))
+		}
+	}
+	logger.Info().Int("hooks", len(m.hooks)).Msg("lifecycle started")
+	return nil
+}
+
+// Stop shuts down: it runs the drain callbacks, then the Stop hooks of the
+// started components in reverse phase order, all within ShutdownTimeout. It
+// returns the joined errors. Only the first call does the work; later ones
+// wait for it and return the same result.
+func (m *Manager) Stop(ctx context.Context) error {
+	m.Shutdown()
+	m.stopOnce.Do(func() {
+		ctx, cancel := context.WithTimeout(ctx, m.cfg.ShutdownTimeout)
+		defer cancel()
+
+		m.mu.Lock()
+		drains := append([]drainFunc(nil), m.drains...)
+		phases := byPhase(m.started)
+		m.mu.Unlock()
+
+		start := time.Now()
+		var errs []error
+		if err := runDrains(ctx, drains); err != nil {
+			errs = append(errs, err)
+		}
+		for i := len(phases) - 1; i >= 0; i-- {
+			err := runPhase(ctx, phases[i], "stop", func(h Hook) func(context.Context) error { return h.Stop })
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+		m.stopErr = stderrors.Join(errs...)
+
+		event := logger.Info()
+		if m.stopErr != nil {
+			event = logger.Error().Err(m.stopErr)
+		}
+		event.EmbedObject(logger.Dur("elapsed", time.Since(start))).Msg("lifecycle stopped")
+	})
+	return m.stopErr
+}
+
+// Shutdown asks Run to stop, e.g. after a fatal error in a component. It
+// does not wait; it is safe to call more than once.
+func (m *Manager) Shutdown() {
+	m.shutdownOnce.Do(func() { close(m.shutdown) })
+}
+
+// Stopping returns a channel closed once shutdown has been requested.
+func (m *Manager) Stopping() <-chan struct{} {
+	return m.shutdown
+}
+
+// Run starts the hooks, waits for a shutdown signal, ctx to be done or
+// Shutdown, then stops them. It returns the start error or the joined stop
+// errors.
+func (m *Manager) Run(ctx context.Context) error {
+	sigs := make(chan os.Signal, 1)
+	if len(m.cfg.Signals) > 0 {
+		signal.Notify(sigs, m.cfg.Signals...)
+		defer signal.Stop(sigs)
+	}
+
+	if err := m.Start(ctx); err != nil {
+		return err
+	}
+	select {
+	case sig := <-sigs:
+		logger.Info().Str("signal", sig.String()).Msg("shutdown signal received")
+	case <-ctx.Done():
+	case <-m.shutdown:
+	}
+	// ctx may already be done; the shutdown gets its own deadline.
+	return m.Stop(context.WithoutCancel(ctx))
+}
+
+// byPhase groups hooks by phase, in ascending phase order and registration
+// order within a phase.
+func byPhase(hooks []Hook) [][]Hook {
+	sorted := append([]Hook(nil), hooks...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Phase < sorted[j].Phase })
+	var phases [][]Hook
+	for i, h := range sorted {
+		if i == 0 || h.Phase != sorted[i-1].Phase {
+			phases = append(phases, nil)
+		}
+		phases[len(phases)-1] = append(phases[len(phases)-1], h)
+	}
+	return phases
+}
+
+// runPhase runs fn of every hook in phase concurrently and joins the errors.
+func runPhase(ctx context.Context, phase []Hook, action string, fn func(Hook) func(context.Context) error) error {
+	errs := make([]error, len(phase))
+	var wg sync.WaitGroup
+	for i, h := range phase {
+		f := fn(h)
+		if f == nil {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			if err := f(ctx); err != nil {
+				errs[i] = fmt.Errorf("lifecycle: %s %s: %w", action, h.Name, err)
+				return
+			}
+			logger.Debug().Str("hook", h.Name).Int("phase", h.Phase).
+				Dur("elapsed", time.Since(start)).Msg("lifecycle " + action)
+		}()
+	}
+	wg.Wait()
+	return stderrors.Join(errs...)
+}
+
+func runDrains(ctx context.Context, drains []drainFunc) error {
+	errs := make([]error, len(drains))
+	var wg sync.WaitGroup
+	for i, d := range drains {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := d.fn(ctx); err != nil {
+				errs[i] = fmt.Errorf("lifecycle: drain %s: %w", d.name, err)
+			}
+		}()
+	}
+	wg.Wait()
+	return stderrors.Join(errs...)
+}
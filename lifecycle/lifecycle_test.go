@@ -0,0 +1,146 @@
+package lifecycle
+
+import (
+	"context"
+	stderrors "errors"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// journal records hook calls from concurrent goroutines.
+type journal struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (j *journal) add(s string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.calls = append(j.calls, s)
+}
+
+func (j *journal) String() string {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return strings.Join(j.calls, " ")
+}
+
+func (j *journal) hook(name string, phase int) Hook {
+	return Hook{
+		Name:  name,
+		Phase: phase,
+		Start: func(context.Context) error { j.add("start:" + name); return nil },
+		Stop:  func(context.Context) error { j.add("stop:" + name); return nil },
+	}
+}
+
+func TestManager_Order(t *testing.T) {
+	j := &journal{}
+	m := New(Config{})
+	m.Register(j.hook("server", 20))
+	m.Register(j.hook("telemetry", 0))
+	m.Register(j.hook("db", 10))
+	m.OnDrain("sessions", func(context.Context) error { j.add("drain"); return nil })
+
+	if err := m.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if err := m.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	want := "start:telemetry start:db start:server drain stop:server stop:db stop:telemetry"
+	if got := j.String(); got != want {
+		t.Fatalf("got  %s\nwant %s", got, want)
+	}
+	if err := m.Stop(context.Background()); err != nil {
+		t.Fatalf("second Stop: %v", err)
+	}
+	if got := j.String(); got != want {
+		t.Fatal("second Stop should not run hooks again")
+	}
+}
+
+func TestManager_StartFailure(t *testing.T) {
+	j := &journal{}
+	m := New(Config{})
+	m.Register(j.hook("telemetry", 0))
+	m.Register(Hook{Name: "db", Phase: 10, Start: func(context.Context) error { return stderrors.New("refused") }})
+	m.Register(j.hook("server", 20))
+
+	err := m.Start(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "lifecycle: start db: refused") {
+		t.Fatalf("got %v", err)
+	}
+	if got := j.String(); got != "start:telemetry stop:telemetry" {
+		t.Fatalf("got %s", got)
+	}
+}
+
+func TestManager_ShutdownTimeout(t *testing.T) {
+	m := New(Config{ShutdownTimeout: 20 * time.Millisecond})
+	m.Register(Hook{Name: "slow", Stop: func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}})
+	if err := m.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	err := m.Stop(context.Background())
+	if !stderrors.Is(err, context.DeadlineExceeded) || time.Since(start) > time.Second {
+		t.Fatalf("got %v after %v", err, time.Since(start))
+	}
+}
+
+func TestManager_Run(t *testing.T) {
+	tests := []struct {
+		name    string
+		trigger func(m *Manager)
+	}{
+		{"signal", func(*Manager) { _ = syscall.Kill(os.Getpid(), syscall.SIGUSR1) }},
+		{"shutdown", func(m *Manager) { m.Shutdown() }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			j := &journal{}
+			m := New(Config{Signals: []os.Signal{syscall.SIGUSR1}})
+			m.Register(j.hook("server", 0))
+			started := make(chan struct{})
+			m.Register(Hook{Name: "ready", Phase: 1, Start: func(context.Context) error {
+				close(started)
+				return nil
+			}})
+
+			done := make(chan error, 1)
+			go func() { done <- m.Run(context.Background()) }()
+			<-started
+			tt.trigger(m)
+			select {
+			case err := <-done:
+				if err != nil {
+					t.Fatalf("Run: %v", err)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("Run did not return")
+			}
+			if got := j.String(); got != "start:server stop:server" {
+				t.Fatalf("got %s", got)
+			}
+		})
+	}
+}
+
+func TestManager_Register_Panics(t *testing.T) {
+	m := New(Config{})
+	m.Register(Hook{Name: "a"})
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic for a duplicate hook")
+		}
+	}()
+	m.Register(Hook{Name: "a"})
+}
@@ -0,0 +1,56 @@
+package logger
+
+import (
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Field suffixes used by the helpers below. Dashboards rely on these to know
+// the unit of a field without guessing.
+const (
+	DurationSuffix = "_ms"
+	BytesSuffix    = "_bytes"
+)
+
+// Field is a unit-normalized log field. It embeds into events or contexts:
+//
+//	logger.Info().EmbedObject(logger.Dur("latency", d)).Msg("batch sent")
+type Field func(e *zerolog.Event)
+
+// MarshalZerologObject implements zerolog.LogObjectMarshaler.
+func (f Field) MarshalZerologObject(e *zerolog.Event) {
+	f(e)
+}
+
+// Dur emits d in milliseconds as "<key>_ms".
+func Dur(key string, d time.Duration) Field {
+	key = withSuffix(key, DurationSuffix)
+	ms := float64(d) / float64(time.Millisecond)
+	return func(e *zerolog.Event) {
+		e.Float64(key, ms)
+	}
+}
+
+// Bytes emits n as "<key>_bytes".
+func Bytes(key string, n int64) Field {
+	key = withSuffix(key, BytesSuffix)
+	return func(e *zerolog.Event) {
+		e.Int64(key, n)
+	}
+}
+
+// Records emits the record count n as "records".
+func Records(n int) Field {
+	return func(e *zerolog.Event) {
+		e.Int("records", n)
+	}
+}
+
+func withSuffix(key, suffix string) string {
+	if strings.HasSuffix(key, suffix) {
+		return key
+	}
+	return key + suffix
+}
@@ -0,0 +1,55 @@
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+func TestDur(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l := zerolog.New(buf)
+	l.Info().EmbedObject(Dur("latency", 1500*time.Microsecond)).Msg("")
+	if !strings.Contains(buf.String(), `"latency_ms":1.5`) {
+		t.Fatalf("got %s", buf.String())
+	}
+}
+
+func TestDur_SuffixNotDuplicated(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l := zerolog.New(buf)
+	l.Info().EmbedObject(Dur("latency_ms", 2*time.Millisecond)).Msg("")
+	if !strings.Contains(buf.String(), `"latency_ms":2`) || strings.Contains(buf.String(), "_ms_ms") {
+		t.Fatalf("got %s", buf.String())
+	}
+}
+
+func TestBytes(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l := zerolog.New(buf)
+	l.Info().EmbedObject(Bytes("payload", 4096)).Msg("")
+	if !strings.Contains(buf.String(), `"payload_bytes":4096`) {
+		t.Fatalf("got %s", buf.String())
+	}
+}
+
+func TestRecords(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l := zerolog.New(buf)
+	l.Info().EmbedObject(Records(250)).Msg("")
+	if !strings.Contains(buf.String(), `"records":250`) {
+		t.Fatalf("got %s", buf.String())
+	}
+}
+
+func TestField_Context(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l := zerolog.New(buf).With().EmbedObject(Bytes("max_batch", 1024)).Logger()
+	l.Info().Msg("")
+	if !strings.Contains(buf.String(), `"max_batch_bytes":1024`) {
+		t.Fatalf("got %s", buf.String())
+	}
+}
@@ -20,6 +20,12 @@ import (
 
 var (
 	globalLogger zerolog.Logger
+	baseLogger   zerolog.Logger // globalLogger without the global fields
+	globalFields map[string]interface{}
+	localOutput  io.Writer    // formatted local output
+	loggerCfg    Config       // config the base logger was built from
+	otelExport   *otelWriter  // optional OTLP mirror, see EnableOTelExport
+	loggerMu     sync.RWMutex // protects all of the above
 	once         sync.Once
 )
 
@@ -29,6 +35,8 @@ type Config struct {
 	Pretty      bool   // human-readable output (for development)
 	Output      io.Writer
 	ServiceName string
+	SpanEvents  bool                   // mirror warn/error context logs as span events
+	Fields      map[string]interface{} // static fields added to every log line
 }
 
 // DefaultConfig returns sensible defaults.
@@ -57,6 +65,8 @@ func initLogger(cfg Config) {
 	zerolog.SetGlobalLevel(level)
 	zerolog.TimeFieldFormat = time.RFC3339Nano
 
+	registerOutput(cfg.Output)
+
 	var output io.Writer = cfg.Output
 	if cfg.Pretty {
 		output = zerolog.ConsoleWriter{
@@ -65,14 +75,93 @@ func initLogger(cfg Config) {
 		}
 	}
 
-	globalLogger = zerolog.New(output).
+	fields := make(map[string]interface{}, len(cfg.Fields))
+	for k, v := range cfg.Fields {
+		fields[k] = v
+	}
+
+	loggerMu.Lock()
+	localOutput = output
+	loggerCfg = cfg
+	globalFields = fields
+	buildBaseLocked()
+	loggerMu.Unlock()
+}
+
+// buildBaseLocked derives baseLogger from localOutput, loggerCfg and
+// otelExport, then rebuilds globalLogger. Callers must hold loggerMu.
+func buildBaseLocked() {
+	w := localOutput
+	if otelExport != nil {
+		w = zerolog.MultiLevelWriter(localOutput, otelExport)
+	}
+
+	base := zerolog.New(w).
 		With().
 		Timestamp().
-		Str("service", cfg.ServiceName).
+		Str("service", loggerCfg.ServiceName).
 		Logger()
+
+	if loggerCfg.SpanEvents {
+		base = base.Hook(spanEventHook{})
+	}
+
+	baseLogger = base
+	rebuildLocked()
+}
+
+// rebuildLocked derives globalLogger from baseLogger and globalFields.
+// Callers must hold loggerMu.
+func rebuildLocked() {
+	if len(globalFields) == 0 {
+		globalLogger = baseLogger
+		return
+	}
+	globalLogger = baseLogger.With().Fields(globalFields).Logger()
+}
+
+// SetOutput replaces the local output with w, bypassing the Pretty console
+// formatting, and returns a function restoring the previous output. It is
+// intended for tests; see the logtest package.
+func SetOutput(w io.Writer) (restore func()) {
+	Get()
+
+	loggerMu.Lock()
+	defer loggerMu.Unlock()
+	prev := localOutput
+	localOutput = w
+	buildBaseLocked()
+
+	return func() {
+		loggerMu.Lock()
+		defer loggerMu.Unlock()
+		localOutput = prev
+		buildBaseLocked()
+	}
+}
+
+// SetGlobalFields adds static fields (build version, instance ID, region, ...)
+// to every subsequent log line. Existing keys are overwritten; a nil value
+// removes the key.
+func SetGlobalFields(fields map[string]interface{}) {
+	Get()
+
+	loggerMu.Lock()
+	defer loggerMu.Unlock()
+	if globalFields == nil {
+		globalFields = make(map[string]interface{}, len(fields))
+	}
+	for k, v := range fields {
+		if v == nil {
+			delete(globalFields, k)
+			continue
+		}
+		globalFields[k] = v
+	}
+	rebuildLocked()
 }
 
-// Get returns the global logger.
+// Get returns a snapshot of the global logger.
 // Auto-initializes with defaults if Init has not been called.
 func Get() *zerolog.Logger {
 	once.Do(func() {
@@ -80,14 +169,18 @@ func Get() *zerolog.Logger {
 			initLogger(DefaultConfig())
 		}
 	})
-	return &globalLogger
+	loggerMu.RLock()
+	l := globalLogger
+	loggerMu.RUnlock()
+	return &l
 }
 
 // WithContext returns a logger with OpenTelemetry trace context fields.
-// Automatically extracts trace_id and span_id from the context if present.
+// Automatically extracts trace_id and span_id from the context if present,
+// along with any fields added by ContextWithFields.
 // This enables log correlation with distributed traces.
 func WithContext(ctx context.Context) *zerolog.Logger {
-	l := Get().With().Logger()
+	l := Get().With().Ctx(ctx).Logger()
 
 	// Extract OpenTelemetry trace context
 	span := trace.SpanFromContext(ctx)
@@ -97,10 +190,31 @@ func WithContext(ctx context.Context) *zerolog.Logger {
 	if span.SpanContext().HasSpanID() {
 		l = l.With().Str("span_id", span.SpanContext().SpanID().String()).Logger()
 	}
+	if fields, _ := ctx.Value(fieldsKey{}).(map[string]interface{}); len(fields) > 0 {
+		l = l.With().Fields(fields).Logger()
+	}
 
 	return &l
 }
 
+type fieldsKey struct{}
+
+// ContextWithFields returns a copy of ctx carrying fields that WithContext,
+// and so the *Ctx helpers, add to every log line, for request-scoped values
+// such as a request or tenant ID. Fields already in ctx are kept unless
+// overwritten.
+func ContextWithFields(ctx context.Context, fields map[string]interface{}) context.Context {
+	prev, _ := ctx.Value(fieldsKey{}).(map[string]interface{})
+	merged := make(map[string]interface{}, len(prev)+len(fields))
+	for k, v := range prev {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, fieldsKey{}, merged)
+}
+
 // ContextWithTrace returns a context with trace information embedded (legacy support).
 // Prefer using OpenTelemetry context propagation instead.
 func ContextWithTrace(ctx context.Context, traceID, spanID string) context.Context {
@@ -188,3 +302,23 @@ func AddSpanEventWithAttrs(ctx context.Context, msg string, attrs map[string]str
 	}
 	span.AddEvent(msg, trace.WithAttributes(kv...))
 }
+
+// spanEventHook mirrors warn and error events onto the span carried by the
+// event context, so WarnCtx/ErrorCtx calls show up on the trace without a
+// separate AddSpanEvent call.
+type spanEventHook struct{}
+
+// Run implements zerolog.Hook.
+func (spanEventHook) Run(e *zerolog.Event, level zerolog.Level, msg string) {
+	if level < zerolog.WarnLevel || level >= zerolog.NoLevel {
+		return
+	}
+	span := trace.SpanFromContext(e.GetCtx())
+	if !span.IsRecording() {
+		return
+	}
+	span.AddEvent(msg, trace.WithAttributes(
+		attribute.String("log.level", level.String()),
+		attribute.String("log.message", msg),
+	))
+}
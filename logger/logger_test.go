@@ -3,12 +3,14 @@ package logger
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"os"
 	"strings"
 	"testing"
 
 	"go.opentelemetry.io/otel"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 func TestInit(t *testing.T) {
@@ -119,6 +121,24 @@ func TestContextWithTrace(t *testing.T) {
 	}
 }
 
+func TestContextWithFields(t *testing.T) {
+	var buf bytes.Buffer
+	restore := SetOutput(&buf)
+	defer restore()
+
+	ctx := ContextWithFields(context.Background(), map[string]interface{}{"request_id": "r1", "tenant_id": "t1"})
+	ctx = ContextWithFields(ctx, map[string]interface{}{"tenant_id": "t2"})
+	WarnCtx(ctx).Msg("with fields")
+
+	var m map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+		t.Fatalf("unmarshal %q: %v", buf.String(), err)
+	}
+	if m["request_id"] != "r1" || m["tenant_id"] != "t2" {
+		t.Fatalf("got %s", buf.String())
+	}
+}
+
 func TestAddSpanEventWithAttrs(t *testing.T) {
 	provider := sdktrace.NewTracerProvider()
 	otel.SetTracerProvider(provider)
@@ -152,3 +172,146 @@ func TestGet_AutoInit(t *testing.T) {
 		t.Fatal("Get returned nil")
 	}
 }
+
+func TestSpanEvents_MirrorsWarnAndError(t *testing.T) {
+	restoreLogger(t)
+
+	initLogger(Config{
+		Level:       "debug",
+		Output:      &bytes.Buffer{},
+		ServiceName: "test-service",
+		SpanEvents:  true,
+	})
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	ctx, span := provider.Tracer("test").Start(context.Background(), "test-span")
+
+	InfoCtx(ctx).Msg("info is not mirrored")
+	WarnCtx(ctx).Msg("slow sink")
+	ErrorCtx(ctx).Msg("sink failed")
+	span.End()
+
+	ended := recorder.Ended()
+	if len(ended) != 1 {
+		t.Fatalf("ended spans: got %d, want 1", len(ended))
+	}
+	events := ended[0].Events()
+	if len(events) != 2 {
+		t.Fatalf("events: got %d, want 2", len(events))
+	}
+	if events[0].Name != "slow sink" || events[1].Name != "sink failed" {
+		t.Fatalf("event names: got %q, %q", events[0].Name, events[1].Name)
+	}
+	var level string
+	for _, kv := range events[1].Attributes {
+		if kv.Key == "log.level" {
+			level = kv.Value.AsString()
+		}
+	}
+	if level != "error" {
+		t.Fatalf("log.level: got %q, want %q", level, "error")
+	}
+}
+
+func TestSpanEvents_DisabledByDefault(t *testing.T) {
+	restoreLogger(t)
+
+	initLogger(Config{Level: "debug", Output: &bytes.Buffer{}, ServiceName: "test-service"})
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	ctx, span := provider.Tracer("test").Start(context.Background(), "test-span")
+
+	ErrorCtx(ctx).Msg("not mirrored")
+	span.End()
+
+	if n := len(recorder.Ended()[0].Events()); n != 0 {
+		t.Fatalf("events: got %d, want 0", n)
+	}
+}
+
+// restoreLogger snapshots the global logger state and restores it when the
+// test finishes, so tests may re-run initLogger with their own config.
+func restoreLogger(t *testing.T) {
+	t.Helper()
+	loggerMu.RLock()
+	g, b, f := globalLogger, baseLogger, globalFields
+	o, c, x := localOutput, loggerCfg, otelExport
+	loggerMu.RUnlock()
+	outputMu.Lock()
+	r := reopener
+	outputMu.Unlock()
+	t.Cleanup(func() {
+		loggerMu.Lock()
+		globalLogger, baseLogger, globalFields = g, b, f
+		localOutput, loggerCfg, otelExport = o, c, x
+		loggerMu.Unlock()
+		outputMu.Lock()
+		reopener = r
+		outputMu.Unlock()
+	})
+}
+
+func TestConfigFields(t *testing.T) {
+	restoreLogger(t)
+
+	buf := &bytes.Buffer{}
+	initLogger(Config{
+		Level:       "debug",
+		Output:      buf,
+		ServiceName: "test-service",
+		Fields:      map[string]interface{}{"version": "1.2.3"},
+	})
+
+	Info().Msg("hello")
+	if !strings.Contains(buf.String(), `"version":"1.2.3"`) {
+		t.Fatalf("expected version field, got: %s", buf.String())
+	}
+}
+
+func TestSetGlobalFields(t *testing.T) {
+	restoreLogger(t)
+
+	buf := &bytes.Buffer{}
+	initLogger(Config{
+		Level:       "debug",
+		Output:      buf,
+		ServiceName: "test-service",
+		Fields:      map[string]interface{}{"region": "eu-west-1"},
+	})
+
+	SetGlobalFields(map[string]interface{}{
+		"instance_id": "node-7",
+		"region":      "us-east-1",
+	})
+	Info().Msg("after")
+
+	out := buf.String()
+	if !strings.Contains(out, `"instance_id":"node-7"`) {
+		t.Fatalf("expected instance_id field, got: %s", out)
+	}
+	if strings.Contains(out, "eu-west-1") || strings.Count(out, `"region"`) != 1 {
+		t.Fatalf("expected single overwritten region field, got: %s", out)
+	}
+
+	buf.Reset()
+	SetGlobalFields(map[string]interface{}{"instance_id": nil})
+	Info().Msg("removed")
+	if strings.Contains(buf.String(), "instance_id") {
+		t.Fatalf("expected instance_id removed, got: %s", buf.String())
+	}
+}
+
+func TestSetGlobalFields_ContextLogger(t *testing.T) {
+	restoreLogger(t)
+
+	buf := &bytes.Buffer{}
+	initLogger(Config{Level: "debug", Output: buf, ServiceName: "test-service"})
+	SetGlobalFields(map[string]interface{}{"build": "abc123"})
+
+	InfoCtx(context.Background()).Msg("ctx")
+	if !strings.Contains(buf.String(), `"build":"abc123"`) {
+		t.Fatalf("expected build field, got: %s", buf.String())
+	}
+}
@@ -0,0 +1,124 @@
+// Package logtest captures logger output in tests and provides assertions on
+// the parsed entries instead of string-matching raw buffers.
+package logtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/planx-lab/planx-common/logger"
+	"github.com/rs/zerolog"
+)
+
+// Entry is a single parsed log line.
+type Entry struct {
+	Level   zerolog.Level
+	Message string
+	Fields  map[string]interface{} // all fields, including level and message
+	Raw     string
+}
+
+// Str returns the string field key, or "" if absent or not a string.
+func (e Entry) Str(key string) string {
+	s, _ := e.Fields[key].(string)
+	return s
+}
+
+// Recorder collects log lines written while it is installed.
+type Recorder struct {
+	t   testing.TB
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+// Capture redirects the global logger into a new Recorder until the test ends.
+func Capture(t testing.TB) *Recorder {
+	t.Helper()
+	r := &Recorder{t: t}
+	restore := logger.SetOutput(r)
+	t.Cleanup(restore)
+	return r
+}
+
+// Write implements io.Writer.
+func (r *Recorder) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.buf.Write(p)
+}
+
+// Reset discards everything captured so far.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.buf.Reset()
+}
+
+// Entries returns the captured lines in order. Lines that are not valid JSON
+// fail the test.
+func (r *Recorder) Entries() []Entry {
+	r.t.Helper()
+	r.mu.Lock()
+	data := r.buf.String()
+	r.mu.Unlock()
+
+	var entries []Entry
+	for _, line := range strings.Split(data, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		fields := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(line), &fields); err != nil {
+			r.t.Errorf("logtest: invalid log line %q: %v", line, err)
+			continue
+		}
+		e := Entry{Fields: fields, Raw: line, Level: zerolog.NoLevel}
+		if s, ok := fields[zerolog.LevelFieldName].(string); ok {
+			if lvl, err := zerolog.ParseLevel(s); err == nil {
+				e.Level = lvl
+			}
+		}
+		e.Message, _ = fields[zerolog.MessageFieldName].(string)
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// Find returns the entries at level whose message contains substring.
+func (r *Recorder) Find(level zerolog.Level, substring string) []Entry {
+	r.t.Helper()
+	var found []Entry
+	for _, e := range r.Entries() {
+		if e.Level == level && strings.Contains(e.Message, substring) {
+			found = append(found, e)
+		}
+	}
+	return found
+}
+
+// AssertLogged fails the test unless an entry at level has a message
+// containing substring.
+func (r *Recorder) AssertLogged(level zerolog.Level, substring string) {
+	r.t.Helper()
+	if len(r.Find(level, substring)) == 0 {
+		r.t.Errorf("logtest: no %s entry containing %q; captured:\n%s", level, substring, r.dump())
+	}
+}
+
+// AssertNotLogged fails the test if an entry at level has a message
+// containing substring.
+func (r *Recorder) AssertNotLogged(level zerolog.Level, substring string) {
+	r.t.Helper()
+	if len(r.Find(level, substring)) != 0 {
+		r.t.Errorf("logtest: unexpected %s entry containing %q; captured:\n%s", level, substring, r.dump())
+	}
+}
+
+func (r *Recorder) dump() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.buf.String()
+}
@@ -0,0 +1,66 @@
+package logtest
+
+import (
+	"testing"
+
+	"github.com/planx-lab/planx-common/logger"
+	"github.com/rs/zerolog"
+)
+
+func TestCapture_Entries(t *testing.T) {
+	rec := Capture(t)
+
+	logger.Warn().Str("sink", "http").Msg("slow response")
+	logger.Error().Msg("write failed")
+
+	entries := rec.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("entries: got %d, want 2", len(entries))
+	}
+	if entries[0].Level != zerolog.WarnLevel || entries[0].Message != "slow response" {
+		t.Fatalf("entry 0: got %+v", entries[0])
+	}
+	if entries[0].Str("sink") != "http" {
+		t.Fatalf("sink field: got %q", entries[0].Str("sink"))
+	}
+}
+
+func TestCapture_Assertions(t *testing.T) {
+	rec := Capture(t)
+
+	logger.Error().Msg("connection refused by sink")
+
+	rec.AssertLogged(zerolog.ErrorLevel, "refused")
+	rec.AssertNotLogged(zerolog.WarnLevel, "refused")
+}
+
+func TestCapture_AssertLoggedFails(t *testing.T) {
+	rec := Capture(t)
+	logger.Error().Msg("boom")
+
+	ft := &fakeT{TB: t}
+	rec.t = ft
+	rec.AssertLogged(zerolog.ErrorLevel, "missing")
+	if !ft.failed {
+		t.Fatal("AssertLogged should fail when no entry matches")
+	}
+}
+
+func TestCapture_Reset(t *testing.T) {
+	rec := Capture(t)
+	logger.Error().Msg("before")
+	rec.Reset()
+	if n := len(rec.Entries()); n != 0 {
+		t.Fatalf("entries after reset: got %d, want 0", n)
+	}
+}
+
+// fakeT records failures without failing the enclosing test.
+type fakeT struct {
+	testing.TB
+	failed bool
+}
+
+func (f *fakeT) Helper() {}
+
+func (f *fakeT) Errorf(string, ...interface{}) { f.failed = true }
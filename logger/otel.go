@@ -0,0 +1,176 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/rs/zerolog"
+	otellog "go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// otelScope is the instrumentation scope name used for mirrored log records.
+const otelScope = "planx/logger"
+
+// EnableOTelExport mirrors every log line to provider while still writing to
+// the local output. Passing nil disables the mirror.
+//
+// telemetry.InitLogging wires this automatically when LoggingConfig.ExportLogger
+// is set, and telemetry.ShutdownLogging detaches it before flushing.
+func EnableOTelExport(provider otellog.LoggerProvider) {
+	Get()
+
+	loggerMu.Lock()
+	defer loggerMu.Unlock()
+	if provider == nil {
+		otelExport = nil
+	} else {
+		otelExport = &otelWriter{logger: provider.Logger(otelScope)}
+	}
+	buildBaseLocked()
+}
+
+// DisableOTelExport stops mirroring log lines to OpenTelemetry.
+func DisableOTelExport() {
+	EnableOTelExport(nil)
+}
+
+// otelWriter converts zerolog JSON lines into OpenTelemetry log records.
+type otelWriter struct {
+	logger otellog.Logger
+}
+
+// Write implements io.Writer for writers that are not level-aware.
+func (w *otelWriter) Write(p []byte) (int, error) {
+	return w.WriteLevel(zerolog.NoLevel, p)
+}
+
+// WriteLevel implements zerolog.LevelWriter.
+func (w *otelWriter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	fields := map[string]interface{}{}
+	dec := json.NewDecoder(bytes.NewReader(p))
+	dec.UseNumber()
+	if err := dec.Decode(&fields); err != nil {
+		// Not a JSON line; export it verbatim rather than dropping it.
+		var rec otellog.Record
+		rec.SetTimestamp(time.Now())
+		rec.SetBody(otellog.StringValue(string(bytes.TrimSpace(p))))
+		w.logger.Emit(context.Background(), rec)
+		return len(p), nil
+	}
+
+	if level == zerolog.NoLevel {
+		if s, ok := fields[zerolog.LevelFieldName].(string); ok {
+			if lvl, err := zerolog.ParseLevel(s); err == nil {
+				level = lvl
+			}
+		}
+	}
+
+	var rec otellog.Record
+	rec.SetTimestamp(time.Now())
+	if s, ok := fields[zerolog.TimestampFieldName].(string); ok {
+		if ts, err := time.Parse(zerolog.TimeFieldFormat, s); err == nil {
+			rec.SetTimestamp(ts)
+		}
+	}
+	if msg, ok := fields[zerolog.MessageFieldName].(string); ok {
+		rec.SetBody(otellog.StringValue(msg))
+	}
+	rec.SetSeverity(severity(level))
+	rec.SetSeverityText(level.String())
+
+	ctx := context.Background()
+	if sc, ok := spanContextFromFields(fields); ok {
+		ctx = trace.ContextWithSpanContext(ctx, sc)
+	}
+
+	for k, v := range fields {
+		switch k {
+		case zerolog.TimestampFieldName, zerolog.LevelFieldName, zerolog.MessageFieldName,
+			"trace_id", "span_id":
+			continue
+		}
+		rec.AddAttributes(otellog.KeyValue{Key: k, Value: logValue(v)})
+	}
+
+	w.logger.Emit(ctx, rec)
+	return len(p), nil
+}
+
+// spanContextFromFields rebuilds the span context from the trace_id/span_id
+// fields injected by WithContext, so exported records stay correlated.
+func spanContextFromFields(fields map[string]interface{}) (trace.SpanContext, bool) {
+	tid, _ := fields["trace_id"].(string)
+	sid, _ := fields["span_id"].(string)
+	if tid == "" || sid == "" {
+		return trace.SpanContext{}, false
+	}
+	traceID, err := trace.TraceIDFromHex(tid)
+	if err != nil {
+		return trace.SpanContext{}, false
+	}
+	spanID, err := trace.SpanIDFromHex(sid)
+	if err != nil {
+		return trace.SpanContext{}, false
+	}
+	return trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	}), true
+}
+
+func severity(level zerolog.Level) otellog.Severity {
+	switch level {
+	case zerolog.TraceLevel:
+		return otellog.SeverityTrace
+	case zerolog.DebugLevel:
+		return otellog.SeverityDebug
+	case zerolog.InfoLevel:
+		return otellog.SeverityInfo
+	case zerolog.WarnLevel:
+		return otellog.SeverityWarn
+	case zerolog.ErrorLevel:
+		return otellog.SeverityError
+	case zerolog.FatalLevel:
+		return otellog.SeverityFatal
+	case zerolog.PanicLevel:
+		return otellog.SeverityFatal4
+	default:
+		return otellog.SeverityUndefined
+	}
+}
+
+func logValue(v interface{}) otellog.Value {
+	switch val := v.(type) {
+	case string:
+		return otellog.StringValue(val)
+	case bool:
+		return otellog.BoolValue(val)
+	case json.Number:
+		if i, err := val.Int64(); err == nil {
+			return otellog.Int64Value(i)
+		}
+		if f, err := val.Float64(); err == nil {
+			return otellog.Float64Value(f)
+		}
+		return otellog.StringValue(val.String())
+	case []interface{}:
+		vs := make([]otellog.Value, 0, len(val))
+		for _, e := range val {
+			vs = append(vs, logValue(e))
+		}
+		return otellog.SliceValue(vs...)
+	case map[string]interface{}:
+		kvs := make([]otellog.KeyValue, 0, len(val))
+		for k, e := range val {
+			kvs = append(kvs, otellog.KeyValue{Key: k, Value: logValue(e)})
+		}
+		return otellog.MapValue(kvs...)
+	default:
+		return otellog.Value{}
+	}
+}
@@ -0,0 +1,141 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	otellog "go.opentelemetry.io/otel/log"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// memoryExporter collects exported log records for assertions.
+type memoryExporter struct {
+	mu      sync.Mutex
+	records []sdklog.Record
+}
+
+func (e *memoryExporter) Export(_ context.Context, records []sdklog.Record) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, r := range records {
+		e.records = append(e.records, r.Clone())
+	}
+	return nil
+}
+
+func (e *memoryExporter) Shutdown(context.Context) error   { return nil }
+func (e *memoryExporter) ForceFlush(context.Context) error { return nil }
+
+func (e *memoryExporter) Records() []sdklog.Record {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]sdklog.Record(nil), e.records...)
+}
+
+func newTestLogProvider() (*sdklog.LoggerProvider, *memoryExporter) {
+	exp := &memoryExporter{}
+	return sdklog.NewLoggerProvider(sdklog.WithProcessor(sdklog.NewSimpleProcessor(exp))), exp
+}
+
+func TestEnableOTelExport(t *testing.T) {
+	restoreLogger(t)
+
+	buf := &bytes.Buffer{}
+	initLogger(Config{Level: "debug", Output: buf, ServiceName: "test-service"})
+
+	lp, exp := newTestLogProvider()
+	EnableOTelExport(lp)
+
+	Warn().Str("sink", "http").Int("attempt", 3).Msg("retrying")
+
+	if !strings.Contains(buf.String(), "retrying") {
+		t.Fatalf("expected local output, got: %s", buf.String())
+	}
+
+	records := exp.Records()
+	if len(records) != 1 {
+		t.Fatalf("records: got %d, want 1", len(records))
+	}
+	rec := records[0]
+	if rec.Body().AsString() != "retrying" {
+		t.Fatalf("body: got %q", rec.Body().AsString())
+	}
+	if rec.Severity() != otellog.SeverityWarn {
+		t.Fatalf("severity: got %v", rec.Severity())
+	}
+	attrs := map[string]otellog.Value{}
+	rec.WalkAttributes(func(kv otellog.KeyValue) bool {
+		attrs[kv.Key] = kv.Value
+		return true
+	})
+	if attrs["sink"].AsString() != "http" {
+		t.Fatalf("sink attr: got %v", attrs["sink"])
+	}
+	if attrs["attempt"].AsInt64() != 3 {
+		t.Fatalf("attempt attr: got %v", attrs["attempt"])
+	}
+	if _, ok := attrs["message"]; ok {
+		t.Fatal("message should be the body, not an attribute")
+	}
+}
+
+func TestEnableOTelExport_TraceCorrelation(t *testing.T) {
+	restoreLogger(t)
+
+	initLogger(Config{Level: "debug", Output: &bytes.Buffer{}, ServiceName: "test-service"})
+	lp, exp := newTestLogProvider()
+	EnableOTelExport(lp)
+
+	tp := sdktrace.NewTracerProvider()
+	ctx, span := tp.Tracer("test").Start(context.Background(), "test-span")
+	defer span.End()
+
+	InfoCtx(ctx).Msg("correlated")
+
+	records := exp.Records()
+	if len(records) != 1 {
+		t.Fatalf("records: got %d, want 1", len(records))
+	}
+	if records[0].TraceID() != span.SpanContext().TraceID() {
+		t.Fatalf("trace id: got %s, want %s", records[0].TraceID(), span.SpanContext().TraceID())
+	}
+	if records[0].SpanID() != span.SpanContext().SpanID() {
+		t.Fatalf("span id: got %s, want %s", records[0].SpanID(), span.SpanContext().SpanID())
+	}
+}
+
+func TestDisableOTelExport(t *testing.T) {
+	restoreLogger(t)
+
+	initLogger(Config{Level: "debug", Output: &bytes.Buffer{}, ServiceName: "test-service"})
+	lp, exp := newTestLogProvider()
+	EnableOTelExport(lp)
+	DisableOTelExport()
+
+	Info().Msg("local only")
+	if n := len(exp.Records()); n != 0 {
+		t.Fatalf("records: got %d, want 0", n)
+	}
+}
+
+func TestEnableOTelExport_Pretty(t *testing.T) {
+	restoreLogger(t)
+
+	buf := &bytes.Buffer{}
+	initLogger(Config{Level: "debug", Pretty: true, Output: buf, ServiceName: "test-service"})
+	lp, exp := newTestLogProvider()
+	EnableOTelExport(lp)
+
+	Info().Msg("pretty")
+	if strings.HasPrefix(buf.String(), "{") {
+		t.Fatalf("local output should stay human-readable, got: %s", buf.String())
+	}
+	records := exp.Records()
+	if len(records) != 1 || records[0].Body().AsString() != "pretty" {
+		t.Fatalf("unexpected records: %v", records)
+	}
+}
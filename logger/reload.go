@@ -0,0 +1,200 @@
+package logger
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/rs/zerolog"
+)
+
+// LevelEnv is the environment variable consulted for the log level on SIGHUP
+// when WatchSignals is given no level callback.
+const LevelEnv = "PLANX_LOG_LEVEL"
+
+var (
+	outputMu sync.Mutex
+	reopener Reopener // the current output, if it can be reopened
+)
+
+// Reopener is implemented by outputs that can reopen their underlying file,
+// typically after logrotate has moved it away.
+type Reopener interface {
+	Reopen() error
+}
+
+// FileWriter is an append-only file output that can be reopened in place.
+type FileWriter struct {
+	path string
+	mu   sync.Mutex
+	f    *os.File
+}
+
+// NewFileWriter opens (or creates) path for appending.
+func NewFileWriter(path string) (*FileWriter, error) {
+	f, err := openLogFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return &FileWriter{path: path, f: f}, nil
+}
+
+func openLogFile(path string) (*os.File, error) {
+	return os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+}
+
+// Write implements io.Writer.
+func (w *FileWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.f == nil {
+		return 0, os.ErrClosed
+	}
+	return w.f.Write(p)
+}
+
+// Reopen closes the current file and opens path again, picking up a fresh
+// file if the old one was rotated.
+func (w *FileWriter) Reopen() error {
+	f, err := openLogFile(w.path)
+	if err != nil {
+		return err
+	}
+	w.mu.Lock()
+	old := w.f
+	w.f = f
+	w.mu.Unlock()
+	if old != nil {
+		return old.Close()
+	}
+	return nil
+}
+
+// Close closes the underlying file.
+func (w *FileWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.f == nil {
+		return nil
+	}
+	err := w.f.Close()
+	w.f = nil
+	return err
+}
+
+// registerOutput records w, the new output, for reopening on Reload if it
+// supports it. It replaces the previous output, which is no longer
+// reopened.
+func registerOutput(w interface{}) {
+	r, _ := w.(Reopener)
+	outputMu.Lock()
+	reopener = r
+	outputMu.Unlock()
+}
+
+// Reload applies a new global level and reopens the output if it is a
+// Reopener, such as a FileWriter.
+// An empty level leaves the current level unchanged.
+func Reload(level string) error {
+	var errs []error
+	if level != "" {
+		lvl, err := zerolog.ParseLevel(level)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("parsing level %q: %w", level, err))
+		} else {
+			zerolog.SetGlobalLevel(lvl)
+		}
+	}
+
+	outputMu.Lock()
+	r := reopener
+	outputMu.Unlock()
+	if r != nil {
+		if err := r.Reopen(); err != nil {
+			errs = append(errs, fmt.Errorf("reopening output: %w", err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// WatchSignals reloads the logger on every SIGHUP until the returned stop
+// function is called. levelFn supplies the new level; if nil, LevelEnv is read.
+func WatchSignals(levelFn func() string) (stop func()) {
+	if levelFn == nil {
+		levelFn = func() string { return os.Getenv(LevelEnv) }
+	}
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGHUP)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-sigs:
+				level := levelFn()
+				if err := Reload(level); err != nil {
+					Error().Err(err).Msg("logger reload failed")
+					continue
+				}
+				Info().Str("level", zerolog.GlobalLevel().String()).Msg("logger reloaded")
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(sigs)
+			close(done)
+		})
+	}
+}
+
+// levelBody is the JSON exchanged by LevelHandler.
+type levelBody struct {
+	Level string `json:"level"`
+}
+
+// LevelHandler serves the global log level, for a /debug/loglevel endpoint.
+// GET returns {"level":"info"}; PUT or POST with the same body changes it
+// until the next Init or Reload:
+//
+//	curl -X PUT -d '{"level":"debug"}' localhost:6060/debug/loglevel
+func LevelHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+		case http.MethodPut, http.MethodPost:
+			var body levelBody
+			if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<10)).Decode(&body); err != nil {
+				http.Error(w, "invalid body: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			lvl, err := zerolog.ParseLevel(body.Level)
+			if err != nil || body.Level == "" {
+				http.Error(w, "invalid level "+body.Level, http.StatusBadRequest)
+				return
+			}
+			prev := zerolog.GlobalLevel()
+			zerolog.SetGlobalLevel(lvl)
+			// Logged at warn so the change shows at any level but a muted one.
+			Warn().Str("from", prev.String()).Str("to", lvl.String()).Msg("logger: level changed")
+		default:
+			w.Header().Set("Allow", "GET, PUT, POST")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		_ = json.NewEncoder(w).Encode(levelBody{Level: zerolog.GlobalLevel().String()})
+	})
+}
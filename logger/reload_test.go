@@ -0,0 +1,190 @@
+package logger
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+func TestFileWriter_Reopen(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "planx.log")
+
+	w, err := NewFileWriter(path)
+	if err != nil {
+		t.Fatalf("NewFileWriter: %v", err)
+	}
+	defer w.Close()
+
+	if _, err := w.Write([]byte("before\n")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	rotated := filepath.Join(dir, "planx.log.1")
+	if err := os.Rename(path, rotated); err != nil {
+		t.Fatalf("rename: %v", err)
+	}
+	if err := w.Reopen(); err != nil {
+		t.Fatalf("Reopen: %v", err)
+	}
+	if _, err := w.Write([]byte("after\n")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	old, _ := os.ReadFile(rotated)
+	cur, _ := os.ReadFile(path)
+	if string(old) != "before\n" {
+		t.Fatalf("rotated file: got %q", old)
+	}
+	if string(cur) != "after\n" {
+		t.Fatalf("current file: got %q", cur)
+	}
+}
+
+func TestFileWriter_WriteAfterClose(t *testing.T) {
+	w, err := NewFileWriter(filepath.Join(t.TempDir(), "planx.log"))
+	if err != nil {
+		t.Fatalf("NewFileWriter: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, err := w.Write([]byte("x")); err == nil {
+		t.Fatal("expected error writing to closed writer")
+	}
+}
+
+func TestReload_Level(t *testing.T) {
+	prev := zerolog.GlobalLevel()
+	defer zerolog.SetGlobalLevel(prev)
+
+	if err := Reload("warn"); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if zerolog.GlobalLevel() != zerolog.WarnLevel {
+		t.Fatalf("level: got %v, want warn", zerolog.GlobalLevel())
+	}
+	if err := Reload(""); err != nil {
+		t.Fatalf("Reload empty: %v", err)
+	}
+	if zerolog.GlobalLevel() != zerolog.WarnLevel {
+		t.Fatal("empty level should leave level unchanged")
+	}
+}
+
+func TestReload_ReopensCurrentOutputOnly(t *testing.T) {
+	restoreLogger(t)
+	dir := t.TempDir()
+	first, second := filepath.Join(dir, "first.log"), filepath.Join(dir, "second.log")
+	for _, path := range []string{first, second} {
+		w, err := NewFileWriter(path)
+		if err != nil {
+			t.Fatalf("NewFileWriter: %v", err)
+		}
+		defer w.Close()
+		initLogger(Config{Level: "debug", Output: w, ServiceName: "test-service"})
+		if err := os.Remove(path); err != nil {
+			t.Fatalf("remove: %v", err)
+		}
+	}
+
+	if err := Reload(""); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if _, err := os.Stat(second); err != nil {
+		t.Fatalf("current output not reopened: %v", err)
+	}
+	if _, err := os.Stat(first); !os.IsNotExist(err) {
+		t.Fatalf("replaced output reopened: %v", err)
+	}
+}
+
+func TestReload_InvalidLevel(t *testing.T) {
+	prev := zerolog.GlobalLevel()
+	defer zerolog.SetGlobalLevel(prev)
+
+	err := Reload("loud")
+	if err == nil || !strings.Contains(err.Error(), "loud") {
+		t.Fatalf("expected parse error, got %v", err)
+	}
+	if zerolog.GlobalLevel() != prev {
+		t.Fatal("invalid level should leave level unchanged")
+	}
+}
+
+func TestWatchSignals_SIGHUP(t *testing.T) {
+	prev := zerolog.GlobalLevel()
+	defer zerolog.SetGlobalLevel(prev)
+
+	stop := WatchSignals(func() string { return "error" })
+	defer stop()
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatalf("kill: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for zerolog.GlobalLevel() != zerolog.ErrorLevel {
+		if time.Now().After(deadline) {
+			t.Fatalf("level not reloaded, got %v", zerolog.GlobalLevel())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWatchSignals_StopIdempotent(t *testing.T) {
+	stop := WatchSignals(nil)
+	stop()
+	stop()
+}
+
+func TestLevelHandler(t *testing.T) {
+	prev := zerolog.GlobalLevel()
+	defer zerolog.SetGlobalLevel(prev)
+	zerolog.SetGlobalLevel(zerolog.InfoLevel)
+	h := LevelHandler()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/loglevel", nil))
+	if rec.Code != http.StatusOK || strings.TrimSpace(rec.Body.String()) != `{"level":"info"}` {
+		t.Fatalf("GET: %d %s", rec.Code, rec.Body)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/debug/loglevel", strings.NewReader(`{"level":"debug"}`)))
+	if rec.Code != http.StatusOK || zerolog.GlobalLevel() != zerolog.DebugLevel {
+		t.Fatalf("PUT: %d %s, level %v", rec.Code, rec.Body, zerolog.GlobalLevel())
+	}
+	if !strings.Contains(rec.Body.String(), `"debug"`) {
+		t.Fatalf("PUT body: %s", rec.Body)
+	}
+}
+
+func TestLevelHandler_Invalid(t *testing.T) {
+	prev := zerolog.GlobalLevel()
+	defer zerolog.SetGlobalLevel(prev)
+	h := LevelHandler()
+
+	for _, body := range []string{`{"level":"loud"}`, `{}`, `not json`} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/debug/loglevel", strings.NewReader(body)))
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: status %d", body, rec.Code)
+		}
+	}
+	if zerolog.GlobalLevel() != prev {
+		t.Fatalf("invalid requests changed the level")
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/debug/loglevel", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("DELETE: status %d", rec.Code)
+	}
+}
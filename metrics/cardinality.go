@@ -0,0 +1,124 @@
+package metrics
+
+import (
+	"sync"
+
+	"github.com/planx-lab/planx-common/logger"
+)
+
+// OverflowLabelValue replaces every label value of a series created beyond
+// the cardinality limit.
+const OverflowLabelValue = "other"
+
+// CardinalityLimiter is a Provider wrapper that counts the distinct label
+// sets created per metric name and, past a limit, routes new label sets to a
+// single overflow series whose label values are all OverflowLabelValue. This
+// protects backends from label explosions such as per-batch IDs.
+type CardinalityLimiter struct {
+	next  Provider
+	limit int
+
+	mu       sync.Mutex
+	series   map[string]map[string]struct{} // name -> label set keys
+	overflow map[string]int                 // name -> label sets rejected, counted per lookup
+}
+
+// LimitCardinality wraps p, allowing at most limit label sets per metric
+// name. A limit <= 0 disables the cap but still counts.
+func LimitCardinality(p Provider, limit int) *CardinalityLimiter {
+	return &CardinalityLimiter{
+		next:     p,
+		limit:    limit,
+		series:   make(map[string]map[string]struct{}),
+		overflow: make(map[string]int),
+	}
+}
+
+// Counter implements Provider.
+func (c *CardinalityLimiter) Counter(name string, labels map[string]string) Counter {
+	return c.next.Counter(name, c.admit(name, labels))
+}
+
+// Gauge implements Provider.
+func (c *CardinalityLimiter) Gauge(name string, labels map[string]string) Gauge {
+	return c.next.Gauge(name, c.admit(name, labels))
+}
+
+// Histogram implements Provider.
+func (c *CardinalityLimiter) Histogram(name string, labels map[string]string, opts ...HistogramOption) Histogram {
+	return c.next.Histogram(name, c.admit(name, labels), opts...)
+}
+
+// GaugeFunc implements Provider.
+func (c *CardinalityLimiter) GaugeFunc(name string, labels map[string]string, fn func() float64) {
+	c.next.GaugeFunc(name, c.admit(name, labels), fn)
+}
+
+// Snapshot implements Snapshotter by delegating to the wrapped provider.
+func (c *CardinalityLimiter) Snapshot() ([]MetricPoint, error) {
+	if s, ok := c.next.(Snapshotter); ok {
+		return s.Snapshot()
+	}
+	return nil, ErrSnapshotUnsupported
+}
+
+// Cardinality returns the number of distinct label sets admitted per metric
+// name.
+func (c *CardinalityLimiter) Cardinality() map[string]int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make(map[string]int, len(c.series))
+	for name, sets := range c.series {
+		out[name] = len(sets)
+	}
+	return out
+}
+
+// Overflowed returns the number of lookups routed to the overflow series per
+// metric name. Rejected label sets are counted, not kept, so a label
+// explosion costs no memory; one looked up twice counts twice.
+func (c *CardinalityLimiter) Overflowed() map[string]int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make(map[string]int, len(c.overflow))
+	for name, n := range c.overflow {
+		out[name] = n
+	}
+	return out
+}
+
+// admit returns labels if the label set is known or fits under the limit,
+// and the overflow label set otherwise.
+func (c *CardinalityLimiter) admit(name string, labels map[string]string) map[string]string {
+	key := seriesKey("", labels)
+
+	c.mu.Lock()
+	sets, ok := c.series[name]
+	if !ok {
+		sets = make(map[string]struct{})
+		c.series[name] = sets
+	}
+	if _, known := sets[key]; known || c.limit <= 0 || len(sets) < c.limit {
+		sets[key] = struct{}{}
+		c.mu.Unlock()
+		return labels
+	}
+	c.overflow[name]++
+	first := c.overflow[name] == 1
+	c.mu.Unlock()
+	if first {
+		logger.Warn().
+			Str("metric", name).
+			Int("limit", c.limit).
+			Msg("metric cardinality limit reached, routing new label sets to overflow series")
+	}
+	return overflowLabels(labels)
+}
+
+func overflowLabels(labels map[string]string) map[string]string {
+	out := make(map[string]string, len(labels))
+	for k := range labels {
+		out[k] = OverflowLabelValue
+	}
+	return out
+}
@@ -0,0 +1,91 @@
+package metrics
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/planx-lab/planx-common/logger/logtest"
+	"github.com/rs/zerolog"
+)
+
+func TestLimitCardinality(t *testing.T) {
+	rec := logtest.Capture(t)
+	p := newFakeProvider()
+	c := LimitCardinality(p, 2)
+
+	for i := 0; i < 5; i++ {
+		c.Counter("planx.batches", map[string]string{"batch_id": strconv.Itoa(i), "stage": "sink"}).Inc()
+	}
+	// Known label sets keep working after the limit is reached.
+	c.Counter("planx.batches", map[string]string{"batch_id": "0", "stage": "sink"}).Inc()
+	c.Gauge("planx.window", map[string]string{"stage": "sink"}).Set(1)
+
+	if got := p.value("planx.batches{batch_id=0,stage=sink}"); got != 2 {
+		t.Fatalf("batch 0 = %v, want 2", got)
+	}
+	if got := p.value("planx.batches{batch_id=other,stage=other}"); got != 3 {
+		t.Fatalf("overflow series = %v, want 3", got)
+	}
+
+	card := c.Cardinality()
+	if card["planx.batches"] != 2 || card["planx.window"] != 1 {
+		t.Fatalf("unexpected cardinality %v", card)
+	}
+	if over := c.Overflowed(); over["planx.batches"] != 3 || len(over) != 1 {
+		t.Fatalf("unexpected overflow counts %v", over)
+	}
+	if n := len(rec.Find(zerolog.WarnLevel, "cardinality limit")); n != 1 {
+		t.Fatalf("expected one warning, got %d", n)
+	}
+}
+
+func TestLimitCardinality_AllKinds(t *testing.T) {
+	mem := NewMemoryProvider()
+	c := LimitCardinality(mem, 1)
+	c.Histogram("planx.latency_ms", map[string]string{"route": "a"}, WithBuckets(1)).Observe(1)
+	c.Histogram("planx.latency_ms", map[string]string{"route": "b"}).Observe(1)
+	c.GaugeFunc("planx.depth", map[string]string{"route": "a"}, func() float64 { return 1 })
+	c.GaugeFunc("planx.depth", map[string]string{"route": "b"}, func() float64 { return 2 })
+
+	points, err := c.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	routes := map[string]bool{}
+	for _, pt := range points {
+		routes[pt.Name+"/"+pt.Labels["route"]] = true
+	}
+	for _, want := range []string{"planx.latency_ms/a", "planx.latency_ms/other", "planx.depth/a", "planx.depth/other"} {
+		if !routes[want] {
+			t.Errorf("missing series %s in %v", want, routes)
+		}
+	}
+}
+
+func TestLimitCardinality_Unlimited(t *testing.T) {
+	c := LimitCardinality(NoopProvider{}, 0)
+	for i := 0; i < 100; i++ {
+		c.Counter("planx.x", map[string]string{"id": strconv.Itoa(i)})
+	}
+	if c.Cardinality()["planx.x"] != 100 || len(c.Overflowed()) != 0 {
+		t.Fatalf("expected no cap, got %v %v", c.Cardinality(), c.Overflowed())
+	}
+	if _, err := c.Snapshot(); err != ErrSnapshotUnsupported {
+		t.Fatalf("expected ErrSnapshotUnsupported, got %v", err)
+	}
+}
+
+func TestLimitCardinality_RejectedNotKept(t *testing.T) {
+	logtest.Capture(t)
+	c := LimitCardinality(newFakeProvider(), 1)
+	for i := 0; i < 1000; i++ {
+		c.Counter("planx.batches", map[string]string{"batch_id": strconv.Itoa(i)})
+	}
+	c.Counter("planx.batches", map[string]string{"batch_id": "999"})
+	if over := c.Overflowed()["planx.batches"]; over != 1000 {
+		t.Fatalf("overflowed = %d, want 1000", over)
+	}
+	if n := len(c.series["planx.batches"]); n != 1 {
+		t.Fatalf("kept %d label sets, want 1", n)
+	}
+}
@@ -0,0 +1,38 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServerInterceptor records request count, latency and in-flight
+// requests for unary RPCs, labeled with the full method and status code.
+func UnaryServerInterceptor(r RequestRecorder) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		r.RecordRequestInFlight(ctx, "grpc", info.FullMethod, 1)
+		defer r.RecordRequestInFlight(ctx, "grpc", info.FullMethod, -1)
+
+		resp, err := handler(ctx, req)
+		r.RecordRequest(ctx, "grpc", info.FullMethod, status.Code(err).String(), msSince(start))
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor is UnaryServerInterceptor for streaming RPCs; the
+// latency covers the whole stream.
+func StreamServerInterceptor(r RequestRecorder) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := ss.Context()
+		start := time.Now()
+		r.RecordRequestInFlight(ctx, "grpc", info.FullMethod, 1)
+		defer r.RecordRequestInFlight(ctx, "grpc", info.FullMethod, -1)
+
+		err := handler(srv, ss)
+		r.RecordRequest(ctx, "grpc", info.FullMethod, status.Code(err).String(), msSince(start))
+		return err
+	}
+}
@@ -0,0 +1,63 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	p := newFakeProvider()
+	ic := UnaryServerInterceptor(NewRequestRecorder(p))
+	info := &grpc.UnaryServerInfo{FullMethod: "/planx.Engine/CreateSession"}
+
+	_, _ = ic(context.Background(), nil, info, func(context.Context, interface{}) (interface{}, error) {
+		if p.value("planx.requests.inflight{protocol=grpc,route=/planx.Engine/CreateSession}") != 1 {
+			t.Error("expected one in-flight request while serving")
+		}
+		return "ok", nil
+	})
+	_, err := ic(context.Background(), nil, info, func(context.Context, interface{}) (interface{}, error) {
+		return nil, status.Error(codes.InvalidArgument, "bad")
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("interceptor should return the handler error, got %v", err)
+	}
+
+	checks := map[string]float64{
+		"planx.requests.total{protocol=grpc,route=/planx.Engine/CreateSession,status=OK}":              1,
+		"planx.requests.total{protocol=grpc,route=/planx.Engine/CreateSession,status=InvalidArgument}": 1,
+		"planx.requests.inflight{protocol=grpc,route=/planx.Engine/CreateSession}":                     0,
+	}
+	for key, want := range checks {
+		if got := p.value(key); got != want {
+			t.Errorf("%s = %v, want %v", key, got, want)
+		}
+	}
+}
+
+type fakeServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s fakeServerStream) Context() context.Context { return s.ctx }
+
+func TestStreamServerInterceptor(t *testing.T) {
+	p := newFakeProvider()
+	ic := StreamServerInterceptor(NewRequestRecorder(p))
+	info := &grpc.StreamServerInfo{FullMethod: "/planx.Engine/Stream"}
+
+	err := ic(nil, fakeServerStream{ctx: context.Background()}, info, func(interface{}, grpc.ServerStream) error {
+		return status.Error(codes.Aborted, "broken")
+	})
+	if status.Code(err) != codes.Aborted {
+		t.Fatalf("got %v", err)
+	}
+	if got := p.value("planx.requests.total{protocol=grpc,route=/planx.Engine/Stream,status=Aborted}"); got != 1 {
+		t.Fatalf("got %v", got)
+	}
+}
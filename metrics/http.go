@@ -0,0 +1,76 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// UnmatchedRoute is the route label for requests no pattern matched, so raw
+// paths never become label values.
+const UnmatchedRoute = "unmatched"
+
+// HTTPMiddleware records request count, latency and in-flight requests for
+// every request served by next. The route label is the http.ServeMux pattern
+// that matched (prefixed with the method if the pattern has none), so wrap
+// the mux itself:
+//
+//	http.ListenAndServe(addr, metrics.HTTPMiddleware(metrics.NewRequestRecorder(p))(mux))
+func HTTPMiddleware(r RequestRecorder) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			ctx := req.Context()
+			start := time.Now()
+			// The pattern is only known once the mux has routed the request,
+			// so in-flight requests are tracked per method.
+			inflight := req.Method
+			r.RecordRequestInFlight(ctx, "http", inflight, 1)
+			defer r.RecordRequestInFlight(ctx, "http", inflight, -1)
+
+			sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(sw, req)
+
+			r.RecordRequest(ctx, "http", httpRoute(req), strconv.Itoa(sw.status), msSince(start))
+		})
+	}
+}
+
+func httpRoute(req *http.Request) string {
+	pattern := req.Pattern
+	if pattern == "" {
+		return UnmatchedRoute
+	}
+	if pattern[0] == '/' {
+		return req.Method + " " + pattern
+	}
+	return pattern
+}
+
+func msSince(start time.Time) float64 {
+	return float64(time.Since(start)) / float64(time.Millisecond)
+}
+
+// statusWriter captures the response status.
+type statusWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.status = code
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(p []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(p)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,55 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHTTPMiddleware(t *testing.T) {
+	p := newFakeProvider()
+	rec := NewRequestRecorder(p)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /sessions/{id}", func(w http.ResponseWriter, _ *http.Request) {
+		if p.value("planx.requests.inflight{protocol=http,route=GET}") != 1 {
+			t.Error("expected one in-flight request while serving")
+		}
+		w.WriteHeader(http.StatusAccepted)
+	})
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	})
+	h := HTTPMiddleware(rec)(mux)
+
+	for _, path := range []string{"/sessions/1", "/sessions/2", "/healthz", "/nope"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	checks := map[string]float64{
+		"planx.requests.total{protocol=http,route=GET /sessions/{id},status=202}": 2,
+		"planx.requests.total{protocol=http,route=GET /healthz,status=200}":       1,
+		"planx.requests.total{protocol=http,route=unmatched,status=404}":          1,
+		"planx.requests.inflight{protocol=http,route=GET}":                        0,
+	}
+	for key, want := range checks {
+		if got := p.value(key); got != want {
+			t.Errorf("%s = %v, want %v", key, got, want)
+		}
+	}
+	if p.created["planx.request.latency_ms{protocol=http,route=GET /sessions/{id}}"] != 1 {
+		t.Errorf("expected a latency histogram per route, got %v", p.created)
+	}
+}
+
+func TestStatusWriter_FirstStatusWins(t *testing.T) {
+	w := &statusWriter{ResponseWriter: httptest.NewRecorder(), status: http.StatusOK}
+	_, _ = w.Write([]byte("x"))
+	w.WriteHeader(http.StatusInternalServerError)
+	if w.status != http.StatusOK {
+		t.Fatalf("got %d, want 200", w.status)
+	}
+	if w.Unwrap() == nil {
+		t.Fatal("expected Unwrap to return the underlying writer")
+	}
+}
@@ -0,0 +1,232 @@
+package metrics
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// MemoryProvider keeps instruments in memory. It is useful in tests and as a
+// local fallback when no backend is configured; its values can be read with
+// Snapshot or served with DebugHandler.
+type MemoryProvider struct {
+	mu         sync.Mutex
+	counters   map[string]*memCounter
+	gauges     map[string]*memGauge
+	histograms map[string]*memHistogram
+	funcs      map[string]*memGaugeFunc
+}
+
+// NewMemoryProvider creates an empty in-memory provider.
+func NewMemoryProvider() *MemoryProvider {
+	return &MemoryProvider{
+		counters:   make(map[string]*memCounter),
+		gauges:     make(map[string]*memGauge),
+		histograms: make(map[string]*memHistogram),
+		funcs:      make(map[string]*memGaugeFunc),
+	}
+}
+
+// Counter returns the counter for name and labels, creating it on first use.
+func (p *MemoryProvider) Counter(name string, labels map[string]string) Counter {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	key := seriesKey(name, labels)
+	c, ok := p.counters[key]
+	if !ok {
+		c = &memCounter{series: newSeries(name, labels)}
+		p.counters[key] = c
+	}
+	return c
+}
+
+// Gauge returns the gauge for name and labels, creating it on first use.
+func (p *MemoryProvider) Gauge(name string, labels map[string]string) Gauge {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	key := seriesKey(name, labels)
+	g, ok := p.gauges[key]
+	if !ok {
+		g = &memGauge{series: newSeries(name, labels)}
+		p.gauges[key] = g
+	}
+	return g
+}
+
+// Histogram returns the histogram for name and labels, creating it on first
+// use. Buckets from opts are honored; objectives are ignored.
+func (p *MemoryProvider) Histogram(name string, labels map[string]string, opts ...HistogramOption) Histogram {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	key := seriesKey(name, labels)
+	h, ok := p.histograms[key]
+	if !ok {
+		o := NewHistogramOptions(opts...)
+		h = &memHistogram{
+			series: newSeries(name, labels),
+			bounds: o.Buckets,
+			counts: make([]uint64, len(o.Buckets)),
+		}
+		p.histograms[key] = h
+	}
+	return h
+}
+
+// GaugeFunc registers fn, sampled on every Snapshot. Registering the same
+// name and labels again replaces the function.
+func (p *MemoryProvider) GaugeFunc(name string, labels map[string]string, fn func() float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.funcs[seriesKey(name, labels)] = &memGaugeFunc{series: newSeries(name, labels), fn: fn}
+}
+
+// Snapshot implements Snapshotter. Points are sorted by name, then labels.
+func (p *MemoryProvider) Snapshot() ([]MetricPoint, error) {
+	p.mu.Lock()
+	points := make([]MetricPoint, 0, len(p.counters)+len(p.gauges)+len(p.histograms)+len(p.funcs))
+	for _, c := range p.counters {
+		points = append(points, c.point())
+	}
+	for _, g := range p.gauges {
+		points = append(points, g.point())
+	}
+	for _, h := range p.histograms {
+		points = append(points, h.point())
+	}
+	funcs := make([]*memGaugeFunc, 0, len(p.funcs))
+	for _, f := range p.funcs {
+		funcs = append(funcs, f)
+	}
+	p.mu.Unlock()
+
+	// Sample outside the provider lock: fn may be slow or create instruments.
+	for _, f := range funcs {
+		points = append(points, MetricPoint{Name: f.name, Kind: KindGauge, Labels: f.labels, Value: f.fn()})
+	}
+	sort.Slice(points, func(i, j int) bool {
+		if points[i].Name != points[j].Name {
+			return points[i].Name < points[j].Name
+		}
+		return seriesKey("", points[i].Labels) < seriesKey("", points[j].Labels)
+	})
+	return points, nil
+}
+
+// seriesKey identifies an instrument by name and sorted labels.
+func seriesKey(name string, labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var sb strings.Builder
+	sb.WriteString(name)
+	for _, k := range keys {
+		sb.WriteString("\xff")
+		sb.WriteString(k)
+		sb.WriteString("=")
+		sb.WriteString(labels[k])
+	}
+	return sb.String()
+}
+
+type series struct {
+	name   string
+	labels map[string]string
+}
+
+func newSeries(name string, labels map[string]string) series {
+	copied := make(map[string]string, len(labels))
+	for k, v := range labels {
+		copied[k] = v
+	}
+	return series{name: name, labels: copied}
+}
+
+type memCounter struct {
+	series
+	mu    sync.Mutex
+	value float64
+}
+
+func (c *memCounter) Inc() { c.Add(1) }
+
+func (c *memCounter) Add(delta float64) {
+	if delta < 0 {
+		return // counters only go up
+	}
+	c.mu.Lock()
+	c.value += delta
+	c.mu.Unlock()
+}
+
+func (c *memCounter) point() MetricPoint {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return MetricPoint{Name: c.name, Kind: KindCounter, Labels: c.labels, Value: c.value}
+}
+
+type memGauge struct {
+	series
+	mu    sync.Mutex
+	value float64
+}
+
+func (g *memGauge) Set(value float64) {
+	g.mu.Lock()
+	g.value = value
+	g.mu.Unlock()
+}
+
+func (g *memGauge) Inc()              { g.Add(1) }
+func (g *memGauge) Dec()              { g.Add(-1) }
+func (g *memGauge) Sub(delta float64) { g.Add(-delta) }
+
+func (g *memGauge) Add(delta float64) {
+	g.mu.Lock()
+	g.value += delta
+	g.mu.Unlock()
+}
+
+func (g *memGauge) point() MetricPoint {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return MetricPoint{Name: g.name, Kind: KindGauge, Labels: g.labels, Value: g.value}
+}
+
+type memHistogram struct {
+	series
+	bounds []float64
+
+	mu     sync.Mutex
+	count  uint64
+	sum    float64
+	counts []uint64 // per bucket, not cumulative
+}
+
+func (h *memHistogram) Observe(value float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.count++
+	h.sum += value
+	if i := sort.SearchFloat64s(h.bounds, value); i < len(h.bounds) {
+		h.counts[i]++
+	}
+}
+
+func (h *memHistogram) point() MetricPoint {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	p := MetricPoint{Name: h.name, Kind: KindHistogram, Labels: h.labels, Count: h.count, Sum: h.sum}
+	var cumulative uint64
+	for i, bound := range h.bounds {
+		cumulative += h.counts[i]
+		p.Buckets = append(p.Buckets, Bucket{UpperBound: bound, Count: cumulative})
+	}
+	return p
+}
+
+type memGaugeFunc struct {
+	series
+	fn func() float64
+}
@@ -0,0 +1,70 @@
+package metrics
+
+import "testing"
+
+func TestMemoryProvider(t *testing.T) {
+	p := NewMemoryProvider()
+	labels := map[string]string{"stage": "sink"}
+
+	p.Counter("planx.batches", labels).Inc()
+	p.Counter("planx.batches", labels).Add(2)
+	p.Counter("planx.batches", labels).Add(-5)
+
+	g := p.Gauge("planx.window", labels)
+	g.Set(10)
+	g.Inc()
+	g.Sub(4)
+
+	h := p.Histogram("planx.latency_ms", nil, WithBuckets(10, 100))
+	for _, v := range []float64{5, 10, 50, 500} {
+		h.Observe(v)
+	}
+
+	depth := 3.0
+	p.GaugeFunc("planx.queue.depth", nil, func() float64 { return depth })
+	depth = 8
+
+	points, err := p.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(points) != 4 {
+		t.Fatalf("expected 4 points, got %+v", points)
+	}
+	byName := map[string]MetricPoint{}
+	for _, pt := range points {
+		byName[pt.Name] = pt
+	}
+
+	if c := byName["planx.batches"]; c.Kind != KindCounter || c.Value != 3 || c.Labels["stage"] != "sink" {
+		t.Fatalf("unexpected counter %+v", c)
+	}
+	if g := byName["planx.window"]; g.Kind != KindGauge || g.Value != 7 {
+		t.Fatalf("unexpected gauge %+v", g)
+	}
+	hp := byName["planx.latency_ms"]
+	if hp.Kind != KindHistogram || hp.Count != 4 || hp.Sum != 565 {
+		t.Fatalf("unexpected histogram %+v", hp)
+	}
+	if len(hp.Buckets) != 2 || hp.Buckets[0].Count != 2 || hp.Buckets[1].Count != 3 {
+		t.Fatalf("unexpected buckets %+v", hp.Buckets)
+	}
+	if f := byName["planx.queue.depth"]; f.Value != 8 {
+		t.Fatalf("gauge func should be sampled at snapshot time, got %+v", f)
+	}
+	if points[0].Name != "planx.batches" {
+		t.Fatalf("points should be sorted by name, got %s first", points[0].Name)
+	}
+}
+
+func TestMemoryProvider_CopiesLabels(t *testing.T) {
+	p := NewMemoryProvider()
+	labels := map[string]string{"stage": "sink"}
+	p.Counter("c", labels).Inc()
+	labels["stage"] = "source"
+
+	points, _ := p.Snapshot()
+	if points[0].Labels["stage"] != "sink" {
+		t.Fatal("provider should copy the label map")
+	}
+}
@@ -32,8 +32,15 @@ type Provider interface {
 	// Gauge returns a gauge with the given name and labels.
 	Gauge(name string, labels map[string]string) Gauge
 
-	// Histogram returns a histogram with the given name and labels.
-	Histogram(name string, labels map[string]string) Histogram
+	// Histogram returns a histogram with the given name and labels. Options
+	// configure buckets or objectives; see HistogramOptions.
+	Histogram(name string, labels map[string]string, opts ...HistogramOption) Histogram
+
+	// GaugeFunc registers a gauge whose value is sampled by calling fn at
+	// collection time, for values such as queue depth or pool size that are
+	// cheaper to read than to track with Add/Sub. fn must be safe for
+	// concurrent use.
+	GaugeFunc(name string, labels map[string]string, fn func() float64)
 }
 
 // Recorder provides high-level metrics recording.
@@ -51,6 +58,21 @@ type Recorder interface {
 	RecordError(ctx context.Context, pluginName string, errorType string)
 }
 
+// RequestRecorder records RED metrics for control-plane requests, as
+// HTTPMiddleware and the gRPC interceptors do. It is separate from Recorder
+// so existing Recorder implementations need not grow these methods.
+type RequestRecorder interface {
+	// RecordRequest records a completed control-plane request. protocol is
+	// "http" or "grpc" for requests served and "http-client" or
+	// "grpc-client" for requests made, route the matched pattern, full
+	// method or, for HTTP clients, the method and host, and status the HTTP
+	// status or gRPC code.
+	RecordRequest(ctx context.Context, protocol, route, status string, latencyMs float64)
+
+	// RecordRequestInFlight adjusts the number of requests being served.
+	RecordRequestInFlight(ctx context.Context, protocol, route string, delta int)
+}
+
 // NoopCounter is a no-op counter for testing.
 type NoopCounter struct{}
 
@@ -74,6 +96,9 @@ func (NoopHistogram) Observe(_ float64) {}
 // NoopProvider is a no-op metrics provider.
 type NoopProvider struct{}
 
-func (NoopProvider) Counter(_ string, _ map[string]string) Counter     { return NoopCounter{} }
-func (NoopProvider) Gauge(_ string, _ map[string]string) Gauge         { return NoopGauge{} }
-func (NoopProvider) Histogram(_ string, _ map[string]string) Histogram { return NoopHistogram{} }
+func (NoopProvider) Counter(_ string, _ map[string]string) Counter { return NoopCounter{} }
+func (NoopProvider) Gauge(_ string, _ map[string]string) Gauge     { return NoopGauge{} }
+func (NoopProvider) Histogram(_ string, _ map[string]string, _ ...HistogramOption) Histogram {
+	return NoopHistogram{}
+}
+func (NoopProvider) GaugeFunc(_ string, _ map[string]string, _ func() float64) {}
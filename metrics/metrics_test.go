@@ -1,6 +1,9 @@
 package metrics
 
-import "testing"
+import (
+	"context"
+	"testing"
+)
 
 func TestNoopCounter_Inc(t *testing.T) {
 	c := NoopCounter{}
@@ -113,3 +116,19 @@ func TestNoopGauge_DoesNotPanic(t *testing.T) {
 	g.Inc()
 	g.Dec()
 }
+
+func TestNoopProvider_GaugeFunc(t *testing.T) {
+	p := NoopProvider{}
+	p.GaugeFunc("test_gauge_func", map[string]string{"k": "v"}, func() float64 { return 1 })
+}
+
+// batchRecorder implements only the batch methods, as Recorders written
+// before RequestRecorder existed do; it must still be a Recorder.
+type batchRecorder struct{}
+
+func (batchRecorder) RecordBatchProcessed(context.Context, string, int)   {}
+func (batchRecorder) RecordBatchLatency(context.Context, string, float64) {}
+func (batchRecorder) RecordSessionActive(context.Context, string, int)    {}
+func (batchRecorder) RecordError(context.Context, string, string)         {}
+
+var _ Recorder = batchRecorder{}
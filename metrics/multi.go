@@ -0,0 +1,94 @@
+package metrics
+
+// Multi returns a provider that creates each instrument on every provider and
+// duplicates all operations to them, e.g. to feed an old and a new backend
+// during a migration.
+func Multi(providers ...Provider) Provider {
+	return multiProvider(append([]Provider(nil), providers...))
+}
+
+type multiProvider []Provider
+
+func (m multiProvider) Counter(name string, labels map[string]string) Counter {
+	out := make(multiCounter, len(m))
+	for i, p := range m {
+		out[i] = p.Counter(name, labels)
+	}
+	return out
+}
+
+func (m multiProvider) Gauge(name string, labels map[string]string) Gauge {
+	out := make(multiGauge, len(m))
+	for i, p := range m {
+		out[i] = p.Gauge(name, labels)
+	}
+	return out
+}
+
+func (m multiProvider) Histogram(name string, labels map[string]string, opts ...HistogramOption) Histogram {
+	out := make(multiHistogram, len(m))
+	for i, p := range m {
+		out[i] = p.Histogram(name, labels, opts...)
+	}
+	return out
+}
+
+func (m multiProvider) GaugeFunc(name string, labels map[string]string, fn func() float64) {
+	for _, p := range m {
+		p.GaugeFunc(name, labels, fn)
+	}
+}
+
+type multiCounter []Counter
+
+func (m multiCounter) Inc() {
+	for _, c := range m {
+		c.Inc()
+	}
+}
+
+func (m multiCounter) Add(delta float64) {
+	for _, c := range m {
+		c.Add(delta)
+	}
+}
+
+type multiGauge []Gauge
+
+func (m multiGauge) Set(value float64) {
+	for _, g := range m {
+		g.Set(value)
+	}
+}
+
+func (m multiGauge) Inc() {
+	for _, g := range m {
+		g.Inc()
+	}
+}
+
+func (m multiGauge) Dec() {
+	for _, g := range m {
+		g.Dec()
+	}
+}
+
+func (m multiGauge) Add(delta float64) {
+	for _, g := range m {
+		g.Add(delta)
+	}
+}
+
+func (m multiGauge) Sub(delta float64) {
+	for _, g := range m {
+		g.Sub(delta)
+	}
+}
+
+type multiHistogram []Histogram
+
+func (m multiHistogram) Observe(value float64) {
+	for _, h := range m {
+		h.Observe(value)
+	}
+}
@@ -0,0 +1,40 @@
+package metrics
+
+import "testing"
+
+func TestMulti(t *testing.T) {
+	a, b := newFakeProvider(), newFakeProvider()
+	m := Multi(a, b, NoopProvider{})
+	labels := map[string]string{"stage": "sink"}
+
+	c := m.Counter("c", labels)
+	c.Inc()
+	c.Add(2)
+
+	g := m.Gauge("g", labels)
+	g.Set(10)
+	g.Inc()
+	g.Dec()
+	g.Add(5)
+	g.Sub(3)
+
+	m.Histogram("h", labels).Observe(4)
+
+	depth := 0.0
+	m.GaugeFunc("depth", labels, func() float64 { return depth })
+	depth = 42
+
+	for i, p := range []*fakeProvider{a, b} {
+		if p.value("c{stage=sink}") != 3 || p.value("g{stage=sink}") != 12 || p.value("h{stage=sink}") != 4 ||
+			p.value("depth{stage=sink}") != 42 {
+			t.Fatalf("provider %d got %v", i, p.values)
+		}
+	}
+}
+
+func TestMulti_Empty(t *testing.T) {
+	m := Multi()
+	m.Counter("c", nil).Inc()
+	m.Gauge("g", nil).Set(1)
+	m.Histogram("h", nil).Observe(1)
+}
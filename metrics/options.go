@@ -0,0 +1,78 @@
+package metrics
+
+import (
+	"sort"
+
+	"github.com/planx-lab/planx-common/clock"
+)
+
+// DefaultLatencyBuckets are bucket boundaries in milliseconds suited to
+// batch and request latencies.
+var DefaultLatencyBuckets = []float64{1, 2.5, 5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000}
+
+// HistogramOptions configures the distribution of a histogram. Backends use
+// what they support: bucketed backends read Buckets, summary-based ones read
+// Objectives. Zero values leave the choice to the backend.
+type HistogramOptions struct {
+	Buckets    []float64           // upper bounds, ascending
+	Objectives map[float64]float64 // quantile -> allowed absolute error
+}
+
+// HistogramOption configures a histogram created by Provider.Histogram.
+type HistogramOption func(*HistogramOptions)
+
+// WithBuckets sets the bucket upper bounds. They are sorted and deduplicated.
+func WithBuckets(bounds ...float64) HistogramOption {
+	return func(o *HistogramOptions) {
+		b := append([]float64(nil), bounds...)
+		sort.Float64s(b)
+		out := b[:0]
+		for i, v := range b {
+			if i == 0 || v != b[i-1] {
+				out = append(out, v)
+			}
+		}
+		o.Buckets = out
+	}
+}
+
+// WithObjectives sets the summary quantile objectives, e.g.
+// {0.5: 0.05, 0.99: 0.001}.
+func WithObjectives(objectives map[float64]float64) HistogramOption {
+	return func(o *HistogramOptions) {
+		o.Objectives = make(map[float64]float64, len(objectives))
+		for q, e := range objectives {
+			o.Objectives[q] = e
+		}
+	}
+}
+
+// NewHistogramOptions applies opts, for Provider implementations.
+func NewHistogramOptions(opts ...HistogramOption) HistogramOptions {
+	var o HistogramOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// ClockOption configures the clock of a Rate, EWMA or Timer.
+type ClockOption func(*clockOptions)
+
+type clockOptions struct {
+	clock clock.Clock
+}
+
+// WithClock sets the clock time is read from. The default is clock.Real;
+// tests pass a clock.Fake.
+func WithClock(c clock.Clock) ClockOption {
+	return func(o *clockOptions) { o.clock = c }
+}
+
+func newClockOptions(opts []ClockOption) clockOptions {
+	o := clockOptions{clock: clock.Real}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
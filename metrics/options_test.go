@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+)
+
+func TestNewHistogramOptions(t *testing.T) {
+	o := NewHistogramOptions(
+		WithBuckets(10, 1, 5, 5),
+		WithObjectives(map[float64]float64{0.5: 0.05, 0.99: 0.001}),
+	)
+	want := []float64{1, 5, 10}
+	if len(o.Buckets) != len(want) {
+		t.Fatalf("got buckets %v, want %v", o.Buckets, want)
+	}
+	for i := range want {
+		if o.Buckets[i] != want[i] {
+			t.Fatalf("got buckets %v, want %v", o.Buckets, want)
+		}
+	}
+	if o.Objectives[0.99] != 0.001 || len(o.Objectives) != 2 {
+		t.Fatalf("unexpected objectives %v", o.Objectives)
+	}
+
+	if z := NewHistogramOptions(); z.Buckets != nil || z.Objectives != nil {
+		t.Fatalf("expected zero options, got %+v", z)
+	}
+}
+
+func TestWithBuckets_CopiesInput(t *testing.T) {
+	in := []float64{3, 1, 2}
+	NewHistogramOptions(WithBuckets(in...))
+	if in[0] != 3 {
+		t.Fatal("WithBuckets should not modify its input")
+	}
+}
+
+func TestHistogramOptions_Forwarded(t *testing.T) {
+	p := newFakeProvider()
+	Multi(p).Histogram("h", nil, WithBuckets(1, 2))
+	if got := p.histOpts["h{}"].Buckets; len(got) != 2 {
+		t.Fatalf("Multi should forward options, got %v", got)
+	}
+
+	v, err := NewHistogramVecWithOptions(p, "hv", []string{"stage"}, WithBuckets(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	v.WithLabelValues("sink").Observe(1)
+	if got := p.histOpts["hv{stage=sink}"].Buckets; len(got) != 1 || got[0] != 5 {
+		t.Fatalf("vec should forward options, got %v", got)
+	}
+
+	NewRecorder(p).RecordBatchLatency(context.Background(), "mysql", 1)
+	if got := p.histOpts["planx.batch.latency_ms{plugin=mysql}"].Buckets; len(got) != len(DefaultLatencyBuckets) {
+		t.Fatalf("recorder should use the default latency buckets, got %v", got)
+	}
+}
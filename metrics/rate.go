@@ -0,0 +1,138 @@
+package metrics
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/planx-lab/planx-common/clock"
+)
+
+// rateBuckets is the number of buckets a Rate window is split into.
+const rateBuckets = 10
+
+// Rate measures events per second over a sliding window, e.g. records/sec
+// for adaptive batch sizing. It is safe for concurrent use.
+type Rate struct {
+	window time.Duration
+	width  time.Duration
+	clock  clock.Clock
+
+	mu      sync.Mutex
+	buckets [rateBuckets]float64
+	starts  [rateBuckets]time.Time
+}
+
+// NewRate creates a rate over window, which must be positive. Windows
+// shorter than a nanosecond per bucket are rounded up to one.
+func NewRate(window time.Duration, opts ...ClockOption) *Rate {
+	if window <= 0 {
+		panic("metrics: NewRate with non-positive window")
+	}
+	window = max(window, rateBuckets)
+	return &Rate{window: window, width: window / rateBuckets, clock: newClockOptions(opts).clock}
+}
+
+// Observe adds n events at the current time.
+func (r *Rate) Observe(n float64) {
+	now := r.clock.Now()
+	start := now.Truncate(r.width)
+	i := int(start.UnixNano()/int64(r.width)) % rateBuckets
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.starts[i].Equal(start) {
+		r.starts[i] = start
+		r.buckets[i] = 0
+	}
+	r.buckets[i] += n
+}
+
+// Value returns the events per second over the window ending now.
+func (r *Rate) Value() float64 {
+	cutoff := r.clock.Now().Add(-r.window)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var sum float64
+	for i, start := range r.starts {
+		if start.After(cutoff) {
+			sum += r.buckets[i]
+		}
+	}
+	return sum / r.window.Seconds()
+}
+
+// ewmaInterval is the tick at which an EWMA folds observations into its rate.
+const ewmaInterval = time.Second
+
+// EWMA is an exponentially weighted moving average of events per second, in
+// the style of Unix load averages: recent seconds weigh more, and the
+// influence of a second decays with time constant tau. It is safe for
+// concurrent use.
+type EWMA struct {
+	alpha float64
+	clock clock.Clock
+
+	mu       sync.Mutex
+	rate     float64
+	pending  float64
+	started  bool
+	lastTick time.Time
+}
+
+// NewEWMA creates an average with time constant tau, which must be positive.
+func NewEWMA(tau time.Duration, opts ...ClockOption) *EWMA {
+	if tau <= 0 {
+		panic("metrics: NewEWMA with non-positive tau")
+	}
+	return &EWMA{
+		alpha: 1 - math.Exp(-ewmaInterval.Seconds()/tau.Seconds()),
+		clock: newClockOptions(opts).clock,
+	}
+}
+
+// Observe adds n events at the current time.
+func (e *EWMA) Observe(n float64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.tickLocked()
+	e.pending += n
+}
+
+// Value returns the current average rate in events per second.
+func (e *EWMA) Value() float64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.tickLocked()
+	return e.rate
+}
+
+// tickLocked folds pending events into the rate for every interval elapsed
+// since the last tick. The first interval seeds the rate directly.
+func (e *EWMA) tickLocked() {
+	now := e.clock.Now()
+	if e.lastTick.IsZero() {
+		e.lastTick = now
+		return
+	}
+	ticks := int64(now.Sub(e.lastTick) / ewmaInterval)
+	if ticks <= 0 {
+		return
+	}
+	e.lastTick = e.lastTick.Add(time.Duration(ticks) * ewmaInterval)
+
+	// Pending events all belong to the first elapsed interval; the others
+	// saw no events and only decay the rate.
+	instant := e.pending / ewmaInterval.Seconds()
+	e.pending = 0
+	if e.started {
+		e.rate += e.alpha * (instant - e.rate)
+	} else {
+		e.rate = instant
+		e.started = true
+	}
+	if ticks > 1 {
+		e.rate *= math.Pow(1-e.alpha, float64(ticks-1))
+	}
+}
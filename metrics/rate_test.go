@@ -0,0 +1,109 @@
+package metrics
+
+import (
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/planx-lab/planx-common/clock"
+)
+
+func TestRate(t *testing.T) {
+	clk := clock.NewFake(time.Unix(1_000_000, 0))
+	r := NewRate(10*time.Second, WithClock(clk))
+
+	for i := 0; i < 10; i++ {
+		r.Observe(100)
+		clk.Add(time.Second)
+	}
+	if got := r.Value(); got != 90 {
+		// The oldest bucket has just left the window.
+		t.Fatalf("got %v records/sec, want 90", got)
+	}
+
+	clk.Add(20 * time.Second)
+	if got := r.Value(); got != 0 {
+		t.Fatalf("expected the window to be empty, got %v", got)
+	}
+
+	r.Observe(50)
+	if got := r.Value(); got != 5 {
+		t.Fatalf("got %v, want 5", got)
+	}
+}
+
+func TestRate_Concurrent(t *testing.T) {
+	r := NewRate(time.Minute)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				r.Observe(1)
+				_ = r.Value()
+			}
+		}()
+	}
+	wg.Wait()
+	if got := r.Value() * 60; math.Abs(got-800) > 1e-9 {
+		t.Fatalf("got %v events in window, want 800", got)
+	}
+}
+
+func TestEWMA(t *testing.T) {
+	clk := clock.NewFake(time.Unix(1_000_000, 0))
+	e := NewEWMA(5*time.Second, WithClock(clk))
+
+	if e.Value() != 0 {
+		t.Fatal("expected zero before any tick")
+	}
+
+	// A steady 100/s converges to 100.
+	for i := 0; i < 60; i++ {
+		e.Observe(100)
+		clk.Add(time.Second)
+	}
+	if got := e.Value(); math.Abs(got-100) > 1e-6 {
+		t.Fatalf("got %v, want ~100", got)
+	}
+
+	// After going idle for one time constant the rate drops to 1/e.
+	clk.Add(5 * time.Second)
+	if got, want := e.Value(), 100*math.Exp(-1); math.Abs(got-want) > 1e-6 {
+		t.Fatalf("got %v, want ~%v", got, want)
+	}
+}
+
+func TestEWMA_SeedsFromFirstInterval(t *testing.T) {
+	clk := clock.NewFake(time.Unix(1_000_000, 0))
+	e := NewEWMA(time.Minute, WithClock(clk))
+
+	e.Observe(0) // starts the clock
+	e.Observe(30)
+	clk.Add(time.Second)
+	if got := e.Value(); got != 30 {
+		t.Fatalf("got %v, want 30", got)
+	}
+}
+
+func TestRate_InvalidWindowPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic")
+		}
+	}()
+	NewRate(0)
+}
+
+func TestRate_TinyWindow(t *testing.T) {
+	clk := clock.NewFake(time.Unix(1_000_000, 0))
+	r := NewRate(time.Nanosecond, WithClock(clk))
+
+	r.Observe(3)
+	// The window is rounded up to one nanosecond per bucket.
+	if got, want := r.Value(), 3/(rateBuckets*time.Nanosecond).Seconds(); got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,136 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+)
+
+// Standard instrument names used by NewRecorder and NewRequestRecorder.
+const (
+	MetricBatchesProcessed = "planx.batches.processed"
+	MetricRecordsProcessed = "planx.records.processed"
+	MetricBatchLatency     = "planx.batch.latency_ms"
+	MetricSessionsActive   = "planx.sessions.active"
+	MetricErrors           = "planx.errors.total"
+	MetricRequests         = "planx.requests.total"
+	MetricRequestLatency   = "planx.request.latency_ms"
+	MetricRequestsInFlight = "planx.requests.inflight"
+)
+
+// Standard label keys used by NewRecorder and NewRequestRecorder.
+const (
+	LabelPlugin    = "plugin"
+	LabelErrorType = "error_type"
+	LabelProtocol  = "protocol"
+	LabelRoute     = "route"
+	LabelStatus    = "status"
+)
+
+// NewRecorder returns a Recorder backed by p. Instruments are created on first
+// use per plugin (and error type) and reused afterwards.
+func NewRecorder(p Provider) Recorder {
+	return newRecorder(p)
+}
+
+// NewRequestRecorder returns a RequestRecorder backed by p, recording the
+// planx.request* instruments per protocol, route and status.
+func NewRequestRecorder(p Provider) RequestRecorder {
+	return newRecorder(p)
+}
+
+func newRecorder(p Provider) *recorder {
+	return &recorder{
+		provider:   p,
+		counters:   make(map[instrumentKey]Counter),
+		gauges:     make(map[instrumentKey]Gauge),
+		histograms: make(map[instrumentKey]Histogram),
+	}
+}
+
+type instrumentKey struct {
+	name, plugin, errorType string
+	protocol, route, status string
+}
+
+type recorder struct {
+	provider Provider
+
+	mu         sync.Mutex
+	counters   map[instrumentKey]Counter
+	gauges     map[instrumentKey]Gauge
+	histograms map[instrumentKey]Histogram
+}
+
+func (r *recorder) RecordBatchProcessed(_ context.Context, pluginName string, recordCount int) {
+	r.counter(instrumentKey{name: MetricBatchesProcessed, plugin: pluginName}).Inc()
+	r.counter(instrumentKey{name: MetricRecordsProcessed, plugin: pluginName}).Add(float64(recordCount))
+}
+
+func (r *recorder) RecordBatchLatency(_ context.Context, pluginName string, latencyMs float64) {
+	r.histogram(instrumentKey{name: MetricBatchLatency, plugin: pluginName}).Observe(latencyMs)
+}
+
+func (r *recorder) RecordSessionActive(_ context.Context, pluginName string, count int) {
+	r.gauge(instrumentKey{name: MetricSessionsActive, plugin: pluginName}).Set(float64(count))
+}
+
+func (r *recorder) RecordError(_ context.Context, pluginName string, errorType string) {
+	r.counter(instrumentKey{name: MetricErrors, plugin: pluginName, errorType: errorType}).Inc()
+}
+
+func (r *recorder) RecordRequest(_ context.Context, protocol, route, status string, latencyMs float64) {
+	r.counter(instrumentKey{name: MetricRequests, protocol: protocol, route: route, status: status}).Inc()
+	r.histogram(instrumentKey{name: MetricRequestLatency, protocol: protocol, route: route}).Observe(latencyMs)
+}
+
+func (r *recorder) RecordRequestInFlight(_ context.Context, protocol, route string, delta int) {
+	r.gauge(instrumentKey{name: MetricRequestsInFlight, protocol: protocol, route: route}).Add(float64(delta))
+}
+
+func (k instrumentKey) labels() map[string]string {
+	if k.protocol != "" {
+		labels := map[string]string{LabelProtocol: k.protocol, LabelRoute: k.route}
+		if k.status != "" {
+			labels[LabelStatus] = k.status
+		}
+		return labels
+	}
+	labels := map[string]string{LabelPlugin: k.plugin}
+	if k.errorType != "" {
+		labels[LabelErrorType] = k.errorType
+	}
+	return labels
+}
+
+func (r *recorder) counter(k instrumentKey) Counter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c, ok := r.counters[k]
+	if !ok {
+		c = r.provider.Counter(k.name, k.labels())
+		r.counters[k] = c
+	}
+	return c
+}
+
+func (r *recorder) gauge(k instrumentKey) Gauge {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	g, ok := r.gauges[k]
+	if !ok {
+		g = r.provider.Gauge(k.name, k.labels())
+		r.gauges[k] = g
+	}
+	return g
+}
+
+func (r *recorder) histogram(k instrumentKey) Histogram {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	h, ok := r.histograms[k]
+	if !ok {
+		h = r.provider.Histogram(k.name, k.labels(), WithBuckets(DefaultLatencyBuckets...))
+		r.histograms[k] = h
+	}
+	return h
+}
@@ -0,0 +1,132 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeProvider records instrument creation and values for tests.
+type fakeProvider struct {
+	mu       sync.Mutex
+	created  map[string]int
+	values   map[string]float64
+	funcs    map[string]func() float64
+	histOpts map[string]HistogramOptions
+}
+
+func newFakeProvider() *fakeProvider {
+	return &fakeProvider{created: map[string]int{}, values: map[string]float64{}, funcs: map[string]func() float64{}, histOpts: map[string]HistogramOptions{}}
+}
+
+func fakeKey(name string, labels map[string]string) string {
+	parts := make([]string, 0, len(labels))
+	for k, v := range labels {
+		parts = append(parts, k+"="+v)
+	}
+	sort.Strings(parts)
+	return fmt.Sprintf("%s{%s}", name, strings.Join(parts, ","))
+}
+
+func (p *fakeProvider) instrument(name string, labels map[string]string) *fakeInstrument {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	key := fakeKey(name, labels)
+	p.created[key]++
+	return &fakeInstrument{p: p, key: key}
+}
+
+// value returns the recorded value for key, sampling gauge funcs.
+func (p *fakeProvider) value(key string) float64 {
+	p.mu.Lock()
+	fn, ok := p.funcs[key]
+	v := p.values[key]
+	p.mu.Unlock()
+	if ok {
+		return fn()
+	}
+	return v
+}
+
+func (p *fakeProvider) Counter(name string, labels map[string]string) Counter {
+	return p.instrument(name, labels)
+}
+
+func (p *fakeProvider) Gauge(name string, labels map[string]string) Gauge {
+	return p.instrument(name, labels)
+}
+
+func (p *fakeProvider) Histogram(name string, labels map[string]string, opts ...HistogramOption) Histogram {
+	p.mu.Lock()
+	p.histOpts[fakeKey(name, labels)] = NewHistogramOptions(opts...)
+	p.mu.Unlock()
+	return p.instrument(name, labels)
+}
+
+func (p *fakeProvider) GaugeFunc(name string, labels map[string]string, fn func() float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	key := fakeKey(name, labels)
+	p.created[key]++
+	p.funcs[key] = fn
+}
+
+// fakeInstrument implements Counter, Gauge and Histogram. Histograms record
+// the sum of observations.
+type fakeInstrument struct {
+	p   *fakeProvider
+	key string
+}
+
+func (i *fakeInstrument) update(fn func(float64) float64) {
+	i.p.mu.Lock()
+	defer i.p.mu.Unlock()
+	i.p.values[i.key] = fn(i.p.values[i.key])
+}
+
+func (i *fakeInstrument) Inc()                  { i.Add(1) }
+func (i *fakeInstrument) Dec()                  { i.Add(-1) }
+func (i *fakeInstrument) Add(d float64)         { i.update(func(v float64) float64 { return v + d }) }
+func (i *fakeInstrument) Sub(d float64)         { i.Add(-d) }
+func (i *fakeInstrument) Set(v float64)         { i.update(func(float64) float64 { return v }) }
+func (i *fakeInstrument) Observe(value float64) { i.Add(value) }
+
+func TestNewRecorder(t *testing.T) {
+	p := newFakeProvider()
+	r := NewRecorder(p)
+	ctx := context.Background()
+
+	r.RecordBatchProcessed(ctx, "mysql", 100)
+	r.RecordBatchProcessed(ctx, "mysql", 50)
+	r.RecordBatchLatency(ctx, "mysql", 12.5)
+	r.RecordSessionActive(ctx, "mysql", 3)
+	r.RecordSessionActive(ctx, "mysql", 2)
+	r.RecordError(ctx, "mysql", "timeout")
+
+	checks := map[string]float64{
+		"planx.batches.processed{plugin=mysql}":               2,
+		"planx.records.processed{plugin=mysql}":               150,
+		"planx.batch.latency_ms{plugin=mysql}":                12.5,
+		"planx.sessions.active{plugin=mysql}":                 2,
+		"planx.errors.total{error_type=timeout,plugin=mysql}": 1,
+	}
+	for key, want := range checks {
+		if got := p.value(key); got != want {
+			t.Errorf("%s = %v, want %v", key, got, want)
+		}
+	}
+	for key, n := range p.created {
+		if n != 1 {
+			t.Errorf("%s created %d times, want once", key, n)
+		}
+	}
+}
+
+func TestNewRecorder_Noop(t *testing.T) {
+	r := NewRecorder(NoopProvider{})
+	r.RecordBatchProcessed(context.Background(), "http", 1)
+	r.RecordError(context.Background(), "http", "x")
+}
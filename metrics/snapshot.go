@@ -0,0 +1,68 @@
+package metrics
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// Metric kinds reported in MetricPoint.Kind.
+const (
+	KindCounter   = "counter"
+	KindGauge     = "gauge"
+	KindHistogram = "histogram"
+)
+
+// ErrSnapshotUnsupported is returned by wrappers whose underlying provider
+// cannot take snapshots.
+var ErrSnapshotUnsupported = errors.New("metrics: provider does not support snapshots")
+
+// Snapshotter is implemented by providers that can report their current
+// values, such as MemoryProvider.
+type Snapshotter interface {
+	Snapshot() ([]MetricPoint, error)
+}
+
+// MetricPoint is the current value of one instrument.
+type MetricPoint struct {
+	Name    string            `json:"name"`
+	Kind    string            `json:"kind"`
+	Labels  map[string]string `json:"labels,omitempty"`
+	Value   float64           `json:"value"`             // counters and gauges
+	Count   uint64            `json:"count,omitempty"`   // histograms
+	Sum     float64           `json:"sum,omitempty"`     // histograms
+	Buckets []Bucket          `json:"buckets,omitempty"` // histograms, cumulative
+}
+
+// Bucket is a cumulative histogram bucket.
+type Bucket struct {
+	UpperBound float64 `json:"le"`
+	Count      uint64  `json:"count"`
+}
+
+// DebugHandler serves a JSON snapshot of p's metrics, for inspecting them
+// over a local socket when no scraper is configured. It responds 501 if p
+// cannot take snapshots.
+func DebugHandler(p Provider) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		s, ok := p.(Snapshotter)
+		if !ok {
+			http.Error(w, ErrSnapshotUnsupported.Error(), http.StatusNotImplemented)
+			return
+		}
+		points, err := s.Snapshot()
+		if errors.Is(err, ErrSnapshotUnsupported) {
+			http.Error(w, err.Error(), http.StatusNotImplemented)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if points == nil {
+			points = []MetricPoint{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(points)
+	})
+}
@@ -0,0 +1,58 @@
+package metrics
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDebugHandler(t *testing.T) {
+	p := NewMemoryProvider()
+	p.Counter("planx.batches", map[string]string{"stage": "sink"}).Add(4)
+
+	rr := httptest.NewRecorder()
+	DebugHandler(p).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/debug/metrics", nil))
+	if rr.Code != http.StatusOK || rr.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("got %d %q", rr.Code, rr.Header().Get("Content-Type"))
+	}
+	var points []MetricPoint
+	if err := json.Unmarshal(rr.Body.Bytes(), &points); err != nil {
+		t.Fatal(err)
+	}
+	if len(points) != 1 || points[0].Value != 4 || points[0].Kind != KindCounter {
+		t.Fatalf("unexpected points %+v", points)
+	}
+}
+
+func TestDebugHandler_Empty(t *testing.T) {
+	rr := httptest.NewRecorder()
+	DebugHandler(NewMemoryProvider()).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rr.Body.String() != "[]\n" {
+		t.Fatalf("expected an empty array, got %q", rr.Body.String())
+	}
+}
+
+func TestDebugHandler_Unsupported(t *testing.T) {
+	for name, p := range map[string]Provider{
+		"noop":           NoopProvider{},
+		"validated noop": Validated(NoopProvider{}),
+	} {
+		rr := httptest.NewRecorder()
+		DebugHandler(p).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+		if rr.Code != http.StatusNotImplemented {
+			t.Errorf("%s: got %d, want 501", name, rr.Code)
+		}
+	}
+}
+
+func TestDebugHandler_ThroughValidated(t *testing.T) {
+	mem := NewMemoryProvider()
+	Validated(mem).Counter("planx.batches", nil).Inc()
+
+	rr := httptest.NewRecorder()
+	DebugHandler(Validated(mem)).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("got %d", rr.Code)
+	}
+}
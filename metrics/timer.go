@@ -0,0 +1,39 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/planx-lab/planx-common/clock"
+)
+
+// Timer measures durations into a Histogram, always in milliseconds, so
+// latency instruments agree on their unit. Name such histograms with an
+// "_ms" suffix.
+type Timer struct {
+	h     Histogram
+	clock clock.Clock
+}
+
+// NewTimer returns a timer observing into h.
+func NewTimer(h Histogram, opts ...ClockOption) *Timer {
+	return &Timer{h: h, clock: newClockOptions(opts).clock}
+}
+
+// Start begins a measurement and returns a function that observes the
+// elapsed time when called:
+//
+//	defer timer.Start()()
+func (t *Timer) Start() (stop func()) {
+	start := t.clock.Now()
+	return func() { t.ObserveDuration(start) }
+}
+
+// ObserveDuration observes the time elapsed since start.
+func (t *Timer) ObserveDuration(since time.Time) {
+	t.Observe(t.clock.Now().Sub(since))
+}
+
+// Observe records d in milliseconds.
+func (t *Timer) Observe(d time.Duration) {
+	t.h.Observe(float64(d) / float64(time.Millisecond))
+}
@@ -0,0 +1,31 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/planx-lab/planx-common/clock"
+)
+
+func TestTimer(t *testing.T) {
+	p := newFakeProvider()
+	base := time.Unix(1_000_000, 0)
+	clk := clock.NewFake(base)
+	timer := NewTimer(p.Histogram("planx.flush.latency_ms", nil), WithClock(clk))
+
+	stop := timer.Start()
+	clk.Add(250 * time.Millisecond)
+	stop()
+
+	timer.ObserveDuration(base.Add(100 * time.Millisecond))
+	timer.Observe(1500 * time.Microsecond)
+
+	if got := p.value("planx.flush.latency_ms{}"); got != 250+150+1.5 {
+		t.Fatalf("observed %v ms, want 401.5", got)
+	}
+}
+
+func TestTimer_Noop(t *testing.T) {
+	timer := NewTimer(NoopHistogram{})
+	timer.Start()()
+}
@@ -0,0 +1,194 @@
+package metrics
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/planx-lab/planx-common/logger"
+)
+
+// NamePrefix is the prefix required on every metric name.
+const NamePrefix = "planx."
+
+// DefaultAllowedLabels are the label keys Validated accepts by default.
+var DefaultAllowedLabels = []string{
+	"tenant_id", "stage", "plugin", "plugin_type", "error_type",
+	"protocol", "method", "route", "status", "code",
+}
+
+// HistogramUnitSuffixes are the unit suffixes a histogram name must end with.
+// Durations are always in milliseconds (see Timer).
+var HistogramUnitSuffixes = []string{"_ms", "_bytes", "_records", "_ratio"}
+
+// nonCanonicalUnits are time units that must be expressed in _ms instead.
+var nonCanonicalUnits = []string{"_s", "_sec", "_secs", "_seconds", "_millis", "_milliseconds", "_us", "_ns"}
+
+var nameSegmentRe = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// ValidationOption configures Validated.
+type ValidationOption func(*validated)
+
+// WithStrict makes violations panic instead of being logged and sanitized.
+// Use it in development and tests to fail fast.
+func WithStrict(strict bool) ValidationOption {
+	return func(v *validated) { v.strict = strict }
+}
+
+// WithAllowedLabels replaces the allowed label keys.
+func WithAllowedLabels(keys ...string) ValidationOption {
+	return func(v *validated) {
+		v.allowed = make(map[string]bool, len(keys))
+		for _, k := range keys {
+			v.allowed[k] = true
+		}
+	}
+}
+
+// Validated wraps p so that every instrument follows the naming conventions:
+// names start with NamePrefix and are dot-separated snake_case segments,
+// histograms end with one of HistogramUnitSuffixes, durations use _ms, and
+// label keys come from an allow-list (DefaultAllowedLabels unless
+// WithAllowedLabels is given).
+//
+// By default violations are logged once per name and sanitized: names are
+// lowercased and prefixed, invalid characters replaced with '_', and
+// disallowed labels dropped. WithStrict(true) panics instead.
+func Validated(p Provider, opts ...ValidationOption) Provider {
+	v := &validated{next: p, reported: make(map[string]bool)}
+	WithAllowedLabels(DefaultAllowedLabels...)(v)
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v
+}
+
+type validated struct {
+	next    Provider
+	strict  bool
+	allowed map[string]bool
+
+	mu       sync.Mutex
+	reported map[string]bool
+}
+
+func (v *validated) Counter(name string, labels map[string]string) Counter {
+	name, labels = v.check(name, labels, false)
+	return v.next.Counter(name, labels)
+}
+
+func (v *validated) Gauge(name string, labels map[string]string) Gauge {
+	name, labels = v.check(name, labels, false)
+	return v.next.Gauge(name, labels)
+}
+
+func (v *validated) Histogram(name string, labels map[string]string, opts ...HistogramOption) Histogram {
+	name, labels = v.check(name, labels, true)
+	return v.next.Histogram(name, labels, opts...)
+}
+
+func (v *validated) GaugeFunc(name string, labels map[string]string, fn func() float64) {
+	name, labels = v.check(name, labels, false)
+	v.next.GaugeFunc(name, labels, fn)
+}
+
+// check validates name and labels and returns the sanitized versions.
+func (v *validated) check(name string, labels map[string]string, histogram bool) (string, map[string]string) {
+	var problems []string
+	if err := ValidateName(name, histogram); err != nil {
+		problems = append(problems, err.Error())
+	}
+	var dropped []string
+	for k := range labels {
+		if !v.allowed[k] {
+			dropped = append(dropped, k)
+		}
+	}
+	if len(dropped) > 0 {
+		problems = append(problems, fmt.Sprintf("labels not allowed: %s", strings.Join(dropped, ", ")))
+	}
+	if len(problems) == 0 {
+		return name, labels
+	}
+
+	msg := fmt.Sprintf("metrics: %s: %s", name, strings.Join(problems, "; "))
+	if v.strict {
+		panic(msg)
+	}
+	v.report(name, msg)
+
+	clean := sanitizeName(name)
+	if len(dropped) == 0 {
+		return clean, labels
+	}
+	kept := make(map[string]string, len(labels))
+	for k, val := range labels {
+		if v.allowed[k] {
+			kept[k] = val
+		}
+	}
+	return clean, kept
+}
+
+func (v *validated) report(name, msg string) {
+	v.mu.Lock()
+	seen := v.reported[name]
+	v.reported[name] = true
+	v.mu.Unlock()
+	if !seen {
+		logger.Warn().Str("metric", name).Msg(msg)
+	}
+}
+
+// ValidateName checks name against the naming conventions enforced by
+// Validated. histogram additionally requires a unit suffix.
+func ValidateName(name string, histogram bool) error {
+	if !strings.HasPrefix(name, NamePrefix) {
+		return fmt.Errorf("name must start with %q", NamePrefix)
+	}
+	for _, seg := range strings.Split(strings.TrimPrefix(name, NamePrefix), ".") {
+		if !nameSegmentRe.MatchString(seg) {
+			return fmt.Errorf("segment %q is not snake_case", seg)
+		}
+	}
+	for _, suffix := range nonCanonicalUnits {
+		if strings.HasSuffix(name, suffix) {
+			return fmt.Errorf("unit suffix %q must be expressed as _ms", suffix)
+		}
+	}
+	if histogram {
+		for _, suffix := range HistogramUnitSuffixes {
+			if strings.HasSuffix(name, suffix) {
+				return nil
+			}
+		}
+		return fmt.Errorf("histogram name must end with a unit suffix (%s)", strings.Join(HistogramUnitSuffixes, ", "))
+	}
+	return nil
+}
+
+// sanitizeName lowercases name, replaces characters outside [a-z0-9_.] with
+// '_' and adds NamePrefix if missing. Unit problems cannot be fixed and are
+// only reported.
+func sanitizeName(name string) string {
+	name = strings.ToLower(name)
+	name = strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '_' || r == '.' {
+			return r
+		}
+		return '_'
+	}, name)
+	if !strings.HasPrefix(name, NamePrefix) {
+		name = NamePrefix + strings.TrimPrefix(name, "planx_")
+	}
+	return name
+}
+
+// Snapshot implements Snapshotter by delegating to the wrapped provider.
+func (v *validated) Snapshot() ([]MetricPoint, error) {
+	if s, ok := v.next.(Snapshotter); ok {
+		return s.Snapshot()
+	}
+	return nil, ErrSnapshotUnsupported
+}
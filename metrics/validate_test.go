@@ -0,0 +1,95 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+
+	"github.com/planx-lab/planx-common/logger/logtest"
+	"github.com/rs/zerolog"
+)
+
+func TestValidateName(t *testing.T) {
+	tests := []struct {
+		name      string
+		histogram bool
+		ok        bool
+	}{
+		{"planx.batches.processed", false, true},
+		{"planx.batch.latency_ms", true, true},
+		{"planx.payload_bytes", true, true},
+		{"batches.processed", false, false},
+		{"planx.Batches", false, false},
+		{"planx.batch-size", false, false},
+		{"planx..x", false, false},
+		{"planx.batch.latency", true, false},
+		{"planx.flush.latency_seconds", false, false},
+	}
+	for _, tt := range tests {
+		err := ValidateName(tt.name, tt.histogram)
+		if (err == nil) != tt.ok {
+			t.Errorf("ValidateName(%q, %v) = %v, want ok=%v", tt.name, tt.histogram, err, tt.ok)
+		}
+	}
+}
+
+func TestValidated_PassesValid(t *testing.T) {
+	p := newFakeProvider()
+	v := Validated(p, WithStrict(true))
+	v.Counter("planx.batches.processed", map[string]string{"stage": "sink"}).Inc()
+	v.Histogram("planx.batch.latency_ms", nil).Observe(1)
+	v.GaugeFunc("planx.queue.depth", map[string]string{"stage": "sink"}, func() float64 { return 3 })
+
+	if p.value("planx.batches.processed{stage=sink}") != 1 || p.value("planx.queue.depth{stage=sink}") != 3 {
+		t.Fatalf("unexpected values %v", p.values)
+	}
+}
+
+func TestValidated_StrictPanics(t *testing.T) {
+	v := Validated(NoopProvider{}, WithStrict(true))
+	for name, fn := range map[string]func(){
+		"name":  func() { v.Counter("BatchesProcessed", nil) },
+		"label": func() { v.Gauge("planx.queue.depth", map[string]string{"batch_id": "42"}) },
+		"unit":  func() { v.Histogram("planx.flush.latency", nil) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: expected a panic", name)
+				}
+			}()
+			fn()
+		}()
+	}
+}
+
+func TestValidated_Sanitizes(t *testing.T) {
+	rec := logtest.Capture(t)
+	p := newFakeProvider()
+	v := Validated(p, WithAllowedLabels("tenant_id"))
+
+	labels := map[string]string{"tenant_id": "t1", "batch_id": "42"}
+	v.Counter("Sink-Errors", labels).Inc()
+	v.Counter("Sink-Errors", labels).Inc()
+
+	if got := p.value("planx.sink_errors{tenant_id=t1}"); got != 2 {
+		t.Fatalf("expected sanitized counter, got values %v", p.values)
+	}
+	if n := len(rec.Find(zerolog.WarnLevel, "Sink-Errors")); n != 1 {
+		t.Fatalf("expected the violation to be logged once, got %d", n)
+	}
+	if labels["batch_id"] != "42" {
+		t.Fatal("caller's label map should not be modified")
+	}
+}
+
+func TestValidated_StandardRecorder(t *testing.T) {
+	r := NewRecorder(Validated(NoopProvider{}, WithStrict(true)))
+	ctx := context.Background()
+	r.RecordBatchProcessed(ctx, "mysql", 1)
+	r.RecordBatchLatency(ctx, "mysql", 1)
+	r.RecordSessionActive(ctx, "mysql", 1)
+	r.RecordError(ctx, "mysql", "timeout")
+	rr := NewRequestRecorder(Validated(NoopProvider{}, WithStrict(true)))
+	rr.RecordRequest(ctx, "grpc", "/planx.Engine/CreateSession", "OK", 1)
+	rr.RecordRequestInFlight(ctx, "grpc", "/planx.Engine/CreateSession", 1)
+}
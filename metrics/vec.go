@@ -0,0 +1,124 @@
+package metrics
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+var labelNameRe = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// CounterVec is a family of counters sharing a name and label names, with
+// one child per combination of label values.
+type CounterVec struct{ v *vec[Counter] }
+
+// GaugeVec is a family of gauges, see CounterVec.
+type GaugeVec struct{ v *vec[Gauge] }
+
+// HistogramVec is a family of histograms, see CounterVec.
+type HistogramVec struct{ v *vec[Histogram] }
+
+// NewCounterVec creates a counter family on p. Label names must be valid
+// identifiers and unique.
+func NewCounterVec(p Provider, name string, labelNames ...string) (*CounterVec, error) {
+	v, err := newVec(name, labelNames, p.Counter)
+	if err != nil {
+		return nil, err
+	}
+	return &CounterVec{v: v}, nil
+}
+
+// NewGaugeVec creates a gauge family on p.
+func NewGaugeVec(p Provider, name string, labelNames ...string) (*GaugeVec, error) {
+	v, err := newVec(name, labelNames, p.Gauge)
+	if err != nil {
+		return nil, err
+	}
+	return &GaugeVec{v: v}, nil
+}
+
+// NewHistogramVec creates a histogram family on p.
+func NewHistogramVec(p Provider, name string, labelNames ...string) (*HistogramVec, error) {
+	return NewHistogramVecWithOptions(p, name, labelNames)
+}
+
+// NewHistogramVecWithOptions creates a histogram family on p whose children
+// are created with opts.
+func NewHistogramVecWithOptions(p Provider, name string, labelNames []string, opts ...HistogramOption) (*HistogramVec, error) {
+	v, err := newVec(name, labelNames, func(name string, labels map[string]string) Histogram {
+		return p.Histogram(name, labels, opts...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &HistogramVec{v: v}, nil
+}
+
+// WithLabelValues returns the counter for the given label values, in label
+// name order, creating it on first use. It panics if the number of values
+// does not match the number of label names.
+func (c *CounterVec) WithLabelValues(values ...string) Counter { return c.v.with(values) }
+
+// WithLabelValues returns the gauge for the given label values, see
+// CounterVec.WithLabelValues.
+func (g *GaugeVec) WithLabelValues(values ...string) Gauge { return g.v.with(values) }
+
+// WithLabelValues returns the histogram for the given label values, see
+// CounterVec.WithLabelValues.
+func (h *HistogramVec) WithLabelValues(values ...string) Histogram { return h.v.with(values) }
+
+type vec[T any] struct {
+	name       string
+	labelNames []string
+	create     func(name string, labels map[string]string) T
+
+	mu       sync.RWMutex
+	children map[string]T
+}
+
+func newVec[T any](name string, labelNames []string, create func(string, map[string]string) T) (*vec[T], error) {
+	seen := make(map[string]bool, len(labelNames))
+	for _, l := range labelNames {
+		if !labelNameRe.MatchString(l) {
+			return nil, fmt.Errorf("metrics: %s: invalid label name %q", name, l)
+		}
+		if seen[l] {
+			return nil, fmt.Errorf("metrics: %s: duplicate label name %q", name, l)
+		}
+		seen[l] = true
+	}
+	return &vec[T]{
+		name:       name,
+		labelNames: append([]string(nil), labelNames...),
+		create:     create,
+		children:   make(map[string]T),
+	}, nil
+}
+
+func (v *vec[T]) with(values []string) T {
+	if len(values) != len(v.labelNames) {
+		panic(fmt.Sprintf("metrics: %s: got %d label values, want %d", v.name, len(values), len(v.labelNames)))
+	}
+	key := strings.Join(values, "\xff")
+
+	v.mu.RLock()
+	child, ok := v.children[key]
+	v.mu.RUnlock()
+	if ok {
+		return child
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if child, ok := v.children[key]; ok {
+		return child
+	}
+	labels := make(map[string]string, len(values))
+	for i, name := range v.labelNames {
+		labels[name] = values[i]
+	}
+	child = v.create(v.name, labels)
+	v.children[key] = child
+	return child
+}
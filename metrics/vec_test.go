@@ -0,0 +1,87 @@
+package metrics
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestCounterVec(t *testing.T) {
+	p := newFakeProvider()
+	v, err := NewCounterVec(p, "planx.requests", "tenant", "stage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	v.WithLabelValues("t1", "sink").Inc()
+	v.WithLabelValues("t1", "sink").Add(2)
+	v.WithLabelValues("t2", "source").Inc()
+
+	if got := p.value("planx.requests{stage=sink,tenant=t1}"); got != 3 {
+		t.Fatalf("t1/sink = %v, want 3", got)
+	}
+	if got := p.value("planx.requests{stage=source,tenant=t2}"); got != 1 {
+		t.Fatalf("t2/source = %v, want 1", got)
+	}
+	if n := p.created["planx.requests{stage=sink,tenant=t1}"]; n != 1 {
+		t.Fatalf("child created %d times, want once", n)
+	}
+}
+
+func TestGaugeAndHistogramVec(t *testing.T) {
+	p := newFakeProvider()
+	g, err := NewGaugeVec(p, "planx.queue.depth", "stage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.WithLabelValues("sink").Set(7)
+
+	h, err := NewHistogramVec(p, "planx.latency_ms", "stage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.WithLabelValues("sink").Observe(1.5)
+
+	if p.value("planx.queue.depth{stage=sink}") != 7 || p.value("planx.latency_ms{stage=sink}") != 1.5 {
+		t.Fatalf("unexpected values %v", p.values)
+	}
+}
+
+func TestVec_LabelValidation(t *testing.T) {
+	if _, err := NewCounterVec(NoopProvider{}, "x", "tenant-id"); err == nil {
+		t.Fatal("expected an error for an invalid label name")
+	}
+	if _, err := NewGaugeVec(NoopProvider{}, "x", "stage", "stage"); err == nil {
+		t.Fatal("expected an error for a duplicate label name")
+	}
+	if _, err := NewHistogramVec(NoopProvider{}, "x", "1stage"); err == nil {
+		t.Fatal("expected an error for a label starting with a digit")
+	}
+}
+
+func TestVec_WrongValueCountPanics(t *testing.T) {
+	v, _ := NewCounterVec(NoopProvider{}, "x", "tenant")
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic")
+		}
+	}()
+	v.WithLabelValues("t1", "extra")
+}
+
+func TestVec_Concurrent(t *testing.T) {
+	p := newFakeProvider()
+	v, _ := NewCounterVec(p, "x", "tenant")
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				v.WithLabelValues("t1").Inc()
+			}
+		}()
+	}
+	wg.Wait()
+	if p.value("x{tenant=t1}") != 800 || p.created["x{tenant=t1}"] != 1 {
+		t.Fatalf("unexpected value %v or creations %d", p.value("x{tenant=t1}"), p.created["x{tenant=t1}"])
+	}
+}
@@ -0,0 +1,104 @@
+package partition
+
+import (
+	"math"
+	"sync"
+)
+
+// DefaultLoadFactor is the load bound of a Bounded ring: no node holds
+// more than 1.25 times the average load.
+const DefaultLoadFactor = 1.25
+
+// Bounded is a consistent-hash ring with bounded loads: a key goes to its
+// owner unless that node already holds LoadFactor times the average load,
+// in which case it goes to the next node clockwise with room. Hot keys
+// then spill over to neighbours instead of overloading one node, while
+// most keys keep their usual owner.
+//
+// Callers Acquire a node for each unit of work, such as a session, and
+// Release it when done. It is safe for concurrent use.
+type Bounded struct {
+	ring   *Ring
+	factor float64
+
+	mu    sync.Mutex
+	loads map[string]int
+	total int
+}
+
+// NewBounded returns an empty ring bounded by factor, which must be above
+// 1; DefaultLoadFactor is used otherwise.
+func NewBounded(factor float64, opts ...RingOption) *Bounded {
+	if factor <= 1 {
+		factor = DefaultLoadFactor
+	}
+	return &Bounded{ring: NewRing(opts...), factor: factor, loads: map[string]int{}}
+}
+
+// Add adds nodes to the ring.
+func (b *Bounded) Add(nodes ...string) { b.ring.Add(nodes...) }
+
+// Remove removes nodes from the ring. Work acquired on them is forgotten,
+// so do not Release it.
+func (b *Bounded) Remove(nodes ...string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.ring.Remove(nodes...)
+	for _, node := range nodes {
+		b.total -= b.loads[node]
+		delete(b.loads, node)
+	}
+}
+
+// Nodes returns the nodes in the ring, sorted.
+func (b *Bounded) Nodes() []string { return b.ring.Nodes() }
+
+// Acquire returns the node for key and counts one unit of load on it. It
+// returns false if the ring is empty.
+func (b *Bounded) Acquire(key string) (string, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	n := b.ring.Len()
+	if n == 0 {
+		return "", false
+	}
+	limit := b.limit(n)
+	var chosen string
+	b.ring.walk(key, func(node string) bool {
+		if b.loads[node] < limit {
+			chosen = node
+			return false
+		}
+		return true
+	})
+	if chosen == "" {
+		// Unreachable while limit exceeds the average, kept as a guard.
+		chosen, _ = b.ring.Get(key)
+	}
+	b.loads[chosen]++
+	b.total++
+	return chosen, true
+}
+
+// Release removes one unit of load from node, acquired with Acquire.
+func (b *Bounded) Release(node string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.loads[node] > 0 {
+		b.loads[node]--
+		b.total--
+	}
+}
+
+// Load returns the current load of node.
+func (b *Bounded) Load(node string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.loads[node]
+}
+
+// limit returns the most load a node may hold once one more unit is
+// placed among n nodes.
+func (b *Bounded) limit(n int) int {
+	return int(math.Ceil(b.factor * float64(b.total+1) / float64(n)))
+}
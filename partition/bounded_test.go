@@ -0,0 +1,60 @@
+package partition
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestBounded_RespectsLimit(t *testing.T) {
+	b := NewBounded(1.25)
+	b.Add("a", "b", "c", "d")
+	// Every key hashes alike, so an unbounded ring would put all on one node.
+	for i := 0; i < 100; i++ {
+		if _, ok := b.Acquire("hot"); !ok {
+			t.Fatalf("Acquire failed")
+		}
+	}
+	for _, node := range b.Nodes() {
+		if load := b.Load(node); load > 32 {
+			t.Fatalf("node %s holds %d of 100, above 1.25x the average", node, load)
+		}
+	}
+}
+
+func TestBounded_PrefersOwner(t *testing.T) {
+	b := NewBounded(0) // default factor
+	b.Add("a", "b", "c")
+	r := NewRing()
+	r.Add("a", "b", "c")
+	for i := 0; i < 30; i++ {
+		key := "key-" + strconv.Itoa(i)
+		node, _ := b.Acquire(key)
+		b.Release(node)
+		if owner, _ := r.Get(key); node != owner {
+			t.Fatalf("%s went to %s on an idle ring, owner is %s", key, node, owner)
+		}
+	}
+}
+
+func TestBounded_ReleaseAndRemove(t *testing.T) {
+	b := NewBounded(2)
+	if _, ok := b.Acquire("key"); ok {
+		t.Fatalf("Acquire on an empty ring succeeded")
+	}
+	b.Add("a", "b")
+	node, _ := b.Acquire("key")
+	if b.Load(node) != 1 {
+		t.Fatalf("load after Acquire = %d", b.Load(node))
+	}
+	b.Release(node)
+	b.Release(node)
+	if b.Load(node) != 0 {
+		t.Fatalf("load after Release = %d", b.Load(node))
+	}
+
+	node, _ = b.Acquire("key")
+	b.Remove(node)
+	if got, _ := b.Acquire("key"); got == node {
+		t.Fatalf("Acquire returned removed node %s", node)
+	}
+}
@@ -0,0 +1,38 @@
+// Package partition provides deterministic routing of keys to partitions
+// and nodes: stable modulo hashing for a fixed partition count, and
+// consistent-hash rings, with an optional load bound, for node sets that
+// change.
+// Engine-side utilities only — must not be imported by SDK or plugins.
+//
+// All hashing is xxhash64, so every process routes a key the same way:
+//
+//	p := partition.ForKey(record.Key, len(processors))
+//
+//	ring := partition.NewRing()
+//	ring.Add("proc-0", "proc-1", "proc-2")
+//	node, _ := ring.Get(sessionID)
+package partition
+
+import "github.com/cespare/xxhash/v2"
+
+// Hash returns the 64-bit hash of key used by this package.
+func Hash(key string) uint64 { return xxhash.Sum64String(key) }
+
+// ForKey returns the partition of key among n, in [0, n). The result
+// depends only on key and n, across processes and releases; changing n
+// moves most keys, so use a Ring when partitions come and go. It panics if
+// n <= 0.
+func ForKey(key string, n int) int {
+	if n <= 0 {
+		panic("partition: ForKey with n <= 0")
+	}
+	return int(Hash(key) % uint64(n))
+}
+
+// ForBytes is ForKey for a key held in a byte slice.
+func ForBytes(key []byte, n int) int {
+	if n <= 0 {
+		panic("partition: ForBytes with n <= 0")
+	}
+	return int(xxhash.Sum64(key) % uint64(n))
+}
@@ -0,0 +1,56 @@
+package partition
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestHash_Stable(t *testing.T) {
+	// xxhash64 of the empty string, fixed by the algorithm.
+	if got := Hash(""); got != 0xef46db3751d8e999 {
+		t.Fatalf("Hash(\"\") = %#x", got)
+	}
+}
+
+func TestForKey_Stable(t *testing.T) {
+	// Routing must not change across releases, or records of a key would
+	// move between processors on upgrade.
+	tests := []struct {
+		key  string
+		want int
+	}{
+		{"tenant-a", 10},
+		{"session-42", 14},
+		{"", 9},
+	}
+	for _, tt := range tests {
+		if got := ForKey(tt.key, 16); got != tt.want {
+			t.Fatalf("ForKey(%q, 16) = %d, want %d", tt.key, got, tt.want)
+		}
+		if got := ForBytes([]byte(tt.key), 16); got != tt.want {
+			t.Fatalf("ForBytes(%q, 16) = %d, want %d", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestForKey_Spread(t *testing.T) {
+	const n, keys = 8, 80000
+	counts := make([]int, n)
+	for i := 0; i < keys; i++ {
+		counts[ForKey("key-"+strconv.Itoa(i), n)]++
+	}
+	for p, c := range counts {
+		if c < keys/n*9/10 || c > keys/n*11/10 {
+			t.Fatalf("partition %d got %d of %d keys: %v", p, c, keys, counts)
+		}
+	}
+}
+
+func TestForKey_PanicsOnZero(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("ForKey(key, 0) did not panic")
+		}
+	}()
+	ForKey("key", 0)
+}
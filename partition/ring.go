@@ -0,0 +1,159 @@
+package partition
+
+import (
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// DefaultReplicas is the number of virtual nodes per node. More spread
+// keys more evenly at the cost of memory and Add time.
+const DefaultReplicas = 128
+
+// RingOption configures a Ring.
+type RingOption func(*Ring)
+
+// WithReplicas sets the number of virtual nodes per node.
+func WithReplicas(n int) RingOption {
+	return func(r *Ring) {
+		if n > 0 {
+			r.replicas = n
+		}
+	}
+}
+
+// Ring is a consistent-hash ring: each node owns the arcs before its
+// virtual nodes, so adding or removing a node moves only about 1/n of the
+// keys. It is safe for concurrent use.
+type Ring struct {
+	replicas int
+
+	mu     sync.RWMutex
+	points []uint64          // sorted virtual node hashes
+	owners map[uint64]string // virtual node hash to node
+	nodes  map[string]bool
+}
+
+// NewRing returns an empty ring.
+func NewRing(opts ...RingOption) *Ring {
+	r := &Ring{replicas: DefaultReplicas, owners: map[uint64]string{}, nodes: map[string]bool{}}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Add adds nodes to the ring. Nodes already present are ignored.
+func (r *Ring) Add(nodes ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.addLocked(nodes)
+}
+
+func (r *Ring) addLocked(nodes []string) {
+	for _, node := range nodes {
+		if r.nodes[node] {
+			continue
+		}
+		r.nodes[node] = true
+		for i := 0; i < r.replicas; i++ {
+			h := Hash(node + "#" + strconv.Itoa(i))
+			// On the rare collision the smaller node name wins, so the
+			// ring does not depend on the order nodes were added in.
+			if owner, ok := r.owners[h]; ok {
+				if owner < node {
+					continue
+				}
+			} else {
+				r.points = append(r.points, h)
+			}
+			r.owners[h] = node
+		}
+	}
+	slices.Sort(r.points)
+}
+
+// Remove removes nodes from the ring. Their keys move to the next nodes
+// clockwise.
+func (r *Ring) Remove(nodes ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	removed := false
+	for _, node := range nodes {
+		if r.nodes[node] {
+			delete(r.nodes, node)
+			removed = true
+		}
+	}
+	if !removed {
+		return
+	}
+	// Rebuild rather than delete points, so collisions the removed nodes
+	// won go back to the remaining nodes.
+	remaining := make([]string, 0, len(r.nodes))
+	for node := range r.nodes {
+		remaining = append(remaining, node)
+	}
+	r.points, r.owners, r.nodes = nil, map[uint64]string{}, map[string]bool{}
+	r.addLocked(remaining)
+}
+
+// Nodes returns the nodes in the ring, sorted.
+func (r *Ring) Nodes() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]string, 0, len(r.nodes))
+	for node := range r.nodes {
+		out = append(out, node)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Len returns the number of nodes in the ring.
+func (r *Ring) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.nodes)
+}
+
+// Get returns the node owning key, or false if the ring is empty.
+func (r *Ring) Get(key string) (string, bool) {
+	var owner string
+	r.walk(key, func(node string) bool {
+		owner = node
+		return false
+	})
+	return owner, owner != ""
+}
+
+// GetN returns up to n distinct nodes for key in ring order, the owner
+// first, for placing replicas.
+func (r *Ring) GetN(key string, n int) []string {
+	var out []string
+	r.walk(key, func(node string) bool {
+		if !slices.Contains(out, node) {
+			out = append(out, node)
+		}
+		return len(out) < n
+	})
+	return out
+}
+
+// walk calls fn with the owner of each virtual node clockwise from key's
+// hash, once around the ring, until fn returns false.
+func (r *Ring) walk(key string, fn func(node string) bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if len(r.points) == 0 {
+		return
+	}
+	h := Hash(key)
+	start := sort.Search(len(r.points), func(i int) bool { return r.points[i] >= h })
+	for i := 0; i < len(r.points); i++ {
+		if !fn(r.owners[r.points[(start+i)%len(r.points)]]) {
+			return
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package partition
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestRing_Empty(t *testing.T) {
+	r := NewRing()
+	if node, ok := r.Get("key"); ok {
+		t.Fatalf("Get on an empty ring = %q", node)
+	}
+	if got := r.GetN("key", 2); len(got) != 0 {
+		t.Fatalf("GetN on an empty ring = %v", got)
+	}
+}
+
+func TestRing_Spread(t *testing.T) {
+	r := NewRing()
+	r.Add("a", "b", "c", "d")
+	counts := map[string]int{}
+	const keys = 40000
+	for i := 0; i < keys; i++ {
+		node, _ := r.Get("key-" + strconv.Itoa(i))
+		counts[node]++
+	}
+	for node, c := range counts {
+		if c < keys/4*7/10 || c > keys/4*13/10 {
+			t.Fatalf("node %s got %d of %d keys: %v", node, c, keys, counts)
+		}
+	}
+}
+
+func TestRing_MinimalMovement(t *testing.T) {
+	r := NewRing()
+	r.Add("a", "b", "c", "d")
+	const keys = 10000
+	before := make([]string, keys)
+	for i := range before {
+		before[i], _ = r.Get("key-" + strconv.Itoa(i))
+	}
+
+	r.Add("e")
+	moved := 0
+	for i := range before {
+		node, _ := r.Get("key-" + strconv.Itoa(i))
+		if node != before[i] {
+			if node != "e" {
+				t.Fatalf("key-%d moved from %s to %s, not to the new node", i, before[i], node)
+			}
+			moved++
+		}
+	}
+	if moved < keys/5*7/10 || moved > keys/5*13/10 {
+		t.Fatalf("%d of %d keys moved, want about a fifth", moved, keys)
+	}
+
+	r.Remove("e")
+	for i := range before {
+		if node, _ := r.Get("key-" + strconv.Itoa(i)); node != before[i] {
+			t.Fatalf("key-%d on %s after removing e, want %s", i, node, before[i])
+		}
+	}
+}
+
+func TestRing_OrderIndependent(t *testing.T) {
+	r1, r2 := NewRing(WithReplicas(16)), NewRing(WithReplicas(16))
+	r1.Add("a", "b", "c")
+	r2.Add("c")
+	r2.Add("b", "a", "a")
+	for i := 0; i < 1000; i++ {
+		key := "key-" + strconv.Itoa(i)
+		n1, _ := r1.Get(key)
+		n2, _ := r2.Get(key)
+		if n1 != n2 {
+			t.Fatalf("%s: %s vs %s", key, n1, n2)
+		}
+	}
+	if !reflect.DeepEqual(r2.Nodes(), []string{"a", "b", "c"}) || r2.Len() != 3 {
+		t.Fatalf("Nodes = %v", r2.Nodes())
+	}
+}
+
+func TestRing_GetN(t *testing.T) {
+	r := NewRing()
+	r.Add("a", "b", "c")
+	got := r.GetN("key", 2)
+	owner, _ := r.Get("key")
+	if len(got) != 2 || got[0] != owner || got[0] == got[1] {
+		t.Fatalf("GetN = %v, owner %s", got, owner)
+	}
+	if got := r.GetN("key", 5); len(got) != 3 {
+		t.Fatalf("GetN beyond the node count = %v", got)
+	}
+}
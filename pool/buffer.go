@@ -0,0 +1,61 @@
+package pool
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/planx-lab/planx-common/telemetry"
+)
+
+// Buffers is a pool of bytes.Buffers.
+type Buffers struct {
+	name   string
+	maxCap int
+	free   sync.Pool
+	counters
+	leaks tracker
+}
+
+// NewBuffers returns a pool of buffers and registers its metrics under
+// name. Buffers that grew beyond maxCap are dropped when put back, so one
+// huge batch does not pin its memory for good; zero or less keeps all.
+// Like telemetry.RegisterPool it panics if name is already used.
+func NewBuffers(name string, maxCap int) *Buffers {
+	p := &Buffers{name: name, maxCap: maxCap}
+	p.leaks.pool = name
+	telemetry.RegisterPool(name, p.Stats)
+	return p
+}
+
+// Get returns an empty buffer.
+func (p *Buffers) Get() *bytes.Buffer {
+	buf, ok := p.free.Get().(*bytes.Buffer)
+	if !ok {
+		buf = new(bytes.Buffer)
+	}
+	p.counters.get(!ok)
+	if trackLeaks {
+		p.leaks.taken(buf)
+	}
+	return buf
+}
+
+// Put resets buf and returns it to the pool.
+func (p *Buffers) Put(buf *bytes.Buffer) {
+	if trackLeaks {
+		p.leaks.returned(buf)
+	}
+	keep := p.maxCap <= 0 || buf.Cap() <= p.maxCap
+	p.counters.put(!keep)
+	if keep {
+		buf.Reset()
+		p.free.Put(buf)
+	}
+}
+
+// Stats returns a snapshot of the pool's counters.
+func (p *Buffers) Stats() Stats { return p.counters.stats() }
+
+// Leaks returns the buffers taken and not put back, in planxdebug builds;
+// it is always empty otherwise.
+func (p *Buffers) Leaks() []Leak { return p.leaks.outstanding() }
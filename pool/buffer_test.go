@@ -0,0 +1,36 @@
+package pool
+
+import (
+	"testing"
+)
+
+func TestBuffers(t *testing.T) {
+	p := NewBuffers(uniqueName(t), 1024)
+	buf := p.Get()
+	buf.WriteString("hello")
+	p.Put(buf)
+
+	buf = p.Get()
+	if buf.Len() != 0 {
+		t.Fatalf("buffer not reset: %q", buf.String())
+	}
+	p.Put(buf)
+
+	big := p.Get()
+	big.Grow(4096)
+	p.Put(big)
+	if s := p.Stats(); s.Gets != 3 || s.Puts != 3 || s.Drops != 1 || s.InUse != 0 {
+		t.Fatalf("stats: %+v", s)
+	}
+}
+
+func TestBuffers_DuplicateName(t *testing.T) {
+	name := uniqueName(t)
+	NewBuffers(name, 0)
+	defer func() {
+		if recover() == nil {
+			t.Fatal("duplicate name accepted")
+		}
+	}()
+	NewBuffers(name, 0)
+}
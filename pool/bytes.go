@@ -0,0 +1,91 @@
+package pool
+
+import (
+	"math/bits"
+	"sync"
+
+	"github.com/planx-lab/planx-common/telemetry"
+)
+
+// Bytes is a pool of byte slices in power-of-two size classes. Get(n)
+// returns a slice from the smallest class that fits n, so a pool wastes
+// at most half of each slice it hands out in exchange for reusing them
+// across batches of different sizes.
+type Bytes struct {
+	name    string
+	minBits int // log2 of the smallest class
+	classes []sync.Pool
+	counters
+	leaks tracker
+}
+
+// NewBytes returns a pool with classes from minSize to maxSize, both
+// rounded up to a power of two, and registers its metrics under name.
+// Requests above maxSize are allocated and never kept. Like
+// telemetry.RegisterPool it panics if name is already used.
+func NewBytes(name string, minSize, maxSize int) *Bytes {
+	minBits := bits.Len(uint(max(minSize, 1) - 1))
+	maxBits := bits.Len(uint(max(maxSize, minSize, 1) - 1))
+	p := &Bytes{name: name, minBits: minBits, classes: make([]sync.Pool, maxBits-minBits+1)}
+	p.leaks.pool = name
+	telemetry.RegisterPool(name, p.Stats)
+	return p
+}
+
+// Get returns a slice of length n. Its capacity is the size of its class,
+// and its contents are not zeroed.
+func (p *Bytes) Get(n int) []byte {
+	n = max(n, 0)
+	class := p.class(n)
+	var b []byte
+	if class < 0 {
+		b = make([]byte, n)
+	} else if v, ok := p.classes[class].Get().(*[]byte); ok {
+		b = (*v)[:n]
+	}
+	allocated := b == nil
+	if allocated {
+		b = make([]byte, n, 1<<(p.minBits+class))
+	}
+	p.counters.get(allocated)
+	if trackLeaks && cap(b) > 0 {
+		p.leaks.taken(&b[:1][0])
+	}
+	return b
+}
+
+// Put returns b, a slice from Get (resliced or not, but from its start),
+// to the pool. Slices not of a class size are dropped.
+func (p *Bytes) Put(b []byte) {
+	if trackLeaks && cap(b) > 0 {
+		p.leaks.returned(&b[:1][0])
+	}
+	c := cap(b)
+	class := p.class(c)
+	keep := class >= 0 && c == 1<<(p.minBits+class)
+	p.counters.put(!keep)
+	if keep {
+		b = b[:0]
+		p.classes[class].Put(&b)
+	}
+}
+
+// Stats returns a snapshot of the pool's counters.
+func (p *Bytes) Stats() Stats { return p.counters.stats() }
+
+// Leaks returns the slices taken and not put back, in planxdebug builds;
+// it is always empty otherwise.
+func (p *Bytes) Leaks() []Leak { return p.leaks.outstanding() }
+
+// class returns the index of the smallest class holding n bytes, or -1 if
+// n is above the largest.
+func (p *Bytes) class(n int) int {
+	b := bits.Len(uint(max(n, 1) - 1))
+	if b < p.minBits {
+		return 0
+	}
+	if b-p.minBits >= len(p.classes) {
+		return -1
+	}
+	return b - p.minBits
+}
@@ -0,0 +1,96 @@
+package pool
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+)
+
+var poolSeq atomic.Int64
+
+// uniqueName returns a pool name not used before in this process, since
+// pool names are registered for good and tests may run more than once.
+func uniqueName(tb testing.TB) string {
+	return fmt.Sprintf("%s-%d", tb.Name(), poolSeq.Add(1))
+}
+
+func TestBytes_Classes(t *testing.T) {
+	p := NewBytes(uniqueName(t), 500, 4000)
+	tests := []struct{ n, cap int }{
+		{0, 512},
+		{1, 512},
+		{512, 512},
+		{513, 1024},
+		{4096, 4096},
+		{5000, 5000}, // above the largest class: not pooled
+	}
+	for _, tt := range tests {
+		b := p.Get(tt.n)
+		if len(b) != tt.n || cap(b) != tt.cap {
+			t.Fatalf("Get(%d): len %d cap %d, want cap %d", tt.n, len(b), cap(b), tt.cap)
+		}
+		p.Put(b)
+	}
+	s := p.Stats()
+	if s.Gets != 6 || s.Puts != 6 || s.Drops != 1 || s.InUse != 0 || s.HighWater != 1 {
+		t.Fatalf("stats: %+v", s)
+	}
+}
+
+func TestBytes_Reuse(t *testing.T) {
+	p := NewBytes(uniqueName(t), 64, 1024)
+	b := p.Get(100)
+	b[0] = 42
+	p.Put(b[:10])
+	got := p.Get(120)
+	// sync.Pool may drop values at any GC, so reuse is likely, not certain.
+	if &got[0] == &b[0] && p.Stats().Allocs != 1 {
+		t.Fatalf("reused slice counted as allocation: %+v", p.Stats())
+	}
+	p.Put(got)
+}
+
+func TestBytes_ForeignSliceDropped(t *testing.T) {
+	p := NewBytes(uniqueName(t), 64, 1024)
+	if trackLeaks {
+		t.Skip("debug builds panic on values not from the pool")
+	}
+	p.Put(make([]byte, 100))
+	if s := p.Stats(); s.Drops != 1 {
+		t.Fatalf("stats: %+v", s)
+	}
+}
+
+func TestBytes_HighWater(t *testing.T) {
+	p := NewBytes(uniqueName(t), 64, 1024)
+	var held [][]byte
+	for i := 0; i < 5; i++ {
+		held = append(held, p.Get(64))
+	}
+	for _, b := range held {
+		p.Put(b)
+	}
+	p.Put(p.Get(64))
+	if s := p.Stats(); s.HighWater != 5 || s.InUse != 0 {
+		t.Fatalf("stats: %+v", s)
+	}
+}
+
+func TestDefaultPools(t *testing.T) {
+	b := Get(1000)
+	if len(b) != 1000 || cap(b) != 1024 {
+		t.Fatalf("Get: len %d cap %d", len(b), cap(b))
+	}
+	Put(b)
+	buf := GetBuffer()
+	buf.WriteString("batch")
+	PutBuffer(buf)
+}
+
+func BenchmarkBytes(b *testing.B) {
+	p := NewBytes(uniqueName(b), 512, 1<<20)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		p.Put(p.Get(64 << 10))
+	}
+}
@@ -0,0 +1,84 @@
+package pool
+
+import (
+	"fmt"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// Leak is a value taken from a pool and not yet put back, recorded in
+// planxdebug builds.
+type Leak struct {
+	Pool  string
+	ID    uint64 // order in which values were taken, across pools
+	Stack string // where it was taken
+}
+
+func (l Leak) String() string {
+	return fmt.Sprintf("pool %s: value #%d taken and not put back, at\n%s", l.Pool, l.ID, l.Stack)
+}
+
+var (
+	leakSeq    atomic.Uint64
+	trackersMu sync.Mutex
+	trackers   []*tracker
+)
+
+// Leaks returns the values of every pool taken and not put back, oldest
+// first, in planxdebug builds; it is always empty otherwise.
+func Leaks() []Leak {
+	trackersMu.Lock()
+	all := append([]*tracker(nil), trackers...)
+	trackersMu.Unlock()
+	var out []Leak
+	for _, t := range all {
+		out = append(out, t.outstanding()...)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// tracker records the values a pool handed out, keyed by pointer. It is
+// only called when trackLeaks is set.
+type tracker struct {
+	pool   string
+	mu     sync.Mutex
+	values map[interface{}]Leak
+}
+
+func (t *tracker) taken(key interface{}) {
+	leak := Leak{Pool: t.pool, ID: leakSeq.Add(1), Stack: string(debug.Stack())}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.values == nil {
+		t.values = map[interface{}]Leak{}
+		trackersMu.Lock()
+		trackers = append(trackers, t)
+		trackersMu.Unlock()
+	}
+	t.values[key] = leak
+}
+
+// returned forgets key. A value put back twice, or that the pool never
+// handed out, is a bug that would hand one buffer to two users: it panics.
+func (t *tracker) returned(key interface{}) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.values[key]; !ok {
+		panic(fmt.Sprintf("pool %s: value put back twice or not taken from this pool", t.pool))
+	}
+	delete(t.values, key)
+}
+
+func (t *tracker) outstanding() []Leak {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := make([]Leak, 0, len(t.values))
+	for _, leak := range t.values {
+		out = append(out, leak)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
@@ -0,0 +1,37 @@
+//go:build planxdebug
+
+package pool
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLeaks_Tracked(t *testing.T) {
+	name := uniqueName(t)
+	p := NewBytes(name, 64, 1024)
+	b := p.Get(10)
+	leaks := p.Leaks()
+	if len(leaks) != 1 || leaks[0].Pool != name || !strings.Contains(leaks[0].Stack, "TestLeaks_Tracked") {
+		t.Fatalf("leaks: %v", leaks)
+	}
+	if found := Leaks(); len(found) == 0 || found[len(found)-1].ID != leaks[0].ID {
+		t.Fatalf("package leaks: %v", found)
+	}
+	p.Put(b[:3])
+	if leaks := p.Leaks(); len(leaks) != 0 {
+		t.Fatalf("leaks after put: %v", leaks)
+	}
+}
+
+func TestLeaks_DoublePut(t *testing.T) {
+	p := NewBuffers(uniqueName(t), 0)
+	buf := p.Get()
+	p.Put(buf)
+	defer func() {
+		if recover() == nil {
+			t.Fatal("double put not detected")
+		}
+	}()
+	p.Put(buf)
+}
@@ -0,0 +1,6 @@
+//go:build !planxdebug
+
+package pool
+
+// trackLeaks records where pooled values are taken in debug builds.
+const trackLeaks = false
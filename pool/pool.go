@@ -0,0 +1,92 @@
+// Package pool provides pooled byte slices and buffers for the batch
+// serialization hot paths, with metrics and leak detection.
+// Engine-side utilities only — must not be imported by SDK or plugins.
+//
+// Bytes pools slices in power-of-two size classes and Buffers pools
+// bytes.Buffers; Get, Put, GetBuffer and PutBuffer use shared default
+// pools:
+//
+//	buf := pool.GetBuffer()
+//	defer pool.PutBuffer(buf)
+//	if err := codec.Encode(buf, b); err != nil { ... }
+//
+// A value must not be used after it is put back. Every pool reports
+// planx.pool.* metrics under its name (see telemetry.RegisterPool). Builds
+// with the planxdebug tag also record where each value was taken, so tests
+// can find values never put back with pooltest.NoLeaks.
+package pool
+
+import (
+	"bytes"
+	"sync/atomic"
+
+	"github.com/planx-lab/planx-common/telemetry"
+)
+
+// Default sizes of the shared pools.
+const (
+	DefaultMinSize      = 512
+	DefaultMaxSize      = 16 << 20
+	DefaultMaxBufferCap = 4 << 20
+)
+
+var (
+	defaultBytes   = NewBytes("bytes", DefaultMinSize, DefaultMaxSize)
+	defaultBuffers = NewBuffers("buffers", DefaultMaxBufferCap)
+)
+
+// Get returns a slice of length n from the shared Bytes pool.
+func Get(n int) []byte { return defaultBytes.Get(n) }
+
+// Put returns a slice from Get to the shared Bytes pool.
+func Put(b []byte) { defaultBytes.Put(b) }
+
+// GetBuffer returns an empty buffer from the shared Buffers pool.
+func GetBuffer() *bytes.Buffer { return defaultBuffers.Get() }
+
+// PutBuffer returns a buffer from GetBuffer to the shared Buffers pool.
+func PutBuffer(buf *bytes.Buffer) { defaultBuffers.Put(buf) }
+
+// Stats is a snapshot of a pool's counters.
+type Stats = telemetry.PoolStats
+
+// counters tracks the Stats of one pool.
+type counters struct {
+	gets, puts, allocs, drops, highWater atomic.Int64
+}
+
+func (c *counters) get(allocated bool) {
+	n := c.gets.Add(1)
+	if allocated {
+		c.allocs.Add(1)
+	}
+	inUse := n - c.puts.Load()
+	for {
+		high := c.highWater.Load()
+		if inUse <= high || c.highWater.CompareAndSwap(high, inUse) {
+			return
+		}
+	}
+}
+
+func (c *counters) put(dropped bool) {
+	c.puts.Add(1)
+	if dropped {
+		c.drops.Add(1)
+	}
+}
+
+func (c *counters) stats() Stats {
+	// Puts first: a get and its put racing with this read must not make
+	// InUse negative.
+	puts := c.puts.Load()
+	gets := c.gets.Load()
+	return Stats{
+		Gets:      gets,
+		Puts:      puts,
+		Allocs:    c.allocs.Load(),
+		Drops:     c.drops.Load(),
+		InUse:     gets - puts,
+		HighWater: c.highWater.Load(),
+	}
+}
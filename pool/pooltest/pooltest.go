@@ -0,0 +1,31 @@
+// Package pooltest checks in tests that pooled values are put back.
+package pooltest
+
+import (
+	"testing"
+
+	"github.com/planx-lab/planx-common/pool"
+)
+
+// NoLeaks fails the test if, when it ends, a value taken from any pool
+// during the test has not been put back. Values are only tracked in
+// planxdebug builds, so run the tests with
+//
+//	go test -tags planxdebug ./...
+//
+// to enable the check; otherwise NoLeaks does nothing. Values taken by
+// tests running in parallel count as this test's.
+func NoLeaks(t testing.TB) {
+	t.Helper()
+	before := map[uint64]bool{}
+	for _, leak := range pool.Leaks() {
+		before[leak.ID] = true
+	}
+	t.Cleanup(func() {
+		for _, leak := range pool.Leaks() {
+			if !before[leak.ID] {
+				t.Errorf("%s", leak)
+			}
+		}
+	})
+}
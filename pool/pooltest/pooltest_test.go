@@ -0,0 +1,15 @@
+package pooltest
+
+import (
+	"testing"
+
+	"github.com/planx-lab/planx-common/pool"
+)
+
+func TestNoLeaks(t *testing.T) {
+	NoLeaks(t)
+	b := pool.Get(100)
+	buf := pool.GetBuffer()
+	pool.Put(b)
+	pool.PutBuffer(buf)
+}
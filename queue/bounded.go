@@ -0,0 +1,235 @@
+// Package queue provides bounded queues that give pipeline stages a
+// standard backpressure primitive.
+// Engine-side utilities only — must not be imported by SDK or plugins.
+package queue
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/planx-lab/planx-common/errors"
+	"github.com/planx-lab/planx-common/telemetry"
+)
+
+// ErrClosed is returned by pushes to a closed queue and by pops from a
+// closed queue that has been drained.
+var ErrClosed = stderrors.New("queue: closed")
+
+// Config configures a Bounded queue.
+type Config struct {
+	// Capacity is the maximum number of items held. It must be positive.
+	Capacity int
+
+	// HighWatermark and LowWatermark, if set, make the queue call OnHigh
+	// when its depth rises to HighWatermark and OnLow when it then falls
+	// back to LowWatermark, e.g. to pause and resume a source. They are
+	// called with the queue locked, so they must be quick and must not use
+	// the queue.
+	HighWatermark int
+	LowWatermark  int
+	OnHigh        func(depth int)
+	OnLow         func(depth int)
+
+	// Stage, if set, makes the queue report its depth to the
+	// planx.window.backlog gauge under this stage.
+	Stage string
+}
+
+// Bounded is a FIFO queue of at most Capacity items. It is safe for
+// concurrent use.
+type Bounded[T any] struct {
+	cfg Config
+
+	mu       sync.Mutex
+	items    []T // ring buffer
+	head     int
+	len      int
+	closed   bool
+	high     bool          // above the high watermark, waiting for the low one
+	notFull  chan struct{} // closed and replaced when an item is popped
+	notEmpty chan struct{} // closed and replaced when an item is pushed
+}
+
+// NewBounded returns an empty queue. It panics if cfg.Capacity is not
+// positive or the watermarks are inconsistent.
+func NewBounded[T any](cfg Config) *Bounded[T] {
+	if cfg.Capacity <= 0 {
+		panic("queue: NewBounded with non-positive capacity")
+	}
+	if cfg.HighWatermark > 0 && (cfg.HighWatermark > cfg.Capacity || cfg.LowWatermark >= cfg.HighWatermark) {
+		panic(fmt.Sprintf("queue: watermarks low=%d high=%d do not fit capacity %d",
+			cfg.LowWatermark, cfg.HighWatermark, cfg.Capacity))
+	}
+	return &Bounded[T]{
+		cfg:      cfg,
+		items:    make([]T, cfg.Capacity),
+		notFull:  make(chan struct{}),
+		notEmpty: make(chan struct{}),
+	}
+}
+
+// Push adds v, blocking while the queue is full. If ctx is done first it
+// returns the context error reclassified by errors.FromContext.
+func (q *Bounded[T]) Push(ctx context.Context, v T) error {
+	for {
+		q.mu.Lock()
+		pushed, err := q.push(v)
+		wait := q.notFull
+		q.mu.Unlock()
+		if pushed || err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return errors.FromContext(ctx, ctx.Err())
+		case <-wait:
+		}
+	}
+}
+
+// PushTimeout is Push waiting at most d. If the queue stays full it returns
+// a *errors.BackpressureError carrying the depth.
+func (q *Bounded[T]) PushTimeout(v T, d time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	defer cancel()
+	if err := q.Push(ctx, v); err != nil {
+		if errors.CodeOf(err) == errors.CodeDeadlineExceeded {
+			return q.fullError()
+		}
+		return err
+	}
+	return nil
+}
+
+// TryPush adds v without blocking. If the queue is full it returns a
+// *errors.BackpressureError carrying the depth.
+func (q *Bounded[T]) TryPush(v T) error {
+	q.mu.Lock()
+	pushed, err := q.push(v)
+	q.mu.Unlock()
+	if !pushed && err == nil {
+		return q.fullError()
+	}
+	return err
+}
+
+// push adds v if there is room. q.mu must be held.
+func (q *Bounded[T]) push(v T) (bool, error) {
+	if q.closed {
+		return false, ErrClosed
+	}
+	if q.len == len(q.items) {
+		return false, nil
+	}
+	q.items[(q.head+q.len)%len(q.items)] = v
+	q.len++
+	close(q.notEmpty)
+	q.notEmpty = make(chan struct{})
+	q.changed(1)
+	return true, nil
+}
+
+// Pop removes and returns the oldest item, blocking while the queue is
+// empty. Once the queue is closed and drained it returns ErrClosed.
+func (q *Bounded[T]) Pop(ctx context.Context) (T, error) {
+	for {
+		q.mu.Lock()
+		v, ok := q.pop()
+		closed := q.closed
+		wait := q.notEmpty
+		q.mu.Unlock()
+		if ok {
+			return v, nil
+		}
+		if closed {
+			return v, ErrClosed
+		}
+		select {
+		case <-ctx.Done():
+			return v, errors.FromContext(ctx, ctx.Err())
+		case <-wait:
+		}
+	}
+}
+
+// TryPop removes and returns the oldest item without blocking; ok is false
+// if the queue is empty.
+func (q *Bounded[T]) TryPop() (v T, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.pop()
+}
+
+// pop removes the oldest item if any. q.mu must be held.
+func (q *Bounded[T]) pop() (T, bool) {
+	var zero T
+	if q.len == 0 {
+		return zero, false
+	}
+	v := q.items[q.head]
+	q.items[q.head] = zero
+	q.head = (q.head + 1) % len(q.items)
+	q.len--
+	close(q.notFull)
+	q.notFull = make(chan struct{})
+	q.changed(-1)
+	return v, true
+}
+
+// changed reports a depth change to the watermarks and the backlog gauge.
+// q.mu must be held.
+func (q *Bounded[T]) changed(delta int) {
+	if q.cfg.Stage != "" {
+		telemetry.UpdateWindowBacklog(context.Background(), q.cfg.Stage, int64(delta))
+	}
+	if q.cfg.HighWatermark <= 0 {
+		return
+	}
+	switch {
+	case !q.high && q.len >= q.cfg.HighWatermark:
+		q.high = true
+		if q.cfg.OnHigh != nil {
+			q.cfg.OnHigh(q.len)
+		}
+	case q.high && q.len <= q.cfg.LowWatermark:
+		q.high = false
+		if q.cfg.OnLow != nil {
+			q.cfg.OnLow(q.len)
+		}
+	}
+}
+
+func (q *Bounded[T]) fullError() error {
+	return errors.NewBackpressureError("queue: full", q.Len(), 0)
+}
+
+// Close stops pushes; items already queued can still be popped. Blocked
+// pushes return ErrClosed and blocked pops return it once the queue is
+// empty. Close is idempotent.
+func (q *Bounded[T]) Close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return
+	}
+	q.closed = true
+	close(q.notFull)
+	close(q.notEmpty)
+	q.notFull = make(chan struct{})
+	q.notEmpty = make(chan struct{})
+}
+
+// Len returns the number of items queued.
+func (q *Bounded[T]) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.len
+}
+
+// Cap returns the capacity.
+func (q *Bounded[T]) Cap() int {
+	return len(q.items)
+}
@@ -0,0 +1,129 @@
+package queue
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+	"time"
+
+	"github.com/planx-lab/planx-common/errors"
+)
+
+func TestBounded_FIFO(t *testing.T) {
+	q := NewBounded[int](Config{Capacity: 3})
+	ctx := context.Background()
+	for i := 1; i <= 3; i++ {
+		if err := q.Push(ctx, i); err != nil {
+			t.Fatalf("Push %d: %v", i, err)
+		}
+	}
+	err := q.TryPush(4)
+	if d, ok := errors.RetryAfter(err); !ok || d != 0 {
+		t.Fatalf("full queue: got %v", err)
+	}
+	for want := 1; want <= 3; want++ {
+		got, err := q.Pop(ctx)
+		if err != nil || got != want {
+			t.Fatalf("Pop: got %d, %v, want %d", got, err, want)
+		}
+		// Wrap the ring buffer around.
+		if want == 1 {
+			if err := q.TryPush(4); err != nil {
+				t.Fatalf("TryPush after pop: %v", err)
+			}
+		}
+	}
+	if v, ok := q.TryPop(); !ok || v != 4 {
+		t.Fatalf("TryPop: got %d, %v", v, ok)
+	}
+	if _, ok := q.TryPop(); ok {
+		t.Fatal("TryPop on an empty queue")
+	}
+}
+
+func TestBounded_BlockingPush(t *testing.T) {
+	q := NewBounded[string](Config{Capacity: 1})
+	ctx := context.Background()
+	_ = q.Push(ctx, "a")
+
+	done := make(chan error, 1)
+	go func() { done <- q.Push(ctx, "b") }()
+	select {
+	case <-done:
+		t.Fatal("Push to a full queue did not block")
+	case <-time.After(10 * time.Millisecond):
+	}
+	if v, _ := q.Pop(ctx); v != "a" {
+		t.Fatalf("got %q", v)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("blocked Push: %v", err)
+	}
+
+	err := q.PushTimeout("c", 10*time.Millisecond)
+	var bp *errors.BackpressureError
+	if !stderrors.As(err, &bp) || bp.QueueDepth != 1 {
+		t.Fatalf("PushTimeout: got %v", err)
+	}
+}
+
+func TestBounded_Watermarks(t *testing.T) {
+	var events []string
+	q := NewBounded[int](Config{
+		Capacity:      10,
+		HighWatermark: 3,
+		LowWatermark:  1,
+		OnHigh:        func(depth int) { events = append(events, "high") },
+		OnLow:         func(depth int) { events = append(events, "low") },
+		Stage:         "processor",
+	})
+	for i := 0; i < 5; i++ {
+		_ = q.TryPush(i)
+	}
+	for i := 0; i < 4; i++ {
+		q.TryPop()
+	}
+	_ = q.TryPush(0)
+	if len(events) != 2 || events[0] != "high" || events[1] != "low" {
+		t.Fatalf("got %v", events)
+	}
+}
+
+func TestBounded_Close(t *testing.T) {
+	q := NewBounded[int](Config{Capacity: 2})
+	ctx := context.Background()
+	_ = q.Push(ctx, 1)
+
+	popped := make(chan error, 1)
+	q2 := NewBounded[int](Config{Capacity: 1})
+	go func() {
+		_, err := q2.Pop(ctx)
+		popped <- err
+	}()
+	time.Sleep(5 * time.Millisecond)
+	q2.Close()
+	if err := <-popped; err != ErrClosed {
+		t.Fatalf("blocked Pop: got %v", err)
+	}
+
+	q.Close()
+	q.Close()
+	if err := q.Push(ctx, 2); err != ErrClosed {
+		t.Fatalf("Push after Close: got %v", err)
+	}
+	if v, err := q.Pop(ctx); err != nil || v != 1 {
+		t.Fatalf("Pop of a queued item: got %d, %v", v, err)
+	}
+	if _, err := q.Pop(ctx); err != ErrClosed {
+		t.Fatalf("Pop of a drained queue: got %v", err)
+	}
+}
+
+func TestBounded_PopCanceled(t *testing.T) {
+	q := NewBounded[int](Config{Capacity: 1})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := q.Pop(ctx); errors.CodeOf(err) != errors.CodeCanceled {
+		t.Fatalf("got %v", err)
+	}
+}
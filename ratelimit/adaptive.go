@@ -0,0 +1,157 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/planx-lab/planx-common/clock"
+	"github.com/planx-lab/planx-common/config"
+	"github.com/planx-lab/planx-common/errors"
+)
+
+// Defaults for zero AdaptiveConfig fields.
+const (
+	DefaultInitialLimit = 10
+	DefaultMinLimit     = 1
+	DefaultMaxLimit     = 1000
+	DefaultBackoffRatio = 0.9
+)
+
+// AdaptiveConfig configures an Adaptive limiter:
+//
+//	adaptive:
+//	  initial_limit: 8
+//	  max_limit: 64
+//	  latency_target: 500ms
+type AdaptiveConfig struct {
+	InitialLimit int `yaml:"initial_limit" json:"initial_limit" validate:"omitempty,min=1"`
+	MinLimit     int `yaml:"min_limit" json:"min_limit" validate:"omitempty,min=1"`
+	MaxLimit     int `yaml:"max_limit" json:"max_limit" validate:"omitempty,gtefield=MinLimit"`
+
+	// LatencyTarget is the call latency above which the downstream counts
+	// as overloaded. Zero means only errors lower the limit.
+	LatencyTarget config.Duration `yaml:"latency_target" json:"latency_target"`
+
+	// BackoffRatio is the factor applied to the limit on overload.
+	BackoffRatio float64 `yaml:"backoff_ratio" json:"backoff_ratio" validate:"omitempty,min=0.1,max=0.99"`
+}
+
+// Adaptive limits the number of calls in flight, such as batches written to
+// a sink whose capacity varies, and tunes the limit with AIMD: every call
+// that succeeds within the latency target while the limit is in use raises
+// it by about one per limit's worth of calls, and every overloaded call
+// (too slow, or failing with a retryable error such as a rate limit or
+// timeout) multiplies it by BackoffRatio. It is safe for concurrent use.
+type Adaptive struct {
+	min, max float64
+	target   time.Duration
+	ratio    float64
+	clock    clock.Clock
+
+	mu       sync.Mutex
+	limit    float64
+	inFlight int
+	released chan struct{} // closed and replaced whenever a slot frees up
+}
+
+// NewAdaptive returns an Adaptive limiter described by cfg.
+func NewAdaptive(cfg AdaptiveConfig, opts ...Option) *Adaptive {
+	a := &Adaptive{
+		min:      float64(cfg.MinLimit),
+		max:      float64(cfg.MaxLimit),
+		target:   cfg.LatencyTarget.Std(),
+		ratio:    cfg.BackoffRatio,
+		clock:    newOptions(opts).clock,
+		limit:    float64(cfg.InitialLimit),
+		released: make(chan struct{}),
+	}
+	if a.min <= 0 {
+		a.min = DefaultMinLimit
+	}
+	if a.max <= 0 {
+		a.max = math.Max(DefaultMaxLimit, a.min)
+	}
+	if a.ratio <= 0 || a.ratio >= 1 {
+		a.ratio = DefaultBackoffRatio
+	}
+	if a.limit <= 0 {
+		a.limit = DefaultInitialLimit
+	}
+	a.limit = math.Min(math.Max(a.limit, a.min), a.max)
+	return a
+}
+
+// Acquire blocks until a call may start or ctx is done. If err is nil the
+// caller must make the call and pass its outcome to release exactly once.
+func (a *Adaptive) Acquire(ctx context.Context) (release func(err error), err error) {
+	for {
+		a.mu.Lock()
+		release, ok := a.acquire()
+		ch := a.released
+		a.mu.Unlock()
+		if ok {
+			return release, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, errors.FromContext(ctx, ctx.Err())
+		case <-ch:
+		}
+	}
+}
+
+// TryAcquire is Acquire without blocking; ok is false if the limit is
+// reached.
+func (a *Adaptive) TryAcquire() (release func(err error), ok bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.acquire()
+}
+
+// acquire takes a slot if one is free. a.mu must be held.
+func (a *Adaptive) acquire() (release func(err error), ok bool) {
+	if a.inFlight >= int(a.limit) {
+		return nil, false
+	}
+	a.inFlight++
+	start := a.clock.Now()
+	var once sync.Once
+	return func(err error) {
+		once.Do(func() { a.release(a.clock.Since(start), err) })
+	}, true
+}
+
+func (a *Adaptive) release(latency time.Duration, err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	utilized := a.inFlight >= int(a.limit)/2
+	a.inFlight--
+
+	overloaded := (err != nil && errors.IsRetryable(err)) || (a.target > 0 && latency > a.target)
+	switch {
+	case overloaded:
+		a.limit = math.Max(a.min, a.limit*a.ratio)
+	case err == nil && utilized:
+		// Grow only while the limit is actually the constraint.
+		a.limit = math.Min(a.max, a.limit+1/a.limit)
+	}
+
+	close(a.released)
+	a.released = make(chan struct{})
+}
+
+// Limit returns the current limit on calls in flight.
+func (a *Adaptive) Limit() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return int(a.limit)
+}
+
+// InFlight returns the number of calls in flight.
+func (a *Adaptive) InFlight() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.inFlight
+}
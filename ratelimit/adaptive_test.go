@@ -0,0 +1,99 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/planx-lab/planx-common/config"
+	"github.com/planx-lab/planx-common/errors"
+)
+
+func TestAdaptive_Blocks(t *testing.T) {
+	a := NewAdaptive(AdaptiveConfig{InitialLimit: 1, MaxLimit: 1})
+	release, err := a.Acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := a.TryAcquire(); ok {
+		t.Fatal("limit of 1 exceeded")
+	}
+
+	acquired := make(chan struct{})
+	go func() {
+		r, err := a.Acquire(context.Background())
+		if err == nil {
+			r(nil)
+		}
+		close(acquired)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	release(nil)
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("waiter not woken by release")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	hold, _ := a.TryAcquire()
+	defer hold(nil)
+	cancel()
+	if _, err := a.Acquire(ctx); errors.CodeOf(err) != errors.CodeCanceled {
+		t.Fatalf("canceled acquire: got %v", err)
+	}
+}
+
+func TestAdaptive_AIMD(t *testing.T) {
+	clk := newClock()
+	a := NewAdaptive(AdaptiveConfig{
+		InitialLimit:  10,
+		MaxLimit:      20,
+		LatencyTarget: config.Duration(100 * time.Millisecond),
+		BackoffRatio:  0.5,
+	}, WithClock(clk))
+
+	// Ten fast calls with the limit fully used add about one.
+	releases := make([]func(error), 0, 10)
+	for i := 0; i < 10; i++ {
+		r, ok := a.TryAcquire()
+		if !ok {
+			t.Fatalf("call %d rejected", i)
+		}
+		releases = append(releases, r)
+	}
+	for _, r := range releases {
+		r(nil)
+	}
+	if got := a.Limit(); got != 10 && got != 11 {
+		t.Fatalf("after successes: got %d", got)
+	}
+	before := a.Limit()
+
+	r, _ := a.TryAcquire()
+	clk.Add(time.Second)
+	r(nil)
+	if got := a.Limit(); got != before/2 {
+		t.Fatalf("after a slow call: got %d, want %d", got, before/2)
+	}
+
+	r, _ = a.TryAcquire()
+	r(errors.NewRateLimitError("slow down", 0))
+	r, _ = a.TryAcquire()
+	r(errors.NewRateLimitError("slow down", 0))
+	r, _ = a.TryAcquire()
+	r(errors.NewConfigError("bad"))
+	if got := a.Limit(); got != before/8 {
+		t.Fatalf("after throttling: got %d", got)
+	}
+	for i := 0; i < 10; i++ {
+		r, _ = a.TryAcquire()
+		r(errors.NewRateLimitError("slow down", 0))
+	}
+	if got := a.Limit(); got != DefaultMinLimit {
+		t.Fatalf("limit should not drop below the minimum: got %d", got)
+	}
+	if a.InFlight() != 0 {
+		t.Fatalf("in flight: got %d", a.InFlight())
+	}
+}
@@ -0,0 +1,129 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/planx-lab/planx-common/clock"
+	"github.com/planx-lab/planx-common/errors"
+)
+
+// TokenBucket is a token-bucket Limiter. It is safe for concurrent use.
+type TokenBucket struct {
+	rate  float64 // tokens per second
+	burst float64
+	clock clock.Clock
+
+	mu     sync.Mutex
+	tokens float64 // negative while waiters hold reservations
+	last   time.Time
+}
+
+// NewTokenBucket returns a full bucket of burst tokens refilled at rate per
+// second. burst below 1 is taken as 1.
+func NewTokenBucket(rate float64, burst int, opts ...Option) *TokenBucket {
+	burst = max(burst, 1)
+	return &TokenBucket{rate: rate, burst: float64(burst), tokens: float64(burst), clock: newOptions(opts).clock}
+}
+
+// refill adds the tokens earned since the last call. b.mu must be held.
+func (b *TokenBucket) refill(now time.Time) {
+	if !b.last.IsZero() {
+		b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	}
+	b.last = now
+}
+
+// Allow implements Limiter.
+func (b *TokenBucket) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(b.clock.Now())
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// Wait implements Limiter. It reserves a token, so concurrent waiters are
+// served in order, and gives it back if ctx ends the wait.
+func (b *TokenBucket) Wait(ctx context.Context) error {
+	b.mu.Lock()
+	b.refill(b.clock.Now())
+	b.tokens--
+	var delay time.Duration
+	if b.tokens < 0 {
+		delay = time.Duration(-b.tokens / b.rate * float64(time.Second))
+	}
+	b.mu.Unlock()
+
+	if err := sleep(ctx, b.clock, delay); err != nil {
+		b.mu.Lock()
+		b.tokens = min(b.burst, b.tokens+1)
+		b.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// LeakyBucket is a leaky-bucket Limiter: events are spaced evenly, and at
+// most capacity callers queue in Wait. It is safe for concurrent use.
+type LeakyBucket struct {
+	interval time.Duration
+	capacity int
+	clock    clock.Clock
+
+	mu   sync.Mutex
+	next time.Time // earliest time of the next event
+}
+
+// NewLeakyBucket returns a bucket releasing rate events per second with room
+// for capacity waiters. capacity below 1 is taken as 1.
+func NewLeakyBucket(rate float64, capacity int, opts ...Option) *LeakyBucket {
+	return &LeakyBucket{interval: interval(rate), capacity: max(capacity, 1), clock: newOptions(opts).clock}
+}
+
+// Allow implements Limiter.
+func (b *LeakyBucket) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.clock.Now()
+	if now.Before(b.next) {
+		return false
+	}
+	b.next = now.Add(b.interval)
+	return true
+}
+
+// Wait implements Limiter. If the bucket is full it returns a
+// *errors.RateLimitError at once with the delay until there is room.
+func (b *LeakyBucket) Wait(ctx context.Context) error {
+	b.mu.Lock()
+	now := b.clock.Now()
+	slot := b.next
+	if slot.Before(now) {
+		slot = now
+	}
+	delay := slot.Sub(now)
+	if queued := int(delay / b.interval); queued >= b.capacity {
+		b.mu.Unlock()
+		retryAfter := delay - time.Duration(b.capacity-1)*b.interval
+		return errors.NewRateLimitError(fmt.Sprintf("ratelimit: %d waiters queued", queued), retryAfter)
+	}
+	b.next = slot.Add(b.interval)
+	b.mu.Unlock()
+
+	if err := sleep(ctx, b.clock, delay); err != nil {
+		b.mu.Lock()
+		// Give the slot back if nobody queued behind it.
+		if b.next.Equal(slot.Add(b.interval)) {
+			b.next = slot
+		}
+		b.mu.Unlock()
+		return err
+	}
+	return nil
+}
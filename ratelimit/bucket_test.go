@@ -0,0 +1,126 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/planx-lab/planx-common/clock"
+	"github.com/planx-lab/planx-common/errors"
+)
+
+func newClock() *clock.Fake { return clock.NewFake(time.Unix(1700000000, 0)) }
+
+func TestTokenBucket_Allow(t *testing.T) {
+	clk := newClock()
+	b := NewTokenBucket(10, 3, WithClock(clk))
+
+	for i := 0; i < 3; i++ {
+		if !b.Allow() {
+			t.Fatalf("burst event %d rejected", i)
+		}
+	}
+	if b.Allow() {
+		t.Fatal("event beyond the burst allowed")
+	}
+	clk.Add(100 * time.Millisecond)
+	if !b.Allow() || b.Allow() {
+		t.Fatal("expected exactly one token after 100ms at 10/s")
+	}
+	clk.Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		if !b.Allow() {
+			t.Fatal("bucket should refill up to the burst")
+		}
+	}
+	if b.Allow() {
+		t.Fatal("bucket should not refill past the burst")
+	}
+}
+
+func TestTokenBucket_Wait(t *testing.T) {
+	b := NewTokenBucket(100, 1)
+	ctx := context.Background()
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := b.Wait(ctx); err != nil {
+			t.Fatalf("Wait: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 15*time.Millisecond {
+		t.Fatalf("3 events at 100/s took only %v", elapsed)
+	}
+}
+
+func TestTokenBucket_WaitFakeClock(t *testing.T) {
+	clk := newClock()
+	b := NewTokenBucket(1, 1, WithClock(clk))
+	b.Allow()
+
+	done := make(chan error, 1)
+	go func() { done <- b.Wait(context.Background()) }()
+	clk.BlockUntil(1)
+	select {
+	case err := <-done:
+		t.Fatalf("Wait returned before the clock advanced: %v", err)
+	default:
+	}
+	clk.Add(time.Second)
+	if err := <-done; err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+}
+
+func TestTokenBucket_WaitDeadline(t *testing.T) {
+	b := NewTokenBucket(1, 1)
+	b.Allow()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := b.Wait(ctx)
+	if d, ok := errors.RetryAfter(err); !ok || d <= 0 {
+		t.Fatalf("got %v", err)
+	}
+	// The reservation was returned, so the next token is still ~1s away
+	// rather than 2s.
+	if b.tokens < -0.01 {
+		t.Fatalf("tokens: got %v", b.tokens)
+	}
+}
+
+func TestLeakyBucket(t *testing.T) {
+	clk := newClock()
+	b := NewLeakyBucket(10, 2, WithClock(clk))
+
+	if !b.Allow() || b.Allow() {
+		t.Fatal("leaky bucket should space events")
+	}
+	clk.Add(100 * time.Millisecond)
+	if !b.Allow() {
+		t.Fatal("event after the interval rejected")
+	}
+}
+
+func TestLeakyBucket_WaitFull(t *testing.T) {
+	b := NewLeakyBucket(20, 2)
+	ctx := context.Background()
+	start := time.Now()
+	if err := b.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Fatalf("second event after %v", elapsed)
+	}
+
+	// Two waiters already queued fill a bucket of capacity 2; there is room
+	// again once the first of them has gone.
+	clk := newClock()
+	b = NewLeakyBucket(1, 2, WithClock(clk))
+	b.next = clk.Now().Add(2 * time.Second)
+	err := b.Wait(ctx)
+	if d, ok := errors.RetryAfter(err); !ok || d != time.Second {
+		t.Fatalf("full bucket: got %v", err)
+	}
+}
@@ -0,0 +1,108 @@
+// Package ratelimit provides rate limiters for Planx engine components,
+// such as sources throttled per tenant, a registry that keeps one limiter
+// per key, and an adaptive limiter on calls in flight.
+// Engine-side utilities only — must not be imported by SDK or plugins.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/planx-lab/planx-common/clock"
+	"github.com/planx-lab/planx-common/errors"
+)
+
+// Limiter limits the rate of events.
+type Limiter interface {
+	// Allow reports whether an event may happen now, consuming a slot if
+	// so. It never blocks.
+	Allow() bool
+
+	// Wait blocks until an event may happen or ctx is done. If the wait
+	// would outlast ctx's deadline it returns a *errors.RateLimitError with
+	// the delay at once instead of sleeping.
+	Wait(ctx context.Context) error
+}
+
+// Algorithms for Config.Algorithm.
+const (
+	TokenBucketAlgorithm = "token_bucket"
+	LeakyBucketAlgorithm = "leaky_bucket"
+)
+
+// Config describes a limiter:
+//
+//	rate: 500       # events per second
+//	burst: 1000
+//	algorithm: token_bucket
+//
+// A token bucket allows bursts of up to Burst events and refills at Rate; a
+// leaky bucket spaces events evenly at Rate and lets up to Burst callers
+// queue in Wait. A zero Rate means unlimited. Burst defaults to 1.
+type Config struct {
+	Algorithm string  `yaml:"algorithm" json:"algorithm" validate:"omitempty,oneof=token_bucket leaky_bucket"`
+	Rate      float64 `yaml:"rate" json:"rate" validate:"min=0"`
+	Burst     int     `yaml:"burst" json:"burst" validate:"omitempty,min=1"`
+}
+
+// Option configures a limiter or Registry.
+type Option func(*options)
+
+type options struct {
+	clock clock.Clock
+}
+
+// WithClock sets the clock limiters measure time on. The default is
+// clock.Real; tests pass a clock.Fake.
+func WithClock(c clock.Clock) Option {
+	return func(o *options) { o.clock = c }
+}
+
+func newOptions(opts []Option) options {
+	o := options{clock: clock.Real}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// New returns the limiter described by cfg.
+func New(cfg Config, opts ...Option) Limiter {
+	if cfg.Rate <= 0 {
+		return unlimited{}
+	}
+	if cfg.Algorithm == LeakyBucketAlgorithm {
+		return NewLeakyBucket(cfg.Rate, cfg.Burst, opts...)
+	}
+	return NewTokenBucket(cfg.Rate, cfg.Burst, opts...)
+}
+
+type unlimited struct{}
+
+func (unlimited) Allow() bool                { return true }
+func (unlimited) Wait(context.Context) error { return nil }
+
+// interval returns the time between events at rate per second.
+func interval(rate float64) time.Duration {
+	return time.Duration(float64(time.Second) / rate)
+}
+
+// sleep waits d, returning early with an error if ctx is done. If ctx's
+// deadline falls before d is over it does not wait at all.
+func sleep(ctx context.Context, c clock.Clock, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	if deadline, ok := ctx.Deadline(); ok && deadline.Sub(c.Now()) < d {
+		return errors.NewRateLimitError(fmt.Sprintf("ratelimit: wait of %v exceeds the deadline", d), d)
+	}
+	timer := c.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return errors.FromContext(ctx, ctx.Err())
+	case <-timer.C():
+		return nil
+	}
+}
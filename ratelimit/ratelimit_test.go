@@ -0,0 +1,32 @@
+package ratelimit
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+	"time"
+
+	"github.com/planx-lab/planx-common/clock"
+	"github.com/planx-lab/planx-common/errors"
+)
+
+func TestNew(t *testing.T) {
+	if _, ok := New(Config{}).(unlimited); !ok {
+		t.Fatal("zero rate should be unlimited")
+	}
+	if _, ok := New(Config{Rate: 5}).(*TokenBucket); !ok {
+		t.Fatal("default algorithm should be the token bucket")
+	}
+	if _, ok := New(Config{Rate: 5, Algorithm: LeakyBucketAlgorithm}).(*LeakyBucket); !ok {
+		t.Fatal("expected a leaky bucket")
+	}
+}
+
+func TestSleep_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := sleep(ctx, clock.Real, time.Second)
+	if errors.CodeOf(err) != errors.CodeCanceled || !stderrors.Is(err, context.Canceled) {
+		t.Fatalf("got %v", err)
+	}
+}
@@ -0,0 +1,105 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/planx-lab/planx-common/config"
+)
+
+// DefaultTTL is how long a Registry keeps an unused limiter.
+const DefaultTTL = 10 * time.Minute
+
+// RegistryConfig configures a Registry:
+//
+//	default:
+//	  rate: 100
+//	  burst: 200
+//	keys:
+//	  tenant-big:
+//	    rate: 1000
+//	    burst: 2000
+//	ttl: 15m
+type RegistryConfig struct {
+	Default Config            `yaml:"default" json:"default"`
+	Keys    map[string]Config `yaml:"keys" json:"keys"`
+	TTL     config.Duration   `yaml:"ttl" json:"ttl"`
+}
+
+// Registry holds one limiter per key, such as a tenant or session ID,
+// created on first use from the key's entry in Keys or else from Default.
+// Limiters unused for TTL are evicted. It is safe for concurrent use.
+type Registry struct {
+	cfg  RegistryConfig
+	ttl  time.Duration
+	opts []Option
+	now  func() time.Time
+
+	mu        sync.Mutex
+	limiters  map[string]*registered
+	lastSweep time.Time
+}
+
+type registered struct {
+	limiter  Limiter
+	lastUsed time.Time
+}
+
+// NewRegistry returns an empty registry. opts apply to the registry and
+// every limiter it creates.
+func NewRegistry(cfg RegistryConfig, opts ...Option) *Registry {
+	ttl := cfg.TTL.Std()
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	return &Registry{cfg: cfg, ttl: ttl, opts: opts, now: newOptions(opts).clock.Now, limiters: map[string]*registered{}}
+}
+
+// Get returns the limiter for key, creating it if needed.
+func (r *Registry) Get(key string) Limiter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.now()
+	if now.Sub(r.lastSweep) >= r.ttl {
+		r.sweep(now)
+	}
+	entry, ok := r.limiters[key]
+	if !ok {
+		cfg, ok := r.cfg.Keys[key]
+		if !ok {
+			cfg = r.cfg.Default
+		}
+		entry = &registered{limiter: New(cfg, r.opts...)}
+		r.limiters[key] = entry
+	}
+	entry.lastUsed = now
+	return entry.limiter
+}
+
+// Allow reports whether key may have an event now; see Limiter.Allow.
+func (r *Registry) Allow(key string) bool {
+	return r.Get(key).Allow()
+}
+
+// Wait blocks until key may have an event; see Limiter.Wait.
+func (r *Registry) Wait(ctx context.Context, key string) error {
+	return r.Get(key).Wait(ctx)
+}
+
+// Len returns the number of limiters held.
+func (r *Registry) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.limiters)
+}
+
+// sweep evicts limiters unused for the TTL. r.mu must be held.
+func (r *Registry) sweep(now time.Time) {
+	for key, entry := range r.limiters {
+		if now.Sub(entry.lastUsed) >= r.ttl {
+			delete(r.limiters, key)
+		}
+	}
+	r.lastSweep = now
+}
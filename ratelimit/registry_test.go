@@ -0,0 +1,34 @@
+package ratelimit
+
+import "testing"
+
+func TestRegistry(t *testing.T) {
+	clk := newClock()
+	r := NewRegistry(RegistryConfig{
+		Default: Config{Rate: 1, Burst: 1},
+		Keys:    map[string]Config{"big": {Rate: 1, Burst: 3}},
+	}, WithClock(clk))
+
+	if !r.Allow("small") || r.Allow("small") {
+		t.Fatal("default limit not applied")
+	}
+	for i := 0; i < 3; i++ {
+		if !r.Allow("big") {
+			t.Fatalf("override burst event %d rejected", i)
+		}
+	}
+	if r.Get("small") != r.Get("small") {
+		t.Fatal("Get should reuse the key's limiter")
+	}
+	if r.Len() != 2 {
+		t.Fatalf("len: got %d", r.Len())
+	}
+
+	clk.Add(DefaultTTL / 2)
+	r.Get("big")
+	clk.Add(DefaultTTL / 2)
+	r.Get("big")
+	if r.Len() != 1 {
+		t.Fatalf("idle key not evicted: got %d limiters", r.Len())
+	}
+}
@@ -0,0 +1,216 @@
+// Package recovery provides supervised goroutines: panics are captured
+// and reported instead of crashing the process, failed goroutines can be
+// restarted with backoff, and live goroutines are listed for the debug
+// server.
+// Engine-side utilities only — must not be imported by SDK or plugins.
+//
+//	recovery.Go(ctx, "router", r.run, recovery.WithRestart(recovery.RestartConfig{}))
+//
+// A panic or error is reported with errors.Observe, with the goroutine
+// name as the stage, so it is logged with its stack, recorded on the span
+// in ctx and counted in planx.errors.total; panics and restarts are also
+// counted in planx.goroutine.panics and planx.goroutine.restarts.
+package recovery
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/planx-lab/planx-common/clock"
+	"github.com/planx-lab/planx-common/config"
+	"github.com/planx-lab/planx-common/errors"
+	"github.com/planx-lab/planx-common/logger"
+	"github.com/planx-lab/planx-common/telemetry"
+)
+
+// Defaults for zero RestartConfig fields.
+const (
+	DefaultBaseBackoff = time.Second
+	DefaultMaxBackoff  = time.Minute
+	DefaultResetAfter  = time.Minute
+)
+
+// RestartConfig configures restarts of a goroutine that panicked or
+// returned an error:
+//
+//	restart:
+//	  max_restarts: 10
+//	  base_backoff: 1s
+//	  max_backoff: 1m
+//	  reset_after: 5m
+//
+// The delay doubles per consecutive restart from BaseBackoff up to
+// MaxBackoff; a run that lasted ResetAfter starts over from BaseBackoff.
+// MaxRestarts zero means no limit. Zero durations take the defaults above.
+type RestartConfig struct {
+	MaxRestarts int             `yaml:"max_restarts" json:"max_restarts" validate:"min=0"`
+	BaseBackoff config.Duration `yaml:"base_backoff" json:"base_backoff"`
+	MaxBackoff  config.Duration `yaml:"max_backoff" json:"max_backoff" validate:"omitempty,gtefield=BaseBackoff"`
+	ResetAfter  config.Duration `yaml:"reset_after" json:"reset_after"`
+}
+
+// Option configures Go.
+type Option func(*Goroutine)
+
+// WithRestart restarts the goroutine per cfg when it panics or returns an
+// error while ctx is live. Without it the goroutine runs once.
+func WithRestart(cfg RestartConfig) Option {
+	return func(g *Goroutine) {
+		if cfg.BaseBackoff <= 0 {
+			cfg.BaseBackoff = config.Duration(DefaultBaseBackoff)
+		}
+		if cfg.MaxBackoff <= 0 {
+			cfg.MaxBackoff = config.Duration(DefaultMaxBackoff)
+		}
+		if cfg.ResetAfter <= 0 {
+			cfg.ResetAfter = config.Duration(DefaultResetAfter)
+		}
+		g.restart = &cfg
+	}
+}
+
+// WithClock sets the clock backoff delays are measured on. The default is
+// clock.Real; tests pass a clock.Fake.
+func WithClock(c clock.Clock) Option {
+	return func(g *Goroutine) { g.clock = c }
+}
+
+// States reported in Info.State.
+const (
+	StateRunning = "running"
+	StateBackoff = "backoff"
+)
+
+// Goroutine is a supervised goroutine started by Go.
+type Goroutine struct {
+	id      uint64
+	name    string
+	restart *RestartConfig
+	clock   clock.Clock
+	done    chan struct{}
+
+	mu       sync.Mutex
+	started  time.Time
+	state    string
+	restarts int
+	lastErr  error
+	err      error
+}
+
+// Go runs fn in a new goroutine named name, recovering panics. The
+// goroutine is listed by List until it exits for good: when fn returns
+// nil, when ctx is done, or when it fails and may not be restarted.
+func Go(ctx context.Context, name string, fn func(ctx context.Context) error, opts ...Option) *Goroutine {
+	g := &Goroutine{name: name, clock: clock.Real, done: make(chan struct{})}
+	for _, opt := range opts {
+		opt(g)
+	}
+	g.started = g.clock.Now()
+	g.state = StateRunning
+	registry.add(g)
+	go g.run(ctx, fn)
+	return g
+}
+
+// Done is closed when the goroutine has exited for good.
+func (g *Goroutine) Done() <-chan struct{} { return g.done }
+
+// Wait waits for the goroutine to exit and returns its final error: nil,
+// the error or recovered panic of its last run, or the context's error if
+// ctx ended a backoff.
+func (g *Goroutine) Wait() error {
+	<-g.done
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.err
+}
+
+func (g *Goroutine) run(ctx context.Context, fn func(ctx context.Context) error) {
+	defer close(g.done)
+	defer registry.remove(g)
+
+	consecutive := 0
+	for {
+		start := g.clock.Now()
+		err := g.call(ctx, fn)
+		if err != nil && ctx.Err() == nil {
+			errors.Observe(ctx, nil, err, "", g.name)
+		}
+		if err == nil || ctx.Err() != nil || !g.mayRestart() {
+			g.finish(err)
+			return
+		}
+
+		if g.clock.Since(start) >= g.restart.ResetAfter.Std() {
+			consecutive = 0
+		}
+		consecutive++
+		delay := backoff(g.restart, consecutive)
+		g.mu.Lock()
+		g.state = StateBackoff
+		g.lastErr = err
+		restarts := g.restarts
+		g.mu.Unlock()
+		logger.WarnCtx(ctx).Str("goroutine", g.name).Int("restarts", restarts).EmbedObject(logger.Dur("backoff", delay)).
+			Msg("recovery: restarting goroutine")
+
+		timer := g.clock.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			g.finish(ctx.Err())
+			return
+		case <-timer.C():
+		}
+		telemetry.RecordGoroutineRestart(ctx, g.name)
+		g.mu.Lock()
+		g.state = StateRunning
+		g.restarts++
+		g.started = g.clock.Now()
+		g.mu.Unlock()
+	}
+}
+
+// call runs fn once, converting a panic into an error. The conversion
+// happens in the deferred function so the error keeps the panic stack.
+func (g *Goroutine) call(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			telemetry.RecordGoroutinePanic(ctx, g.name)
+			err = errors.Recover(r).WithField("goroutine", g.name)
+		}
+	}()
+	return fn(ctx)
+}
+
+// mayRestart reports whether a failed run may be restarted.
+func (g *Goroutine) mayRestart() bool {
+	if g.restart == nil {
+		return false
+	}
+	g.mu.Lock()
+	restarts := g.restarts
+	g.mu.Unlock()
+	if g.restart.MaxRestarts > 0 && restarts >= g.restart.MaxRestarts {
+		logger.Error().Str("goroutine", g.name).Int("restarts", restarts).
+			Msg("recovery: goroutine failed too often, not restarting")
+		return false
+	}
+	return true
+}
+
+func (g *Goroutine) finish(err error) {
+	g.mu.Lock()
+	g.err = err
+	g.mu.Unlock()
+}
+
+// backoff returns the delay before the n-th consecutive restart (1-based).
+func backoff(cfg *RestartConfig, n int) time.Duration {
+	d, limit := cfg.BaseBackoff.Std(), cfg.MaxBackoff.Std()
+	for i := 1; i < n && d < limit; i++ {
+		d *= 2
+	}
+	return min(d, limit)
+}
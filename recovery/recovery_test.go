@@ -0,0 +1,146 @@
+package recovery
+
+import (
+	"context"
+	stderrors "errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/planx-lab/planx-common/clock"
+	"github.com/planx-lab/planx-common/config"
+	"github.com/planx-lab/planx-common/errors"
+	"github.com/planx-lab/planx-common/logger/logtest"
+)
+
+func wait(t *testing.T, g *Goroutine) error {
+	t.Helper()
+	select {
+	case <-g.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatalf("goroutine did not exit")
+	}
+	return g.Wait()
+}
+
+func TestGo_RecoversPanic(t *testing.T) {
+	rec := logtest.Capture(t)
+	g := Go(context.Background(), "panicky", func(context.Context) error {
+		panic("boom")
+	})
+	err := wait(t, g)
+	if errors.CodeOf(err) != errors.CodePanic || !strings.Contains(err.Error(), "boom") {
+		t.Fatalf("Wait = %v, want a panic error", err)
+	}
+	entries := rec.Find(zerolog.ErrorLevel, "stage failed")
+	if len(entries) != 1 {
+		t.Fatalf("panic logged %d times", len(entries))
+	}
+	if e := entries[0]; e.Str("stage") != "panicky" || !strings.Contains(e.Str("stack"), "recovery_test.go") {
+		t.Fatalf("panic log missing stage or stack: %v", e)
+	}
+}
+
+func TestGo_NilReturnIsNotReported(t *testing.T) {
+	rec := logtest.Capture(t)
+	var runs atomic.Int32
+	g := Go(context.Background(), "ok", func(context.Context) error {
+		runs.Add(1)
+		return nil
+	}, WithRestart(RestartConfig{}))
+	if err := wait(t, g); err != nil {
+		t.Fatalf("Wait = %v", err)
+	}
+	if runs.Load() != 1 {
+		t.Fatalf("ran %d times, want once", runs.Load())
+	}
+	rec.AssertNotLogged(zerolog.ErrorLevel, "stage failed")
+}
+
+func TestGo_RestartWithBackoff(t *testing.T) {
+	rec := logtest.Capture(t)
+	clk := clock.NewFake(time.Unix(0, 0))
+	var runs atomic.Int32
+	g := Go(context.Background(), "flaky", func(context.Context) error {
+		if runs.Add(1) < 3 {
+			panic("not yet")
+		}
+		return nil
+	}, WithClock(clk), WithRestart(RestartConfig{
+		BaseBackoff: config.Duration(time.Second),
+		MaxBackoff:  config.Duration(10 * time.Second),
+	}))
+
+	clk.BlockUntil(1)
+	clk.Add(time.Second)
+	clk.BlockUntil(1)
+	if clk.Waiters() != 1 {
+		t.Fatalf("no second backoff")
+	}
+	clk.Add(time.Second) // the second backoff is 2s
+	if runs.Load() != 2 {
+		t.Fatalf("restarted before its backoff elapsed: %d runs", runs.Load())
+	}
+	clk.Add(time.Second)
+	if err := wait(t, g); err != nil {
+		t.Fatalf("Wait = %v", err)
+	}
+	if runs.Load() != 3 {
+		t.Fatalf("ran %d times, want 3", runs.Load())
+	}
+	entries := rec.Find(zerolog.WarnLevel, "recovery: restarting goroutine")
+	if len(entries) != 2 || entries[0].Fields["backoff_ms"] != float64(1000) || entries[1].Fields["backoff_ms"] != float64(2000) {
+		t.Fatalf("restart logs = %v", entries)
+	}
+}
+
+func TestGo_MaxRestarts(t *testing.T) {
+	rec := logtest.Capture(t)
+	clk := clock.NewFake(time.Unix(0, 0))
+	failure := stderrors.New("sink gone")
+	var runs atomic.Int32
+	g := Go(context.Background(), "failing", func(context.Context) error {
+		runs.Add(1)
+		return failure
+	}, WithClock(clk), WithRestart(RestartConfig{MaxRestarts: 2}))
+
+	for i := 0; i < 2; i++ {
+		clk.BlockUntil(1)
+		clk.Add(time.Minute)
+	}
+	if err := wait(t, g); !stderrors.Is(err, failure) {
+		t.Fatalf("Wait = %v, want %v", err, failure)
+	}
+	if runs.Load() != 3 {
+		t.Fatalf("ran %d times, want 3", runs.Load())
+	}
+	rec.AssertLogged(zerolog.ErrorLevel, "not restarting")
+}
+
+func TestGo_CancelDuringBackoff(t *testing.T) {
+	logtest.Capture(t)
+	clk := clock.NewFake(time.Unix(0, 0))
+	ctx, cancel := context.WithCancel(context.Background())
+	g := Go(ctx, "cancelled", func(context.Context) error {
+		return stderrors.New("fail")
+	}, WithClock(clk), WithRestart(RestartConfig{}))
+
+	clk.BlockUntil(1)
+	cancel()
+	if err := wait(t, g); !stderrors.Is(err, context.Canceled) {
+		t.Fatalf("Wait = %v, want context.Canceled", err)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	cfg := &RestartConfig{BaseBackoff: config.Duration(time.Second), MaxBackoff: config.Duration(5 * time.Second)}
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, w := range want {
+		if got := backoff(cfg, i+1); got != w {
+			t.Fatalf("backoff(%d) = %v, want %v", i+1, got, w)
+		}
+	}
+}
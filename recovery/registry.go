@@ -0,0 +1,77 @@
+package recovery
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Info describes a live supervised goroutine.
+type Info struct {
+	ID        uint64    `json:"id"`
+	Name      string    `json:"name"`
+	State     string    `json:"state"`
+	Started   time.Time `json:"started"` // start of the current run
+	Restarts  int       `json:"restarts"`
+	LastError string    `json:"last_error,omitempty"`
+}
+
+var registry = &goroutines{live: map[uint64]*Goroutine{}}
+
+type goroutines struct {
+	nextID atomic.Uint64
+	mu     sync.Mutex
+	live   map[uint64]*Goroutine
+}
+
+func (r *goroutines) add(g *Goroutine) {
+	g.id = r.nextID.Add(1)
+	r.mu.Lock()
+	r.live[g.id] = g
+	r.mu.Unlock()
+}
+
+func (r *goroutines) remove(g *Goroutine) {
+	r.mu.Lock()
+	delete(r.live, g.id)
+	r.mu.Unlock()
+}
+
+// List returns the live supervised goroutines, in start order.
+func List() []Info {
+	registry.mu.Lock()
+	gs := make([]*Goroutine, 0, len(registry.live))
+	for _, g := range registry.live {
+		gs = append(gs, g)
+	}
+	registry.mu.Unlock()
+
+	infos := make([]Info, 0, len(gs))
+	for _, g := range gs {
+		infos = append(infos, g.info())
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].ID < infos[j].ID })
+	return infos
+}
+
+func (g *Goroutine) info() Info {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	info := Info{ID: g.id, Name: g.name, State: g.state, Started: g.started, Restarts: g.restarts}
+	if g.lastErr != nil {
+		info.LastError = g.lastErr.Error()
+	}
+	return info
+}
+
+// Handler serves List as JSON, for a /debug/goroutines endpoint.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		_ = json.NewEncoder(w).Encode(List())
+	})
+}
@@ -0,0 +1,46 @@
+package recovery
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func find(infos []Info, g *Goroutine) (Info, bool) {
+	for _, info := range infos {
+		if info.ID == g.id {
+			return info, true
+		}
+	}
+	return Info{}, false
+}
+
+func TestList(t *testing.T) {
+	release := make(chan struct{})
+	g := Go(context.Background(), "lister", func(context.Context) error {
+		<-release
+		return nil
+	})
+	info, ok := find(List(), g)
+	if !ok || info.Name != "lister" || info.State != StateRunning || info.Started.IsZero() {
+		t.Fatalf("List = %+v, want a running lister", List())
+	}
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/goroutines", nil))
+	var served []Info
+	if err := json.NewDecoder(rec.Body).Decode(&served); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	if _, ok := find(served, g); !ok {
+		t.Fatalf("Handler served %+v without lister", served)
+	}
+
+	close(release)
+	wait(t, g)
+	if _, ok := find(List(), g); ok {
+		t.Fatalf("exited goroutine still listed")
+	}
+}
@@ -0,0 +1,78 @@
+// Package requestid provides request IDs for correlating a tenant's report
+// with logs and traces.
+// Engine-side utilities only — must not be imported by SDK or plugins.
+//
+// An ID is accepted from the caller or created at the edge, stored in the
+// context with NewContext, and from there added to every context-aware log
+// line and to the request's span. The transports carry it on: see
+// httputil.RequestID and the grpcutil interceptors for servers, and
+// httpclient and grpcutil.Dial for outbound calls.
+package requestid
+
+import (
+	"context"
+
+	"github.com/planx-lab/planx-common/id"
+	"github.com/planx-lab/planx-common/logger"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Header is the HTTP header carrying the ID.
+const Header = "X-Request-ID"
+
+// MetadataKey is the gRPC metadata key carrying the ID.
+const MetadataKey = "x-request-id"
+
+// LogField and SpanAttribute name the ID in logs and on spans.
+const (
+	LogField      = "request_id"
+	SpanAttribute = "planx.request_id"
+)
+
+// MaxLen is the longest ID accepted from a caller.
+const MaxLen = 128
+
+type contextKey struct{}
+
+// New returns a new ID, a ULID.
+func New() string {
+	return id.NewULID().String()
+}
+
+// Valid reports whether s is usable as an ID: 1 to MaxLen printable ASCII
+// characters, so it is safe to log and echo back.
+func Valid(s string) bool {
+	if s == "" || len(s) > MaxLen {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < 0x21 || s[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// Ensure returns s if it is Valid, otherwise a New ID.
+func Ensure(s string) string {
+	if Valid(s) {
+		return s
+	}
+	return New()
+}
+
+// NewContext returns a copy of ctx carrying reqID. Context-aware logging
+// (logger.InfoCtx and friends) includes it as request_id, and the span in
+// ctx, if any, is tagged with it.
+func NewContext(ctx context.Context, reqID string) context.Context {
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String(SpanAttribute, reqID))
+	ctx = logger.ContextWithFields(ctx, map[string]interface{}{LogField: reqID})
+	return context.WithValue(ctx, contextKey{}, reqID)
+}
+
+// FromContext returns the ID in ctx, or "".
+func FromContext(ctx context.Context) string {
+	reqID, _ := ctx.Value(contextKey{}).(string)
+	return reqID
+}
@@ -0,0 +1,76 @@
+package requestid
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/planx-lab/planx-common/logger"
+	"github.com/planx-lab/planx-common/logger/logtest"
+	"github.com/rs/zerolog"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestValid(t *testing.T) {
+	tests := []struct {
+		in   string
+		want bool
+	}{
+		{"req-123", true},
+		{"01J9ZQ3K8W5V1N2P3Q4R5S6T7V", true},
+		{"", false},
+		{"has space", false},
+		{"tab\there", false},
+		{"ünicode", false},
+		{strings.Repeat("x", MaxLen), true},
+		{strings.Repeat("x", MaxLen+1), false},
+	}
+	for _, tt := range tests {
+		if got := Valid(tt.in); got != tt.want {
+			t.Fatalf("Valid(%q): got %v", tt.in, got)
+		}
+	}
+}
+
+func TestEnsure(t *testing.T) {
+	if got := Ensure("req-1"); got != "req-1" {
+		t.Fatalf("valid ID replaced: %q", got)
+	}
+	a, b := Ensure(""), Ensure("bad id")
+	if len(a) != 26 || len(b) != 26 || a == b {
+		t.Fatalf("generated %q, %q", a, b)
+	}
+}
+
+func TestNewContext(t *testing.T) {
+	rec := logtest.Capture(t)
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	ctx, span := provider.Tracer("test").Start(context.Background(), "request")
+
+	ctx = NewContext(ctx, "req-9")
+	if got := FromContext(ctx); got != "req-9" {
+		t.Fatalf("FromContext: got %q", got)
+	}
+	if FromContext(context.Background()) != "" {
+		t.Fatal("empty context has an ID")
+	}
+
+	logger.WarnCtx(ctx).Msg("correlated")
+	if entries := rec.Find(zerolog.WarnLevel, "correlated"); len(entries) != 1 || entries[0].Str(LogField) != "req-9" {
+		t.Fatalf("log line: %v", entries)
+	}
+
+	span.End()
+	attrs := exporter.GetSpans()[0].Attributes
+	found := false
+	for _, kv := range attrs {
+		if string(kv.Key) == SpanAttribute && kv.Value.AsString() == "req-9" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("span attributes: %v", attrs)
+	}
+}
@@ -0,0 +1,85 @@
+package retry
+
+import (
+	"context"
+	"time"
+
+	"github.com/planx-lab/planx-common/clock"
+	"github.com/planx-lab/planx-common/errors"
+)
+
+// Hedged calls fn and, if no attempt has finished after delay, calls it
+// again concurrently, up to maxParallel attempts in flight in total. The
+// first success wins and the context passed to the other attempts is
+// canceled, which cuts tail latency for idempotent requests to slow
+// downstreams:
+//
+//	resp, err := retry.Hedged(ctx, func(ctx context.Context) (*http.Response, error) {
+//		return client.Do(req.Clone(ctx))
+//	}, p95Latency, 2)
+//
+// An attempt failing with a retryable error (see errors.IsRetryable) starts
+// the next one at once instead of waiting for delay. A non-retryable error
+// is returned immediately. If every attempt fails, the last error is
+// returned. fn must be safe to run concurrently with itself.
+//
+// Of opts only WithClock applies, setting the clock delay is measured on.
+func Hedged[T any](ctx context.Context, fn func(ctx context.Context) (T, error), delay time.Duration, maxParallel int, opts ...PolicyOption) (T, error) {
+	if maxParallel < 1 {
+		maxParallel = 1
+	}
+	p := &Policy{clock: clock.Real}
+	for _, opt := range opts {
+		opt(p)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		val T
+		err error
+	}
+	// Buffered so the attempts that lose never block.
+	results := make(chan result, maxParallel)
+	launch := func() {
+		go func() {
+			val, err := fn(ctx)
+			results <- result{val, err}
+		}()
+	}
+
+	launch()
+	launched, pending := 1, 1
+	timer := p.clock.NewTimer(delay)
+	defer timer.Stop()
+
+	var zero T
+	for {
+		select {
+		case r := <-results:
+			pending--
+			if r.err == nil {
+				return r.val, nil
+			}
+			if !errors.IsRetryable(r.err) {
+				return zero, r.err
+			}
+			if launched < maxParallel && ctx.Err() == nil {
+				launch()
+				launched++
+				pending++
+				continue
+			}
+			if pending == 0 {
+				return zero, r.err
+			}
+		case <-timer.C():
+			if launched < maxParallel {
+				launch()
+				launched++
+				pending++
+				timer.Reset(delay)
+			}
+		}
+	}
+}
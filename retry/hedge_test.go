@@ -0,0 +1,71 @@
+package retry
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/planx-lab/planx-common/clock"
+	"github.com/planx-lab/planx-common/errors"
+)
+
+func TestHedged_SecondAttemptWins(t *testing.T) {
+	clk := clock.NewFake(time.Unix(1700000000, 0))
+	var calls int32
+	canceled := make(chan struct{})
+	go func() {
+		// Nothing runs the second attempt until the hedge delay passes.
+		clk.BlockUntil(1)
+		clk.Add(time.Hour)
+	}()
+	got, err := Hedged(context.Background(), func(ctx context.Context) (string, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			<-ctx.Done()
+			close(canceled)
+			return "", ctx.Err()
+		}
+		return "fast", nil
+	}, time.Hour, 2, WithClock(clk))
+	if err != nil || got != "fast" || atomic.LoadInt32(&calls) != 2 {
+		t.Fatalf("got %q, %v after %d calls", got, err, calls)
+	}
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Fatal("slow attempt was not canceled")
+	}
+}
+
+func TestHedged_FirstFastEnough(t *testing.T) {
+	var calls int32
+	got, err := Hedged(context.Background(), func(context.Context) (int, error) {
+		atomic.AddInt32(&calls, 1)
+		return 42, nil
+	}, time.Hour, 3)
+	if err != nil || got != 42 || atomic.LoadInt32(&calls) != 1 {
+		t.Fatalf("got %d, %v after %d calls", got, err, calls)
+	}
+}
+
+func TestHedged_AllFail(t *testing.T) {
+	var calls int32
+	_, err := Hedged(context.Background(), func(context.Context) (int, error) {
+		atomic.AddInt32(&calls, 1)
+		return 0, errors.NewTransportError("down", true)
+	}, time.Hour, 3)
+	if err == nil || atomic.LoadInt32(&calls) != 3 {
+		t.Fatalf("got %v after %d calls", err, calls)
+	}
+}
+
+func TestHedged_NotRetryable(t *testing.T) {
+	var calls int32
+	_, err := Hedged(context.Background(), func(context.Context) (int, error) {
+		atomic.AddInt32(&calls, 1)
+		return 0, errors.NewConfigError("bad")
+	}, time.Hour, 3)
+	if err == nil || atomic.LoadInt32(&calls) != 1 {
+		t.Fatalf("got %v after %d calls", err, calls)
+	}
+}
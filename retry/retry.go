@@ -0,0 +1,169 @@
+// Package retry provides configurable retry policies for Planx engine
+// components, such as sinks retrying writes to a downstream.
+// Engine-side utilities only — must not be imported by SDK or plugins.
+package retry
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/planx-lab/planx-common/clock"
+	"github.com/planx-lab/planx-common/config"
+	"github.com/planx-lab/planx-common/errors"
+	"github.com/planx-lab/planx-common/logger"
+)
+
+// Jitter selects how backoff delays are randomized.
+type Jitter string
+
+// Jitter modes. Full jitter spreads retries the most and is the default.
+const (
+	JitterNone  Jitter = "none"  // exactly the exponential delay
+	JitterFull  Jitter = "full"  // uniform in [0, delay]
+	JitterEqual Jitter = "equal" // uniform in [delay/2, delay]
+)
+
+// Defaults for zero PolicyConfig fields.
+const (
+	DefaultMaxAttempts = 3
+	DefaultBaseBackoff = 100 * time.Millisecond
+	DefaultMaxBackoff  = 10 * time.Second
+)
+
+// PolicyConfig is the configuration of a Policy, embeddable in pipeline and
+// sink configs so retries can be tuned per tenant:
+//
+//	retry:
+//	  max_attempts: 5
+//	  base_backoff: 200ms
+//	  max_backoff: 30s
+//	  jitter: equal
+//	  retryable_codes: [PLX-TRANSPORT-UNAVAILABLE, PLX-RATE-LIMITED]
+//
+// Zero fields take the defaults above. RetryableCodes, if set, replaces
+// errors.IsRetryable: only errors whose errors.CodeOf is listed are retried.
+type PolicyConfig struct {
+	MaxAttempts    int             `yaml:"max_attempts" json:"max_attempts" validate:"omitempty,min=1"`
+	BaseBackoff    config.Duration `yaml:"base_backoff" json:"base_backoff" validate:"omitempty,min=1ms"`
+	MaxBackoff     config.Duration `yaml:"max_backoff" json:"max_backoff" validate:"omitempty,gtefield=BaseBackoff"`
+	Jitter         Jitter          `yaml:"jitter" json:"jitter" validate:"omitempty,oneof=none full equal"`
+	RetryableCodes []errors.Code   `yaml:"retryable_codes" json:"retryable_codes"`
+}
+
+// Policy runs operations with retries. It is safe for concurrent use.
+type Policy struct {
+	maxAttempts int
+	base, max   time.Duration
+	jitter      Jitter
+	codes       map[errors.Code]bool
+	clock       clock.Clock
+}
+
+// PolicyOption configures a Policy.
+type PolicyOption func(*Policy)
+
+// WithClock sets the clock backoff delays are measured on. The default is
+// clock.Real; tests pass a clock.Fake.
+func WithClock(c clock.Clock) PolicyOption {
+	return func(p *Policy) { p.clock = c }
+}
+
+// NewPolicy returns the Policy described by cfg.
+func NewPolicy(cfg PolicyConfig, opts ...PolicyOption) *Policy {
+	p := &Policy{
+		maxAttempts: cfg.MaxAttempts,
+		base:        cfg.BaseBackoff.Std(),
+		max:         cfg.MaxBackoff.Std(),
+		jitter:      cfg.Jitter,
+		clock:       clock.Real,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if p.maxAttempts <= 0 {
+		p.maxAttempts = DefaultMaxAttempts
+	}
+	if p.base <= 0 {
+		p.base = DefaultBaseBackoff
+	}
+	if p.max <= 0 {
+		p.max = DefaultMaxBackoff
+	}
+	if p.jitter == "" {
+		p.jitter = JitterFull
+	}
+	if len(cfg.RetryableCodes) > 0 {
+		p.codes = make(map[errors.Code]bool, len(cfg.RetryableCodes))
+		for _, code := range cfg.RetryableCodes {
+			p.codes[code] = true
+		}
+	}
+	return p
+}
+
+// Do calls fn until it succeeds, returns an error that is not retryable, or
+// the policy's attempts are used up. Between attempts it waits the
+// exponential backoff, or the delay a RateLimitError or BackpressureError
+// asks for if that is longer.
+//
+// If ctx is done while waiting, Do returns the last error reclassified by
+// errors.FromContext. When attempts run out the last error is returned
+// wrapped with the attempt count; errors.Is, errors.As and errors.CodeOf
+// still see the original.
+func (p *Policy) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return errors.FromContext(ctx, err)
+		}
+		if !p.retryable(err) {
+			return err
+		}
+		if attempt >= p.maxAttempts {
+			return errors.WrapNoStack(err, fmt.Sprintf("retry: gave up after %d attempts", attempt))
+		}
+
+		delay := p.backoff(attempt)
+		if hint, ok := errors.RetryAfter(err); ok && hint > delay {
+			delay = hint
+		}
+		logger.Debug().Err(err).Int("attempt", attempt).EmbedObject(logger.Dur("backoff", delay)).Msg("retrying")
+
+		timer := p.clock.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return errors.FromContext(ctx, err)
+		case <-timer.C():
+		}
+	}
+}
+
+func (p *Policy) retryable(err error) bool {
+	if p.codes != nil {
+		return p.codes[errors.CodeOf(err)]
+	}
+	return errors.IsRetryable(err)
+}
+
+// backoff returns the delay after the given failed attempt (1-based): base
+// doubled per attempt, capped at max, then jittered.
+func (p *Policy) backoff(attempt int) time.Duration {
+	d := p.base
+	for i := 1; i < attempt && d < p.max; i++ {
+		d *= 2
+	}
+	d = min(d, p.max)
+	switch p.jitter {
+	case JitterFull:
+		return rand.N(d + 1)
+	case JitterEqual:
+		return d/2 + rand.N(d/2+1)
+	}
+	return d
+}
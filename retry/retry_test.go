@@ -0,0 +1,187 @@
+package retry
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+	"time"
+
+	"github.com/planx-lab/planx-common/clock"
+	"github.com/planx-lab/planx-common/config"
+	"github.com/planx-lab/planx-common/errors"
+)
+
+func fastPolicy(attempts int) *Policy {
+	return NewPolicy(PolicyConfig{
+		MaxAttempts: attempts,
+		BaseBackoff: config.Duration(time.Millisecond),
+		MaxBackoff:  config.Duration(2 * time.Millisecond),
+	})
+}
+
+func TestPolicyDo_RetriesUntilSuccess(t *testing.T) {
+	calls := 0
+	err := fastPolicy(3).Do(context.Background(), func(context.Context) error {
+		calls++
+		if calls < 3 {
+			return errors.NewTransportError("down", true)
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Fatalf("got %v after %d calls", err, calls)
+	}
+}
+
+func TestPolicyDo_GivesUp(t *testing.T) {
+	calls := 0
+	err := fastPolicy(2).Do(context.Background(), func(context.Context) error {
+		calls++
+		return errors.NewTransportError("down", true)
+	})
+	if calls != 2 {
+		t.Fatalf("calls: got %d", calls)
+	}
+	var te *errors.TransportError
+	if !stderrors.As(err, &te) || errors.CodeOf(err) != errors.CodeTransportUnavailable {
+		t.Fatalf("got %v", err)
+	}
+}
+
+func TestPolicyDo_NotRetryable(t *testing.T) {
+	calls := 0
+	cfgErr := errors.NewConfigError("bad")
+	err := fastPolicy(5).Do(context.Background(), func(context.Context) error {
+		calls++
+		return cfgErr
+	})
+	if calls != 1 || err != cfgErr {
+		t.Fatalf("got %v after %d calls", err, calls)
+	}
+}
+
+func TestPolicyDo_RetryableCodes(t *testing.T) {
+	p := NewPolicy(PolicyConfig{
+		MaxAttempts:    3,
+		BaseBackoff:    config.Duration(time.Millisecond),
+		RetryableCodes: []errors.Code{errors.CodeStreamBroken},
+	})
+	calls := 0
+	_ = p.Do(context.Background(), func(context.Context) error {
+		calls++
+		return errors.NewStreamError("eof")
+	})
+	if calls != 3 {
+		t.Fatalf("stream error: got %d calls", calls)
+	}
+	calls = 0
+	_ = p.Do(context.Background(), func(context.Context) error {
+		calls++
+		return errors.NewTransportError("down", true)
+	})
+	if calls != 1 {
+		t.Fatalf("unlisted code: got %d calls", calls)
+	}
+}
+
+func TestPolicyDo_ContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := NewPolicy(PolicyConfig{MaxAttempts: 5, BaseBackoff: config.Duration(time.Hour), Jitter: JitterNone})
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	err := p.Do(ctx, func(context.Context) error {
+		return errors.NewTransportError("down", true)
+	})
+	if errors.CodeOf(err) != errors.CodeCanceled || !stderrors.Is(err, context.Canceled) {
+		t.Fatalf("got %v", err)
+	}
+}
+
+func TestPolicyBackoff(t *testing.T) {
+	p := NewPolicy(PolicyConfig{
+		BaseBackoff: config.Duration(100 * time.Millisecond),
+		MaxBackoff:  config.Duration(time.Second),
+		Jitter:      JitterNone,
+	})
+	for attempt, want := range map[int]time.Duration{
+		1:   100 * time.Millisecond,
+		2:   200 * time.Millisecond,
+		4:   800 * time.Millisecond,
+		5:   time.Second,
+		100: time.Second,
+	} {
+		if got := p.backoff(attempt); got != want {
+			t.Fatalf("attempt %d: got %v, want %v", attempt, got, want)
+		}
+	}
+
+	p.jitter = JitterEqual
+	for i := 0; i < 100; i++ {
+		if got := p.backoff(3); got < 200*time.Millisecond || got > 400*time.Millisecond {
+			t.Fatalf("equal jitter: got %v", got)
+		}
+	}
+}
+
+func TestPolicyConfig_YAML(t *testing.T) {
+	var cfg struct {
+		Retry PolicyConfig `yaml:"retry"`
+	}
+	err := config.ParseYAML([]byte(`
+retry:
+  max_attempts: 5
+  base_backoff: 200ms
+  max_backoff: 30s
+  jitter: equal
+  retryable_codes: [PLX-RATE-LIMITED]
+`), &cfg)
+	if err != nil {
+		t.Fatalf("ParseYAML: %v", err)
+	}
+	if err := config.Validate(cfg); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	p := NewPolicy(cfg.Retry)
+	if p.maxAttempts != 5 || p.base != 200*time.Millisecond || p.jitter != JitterEqual || !p.codes[errors.CodeRateLimited] {
+		t.Fatalf("got %+v", p)
+	}
+
+	cfg.Retry.Jitter = "random"
+	if err := config.Validate(cfg); err == nil {
+		t.Fatal("expected an invalid jitter to fail validation")
+	}
+}
+
+func TestPolicyDo_Clock(t *testing.T) {
+	clk := clock.NewFake(time.Unix(1700000000, 0))
+	p := NewPolicy(PolicyConfig{
+		MaxAttempts: 2,
+		BaseBackoff: config.Duration(time.Hour),
+		Jitter:      JitterNone,
+	}, WithClock(clk))
+
+	calls := 0
+	done := make(chan error, 1)
+	go func() {
+		done <- p.Do(context.Background(), func(context.Context) error {
+			calls++
+			if calls == 1 {
+				return errors.NewRateLimitError("slow down", 2*time.Hour)
+			}
+			return nil
+		})
+	}()
+	clk.BlockUntil(1)
+	clk.Add(time.Hour)
+	select {
+	case <-done:
+		t.Fatal("retried before the RetryAfter hint")
+	default:
+	}
+	clk.Add(time.Hour)
+	if err := <-done; err != nil || calls != 2 {
+		t.Fatalf("got %v after %d calls", err, calls)
+	}
+}
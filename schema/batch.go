@@ -0,0 +1,41 @@
+package schema
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/planx-lab/planx-common/batch"
+	"github.com/planx-lab/planx-common/errors"
+)
+
+// ContextKey is the batch context key holding the ID of the schema the
+// batch's records are written with.
+const ContextKey = "planx-schema-id"
+
+// Attach records in b that its records are written with the schema id.
+func Attach(b *batch.Batch, id int) {
+	if b.Context == nil {
+		b.Context = map[string]string{}
+	}
+	b.Context[ContextKey] = strconv.Itoa(id)
+}
+
+// IDOf returns the schema ID attached to b, if any.
+func IDOf(b *batch.Batch) (int, bool) {
+	v, ok := b.Context[ContextKey]
+	if !ok {
+		return 0, false
+	}
+	id, err := strconv.Atoi(v)
+	return id, err == nil
+}
+
+// Resolve returns the schema attached to b, looked up in r.
+func Resolve(ctx context.Context, r Registry, b *batch.Batch) (*Schema, error) {
+	id, ok := IDOf(b)
+	if !ok {
+		return nil, errors.NewWithCode(CodeNotFound, "schema: batch has no schema attached").
+			WithField("batch_id", b.ID)
+	}
+	return r.ByID(ctx, id)
+}
@@ -0,0 +1,41 @@
+package schema
+
+import (
+	"context"
+	"testing"
+
+	"github.com/planx-lab/planx-common/batch"
+	"github.com/planx-lab/planx-common/errors"
+)
+
+// stubRegistry answers ByID from a map.
+type stubRegistry struct {
+	Registry
+	schemas map[int]*Schema
+}
+
+func (r stubRegistry) ByID(_ context.Context, id int) (*Schema, error) {
+	if s, ok := r.schemas[id]; ok {
+		return s, nil
+	}
+	return nil, errors.NewWithCode(CodeNotFound, "no such schema")
+}
+
+func TestAttach(t *testing.T) {
+	b := &batch.Batch{ID: "b-1"}
+	if _, ok := IDOf(b); ok {
+		t.Fatal("unattached batch reported a schema")
+	}
+	Attach(b, 12)
+	if id, ok := IDOf(b); !ok || id != 12 {
+		t.Fatalf("got %d, %v", id, ok)
+	}
+
+	r := stubRegistry{schemas: map[int]*Schema{12: orderSchema()}}
+	if s, err := Resolve(context.Background(), r, b); err != nil || s.Subject != "orders-value" {
+		t.Fatalf("Resolve: got %v, %v", s, err)
+	}
+	if _, err := Resolve(context.Background(), r, &batch.Batch{ID: "b-2"}); errors.CodeOf(err) != CodeNotFound {
+		t.Fatalf("Resolve unattached: got %v", err)
+	}
+}
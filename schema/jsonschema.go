@@ -0,0 +1,173 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// jsonSchema is the subset of JSON Schema that descriptors map to. It is
+// how schemas are stored in registries, as schema type "JSON".
+type jsonSchema struct {
+	Schema          string                 `json:"$schema,omitempty"`
+	Title           string                 `json:"title,omitempty"`
+	Description     string                 `json:"description,omitempty"`
+	Type            string                 `json:"type"`
+	Format          string                 `json:"format,omitempty"`
+	ContentEncoding string                 `json:"contentEncoding,omitempty"`
+	Properties      map[string]*jsonSchema `json:"properties,omitempty"`
+	PropertyOrder   []string               `json:"propertyOrder,omitempty"`
+	Required        []string               `json:"required,omitempty"`
+	Items           *jsonSchema            `json:"items,omitempty"`
+}
+
+const draft = "https://json-schema.org/draft/2020-12/schema"
+
+// MarshalJSONSchema returns s as a JSON Schema document: an object whose
+// title is the subject and whose properties are the fields, in order.
+func MarshalJSONSchema(s *Schema) ([]byte, error) {
+	root := objectSchema(s.Fields)
+	root.Schema = draft
+	root.Title = s.Subject
+	return json.Marshal(root)
+}
+
+// UnmarshalJSONSchema parses a JSON Schema document written by
+// MarshalJSONSchema, or any using the same subset.
+func UnmarshalJSONSchema(data []byte) (*Schema, error) {
+	var root jsonSchema
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	if root.Type != "object" {
+		return nil, fmt.Errorf("schema: top-level type is %q, want object", root.Type)
+	}
+	fields, err := objectFields(&root)
+	if err != nil {
+		return nil, err
+	}
+	return &Schema{Subject: root.Title, Fields: fields}, nil
+}
+
+func objectSchema(fields []Field) *jsonSchema {
+	js := &jsonSchema{Type: "object", Properties: map[string]*jsonSchema{}}
+	for _, f := range fields {
+		js.Properties[f.Name] = fieldSchema(f)
+		js.PropertyOrder = append(js.PropertyOrder, f.Name)
+		if !f.Optional {
+			js.Required = append(js.Required, f.Name)
+		}
+	}
+	return js
+}
+
+func fieldSchema(f Field) *jsonSchema {
+	var js *jsonSchema
+	switch f.Type {
+	case TypeRecord:
+		js = objectSchema(f.Fields)
+	case TypeArray:
+		js = &jsonSchema{Type: "array"}
+		if f.Items != nil {
+			js.Items = fieldSchema(*f.Items)
+		}
+	case TypeInt:
+		js = &jsonSchema{Type: "integer"}
+	case TypeFloat:
+		js = &jsonSchema{Type: "number"}
+	case TypeBool:
+		js = &jsonSchema{Type: "boolean"}
+	case TypeBytes:
+		js = &jsonSchema{Type: "string", ContentEncoding: "base64"}
+	case TypeTimestamp:
+		js = &jsonSchema{Type: "string", Format: "date-time"}
+	default:
+		js = &jsonSchema{Type: "string"}
+	}
+	js.Description = f.Doc
+	return js
+}
+
+func objectFields(js *jsonSchema) ([]Field, error) {
+	required := map[string]bool{}
+	for _, name := range js.Required {
+		required[name] = true
+	}
+	// Keep the recorded order; properties it misses follow in name order,
+	// as encoding/json lists them.
+	order := append([]string(nil), js.PropertyOrder...)
+	listed := map[string]bool{}
+	for _, name := range order {
+		listed[name] = true
+	}
+	for _, name := range sortedKeys(js.Properties) {
+		if !listed[name] {
+			order = append(order, name)
+		}
+	}
+
+	var fields []Field
+	for _, name := range order {
+		prop, ok := js.Properties[name]
+		if !ok {
+			continue
+		}
+		f, err := schemaField(prop)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		f.Name = name
+		f.Optional = !required[name]
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+func schemaField(js *jsonSchema) (Field, error) {
+	f := Field{Doc: js.Description}
+	switch js.Type {
+	case "object":
+		f.Type = TypeRecord
+		fields, err := objectFields(js)
+		if err != nil {
+			return f, err
+		}
+		f.Fields = fields
+	case "array":
+		f.Type = TypeArray
+		if js.Items != nil {
+			items, err := schemaField(js.Items)
+			if err != nil {
+				return f, err
+			}
+			f.Items = &items
+		}
+	case "integer":
+		f.Type = TypeInt
+	case "number":
+		f.Type = TypeFloat
+	case "boolean":
+		f.Type = TypeBool
+	case "string":
+		switch {
+		case js.ContentEncoding == "base64":
+			f.Type = TypeBytes
+		case js.Format == "date-time":
+			f.Type = TypeTimestamp
+		default:
+			f.Type = TypeString
+		}
+	default:
+		return f, fmt.Errorf("unsupported JSON Schema type %q", js.Type)
+	}
+	return f, nil
+}
+
+func sortedKeys(m map[string]*jsonSchema) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,59 @@
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestJSONSchema_RoundTrip(t *testing.T) {
+	want := orderSchema()
+	want.Fields[0].Doc = "order ID"
+	data, err := MarshalJSONSchema(want)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	got, err := UnmarshalJSONSchema(data)
+	if err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestJSONSchema_Document(t *testing.T) {
+	data, _ := MarshalJSONSchema(orderSchema())
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if doc["type"] != "object" || doc["title"] != "orders-value" {
+		t.Fatalf("got %s", data)
+	}
+	props := doc["properties"].(map[string]interface{})
+	placed := props["placed_at"].(map[string]interface{})
+	if placed["type"] != "string" || placed["format"] != "date-time" {
+		t.Fatalf("placed_at: got %v", placed)
+	}
+	if required := doc["required"].([]interface{}); len(required) != 5 {
+		t.Fatalf("required: got %v", required)
+	}
+}
+
+func TestJSONSchema_Foreign(t *testing.T) {
+	// A document from another tool: no property order, unsupported types.
+	s, err := UnmarshalJSONSchema([]byte(`{"type":"object","properties":{"b":{"type":"integer"},"a":{"type":"string"}},"required":["a"]}`))
+	if err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if len(s.Fields) != 2 || s.Fields[0].Name != "a" || s.Fields[0].Optional || !s.Fields[1].Optional {
+		t.Fatalf("got %+v", s.Fields)
+	}
+	if _, err := UnmarshalJSONSchema([]byte(`{"type":"object","properties":{"x":{"type":"null"}}}`)); err == nil {
+		t.Fatal("expected an error for an unsupported type")
+	}
+	if _, err := UnmarshalJSONSchema([]byte(`{"type":"string"}`)); err == nil {
+		t.Fatal("expected an error for a non-object schema")
+	}
+}
@@ -0,0 +1,264 @@
+package schema
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/planx-lab/planx-common/concurrency"
+	"github.com/planx-lab/planx-common/errors"
+)
+
+// Registry stores schemas by subject and assigns them IDs and versions.
+type Registry interface {
+	// Register stores s under its subject, or finds it if already stored,
+	// and returns it with its ID set.
+	Register(ctx context.Context, s *Schema) (*Schema, error)
+
+	// ByID returns the schema with the given ID. IDs never change meaning,
+	// so implementations may cache the result indefinitely.
+	ByID(ctx context.Context, id int) (*Schema, error)
+
+	// Latest returns the newest version registered under subject.
+	Latest(ctx context.Context, subject string) (*Schema, error)
+
+	// Compatible checks s against the newest version of its subject under
+	// the registry's compatibility rules, failing with CodeIncompatible if
+	// it may not be registered. A subject with no versions accepts any
+	// schema.
+	Compatible(ctx context.Context, s *Schema) error
+}
+
+// HTTPRegistry is a Registry backed by a Confluent-compatible schema
+// registry REST API. Schemas are stored as JSON Schema documents (see
+// MarshalJSONSchema). Lookups by ID are cached and concurrent lookups of
+// the same ID share one request.
+type HTTPRegistry struct {
+	baseURL  string
+	client   *http.Client
+	user     string
+	password string
+
+	lookups *concurrency.Singleflight[int, *Schema]
+	mu      sync.RWMutex
+	byID    map[int]*Schema
+}
+
+// HTTPOption configures an HTTPRegistry.
+type HTTPOption func(*HTTPRegistry)
+
+// WithHTTPClient sets the client used for requests. The default has a 10s
+// timeout.
+func WithHTTPClient(c *http.Client) HTTPOption {
+	return func(r *HTTPRegistry) { r.client = c }
+}
+
+// WithBasicAuth authenticates requests, as hosted registries require.
+func WithBasicAuth(user, password string) HTTPOption {
+	return func(r *HTTPRegistry) { r.user, r.password = user, password }
+}
+
+// NewHTTPRegistry returns a client for the registry at baseURL, e.g.
+// "http://schema-registry:8081".
+func NewHTTPRegistry(baseURL string, opts ...HTTPOption) *HTTPRegistry {
+	r := &HTTPRegistry{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  &http.Client{Timeout: 10 * time.Second},
+		lookups: concurrency.NewSingleflight[int, *Schema](0),
+		byID:    map[int]*Schema{},
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+const registryContentType = "application/vnd.schemaregistry.v1+json"
+
+// schemaRequest is the body of register and compatibility requests.
+type schemaRequest struct {
+	Schema     string `json:"schema"`
+	SchemaType string `json:"schemaType"`
+}
+
+// schemaResponse covers the schema responses used here.
+type schemaResponse struct {
+	Subject string `json:"subject"`
+	ID      int    `json:"id"`
+	Version int    `json:"version"`
+	Schema  string `json:"schema"`
+}
+
+// Register implements Registry.
+func (r *HTTPRegistry) Register(ctx context.Context, s *Schema) (*Schema, error) {
+	body, err := r.schemaBody(s)
+	if err != nil {
+		return nil, err
+	}
+	var resp schemaResponse
+	path := "/subjects/" + url.PathEscape(s.Subject) + "/versions"
+	if err := r.do(ctx, http.MethodPost, path, body, &resp); err != nil {
+		return nil, err
+	}
+	out := *s
+	out.ID = resp.ID
+	r.remember(&out)
+	return &out, nil
+}
+
+// ByID implements Registry.
+func (r *HTTPRegistry) ByID(ctx context.Context, id int) (*Schema, error) {
+	r.mu.RLock()
+	s, ok := r.byID[id]
+	r.mu.RUnlock()
+	if ok {
+		return s, nil
+	}
+	s, _, err := r.lookups.Do(ctx, id, func(ctx context.Context) (*Schema, error) {
+		var resp schemaResponse
+		if err := r.do(ctx, http.MethodGet, "/schemas/ids/"+strconv.Itoa(id), nil, &resp); err != nil {
+			return nil, err
+		}
+		s, err := UnmarshalJSONSchema([]byte(resp.Schema))
+		if err != nil {
+			return nil, errors.WrapStreamError(err, fmt.Sprintf("schema: registry returned an unreadable schema %d", id))
+		}
+		s.ID = id
+		r.remember(s)
+		return s, nil
+	})
+	return s, err
+}
+
+// Latest implements Registry.
+func (r *HTTPRegistry) Latest(ctx context.Context, subject string) (*Schema, error) {
+	var resp schemaResponse
+	path := "/subjects/" + url.PathEscape(subject) + "/versions/latest"
+	if err := r.do(ctx, http.MethodGet, path, nil, &resp); err != nil {
+		return nil, err
+	}
+	s, err := UnmarshalJSONSchema([]byte(resp.Schema))
+	if err != nil {
+		return nil, errors.WrapStreamError(err, fmt.Sprintf("schema: registry returned an unreadable schema for %q", subject))
+	}
+	s.Subject, s.ID, s.Version = resp.Subject, resp.ID, resp.Version
+	return s, nil
+}
+
+// Compatible implements Registry.
+func (r *HTTPRegistry) Compatible(ctx context.Context, s *Schema) error {
+	body, err := r.schemaBody(s)
+	if err != nil {
+		return err
+	}
+	var resp struct {
+		IsCompatible bool     `json:"is_compatible"`
+		Messages     []string `json:"messages"`
+	}
+	path := "/compatibility/subjects/" + url.PathEscape(s.Subject) + "/versions/latest?verbose=true"
+	err = r.do(ctx, http.MethodPost, path, body, &resp)
+	if errors.CodeOf(err) == CodeNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !resp.IsCompatible {
+		return errors.NewWithCode(CodeIncompatible, fmt.Sprintf("schema: %q is incompatible with the latest version", s.Subject)).
+			WithField("problems", resp.Messages)
+	}
+	return nil
+}
+
+func (r *HTTPRegistry) schemaBody(s *Schema) ([]byte, error) {
+	if err := s.Check(); err != nil {
+		return nil, err
+	}
+	doc, err := MarshalJSONSchema(s)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(schemaRequest{Schema: string(doc), SchemaType: "JSON"})
+}
+
+func (r *HTTPRegistry) remember(s *Schema) {
+	r.mu.Lock()
+	r.byID[s.ID] = s
+	r.mu.Unlock()
+}
+
+// do sends a request and decodes a successful response into out.
+func (r *HTTPRegistry) do(ctx context.Context, method, path string, body []byte, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, r.baseURL+path, reader)
+	if err != nil {
+		return errors.WrapConfigError(err, "schema: invalid registry request")
+	}
+	req.Header.Set("Accept", registryContentType)
+	if body != nil {
+		req.Header.Set("Content-Type", registryContentType)
+	}
+	if r.user != "" {
+		req.SetBasicAuth(r.user, r.password)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return errors.FromContext(ctx, errors.WrapTransportError(err, "schema: registry request failed", true))
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return errors.WrapTransportError(err, "schema: reading registry response", true)
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return statusError(method, path, resp, data)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return errors.WrapStreamError(err, "schema: malformed registry response")
+	}
+	return nil
+}
+
+// statusError classifies a failed registry response.
+func statusError(method, path string, resp *http.Response, data []byte) error {
+	var body struct {
+		ErrorCode int    `json:"error_code"`
+		Message   string `json:"message"`
+	}
+	_ = json.Unmarshal(data, &body)
+	msg := fmt.Sprintf("schema: %s %s: %s", method, path, resp.Status)
+	if body.Message != "" {
+		msg += ": " + body.Message
+	}
+
+	fields := map[string]interface{}{"status": resp.StatusCode}
+	if body.ErrorCode != 0 {
+		fields["registry_error_code"] = body.ErrorCode
+	}
+
+	switch status := resp.StatusCode; {
+	case status == http.StatusNotFound:
+		return errors.NewWithCode(CodeNotFound, msg).WithFields(fields)
+	case status == http.StatusConflict:
+		return errors.NewWithCode(CodeIncompatible, msg).WithFields(fields)
+	case status == http.StatusTooManyRequests:
+		retryAfter, _ := strconv.Atoi(resp.Header.Get("Retry-After"))
+		return errors.NewRateLimitError(msg, time.Duration(retryAfter)*time.Second).WithFields(fields)
+	case status >= http.StatusInternalServerError:
+		return errors.NewTransportError(msg, true).WithFields(fields)
+	default:
+		return errors.NewConfigError(msg).WithFields(fields)
+	}
+}
@@ -0,0 +1,204 @@
+package schema
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/planx-lab/planx-common/errors"
+)
+
+// fakeRegistry serves the parts of the Confluent schema registry API the
+// client uses, for one subject.
+type fakeRegistry struct {
+	mu       sync.Mutex
+	versions []string // schema documents; ID and version are index+1
+	lookups  int32
+	release  chan struct{} // if set, ID lookups wait for it
+}
+
+func (f *fakeRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if user, pass, _ := r.BasicAuth(); user != "key" || pass != "secret" {
+		writeRegistryError(w, http.StatusUnauthorized, 40101, "Unauthorized")
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var req schemaRequest
+	if r.Method == http.MethodPost {
+		if ct := r.Header.Get("Content-Type"); ct != registryContentType {
+			writeRegistryError(w, http.StatusUnsupportedMediaType, 415, ct)
+			return
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+	}
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/subjects/orders-value/versions":
+		for i, doc := range f.versions {
+			if doc == req.Schema {
+				writeJSON(w, map[string]int{"id": i + 1})
+				return
+			}
+		}
+		f.versions = append(f.versions, req.Schema)
+		writeJSON(w, map[string]int{"id": len(f.versions)})
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/schemas/ids/"):
+		atomic.AddInt32(&f.lookups, 1)
+		if f.release != nil {
+			f.mu.Unlock()
+			<-f.release
+			f.mu.Lock()
+		}
+		id := strings.TrimPrefix(r.URL.Path, "/schemas/ids/")
+		if id != "1" || len(f.versions) == 0 {
+			writeRegistryError(w, http.StatusNotFound, 40403, "Schema not found")
+			return
+		}
+		writeJSON(w, map[string]string{"schema": f.versions[0]})
+	case r.Method == http.MethodGet && r.URL.Path == "/subjects/orders-value/versions/latest":
+		n := len(f.versions)
+		if n == 0 {
+			writeRegistryError(w, http.StatusNotFound, 40401, "Subject not found")
+			return
+		}
+		writeJSON(w, map[string]interface{}{"subject": "orders-value", "id": n, "version": n, "schema": f.versions[n-1]})
+	case r.Method == http.MethodPost && r.URL.Path == "/compatibility/subjects/orders-value/versions/latest":
+		if len(f.versions) == 0 {
+			writeRegistryError(w, http.StatusNotFound, 40401, "Subject not found")
+			return
+		}
+		old, _ := UnmarshalJSONSchema([]byte(f.versions[len(f.versions)-1]))
+		next, _ := UnmarshalJSONSchema([]byte(req.Schema))
+		resp := map[string]interface{}{"is_compatible": true}
+		if err := CheckCompatible(old, next); err != nil {
+			resp = map[string]interface{}{"is_compatible": false, "messages": errors.Fields(err)["problems"]}
+		}
+		writeJSON(w, resp)
+	case r.URL.Path == "/subjects/broken/versions/latest":
+		writeRegistryError(w, http.StatusInternalServerError, 50001, "Error in the backend data store")
+	default:
+		writeRegistryError(w, http.StatusNotFound, 404, "not found")
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", registryContentType)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeRegistryError(w http.ResponseWriter, status, code int, msg string) {
+	w.Header().Set("Content-Type", registryContentType)
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"error_code": code, "message": msg})
+}
+
+func newTestRegistry(t *testing.T) (*HTTPRegistry, *fakeRegistry) {
+	fake := &fakeRegistry{}
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+	return NewHTTPRegistry(srv.URL+"/", WithBasicAuth("key", "secret"), WithHTTPClient(srv.Client())), fake
+}
+
+func TestHTTPRegistry(t *testing.T) {
+	r, _ := newTestRegistry(t)
+	ctx := context.Background()
+
+	if err := r.Compatible(ctx, orderSchema()); err != nil {
+		t.Fatalf("Compatible with no versions: %v", err)
+	}
+	registered, err := r.Register(ctx, orderSchema())
+	if err != nil || registered.ID != 1 {
+		t.Fatalf("Register: got %+v, %v", registered, err)
+	}
+	if again, _ := r.Register(ctx, orderSchema()); again.ID != 1 {
+		t.Fatalf("re-Register: got ID %d", again.ID)
+	}
+
+	latest, err := r.Latest(ctx, "orders-value")
+	if err != nil || latest.ID != 1 || latest.Version != 1 || len(latest.Fields) != 7 {
+		t.Fatalf("Latest: got %+v, %v", latest, err)
+	}
+
+	incompatible := orderSchema()
+	incompatible.Fields[0].Type = TypeInt
+	err = r.Compatible(ctx, incompatible)
+	if errors.CodeOf(err) != CodeIncompatible {
+		t.Fatalf("Compatible: got %v", err)
+	}
+	if problems, _ := errors.Fields(err)["problems"].([]string); len(problems) != 1 {
+		t.Fatalf("problems: got %v", errors.Fields(err)["problems"])
+	}
+}
+
+func TestHTTPRegistry_ByIDCachedAndShared(t *testing.T) {
+	r, fake := newTestRegistry(t)
+	ctx := context.Background()
+	if _, err := r.Register(ctx, orderSchema()); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	// Register cached the schema, so this lookup needs no request.
+	if s, err := r.ByID(ctx, 1); err != nil || s.Subject != "orders-value" {
+		t.Fatalf("ByID: got %+v, %v", s, err)
+	}
+	if fake.lookups != 0 {
+		t.Fatalf("got %d lookups, want 0", fake.lookups)
+	}
+
+	// A fresh client shares one request between concurrent lookups.
+	srvURL := strings.TrimSuffix(r.baseURL, "/")
+	fresh := NewHTTPRegistry(srvURL, WithBasicAuth("key", "secret"))
+	fake.release = make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if s, err := fresh.ByID(ctx, 1); err != nil || s.ID != 1 || len(s.Fields) != 7 {
+				t.Errorf("ByID: got %+v, %v", s, err)
+			}
+		}()
+	}
+	for atomic.LoadInt32(&fake.lookups) == 0 {
+		runtime.Gosched()
+	}
+	close(fake.release)
+	wg.Wait()
+	if _, err := fresh.ByID(ctx, 1); err != nil {
+		t.Fatalf("cached ByID: %v", err)
+	}
+	if n := atomic.LoadInt32(&fake.lookups); n != 1 {
+		t.Fatalf("got %d lookups, want 1", n)
+	}
+}
+
+func TestHTTPRegistry_Errors(t *testing.T) {
+	r, _ := newTestRegistry(t)
+	ctx := context.Background()
+
+	_, err := r.ByID(ctx, 42)
+	if errors.CodeOf(err) != CodeNotFound || errors.Fields(err)["registry_error_code"] != 40403 {
+		t.Fatalf("not found: got %v, %v", err, errors.Fields(err))
+	}
+	_, err = r.Latest(ctx, "broken")
+	if errors.CodeOf(err) != errors.CodeTransportUnavailable || !errors.IsRetryable(err) {
+		t.Fatalf("server error: got %v", err)
+	}
+
+	unauthorized := NewHTTPRegistry(r.baseURL, WithHTTPClient(r.client))
+	_, err = unauthorized.Latest(ctx, "orders-value")
+	if errors.CodeOf(err) != errors.CodeConfigInvalid || !strings.Contains(err.Error(), "Unauthorized") {
+		t.Fatalf("unauthorized: got %v", err)
+	}
+
+	if _, err := r.Register(ctx, &Schema{Subject: "orders-value", Fields: []Field{{Name: "x", Type: "decimal"}}}); errors.CodeOf(err) != errors.CodeConfigInvalid {
+		t.Fatalf("invalid schema: got %v", err)
+	}
+}
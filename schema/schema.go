@@ -0,0 +1,246 @@
+// Package schema describes the shape of records, attaches schema IDs to
+// batches and talks to schema registries, so processors validate and evolve
+// record shapes the same way.
+// Engine-side utilities only — must not be imported by SDK or plugins.
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/planx-lab/planx-common/errors"
+)
+
+// Schema error codes.
+const (
+	CodeNotFound      errors.Code = "PLX-SCHEMA-NOT-FOUND"
+	CodeIncompatible  errors.Code = "PLX-SCHEMA-INCOMPATIBLE"
+	CodeInvalidRecord errors.Code = "PLX-SCHEMA-RECORD"
+)
+
+func init() {
+	errors.RegisterCode(errors.CodeInfo{Code: CodeNotFound, Description: "schema or subject not found in the registry"})
+	errors.RegisterCode(errors.CodeInfo{Code: CodeIncompatible, Description: "schema change breaks compatibility"})
+	errors.RegisterCode(errors.CodeInfo{Code: CodeInvalidRecord, Description: "record does not match its schema"})
+}
+
+// Type is the type of a field.
+type Type string
+
+// Field types.
+const (
+	TypeString    Type = "string"
+	TypeInt       Type = "int"
+	TypeFloat     Type = "float"
+	TypeBool      Type = "bool"
+	TypeBytes     Type = "bytes"     // base64 in JSON
+	TypeTimestamp Type = "timestamp" // RFC 3339 in JSON
+	TypeRecord    Type = "record"    // nested fields
+	TypeArray     Type = "array"     // elements described by Items
+)
+
+// Field describes one field of a record.
+type Field struct {
+	Name     string  `json:"name"`
+	Type     Type    `json:"type"`
+	Optional bool    `json:"optional,omitempty"`
+	Doc      string  `json:"doc,omitempty"`
+	Fields   []Field `json:"fields,omitempty"` // for TypeRecord
+	Items    *Field  `json:"items,omitempty"`  // for TypeArray; its Name is unused
+}
+
+// Schema describes records of a subject, such as "orders-value". ID and
+// Version are assigned by the registry and are zero for unregistered
+// schemas.
+type Schema struct {
+	Subject string  `json:"subject"`
+	ID      int     `json:"id,omitempty"`
+	Version int     `json:"version,omitempty"`
+	Fields  []Field `json:"fields"`
+}
+
+// Check reports mistakes in the descriptor itself: empty or duplicate
+// field names, unknown types, records without fields and arrays without
+// items.
+func (s *Schema) Check() error {
+	var problems []string
+	checkFields(s.Fields, "", &problems)
+	if len(problems) > 0 {
+		return errors.NewConfigErrorf("schema %q: %s", s.Subject, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func checkFields(fields []Field, path string, problems *[]string) {
+	seen := map[string]bool{}
+	for _, f := range fields {
+		switch {
+		case f.Name == "":
+			*problems = append(*problems, fmt.Sprintf("%sfield with empty name", prefix(path)))
+			continue
+		case seen[f.Name]:
+			*problems = append(*problems, fmt.Sprintf("%s: duplicate field", path+f.Name))
+		}
+		seen[f.Name] = true
+		checkField(f, path+f.Name, problems)
+	}
+}
+
+func checkField(f Field, path string, problems *[]string) {
+	switch f.Type {
+	case TypeString, TypeInt, TypeFloat, TypeBool, TypeBytes, TypeTimestamp:
+	case TypeRecord:
+		if len(f.Fields) == 0 {
+			*problems = append(*problems, path+": record without fields")
+		}
+		checkFields(f.Fields, path+".", problems)
+	case TypeArray:
+		if f.Items == nil {
+			*problems = append(*problems, path+": array without items")
+			return
+		}
+		checkField(*f.Items, path+"[]", problems)
+	default:
+		*problems = append(*problems, fmt.Sprintf("%s: unknown type %q", path, f.Type))
+	}
+}
+
+func prefix(path string) string {
+	if path == "" {
+		return ""
+	}
+	return strings.TrimSuffix(path, ".") + ": "
+}
+
+// ValidateJSON checks that payload is a JSON object matching s: required
+// fields are present and every known field has the right type. Fields the
+// schema does not know are allowed. Violations are returned as one error
+// coded CodeInvalidRecord whose "violations" field lists them.
+func (s *Schema) ValidateJSON(payload []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(payload, &v); err != nil {
+		return errors.Wrap(err, "schema: record is not valid JSON").WithCode(CodeInvalidRecord)
+	}
+	var violations []string
+	validateValue(v, Field{Type: TypeRecord, Fields: s.Fields}, "", &violations)
+	if len(violations) > 0 {
+		return errors.NewWithCode(CodeInvalidRecord, "schema: record does not match "+s.Subject+": "+strings.Join(violations, "; ")).
+			WithField("violations", violations)
+	}
+	return nil
+}
+
+func validateValue(v interface{}, f Field, path string, violations *[]string) {
+	if v == nil {
+		switch {
+		case path == "":
+			*violations = append(*violations, "record: expected record")
+		case !f.Optional:
+			*violations = append(*violations, path+": must not be null")
+		}
+		return
+	}
+	ok := true
+	switch f.Type {
+	case TypeString:
+		_, ok = v.(string)
+	case TypeInt:
+		n, isNum := v.(float64)
+		ok = isNum && n == float64(int64(n))
+	case TypeFloat:
+		_, ok = v.(float64)
+	case TypeBool:
+		_, ok = v.(bool)
+	case TypeBytes:
+		_, ok = v.(string)
+	case TypeTimestamp:
+		str, isStr := v.(string)
+		if ok = isStr; ok {
+			_, err := time.Parse(time.RFC3339Nano, str)
+			ok = err == nil
+		}
+	case TypeRecord:
+		obj, isObj := v.(map[string]interface{})
+		if ok = isObj; ok {
+			for _, sub := range f.Fields {
+				val, present := obj[sub.Name]
+				subPath := joinPath(path, sub.Name)
+				if !present {
+					if !sub.Optional {
+						*violations = append(*violations, subPath+": is required")
+					}
+					continue
+				}
+				validateValue(val, sub, subPath, violations)
+			}
+		}
+	case TypeArray:
+		arr, isArr := v.([]interface{})
+		if ok = isArr; ok && f.Items != nil {
+			for i, el := range arr {
+				validateValue(el, *f.Items, fmt.Sprintf("%s[%d]", path, i), violations)
+			}
+		}
+	}
+	if !ok {
+		name := path
+		if name == "" {
+			name = "record"
+		}
+		*violations = append(*violations, fmt.Sprintf("%s: expected %s", name, f.Type))
+	}
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// CheckCompatible reports whether records written with old can be read
+// with next (backward compatibility, the registry default): next may add
+// optional fields and drop fields, but must not add required fields or
+// change a field's type.
+func CheckCompatible(old, next *Schema) error {
+	var problems []string
+	compareFields(old.Fields, next.Fields, "", &problems)
+	if len(problems) > 0 {
+		return errors.NewWithCode(CodeIncompatible, fmt.Sprintf("schema %q: %s", next.Subject, strings.Join(problems, "; "))).
+			WithField("problems", problems)
+	}
+	return nil
+}
+
+func compareFields(old, next []Field, path string, problems *[]string) {
+	byName := make(map[string]Field, len(old))
+	for _, f := range old {
+		byName[f.Name] = f
+	}
+	for _, f := range next {
+		p := joinPath(path, f.Name)
+		prev, existed := byName[f.Name]
+		if !existed {
+			if !f.Optional {
+				*problems = append(*problems, p+": new field must be optional")
+			}
+			continue
+		}
+		compareField(prev, f, p, problems)
+	}
+}
+
+func compareField(prev, f Field, path string, problems *[]string) {
+	switch {
+	case prev.Type != f.Type:
+		*problems = append(*problems, fmt.Sprintf("%s: type changed from %s to %s", path, prev.Type, f.Type))
+	case prev.Optional && !f.Optional:
+		*problems = append(*problems, path+": optional field made required")
+	case f.Type == TypeRecord:
+		compareFields(prev.Fields, f.Fields, path, problems)
+	case f.Type == TypeArray && prev.Items != nil && f.Items != nil:
+		compareField(*prev.Items, *f.Items, path+"[]", problems)
+	}
+}
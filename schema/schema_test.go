@@ -0,0 +1,103 @@
+package schema
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/planx-lab/planx-common/errors"
+)
+
+func orderSchema() *Schema {
+	return &Schema{
+		Subject: "orders-value",
+		Fields: []Field{
+			{Name: "id", Type: TypeString},
+			{Name: "amount", Type: TypeFloat},
+			{Name: "quantity", Type: TypeInt},
+			{Name: "paid", Type: TypeBool, Optional: true},
+			{Name: "placed_at", Type: TypeTimestamp},
+			{Name: "customer", Type: TypeRecord, Fields: []Field{
+				{Name: "name", Type: TypeString},
+				{Name: "email", Type: TypeString, Optional: true},
+			}},
+			{Name: "tags", Type: TypeArray, Optional: true, Items: &Field{Type: TypeString}},
+		},
+	}
+}
+
+func TestCheck(t *testing.T) {
+	if err := orderSchema().Check(); err != nil {
+		t.Fatalf("valid schema: %v", err)
+	}
+	bad := &Schema{Subject: "bad", Fields: []Field{
+		{Name: "a", Type: TypeString},
+		{Name: "a", Type: TypeInt},
+		{Name: "", Type: TypeInt},
+		{Name: "b", Type: "decimal"},
+		{Name: "c", Type: TypeRecord},
+		{Name: "d", Type: TypeArray},
+	}}
+	err := bad.Check()
+	for _, want := range []string{"a: duplicate field", "field with empty name", `b: unknown type "decimal"`, "c: record without fields", "d: array without items"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("want %q in %v", want, err)
+		}
+	}
+}
+
+func TestValidateJSON(t *testing.T) {
+	s := orderSchema()
+	valid := `{"id":"o-1","amount":9.5,"quantity":2,"placed_at":"2024-05-01T10:00:00Z","customer":{"name":"Ada"},"tags":["a"],"extra":1}`
+	if err := s.ValidateJSON([]byte(valid)); err != nil {
+		t.Fatalf("valid record: %v", err)
+	}
+
+	invalid := `{"id":7,"amount":"9.5","quantity":2.5,"placed_at":"yesterday","customer":{"email":null},"tags":[1]}`
+	err := s.ValidateJSON([]byte(invalid))
+	if errors.CodeOf(err) != CodeInvalidRecord {
+		t.Fatalf("got %v", err)
+	}
+	violations, _ := errors.Fields(err)["violations"].([]string)
+	want := []string{
+		"id: expected string",
+		"amount: expected float",
+		"quantity: expected int",
+		"placed_at: expected timestamp",
+		"customer.name: is required",
+		"tags[0]: expected string",
+	}
+	if strings.Join(violations, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("got %q", violations)
+	}
+
+	for _, payload := range []string{`[1]`, `null`, `{`} {
+		if err := s.ValidateJSON([]byte(payload)); errors.CodeOf(err) != CodeInvalidRecord {
+			t.Fatalf("%s: got %v", payload, err)
+		}
+	}
+}
+
+func TestCheckCompatible(t *testing.T) {
+	old := orderSchema()
+
+	next := orderSchema()
+	next.Fields = append(next.Fields[:3], next.Fields[4:]...) // drop paid
+	next.Fields = append(next.Fields, Field{Name: "note", Type: TypeString, Optional: true})
+	if err := CheckCompatible(old, next); err != nil {
+		t.Fatalf("compatible change: %v", err)
+	}
+
+	broken := orderSchema()
+	broken.Fields[1].Type = TypeString
+	broken.Fields[3].Optional = false
+	broken.Fields[5].Fields = append(broken.Fields[5].Fields, Field{Name: "phone", Type: TypeString})
+	broken.Fields[6].Items = &Field{Type: TypeInt}
+	err := CheckCompatible(old, broken)
+	if errors.CodeOf(err) != CodeIncompatible {
+		t.Fatalf("got %v", err)
+	}
+	problems, _ := errors.Fields(err)["problems"].([]string)
+	if len(problems) != 4 {
+		t.Fatalf("got %q", problems)
+	}
+}
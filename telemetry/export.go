@@ -0,0 +1,106 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// Signals whose exports are tracked for ExportHealth.
+const (
+	SignalTraces  = "traces"
+	SignalMetrics = "metrics"
+	SignalLogs    = "logs"
+)
+
+// exportState is the outcome of the latest export of one signal.
+type exportState struct {
+	err         error
+	failingFrom time.Time // first failure of the current streak
+}
+
+var (
+	exportMu     sync.Mutex
+	exportStates = map[string]*exportState{}
+)
+
+// recordExport notes the outcome of an export of signal.
+func recordExport(signal string, err error) {
+	exportMu.Lock()
+	defer exportMu.Unlock()
+	st, ok := exportStates[signal]
+	if !ok {
+		st = &exportState{}
+		exportStates[signal] = st
+	}
+	if err == nil {
+		*st = exportState{}
+		return
+	}
+	if st.err == nil {
+		st.failingFrom = time.Now()
+	}
+	st.err = err
+}
+
+// ExportHealth returns an error naming every signal whose latest export
+// failed, or nil if all exports since InitTracing, InitMetrics and
+// InitLogging succeeded (or none happened yet). Providers built with
+// InitMetricsWithReaders use the caller's readers and are not tracked.
+func ExportHealth() error {
+	exportMu.Lock()
+	defer exportMu.Unlock()
+	var failing []string
+	for signal, st := range exportStates {
+		if st.err != nil {
+			failing = append(failing, fmt.Sprintf("%s export failing since %s: %v",
+				signal, st.failingFrom.Format(time.RFC3339), st.err))
+		}
+	}
+	if len(failing) == 0 {
+		return nil
+	}
+	sort.Strings(failing)
+	return fmt.Errorf("telemetry: %s", strings.Join(failing, "; "))
+}
+
+// trackedSpanExporter records the outcome of every span export.
+type trackedSpanExporter struct {
+	sdktrace.SpanExporter
+}
+
+func (e trackedSpanExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	err := e.SpanExporter.ExportSpans(ctx, spans)
+	recordExport(SignalTraces, err)
+	return err
+}
+
+// trackedMetricExporter records the outcome of every metric export.
+type trackedMetricExporter struct {
+	sdkmetric.Exporter
+}
+
+func (e trackedMetricExporter) Export(ctx context.Context, rm *metricdata.ResourceMetrics) error {
+	err := e.Exporter.Export(ctx, rm)
+	recordExport(SignalMetrics, err)
+	return err
+}
+
+// trackedLogExporter records the outcome of every log export.
+type trackedLogExporter struct {
+	sdklog.Exporter
+}
+
+func (e trackedLogExporter) Export(ctx context.Context, records []sdklog.Record) error {
+	err := e.Exporter.Export(ctx, records)
+	recordExport(SignalLogs, err)
+	return err
+}
@@ -0,0 +1,50 @@
+package telemetry
+
+import (
+	"context"
+	stderrors "errors"
+	"strings"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// failingSpanExporter fails every export while err is set.
+type failingSpanExporter struct {
+	*tracetest.InMemoryExporter
+	err error
+}
+
+func (e *failingSpanExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	if e.err != nil {
+		return e.err
+	}
+	return e.InMemoryExporter.ExportSpans(ctx, spans)
+}
+
+func TestExportHealth(t *testing.T) {
+	defer func() {
+		exportMu.Lock()
+		exportStates = map[string]*exportState{}
+		exportMu.Unlock()
+	}()
+	inner := &failingSpanExporter{InMemoryExporter: tracetest.NewInMemoryExporter()}
+	exp := trackedSpanExporter{inner}
+	ctx := context.Background()
+
+	if err := exp.ExportSpans(ctx, nil); err != nil || ExportHealth() != nil {
+		t.Fatalf("healthy export: %v, %v", err, ExportHealth())
+	}
+	inner.err = stderrors.New("connection refused")
+	_ = exp.ExportSpans(ctx, nil)
+	err := ExportHealth()
+	if err == nil || !strings.Contains(err.Error(), "traces export failing") || !strings.Contains(err.Error(), "connection refused") {
+		t.Fatalf("got %v", err)
+	}
+	inner.err = nil
+	_ = exp.ExportSpans(ctx, nil)
+	if err := ExportHealth(); err != nil {
+		t.Fatalf("after recovery: %v", err)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"sync"
 
+	"github.com/planx-lab/planx-common/logger"
 	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp"
 	"go.opentelemetry.io/otel/exporters/stdout/stdoutlog"
 	"go.opentelemetry.io/otel/log/global"
@@ -23,8 +24,9 @@ var (
 
 // LoggingConfig holds logging configuration.
 type LoggingConfig struct {
-	ServiceName string
-	Endpoint    string // OTLP endpoint, empty for stdout
+	ServiceName  string
+	Endpoint     string // OTLP endpoint, empty for stdout
+	ExportLogger bool   // mirror the zerolog logger into this pipeline
 }
 
 // InitLogging initializes OpenTelemetry logging with OTLP or stdout exporter.
@@ -64,21 +66,28 @@ func initLoggingInternal(ctx context.Context, cfg LoggingConfig) error {
 
 	loggerProvider = sdklog.NewLoggerProvider(
 		sdklog.WithResource(res),
-		sdklog.WithProcessor(sdklog.NewBatchProcessor(exporter)),
+		sdklog.WithProcessor(sdklog.NewBatchProcessor(trackedLogExporter{exporter})),
 	)
 
 	global.SetLoggerProvider(loggerProvider)
 
+	if cfg.ExportLogger {
+		logger.EnableOTelExport(loggerProvider)
+	}
+
 	return nil
 }
 
 // ShutdownLogging gracefully shuts down the logger provider.
+// The zerolog bridge is detached first so that every mirrored line is
+// flushed by the shutdown and later lines only go to the local output.
 func ShutdownLogging(ctx context.Context) error {
 	lpMu.Lock()
 	lp := loggerProvider
 	loggerProvider = nil
 	lpMu.Unlock()
 	if lp != nil {
+		logger.DisableOTelExport()
 		return lp.Shutdown(ctx)
 	}
 	return nil
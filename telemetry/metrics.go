@@ -34,10 +34,31 @@ var (
 	stageLatency metric.Float64Histogram
 	ackLatency   metric.Float64Histogram
 
+	// Compression throughput
+	compressBytesIn  metric.Int64Counter
+	compressBytesOut metric.Int64Counter
+	compressLatency  metric.Float64Histogram
+
+	// Feature flags
+	flagEvaluations metric.Int64Counter
+
+	// Supervised goroutines
+	goroutinePanics   metric.Int64Counter
+	goroutineRestarts metric.Int64Counter
+
+	// Deduplication
+	dedupLookups metric.Int64Counter
+
 	// Gauges
 	windowBacklog   metric.Int64UpDownCounter
 	sessionsActive  metric.Int64UpDownCounter
 	inFlightBatches metric.Int64UpDownCounter
+	circuitState    metric.Int64Gauge
+	drainLeases     metric.Int64UpDownCounter
+
+	// Pools, sampled at collection time
+	poolsMu sync.RWMutex
+	pools   = map[string]func() PoolStats{}
 )
 
 // MetricsConfig holds metrics configuration.
@@ -86,7 +107,7 @@ func initMetricsInternal(ctx context.Context, cfg MetricsConfig) error {
 
 	provider := sdkmetric.NewMeterProvider(
 		sdkmetric.WithResource(res),
-		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter, sdkmetric.WithInterval(interval))),
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(trackedMetricExporter{exporter}, sdkmetric.WithInterval(interval))),
 	)
 
 	otel.SetMeterProvider(provider)
@@ -192,6 +213,63 @@ func initInstruments(provider *sdkmetric.MeterProvider) error {
 	if err != nil {
 		errs = append(errs, fmt.Errorf("creating batches.inflight updowncounter: %w", err))
 	}
+	circuitState, err = meter.Int64Gauge("planx.circuitbreaker.state",
+		metric.WithDescription("Circuit breaker state (0 closed, 1 half-open, 2 open)"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("creating circuitbreaker.state gauge: %w", err))
+	}
+	drainLeases, err = meter.Int64UpDownCounter("planx.drain.leases",
+		metric.WithDescription("Work leases held (remaining work during drain)"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("creating drain.leases updowncounter: %w", err))
+	}
+
+	compressBytesIn, err = meter.Int64Counter("planx.compress.bytes.in",
+		metric.WithDescription("Bytes fed to compression codecs"),
+		metric.WithUnit("By"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("creating compress.bytes.in counter: %w", err))
+	}
+	compressBytesOut, err = meter.Int64Counter("planx.compress.bytes.out",
+		metric.WithDescription("Bytes produced by compression codecs"),
+		metric.WithUnit("By"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("creating compress.bytes.out counter: %w", err))
+	}
+	compressLatency, err = meter.Float64Histogram("planx.compress.latency",
+		metric.WithDescription("Compression and decompression latency in milliseconds"),
+		metric.WithUnit("ms"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("creating compress.latency histogram: %w", err))
+	}
+
+	flagEvaluations, err = meter.Int64Counter("planx.featureflag.evaluations",
+		metric.WithDescription("Feature flag evaluations"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("creating featureflag.evaluations counter: %w", err))
+	}
+
+	goroutinePanics, err = meter.Int64Counter("planx.goroutine.panics",
+		metric.WithDescription("Panics recovered in supervised goroutines"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("creating goroutine.panics counter: %w", err))
+	}
+
+	goroutineRestarts, err = meter.Int64Counter("planx.goroutine.restarts",
+		metric.WithDescription("Restarts of supervised goroutines"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("creating goroutine.restarts counter: %w", err))
+	}
+
+	dedupLookups, err = meter.Int64Counter("planx.dedup.lookups",
+		metric.WithDescription("Idempotency key lookups by deduplication trackers"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("creating dedup.lookups counter: %w", err))
+	}
+
+	if err := initPoolInstruments(); err != nil {
+		errs = append(errs, err)
+	}
 
 	return errors.Join(errs...)
 }
@@ -244,10 +322,15 @@ func RecordAckLatency(ctx context.Context, latencyMs float64) {
 
 // RecordError records an error.
 func RecordError(ctx context.Context, tenantID, stage, errorType string) {
+	RecordErrors(ctx, tenantID, stage, errorType, 1)
+}
+
+// RecordErrors records n errors at once, for aggregated reports.
+func RecordErrors(ctx context.Context, tenantID, stage, errorType string, n int64) {
 	if errorsTotal == nil {
 		return
 	}
-	errorsTotal.Add(ctx, 1, metric.WithAttributes(
+	errorsTotal.Add(ctx, n, metric.WithAttributes(
 		attribute.String("tenant_id", tenantID),
 		attribute.String("stage", stage),
 		attribute.String("error_type", errorType),
@@ -281,3 +364,154 @@ func UpdateInFlightBatches(ctx context.Context, delta int64) {
 	}
 	inFlightBatches.Add(ctx, delta)
 }
+
+// RecordCircuitState records the state of the named circuit breaker:
+// 0 closed, 1 half-open, 2 open.
+func RecordCircuitState(ctx context.Context, breaker string, state int64) {
+	if circuitState == nil {
+		return
+	}
+	circuitState.Record(ctx, state, metric.WithAttributes(
+		attribute.String("breaker", breaker),
+	))
+}
+
+// UpdateDrainLeases updates the work leases gauge of the named drainer.
+func UpdateDrainLeases(ctx context.Context, drainer string, delta int64) {
+	if drainLeases == nil {
+		return
+	}
+	drainLeases.Add(ctx, delta, metric.WithAttributes(
+		attribute.String("drainer", drainer),
+	))
+}
+
+// RecordCompression records one compress or decompress call by codec: the
+// bytes in and out, from which throughput and ratio follow, and its latency.
+func RecordCompression(ctx context.Context, codec, op string, in, out int64, elapsed time.Duration) {
+	if compressBytesIn == nil || compressBytesOut == nil || compressLatency == nil {
+		return
+	}
+	attrs := metric.WithAttributes(
+		attribute.String("codec", codec),
+		attribute.String("op", op),
+	)
+	compressBytesIn.Add(ctx, in, attrs)
+	compressBytesOut.Add(ctx, out, attrs)
+	compressLatency.Record(ctx, float64(elapsed.Microseconds())/1000, attrs)
+}
+
+// RecordFlagEvaluation records one evaluation of a feature flag. result is
+// how the value was found: "provider", "default", "error" or
+// "type_mismatch".
+func RecordFlagEvaluation(ctx context.Context, flag, result string) {
+	if flagEvaluations == nil {
+		return
+	}
+	flagEvaluations.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("flag", flag),
+		attribute.String("result", result),
+	))
+}
+
+// RecordGoroutinePanic records a panic recovered in the supervised
+// goroutine name.
+func RecordGoroutinePanic(ctx context.Context, name string) {
+	if goroutinePanics == nil {
+		return
+	}
+	goroutinePanics.Add(ctx, 1, metric.WithAttributes(attribute.String("goroutine", name)))
+}
+
+// RecordGoroutineRestart records a restart of the supervised goroutine
+// name.
+func RecordGoroutineRestart(ctx context.Context, name string) {
+	if goroutineRestarts == nil {
+		return
+	}
+	goroutineRestarts.Add(ctx, 1, metric.WithAttributes(attribute.String("goroutine", name)))
+}
+
+// RecordDedupLookup records one idempotency key lookup by the
+// deduplication tracker named tracker. result is "hit", "miss" or "error".
+func RecordDedupLookup(ctx context.Context, tracker, result string) {
+	if dedupLookups == nil {
+		return
+	}
+	dedupLookups.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("tracker", tracker),
+		attribute.String("result", result),
+	))
+}
+
+// PoolStats is a snapshot of the counters of a buffer pool.
+type PoolStats struct {
+	Gets, Puts int64 // buffers handed out and returned
+	Allocs     int64 // gets the pool could not serve from its free list
+	Drops      int64 // puts the pool did not keep
+	InUse      int64 // buffers handed out and not yet returned
+	HighWater  int64 // the most ever in use at once
+}
+
+// RegisterPool reports the named pool as planx.pool.* metrics, sampling
+// stats at collection time so the pool itself only keeps atomic counters.
+// Like errors.RegisterCode it panics if the name is empty or already
+// registered.
+func RegisterPool(name string, stats func() PoolStats) {
+	if name == "" || stats == nil {
+		panic("telemetry: RegisterPool with empty name or nil stats")
+	}
+	poolsMu.Lock()
+	defer poolsMu.Unlock()
+	if _, dup := pools[name]; dup {
+		panic(fmt.Sprintf("telemetry: pool %q registered twice", name))
+	}
+	pools[name] = stats
+}
+
+func initPoolInstruments() error {
+	var errs []error
+	counter := func(name, desc string) metric.Int64ObservableCounter {
+		c, err := meter.Int64ObservableCounter(name, metric.WithDescription(desc))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("creating %s counter: %w", name, err))
+		}
+		return c
+	}
+	gauge := func(name, desc string) metric.Int64ObservableGauge {
+		g, err := meter.Int64ObservableGauge(name, metric.WithDescription(desc))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("creating %s gauge: %w", name, err))
+		}
+		return g
+	}
+	gets := counter("planx.pool.gets", "Buffers handed out by pools")
+	puts := counter("planx.pool.puts", "Buffers returned to pools")
+	allocs := counter("planx.pool.allocs", "Pool gets that allocated a new buffer")
+	drops := counter("planx.pool.drops", "Buffers returned to pools but not kept")
+	inUse := gauge("planx.pool.in_use", "Pool buffers currently in use")
+	highWater := gauge("planx.pool.in_use.high", "Most pool buffers in use at once")
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	_, err := meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		poolsMu.RLock()
+		defer poolsMu.RUnlock()
+		for name, stats := range pools {
+			s := stats()
+			attrs := metric.WithAttributes(attribute.String("pool", name))
+			o.ObserveInt64(gets, s.Gets, attrs)
+			o.ObserveInt64(puts, s.Puts, attrs)
+			o.ObserveInt64(allocs, s.Allocs, attrs)
+			o.ObserveInt64(drops, s.Drops, attrs)
+			o.ObserveInt64(inUse, s.InUse, attrs)
+			o.ObserveInt64(highWater, s.HighWater, attrs)
+		}
+		return nil
+	}, gets, puts, allocs, drops, inUse, highWater)
+	if err != nil {
+		return fmt.Errorf("registering pool callback: %w", err)
+	}
+	return nil
+}
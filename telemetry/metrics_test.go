@@ -3,6 +3,10 @@ package telemetry
 import (
 	"context"
 	"testing"
+	"time"
+
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
 )
 
 func TestInitMetrics(t *testing.T) {
@@ -41,6 +45,11 @@ func TestRecordError(t *testing.T) {
 	RecordError(ctx, "tenant-1", "sink", "connection_refused")
 }
 
+func TestRecordErrors(t *testing.T) {
+	ctx := context.Background()
+	RecordErrors(ctx, "tenant-1", "sink", "transport", 50)
+}
+
 func TestUpdateWindowBacklog(t *testing.T) {
 	ctx := context.Background()
 	UpdateWindowBacklog(ctx, "processor-1", 5)
@@ -58,3 +67,72 @@ func TestUpdateInFlightBatches(t *testing.T) {
 	UpdateInFlightBatches(ctx, 10)
 	UpdateInFlightBatches(ctx, -5)
 }
+
+func TestRecordCircuitState(t *testing.T) {
+	ctx := context.Background()
+	RecordCircuitState(ctx, "sink-http", 2)
+	RecordCircuitState(ctx, "sink-http", 0)
+}
+
+func TestUpdateDrainLeases(t *testing.T) {
+	ctx := context.Background()
+	UpdateDrainLeases(ctx, "sessions", 1)
+	UpdateDrainLeases(ctx, "sessions", -1)
+}
+
+func TestRecordCompression(t *testing.T) {
+	RecordCompression(context.Background(), "zstd", "compress", 4096, 512, time.Millisecond)
+}
+
+func TestRecordFlagEvaluation(t *testing.T) {
+	RecordFlagEvaluation(context.Background(), "engine.new_router", "provider")
+}
+
+func TestRecordGoroutineEvents(t *testing.T) {
+	RecordGoroutinePanic(context.Background(), "router")
+	RecordGoroutineRestart(context.Background(), "router")
+}
+
+func TestRecordDedupLookup(t *testing.T) {
+	RecordDedupLookup(context.Background(), "sink", "hit")
+}
+
+func TestRegisterPool(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	if _, err := InitMetricsWithReaders(context.Background(), MetricsConfig{ServiceName: "test"}, reader); err != nil {
+		t.Fatalf("InitMetricsWithReaders: %v", err)
+	}
+	RegisterPool("telemetry-test", func() PoolStats { return PoolStats{Gets: 7, InUse: 2, HighWater: 5} })
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	got := map[string]int64{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			var points []metricdata.DataPoint[int64]
+			switch data := m.Data.(type) {
+			case metricdata.Sum[int64]:
+				points = data.DataPoints
+			case metricdata.Gauge[int64]:
+				points = data.DataPoints
+			}
+			for _, p := range points {
+				if v, _ := p.Attributes.Value("pool"); v.AsString() == "telemetry-test" {
+					got[m.Name] = p.Value
+				}
+			}
+		}
+	}
+	if got["planx.pool.gets"] != 7 || got["planx.pool.in_use"] != 2 || got["planx.pool.in_use.high"] != 5 {
+		t.Fatalf("got %v", got)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("duplicate pool accepted")
+		}
+	}()
+	RegisterPool("telemetry-test", func() PoolStats { return PoolStats{} })
+}
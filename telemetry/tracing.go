@@ -65,7 +65,7 @@ func initTracingInternal(ctx context.Context, cfg TracingConfig) error {
 	provider := sdktrace.NewTracerProvider(
 		sdktrace.WithSampler(sdktrace.AlwaysSample()),
 		sdktrace.WithResource(res),
-		sdktrace.WithBatcher(exporter),
+		sdktrace.WithBatcher(trackedSpanExporter{exporter}),
 	)
 
 	otel.SetTracerProvider(provider)
@@ -0,0 +1,39 @@
+package testutil
+
+import (
+	"testing"
+	"time"
+)
+
+// Eventually polls cond every PollInterval until it returns true, and
+// fails the test if it has not within timeout. what describes the
+// condition for the failure message.
+func Eventually(t testing.TB, timeout time.Duration, cond func() bool, what string) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("testutil: %s: not true within %s", what, timeout)
+			return
+		}
+		time.Sleep(PollInterval)
+	}
+}
+
+// Retry calls fn every PollInterval until it returns nil, and fails the
+// test with its last error if it has not within timeout.
+func Retry(t testing.TB, timeout time.Duration, fn func() error) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for {
+		err := fn()
+		if err == nil {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("testutil: still failing after %s: %v", timeout, err)
+			return
+		}
+		time.Sleep(PollInterval)
+	}
+}
@@ -0,0 +1,51 @@
+package testutil
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeTB records Fatalf instead of stopping the test, to check failures.
+type fakeTB struct {
+	testing.TB
+	failed atomic.Bool
+	msg    string
+}
+
+func (f *fakeTB) Helper() {}
+
+func (f *fakeTB) Fatalf(format string, args ...interface{}) {
+	f.failed.Store(true)
+	f.msg = format
+}
+
+func (f *fakeTB) Errorf(format string, args ...interface{}) { f.Fatalf(format, args...) }
+
+func TestEventually(t *testing.T) {
+	var n atomic.Int32
+	Eventually(t, time.Second, func() bool { return n.Add(1) == 3 }, "third call")
+
+	fake := &fakeTB{TB: t}
+	Eventually(fake, 30*time.Millisecond, func() bool { return false }, "never")
+	if !fake.failed.Load() {
+		t.Fatal("false condition passed")
+	}
+}
+
+func TestRetry(t *testing.T) {
+	var n atomic.Int32
+	Retry(t, time.Second, func() error {
+		if n.Add(1) < 3 {
+			return errors.New("not yet")
+		}
+		return nil
+	})
+
+	fake := &fakeTB{TB: t}
+	Retry(fake, 30*time.Millisecond, func() error { return errors.New("down") })
+	if !fake.failed.Load() {
+		t.Fatal("failing function passed")
+	}
+}
@@ -0,0 +1,60 @@
+package testutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// WriteFile writes data to name, a path relative to a directory removed
+// when the test ends, creating parent directories, and returns its path.
+func WriteFile(t testing.TB, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("testutil: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatalf("testutil: %v", err)
+	}
+	return path
+}
+
+// WriteConfig writes v as a config file named name, as WriteFile does, and
+// returns its path. A string or []byte is written as is, for configs
+// written out by hand; anything else is encoded by the extension of name:
+// .yaml or .yml, .json or .toml.
+func WriteConfig(t testing.TB, name string, v interface{}) string {
+	t.Helper()
+	var data []byte
+	var err error
+	switch v := v.(type) {
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		switch ext := strings.ToLower(filepath.Ext(name)); ext {
+		case ".yaml", ".yml":
+			data, err = yaml.Marshal(v)
+		case ".json":
+			data, err = json.MarshalIndent(v, "", "  ")
+		case ".toml":
+			var buf bytes.Buffer
+			err = toml.NewEncoder(&buf).Encode(v)
+			data = buf.Bytes()
+		default:
+			t.Fatalf("testutil: cannot encode a config as %q", ext)
+		}
+	}
+	if err != nil {
+		t.Fatalf("testutil: encode %s: %v", name, err)
+	}
+	return WriteFile(t, name, data)
+}
@@ -0,0 +1,41 @@
+package testutil
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteFile(t *testing.T) {
+	path := WriteFile(t, "conf.d/engine.yaml", []byte("a: 1\n"))
+	if filepath.Base(filepath.Dir(path)) != "conf.d" {
+		t.Fatalf("path: %s", path)
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "a: 1\n" {
+		t.Fatalf("read: %q, %v", data, err)
+	}
+}
+
+func TestWriteConfig(t *testing.T) {
+	type engine struct {
+		Name    string `yaml:"name" json:"name" toml:"name"`
+		Workers int    `yaml:"workers" json:"workers" toml:"workers"`
+	}
+	cfg := engine{Name: "edge", Workers: 4}
+	tests := map[string]string{
+		"engine.yaml": "name: edge\nworkers: 4\n",
+		"engine.json": "{\n  \"name\": \"edge\",\n  \"workers\": 4\n}",
+		"engine.toml": "name = \"edge\"\nworkers = 4\n",
+	}
+	for name, want := range tests {
+		data, err := os.ReadFile(WriteConfig(t, name, cfg))
+		if err != nil || string(data) != want {
+			t.Fatalf("%s: got %q, %v", name, data, err)
+		}
+	}
+	data, _ := os.ReadFile(WriteConfig(t, "raw.yaml", "name: [unclosed"))
+	if !strings.HasPrefix(string(data), "name: [") {
+		t.Fatalf("raw config rewritten: %q", data)
+	}
+}
@@ -0,0 +1,61 @@
+package testutil
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/planx-lab/planx-common/env"
+)
+
+// UpdateGoldenEnv names the environment variable that makes Golden rewrite
+// the golden files instead of comparing against them:
+//
+//	PLANX_UPDATE_GOLDEN=1 go test ./...
+const UpdateGoldenEnv = "PLANX_UPDATE_GOLDEN"
+
+// Golden compares got with the file testdata/<name>.golden and fails the
+// test, showing the first differing line, if they differ.
+func Golden(t testing.TB, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if env.String(UpdateGoldenEnv, "") != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("testutil: %v", err)
+		}
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatalf("testutil: %v", err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("testutil: %v (run with %s=1 to create it)", err, UpdateGoldenEnv)
+		return
+	}
+	if bytes.Equal(got, want) {
+		return
+	}
+	line, w, g := firstDiff(want, got)
+	t.Errorf("testutil: output differs from %s at line %d:\n\twant: %q\n\tgot:  %q\n(run with %s=1 to update it)",
+		path, line, w, g, UpdateGoldenEnv)
+}
+
+// firstDiff returns the first line, counting from 1, where a and b differ,
+// with its text in each; a missing line is empty.
+func firstDiff(a, b []byte) (line int, lineA, lineB string) {
+	la, lb := bytes.Split(a, []byte("\n")), bytes.Split(b, []byte("\n"))
+	for i := 0; ; i++ {
+		var x, y []byte
+		if i < len(la) {
+			x = la[i]
+		}
+		if i < len(lb) {
+			y = lb[i]
+		}
+		if !bytes.Equal(x, y) || i >= len(la) || i >= len(lb) {
+			return i + 1, string(x), string(y)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package testutil
+
+import (
+	"os"
+	"testing"
+)
+
+func TestGolden(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv(UpdateGoldenEnv, "1")
+	Golden(t, "report", []byte("line 1\nline 2\n"))
+
+	t.Setenv(UpdateGoldenEnv, "")
+	Golden(t, "report", []byte("line 1\nline 2\n"))
+
+	fake := &fakeTB{TB: t}
+	Golden(fake, "report", []byte("line 1\nline two\n"))
+	if !fake.failed.Load() {
+		t.Fatal("difference not reported")
+	}
+	if _, err := os.Stat("testdata/report.golden"); err != nil {
+		t.Fatalf("golden file: %v", err)
+	}
+}
+
+func TestFirstDiff(t *testing.T) {
+	tests := []struct {
+		a, b       string
+		line       int
+		lineA, lnB string
+	}{
+		{"a\nb\n", "a\nc\n", 2, "b", "c"},
+		{"a\n", "a\nb\n", 2, "", "b"},
+		{"a\nb", "a", 2, "b", ""},
+	}
+	for _, tt := range tests {
+		line, a, b := firstDiff([]byte(tt.a), []byte(tt.b))
+		if line != tt.line || a != tt.lineA || b != tt.lnB {
+			t.Fatalf("%q vs %q: line %d %q %q", tt.a, tt.b, line, a, b)
+		}
+	}
+}
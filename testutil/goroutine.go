@@ -0,0 +1,99 @@
+package testutil
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// GoroutineGracePeriod is how long NoGoroutineLeaks waits for goroutines
+// to exit after the test ends.
+const GoroutineGracePeriod = 2 * time.Second
+
+// ignoredGoroutines are stack substrings of goroutines that outlive tests
+// by design.
+var ignoredGoroutines = []string{
+	"testing.(*T).Run",
+	"testing.tRunner",
+	"testing.runTests",
+	"os/signal.signal_recv",
+	"runtime.ensureSigM",
+	"go.opentelemetry.io/otel/sdk/trace.(*batchSpanProcessor).processQueue",
+	"go.opentelemetry.io/otel/sdk/metric.(*PeriodicReader).run",
+	"net/http.(*persistConn)", // idle keep-alive connections of http.DefaultTransport
+}
+
+// NoGoroutineLeaks fails the test if goroutines started during it are
+// still running GoroutineGracePeriod after it ends. Call it first, so that
+// its check runs after the test's other cleanups have stopped servers and
+// workers. Tests running in parallel may be blamed for each other's
+// goroutines, so use it in tests that do not call t.Parallel.
+func NoGoroutineLeaks(t testing.TB) {
+	t.Helper()
+	before := map[string]bool{}
+	for id := range goroutines() {
+		before[id] = true
+	}
+	t.Cleanup(func() {
+		deadline := time.Now().Add(GoroutineGracePeriod)
+		for {
+			leaked := newGoroutines(before)
+			if len(leaked) == 0 {
+				return
+			}
+			if time.Now().After(deadline) {
+				for _, stack := range leaked {
+					t.Errorf("testutil: goroutine leaked:\n%s", stack)
+				}
+				return
+			}
+			time.Sleep(PollInterval)
+		}
+	})
+}
+
+// newGoroutines returns the stacks of goroutines not in before and not
+// ignored.
+func newGoroutines(before map[string]bool) []string {
+	var out []string
+	for id, stack := range goroutines() {
+		if before[id] || ignored(stack) {
+			continue
+		}
+		out = append(out, stack)
+	}
+	return out
+}
+
+func ignored(stack string) bool {
+	for _, s := range ignoredGoroutines {
+		if strings.Contains(stack, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// goroutines returns the stacks of all goroutines by ID, except the
+// calling one.
+func goroutines() map[string]string {
+	buf := make([]byte, 1<<16)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+	out := map[string]string{}
+	for i, stack := range strings.Split(string(buf), "\n\n") {
+		if i == 0 {
+			continue // the calling goroutine comes first
+		}
+		header, _, _ := strings.Cut(stack, " [")
+		out[strings.TrimPrefix(header, "goroutine ")] = stack
+	}
+	return out
+}
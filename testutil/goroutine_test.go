@@ -0,0 +1,27 @@
+package testutil
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNoGoroutineLeaks(t *testing.T) {
+	t.Run("clean", func(t *testing.T) {
+		NoGoroutineLeaks(t)
+		done := make(chan struct{})
+		go func() { <-done }()
+		t.Cleanup(func() { close(done) })
+	})
+
+	stop := make(chan struct{})
+	defer close(stop)
+	before := map[string]bool{}
+	for id := range goroutines() {
+		before[id] = true
+	}
+	go func() { <-stop }()
+	leaked := newGoroutines(before)
+	if len(leaked) != 1 || !strings.Contains(leaked[0], "TestNoGoroutineLeaks") {
+		t.Fatalf("leaked: %v", leaked)
+	}
+}
@@ -0,0 +1,26 @@
+package testutil
+
+import (
+	"net"
+	"strconv"
+	"testing"
+)
+
+// FreePort returns a TCP port on 127.0.0.1 that was free when it was
+// picked. Another process may take it before the test binds it, so prefer
+// listening on port 0 when the code under test allows it.
+func FreePort(t testing.TB) int {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("testutil: free port: %v", err)
+	}
+	defer lis.Close()
+	return lis.Addr().(*net.TCPAddr).Port
+}
+
+// FreeAddr returns "127.0.0.1:port" for a FreePort.
+func FreeAddr(t testing.TB) string {
+	t.Helper()
+	return net.JoinHostPort("127.0.0.1", strconv.Itoa(FreePort(t)))
+}
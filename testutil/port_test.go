@@ -0,0 +1,18 @@
+package testutil
+
+import (
+	"net"
+	"testing"
+)
+
+func TestFreeAddr(t *testing.T) {
+	addr := FreeAddr(t)
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Fatalf("listen on %s: %v", addr, err)
+	}
+	lis.Close()
+	if FreePort(t) <= 0 {
+		t.Fatal("no port")
+	}
+}
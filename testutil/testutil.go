@@ -0,0 +1,49 @@
+// Package testutil provides the scaffolding shared by test suites: temp
+// config files, free ports, test-scoped contexts, goroutine leak checks,
+// polling assertions and golden files.
+// Engine-side utilities only — must not be imported by SDK or plugins.
+//
+// Helpers take a testing.TB and fail the test themselves, so a test reads
+//
+//	ctx := testutil.Context(t)
+//	path := testutil.WriteConfig(t, "engine.yaml", cfg)
+//	testutil.Eventually(t, time.Second, func() bool { return srv.Ready() }, "server ready")
+package testutil
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// DefaultTimeout bounds the context of Context when the test itself has
+// no earlier deadline.
+const DefaultTimeout = 30 * time.Second
+
+// PollInterval is how often Eventually and Retry try again.
+const PollInterval = 10 * time.Millisecond
+
+// Context returns a context that is cancelled when the test ends or, at
+// the latest, after DefaultTimeout or just before the test's own deadline
+// (go test -timeout), so a hung test fails with its context error rather
+// than a panic dump.
+func Context(t testing.TB) context.Context {
+	t.Helper()
+	timeout := DefaultTimeout
+	if dt, ok := t.(interface{ Deadline() (time.Time, bool) }); ok {
+		if deadline, ok := dt.Deadline(); ok {
+			// Leave time for cleanups and reporting.
+			timeout = min(timeout, time.Until(deadline)*9/10)
+		}
+	}
+	return ContextWithTimeout(t, timeout)
+}
+
+// ContextWithTimeout returns a context that is cancelled after timeout or
+// when the test ends.
+func ContextWithTimeout(t testing.TB, timeout time.Duration) context.Context {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	t.Cleanup(cancel)
+	return ctx
+}
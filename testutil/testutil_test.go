@@ -0,0 +1,24 @@
+package testutil
+
+import (
+	"testing"
+	"time"
+)
+
+func TestContext(t *testing.T) {
+	ctx := Context(t)
+	deadline, ok := ctx.Deadline()
+	if !ok || time.Until(deadline) > DefaultTimeout {
+		t.Fatalf("deadline: %v, %v", deadline, ok)
+	}
+
+	var cancelled <-chan struct{}
+	t.Run("scoped", func(t *testing.T) {
+		cancelled = ContextWithTimeout(t, time.Hour).Done()
+	})
+	select {
+	case <-cancelled:
+	default:
+		t.Fatal("context not cancelled when the test ended")
+	}
+}
@@ -0,0 +1,123 @@
+package tlsutil
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/planx-lab/planx-common/config"
+	"github.com/planx-lab/planx-common/errors"
+	"github.com/planx-lab/planx-common/logger"
+)
+
+// store holds the certificate and CAs loaded from a Config, swapped as a
+// whole when the files change.
+type store struct {
+	certFile, keyFile, caFile string
+	spiffeIDs                 []string
+
+	mu    sync.RWMutex
+	cert  *tls.Certificate
+	roots *x509.CertPool // nil for the system roots
+}
+
+func newStore(cfg Config) (*store, error) {
+	s := &store{certFile: cfg.CertFile, keyFile: cfg.KeyFile, caFile: cfg.CAFile, spiffeIDs: cfg.SPIFFEIDs}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// load reads the files and, if they are all valid, replaces the current
+// certificate and CAs. On error the current ones are kept.
+func (s *store) load() error {
+	var cert *tls.Certificate
+	if s.certFile != "" {
+		c, err := tls.LoadX509KeyPair(s.certFile, s.keyFile)
+		if err != nil {
+			return errors.WrapConfigError(err, "tlsutil: load certificate "+s.certFile)
+		}
+		cert = &c
+	}
+	var roots *x509.CertPool
+	if s.caFile != "" {
+		pem, err := os.ReadFile(s.caFile)
+		if err != nil {
+			return errors.WrapConfigError(err, "tlsutil: read CAs")
+		}
+		roots = x509.NewCertPool()
+		if !roots.AppendCertsFromPEM(pem) {
+			return errors.NewConfigErrorf("tlsutil: no certificates in %s", s.caFile)
+		}
+	}
+	s.mu.Lock()
+	s.cert, s.roots = cert, roots
+	s.mu.Unlock()
+	return nil
+}
+
+// watch reloads the files whenever they change until stop is called.
+func (s *store) watch(interval time.Duration) (stop func()) {
+	var paths []string
+	for _, p := range []string{s.certFile, s.keyFile, s.caFile} {
+		if p != "" {
+			paths = append(paths, p)
+		}
+	}
+	if len(paths) == 0 {
+		return func() {}
+	}
+	return config.Watch(interval, s.reload, paths...)
+}
+
+// reload is the watch callback. A certificate and key are often written
+// one after the other, so a failure here is usually a half-finished
+// rotation that the next change completes.
+func (s *store) reload() {
+	if err := s.load(); err != nil {
+		logger.Warn().Err(err).Msg("tlsutil: reload failed, keeping current certificates")
+		return
+	}
+	ev := logger.Info().Str("cert_file", s.certFile).Str("ca_file", s.caFile)
+	if cert := s.certificate(); cert != nil && cert.Leaf != nil {
+		ev = ev.Time("not_after", cert.Leaf.NotAfter)
+	}
+	ev.Msg("tlsutil: certificates reloaded")
+}
+
+func (s *store) certificate() *tls.Certificate {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cert
+}
+
+// verify checks a peer's chain against the current CAs, for usage and, if
+// not empty, dnsName, then its SPIFFE ID if any are configured.
+func (s *store) verify(chain []*x509.Certificate, usage x509.ExtKeyUsage, dnsName string) error {
+	if len(chain) == 0 {
+		return errors.NewTransportError("tlsutil: peer sent no certificate", false)
+	}
+	s.mu.RLock()
+	roots := s.roots
+	s.mu.RUnlock()
+
+	opts := x509.VerifyOptions{
+		Roots:         roots,
+		DNSName:       dnsName,
+		Intermediates: x509.NewCertPool(),
+		KeyUsages:     []x509.ExtKeyUsage{usage},
+	}
+	for _, c := range chain[1:] {
+		opts.Intermediates.AddCert(c)
+	}
+	if _, err := chain[0].Verify(opts); err != nil {
+		return errors.WrapTransportError(err, "tlsutil: peer certificate rejected", false)
+	}
+	if len(s.spiffeIDs) > 0 {
+		return MatchSPIFFEID(chain[0], s.spiffeIDs)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package tlsutil
+
+import (
+	"crypto/x509"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/planx-lab/planx-common/logger/logtest"
+	"github.com/rs/zerolog"
+)
+
+func TestReload_RotatedCertificate(t *testing.T) {
+	rec := logtest.Capture(t)
+	ca, server, client := pki(t, "")
+	client.ServerName = "localhost"
+	serverTLS, clientTLS := load(t, server, client)
+	if serial, err := handshake(t, serverTLS, clientTLS); err != nil || serial != 2 {
+		t.Fatalf("before rotation: serial %d, %v", serial, err)
+	}
+
+	certPEM, keyPEM := ca.issue(t, 10, x509.ExtKeyUsageServerAuth, "")
+	writeFiles(t, filepath.Dir(server.CertFile), certPEM, keyPEM, ca.pem)
+	for i := 0; ; i++ {
+		serial, err := handshake(t, serverTLS, clientTLS)
+		if err != nil {
+			t.Fatalf("during rotation: %v", err)
+		}
+		if serial == 10 {
+			break
+		}
+		if i == 100 {
+			t.Fatal("rotated certificate not served")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	rec.AssertLogged(zerolog.InfoLevel, "tlsutil: certificates reloaded")
+}
+
+func TestReload_KeepsCurrentOnError(t *testing.T) {
+	rec := logtest.Capture(t)
+	_, server, client := pki(t, "")
+	client.ServerName = "localhost"
+	serverTLS, clientTLS := load(t, server, client)
+
+	if err := os.WriteFile(server.CertFile, []byte("not a certificate"), 0600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	for i := 0; len(rec.Find(zerolog.WarnLevel, "tlsutil: reload failed, keeping current certificates")) == 0; i++ {
+		if i == 100 {
+			t.Fatal("failed reload not logged")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if serial, err := handshake(t, serverTLS, clientTLS); err != nil || serial != 2 {
+		t.Fatalf("after failed reload: serial %d, %v", serial, err)
+	}
+}
@@ -0,0 +1,39 @@
+package tlsutil
+
+import (
+	"crypto/x509"
+	"strings"
+
+	"github.com/planx-lab/planx-common/errors"
+)
+
+// SPIFFEID returns the SPIFFE ID of cert, its URI SAN. As the SPIFFE X.509
+// profile requires, the certificate must have exactly one URI SAN, with
+// the spiffe scheme and a trust domain.
+func SPIFFEID(cert *x509.Certificate) (string, error) {
+	if len(cert.URIs) != 1 {
+		return "", errors.NewTransportErrorf(false, "tlsutil: certificate has %d URI SANs, want 1 SPIFFE ID", len(cert.URIs))
+	}
+	u := cert.URIs[0]
+	if u.Scheme != "spiffe" || u.Host == "" || u.User != nil || u.RawQuery != "" || u.Fragment != "" {
+		return "", errors.NewTransportErrorf(false, "tlsutil: %q is not a SPIFFE ID", u.String())
+	}
+	return u.String(), nil
+}
+
+// MatchSPIFFEID checks that the SPIFFE ID of cert is one of allowed. An
+// allowed ID without a path, such as "spiffe://planx.internal", accepts
+// every ID in that trust domain.
+func MatchSPIFFEID(cert *x509.Certificate, allowed []string) error {
+	got, err := SPIFFEID(cert)
+	if err != nil {
+		return err
+	}
+	for _, want := range allowed {
+		want = strings.TrimSuffix(want, "/")
+		if got == want || (!strings.Contains(strings.TrimPrefix(want, "spiffe://"), "/") && strings.HasPrefix(got, want+"/")) {
+			return nil
+		}
+	}
+	return errors.NewTransportErrorf(false, "tlsutil: SPIFFE ID %s is not allowed", got).WithField("spiffe_id", got)
+}
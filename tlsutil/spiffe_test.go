@@ -0,0 +1,55 @@
+package tlsutil
+
+import (
+	"crypto/x509"
+	"net/url"
+	"testing"
+)
+
+func certWithURIs(uris ...string) *x509.Certificate {
+	cert := &x509.Certificate{}
+	for _, s := range uris {
+		u, _ := url.Parse(s)
+		cert.URIs = append(cert.URIs, u)
+	}
+	return cert
+}
+
+func TestSPIFFEID(t *testing.T) {
+	if id, err := SPIFFEID(certWithURIs("spiffe://planx.internal/engine")); err != nil || id != "spiffe://planx.internal/engine" {
+		t.Fatalf("got %q, %v", id, err)
+	}
+	for _, cert := range []*x509.Certificate{
+		certWithURIs(),
+		certWithURIs("spiffe://planx.internal/a", "spiffe://planx.internal/b"),
+		certWithURIs("https://planx.internal/engine"),
+		certWithURIs("spiffe:///engine"),
+		certWithURIs("spiffe://planx.internal/engine?x=1"),
+	} {
+		if id, err := SPIFFEID(cert); err == nil {
+			t.Fatalf("%v: accepted as %q", cert.URIs, id)
+		}
+	}
+}
+
+func TestMatchSPIFFEID(t *testing.T) {
+	tests := []struct {
+		id      string
+		allowed []string
+		want    bool
+	}{
+		{"spiffe://planx.internal/plugin/kafka", []string{"spiffe://planx.internal/plugin/kafka"}, true},
+		{"spiffe://planx.internal/plugin/kafka", []string{"spiffe://planx.internal/engine", "spiffe://planx.internal/plugin/kafka"}, true},
+		{"spiffe://planx.internal/plugin/kafka", []string{"spiffe://planx.internal"}, true},
+		{"spiffe://planx.internal/plugin/kafka", []string{"spiffe://planx.internal/"}, true},
+		{"spiffe://planx.internal/plugin/kafka", []string{"spiffe://planx.internal/plugin"}, false},
+		{"spiffe://planx.internal.evil/engine", []string{"spiffe://planx.internal"}, false},
+		{"spiffe://other.domain/engine", []string{"spiffe://planx.internal"}, false},
+	}
+	for _, tt := range tests {
+		err := MatchSPIFFEID(certWithURIs(tt.id), tt.allowed)
+		if (err == nil) != tt.want {
+			t.Fatalf("%s against %v: got %v", tt.id, tt.allowed, err)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+package tlsutil
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	stderrors "errors"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/planx-lab/planx-common/config"
+	"github.com/planx-lab/planx-common/errors"
+)
+
+// testCA issues certificates for the tests.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newCA(t *testing.T) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CA: %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns a PEM certificate and key for usage, with the DNS name
+// "localhost", ips as IP SANs and, if spiffeID is set, that URI SAN.
+func (ca *testCA) issue(t *testing.T, serial int64, usage x509.ExtKeyUsage, spiffeID string, ips ...net.IP) (certPEM, keyPEM []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  ips,
+	}
+	if spiffeID != "" {
+		u, _ := url.Parse(spiffeID)
+		tmpl.URIs = []*url.URL{u}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatalf("certificate: %v", err)
+	}
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+// writeFiles writes a certificate, key and CA into dir and returns the
+// Config naming them.
+func writeFiles(t *testing.T, dir string, certPEM, keyPEM, caPEM []byte) Config {
+	t.Helper()
+	cfg := Config{
+		CertFile:       filepath.Join(dir, "tls.crt"),
+		KeyFile:        filepath.Join(dir, "tls.key"),
+		CAFile:         filepath.Join(dir, "ca.crt"),
+		ReloadInterval: config.Duration(10 * time.Millisecond),
+	}
+	for path, data := range map[string][]byte{cfg.CertFile: certPEM, cfg.KeyFile: keyPEM, cfg.CAFile: caPEM} {
+		if err := os.WriteFile(path, data, 0600); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	return cfg
+}
+
+// pki writes a server and a client identity issued by one CA.
+func pki(t *testing.T, clientID string) (ca *testCA, server, client Config) {
+	t.Helper()
+	ca = newCA(t)
+	certPEM, keyPEM := ca.issue(t, 2, x509.ExtKeyUsageServerAuth, "spiffe://planx.internal/engine")
+	server = writeFiles(t, t.TempDir(), certPEM, keyPEM, ca.pem)
+	certPEM, keyPEM = ca.issue(t, 3, x509.ExtKeyUsageClientAuth, clientID)
+	client = writeFiles(t, t.TempDir(), certPEM, keyPEM, ca.pem)
+	return ca, server, client
+}
+
+// handshake connects a client with clientTLS to a server with serverTLS
+// over loopback TCP. It returns the serial of the server certificate the
+// client saw and the first handshake error of either side.
+func handshake(t *testing.T, serverTLS, clientTLS *tls.Config) (int64, error) {
+	t.Helper()
+	lis, err := tls.Listen("tcp", "127.0.0.1:0", serverTLS)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer lis.Close()
+	serverErr := make(chan error, 1)
+	go func() {
+		conn, err := lis.Accept()
+		if err != nil {
+			serverErr <- err
+			return
+		}
+		defer conn.Close()
+		serverErr <- conn.(*tls.Conn).Handshake()
+	}()
+
+	conn, err := tls.Dial("tcp", lis.Addr().String(), clientTLS)
+	if err != nil {
+		<-serverErr
+		return 0, err
+	}
+	defer conn.Close()
+	// Under TLS 1.3 the server verifies the client after the client has
+	// finished, so wait for its verdict.
+	if err := <-serverErr; err != nil {
+		return 0, err
+	}
+	return conn.ConnectionState().PeerCertificates[0].SerialNumber.Int64(), nil
+}
+
+func load(t *testing.T, server, client Config) (serverTLS, clientTLS *tls.Config) {
+	t.Helper()
+	serverTLS, stop, err := LoadServerConfig(server)
+	if err != nil {
+		t.Fatalf("LoadServerConfig: %v", err)
+	}
+	t.Cleanup(stop)
+	clientTLS, stop, err = LoadClientConfig(client)
+	if err != nil {
+		t.Fatalf("LoadClientConfig: %v", err)
+	}
+	t.Cleanup(stop)
+	return serverTLS, clientTLS
+}
+
+func TestServerTLS(t *testing.T) {
+	_, server, client := pki(t, "")
+	client.CertFile, client.KeyFile = "", ""
+	client.ServerName = "localhost"
+	serverTLS, clientTLS := load(t, server, client)
+	if _, err := handshake(t, serverTLS, clientTLS); err != nil {
+		t.Fatalf("handshake: %v", err)
+	}
+
+	client.ServerName = "other.example"
+	_, clientTLS = load(t, server, client)
+	if _, err := handshake(t, serverTLS, clientTLS); err == nil {
+		t.Fatal("wrong server name accepted")
+	}
+}
+
+func TestServerTLS_IP(t *testing.T) {
+	ca, server, client := pki(t, "")
+	client.CertFile, client.KeyFile = "", ""
+	serverTLS, clientTLS := load(t, server, client)
+	// The test dials 127.0.0.1; the certificate names only localhost.
+	if _, err := handshake(t, serverTLS, clientTLS); err == nil {
+		t.Fatal("certificate without IP SANs accepted for an IP")
+	}
+
+	client.ServerName = "127.0.0.1"
+	_, clientTLS = load(t, server, client)
+	if _, err := handshake(t, serverTLS, clientTLS); err == nil {
+		t.Fatal("certificate without IP SANs accepted for server_name 127.0.0.1")
+	}
+
+	certPEM, keyPEM := ca.issue(t, 4, x509.ExtKeyUsageServerAuth, "", net.IPv4(127, 0, 0, 1))
+	server = writeFiles(t, t.TempDir(), certPEM, keyPEM, ca.pem)
+	serverTLS, clientTLS = load(t, server, client)
+	if _, err := handshake(t, serverTLS, clientTLS); err != nil {
+		t.Fatalf("certificate with the IP SAN: %v", err)
+	}
+}
+
+func TestMutualTLS(t *testing.T) {
+	_, server, client := pki(t, "spiffe://planx.internal/plugin/kafka")
+	server.ClientAuth = true
+	server.SPIFFEIDs = []string{"spiffe://planx.internal/plugin/kafka"}
+	client.SPIFFEIDs = []string{"spiffe://planx.internal/engine"}
+	serverTLS, clientTLS := load(t, server, client)
+	if _, err := handshake(t, serverTLS, clientTLS); err != nil {
+		t.Fatalf("handshake: %v", err)
+	}
+
+	// SPIFFE IDs replace the host name check on the client.
+	clientTLS.ServerName = "not-in-the-certificate"
+	if _, err := handshake(t, serverTLS, clientTLS); err != nil {
+		t.Fatalf("handshake by SPIFFE ID: %v", err)
+	}
+
+	noCert := client
+	noCert.CertFile, noCert.KeyFile = "", ""
+	_, clientTLS = load(t, server, noCert)
+	if _, err := handshake(t, serverTLS, clientTLS); err == nil {
+		t.Fatal("client without a certificate accepted")
+	}
+
+	_, _, other := pki(t, "spiffe://planx.internal/plugin/kafka")
+	other.CAFile = client.CAFile
+	other.SPIFFEIDs = client.SPIFFEIDs
+	_, clientTLS = load(t, server, other)
+	if _, err := handshake(t, serverTLS, clientTLS); err == nil {
+		t.Fatal("client certificate from another CA accepted")
+	}
+}
+
+func TestMutualTLS_SPIFFEIDRejected(t *testing.T) {
+	_, server, client := pki(t, "spiffe://other.domain/plugin")
+	server.ClientAuth = true
+	server.SPIFFEIDs = []string{"spiffe://planx.internal"}
+	client.ServerName = "localhost"
+	serverTLS, clientTLS := load(t, server, client)
+	if _, err := handshake(t, serverTLS, clientTLS); err == nil {
+		t.Fatal("SPIFFE ID outside the trust domain accepted")
+	}
+}
+
+func TestLoadServerConfig_Errors(t *testing.T) {
+	var cfgErr *errors.ConfigError
+	if _, _, err := LoadServerConfig(Config{}); !stderrors.As(err, &cfgErr) {
+		t.Fatalf("no certificate: got %v", err)
+	}
+	_, server, _ := pki(t, "")
+	server.SPIFFEIDs = []string{"spiffe://planx.internal"}
+	if _, _, err := LoadServerConfig(server); !stderrors.As(err, &cfgErr) {
+		t.Fatalf("SPIFFE IDs without client auth: got %v", err)
+	}
+	server.SPIFFEIDs = nil
+	server.KeyFile = server.CAFile
+	if _, _, err := LoadServerConfig(server); !stderrors.As(err, &cfgErr) {
+		t.Fatalf("mismatched key: got %v", err)
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	if err := config.Validate(Config{CertFile: "tls.crt"}); err == nil {
+		t.Fatal("certificate without a key accepted")
+	}
+	if err := config.Validate(Config{CertFile: "tls.crt", KeyFile: "tls.key", ClientAuth: true}); err == nil {
+		t.Fatal("client auth without CAs accepted")
+	}
+	if err := config.Validate(Config{CertFile: "tls.crt", KeyFile: "tls.key"}); err != nil {
+		t.Fatalf("valid config: %v", err)
+	}
+}
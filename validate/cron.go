@@ -0,0 +1,122 @@
+package validate
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronField describes one field of a cron expression.
+type cronField struct {
+	name     string
+	min, max int
+	names    []string // names of min, min+1, ..., matched case-insensitively
+}
+
+var cronFields = []cronField{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}},
+	{name: "day of week", min: 0, max: 7, names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}},
+}
+
+var cronDescriptors = map[string]bool{
+	"@yearly": true, "@annually": true, "@monthly": true, "@weekly": true,
+	"@daily": true, "@midnight": true, "@hourly": true,
+}
+
+// Cron checks that value is a standard five-field cron expression
+// (minute, hour, day of month, month, day of week), with lists, ranges,
+// steps and month and day names:
+//
+//	*/5 * * * *
+//	0 2 * * MON-FRI
+//	0 0 1,15 * ?
+//
+// The descriptors @yearly, @annually, @monthly, @weekly, @daily, @midnight
+// and @hourly are accepted, as is "@every <duration>" with a positive
+// duration.
+func Cron(field, value string) error {
+	if msg := cronProblem(strings.TrimSpace(value)); msg != "" {
+		return fail(field, "must be a cron expression: %s, got %q", msg, value)
+	}
+	return nil
+}
+
+func cronProblem(s string) string {
+	if strings.HasPrefix(s, "@") {
+		if every, ok := strings.CutPrefix(s, "@every "); ok {
+			if d, err := time.ParseDuration(strings.TrimSpace(every)); err != nil || d <= 0 {
+				return "@every needs a positive duration"
+			}
+			return ""
+		}
+		if !cronDescriptors[strings.ToLower(s)] {
+			return "unknown descriptor " + s
+		}
+		return ""
+	}
+	fields := strings.Fields(s)
+	if len(fields) != len(cronFields) {
+		return fmt.Sprintf("want %d fields, got %d", len(cronFields), len(fields))
+	}
+	for i, f := range fields {
+		if msg := cronFields[i].problem(f); msg != "" {
+			return cronFields[i].name + " " + msg
+		}
+	}
+	return ""
+}
+
+// problem checks one field: a comma-separated list of "*", "?" (days
+// only), "a", "a-b", each optionally followed by "/step".
+func (f cronField) problem(s string) string {
+	for _, item := range strings.Split(s, ",") {
+		rng, step, hasStep := strings.Cut(item, "/")
+		if hasStep {
+			if n, err := strconv.Atoi(step); err != nil || n < 1 || n > f.max {
+				return fmt.Sprintf("has invalid step %q", step)
+			}
+		}
+		switch {
+		case rng == "*":
+			continue
+		case rng == "?" && (f.name == "day of month" || f.name == "day of week"):
+			if hasStep {
+				return "cannot step ?"
+			}
+			continue
+		}
+		lo, hi, isRange := strings.Cut(rng, "-")
+		a, ok := f.value(lo)
+		if !ok {
+			return fmt.Sprintf("has invalid value %q", lo)
+		}
+		if isRange {
+			b, ok := f.value(hi)
+			if !ok {
+				return fmt.Sprintf("has invalid value %q", hi)
+			}
+			if b < a {
+				return fmt.Sprintf("has backwards range %q", rng)
+			}
+		}
+	}
+	return ""
+}
+
+// value parses a number or name within the field's bounds.
+func (f cronField) value(s string) (int, bool) {
+	for i, name := range f.names {
+		if strings.EqualFold(s, name) {
+			return f.min + i, true
+		}
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < f.min || n > f.max {
+		return 0, false
+	}
+	return n, true
+}
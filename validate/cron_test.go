@@ -0,0 +1,51 @@
+package validate
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCron(t *testing.T) {
+	valid := []string{
+		"* * * * *",
+		"*/5 * * * *",
+		"0 2 * * MON-FRI",
+		"0 0 1,15 * ?",
+		"30 4 1-10/2 jan,jul 0",
+		"0 0 * * 7",
+		"5/15 * * * *",
+		"@daily",
+		"@HOURLY",
+		"@every 90s",
+	}
+	for _, s := range valid {
+		if err := Cron("schedule", s); err != nil {
+			t.Fatalf("%q: %v", s, err)
+		}
+	}
+
+	invalid := map[string]string{
+		"":                 "want 5 fields",
+		"* * * *":          "want 5 fields",
+		"* * * * * *":      "want 5 fields",
+		"60 * * * *":       "minute",
+		"* 24 * * *":       "hour",
+		"* * 0 * *":        "day of month",
+		"* * * 13 *":       "month",
+		"* * * * 8":        "day of week",
+		"*/0 * * * *":      "step",
+		"10-5 * * * *":     "backwards",
+		"? * * * *":        "minute",
+		"* * * FOO *":      "month",
+		"* * ?/2 * *":      "cannot step",
+		"@fortnightly":     "unknown descriptor",
+		"@every -1s":       "positive duration",
+		"@every sometimes": "positive duration",
+	}
+	for s, want := range invalid {
+		err := Cron("schedule", s)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("%q: got %v, want %q", s, err, want)
+		}
+	}
+}
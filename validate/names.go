@@ -0,0 +1,43 @@
+package validate
+
+// MaxNameLen is the longest identifier or slug accepted.
+const MaxNameLen = 63
+
+// Identifier checks that value is an identifier: 1 to MaxNameLen letters,
+// digits and underscores, not starting with a digit. Field, column and
+// topic names that end up in generated code or queries must be
+// identifiers.
+func Identifier(field, value string) error {
+	if value == "" || len(value) > MaxNameLen {
+		return fail(field, "must be an identifier of 1 to %d characters, got %q", MaxNameLen, value)
+	}
+	if value[0] >= '0' && value[0] <= '9' {
+		return fail(field, "must be an identifier not starting with a digit, got %q", value)
+	}
+	for i := 0; i < len(value); i++ {
+		if !isAlnum(value[i]) && value[i] != '_' {
+			return fail(field, "must be an identifier of letters, digits and '_', got %q", value)
+		}
+	}
+	return nil
+}
+
+// Slug checks that value is a slug: 1 to MaxNameLen lowercase letters,
+// digits and hyphens, starting and ending with a letter or digit. Slugs
+// are valid DNS labels and Kubernetes names, as tenant and pipeline names
+// must be.
+func Slug(field, value string) error {
+	if value == "" || len(value) > MaxNameLen {
+		return fail(field, "must be a slug of 1 to %d characters, got %q", MaxNameLen, value)
+	}
+	if value[0] == '-' || value[len(value)-1] == '-' {
+		return fail(field, "must be a slug not starting or ending with '-', got %q", value)
+	}
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-') {
+			return fail(field, "must be a slug of lowercase letters, digits and '-', got %q", value)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package validate
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIdentifier(t *testing.T) {
+	for _, s := range []string{"orders", "_private", "Order_Items2", strings.Repeat("x", MaxNameLen)} {
+		if err := Identifier("column", s); err != nil {
+			t.Fatalf("%q: %v", s, err)
+		}
+	}
+	for _, s := range []string{"", "2fast", "with-hyphen", "dot.ted", "spa ce", "ünicode", strings.Repeat("x", MaxNameLen+1)} {
+		if err := Identifier("column", s); err == nil {
+			t.Fatalf("%q accepted", s)
+		}
+	}
+}
+
+func TestSlug(t *testing.T) {
+	for _, s := range []string{"orders", "orders-eu-1", "0day", strings.Repeat("x", MaxNameLen)} {
+		if err := Slug("tenant", s); err != nil {
+			t.Fatalf("%q: %v", s, err)
+		}
+	}
+	for _, s := range []string{"", "Orders", "-orders", "orders-", "under_score", "dot.ted", strings.Repeat("x", MaxNameLen+1)} {
+		if err := Slug("tenant", s); err == nil {
+			t.Fatalf("%q accepted", s)
+		}
+	}
+}
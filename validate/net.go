@@ -0,0 +1,104 @@
+package validate
+
+import (
+	"net"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Hostname checks that value is a host name as RFC 1123 defines it: at
+// most 253 characters of dot-separated labels, each 1 to 63 letters,
+// digits and hyphens, not starting or ending with a hyphen. A trailing dot
+// is allowed.
+func Hostname(field, value string) error {
+	if msg := hostnameProblem(value); msg != "" {
+		return fail(field, "%s, got %q", msg, value)
+	}
+	return nil
+}
+
+func hostnameProblem(s string) string {
+	s = strings.TrimSuffix(s, ".")
+	if s == "" {
+		return "must be a host name"
+	}
+	if len(s) > 253 {
+		return "must be a host name of at most 253 characters"
+	}
+	for _, label := range strings.Split(s, ".") {
+		if label == "" || len(label) > 63 {
+			return "must be a host name with labels of 1 to 63 characters"
+		}
+		if label[0] == '-' || label[len(label)-1] == '-' {
+			return "must be a host name with labels not starting or ending with '-'"
+		}
+		for i := 0; i < len(label); i++ {
+			c := label[i]
+			if !isAlnum(c) && c != '-' {
+				return "must be a host name of letters, digits, '-' and '.'"
+			}
+		}
+	}
+	return ""
+}
+
+// HostPort checks that value is "host:port", where host is a host name or
+// an IP address (IPv6 in brackets) and port is 1 to 65535. The host may be
+// empty, as in ":8080", for listen addresses.
+func HostPort(field, value string) error {
+	host, port, err := net.SplitHostPort(value)
+	if err != nil {
+		return fail(field, "must be host:port, got %q", value)
+	}
+	if host != "" {
+		if _, err := netip.ParseAddr(host); err != nil && hostnameProblem(host) != "" {
+			return fail(field, "must be host:port with a valid host, got %q", value)
+		}
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+		return fail(field, "must be host:port with a port from 1 to 65535, got %q", value)
+	}
+	return nil
+}
+
+// Port checks that port is from 1 to 65535.
+func Port(field string, port int) error {
+	if port < 1 || port > 65535 {
+		return fail(field, "must be a port from 1 to 65535, got %d", port)
+	}
+	return nil
+}
+
+// URL checks that value is an absolute URL with a host, and if schemes
+// are given, that its scheme is one of them.
+func URL(field, value string, schemes ...string) error {
+	u, err := url.Parse(value)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return fail(field, "must be an absolute URL, got %q", value)
+	}
+	if len(schemes) > 0 && !slices.Contains(schemes, strings.ToLower(u.Scheme)) {
+		return fail(field, "must be a URL with scheme %s, got %q", strings.Join(schemes, " or "), value)
+	}
+	return nil
+}
+
+// CIDR checks that value is an IPv4 or IPv6 prefix such as "10.0.0.0/8".
+// A prefix with host bits set, such as "10.0.0.1/8", is rejected as it is
+// most likely a typo.
+func CIDR(field, value string) error {
+	p, err := netip.ParsePrefix(value)
+	if err != nil {
+		return fail(field, "must be a CIDR prefix such as 10.0.0.0/8, got %q", value)
+	}
+	if masked := p.Masked(); masked != p {
+		return fail(field, "must not have host bits set, got %q (did you mean %s?)", value, masked)
+	}
+	return nil
+}
+
+func isAlnum(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
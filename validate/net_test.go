@@ -0,0 +1,75 @@
+package validate
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHostname(t *testing.T) {
+	for _, s := range []string{"localhost", "kafka-0.kafka.svc.cluster.local", "example.com.", "a1", strings.Repeat("a", 63) + ".io"} {
+		if err := Hostname("host", s); err != nil {
+			t.Fatalf("%q: %v", s, err)
+		}
+	}
+	for _, s := range []string{"", "-kafka", "kafka-", "a..b", "under_score", "sp ace", strings.Repeat("a", 64) + ".io", strings.Repeat("a.", 127) + "aa"} {
+		if err := Hostname("host", s); err == nil {
+			t.Fatalf("%q accepted", s)
+		}
+	}
+}
+
+func TestHostPort(t *testing.T) {
+	for _, s := range []string{"localhost:9092", "10.0.0.1:443", "[::1]:8080", ":8080", "kafka.svc:65535"} {
+		if err := HostPort("endpoint", s); err != nil {
+			t.Fatalf("%q: %v", s, err)
+		}
+	}
+	for _, s := range []string{"localhost", "localhost:0", "localhost:65536", "localhost:http", "bad_host:80", "::1:80", "host:port:1"} {
+		if err := HostPort("endpoint", s); err == nil {
+			t.Fatalf("%q accepted", s)
+		}
+	}
+}
+
+func TestPort(t *testing.T) {
+	if Port("port", 1) != nil || Port("port", 65535) != nil {
+		t.Fatal("valid port rejected")
+	}
+	if Port("port", 0) == nil || Port("port", 65536) == nil {
+		t.Fatal("invalid port accepted")
+	}
+}
+
+func TestURL(t *testing.T) {
+	if err := URL("url", "https://example.com/hook?x=1"); err != nil {
+		t.Fatalf("valid URL: %v", err)
+	}
+	if err := URL("url", "HTTPS://example.com", "https"); err != nil {
+		t.Fatalf("scheme case: %v", err)
+	}
+	for _, s := range []string{"", "example.com", "/relative/path", "https://", "://x", "http://%zz"} {
+		if err := URL("url", s); err == nil {
+			t.Fatalf("%q accepted", s)
+		}
+	}
+	if err := URL("url", "ftp://example.com", "http", "https"); err == nil || !strings.Contains(err.Error(), "http or https") {
+		t.Fatalf("scheme not restricted: %v", err)
+	}
+}
+
+func TestCIDR(t *testing.T) {
+	for _, s := range []string{"10.0.0.0/8", "192.168.1.0/24", "0.0.0.0/0", "fd00::/8", "10.1.2.3/32"} {
+		if err := CIDR("allow", s); err != nil {
+			t.Fatalf("%q: %v", s, err)
+		}
+	}
+	for _, s := range []string{"", "10.0.0.0", "10.0.0.0/33", "not/8"} {
+		if err := CIDR("allow", s); err == nil {
+			t.Fatalf("%q accepted", s)
+		}
+	}
+	err := CIDR("allow", "10.0.0.1/8")
+	if err == nil || !strings.Contains(err.Error(), "did you mean 10.0.0.0/8") {
+		t.Fatalf("host bits: %v", err)
+	}
+}
@@ -0,0 +1,124 @@
+// Package validate provides reusable validators for configuration values
+// and session parameters.
+// Engine-side utilities only — must not be imported by SDK or plugins.
+//
+// Each validator checks one value and returns nil or an
+// *errors.ConfigError naming the field, so the engine can check plugin
+// parameters at CreateSession time the same way config.Validate checks the
+// engine's own configuration (which accepts the same checks as tag rules,
+// see CheckRule):
+//
+//	err := validate.Join(
+//		validate.HostPort("params.brokers[0]", p.Brokers[0]),
+//		validate.Identifier("params.topic", p.Topic),
+//		validate.DurationRange("params.flush_interval", p.FlushInterval, 10*time.Millisecond, time.Minute),
+//	)
+//
+// Use Violations to read the failed fields back from the result.
+package validate
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/planx-lab/planx-common/errors"
+)
+
+// Violation is a single failed check. Field is the dotted path of the
+// value, e.g. "sinks[0].endpoint"; it may be empty for checks that are not
+// about one field.
+type Violation struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (v Violation) String() string {
+	if v.Field == "" {
+		return v.Message
+	}
+	return v.Field + ": " + v.Message
+}
+
+// Error returns vs as one *errors.ConfigError whose "violations" field
+// holds them, or nil if vs is empty.
+func Error(vs ...Violation) error {
+	if len(vs) == 0 {
+		return nil
+	}
+	msgs := make([]string, len(vs))
+	for i, v := range vs {
+		msgs[i] = v.String()
+	}
+	return errors.NewConfigErrorf("invalid configuration: %s", strings.Join(msgs, "; ")).
+		WithField("violations", vs)
+}
+
+// Join merges the results of several validators into one error, or nil if
+// all passed. Errors other than violations, such as a Validate method's,
+// are kept as violations without a field.
+func Join(errs ...error) error {
+	var vs []Violation
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		if got := Violations(err); len(got) > 0 {
+			vs = append(vs, got...)
+		} else {
+			vs = append(vs, Violation{Message: err.Error()})
+		}
+	}
+	return Error(vs...)
+}
+
+// Violations returns the violations carried by an error from this package
+// or from config.Validate.
+func Violations(err error) []Violation {
+	vs, _ := errors.Fields(err)["violations"].([]Violation)
+	return vs
+}
+
+// fail returns the error of a failed check of field.
+func fail(field, format string, args ...interface{}) error {
+	return Error(Violation{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// DurationRange checks that min <= d <= max. A max of zero or less means
+// no upper bound.
+func DurationRange(field string, d, min, max time.Duration) error {
+	if d < min {
+		return fail(field, "must be at least %s, got %s", min, d)
+	}
+	if max > 0 && d > max {
+		return fail(field, "must be at most %s, got %s", max, d)
+	}
+	return nil
+}
+
+// stringRules are the validators CheckRule exposes as tag rules.
+var stringRules = map[string]func(field, value, arg string) error{
+	"hostname":   func(field, value, _ string) error { return Hostname(field, value) },
+	"hostport":   func(field, value, _ string) error { return HostPort(field, value) },
+	"url":        func(field, value, arg string) error { return URL(field, value, strings.Fields(arg)...) },
+	"cidr":       func(field, value, _ string) error { return CIDR(field, value) },
+	"cron":       func(field, value, _ string) error { return Cron(field, value) },
+	"identifier": func(field, value, _ string) error { return Identifier(field, value) },
+	"slug":       func(field, value, _ string) error { return Slug(field, value) },
+}
+
+// CheckRule applies the string validator named by a validate tag rule to
+// value: hostname, hostport, url (optionally "url=https http" to restrict
+// the schemes), cidr, cron, identifier or slug. ok reports whether name is
+// one of them; msg is the violation, or "" if value passes. config.Validate
+// uses it for these rules.
+func CheckRule(name, arg, value string) (msg string, ok bool) {
+	rule, ok := stringRules[name]
+	if !ok {
+		return "", false
+	}
+	if vs := Violations(rule("", value, arg)); len(vs) > 0 {
+		return vs[0].Message, true
+	}
+	return "", true
+}
@@ -0,0 +1,68 @@
+package validate
+
+import (
+	stderrors "errors"
+	"testing"
+	"time"
+
+	"github.com/planx-lab/planx-common/errors"
+)
+
+func TestJoin(t *testing.T) {
+	if err := Join(nil, Slug("name", "orders")); err != nil {
+		t.Fatalf("valid values: %v", err)
+	}
+
+	err := Join(
+		Slug("name", "Orders"),
+		nil,
+		HostPort("endpoint", "kafka"),
+		stderrors.New("brokers and bootstrap_url are exclusive"),
+	)
+	var cfgErr *errors.ConfigError
+	if !stderrors.As(err, &cfgErr) {
+		t.Fatalf("not a config error: %v", err)
+	}
+	vs := Violations(err)
+	if len(vs) != 3 || vs[0].Field != "name" || vs[1].Field != "endpoint" || vs[2].Field != "" {
+		t.Fatalf("violations: %v", vs)
+	}
+	if vs[2].String() != "brokers and bootstrap_url are exclusive" {
+		t.Fatalf("plain error: %q", vs[2])
+	}
+}
+
+func TestDurationRange(t *testing.T) {
+	tests := []struct {
+		d, min, max time.Duration
+		ok          bool
+	}{
+		{time.Second, time.Millisecond, time.Minute, true},
+		{time.Millisecond, time.Millisecond, time.Minute, true},
+		{time.Minute, time.Millisecond, time.Minute, true},
+		{time.Microsecond, time.Millisecond, time.Minute, false},
+		{time.Hour, time.Millisecond, time.Minute, false},
+		{time.Hour, time.Millisecond, 0, true},
+	}
+	for _, tt := range tests {
+		if err := DurationRange("timeout", tt.d, tt.min, tt.max); (err == nil) != tt.ok {
+			t.Fatalf("%s in [%s, %s]: got %v", tt.d, tt.min, tt.max, err)
+		}
+	}
+	vs := Violations(DurationRange("timeout", time.Hour, 0, time.Minute))
+	if len(vs) != 1 || vs[0].String() != "timeout: must be at most 1m0s, got 1h0m0s" {
+		t.Fatalf("violation: %v", vs)
+	}
+}
+
+func TestCheckRule(t *testing.T) {
+	if msg, ok := CheckRule("slug", "", "orders-eu"); !ok || msg != "" {
+		t.Fatalf("valid slug: %q, %v", msg, ok)
+	}
+	if msg, ok := CheckRule("url", "https", "http://example.com"); !ok || msg == "" {
+		t.Fatalf("scheme not restricted: %q, %v", msg, ok)
+	}
+	if _, ok := CheckRule("min", "1", "x"); ok {
+		t.Fatal("unknown rule claimed")
+	}
+}